@@ -5,7 +5,6 @@ import (
 	"testing"
 
 	"github.com/PuerkitoBio/goquery"
-	"github.com/leodip/goiabada/internal/enums"
 	"github.com/leodip/goiabada/internal/lib"
 	"github.com/stretchr/testify/assert"
 )
@@ -182,121 +181,121 @@ func TestAccountChangePassword_Post_ValidatePassword(t *testing.T) {
 
 	testCases := []struct {
 		testCase    string
-		policy      enums.PasswordPolicy
+		policy      passwordPolicy
 		password    string
 		expectedErr string
 	}{
 		{
 			testCase:    "1",
-			policy:      enums.PasswordPolicyNone,
+			policy:      passwordPolicyNone,
 			password:    "",
 			expectedErr: "New password is required",
 		},
 		{
 			testCase:    "2",
-			policy:      enums.PasswordPolicyNone,
+			policy:      passwordPolicyNone,
 			password:    "a",
 			expectedErr: "",
 		},
 		{
 			testCase:    "3",
-			policy:      enums.PasswordPolicyLow,
+			policy:      passwordPolicyLow,
 			password:    "asd12",
 			expectedErr: "The minimum length for the password is 6 characters",
 		},
 		{
 			testCase:    "4",
-			policy:      enums.PasswordPolicyLow,
+			policy:      passwordPolicyLow,
 			password:    "asd123",
 			expectedErr: "",
 		},
 		{
 			testCase:    "5",
-			policy:      enums.PasswordPolicyMedium,
+			policy:      passwordPolicyMedium,
 			password:    "asd1234",
 			expectedErr: "The minimum length for the password is 8 characters",
 		},
 		{
 			testCase:    "6",
-			policy:      enums.PasswordPolicyMedium,
+			policy:      passwordPolicyMedium,
 			password:    "abcdefgh",
 			expectedErr: "As per our policy, an uppercase character is required in the password",
 		},
 		{
 			testCase:    "7",
-			policy:      enums.PasswordPolicyMedium,
+			policy:      passwordPolicyMedium,
 			password:    "ABCDEFGH",
 			expectedErr: "As per our policy, a lowercase character is required in the password",
 		},
 		{
 			testCase:    "8",
-			policy:      enums.PasswordPolicyMedium,
+			policy:      passwordPolicyMedium,
 			password:    "abcdEFGH",
 			expectedErr: "As per our policy, your password must contain a numerical digit",
 		},
 		{
 			testCase:    "9",
-			policy:      enums.PasswordPolicyMedium,
+			policy:      passwordPolicyMedium,
 			password:    "abcdEFG9",
 			expectedErr: "",
 		},
 		{
 			testCase:    "10",
-			policy:      enums.PasswordPolicyHigh,
+			policy:      passwordPolicyHigh,
 			password:    "asd123456",
 			expectedErr: "The minimum length for the password is 10 characters",
 		},
 		{
 			testCase:    "11",
-			policy:      enums.PasswordPolicyHigh,
+			policy:      passwordPolicyHigh,
 			password:    "abcdefghij",
 			expectedErr: "As per our policy, an uppercase character is required in the password",
 		},
 		{
 			testCase:    "12",
-			policy:      enums.PasswordPolicyHigh,
+			policy:      passwordPolicyHigh,
 			password:    "ABCDEFGHIJ",
 			expectedErr: "As per our policy, a lowercase character is required in the password",
 		},
 		{
 			testCase:    "13",
-			policy:      enums.PasswordPolicyHigh,
+			policy:      passwordPolicyHigh,
 			password:    "abcdEFGHij",
 			expectedErr: "As per our policy, your password must contain a numerical digit",
 		},
 		{
 			testCase:    "14",
-			policy:      enums.PasswordPolicyHigh,
+			policy:      passwordPolicyHigh,
 			password:    "abcdEFGHi9",
 			expectedErr: "As per our policy, a special character/symbol is required in the password",
 		},
 		{
 			testCase:    "15",
-			policy:      enums.PasswordPolicyHigh,
+			policy:      passwordPolicyHigh,
 			password:    "abcdEFGHi9$",
 			expectedErr: "",
 		},
 		{
 			testCase:    "16",
-			policy:      enums.PasswordPolicyNone,
+			policy:      passwordPolicyNone,
 			password:    "1234567890123456789012345678901234567890123456789012345678901254a",
 			expectedErr: "The maximum length for the password is 64 characters",
 		},
 		{
 			testCase:    "17",
-			policy:      enums.PasswordPolicyLow,
+			policy:      passwordPolicyLow,
 			password:    "1234567890123456789012345678901234567890123456789012345678901254a",
 			expectedErr: "The maximum length for the password is 64 characters",
 		},
 		{
 			testCase:    "18",
-			policy:      enums.PasswordPolicyMedium,
+			policy:      passwordPolicyMedium,
 			password:    "1234567890123456789012345678901234567890123456789012345678901254a",
 			expectedErr: "The maximum length for the password is 64 characters",
 		},
 		{
 			testCase:    "19",
-			policy:      enums.PasswordPolicyHigh,
+			policy:      passwordPolicyHigh,
 			password:    "1234567890123456789012345678901234567890123456789012345678901254a",
 			expectedErr: "The maximum length for the password is 64 characters",
 		},
@@ -319,7 +318,7 @@ func TestAccountChangePassword_Post_ValidatePassword(t *testing.T) {
 		if err != nil {
 			t.Fatal(err)
 		}
-		settings.PasswordPolicy = tc.policy
+		tc.policy.applyTo(settings)
 		err = database.UpdateSettings(nil, settings)
 		if err != nil {
 			t.Fatal(err)
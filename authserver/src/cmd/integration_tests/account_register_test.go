@@ -7,7 +7,6 @@ import (
 	"github.com/PuerkitoBio/goquery"
 	"github.com/brianvoe/gofakeit/v6"
 	"github.com/leodip/goiabada/internal/entities"
-	"github.com/leodip/goiabada/internal/enums"
 	"github.com/leodip/goiabada/internal/lib"
 	"github.com/stretchr/testify/assert"
 )
@@ -479,7 +478,7 @@ func TestAccountRegister_Post_InvalidPassword(t *testing.T) {
 	}
 
 	settings.SelfRegistrationEnabled = true
-	settings.PasswordPolicy = enums.PasswordPolicyMedium
+	passwordPolicyMedium.applyTo(settings)
 	err = database.UpdateSettings(nil, settings)
 	if err != nil {
 		t.Fatal(err)
@@ -533,7 +532,7 @@ func TestAccountRegister_Post_SelfRegistrationNotEnabled(t *testing.T) {
 	}
 
 	settings.SelfRegistrationEnabled = true
-	settings.PasswordPolicy = enums.PasswordPolicyLow
+	passwordPolicyLow.applyTo(settings)
 	err = database.UpdateSettings(nil, settings)
 	if err != nil {
 		t.Fatal(err)
@@ -588,7 +587,7 @@ func TestAccountRegister_Post_RequiresEmailVerification(t *testing.T) {
 
 	settings.SelfRegistrationEnabled = true
 	settings.SelfRegistrationRequiresEmailVerification = true
-	settings.PasswordPolicy = enums.PasswordPolicyLow
+	passwordPolicyLow.applyTo(settings)
 	err = database.UpdateSettings(nil, settings)
 	if err != nil {
 		t.Fatal(err)
@@ -654,7 +653,7 @@ func TestAccountRegister_Post_NoEmailVerification(t *testing.T) {
 
 	settings.SelfRegistrationEnabled = true
 	settings.SelfRegistrationRequiresEmailVerification = false
-	settings.PasswordPolicy = enums.PasswordPolicyLow
+	passwordPolicyLow.applyTo(settings)
 	err = database.UpdateSettings(nil, settings)
 	if err != nil {
 		t.Fatal(err)
@@ -0,0 +1,657 @@
+package integrationtests
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/brianvoe/gofakeit/v6"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/leodip/goiabada/internal/constants"
+	"github.com/leodip/goiabada/internal/entities"
+	"github.com/leodip/goiabada/internal/enums"
+	"github.com/leodip/goiabada/internal/lib"
+	"github.com/stretchr/testify/assert"
+)
+
+// getAdminApiAccessToken creates a client with the permissions passed as parameter and returns an
+// access token obtained with the client credentials flow.
+func getAdminApiAccessToken(t *testing.T, permissionIdentifiers ...string) string {
+	settings, err := database.GetSettingsById(nil, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	clientSecret := lib.GenerateSecureRandomString(60)
+	encClientSecret, err := lib.EncryptText(clientSecret, settings.AESEncryptionKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := &entities.Client{
+		ClientIdentifier:                        "api-" + strings.ToLower(gofakeit.LetterN(12)),
+		Enabled:                                 true,
+		ClientSecretEncrypted:                   encClientSecret,
+		DefaultAcrLevel:                         enums.AcrLevel1,
+		IncludeOpenIDConnectClaimsInAccessToken: enums.ThreeStateSettingDefault.String(),
+		ClientCredentialsEnabled:                true,
+	}
+	err = database.CreateClient(nil, client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = database.DeleteClient(nil, client.Id)
+	})
+
+	scopes := []string{}
+	for _, permissionIdentifier := range permissionIdentifiers {
+		permission := getAuthServerPermission(t, permissionIdentifier)
+		err = database.CreateClientPermission(nil, &entities.ClientPermission{
+			ClientId:     client.Id,
+			PermissionId: permission.Id,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		scopes = append(scopes, constants.AuthServerResourceIdentifier+":"+permissionIdentifier)
+	}
+
+	httpClient := createHttpClient(&createHttpClientInput{
+		T: t,
+	})
+	data := postToTokenEndpoint(t, httpClient, lib.GetBaseUrl()+"/auth/token", url.Values{
+		"grant_type":    {"client_credentials"},
+		"client_id":     {client.ClientIdentifier},
+		"client_secret": {clientSecret},
+		"scope":         {strings.Join(scopes, " ")},
+	})
+	accessToken, ok := data["access_token"].(string)
+	if !ok {
+		t.Fatalf("unable to get an access token: %v", data)
+	}
+	return accessToken
+}
+
+// signTestToken signs the claims with the current signing key of the server, to build tokens that
+// can't be obtained from the token endpoint in a test.
+func signTestToken(t *testing.T, claims jwt.MapClaims) string {
+	keyPair, err := database.GetCurrentSigningKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	privKey, err := jwt.ParseRSAPrivateKeyFromPEM(keyPair.PrivateKeyPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = keyPair.KeyIdentifier
+	signed, err := token.SignedString(privKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed
+}
+
+// apiRequest sends a request to the admin API and returns the response, with the body decoded.
+func apiRequest(t *testing.T, accessToken string, method string, path string, body any) (*http.Response, map[string]any) {
+
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequest(method, lib.GetBaseUrl()+"/api/v1"+path, reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(accessToken) > 0 {
+		req.Header.Set("Authorization", "Bearer "+accessToken)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	httpClient := createHttpClient(&createHttpClientInput{
+		T: t,
+	})
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var result map[string]any
+	if len(respBody) > 0 {
+		err = json.Unmarshal(respBody, &result)
+		if err != nil {
+			t.Fatalf("unable to decode %v: %v", string(respBody), err)
+		}
+	}
+	return resp, result
+}
+
+func TestAdminApi_Authorization(t *testing.T) {
+	setup()
+	resp, data := apiRequest(t, "", "GET", "/users", nil)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	assert.Equal(t, "invalid_token", data["error"])
+	assert.Contains(t, resp.Header.Get("WWW-Authenticate"), "invalid_token")
+
+	accessToken := getAdminApiAccessToken(t, constants.AdminApiUsersReadPermissionIdentifier)
+
+	resp, data = apiRequest(t, accessToken, "GET", "/users?pageSize=5", nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.LessOrEqual(t, len(data["items"].([]any)), 5)
+	assert.Equal(t, float64(5), data["pageSize"])
+
+	// read access doesn't allow changes, nor access to other areas
+	resp, data = apiRequest(t, accessToken, "POST", "/users", map[string]any{"email": gofakeit.Email()})
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	assert.Equal(t, "insufficient_scope", data["error"])
+	assert.Contains(t, data["error_description"], constants.AdminApiUsersWritePermissionIdentifier)
+
+	resp, _ = apiRequest(t, accessToken, "GET", "/groups", nil)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	// refresh tokens carry the same scopes, but are not access tokens
+	refreshToken := signTestToken(t, jwt.MapClaims{
+		"iss":   lib.GetBaseUrl(),
+		"sub":   gofakeit.UUID(),
+		"typ":   enums.TokenTypeRefresh.String(),
+		"exp":   time.Now().Add(5 * time.Minute).Unix(),
+		"scope": constants.AuthServerResourceIdentifier + ":" + constants.AdminApiUsersReadPermissionIdentifier,
+	})
+	resp, data = apiRequest(t, refreshToken, "GET", "/users", nil)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	assert.Equal(t, "invalid_token", data["error"])
+
+	// the OpenAPI document is public
+	resp, data = apiRequest(t, "", "GET", "/openapi.json", nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "3.0.3", data["openapi"])
+	assert.Contains(t, data["paths"], "/users/{userId}/permissions")
+}
+
+func TestAdminApi_Users(t *testing.T) {
+	setup()
+	accessToken := getAdminApiAccessToken(t, constants.AdminApiUsersReadPermissionIdentifier,
+		constants.AdminApiUsersWritePermissionIdentifier)
+
+	email := strings.ToLower(gofakeit.Email())
+	resp, user := apiRequest(t, accessToken, "POST", "/users", map[string]any{
+		"email":              email,
+		"emailVerified":      true,
+		"password":           "Api-" + gofakeit.Password(true, true, true, false, false, 12) + "1",
+		"givenName":          "Maria",
+		"familyName":         "Silva",
+		"gender":             "female",
+		"birthDate":          "1990-05-17",
+		"phoneNumberCountry": "+351",
+		"phoneNumber":        "912 345 678",
+		"address":            map[string]any{"locality": "Lisboa", "country": "PRT"},
+	})
+	if !assert.Equal(t, http.StatusCreated, resp.StatusCode, user) {
+		return
+	}
+	userId := int64(user["id"].(float64))
+	t.Cleanup(func() {
+		_ = database.DeleteUser(nil, userId)
+	})
+	assert.Equal(t, email, user["email"])
+	assert.Equal(t, true, user["enabled"])
+	assert.Equal(t, "female", user["gender"])
+	assert.Equal(t, "1990-05-17", user["birthDate"])
+	assert.Equal(t, "+351", user["phoneNumberCountry"])
+	assert.Equal(t, "912 345 678", user["phoneNumber"])
+	assert.Equal(t, "Lisboa", user["address"].(map[string]any)["locality"])
+
+	userEntity, err := database.GetUserById(nil, userId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, len(userEntity.PasswordHash) > 0)
+
+	// the same email can't be used twice
+	resp, data := apiRequest(t, accessToken, "POST", "/users", map[string]any{"email": email})
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+	assert.Equal(t, "conflict", data["error"])
+
+	// validation errors
+	resp, data = apiRequest(t, accessToken, "PUT", fmt.Sprintf("/users/%v", userId), map[string]any{
+		"email":  email,
+		"gender": "unknown",
+	})
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	assert.Equal(t, "invalid_request", data["error"])
+	assert.Equal(t, "Gender is invalid.", data["error_description"])
+
+	// PUT replaces the user
+	resp, user = apiRequest(t, accessToken, "PUT", fmt.Sprintf("/users/%v", userId), map[string]any{
+		"email":     email,
+		"enabled":   false,
+		"givenName": "Mariana",
+	})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, false, user["enabled"])
+	assert.Equal(t, "Mariana", user["givenName"])
+	assert.Equal(t, "", user["familyName"])
+	assert.Equal(t, "", user["phoneNumber"])
+
+	// attributes
+	resp, attribute := apiRequest(t, accessToken, "POST", fmt.Sprintf("/users/%v/attributes", userId), map[string]any{
+		"key":                  "department",
+		"value":                "Sales",
+		"includeInAccessToken": true,
+	})
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	attributeId := int64(attribute["id"].(float64))
+
+	resp, data = apiRequest(t, accessToken, "GET", fmt.Sprintf("/users/%v/attributes", userId), nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, float64(1), data["total"])
+
+	resp, _ = apiRequest(t, accessToken, "DELETE", fmt.Sprintf("/users/%v/attributes/%v", userId, attributeId), nil)
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	// groups and permissions are replaced
+	group := createImportTestGroup(t)
+	resp, data = apiRequest(t, accessToken, "PUT", fmt.Sprintf("/users/%v/groups", userId), map[string]any{
+		"ids": []int64{group.Id},
+	})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, float64(1), data["total"])
+
+	// the admins group grants authserver:admin-website, which the token doesn't hold
+	admins := createImportTestGroup(t)
+	err = database.CreateGroupPermission(nil, &entities.GroupPermission{
+		GroupId:      admins.Id,
+		PermissionId: getAuthServerPermission(t, constants.AdminWebsitePermissionIdentifier).Id,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, data = apiRequest(t, accessToken, "PUT", fmt.Sprintf("/users/%v/groups", userId), map[string]any{
+		"ids": []int64{group.Id, admins.Id},
+	})
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	assert.Contains(t, data["error_description"], "authserver:"+constants.AdminWebsitePermissionIdentifier)
+
+	resp, data = apiRequest(t, accessToken, "PUT", fmt.Sprintf("/users/%v/groups", userId), map[string]any{
+		"ids": []int64{},
+	})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, float64(0), data["total"])
+
+	permission := getAuthServerPermission(t, constants.ManageAccountPermissionIdentifier)
+	resp, data = apiRequest(t, accessToken, "PUT", fmt.Sprintf("/users/%v/permissions", userId), map[string]any{
+		"ids": []int64{permission.Id},
+	})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	permissions := data["items"].([]any)
+	if assert.Equal(t, 1, len(permissions)) {
+		assert.Equal(t, "authserver:manage-account", permissions[0].(map[string]any)["scope"])
+	}
+
+	resp, data = apiRequest(t, accessToken, "PUT", fmt.Sprintf("/users/%v/permissions", userId), map[string]any{
+		"ids": []int64{999999},
+	})
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	assert.Equal(t, "Permission 999999 not found.", data["error_description"])
+
+	// a token can't grant authserver permissions it doesn't hold
+	for _, permissionIdentifier := range []string{constants.AdminWebsitePermissionIdentifier,
+		constants.AdminApiClientsWritePermissionIdentifier} {
+		escalation := getAuthServerPermission(t, permissionIdentifier)
+		resp, data = apiRequest(t, accessToken, "PUT", fmt.Sprintf("/users/%v/permissions", userId), map[string]any{
+			"ids": []int64{permission.Id, escalation.Id},
+		})
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+		assert.Contains(t, data["error_description"], "authserver:"+permissionIdentifier)
+	}
+
+	// sessions and consents
+	resp, data = apiRequest(t, accessToken, "GET", fmt.Sprintf("/users/%v/sessions", userId), nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, float64(0), data["total"])
+
+	resp, _ = apiRequest(t, accessToken, "DELETE", fmt.Sprintf("/users/%v/consents/999999", userId), nil)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	resp, _ = apiRequest(t, accessToken, "DELETE", fmt.Sprintf("/users/%v", userId), nil)
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	resp, data = apiRequest(t, accessToken, "GET", fmt.Sprintf("/users/%v", userId), nil)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	assert.Equal(t, "not_found", data["error"])
+}
+
+func TestAdminApi_GroupsAndMembers(t *testing.T) {
+	setup()
+	accessToken := getAdminApiAccessToken(t, constants.AdminApiGroupsReadPermissionIdentifier,
+		constants.AdminApiGroupsWritePermissionIdentifier)
+
+	groupIdentifier := "api-" + strings.ToLower(gofakeit.LetterN(10))
+	resp, group := apiRequest(t, accessToken, "POST", "/groups", map[string]any{
+		"groupIdentifier":  groupIdentifier,
+		"description":      "Created via the admin API",
+		"includeInIdToken": true,
+	})
+	if !assert.Equal(t, http.StatusCreated, resp.StatusCode, group) {
+		return
+	}
+	groupId := int64(group["id"].(float64))
+	t.Cleanup(func() {
+		_ = database.DeleteGroup(nil, groupId)
+	})
+	assert.Equal(t, groupIdentifier, group["groupIdentifier"])
+
+	resp, data := apiRequest(t, accessToken, "POST", "/groups", map[string]any{"groupIdentifier": groupIdentifier})
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+	assert.Equal(t, "The group identifier is already in use.", data["error_description"])
+
+	resp, data = apiRequest(t, accessToken, "POST", "/groups", map[string]any{"groupIdentifier": "a"})
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	user := createPasskeyTestUser(t, gofakeit.Password(true, true, true, true, false, 12))
+
+	resp, _ = apiRequest(t, accessToken, "POST", fmt.Sprintf("/groups/%v/members", groupId), map[string]any{"userId": user.Id})
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	resp, _ = apiRequest(t, accessToken, "POST", fmt.Sprintf("/groups/%v/members", groupId), map[string]any{"userId": user.Id})
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+
+	resp, data = apiRequest(t, accessToken, "GET", fmt.Sprintf("/groups/%v/members", groupId), nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, float64(1), data["total"])
+	members := data["items"].([]any)
+	if assert.Equal(t, 1, len(members)) {
+		assert.Equal(t, user.Email, members[0].(map[string]any)["email"])
+	}
+
+	resp, _ = apiRequest(t, accessToken, "DELETE", fmt.Sprintf("/groups/%v/members/%v", groupId, user.Id), nil)
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	resp, data = apiRequest(t, accessToken, "GET", fmt.Sprintf("/groups/%v/members", groupId), nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, float64(0), data["total"])
+
+	resp, group = apiRequest(t, accessToken, "PUT", fmt.Sprintf("/groups/%v", groupId), map[string]any{
+		"groupIdentifier":      groupIdentifier,
+		"includeInAccessToken": true,
+	})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, false, group["includeInIdToken"])
+	assert.Equal(t, true, group["includeInAccessToken"])
+	assert.Equal(t, "", group["description"])
+
+	resp, _ = apiRequest(t, accessToken, "DELETE", fmt.Sprintf("/groups/%v", groupId), nil)
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	resp, _ = apiRequest(t, accessToken, "GET", fmt.Sprintf("/groups/%v", groupId), nil)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestAdminApi_Users_AdminsCantBeTakenOver(t *testing.T) {
+	setup()
+	accessToken := getAdminApiAccessToken(t, constants.AdminApiUsersReadPermissionIdentifier,
+		constants.AdminApiUsersWritePermissionIdentifier)
+	adminWebsite := getAuthServerPermission(t, constants.AdminWebsitePermissionIdentifier)
+
+	// an admin through a permission, and an admin through a group
+	directAdmin := createCliTestUser(t)
+	err := database.CreateUserPermission(nil, &entities.UserPermission{
+		UserId:       directAdmin.Id,
+		PermissionId: adminWebsite.Id,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	admins := createImportTestGroup(t)
+	err = database.CreateGroupPermission(nil, &entities.GroupPermission{
+		GroupId:      admins.Id,
+		PermissionId: adminWebsite.Id,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	groupAdmin := createCliTestUser(t)
+	err = database.CreateUserGroup(nil, &entities.UserGroup{
+		UserId:  groupAdmin.Id,
+		GroupId: admins.Id,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, admin := range []*entities.User{directAdmin, groupAdmin} {
+		resp, data := apiRequest(t, accessToken, "PUT", fmt.Sprintf("/users/%v", admin.Id), map[string]any{
+			"email":    "taken-over-" + admin.Email,
+			"password": "Api-" + gofakeit.Password(true, true, true, false, false, 12) + "1",
+		})
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+		assert.Contains(t, data["error_description"], "authserver:"+constants.AdminWebsitePermissionIdentifier)
+
+		resp, _ = apiRequest(t, accessToken, "DELETE", fmt.Sprintf("/users/%v", admin.Id), nil)
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+		user, err := database.GetUserById(nil, admin.Id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if assert.NotNil(t, user) {
+			assert.Equal(t, admin.Email, user.Email)
+			assert.Equal(t, admin.PasswordHash, user.PasswordHash)
+		}
+	}
+}
+
+func TestAdminApi_ClientsAndResources(t *testing.T) {
+	setup()
+	accessToken := getAdminApiAccessToken(t,
+		constants.AdminApiClientsReadPermissionIdentifier, constants.AdminApiClientsWritePermissionIdentifier,
+		constants.AdminApiResourcesReadPermissionIdentifier, constants.AdminApiResourcesWritePermissionIdentifier)
+
+	resourceIdentifier := "api-" + strings.ToLower(gofakeit.LetterN(10))
+	resp, resource := apiRequest(t, accessToken, "POST", "/resources", map[string]any{
+		"resourceIdentifier": resourceIdentifier,
+		"description":        "Created via the admin API",
+	})
+	if !assert.Equal(t, http.StatusCreated, resp.StatusCode, resource) {
+		return
+	}
+	resourceId := int64(resource["id"].(float64))
+	t.Cleanup(func() {
+		_ = database.DeleteResource(nil, resourceId)
+	})
+
+	resp, permission := apiRequest(t, accessToken, "POST", fmt.Sprintf("/resources/%v/permissions", resourceId), map[string]any{
+		"permissionIdentifier": "read",
+		"description":          "Read access",
+	})
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.Equal(t, resourceIdentifier+":read", permission["scope"])
+	permissionId := int64(permission["id"].(float64))
+
+	resp, _ = apiRequest(t, accessToken, "POST", fmt.Sprintf("/resources/%v/permissions", resourceId), map[string]any{
+		"permissionIdentifier": "read",
+	})
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+
+	// the system-level resource can't be modified
+	authServerResource, err := database.GetResourceByResourceIdentifier(nil, constants.AuthServerResourceIdentifier)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, data := apiRequest(t, accessToken, "POST", fmt.Sprintf("/resources/%v/permissions", authServerResource.Id), map[string]any{
+		"permissionIdentifier": "other",
+	})
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	assert.Equal(t, "forbidden", data["error"])
+
+	clientIdentifier := "api-" + strings.ToLower(gofakeit.LetterN(10))
+	resp, client := apiRequest(t, accessToken, "POST", "/clients", map[string]any{
+		"clientIdentifier":         clientIdentifier,
+		"enabled":                  true,
+		"authorizationCodeEnabled": true,
+		"clientCredentialsEnabled": true,
+		"redirectURIs":             []string{"https://example.com/callback", "https://example.com/callback"},
+		"webOrigins":               []string{"https://EXAMPLE.com"},
+	})
+	if !assert.Equal(t, http.StatusCreated, resp.StatusCode, client) {
+		return
+	}
+	clientId := int64(client["id"].(float64))
+	t.Cleanup(func() {
+		_ = database.DeleteClient(nil, clientId)
+	})
+	assert.Equal(t, []any{"https://example.com/callback"}, client["redirectURIs"])
+	assert.Equal(t, []any{"https://example.com"}, client["webOrigins"])
+	assert.Equal(t, "urn:goiabada:pwd:otp_ifpossible", client["defaultAcrLevel"])
+	assert.Equal(t, "default", client["includeOpenIDConnectClaimsInAccessToken"])
+	assert.Len(t, client["clientSecret"], 60)
+
+	resp, data = apiRequest(t, accessToken, "PUT", fmt.Sprintf("/clients/%v/permissions", clientId), map[string]any{
+		"ids": []int64{permissionId},
+	})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, float64(1), data["total"])
+
+	// a token can't grant authserver permissions it doesn't hold, nor get the secret of a client
+	// that holds them
+	usersWrite := getAuthServerPermission(t, constants.AdminApiUsersWritePermissionIdentifier)
+	resp, data = apiRequest(t, accessToken, "PUT", fmt.Sprintf("/clients/%v/permissions", clientId), map[string]any{
+		"ids": []int64{permissionId, usersWrite.Id},
+	})
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	assert.Contains(t, data["error_description"], "authserver:"+constants.AdminApiUsersWritePermissionIdentifier)
+
+	privileged := &entities.Client{
+		ClientIdentifier:         "api-" + strings.ToLower(gofakeit.LetterN(12)),
+		Enabled:                  true,
+		DefaultAcrLevel:          enums.AcrLevel1,
+		ClientCredentialsEnabled: true,
+	}
+	err = database.CreateClient(nil, privileged)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = database.DeleteClient(nil, privileged.Id)
+	})
+	err = database.CreateClientPermission(nil, &entities.ClientPermission{
+		ClientId:     privileged.Id,
+		PermissionId: usersWrite.Id,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, _ = apiRequest(t, accessToken, "POST", fmt.Sprintf("/clients/%v/secret", privileged.Id), nil)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	// the secret is not returned again, until a new one is generated
+	resp, data = apiRequest(t, accessToken, "GET", fmt.Sprintf("/clients/%v", clientId), nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.NotContains(t, data, "clientSecret")
+
+	resp, data = apiRequest(t, accessToken, "POST", fmt.Sprintf("/clients/%v/secret", clientId), nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	clientSecret := data["clientSecret"].(string)
+	assert.NotEqual(t, client["clientSecret"], clientSecret)
+
+	httpClient := createHttpClient(&createHttpClientInput{
+		T: t,
+	})
+	tokenResponse := postToTokenEndpoint(t, httpClient, lib.GetBaseUrl()+"/auth/token", url.Values{
+		"grant_type":    {"client_credentials"},
+		"client_id":     {clientIdentifier},
+		"client_secret": {clientSecret},
+		"scope":         {resourceIdentifier + ":read"},
+	})
+	assert.NotEmpty(t, tokenResponse["access_token"])
+
+	// public clients can't use the client credentials flow
+	resp, data = apiRequest(t, accessToken, "PUT", fmt.Sprintf("/clients/%v", clientId), map[string]any{
+		"clientIdentifier":         clientIdentifier,
+		"isPublic":                 true,
+		"clientCredentialsEnabled": true,
+	})
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	assert.Equal(t, "Public clients can't use the client credentials flow.", data["error_description"])
+
+	resp, data = apiRequest(t, accessToken, "PUT", fmt.Sprintf("/clients/%v", clientId), map[string]any{
+		"clientIdentifier":         clientIdentifier,
+		"isPublic":                 true,
+		"authorizationCodeEnabled": true,
+		"redirectURIs":             []string{"https://example.com/other"},
+	})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, []any{"https://example.com/other"}, data["redirectURIs"])
+	assert.Equal(t, []any{}, data["webOrigins"])
+	clientEntity, err := database.GetClientById(nil, clientId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, clientEntity.IsPublic)
+	assert.Nil(t, clientEntity.ClientSecretEncrypted)
+
+	// the system-level client can't be modified
+	systemClient, err := database.GetClientByClientIdentifier(nil, constants.SystemClientIdentifier)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, _ = apiRequest(t, accessToken, "DELETE", fmt.Sprintf("/clients/%v", systemClient.Id), nil)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	resp, _ = apiRequest(t, accessToken, "DELETE", fmt.Sprintf("/clients/%v", clientId), nil)
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	resp, _ = apiRequest(t, accessToken, "DELETE", fmt.Sprintf("/resources/%v", resourceId), nil)
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+}
+
+func TestAdminApi_Settings(t *testing.T) {
+	setup()
+	accessToken := getAdminApiAccessToken(t, constants.AdminApiSettingsReadPermissionIdentifier,
+		constants.AdminApiSettingsWritePermissionIdentifier)
+
+	resp, settings := apiRequest(t, accessToken, "GET", "/settings", nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.NotEmpty(t, settings["issuer"])
+
+	invalidSettings := map[string]any{}
+	for key, value := range settings {
+		invalidSettings[key] = value
+	}
+	invalidSettings["userSessionIdleTimeoutInSeconds"] = 7200
+	invalidSettings["userSessionMaxLifetimeInSeconds"] = 3600
+	resp, data := apiRequest(t, accessToken, "PUT", "/settings", invalidSettings)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	assert.Equal(t, "User session - the idle timeout cannot be greater than the max lifetime.", data["error_description"])
+
+	updatedSettings := map[string]any{}
+	for key, value := range settings {
+		updatedSettings[key] = value
+	}
+	updatedSettings["trustedDeviceLifetimeInDays"] = 45
+	resp, data = apiRequest(t, accessToken, "PUT", "/settings", updatedSettings)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, float64(45), data["trustedDeviceLifetimeInDays"])
+
+	resp, _ = apiRequest(t, accessToken, "PUT", "/settings", settings)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
@@ -0,0 +1,229 @@
+package integrationtests
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/brianvoe/gofakeit/v6"
+	"github.com/leodip/goiabada/internal/constants"
+	"github.com/leodip/goiabada/internal/entities"
+	"github.com/leodip/goiabada/internal/lib"
+	"github.com/stretchr/testify/assert"
+)
+
+func getAuditPage(t *testing.T, httpClient *http.Client, query url.Values) *goquery.Document {
+	resp := getPage(t, httpClient, lib.GetBaseUrl()+"/admin/audit?"+query.Encode())
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	doc, err := goquery.NewDocumentFromReader(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return doc
+}
+
+func getAuditEventTypes(doc *goquery.Document) []string {
+	eventTypes := []string{}
+	doc.Find("#auditEventsTable tbody tr").Each(func(i int, s *goquery.Selection) {
+		if s.Find("td").Length() > 1 {
+			eventTypes = append(eventTypes, strings.TrimSpace(s.Find("td").Eq(1).Text()))
+		}
+	})
+	return eventTypes
+}
+
+func createAuditTestEvent(t *testing.T, eventType string, createdAt time.Time, actorSubject string,
+	clientId int64) *entities.AuditEvent {
+	auditEvent := &entities.AuditEvent{
+		CreatedAt:    sql.NullTime{Time: createdAt, Valid: true},
+		EventType:    eventType,
+		ActorSubject: actorSubject,
+		IpAddress:    "10.0.0.1",
+		RequestId:    gofakeit.UUID(),
+		ClientId:     sql.NullInt64{Int64: clientId, Valid: clientId > 0},
+		Details:      `{"test":true}`,
+	}
+	err := database.CreateAuditEvent(nil, auditEvent)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return auditEvent
+}
+
+func TestAdminAudit_Get_RecordsEvents(t *testing.T) {
+	setup()
+
+	httpClient := loginToAdminArea(t, "admin@example.com", "changeme")
+
+	query := url.Values{}
+	query.Set("user", "admin@example.com")
+	query.Set("eventType", constants.AuditAuthSuccessPwd)
+	query.Set("from", time.Now().UTC().Format("2006-01-02"))
+
+	// the events are recorded in the background
+	var eventTypes []string
+	for i := 0; i < 20; i++ {
+		eventTypes = getAuditEventTypes(getAuditPage(t, httpClient, query))
+		if len(eventTypes) > 0 {
+			break
+		}
+		time.Sleep(250 * time.Millisecond)
+	}
+
+	assert.NotEmpty(t, eventTypes)
+	for _, eventType := range eventTypes {
+		assert.Equal(t, constants.AuditAuthSuccessPwd, eventType)
+	}
+
+	user, err := database.GetUserByEmail(nil, "admin@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	auditEvents, _, err := database.SearchAuditEventsPaginated(nil, &entities.AuditEventFilter{
+		EventType: constants.AuditAuthSuccessPwd,
+		UserId:    user.Id,
+	}, 1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(t, auditEvents, 1)
+	assert.NotEmpty(t, auditEvents[0].IpAddress)
+	assert.NotEmpty(t, auditEvents[0].RequestId)
+	assert.Contains(t, auditEvents[0].Details, `"userId"`)
+}
+
+func TestAdminAudit_Get_Filters(t *testing.T) {
+	setup()
+
+	eventType := "test_event_" + strings.ToLower(gofakeit.LetterN(8))
+	actorSubject := gofakeit.UUID()
+
+	client, err := database.GetClientByClientIdentifier(nil, "test-client-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now().UTC()
+	createAuditTestEvent(t, eventType, now.AddDate(0, 0, -10), actorSubject, 0)
+	createAuditTestEvent(t, eventType, now, actorSubject, 0)
+	createAuditTestEvent(t, eventType, now, "", client.Id)
+
+	httpClient := loginToAdminArea(t, "admin@example.com", "changeme")
+
+	query := url.Values{}
+	query.Set("eventType", eventType)
+	doc := getAuditPage(t, httpClient, query)
+	assert.Len(t, getAuditEventTypes(doc), 3)
+
+	// the option of the new event type is available
+	assert.Equal(t, 1, doc.Find("#eventType option[value='"+eventType+"']").Length())
+
+	// the deleted users are found by subject
+	query.Set("user", actorSubject)
+	assert.Len(t, getAuditEventTypes(getAuditPage(t, httpClient, query)), 2)
+
+	query.Set("from", now.AddDate(0, 0, -1).Format("2006-01-02"))
+	assert.Len(t, getAuditEventTypes(getAuditPage(t, httpClient, query)), 1)
+
+	query.Set("from", now.AddDate(0, 0, -11).Format("2006-01-02"))
+	query.Set("to", now.AddDate(0, 0, -9).Format("2006-01-02"))
+	assert.Len(t, getAuditEventTypes(getAuditPage(t, httpClient, query)), 1)
+
+	query = url.Values{}
+	query.Set("eventType", eventType)
+	query.Set("client", client.ClientIdentifier)
+	assert.Len(t, getAuditEventTypes(getAuditPage(t, httpClient, query)), 1)
+
+	query.Set("client", "unknown-client")
+	doc = getAuditPage(t, httpClient, query)
+	assert.Len(t, getAuditEventTypes(doc), 0)
+	assert.Equal(t, "Could not find any audit event.", strings.TrimSpace(doc.Find("#auditEventsTable tbody tr td").Text()))
+}
+
+func TestAdminAudit_Get_InvalidDates(t *testing.T) {
+	setup()
+
+	httpClient := loginToAdminArea(t, "admin@example.com", "changeme")
+
+	query := url.Values{}
+	query.Set("from", "yesterday")
+	doc := getAuditPage(t, httpClient, query)
+	assert.Equal(t, "Invalid start date. Please use the format YYYY-MM-DD.", strings.TrimSpace(doc.Find("div.text-error p").Text()))
+	assert.Len(t, getAuditEventTypes(doc), 0)
+
+	query = url.Values{}
+	query.Set("from", "2024-05-02")
+	query.Set("to", "2024-05-01")
+	doc = getAuditPage(t, httpClient, query)
+	assert.Equal(t, "The start date must be before or equal to the end date.", strings.TrimSpace(doc.Find("div.text-error p").Text()))
+}
+
+func TestAdminAudit_Export(t *testing.T) {
+	setup()
+
+	eventType := "test_event_" + strings.ToLower(gofakeit.LetterN(8))
+	auditEvent1 := createAuditTestEvent(t, eventType, time.Now().UTC().Add(-time.Minute), "actor-1", 0)
+	auditEvent2 := createAuditTestEvent(t, eventType, time.Now().UTC(), "actor-2", 0)
+
+	httpClient := loginToAdminArea(t, "admin@example.com", "changeme")
+
+	query := url.Values{}
+	query.Set("eventType", eventType)
+	resp := getPage(t, httpClient, lib.GetBaseUrl()+"/admin/audit/export?"+query.Encode())
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/csv; charset=utf-8", resp.Header.Get("Content-Type"))
+	assert.True(t, strings.HasPrefix(resp.Header.Get("Content-Disposition"), `attachment; filename="audit-`))
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	records, err := csv.NewReader(strings.NewReader(string(body))).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Len(t, records, 3)
+	assert.Equal(t, []string{"timestamp", "event_type", "actor_subject", "ip_address", "request_id", "details"}, records[0])
+
+	// the most recent first
+	assert.Equal(t, eventType, records[1][1])
+	assert.Equal(t, "actor-2", records[1][2])
+	assert.Equal(t, auditEvent2.RequestId, records[1][4])
+	assert.Equal(t, `{"test":true}`, records[1][5])
+	assert.Equal(t, "actor-1", records[2][2])
+	assert.Equal(t, auditEvent1.RequestId, records[2][4])
+}
+
+func TestAdminAudit_DeleteExpiredEvents(t *testing.T) {
+	setup()
+
+	eventType := "test_event_" + strings.ToLower(gofakeit.LetterN(8))
+	createAuditTestEvent(t, eventType, time.Now().UTC().AddDate(0, 0, -400), "", 0)
+	createAuditTestEvent(t, eventType, time.Now().UTC(), "", 0)
+
+	deleted, err := database.DeleteAuditEventsCreatedBefore(nil, time.Now().UTC().AddDate(0, 0, -365))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.GreaterOrEqual(t, deleted, int64(1))
+
+	auditEvents, total, err := database.SearchAuditEventsPaginated(nil, &entities.AuditEventFilter{
+		EventType: eventType,
+	}, 1, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 1, total)
+	assert.Len(t, auditEvents, 1)
+}
@@ -30,11 +30,26 @@ func TestAdminClients_Get(t *testing.T) {
 	data := unmarshalToMap(t, resp)
 
 	permissions := data["Permissions"].([]interface{})
-	assert.Equal(t, 2, len(permissions))
+	assert.Equal(t, 13, len(permissions))
 
 	permission := permissions[0].(map[string]interface{})
 	assert.Equal(t, "manage-account", permission["PermissionIdentifier"])
 
 	permission = permissions[1].(map[string]interface{})
 	assert.Equal(t, "admin-website", permission["PermissionIdentifier"])
+
+	permission = permissions[2].(map[string]interface{})
+	assert.Equal(t, "scim", permission["PermissionIdentifier"])
+
+	adminApiPermissions := []string{
+		"admin-api-users-read", "admin-api-users-write",
+		"admin-api-groups-read", "admin-api-groups-write",
+		"admin-api-clients-read", "admin-api-clients-write",
+		"admin-api-resources-read", "admin-api-resources-write",
+		"admin-api-settings-read", "admin-api-settings-write",
+	}
+	for i, permissionIdentifier := range adminApiPermissions {
+		permission = permissions[3+i].(map[string]interface{})
+		assert.Equal(t, permissionIdentifier, permission["PermissionIdentifier"])
+	}
 }
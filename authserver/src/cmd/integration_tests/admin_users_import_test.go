@@ -0,0 +1,318 @@
+package integrationtests
+
+import (
+	"bytes"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/brianvoe/gofakeit/v6"
+	core_users "github.com/leodip/goiabada/internal/core/users"
+	"github.com/leodip/goiabada/internal/entities"
+	"github.com/leodip/goiabada/internal/lib"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func postUsersImportFile(t *testing.T, httpClient *http.Client, csrf string, fileName string, content string,
+	fields map[string]string) *goquery.Document {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	err := writer.WriteField("gorilla.csrf.Token", csrf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, value := range fields {
+		err = writer.WriteField(name, value)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	part, err := writer.CreateFormFile("usersFile", fileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = part.Write([]byte(content))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = writer.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := httpClient.Post(lib.GetBaseUrl()+"/admin/users/import", writer.FormDataContentType(), body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	doc, err := goquery.NewDocumentFromReader(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return doc
+}
+
+func getImportRowStatuses(doc *goquery.Document) []string {
+	statuses := []string{}
+	doc.Find("#importRows tbody tr").Each(func(i int, s *goquery.Selection) {
+		statuses = append(statuses, strings.TrimSpace(s.Find("td").Eq(2).Text()))
+	})
+	return statuses
+}
+
+func createImportTestGroup(t *testing.T) *entities.Group {
+	group := &entities.Group{
+		GroupIdentifier: "import-" + strings.ToLower(gofakeit.LetterN(8)),
+		Description:     "Imported users",
+	}
+	err := database.CreateGroup(nil, group)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = database.DeleteGroup(nil, group.Id)
+	})
+	return group
+}
+
+func deleteUserOnCleanup(t *testing.T, email string) {
+	t.Cleanup(func() {
+		if user, _ := database.GetUserByEmail(nil, email); user != nil {
+			_ = database.DeleteUser(nil, user.Id)
+		}
+	})
+}
+
+func TestAdminUsersImport_Post(t *testing.T) {
+	setup()
+
+	group := createImportTestGroup(t)
+
+	email1 := strings.ToLower(gofakeit.LetterN(10)) + "@example.com"
+	email2 := strings.ToLower(gofakeit.LetterN(10)) + "@example.com"
+	deleteUserOnCleanup(t, email1)
+	deleteUserOnCleanup(t, email2)
+
+	passwordHash, err := bcrypt.GenerateFromPassword([]byte("imported-password"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	csv := "E-mail,givenName,familyName,emailVerified,phoneNumberCountry,phoneNumber,groups,attributes,passwordHash,notes\n" +
+		email1 + ",Ana,Silva,yes,+351,912345678," + group.GroupIdentifier + ",department=sales;level=2,,first\n" +
+		email2 + ",Bruno,Costa,no,,,,," + string(passwordHash) + ",second\n" +
+		"admin@example.com,Admin,,true,,,,,,existing\n" +
+		"not-an-email,Invalid,,,,,,,,invalid\n" +
+		strings.ToUpper(email1) + ",Repeated,,,,,,,,repeated\n" +
+		strings.ToLower(gofakeit.LetterN(10)) + "@example.com,Unknown,,,,,unknown-group,,,group\n"
+
+	httpClient := loginToAdminArea(t, "admin@example.com", "changeme")
+	resp := getPage(t, httpClient, lib.GetBaseUrl()+"/admin/users/import")
+	defer resp.Body.Close()
+	csrf := getCsrfValue(t, resp)
+
+	// the dry run validates the file without creating the users
+	doc := postUsersImportFile(t, httpClient, csrf, "users.csv", csv, map[string]string{
+		"columnMapping": "E-mail=email",
+		"dryRun":        "on",
+	})
+	assert.Equal(t, "2 valid, 1 skipped, 3 failed.", strings.TrimSpace(doc.Find("#importSummary").Text()))
+	assert.Contains(t, doc.Find("p.text-warning").Text(), "notes")
+	assert.Equal(t, []string{"valid", "valid", "skipped", "failed", "failed", "failed"}, getImportRowStatuses(doc))
+	assert.Contains(t, doc.Find("#importRows tbody tr").Eq(4).Text(), "repeated in the row 1")
+	assert.Contains(t, doc.Find("#importRows tbody tr").Eq(5).Text(), "Group not found")
+
+	user, err := database.GetUserByEmail(nil, email1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Nil(t, user)
+
+	// the valid users are imported even though other rows failed
+	doc = postUsersImportFile(t, httpClient, csrf, "users.csv", csv, map[string]string{
+		"columnMapping": "E-mail=email",
+	})
+	assert.Equal(t, "2 created, 1 skipped, 3 failed.", strings.TrimSpace(doc.Find("#importSummary").Text()))
+
+	user, err = database.GetUserByEmail(nil, email1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !assert.NotNil(t, user) {
+		return
+	}
+	assert.Equal(t, "Ana", user.GivenName)
+	assert.Equal(t, "Silva", user.FamilyName)
+	assert.True(t, user.EmailVerified)
+	assert.True(t, user.Enabled)
+	assert.Equal(t, "+351 912345678", user.PhoneNumber)
+	assert.Empty(t, user.PasswordHash)
+
+	userGroups, err := database.GetUserGroupsByUserId(nil, user.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if assert.Len(t, userGroups, 1) {
+		assert.Equal(t, group.Id, userGroups[0].GroupId)
+	}
+	attributes, err := database.GetUserAttributesByUserId(nil, user.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(t, attributes, 2)
+	for _, attribute := range attributes {
+		assert.True(t, attribute.IncludeInIdToken)
+		assert.True(t, attribute.IncludeInAccessToken)
+	}
+
+	user, err = database.GetUserByEmail(nil, email2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !assert.NotNil(t, user) {
+		return
+	}
+	assert.True(t, lib.VerifyPasswordHash(user.PasswordHash, "imported-password"))
+	assert.True(t, user.PasswordChangedAt.Valid)
+
+	// importing the file again skips the users that were created
+	doc = postUsersImportFile(t, httpClient, csrf, "users.csv", csv, map[string]string{
+		"columnMapping": "E-mail=email",
+	})
+	assert.Equal(t, "0 created, 4 skipped, 2 failed.", strings.TrimSpace(doc.Find("#importSummary").Text()))
+}
+
+func TestAdminUsersImport_PostJSON(t *testing.T) {
+	setup()
+
+	email := strings.ToLower(gofakeit.LetterN(10)) + "@example.com"
+	deleteUserOnCleanup(t, email)
+
+	json := `[{"email": "` + email + `", "enabled": false, "username": "u` + strings.ToLower(gofakeit.LetterN(10)) + `",
+		"gender": "female", "birthDate": "1990-05-20", "zoneInfo": "Europe/Lisbon", "locale": "pt-PT",
+		"attributes": [{"key": "tier", "value": "gold", "includeInIdToken": false, "includeInAccessToken": true}]}]`
+
+	httpClient := loginToAdminArea(t, "admin@example.com", "changeme")
+	resp := getPage(t, httpClient, lib.GetBaseUrl()+"/admin/users/import")
+	defer resp.Body.Close()
+	csrf := getCsrfValue(t, resp)
+
+	doc := postUsersImportFile(t, httpClient, csrf, "users.json", json, nil)
+	assert.Equal(t, "1 created, 0 skipped, 0 failed.", strings.TrimSpace(doc.Find("#importSummary").Text()))
+
+	user, err := database.GetUserByEmail(nil, email)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !assert.NotNil(t, user) {
+		return
+	}
+	assert.False(t, user.Enabled)
+	assert.Equal(t, "female", user.Gender)
+	assert.Equal(t, "1990-05-20", user.BirthDate.Time.Format("2006-01-02"))
+	assert.Equal(t, "Europe/Lisbon", user.ZoneInfo)
+	assert.Equal(t, "Portugal", user.ZoneInfoCountryName)
+
+	attributes, err := database.GetUserAttributesByUserId(nil, user.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if assert.Len(t, attributes, 1) {
+		assert.False(t, attributes[0].IncludeInIdToken)
+		assert.True(t, attributes[0].IncludeInAccessToken)
+	}
+
+	// the whole file is rejected when it can't be parsed
+	doc = postUsersImportFile(t, httpClient, csrf, "users.json", `[{"email": "a@example.com", "unknown": 1}]`, nil)
+	assert.Contains(t, doc.Find("div.text-error p").Text(), "Unable to parse the JSON file")
+
+	doc = postUsersImportFile(t, httpClient, csrf, "users.csv", "name\nAna\n", nil)
+	assert.Contains(t, doc.Find("div.text-error p").Text(), "must have a column with the email address")
+
+	doc = postUsersImportFile(t, httpClient, csrf, "users.csv", "email\na@example.com\n", map[string]string{
+		"columnMapping": "Mail=unknownField",
+	})
+	assert.Contains(t, doc.Find("div.text-error p").Text(), "Invalid column mapping")
+}
+
+func TestAdminUsersExport_Post(t *testing.T) {
+	setup()
+
+	group := createImportTestGroup(t)
+
+	email := strings.ToLower(gofakeit.LetterN(10)) + "@example.com"
+	deleteUserOnCleanup(t, email)
+
+	httpClient := loginToAdminArea(t, "admin@example.com", "changeme")
+	resp := getPage(t, httpClient, lib.GetBaseUrl()+"/admin/users/import")
+	defer resp.Body.Close()
+	csrf := getCsrfValue(t, resp)
+
+	passwordHash, err := bcrypt.GenerateFromPassword([]byte("imported-password"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	csv := "email,givenName,phoneNumberCountry,phoneNumber,groups,attributes,passwordHash\n" +
+		email + ",Carla,+351,912345678," + group.GroupIdentifier + ",department=sales," + string(passwordHash) + "\n"
+	doc := postUsersImportFile(t, httpClient, csrf, "users.csv", csv, nil)
+	assert.Equal(t, "1 created, 0 skipped, 0 failed.", strings.TrimSpace(doc.Find("#importSummary").Text()))
+
+	export := func(groupId string, format string) []core_users.UserRecord {
+		formData := url.Values{
+			"gorilla.csrf.Token": {csrf},
+			"groupId":            {groupId},
+			"format":             {format},
+		}
+		resp, err := httpClient.PostForm(lib.GetBaseUrl()+"/admin/users/export", formData)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Contains(t, resp.Header.Get("Content-Disposition"), "attachment")
+		data, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		records, _, err := core_users.ReadRecords(data, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return records
+	}
+
+	// only the members of the group are exported
+	records := export(strconv.FormatInt(group.Id, 10), "csv")
+	if assert.Len(t, records, 1) {
+		assert.Equal(t, email, records[0].Email)
+		assert.Equal(t, "Carla", records[0].GivenName)
+		assert.Equal(t, "+351", records[0].PhoneNumberCountry)
+		assert.Equal(t, "912345678", records[0].PhoneNumber)
+		assert.Equal(t, []string{group.GroupIdentifier}, records[0].Groups)
+		if assert.Len(t, records[0].Attributes, 1) {
+			assert.Equal(t, "department", records[0].Attributes[0].Key)
+			assert.Equal(t, "sales", records[0].Attributes[0].Value)
+		}
+		assert.Empty(t, records[0].PasswordHash)
+	}
+
+	records = export("", "json")
+	found := false
+	for _, record := range records {
+		if record.Email == email {
+			found = true
+			assert.Equal(t, []string{group.GroupIdentifier}, record.Groups)
+		}
+		assert.Empty(t, record.PasswordHash)
+	}
+	assert.True(t, found)
+	assert.Greater(t, len(records), 1)
+}
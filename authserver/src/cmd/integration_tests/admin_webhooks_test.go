@@ -0,0 +1,498 @@
+package integrationtests
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/brianvoe/gofakeit/v6"
+	"github.com/leodip/goiabada/internal/constants"
+	core_webhooks "github.com/leodip/goiabada/internal/core/webhooks"
+	"github.com/leodip/goiabada/internal/entities"
+	"github.com/leodip/goiabada/internal/lib"
+	"github.com/stretchr/testify/assert"
+)
+
+type userEventsTestServer struct {
+	server     *httptest.Server
+	mutex      sync.Mutex
+	statusCode int
+	events     []core_webhooks.Event
+}
+
+func newUserEventsTestServer(t *testing.T, secret string, statusCode int) *userEventsTestServer {
+	us := &userEventsTestServer{statusCode: statusCode}
+	us.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		us.mutex.Lock()
+		defer us.mutex.Unlock()
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		timestamp, err := strconv.ParseInt(r.Header.Get("X-Goiabada-Timestamp"), 10, 64)
+		assert.NoError(t, err)
+		assert.Equal(t, lib.SignWebhookPayload(secret, timestamp, body), r.Header.Get("X-Goiabada-Signature"))
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+
+		var event core_webhooks.Event
+		err = json.Unmarshal(body, &event)
+		assert.NoError(t, err)
+		assert.Equal(t, event.Type, r.Header.Get("X-Goiabada-Event"))
+		assert.Equal(t, event.Id, r.Header.Get("X-Goiabada-Delivery"))
+
+		if us.statusCode == http.StatusOK {
+			us.events = append(us.events, event)
+		}
+		w.WriteHeader(us.statusCode)
+	}))
+	t.Cleanup(us.server.Close)
+	return us
+}
+
+func (us *userEventsTestServer) getEvents() []core_webhooks.Event {
+	us.mutex.Lock()
+	defer us.mutex.Unlock()
+	return append([]core_webhooks.Event{}, us.events...)
+}
+
+func createTestWebhook(t *testing.T, webhookUrl string, secret string, eventTypes ...string) *entities.Webhook {
+	settings, err := database.GetSettingsById(nil, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	secretEncrypted, err := lib.EncryptText(secret, settings.AESEncryptionKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	webhook := &entities.Webhook{
+		Url:             webhookUrl,
+		Description:     "Test webhook",
+		SecretEncrypted: secretEncrypted,
+		EventTypes:      strings.Join(eventTypes, " "),
+		Enabled:         true,
+	}
+	err = database.CreateWebhook(nil, webhook)
+	if err != nil {
+		t.Fatal(err)
+	}
+	deleteWebhookOnCleanup(t, webhook.Id)
+	return webhook
+}
+
+func deleteWebhookOnCleanup(t *testing.T, webhookId int64) {
+	t.Cleanup(func() {
+		_ = database.DeleteWebhook(nil, webhookId)
+	})
+}
+
+func getWebhookDeliveries(t *testing.T, webhookId int64) []entities.WebhookDelivery {
+	deliveries, _, err := database.GetWebhookDeliveriesPaginated(nil, webhookId, 1, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return deliveries
+}
+
+// waitForWebhookDeliveries waits for the dispatcher of the server to make an attempt to send all
+// the deliveries of the webhook.
+func waitForWebhookDeliveries(t *testing.T, webhookId int64, count int) []entities.WebhookDelivery {
+	deadline := time.Now().Add(20 * time.Second)
+	for {
+		deliveries := getWebhookDeliveries(t, webhookId)
+		attempted := 0
+		for _, delivery := range deliveries {
+			if delivery.Attempts > 0 {
+				attempted++
+			}
+		}
+		if (attempted >= count && len(deliveries) >= count) || time.Now().After(deadline) {
+			return deliveries
+		}
+		time.Sleep(250 * time.Millisecond)
+	}
+}
+
+func createCliTestUser(t *testing.T) *entities.User {
+	email := strings.ToLower(gofakeit.LetterN(10)) + "@example.com"
+	deleteUserOnCleanup(t, email)
+	_, err := runCli(t, "", "user", "create", "-email", email, "-password", "Cli-"+gofakeit.Password(true, true, true, false, false, 12)+"1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	user, err := database.GetUserByEmail(nil, email)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return user
+}
+
+func TestAdminWebhooks_Get(t *testing.T) {
+	setup()
+
+	webhook := createTestWebhook(t, "https://"+strings.ToLower(gofakeit.LetterN(10))+".example.com/hook",
+		gofakeit.LetterN(32), constants.WebhookEventUserCreated, constants.WebhookEventUserDeleted)
+
+	httpClient := loginToAdminArea(t, "admin@example.com", "changeme")
+
+	resp := getPage(t, httpClient, lib.GetBaseUrl()+"/admin/webhooks")
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	doc, err := goquery.NewDocumentFromReader(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	row := doc.Find("#webhooksTable tbody tr:contains('" + webhook.Url + "')")
+	if !assert.Equal(t, 1, row.Length()) {
+		return
+	}
+	assert.Contains(t, row.Text(), constants.WebhookEventUserCreated)
+	assert.Contains(t, row.Text(), constants.WebhookEventUserDeleted)
+	assert.NotContains(t, row.Text(), constants.WebhookEventUserUpdated)
+}
+
+func TestAdminWebhookNew_Post(t *testing.T) {
+	setup()
+
+	httpClient := loginToAdminArea(t, "admin@example.com", "changeme")
+
+	resp := getPage(t, httpClient, lib.GetBaseUrl()+"/admin/webhooks/new")
+	defer resp.Body.Close()
+	csrf := getCsrfValue(t, resp)
+
+	webhookUrl := "https://" + strings.ToLower(gofakeit.LetterN(10)) + ".example.com/hook"
+	resp = postForm(t, httpClient, lib.GetBaseUrl()+"/admin/webhooks/new", url.Values{
+		"gorilla.csrf.Token": {csrf},
+		"url":                {webhookUrl},
+		"description":        {"Provisioning"},
+		"eventTypes":         {constants.WebhookEventUserCreated, constants.WebhookEventUserAddedToGroup},
+		"enabled":            {"on"},
+	})
+	defer resp.Body.Close()
+
+	webhooks, err := database.GetAllWebhooks(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var webhook *entities.Webhook
+	for i := range webhooks {
+		if webhooks[i].Url == webhookUrl {
+			webhook = &webhooks[i]
+		}
+	}
+	if !assert.NotNil(t, webhook) {
+		return
+	}
+	deleteWebhookOnCleanup(t, webhook.Id)
+
+	assertRedirect(t, resp, fmt.Sprintf("/admin/webhooks/%v/settings", webhook.Id))
+	assert.Equal(t, "Provisioning", webhook.Description)
+	assert.Equal(t, []string{constants.WebhookEventUserCreated, constants.WebhookEventUserAddedToGroup}, webhook.GetEventTypes())
+	assert.True(t, webhook.Enabled)
+
+	// the secret is generated, and shown in the settings
+	resp = getPage(t, httpClient, lib.GetBaseUrl()+fmt.Sprintf("/admin/webhooks/%v/settings", webhook.Id))
+	defer resp.Body.Close()
+	doc, err := goquery.NewDocumentFromReader(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	secret, _ := doc.Find("#webhookSecret").Attr("value")
+	assert.Len(t, secret, 60)
+}
+
+func TestAdminWebhookNew_Post_ValidationErrors(t *testing.T) {
+	setup()
+
+	httpClient := loginToAdminArea(t, "admin@example.com", "changeme")
+
+	testCases := []struct {
+		url           string
+		description   string
+		eventTypes    []string
+		expectedError string
+	}{
+		{"", "", []string{constants.WebhookEventUserCreated}, "The URL is required."},
+		{"https://example.com/" + strings.Repeat("a", 500), "", []string{constants.WebhookEventUserCreated},
+			"The URL cannot exceed a maximum length of 512 characters."},
+		{"ftp://example.com/hook", "", []string{constants.WebhookEventUserCreated}, "The URL must be a valid http or https URL."},
+		{"https://example.com/hook", strings.Repeat("a", 129), []string{constants.WebhookEventUserCreated},
+			"The description cannot exceed a maximum length of 128 characters."},
+		{"https://example.com/hook", "", []string{}, "Please select at least one event."},
+		{"https://example.com/hook", "", []string{"user.logged_in"}, "Invalid event type user.logged_in."},
+	}
+
+	for _, testCase := range testCases {
+		resp := getPage(t, httpClient, lib.GetBaseUrl()+"/admin/webhooks/new")
+		csrf := getCsrfValue(t, resp)
+		resp.Body.Close()
+
+		resp = postForm(t, httpClient, lib.GetBaseUrl()+"/admin/webhooks/new", url.Values{
+			"gorilla.csrf.Token": {csrf},
+			"url":                {testCase.url},
+			"description":        {testCase.description},
+			"eventTypes":         testCase.eventTypes,
+		})
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		doc, err := goquery.NewDocumentFromReader(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, testCase.expectedError, strings.TrimSpace(doc.Find(".text-error p").Text()))
+	}
+}
+
+func TestAdminWebhookSettings_Post(t *testing.T) {
+	setup()
+
+	secret := gofakeit.LetterN(32)
+	webhook := createTestWebhook(t, "https://"+strings.ToLower(gofakeit.LetterN(10))+".example.com/hook",
+		secret, constants.WebhookEventUserCreated)
+
+	httpClient := loginToAdminArea(t, "admin@example.com", "changeme")
+
+	settingsUrl := lib.GetBaseUrl() + fmt.Sprintf("/admin/webhooks/%v/settings", webhook.Id)
+	resp := getPage(t, httpClient, settingsUrl)
+	defer resp.Body.Close()
+	csrf := getCsrfValue(t, resp)
+
+	newUrl := "https://" + strings.ToLower(gofakeit.LetterN(10)) + ".example.com/events"
+	resp = postForm(t, httpClient, settingsUrl, url.Values{
+		"gorilla.csrf.Token": {csrf},
+		"url":                {newUrl},
+		"description":        {"Deprovisioning"},
+		"eventTypes":         {constants.WebhookEventUserDisabled, constants.WebhookEventUserDeleted},
+		"regenerateSecret":   {"on"},
+	})
+	defer resp.Body.Close()
+	assertRedirect(t, resp, fmt.Sprintf("/admin/webhooks/%v/settings", webhook.Id))
+
+	updated, err := database.GetWebhookById(nil, webhook.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, newUrl, updated.Url)
+	assert.Equal(t, "Deprovisioning", updated.Description)
+	assert.Equal(t, []string{constants.WebhookEventUserDisabled, constants.WebhookEventUserDeleted}, updated.GetEventTypes())
+	assert.False(t, updated.Enabled)
+
+	resp = getPage(t, httpClient, settingsUrl)
+	defer resp.Body.Close()
+	doc, err := goquery.NewDocumentFromReader(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Contains(t, doc.Text(), "Webhook settings saved successfully")
+	newSecret, _ := doc.Find("#webhookSecret").Attr("value")
+	assert.Len(t, newSecret, 60)
+	assert.NotEqual(t, secret, newSecret)
+}
+
+func TestAdminWebhookDelete_Post(t *testing.T) {
+	setup()
+
+	webhook := createTestWebhook(t, "https://"+strings.ToLower(gofakeit.LetterN(10))+".example.com/hook",
+		gofakeit.LetterN(32), constants.WebhookEventUserCreated)
+
+	httpClient := loginToAdminArea(t, "admin@example.com", "changeme")
+
+	deleteUrl := lib.GetBaseUrl() + fmt.Sprintf("/admin/webhooks/%v/delete", webhook.Id)
+	resp := getPage(t, httpClient, deleteUrl)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	csrf := getCsrfValue(t, resp)
+
+	resp = postForm(t, httpClient, deleteUrl, url.Values{
+		"gorilla.csrf.Token": {csrf},
+	})
+	defer resp.Body.Close()
+	assertRedirect(t, resp, "/admin/webhooks")
+
+	deleted, err := database.GetWebhookById(nil, webhook.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Nil(t, deleted)
+}
+
+func TestAdminWebhooks_UserLifecycleEvents(t *testing.T) {
+	setup()
+
+	secret := gofakeit.LetterN(32)
+	us := newUserEventsTestServer(t, secret, http.StatusOK)
+	webhook := createTestWebhook(t, us.server.URL, secret, constants.WebhookEventUserCreated,
+		constants.WebhookEventUserAddedToGroup, constants.WebhookEventUserDeleted)
+	notSubscribed := createTestWebhook(t, us.server.URL, secret, constants.WebhookEventUserDisabled)
+
+	// the user is created by the cli, and the event is delivered by the server
+	user := createCliTestUser(t)
+	group := createImportTestGroup(t)
+
+	httpClient := loginToAdminArea(t, "admin@example.com", "changeme")
+
+	resp := getPage(t, httpClient, lib.GetBaseUrl()+"/admin/groups/"+strconv.Itoa(int(group.Id))+"/members/add")
+	defer resp.Body.Close()
+	csrf := getCsrfValue(t, resp)
+
+	resp = postForm(t, httpClient, lib.GetBaseUrl()+"/admin/groups/"+strconv.Itoa(int(group.Id))+"/members/add?groupId="+
+		strconv.Itoa(int(group.Id))+"&userId="+strconv.Itoa(int(user.Id)), url.Values{
+		"gorilla.csrf.Token": {csrf},
+	})
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	deleteUrl := lib.GetBaseUrl() + fmt.Sprintf("/admin/users/%v/delete", user.Id)
+	resp = getPage(t, httpClient, deleteUrl)
+	defer resp.Body.Close()
+	csrf = getCsrfValue(t, resp)
+
+	resp = postForm(t, httpClient, deleteUrl, url.Values{
+		"gorilla.csrf.Token": {csrf},
+	})
+	defer resp.Body.Close()
+
+	deliveries := waitForWebhookDeliveries(t, webhook.Id, 3)
+	if !assert.Len(t, deliveries, 3) {
+		return
+	}
+	for _, delivery := range deliveries {
+		assert.Equal(t, constants.WebhookDeliveryStatusDelivered, delivery.Status)
+		assert.Equal(t, 1, delivery.Attempts)
+		assert.Equal(t, http.StatusOK, delivery.LastResponseCode)
+		assert.False(t, delivery.NextAttemptAt.Valid)
+	}
+	assert.Empty(t, getWebhookDeliveries(t, notSubscribed.Id))
+
+	events := map[string]core_webhooks.Event{}
+	for _, event := range us.getEvents() {
+		events[event.Type] = event
+	}
+	if !assert.Len(t, events, 3) {
+		return
+	}
+	for _, event := range events {
+		assert.NotEmpty(t, event.Id)
+		assert.False(t, event.CreatedAt.IsZero())
+		if assert.NotNil(t, event.Data.User) {
+			assert.Equal(t, user.Subject.String(), event.Data.User.Subject)
+			assert.Equal(t, user.Email, event.Data.User.Email)
+		}
+	}
+	assert.Nil(t, events[constants.WebhookEventUserCreated].Data.Group)
+	if assert.NotNil(t, events[constants.WebhookEventUserAddedToGroup].Data.Group) {
+		assert.Equal(t, group.GroupIdentifier, events[constants.WebhookEventUserAddedToGroup].Data.Group.GroupIdentifier)
+	}
+}
+
+func TestAdminWebhooks_FailedDeliveryIsRetried(t *testing.T) {
+	setup()
+
+	secret := gofakeit.LetterN(32)
+	us := newUserEventsTestServer(t, secret, http.StatusInternalServerError)
+	webhook := createTestWebhook(t, us.server.URL, secret, constants.WebhookEventUserCreated)
+
+	createCliTestUser(t)
+
+	deliveries := waitForWebhookDeliveries(t, webhook.Id, 1)
+	if !assert.Len(t, deliveries, 1) {
+		return
+	}
+	delivery := deliveries[0]
+	assert.Equal(t, constants.WebhookDeliveryStatusPending, delivery.Status)
+	assert.Equal(t, 1, delivery.Attempts)
+	assert.Equal(t, http.StatusInternalServerError, delivery.LastResponseCode)
+	assert.Equal(t, "the webhook responded with status code 500", delivery.LastError)
+	assert.True(t, delivery.LastAttemptAt.Valid)
+	if assert.True(t, delivery.NextAttemptAt.Valid) {
+		assert.True(t, delivery.NextAttemptAt.Time.After(delivery.LastAttemptAt.Time))
+	}
+
+	// the delivery log shows the attempt
+	httpClient := loginToAdminArea(t, "admin@example.com", "changeme")
+	deliveriesUrl := lib.GetBaseUrl() + fmt.Sprintf("/admin/webhooks/%v/deliveries", webhook.Id)
+	resp := getPage(t, httpClient, deliveriesUrl)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	doc, err := goquery.NewDocumentFromReader(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows := doc.Find("#deliveriesTable tbody tr:contains('" + delivery.EventId + "')")
+	assert.Equal(t, 1, rows.Length())
+	assert.Contains(t, rows.Text(), "the webhook responded with status code 500")
+}
+
+func TestAdminWebhookDeliveryRedeliver_Post(t *testing.T) {
+	setup()
+
+	secret := gofakeit.LetterN(32)
+	us := newUserEventsTestServer(t, secret, http.StatusOK)
+	webhook := createTestWebhook(t, us.server.URL, secret, constants.WebhookEventUserCreated)
+
+	createCliTestUser(t)
+
+	deliveries := waitForWebhookDeliveries(t, webhook.Id, 1)
+	if !assert.Len(t, deliveries, 1) {
+		return
+	}
+	delivery := deliveries[0]
+	assert.Equal(t, constants.WebhookDeliveryStatusDelivered, delivery.Status)
+
+	httpClient := loginToAdminArea(t, "admin@example.com", "changeme")
+
+	deliveriesUrl := lib.GetBaseUrl() + fmt.Sprintf("/admin/webhooks/%v/deliveries", webhook.Id)
+	resp := getPage(t, httpClient, deliveriesUrl)
+	defer resp.Body.Close()
+	csrf := getCsrfValue(t, resp)
+
+	resp = postForm(t, httpClient, deliveriesUrl+fmt.Sprintf("/%v/redeliver", delivery.Id), url.Values{
+		"gorilla.csrf.Token": {csrf},
+	})
+	defer resp.Body.Close()
+	assertRedirect(t, resp, fmt.Sprintf("/admin/webhooks/%v/deliveries", webhook.Id))
+
+	// the event is sent again with the same id, so the receiver can discard it
+	deliveries = waitForWebhookDeliveries(t, webhook.Id, 2)
+	if !assert.Len(t, deliveries, 2) {
+		return
+	}
+	for _, d := range deliveries {
+		assert.Equal(t, delivery.EventId, d.EventId)
+		assert.Equal(t, delivery.Payload, d.Payload)
+		assert.Equal(t, constants.WebhookDeliveryStatusDelivered, d.Status)
+	}
+
+	events := us.getEvents()
+	if assert.Len(t, events, 2) {
+		assert.Equal(t, events[0].Id, events[1].Id)
+	}
+
+	// a delivery of another webhook can't be redelivered
+	other := createTestWebhook(t, "https://"+strings.ToLower(gofakeit.LetterN(10))+".example.com/hook",
+		secret, constants.WebhookEventUserCreated)
+	resp = getPage(t, httpClient, lib.GetBaseUrl()+fmt.Sprintf("/admin/webhooks/%v/settings", other.Id))
+	defer resp.Body.Close()
+	csrf = getCsrfValue(t, resp)
+
+	resp = postForm(t, httpClient, lib.GetBaseUrl()+fmt.Sprintf("/admin/webhooks/%v/deliveries/%v/redeliver", other.Id, delivery.Id), url.Values{
+		"gorilla.csrf.Token": {csrf},
+	})
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+	assert.Empty(t, getWebhookDeliveries(t, other.Id))
+}
@@ -0,0 +1,505 @@
+package integrationtests
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/brianvoe/gofakeit/v6"
+	"github.com/leodip/goiabada/internal/constants"
+	core_audit "github.com/leodip/goiabada/internal/core/audit"
+	"github.com/leodip/goiabada/internal/lib"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestAuditEvent(event string) *lib.AuditEvent {
+	return &lib.AuditEvent{
+		Timestamp:    time.Now().UTC(),
+		Event:        event,
+		ActorSubject: "actor-" + gofakeit.LetterN(6),
+		IpAddress:    "10.0.0.1",
+		RequestId:    gofakeit.UUID(),
+		Details:      map[string]interface{}{"userId": 1},
+	}
+}
+
+func readAuditEventsFile(t *testing.T, path string) []lib.AuditEvent {
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	auditEvents := []lib.AuditEvent{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var auditEvent lib.AuditEvent
+		err = json.Unmarshal(scanner.Bytes(), &auditEvent)
+		if err != nil {
+			t.Fatal(err)
+		}
+		auditEvents = append(auditEvents, auditEvent)
+	}
+	return auditEvents
+}
+
+func TestAuditSinks_File_Cli(t *testing.T) {
+	setup()
+
+	path := filepath.Join(t.TempDir(), "audit", "audit.log")
+	t.Setenv("GOIABADA_AUDITING_FILE_ENABLED", "true")
+	t.Setenv("GOIABADA_AUDITING_FILE_PATH", path)
+
+	email := strings.ToLower(gofakeit.Email())
+	deleteUserOnCleanup(t, email)
+	_, err := runCli(t, "", "user", "create", "-email", email, "-password", "Cli-"+gofakeit.Password(true, true, true, false, false, 12)+"1")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	// the sinks are closed when the command exits, so the events are in the file
+	auditEvents := readAuditEventsFile(t, path)
+	if !assert.Len(t, auditEvents, 1) {
+		return
+	}
+	assert.Equal(t, constants.AuditCreatedUser, auditEvents[0].Event)
+	assert.Equal(t, email, auditEvents[0].Details["email"])
+	assert.NotEmpty(t, auditEvents[0].ActorSubject)
+	assert.Equal(t, auditEvents[0].ActorSubject, auditEvents[0].Details["cliUser"])
+	assert.False(t, auditEvents[0].Timestamp.IsZero())
+}
+
+func TestAuditSinks_File_Rotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+
+	sink, err := core_audit.NewFileSink(core_audit.FileSinkConfig{
+		Path:       path,
+		MaxSize:    1000,
+		MaxBackups: 2,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sink.Start()
+
+	events := []string{}
+	for i := 0; i < 40; i++ {
+		event := fmt.Sprintf("test_event_%v", i)
+		events = append(events, event)
+		sink.WriteAuditEvent(newTestAuditEvent(event))
+	}
+	sink.Close()
+
+	_, err = os.Stat(path + ".3")
+	assert.True(t, os.IsNotExist(err))
+
+	// the backups have the older events, and the events of a file are in order
+	written := []string{}
+	for _, p := range []string{path + ".2", path + ".1", path} {
+		info, err := os.Stat(p)
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.LessOrEqual(t, info.Size(), int64(1000))
+		for _, auditEvent := range readAuditEventsFile(t, p) {
+			written = append(written, auditEvent.Event)
+		}
+	}
+	assert.Equal(t, events[len(events)-len(written):], written)
+}
+
+func TestAuditSinks_Syslog_Udp(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	sink, err := core_audit.NewSyslogSink(core_audit.SyslogSinkConfig{
+		Network:  "udp",
+		Address:  conn.LocalAddr().String(),
+		Facility: "authpriv",
+		AppName:  "goiabada",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sink.Start()
+
+	auditEvent := newTestAuditEvent(constants.AuditAuthSuccessPwd)
+	auditEvent.ActorSubject = `actor "quoted" ]`
+	sink.WriteAuditEvent(auditEvent)
+	sink.Close()
+
+	buf := make([]byte, 4096)
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	n, _, err := conn.ReadFrom(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	message := string(buf[:n])
+
+	// authpriv (10) * 8 + informational (6)
+	assert.True(t, strings.HasPrefix(message, "<86>1 "+auditEvent.Timestamp.Format("2006-01-02T15:04:05.000000Z")+" "))
+	parts := strings.SplitN(message, " ", 8)
+	assert.Equal(t, "goiabada", parts[3])
+	assert.Equal(t, strconv.Itoa(os.Getpid()), parts[4])
+	assert.Equal(t, constants.AuditAuthSuccessPwd, parts[5])
+	assert.Contains(t, message, fmt.Sprintf(`[goiabada@32473 requestId="%v" ipAddress="10.0.0.1" actorSubject="actor \"quoted\" \]"]`,
+		auditEvent.RequestId))
+
+	msg := message[strings.Index(message, "\xEF\xBB\xBF")+3:]
+	var received lib.AuditEvent
+	err = json.Unmarshal([]byte(msg), &received)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, auditEvent.RequestId, received.RequestId)
+	assert.Equal(t, auditEvent.ActorSubject, received.ActorSubject)
+}
+
+func TestAuditSinks_Syslog_Tcp(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	received := make(chan string, 10)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		reader := bufio.NewReader(conn)
+		for {
+			// octet counting: the length, a space and the message
+			length, err := reader.ReadString(' ')
+			if err != nil {
+				return
+			}
+			n, err := strconv.Atoi(strings.TrimSpace(length))
+			if err != nil {
+				return
+			}
+			message := make([]byte, n)
+			_, err = io.ReadFull(reader, message)
+			if err != nil {
+				return
+			}
+			received <- string(message)
+		}
+	}()
+
+	sink, err := core_audit.NewSyslogSink(core_audit.SyslogSinkConfig{
+		Network:  "tcp",
+		Address:  listener.Addr().String(),
+		Facility: "local0",
+		AppName:  "goiabada",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sink.Start()
+
+	sink.WriteAuditEvent(newTestAuditEvent("test_event_1"))
+	sink.WriteAuditEvent(newTestAuditEvent("test_event_2"))
+	sink.Close()
+
+	for _, event := range []string{"test_event_1", "test_event_2"} {
+		select {
+		case message := <-received:
+			// local0 (16) * 8 + informational (6)
+			assert.True(t, strings.HasPrefix(message, "<134>1 "))
+			assert.Equal(t, event, strings.SplitN(message, " ", 8)[5])
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for the syslog message")
+		}
+	}
+}
+
+func TestAuditSinks_Syslog_InvalidConfig(t *testing.T) {
+	_, err := core_audit.NewSyslogSink(core_audit.SyslogSinkConfig{Network: "tls", Address: "localhost:514", Facility: "auth"})
+	assert.EqualError(t, err, "invalid syslog network tls, expecting udp or tcp")
+
+	_, err = core_audit.NewSyslogSink(core_audit.SyslogSinkConfig{Network: "udp", Address: "localhost:514", Facility: "unknown"})
+	assert.EqualError(t, err, "invalid syslog facility unknown")
+}
+
+type webhookTestServer struct {
+	server    *httptest.Server
+	mutex     sync.Mutex
+	requests  int
+	events    []lib.AuditEvent
+	batches   []int
+	responses []int
+}
+
+func newWebhookTestServer(t *testing.T, secret string, responses ...int) *webhookTestServer {
+	ws := &webhookTestServer{responses: responses}
+	ws.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ws.mutex.Lock()
+		defer ws.mutex.Unlock()
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		timestamp, err := strconv.ParseInt(r.Header.Get("X-Goiabada-Timestamp"), 10, 64)
+		assert.NoError(t, err)
+		assert.Equal(t, lib.SignWebhookPayload(secret, timestamp, body), r.Header.Get("X-Goiabada-Signature"))
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+
+		statusCode := http.StatusOK
+		if ws.requests < len(ws.responses) {
+			statusCode = ws.responses[ws.requests]
+		}
+		ws.requests++
+
+		if statusCode == http.StatusOK {
+			var payload struct {
+				Events []lib.AuditEvent `json:"events"`
+			}
+			err = json.Unmarshal(body, &payload)
+			assert.NoError(t, err)
+			ws.events = append(ws.events, payload.Events...)
+			ws.batches = append(ws.batches, len(payload.Events))
+		}
+		w.WriteHeader(statusCode)
+	}))
+	t.Cleanup(ws.server.Close)
+	return ws
+}
+
+func TestAuditSinks_Webhook_Batches(t *testing.T) {
+	secret := gofakeit.Password(true, true, true, false, false, 32)
+	ws := newWebhookTestServer(t, secret)
+
+	sink, err := core_audit.NewWebhookSink(core_audit.WebhookSinkConfig{
+		Url:           ws.server.URL,
+		Secret:        secret,
+		BatchSize:     3,
+		FlushInterval: time.Hour,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sink.Start()
+
+	requestIds := []string{}
+	for i := 0; i < 7; i++ {
+		auditEvent := newTestAuditEvent(constants.AuditAuthSuccessPwd)
+		requestIds = append(requestIds, auditEvent.RequestId)
+		sink.WriteAuditEvent(auditEvent)
+	}
+	sink.Close()
+
+	ws.mutex.Lock()
+	defer ws.mutex.Unlock()
+
+	// the last batch is delivered when the sink is closed
+	assert.Equal(t, []int{3, 3, 1}, ws.batches)
+	delivered := []string{}
+	for _, auditEvent := range ws.events {
+		delivered = append(delivered, auditEvent.RequestId)
+		assert.Equal(t, "10.0.0.1", auditEvent.IpAddress)
+		assert.NotEmpty(t, auditEvent.ActorSubject)
+	}
+	assert.Equal(t, requestIds, delivered)
+}
+
+func TestAuditSinks_Webhook_FlushInterval(t *testing.T) {
+	secret := gofakeit.Password(true, true, true, false, false, 32)
+	ws := newWebhookTestServer(t, secret)
+
+	sink, err := core_audit.NewWebhookSink(core_audit.WebhookSinkConfig{
+		Url:           ws.server.URL,
+		Secret:        secret,
+		BatchSize:     100,
+		FlushInterval: 100 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sink.Start()
+	defer sink.Close()
+
+	sink.WriteAuditEvent(newTestAuditEvent(constants.AuditAuthSuccessPwd))
+
+	delivered := 0
+	for i := 0; i < 50 && delivered == 0; i++ {
+		time.Sleep(100 * time.Millisecond)
+		ws.mutex.Lock()
+		delivered = len(ws.events)
+		ws.mutex.Unlock()
+	}
+	assert.Equal(t, 1, delivered)
+}
+
+func TestAuditSinks_Webhook_Retry(t *testing.T) {
+	secret := gofakeit.Password(true, true, true, false, false, 32)
+	ws := newWebhookTestServer(t, secret, http.StatusInternalServerError, http.StatusTooManyRequests)
+	deadLetterPath := filepath.Join(t.TempDir(), "dead-letter.log")
+
+	sink, err := core_audit.NewWebhookSink(core_audit.WebhookSinkConfig{
+		Url:            ws.server.URL,
+		Secret:         secret,
+		BatchSize:      10,
+		FlushInterval:  time.Hour,
+		MaxRetries:     2,
+		RetryInterval:  10 * time.Millisecond,
+		DeadLetterPath: deadLetterPath,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sink.Start()
+
+	sink.WriteAuditEvent(newTestAuditEvent(constants.AuditAuthSuccessPwd))
+	sink.Close()
+
+	ws.mutex.Lock()
+	defer ws.mutex.Unlock()
+	assert.Equal(t, 3, ws.requests)
+	assert.Len(t, ws.events, 1)
+
+	_, err = os.Stat(deadLetterPath)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestAuditSinks_Webhook_DeadLetter(t *testing.T) {
+	secret := gofakeit.Password(true, true, true, false, false, 32)
+	deadLetterPath := filepath.Join(t.TempDir(), "dead-letter.log")
+
+	testCases := []struct {
+		name      string
+		responses []int
+		requests  int
+	}{
+		// the client errors are not retried
+		{"client error", []int{http.StatusBadRequest}, 1},
+		{"retries exhausted", []int{http.StatusBadGateway, http.StatusBadGateway, http.StatusBadGateway}, 3},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			ws := newWebhookTestServer(t, secret, testCase.responses...)
+
+			sink, err := core_audit.NewWebhookSink(core_audit.WebhookSinkConfig{
+				Url:            ws.server.URL,
+				Secret:         secret,
+				BatchSize:      10,
+				FlushInterval:  time.Hour,
+				MaxRetries:     2,
+				RetryInterval:  10 * time.Millisecond,
+				DeadLetterPath: deadLetterPath,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			sink.Start()
+
+			auditEvent1 := newTestAuditEvent(constants.AuditAuthSuccessPwd)
+			auditEvent2 := newTestAuditEvent(constants.AuditAuthFailedPwd)
+			sink.WriteAuditEvent(auditEvent1)
+			sink.WriteAuditEvent(auditEvent2)
+			sink.Close()
+
+			ws.mutex.Lock()
+			assert.Equal(t, testCase.requests, ws.requests)
+			ws.mutex.Unlock()
+
+			auditEvents := readAuditEventsFile(t, deadLetterPath)
+			if !assert.GreaterOrEqual(t, len(auditEvents), 2) {
+				return
+			}
+			last := auditEvents[len(auditEvents)-2:]
+			assert.Equal(t, auditEvent1.RequestId, last[0].RequestId)
+			assert.Equal(t, auditEvent2.RequestId, last[1].RequestId)
+			assert.Equal(t, auditEvent2.Event, last[1].Event)
+		})
+	}
+}
+
+func TestAuditSinks_Webhook_RetriesDontBlockTheQueue(t *testing.T) {
+	secret := gofakeit.Password(true, true, true, false, false, 32)
+	deadLetterPath := filepath.Join(t.TempDir(), "dead-letter.log")
+
+	// the webhook doesn't respond until it's released
+	release := make(chan struct{})
+	mutex := sync.Mutex{}
+	delivered := []string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+
+		var payload struct {
+			Events []lib.AuditEvent `json:"events"`
+		}
+		err := json.NewDecoder(r.Body).Decode(&payload)
+		assert.NoError(t, err)
+
+		mutex.Lock()
+		defer mutex.Unlock()
+		for _, auditEvent := range payload.Events {
+			delivered = append(delivered, auditEvent.RequestId)
+		}
+	}))
+	defer server.Close()
+
+	sink, err := core_audit.NewWebhookSink(core_audit.WebhookSinkConfig{
+		Url:            server.URL,
+		Secret:         secret,
+		BatchSize:      1,
+		FlushInterval:  time.Hour,
+		Timeout:        time.Minute,
+		DeadLetterPath: deadLetterPath,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sink.Start()
+
+	// more events than the queue holds
+	const count = 1500
+	requestIds := []string{}
+	for i := 0; i < count; i++ {
+		auditEvent := newTestAuditEvent(constants.AuditAuthSuccessPwd)
+		requestIds = append(requestIds, auditEvent.RequestId)
+		sink.WriteAuditEvent(auditEvent)
+	}
+
+	// while the first batch is being delivered, the batches that can't wait go to the dead-letter file
+	deadLetters := 0
+	for i := 0; i < 50 && deadLetters < count-20; i++ {
+		time.Sleep(100 * time.Millisecond)
+		if _, err := os.Stat(deadLetterPath); err == nil {
+			deadLetters = len(readAuditEventsFile(t, deadLetterPath))
+		}
+	}
+	assert.GreaterOrEqual(t, deadLetters, count-20)
+
+	close(release)
+	sink.Close()
+
+	// no event was lost
+	mutex.Lock()
+	defer mutex.Unlock()
+	all := delivered
+	for _, auditEvent := range readAuditEventsFile(t, deadLetterPath) {
+		all = append(all, auditEvent.RequestId)
+	}
+	assert.ElementsMatch(t, requestIds, all)
+}
@@ -0,0 +1,301 @@
+package integrationtests
+
+import (
+	"database/sql"
+	"encoding/json"
+	"io"
+	"mime/quotedprintable"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/leodip/goiabada/internal/enums"
+	"github.com/leodip/goiabada/internal/lib"
+	"github.com/stretchr/testify/assert"
+)
+
+// setEmailLoginEnabled toggles email login on test-client-2, and restores it when the test finishes.
+func setEmailLoginEnabled(t *testing.T, enabled bool) {
+	client, err := database.GetClientByClientIdentifier(nil, "test-client-2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	original := client.EmailLoginEnabled
+	client.EmailLoginEnabled = enabled
+	err = database.UpdateClient(nil, client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		client.EmailLoginEnabled = original
+		_ = database.UpdateClient(nil, client)
+	})
+}
+
+// requestEmailLoginCode starts an authorization request and asks for a sign-in code to be sent to the email.
+func requestEmailLoginCode(t *testing.T, acrLevel enums.AcrLevel, email string) (*http.Client, *http.Response) {
+	httpClient := startAuthorization(t, acrLevel)
+	return httpClient, postEmailLoginEmail(t, httpClient, email)
+}
+
+// postEmailLoginEmail asks for a sign-in code to be sent to the email, in the login of the http client.
+func postEmailLoginEmail(t *testing.T, httpClient *http.Client, email string) *http.Response {
+	resp := getPage(t, httpClient, lib.GetBaseUrl()+"/auth/email")
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	return postForm(t, httpClient, lib.GetBaseUrl()+"/auth/email", url.Values{
+		"email":              {email},
+		"gorilla.csrf.Token": {getCsrfValue(t, resp)},
+	})
+}
+
+func getEmailMessages(t *testing.T, to string) *MailhogData {
+	resp, err := http.Get("http://mailhog:8025/api/v2/search?kind=to&query=" + to)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var mailhogData MailhogData
+	err = json.Unmarshal(body, &mailhogData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &mailhogData
+}
+
+// getEmailLoginCode returns the code and the token of the link from the last email sent to the user.
+func getEmailLoginCode(t *testing.T, to string) (string, string) {
+	mailhogData := getEmailMessages(t, to)
+	if len(mailhogData.Items) == 0 {
+		t.Fatal("expecting to find an email sent to " + to)
+	}
+
+	content := mailhogData.Items[0].Content
+	body := content.Body
+	if len(content.Headers.ContentTransferEncoding) > 0 &&
+		strings.EqualFold(content.Headers.ContentTransferEncoding[0], "quoted-printable") {
+		decoded, err := io.ReadAll(quotedprintable.NewReader(strings.NewReader(body)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		body = string(decoded)
+	}
+
+	codeMatch := regexp.MustCompile(`<strong>(\d{6})</strong>`).FindStringSubmatch(body)
+	tokenMatch := regexp.MustCompile(`/auth/email/verify\?token=([0-9A-Za-z._-]+)`).FindStringSubmatch(body)
+	if codeMatch == nil || tokenMatch == nil {
+		t.Fatal("unable to find the code in the email: " + body)
+	}
+	return codeMatch[1], tokenMatch[1]
+}
+
+func postEmailLoginCode(t *testing.T, httpClient *http.Client, code string) *http.Response {
+	resp := getPage(t, httpClient, lib.GetBaseUrl()+"/auth/pwd")
+	defer resp.Body.Close()
+
+	return postForm(t, httpClient, lib.GetBaseUrl()+"/auth/email/code", url.Values{
+		"code":               {code},
+		"gorilla.csrf.Token": {getCsrfValue(t, resp)},
+	})
+}
+
+func TestAuthEmail_Code(t *testing.T) {
+	setup()
+	setEmailLoginEnabled(t, true)
+
+	user := createPasskeyTestUser(t, "abc123")
+
+	httpClient := startAuthorization(t, enums.AcrLevel1)
+	resp := getPage(t, httpClient, lib.GetBaseUrl()+"/auth/pwd")
+	defer resp.Body.Close()
+	doc, err := goquery.NewDocumentFromReader(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 1, doc.Find("a[href='/auth/email']").Length())
+
+	httpClient, resp = requestEmailLoginCode(t, enums.AcrLevel1, user.Email)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	code, _ := getEmailLoginCode(t, user.Email)
+
+	// the code is stored hashed
+	dbUser, err := database.GetUserById(nil, user.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	codeHash, err := lib.HashString(code)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, codeHash, dbUser.EmailLoginCodeHash)
+
+	resp = postEmailLoginCode(t, httpClient, "000000x")
+	assertPwdLoginError(t, resp, "The code is invalid or has expired.")
+
+	resp = postEmailLoginCode(t, httpClient, code)
+	defer resp.Body.Close()
+	authCode := completeFederatedLogin(t, httpClient, resp)
+	assert.Equal(t, user.Id, authCode.User.Id)
+	assert.Equal(t, enums.AcrLevel1.String(), authCode.AcrLevel)
+	assert.Equal(t, "email", authCode.AuthMethods)
+
+	// the code can only be used once
+	dbUser, err = database.GetUserById(nil, user.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "", dbUser.EmailLoginCodeHash)
+	assert.Equal(t, "", dbUser.EmailLoginTokenHash)
+	assert.False(t, dbUser.EmailLoginCodeIssuedAt.Valid)
+}
+
+func TestAuthEmail_Link(t *testing.T) {
+	setup()
+	setEmailLoginEnabled(t, true)
+
+	user := createPasskeyTestUser(t, "abc123")
+
+	httpClient, resp := requestEmailLoginCode(t, enums.AcrLevel1, user.Email)
+	defer resp.Body.Close()
+
+	_, token := getEmailLoginCode(t, user.Email)
+
+	// the link must be opened in the browser where the code was requested
+	otherClient := startAuthorization(t, enums.AcrLevel1)
+	resp = getPage(t, otherClient, lib.GetBaseUrl()+"/auth/email/verify?token="+token)
+	assertPwdLoginError(t, resp, "The code is invalid or has expired.")
+
+	resp = getPage(t, httpClient, lib.GetBaseUrl()+"/auth/email/verify?token="+token)
+	defer resp.Body.Close()
+	authCode := completeFederatedLogin(t, httpClient, resp)
+	assert.Equal(t, user.Id, authCode.User.Id)
+	assert.Equal(t, "email", authCode.AuthMethods)
+}
+
+func TestAuthEmail_SecondFactor(t *testing.T) {
+	setup()
+	setEmailLoginEnabled(t, true)
+
+	user := createPasskeyTestUser(t, "abc123")
+
+	// the mandatory OTP level still requires the second factor
+	httpClient, resp := requestEmailLoginCode(t, enums.AcrLevel3, user.Email)
+	defer resp.Body.Close()
+
+	code, _ := getEmailLoginCode(t, user.Email)
+	resp = postEmailLoginCode(t, httpClient, code)
+	defer resp.Body.Close()
+	assertRedirect(t, resp, "/auth/otp")
+}
+
+func TestAuthEmail_TooManyAttempts(t *testing.T) {
+	setup()
+	setEmailLoginEnabled(t, true)
+	// the failed attempts also count for the login lockout (see TestLoginLockout_EmailCode), which is
+	// disabled here to test the limit of the code alone
+	setLoginLockoutSettings(t, 0, 0, 0)
+
+	user := createPasskeyTestUser(t, "abc123")
+
+	httpClient, resp := requestEmailLoginCode(t, enums.AcrLevel1, user.Email)
+	defer resp.Body.Close()
+
+	code, _ := getEmailLoginCode(t, user.Email)
+	for i := 0; i < 5; i++ {
+		resp = postEmailLoginCode(t, httpClient, "wrong")
+		assertPwdLoginError(t, resp, "The code is invalid or has expired.")
+	}
+
+	resp = postEmailLoginCode(t, httpClient, code)
+	assertPwdLoginError(t, resp, "The code is invalid or has expired.")
+
+	// sending a new code doesn't reset the attempts of the login
+	resp = postEmailLoginEmail(t, httpClient, user.Email)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	newCode, _ := getEmailLoginCode(t, user.Email)
+	assert.NotEqual(t, code, newCode)
+	resp = postEmailLoginCode(t, httpClient, newCode)
+	assertPwdLoginError(t, resp, "The code is invalid or has expired.")
+}
+
+func TestAuthEmail_Expired(t *testing.T) {
+	setup()
+	setEmailLoginEnabled(t, true)
+
+	user := createPasskeyTestUser(t, "abc123")
+
+	httpClient, resp := requestEmailLoginCode(t, enums.AcrLevel1, user.Email)
+	defer resp.Body.Close()
+
+	code, _ := getEmailLoginCode(t, user.Email)
+
+	dbUser, err := database.GetUserById(nil, user.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dbUser.EmailLoginCodeIssuedAt = sql.NullTime{Time: time.Now().UTC().Add(-11 * time.Minute), Valid: true}
+	err = database.UpdateUser(nil, dbUser)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp = postEmailLoginCode(t, httpClient, code)
+	assertPwdLoginError(t, resp, "The code is invalid or has expired.")
+}
+
+func TestAuthEmail_UnverifiedEmail(t *testing.T) {
+	setup()
+	setEmailLoginEnabled(t, true)
+
+	user := createPasskeyTestUser(t, "abc123")
+	user.EmailVerified = false
+	err := database.UpdateUser(nil, user)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the response doesn't reveal whether a code was sent
+	_, resp := requestEmailLoginCode(t, enums.AcrLevel1, user.Email)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	doc, err := goquery.NewDocumentFromReader(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 1, doc.Find("input[name='code']").Length())
+
+	assert.Equal(t, 0, len(getEmailMessages(t, user.Email).Items))
+}
+
+func TestAuthEmail_NotEnabledForClient(t *testing.T) {
+	setup()
+	setEmailLoginEnabled(t, false)
+
+	httpClient := startAuthorization(t, enums.AcrLevel1)
+	resp := getPage(t, httpClient, lib.GetBaseUrl()+"/auth/pwd")
+	defer resp.Body.Close()
+	doc, err := goquery.NewDocumentFromReader(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 0, doc.Find("a[href='/auth/email']").Length())
+
+	resp = getPage(t, httpClient, lib.GetBaseUrl()+"/auth/email")
+	defer resp.Body.Close()
+	assertRedirect(t, resp, "/auth/pwd")
+}
@@ -0,0 +1,525 @@
+package integrationtests
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/xml"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/brianvoe/gofakeit/v6"
+	"github.com/crewjam/saml"
+	"github.com/crewjam/saml/logger"
+	"github.com/leodip/goiabada/internal/constants"
+	"github.com/leodip/goiabada/internal/entities"
+	"github.com/leodip/goiabada/internal/enums"
+	"github.com/leodip/goiabada/internal/lib"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeSAMLProvider is a SAML identity provider used as a stand-in for an upstream
+// identity provider during the integration tests.
+type fakeSAMLProvider struct {
+	server *httptest.Server
+	idp    *saml.IdentityProvider
+
+	mu      sync.Mutex
+	session *saml.Session
+}
+
+func newFakeSAMLProvider(t *testing.T) *fakeSAMLProvider {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "fake-saml-idp"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(24 * time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+	}
+	certificateDER, err := x509.CreateCertificate(rand.Reader, template, template, &privateKey.PublicKey, privateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certificate, err := x509.ParseCertificate(certificateDER)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := &fakeSAMLProvider{}
+
+	mux := http.NewServeMux()
+	p.server = httptest.NewServer(mux)
+	t.Cleanup(p.server.Close)
+
+	metadataURL, _ := url.Parse(p.server.URL + "/metadata")
+	ssoURL, _ := url.Parse(p.server.URL + "/sso")
+
+	p.idp = &saml.IdentityProvider{
+		Key:                     privateKey,
+		Certificate:             certificate,
+		Logger:                  logger.DefaultLogger,
+		MetadataURL:             *metadataURL,
+		SSOURL:                  *ssoURL,
+		ServiceProviderProvider: p,
+		SessionProvider:         p,
+	}
+
+	mux.HandleFunc("/metadata", p.idp.ServeMetadata)
+	mux.HandleFunc("/sso", p.idp.ServeSSO)
+
+	return p
+}
+
+func (p *fakeSAMLProvider) metadataXML(t *testing.T) []byte {
+	metadataXML, err := xml.Marshal(p.idp.Metadata())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return metadataXML
+}
+
+func (p *fakeSAMLProvider) setSession(session *saml.Session) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.session = session
+}
+
+func (p *fakeSAMLProvider) GetSession(w http.ResponseWriter, r *http.Request, req *saml.IdpAuthnRequest) *saml.Session {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.session
+}
+
+// GetServiceProvider loads the service provider metadata published by goiabada.
+func (p *fakeSAMLProvider) GetServiceProvider(r *http.Request, serviceProviderID string) (*saml.EntityDescriptor, error) {
+	resp, err := http.Get(serviceProviderID)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var entityDescriptor saml.EntityDescriptor
+	err = xml.Unmarshal(body, &entityDescriptor)
+	if err != nil {
+		return nil, err
+	}
+	return &entityDescriptor, nil
+}
+
+func createSAMLIdentityProvider(t *testing.T, provider *fakeSAMLProvider, linkByEmail bool, autoProvision bool) *entities.IdentityProvider {
+	idp := &entities.IdentityProvider{
+		Name:                       "SAML " + gofakeit.LetterN(6),
+		IdentityProviderIdentifier: "saml-" + strings.ToLower(gofakeit.LetterN(10)),
+		Type:                       enums.IdentityProviderTypeSAML.String(),
+		Enabled:                    true,
+		Issuer:                     provider.idp.Metadata().EntityID,
+		SAMLMetadataXML:            provider.metadataXML(t),
+		SAMLNameIdFormat:           string(saml.PersistentNameIDFormat),
+		EmailClaim:                 "mail",
+		GivenNameClaim:             "givenName",
+		FamilyNameClaim:            "sn",
+		GroupsClaim:                "eduPersonAffiliation",
+		UserAttributesMapping:      "department=department\nurn:oid:1.3.6.1.4.1.5923.1.1.1.1=affiliation",
+		LinkByEmail:                linkByEmail,
+		AutoProvision:              autoProvision,
+	}
+	err := database.CreateIdentityProvider(nil, idp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = database.DeleteIdentityProvider(nil, idp.Id)
+	})
+	return idp
+}
+
+func newFakeSAMLSession(email string, groups []string) *saml.Session {
+	return &saml.Session{
+		ID:            gofakeit.UUID(),
+		CreateTime:    time.Now(),
+		ExpireTime:    time.Now().Add(time.Hour),
+		Index:         gofakeit.LetterN(10),
+		NameID:        gofakeit.UUID(),
+		NameIDFormat:  string(saml.PersistentNameIDFormat),
+		UserGivenName: "Joana",
+		UserSurname:   "Pereira",
+		Groups:        groups,
+		CustomAttributes: []saml.Attribute{
+			{
+				Name:       "mail",
+				NameFormat: "urn:oasis:names:tc:SAML:2.0:attrname-format:basic",
+				Values:     []saml.AttributeValue{{Type: "xs:string", Value: email}},
+			},
+			{
+				Name:       "department",
+				NameFormat: "urn:oasis:names:tc:SAML:2.0:attrname-format:basic",
+				Values:     []saml.AttributeValue{{Type: "xs:string", Value: "Finance"}},
+			},
+		},
+	}
+}
+
+// startSAMLLogin starts an authorization request at goiabada and follows the flow through the
+// upstream SAML identity provider, up to the form that posts the SAML response to the assertion consumer service.
+func startSAMLLogin(t *testing.T, idp *entities.IdentityProvider) (*http.Client, url.Values) {
+	destUrl := lib.GetBaseUrl() +
+		"/auth/authorize/?client_id=test-client-2&redirect_uri=https://goiabada-test-client:8090/callback.html&response_type=code" +
+		"&code_challenge_method=S256&code_challenge=bQCdz4Hkhb3ctpajAwCCN899mNNfQGmRvMwruYT1Y9Y" +
+		"&response_mode=query&scope=openid%20profile%20email&state=a1b2c3&nonce=m9n8b7" +
+		"&acr_values=" + enums.AcrLevel1.String()
+
+	httpClient := createHttpClient(&createHttpClientInput{
+		T: t,
+	})
+
+	resp, err := httpClient.Get(destUrl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	assertRedirect(t, resp, "/auth/pwd")
+
+	resp = getPage(t, httpClient, lib.GetBaseUrl()+"/auth/pwd")
+	defer resp.Body.Close()
+
+	doc, err := goquery.NewDocumentFromReader(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 1, doc.Find("a[href='/auth/federated/"+idp.IdentityProviderIdentifier+"']").Length())
+
+	resp = getPage(t, httpClient, lib.GetBaseUrl()+"/auth/federated/"+idp.IdentityProviderIdentifier)
+	defer resp.Body.Close()
+	assertRedirect(t, resp, "/sso")
+
+	resp = getPage(t, httpClient, resp.Header.Get("Location"))
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	doc, err = goquery.NewDocumentFromReader(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	form := doc.Find("form#SAMLResponseForm")
+	action, _ := form.Attr("action")
+	assert.Equal(t, lib.GetBaseUrl()+"/auth/federated/"+idp.IdentityProviderIdentifier+"/saml/acs", action)
+
+	samlResponse, _ := form.Find("input[name='SAMLResponse']").Attr("value")
+	relayState, _ := form.Find("input[name='RelayState']").Attr("value")
+
+	return httpClient, url.Values{
+		"SAMLResponse": {samlResponse},
+		"RelayState":   {relayState},
+	}
+}
+
+// postSAMLResponse posts the SAML response to the assertion consumer service, and then submits the
+// intermediate form (which posts it again from goiabada's origin) to the callback endpoint.
+func postSAMLResponse(t *testing.T, httpClient *http.Client, idp *entities.IdentityProvider, formData url.Values) *http.Response {
+	resp, err := httpClient.PostForm(lib.GetBaseUrl()+"/auth/federated/"+idp.IdentityProviderIdentifier+"/saml/acs", formData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	doc, err := goquery.NewDocumentFromReader(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	form := doc.Find("form#samlResponseForm")
+	action, _ := form.Attr("action")
+	assert.Equal(t, lib.GetBaseUrl()+"/auth/federated/"+idp.IdentityProviderIdentifier+"/callback", action)
+
+	samlResponse, _ := form.Find("input[name='SAMLResponse']").Attr("value")
+	relayState, _ := form.Find("input[name='RelayState']").Attr("value")
+
+	resp, err = httpClient.PostForm(action, url.Values{
+		"SAMLResponse": {samlResponse},
+		"RelayState":   {relayState},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp
+}
+
+func TestAuthFederatedSAML_AutoProvision(t *testing.T) {
+	setup()
+
+	provider := newFakeSAMLProvider(t)
+	idp := createSAMLIdentityProvider(t, provider, false, true)
+
+	group := &entities.Group{
+		GroupIdentifier: "saml-group-" + strings.ToLower(gofakeit.LetterN(8)),
+		Description:     "Group asserted by the SAML identity provider",
+	}
+	err := database.CreateGroup(nil, group)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = database.DeleteGroup(nil, group.Id)
+	})
+
+	// only the mapped groups are synchronized, and never a group granting authserver permissions
+	admins := createImportTestGroup(t)
+	err = database.CreateGroupPermission(nil, &entities.GroupPermission{
+		GroupId:      admins.Id,
+		PermissionId: getAuthServerPermission(t, constants.AdminWebsitePermissionIdentifier).Id,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	unmapped := createImportTestGroup(t)
+	idp.GroupsMapping = "staff=" + group.GroupIdentifier + "\nadmins=" + admins.GroupIdentifier +
+		"\nstaff=group-that-does-not-exist"
+	err = database.UpdateIdentityProvider(nil, idp)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	email := strings.ToLower(gofakeit.Email())
+	session := newFakeSAMLSession(email, []string{"staff", "admins", unmapped.GroupIdentifier})
+	provider.setSession(session)
+
+	httpClient, formData := startSAMLLogin(t, idp)
+	resp := postSAMLResponse(t, httpClient, idp, formData)
+	defer resp.Body.Close()
+
+	code := completeFederatedLogin(t, httpClient, resp)
+	t.Cleanup(func() {
+		_ = database.DeleteUser(nil, code.User.Id)
+	})
+
+	assert.Equal(t, enums.AuthMethodFederated.String(), code.AuthMethods)
+	assert.Equal(t, email, code.User.Email)
+	assert.False(t, code.User.EmailVerified)
+	assert.Equal(t, "Joana", code.User.GivenName)
+	assert.Equal(t, "Pereira", code.User.FamilyName)
+
+	federatedIdentity, err := database.GetUserFederatedIdentityByIdentityProviderIdAndSubject(nil, idp.Id, session.NameID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.NotNil(t, federatedIdentity)
+	assert.Equal(t, code.User.Id, federatedIdentity.UserId)
+
+	userAttributes, err := database.GetUserAttributesByUserId(nil, code.User.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	attributes := map[string]string{}
+	for _, userAttribute := range userAttributes {
+		attributes[userAttribute.Key] = userAttribute.Value
+	}
+	assert.Equal(t, "Finance", attributes["department"])
+	assert.Equal(t, "staff admins "+unmapped.GroupIdentifier, attributes["affiliation"])
+
+	userGroups, err := database.GetUserGroupsByUserId(nil, code.User.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(t, userGroups, 1)
+	assert.Equal(t, group.Id, userGroups[0].GroupId)
+
+	// a second login with the same NameID reuses the linked account
+
+	httpClient, formData = startSAMLLogin(t, idp)
+	resp = postSAMLResponse(t, httpClient, idp, formData)
+	defer resp.Body.Close()
+
+	code2 := completeFederatedLogin(t, httpClient, resp)
+	assert.Equal(t, code.User.Id, code2.User.Id)
+}
+
+func TestAuthFederatedSAML_LinkByEmail(t *testing.T) {
+	setup()
+
+	provider := newFakeSAMLProvider(t)
+	idp := createSAMLIdentityProvider(t, provider, true, false)
+
+	user := createFederatedTestUser(t)
+	provider.setSession(newFakeSAMLSession(user.Email, nil))
+
+	// the emails asserted by the identity provider are not trusted yet
+	httpClient, formData := startSAMLLogin(t, idp)
+	resp := postSAMLResponse(t, httpClient, idp, formData)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	doc, err := goquery.NewDocumentFromReader(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Contains(t, doc.Find("p.text-error").Text(), "not linked to this identity provider")
+
+	federatedIdentities, err := database.GetUserFederatedIdentitiesByUserId(nil, user.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(t, federatedIdentities, 0)
+
+	idp.TrustEmail = true
+	err = database.UpdateIdentityProvider(nil, idp)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	httpClient, formData = startSAMLLogin(t, idp)
+	resp = postSAMLResponse(t, httpClient, idp, formData)
+	defer resp.Body.Close()
+
+	code := completeFederatedLogin(t, httpClient, resp)
+	assert.Equal(t, user.Id, code.User.Id)
+
+	federatedIdentities, err = database.GetUserFederatedIdentitiesByUserId(nil, user.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(t, federatedIdentities, 1)
+}
+
+func TestAuthFederatedSAML_TamperedResponse(t *testing.T) {
+	setup()
+
+	provider := newFakeSAMLProvider(t)
+	idp := createSAMLIdentityProvider(t, provider, false, true)
+
+	email := strings.ToLower(gofakeit.Email())
+	provider.setSession(newFakeSAMLSession(email, nil))
+
+	httpClient, formData := startSAMLLogin(t, idp)
+
+	responseXML, err := base64.StdEncoding.DecodeString(formData.Get("SAMLResponse"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tamperedXML := strings.ReplaceAll(string(responseXML), email, "attacker@example.com")
+	assert.NotEqual(t, string(responseXML), tamperedXML)
+	formData.Set("SAMLResponse", base64.StdEncoding.EncodeToString([]byte(tamperedXML)))
+
+	resp := postSAMLResponse(t, httpClient, idp, formData)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	doc, err := goquery.NewDocumentFromReader(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "Authentication with "+idp.Name+" failed.", strings.TrimSpace(doc.Find("p.text-error").Text()))
+
+	user, err := database.GetUserByEmail(nil, "attacker@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Nil(t, user)
+}
+
+func TestAuthFederatedSAML_ServiceProviderMetadata(t *testing.T) {
+	setup()
+
+	provider := newFakeSAMLProvider(t)
+	idp := createSAMLIdentityProvider(t, provider, false, true)
+
+	metadataURL := lib.GetBaseUrl() + "/auth/federated/" + idp.IdentityProviderIdentifier + "/saml/metadata"
+	entityDescriptor, err := provider.GetServiceProvider(nil, metadataURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, metadataURL, entityDescriptor.EntityID)
+	assert.Len(t, entityDescriptor.SPSSODescriptors, 1)
+	assert.Equal(t, lib.GetBaseUrl()+"/auth/federated/"+idp.IdentityProviderIdentifier+"/saml/acs",
+		entityDescriptor.SPSSODescriptors[0].AssertionConsumerServices[0].Location)
+}
+
+func TestAdminIdentityProviderNew_Post_SAML(t *testing.T) {
+	setup()
+
+	provider := newFakeSAMLProvider(t)
+
+	httpClient := loginToAdminArea(t, "admin@example.com", "changeme")
+
+	destUrl := lib.GetBaseUrl() + "/admin/identity-providers/new"
+	resp, err := httpClient.Get(destUrl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	csrf := getCsrfValue(t, resp)
+
+	identifier := "saml-" + strings.ToLower(gofakeit.LetterN(10))
+	resp, err = httpClient.PostForm(destUrl, url.Values{
+		"type":                       {enums.IdentityProviderTypeSAML.String()},
+		"name":                       {"Parent company"},
+		"identityProviderIdentifier": {identifier},
+		"samlMetadataURL":            {provider.server.URL + "/metadata"},
+		"gorilla.csrf.Token":         {csrf},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	idp, err := database.GetIdentityProviderByIdentifier(nil, identifier)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !assert.NotNil(t, idp) {
+		return
+	}
+	t.Cleanup(func() {
+		_ = database.DeleteIdentityProvider(nil, idp.Id)
+	})
+
+	assertRedirect(t, resp, "/admin/identity-providers/"+strconv.FormatInt(idp.Id, 10)+"/settings")
+	assert.Equal(t, enums.IdentityProviderTypeSAML.String(), idp.Type)
+	assert.Equal(t, provider.idp.Metadata().EntityID, idp.Issuer)
+	assert.False(t, idp.Enabled)
+	assert.NotEmpty(t, idp.SAMLMetadataXML)
+
+	// invalid metadata is rejected
+
+	resp, err = httpClient.PostForm(destUrl, url.Values{
+		"type":                       {enums.IdentityProviderTypeSAML.String()},
+		"name":                       {"Invalid"},
+		"identityProviderIdentifier": {"saml-" + strings.ToLower(gofakeit.LetterN(10))},
+		"samlMetadataXML":            {"<EntityDescriptor xmlns=\"urn:oasis:names:tc:SAML:2.0:metadata\" entityID=\"x\"></EntityDescriptor>"},
+		"gorilla.csrf.Token":         {csrf},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	doc, err := goquery.NewDocumentFromReader(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Contains(t, doc.Find("div.text-error p").Text(), "The metadata is invalid")
+}
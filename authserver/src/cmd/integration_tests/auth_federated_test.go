@@ -0,0 +1,577 @@
+package integrationtests
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/brianvoe/gofakeit/v6"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/leodip/goiabada/internal/entities"
+	"github.com/leodip/goiabada/internal/enums"
+	"github.com/leodip/goiabada/internal/lib"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeOIDCProvider is a minimal upstream OpenID Connect provider used as a stand-in
+// for an external identity provider during the integration tests.
+type fakeOIDCProvider struct {
+	server       *httptest.Server
+	privateKey   *rsa.PrivateKey
+	clientId     string
+	clientSecret string
+
+	mu      sync.Mutex
+	claims  jwt.MapClaims
+	pending map[string]fakeOIDCPendingCode
+}
+
+type fakeOIDCPendingCode struct {
+	nonce         string
+	codeChallenge string
+	redirectURI   string
+	claims        jwt.MapClaims
+}
+
+func newFakeOIDCProvider(t *testing.T) *fakeOIDCProvider {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := &fakeOIDCProvider{
+		privateKey:   privateKey,
+		clientId:     "upstream-" + gofakeit.LetterN(8),
+		clientSecret: gofakeit.LetterN(32),
+		pending:      map[string]fakeOIDCPendingCode{},
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", p.handleDiscovery)
+	mux.HandleFunc("/authorize", p.handleAuthorize)
+	mux.HandleFunc("/token", p.handleToken)
+	mux.HandleFunc("/certs", p.handleCerts)
+	p.server = httptest.NewServer(mux)
+	t.Cleanup(p.server.Close)
+
+	return p
+}
+
+func (p *fakeOIDCProvider) setClaims(claims jwt.MapClaims) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.claims = claims
+}
+
+func (p *fakeOIDCProvider) handleDiscovery(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]string{
+		"issuer":                 p.server.URL,
+		"authorization_endpoint": p.server.URL + "/authorize",
+		"token_endpoint":         p.server.URL + "/token",
+		"jwks_uri":               p.server.URL + "/certs",
+	})
+}
+
+func (p *fakeOIDCProvider) handleAuthorize(w http.ResponseWriter, r *http.Request) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	query := r.URL.Query()
+	code := gofakeit.LetterN(32)
+	p.pending[code] = fakeOIDCPendingCode{
+		nonce:         query.Get("nonce"),
+		codeChallenge: query.Get("code_challenge"),
+		redirectURI:   query.Get("redirect_uri"),
+		claims:        p.claims,
+	}
+
+	redirectURI, _ := url.Parse(query.Get("redirect_uri"))
+	values := redirectURI.Query()
+	values.Set("code", code)
+	values.Set("state", query.Get("state"))
+	redirectURI.RawQuery = values.Encode()
+	http.Redirect(w, r, redirectURI.String(), http.StatusFound)
+}
+
+func (p *fakeOIDCProvider) handleToken(w http.ResponseWriter, r *http.Request) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	_ = r.ParseForm()
+	pendingCode, ok := p.pending[r.PostForm.Get("code")]
+	delete(p.pending, r.PostForm.Get("code"))
+
+	verifierHash := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	codeChallenge := base64.RawURLEncoding.EncodeToString(verifierHash[:])
+
+	if !ok || r.PostForm.Get("client_id") != p.clientId || r.PostForm.Get("client_secret") != p.clientSecret ||
+		r.PostForm.Get("redirect_uri") != pendingCode.redirectURI || codeChallenge != pendingCode.codeChallenge {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"error":"invalid_grant"}`))
+		return
+	}
+
+	claims := jwt.MapClaims{
+		"iss":   p.server.URL,
+		"aud":   p.clientId,
+		"iat":   time.Now().Unix(),
+		"exp":   time.Now().Add(5 * time.Minute).Unix(),
+		"nonce": pendingCode.nonce,
+	}
+	for k, v := range pendingCode.claims {
+		claims[k] = v
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = "test-key"
+	idToken, err := token.SignedString(p.privateKey)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]string{
+		"access_token": gofakeit.LetterN(32),
+		"token_type":   "Bearer",
+		"id_token":     idToken,
+	})
+}
+
+func (p *fakeOIDCProvider) handleCerts(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"keys": []map[string]string{
+			{
+				"kid": "test-key",
+				"kty": "RSA",
+				"use": "sig",
+				"alg": "RS256",
+				"n":   base64.RawURLEncoding.EncodeToString(p.privateKey.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(p.privateKey.E)).Bytes()),
+			},
+		},
+	})
+}
+
+// createFederatedTestUser creates a user without OTP that is removed at the end of the test,
+// so it does not interfere with the tests that pick the last user by OTP state.
+func createFederatedTestUser(t *testing.T) *entities.User {
+	user := &entities.User{
+		Subject:       uuid.New(),
+		Email:         strings.ToLower(gofakeit.Email()),
+		EmailVerified: true,
+		Enabled:       true,
+	}
+	err := database.CreateUser(nil, user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = database.DeleteUser(nil, user.Id)
+	})
+	return user
+}
+
+func createFederatedIdentityProvider(t *testing.T, provider *fakeOIDCProvider, linkByEmail bool, autoProvision bool) *entities.IdentityProvider {
+	settings, err := database.GetSettingsById(nil, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	clientSecretEncrypted, err := lib.EncryptText(provider.clientSecret, settings.AESEncryptionKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	idp := &entities.IdentityProvider{
+		Name:                       "Upstream " + gofakeit.LetterN(6),
+		IdentityProviderIdentifier: "idp-" + strings.ToLower(gofakeit.LetterN(10)),
+		Type:                       enums.IdentityProviderTypeOIDC.String(),
+		Enabled:                    true,
+		Issuer:                     provider.server.URL,
+		ClientIdentifier:           provider.clientId,
+		ClientSecretEncrypted:      clientSecretEncrypted,
+		Scopes:                     "openid profile email",
+		EmailClaim:                 "email",
+		GivenNameClaim:             "given_name",
+		FamilyNameClaim:            "family_name",
+		LinkByEmail:                linkByEmail,
+		AutoProvision:              autoProvision,
+	}
+	err = database.CreateIdentityProvider(nil, idp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = database.DeleteIdentityProvider(nil, idp.Id)
+	})
+	return idp
+}
+
+// startFederatedLogin starts an authorization request at goiabada and follows the flow through the
+// upstream provider, returning the response of the federated callback.
+func startFederatedLogin(t *testing.T, idp *entities.IdentityProvider) (*http.Client, *http.Response) {
+	destUrl := lib.GetBaseUrl() +
+		"/auth/authorize/?client_id=test-client-2&redirect_uri=https://goiabada-test-client:8090/callback.html&response_type=code" +
+		"&code_challenge_method=S256&code_challenge=bQCdz4Hkhb3ctpajAwCCN899mNNfQGmRvMwruYT1Y9Y" +
+		"&response_mode=query&scope=openid%20profile%20email&state=a1b2c3&nonce=m9n8b7" +
+		"&acr_values=" + enums.AcrLevel1.String()
+
+	httpClient := createHttpClient(&createHttpClientInput{
+		T: t,
+	})
+
+	resp, err := httpClient.Get(destUrl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	assertRedirect(t, resp, "/auth/pwd")
+
+	resp = getPage(t, httpClient, lib.GetBaseUrl()+"/auth/pwd")
+	defer resp.Body.Close()
+
+	doc, err := goquery.NewDocumentFromReader(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	button := doc.Find("a[href='/auth/federated/" + idp.IdentityProviderIdentifier + "']")
+	assert.Equal(t, 1, button.Length())
+	assert.Contains(t, button.Text(), "Sign in with "+idp.Name)
+
+	resp = getPage(t, httpClient, lib.GetBaseUrl()+"/auth/federated/"+idp.IdentityProviderIdentifier)
+	defer resp.Body.Close()
+	assertRedirect(t, resp, "/authorize")
+
+	upstreamURL, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, idp.ClientIdentifier, upstreamURL.Query().Get("client_id"))
+	assert.Equal(t, "S256", upstreamURL.Query().Get("code_challenge_method"))
+	assert.Equal(t, lib.GetBaseUrl()+"/auth/federated/"+idp.IdentityProviderIdentifier+"/callback",
+		upstreamURL.Query().Get("redirect_uri"))
+
+	resp = getPage(t, httpClient, upstreamURL.String())
+	defer resp.Body.Close()
+	assertRedirect(t, resp, "/auth/federated/"+idp.IdentityProviderIdentifier+"/callback")
+
+	resp = getPage(t, httpClient, resp.Header.Get("Location"))
+	return httpClient, resp
+}
+
+func completeFederatedLogin(t *testing.T, httpClient *http.Client, resp *http.Response) *entities.Code {
+	assertRedirect(t, resp, "/auth/consent")
+	resp = getPage(t, httpClient, lib.GetBaseUrl()+"/auth/consent")
+	defer resp.Body.Close()
+
+	assertRedirect(t, resp, "/callback.html")
+	codeVal, _ := getCodeAndStateFromUrl(t, resp)
+
+	codeHash, err := lib.HashString(codeVal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	code, err := database.GetCodeByCodeHash(nil, codeHash, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = database.CodeLoadUser(nil, code)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return code
+}
+
+func TestAuthFederated_AutoProvision(t *testing.T) {
+	setup()
+
+	provider := newFakeOIDCProvider(t)
+	idp := createFederatedIdentityProvider(t, provider, false, true)
+
+	subject := gofakeit.UUID()
+	email := strings.ToLower(gofakeit.Email())
+	provider.setClaims(jwt.MapClaims{
+		"sub":            subject,
+		"email":          email,
+		"email_verified": true,
+		"given_name":     "Maria",
+		"family_name":    "Silva",
+	})
+
+	httpClient, resp := startFederatedLogin(t, idp)
+	defer resp.Body.Close()
+	code := completeFederatedLogin(t, httpClient, resp)
+	t.Cleanup(func() {
+		_ = database.DeleteUser(nil, code.User.Id)
+	})
+
+	assert.Equal(t, enums.AuthMethodFederated.String(), code.AuthMethods)
+	assert.Equal(t, enums.AcrLevel1.String(), code.AcrLevel)
+	assert.Equal(t, email, code.User.Email)
+	assert.True(t, code.User.EmailVerified)
+	assert.Equal(t, "Maria", code.User.GivenName)
+	assert.Equal(t, "Silva", code.User.FamilyName)
+
+	federatedIdentity, err := database.GetUserFederatedIdentityByIdentityProviderIdAndSubject(nil, idp.Id, subject)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.NotNil(t, federatedIdentity)
+	assert.Equal(t, code.User.Id, federatedIdentity.UserId)
+	assert.True(t, federatedIdentity.LastLoginAt.Valid)
+
+	// a second login with the same subject reuses the linked account
+
+	provider.setClaims(jwt.MapClaims{
+		"sub":   subject,
+		"email": strings.ToLower(gofakeit.Email()),
+	})
+
+	httpClient, resp = startFederatedLogin(t, idp)
+	defer resp.Body.Close()
+	code2 := completeFederatedLogin(t, httpClient, resp)
+
+	assert.Equal(t, code.User.Id, code2.User.Id)
+	assert.Equal(t, email, code2.User.Email)
+}
+
+func TestAuthFederated_LinkByVerifiedEmail(t *testing.T) {
+	setup()
+
+	provider := newFakeOIDCProvider(t)
+	idp := createFederatedIdentityProvider(t, provider, true, false)
+
+	user := createFederatedTestUser(t)
+
+	subject := gofakeit.UUID()
+	provider.setClaims(jwt.MapClaims{
+		"sub":            subject,
+		"email":          strings.ToUpper(user.Email),
+		"email_verified": true,
+	})
+
+	httpClient, resp := startFederatedLogin(t, idp)
+	defer resp.Body.Close()
+	code := completeFederatedLogin(t, httpClient, resp)
+
+	assert.Equal(t, user.Id, code.User.Id)
+	assert.Equal(t, enums.AuthMethodFederated.String(), code.AuthMethods)
+
+	federatedIdentities, err := database.GetUserFederatedIdentitiesByUserId(nil, user.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(t, federatedIdentities, 1)
+	assert.Equal(t, subject, federatedIdentities[0].Subject)
+	assert.Equal(t, idp.Id, federatedIdentities[0].IdentityProviderId)
+}
+
+func TestAuthFederated_UnverifiedEmailIsNotLinked(t *testing.T) {
+	setup()
+
+	provider := newFakeOIDCProvider(t)
+	idp := createFederatedIdentityProvider(t, provider, true, true)
+
+	user := createFederatedTestUser(t)
+
+	provider.setClaims(jwt.MapClaims{
+		"sub":            gofakeit.UUID(),
+		"email":          user.Email,
+		"email_verified": false,
+	})
+
+	_, resp := startFederatedLogin(t, idp)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	doc, err := goquery.NewDocumentFromReader(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Contains(t, doc.Find("p.text-error").Text(), "not linked to this identity provider")
+
+	federatedIdentities, err := database.GetUserFederatedIdentitiesByUserId(nil, user.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(t, federatedIdentities, 0)
+}
+
+func TestAuthFederated_DisabledUserIsNotLinkedOrSynchronized(t *testing.T) {
+	setup()
+
+	provider := newFakeOIDCProvider(t)
+	idp := createFederatedIdentityProvider(t, provider, true, false)
+
+	user := createFederatedTestUser(t)
+	user.Enabled = false
+	err := database.UpdateUser(nil, user)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertDisabled := func(resp *http.Response) {
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		doc, err := goquery.NewDocumentFromReader(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Contains(t, doc.Find("p.text-error").Text(), "Your account is disabled.")
+	}
+
+	// a disabled user is not linked by email
+	provider.setClaims(jwt.MapClaims{
+		"sub":            gofakeit.UUID(),
+		"email":          user.Email,
+		"email_verified": true,
+	})
+
+	_, resp := startFederatedLogin(t, idp)
+	defer resp.Body.Close()
+	assertDisabled(resp)
+
+	federatedIdentities, err := database.GetUserFederatedIdentitiesByUserId(nil, user.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(t, federatedIdentities, 0)
+
+	// nor is the profile of a linked user synchronized
+	subject := gofakeit.UUID()
+	err = database.CreateUserFederatedIdentity(nil, &entities.UserFederatedIdentity{
+		UserId:             user.Id,
+		IdentityProviderId: idp.Id,
+		Subject:            subject,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	provider.setClaims(jwt.MapClaims{
+		"sub":         subject,
+		"email":       user.Email,
+		"given_name":  "Changed",
+		"family_name": "Changed",
+	})
+
+	_, resp = startFederatedLogin(t, idp)
+	defer resp.Body.Close()
+	assertDisabled(resp)
+
+	dbUser := getDbUser(t, user.Id)
+	assert.Equal(t, user.GivenName, dbUser.GivenName)
+	assert.Equal(t, user.FamilyName, dbUser.FamilyName)
+
+	federatedIdentity, err := database.GetUserFederatedIdentityByIdentityProviderIdAndSubject(nil, idp.Id, subject)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.False(t, federatedIdentity.LastLoginAt.Valid)
+}
+
+func TestAuthFederated_NoAccountAndProvisioningDisabled(t *testing.T) {
+	setup()
+
+	provider := newFakeOIDCProvider(t)
+	idp := createFederatedIdentityProvider(t, provider, true, false)
+
+	email := strings.ToLower(gofakeit.Email())
+	provider.setClaims(jwt.MapClaims{
+		"sub":            gofakeit.UUID(),
+		"email":          email,
+		"email_verified": true,
+	})
+
+	_, resp := startFederatedLogin(t, idp)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	doc, err := goquery.NewDocumentFromReader(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.NotEmpty(t, doc.Find("p.text-error").Text())
+
+	user, err := database.GetUserByEmail(nil, email)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Nil(t, user)
+}
+
+func TestAuthFederated_InvalidClientSecret(t *testing.T) {
+	setup()
+
+	provider := newFakeOIDCProvider(t)
+	idp := createFederatedIdentityProvider(t, provider, false, true)
+	provider.clientSecret = "wrong-" + provider.clientSecret
+
+	provider.setClaims(jwt.MapClaims{
+		"sub":            gofakeit.UUID(),
+		"email":          strings.ToLower(gofakeit.Email()),
+		"email_verified": true,
+	})
+
+	_, resp := startFederatedLogin(t, idp)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	doc, err := goquery.NewDocumentFromReader(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, fmt.Sprintf("Authentication with %v failed.", idp.Name), strings.TrimSpace(doc.Find("p.text-error").Text()))
+}
+
+func TestAuthFederated_DisabledIdentityProviderIsNotOffered(t *testing.T) {
+	setup()
+
+	provider := newFakeOIDCProvider(t)
+	idp := createFederatedIdentityProvider(t, provider, false, true)
+	idp.Enabled = false
+	err := database.UpdateIdentityProvider(nil, idp)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	httpClient := createHttpClient(&createHttpClientInput{
+		T: t,
+	})
+	resp, err := httpClient.Get(lib.GetBaseUrl() +
+		"/auth/authorize/?client_id=test-client-2&redirect_uri=https://goiabada-test-client:8090/callback.html&response_type=code" +
+		"&code_challenge_method=S256&code_challenge=bQCdz4Hkhb3ctpajAwCCN899mNNfQGmRvMwruYT1Y9Y" +
+		"&response_mode=query&scope=openid&state=a1b2c3&nonce=m9n8b7")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	assertRedirect(t, resp, "/auth/pwd")
+
+	resp = getPage(t, httpClient, lib.GetBaseUrl()+"/auth/pwd")
+	defer resp.Body.Close()
+
+	doc, err := goquery.NewDocumentFromReader(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 0, doc.Find("a[href='/auth/federated/"+idp.IdentityProviderIdentifier+"']").Length())
+
+	resp = getPage(t, httpClient, lib.GetBaseUrl()+"/auth/federated/"+idp.IdentityProviderIdentifier)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+}
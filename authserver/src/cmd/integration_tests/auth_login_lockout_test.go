@@ -0,0 +1,303 @@
+package integrationtests
+
+import (
+	"database/sql"
+	"net/http"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/brianvoe/gofakeit/v6"
+	"github.com/leodip/goiabada/internal/entities"
+	"github.com/leodip/goiabada/internal/enums"
+	"github.com/leodip/goiabada/internal/lib"
+	"github.com/pquerna/otp/totp"
+	"github.com/stretchr/testify/assert"
+)
+
+// clearFailedLoginsFromThisIp forgets the failed login attempts made from the IP address of the tests.
+func clearFailedLoginsFromThisIp(t *testing.T) {
+	resp, err := http.Get(lib.GetBaseUrl() + "/test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	ipAddress := unmarshalToMap(t, resp)["ipWithoutPort"].(string)
+
+	failedLoginIp, err := database.GetFailedLoginIpByIpAddress(nil, ipAddress)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if failedLoginIp != nil {
+		err = database.DeleteFailedLoginIp(nil, failedLoginIp.Id)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// setLoginLockoutSettings changes the login security settings, and restores them when the test finishes.
+func setLoginLockoutSettings(t *testing.T, delayAfter int, lockoutAfter int, ipLockoutAfter int) {
+	clearFailedLoginsFromThisIp(t)
+
+	settings, err := database.GetSettingsById(nil, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	original := *settings
+
+	settings.LoginDelayAfterFailedAttempts = delayAfter
+	settings.LoginLockoutAfterFailedAttempts = lockoutAfter
+	settings.LoginIpLockoutAfterFailedAttempts = ipLockoutAfter
+	settings.LoginLockoutDurationInSeconds = 900
+	settings.SMTPEnabled = true
+	err = database.UpdateSettings(nil, settings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = database.UpdateSettings(nil, &original)
+		clearFailedLoginsFromThisIp(t)
+	})
+}
+
+func postPassword(t *testing.T, email string, password string) (*http.Client, *http.Response) {
+	httpClient := startAuthorization(t, enums.AcrLevel1)
+	resp := getPage(t, httpClient, lib.GetBaseUrl()+"/auth/pwd")
+	defer resp.Body.Close()
+	return httpClient, authenticateWithPassword(t, httpClient, email, password, getCsrfValue(t, resp))
+}
+
+func getDbUser(t *testing.T, userId int64) *entities.User {
+	user, err := database.GetUserById(nil, userId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return user
+}
+
+func TestLoginLockout_ProgressiveDelay(t *testing.T) {
+	setup()
+	setLoginLockoutSettings(t, 2, 0, 0)
+
+	user := createPasskeyTestUser(t, "abc123")
+
+	for i := 0; i < 2; i++ {
+		_, resp := postPassword(t, user.Email, "wrong-password")
+		assertPwdLoginError(t, resp, "Authentication failed.")
+	}
+	assert.Equal(t, 2, getDbUser(t, user.Id).FailedLoginAttempts)
+
+	// the correct password is not even verified during the delay
+	_, resp := postPassword(t, user.Email, "abc123")
+	assertPwdLoginError(t, resp, "Please wait")
+
+	dbUser := getDbUser(t, user.Id)
+	dbUser.LastFailedLoginAt = sql.NullTime{Time: time.Now().UTC().Add(-time.Minute), Valid: true}
+	err := database.UpdateUser(nil, dbUser)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	httpClient, resp := postPassword(t, user.Email, "abc123")
+	defer resp.Body.Close()
+	authCode := completeFederatedLogin(t, httpClient, resp)
+	assert.Equal(t, user.Id, authCode.User.Id)
+
+	// a successful login clears the failed attempts
+	dbUser = getDbUser(t, user.Id)
+	assert.Equal(t, 0, dbUser.FailedLoginAttempts)
+	assert.False(t, dbUser.LastFailedLoginAt.Valid)
+}
+
+func TestLoginLockout_UserLockedAndUnlockedByAdmin(t *testing.T) {
+	setup()
+	setLoginLockoutSettings(t, 0, 3, 0)
+
+	user := createPasskeyTestUser(t, "abc123")
+
+	for i := 0; i < 3; i++ {
+		_, resp := postPassword(t, user.Email, "wrong-password")
+		assertPwdLoginError(t, resp, "Authentication failed.")
+	}
+
+	dbUser := getDbUser(t, user.Id)
+	assert.True(t, dbUser.IsLocked())
+	assert.WithinDuration(t, time.Now().UTC().Add(15*time.Minute), dbUser.LockedUntil.Time, time.Minute)
+
+	// the user is notified
+	mailhogData := getEmailMessages(t, user.Email)
+	if assert.Len(t, mailhogData.Items, 1) {
+		assert.Equal(t, "Your account was temporarily locked", mailhogData.Items[0].Content.Headers.Subject[0])
+	}
+
+	_, resp := postPassword(t, user.Email, "abc123")
+	assertPwdLoginError(t, resp, "Your account is temporarily locked")
+
+	// the admin sees the locked user and unlocks it
+	httpClient := loginToAdminArea(t, "admin@example.com", "changeme")
+	resp = getPage(t, httpClient, lib.GetBaseUrl()+"/admin/users/locked")
+	defer resp.Body.Close()
+	csrf := getCsrfValue(t, resp)
+
+	resp = getPage(t, httpClient, lib.GetBaseUrl()+"/admin/users/locked")
+	defer resp.Body.Close()
+	doc, err := goquery.NewDocumentFromReader(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Contains(t, doc.Find("table#lockedUsers tbody").Text(), user.Email)
+
+	resp = postForm(t, httpClient, lib.GetBaseUrl()+"/admin/users/locked", url.Values{
+		"unlockUserId":       {strconv.FormatInt(user.Id, 10)},
+		"gorilla.csrf.Token": {csrf},
+	})
+	defer resp.Body.Close()
+	assertRedirect(t, resp, "/admin/users/locked")
+
+	dbUser = getDbUser(t, user.Id)
+	assert.False(t, dbUser.IsLocked())
+	assert.Equal(t, 0, dbUser.FailedLoginAttempts)
+
+	httpClient, resp = postPassword(t, user.Email, "abc123")
+	defer resp.Body.Close()
+	authCode := completeFederatedLogin(t, httpClient, resp)
+	assert.Equal(t, user.Id, authCode.User.Id)
+}
+
+func TestLoginLockout_Otp(t *testing.T) {
+	setup()
+	setLoginLockoutSettings(t, 0, 2, 0)
+
+	user := createOtpTestUser(t)
+
+	httpClient, resp := authenticateWithPasswordOnDevice(t, user, enums.AcrLevel2, nil)
+	defer resp.Body.Close()
+	assertRedirect(t, resp, "/auth/otp")
+
+	postOtp := func(otp string) *http.Response {
+		resp := getPage(t, httpClient, lib.GetBaseUrl()+"/auth/otp")
+		defer resp.Body.Close()
+		return postForm(t, httpClient, lib.GetBaseUrl()+"/auth/otp", url.Values{
+			"otp":                {otp},
+			"gorilla.csrf.Token": {getCsrfValue(t, resp)},
+		})
+	}
+
+	for i := 0; i < 2; i++ {
+		resp = postOtp("000000")
+		assertPwdLoginError(t, resp, "Incorrect OTP Code.")
+	}
+	assert.True(t, getDbUser(t, user.Id).IsLocked())
+
+	otpCode, err := totp.GenerateCode(user.OTPSecret, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp = postOtp(otpCode)
+	assertPwdLoginError(t, resp, "Your account is temporarily locked")
+}
+
+func TestLoginLockout_SMSOtp(t *testing.T) {
+	setup()
+	setLoginLockoutSettings(t, 0, 2, 0)
+	setSMSOTPAllowedForMandatory2FA(t, true)
+
+	user := createSMSTestUser(t)
+	httpClient, csrf := startSMSOTP(t, user, enums.AcrLevel3)
+
+	resp := sendSMSOTP(t, httpClient, csrf)
+	defer resp.Body.Close()
+	code := getLastSMSOTPCode(t, user.PhoneNumber)
+
+	for i := 0; i < 2; i++ {
+		resp = postSMSOTP(t, httpClient, csrf, "000000")
+		assertPwdLoginError(t, resp, "The code is invalid or has expired.")
+	}
+	assert.True(t, getDbUser(t, user.Id).IsLocked())
+
+	resp = postSMSOTP(t, httpClient, csrf, code)
+	assertPwdLoginError(t, resp, "Your account is temporarily locked")
+}
+
+func TestLoginLockout_EmailCode(t *testing.T) {
+	setup()
+	setLoginLockoutSettings(t, 0, 2, 0)
+	setEmailLoginEnabled(t, true)
+
+	user := createPasskeyTestUser(t, "abc123")
+
+	httpClient, resp := requestEmailLoginCode(t, enums.AcrLevel1, user.Email)
+	defer resp.Body.Close()
+	code, _ := getEmailLoginCode(t, user.Email)
+
+	for i := 0; i < 2; i++ {
+		resp = postEmailLoginCode(t, httpClient, "000000")
+		assertPwdLoginError(t, resp, "The code is invalid or has expired.")
+	}
+	assert.True(t, getDbUser(t, user.Id).IsLocked())
+
+	resp = postEmailLoginCode(t, httpClient, code)
+	assertPwdLoginError(t, resp, "Your account is temporarily locked")
+}
+
+func TestLoginLockout_IpAddress(t *testing.T) {
+	setup()
+
+	// the admin signs in before the IP address gets locked
+	adminHttpClient := loginToAdminArea(t, "admin@example.com", "changeme")
+
+	setLoginLockoutSettings(t, 0, 0, 3)
+
+	user := createPasskeyTestUser(t, "abc123")
+
+	// failed attempts against unknown accounts count for the IP address
+	for i := 0; i < 3; i++ {
+		_, resp := postPassword(t, gofakeit.Email(), "wrong-password")
+		assertPwdLoginError(t, resp, "Authentication failed.")
+	}
+
+	_, resp := postPassword(t, user.Email, "abc123")
+	assertPwdLoginError(t, resp, "Your account is temporarily locked")
+	assert.False(t, getDbUser(t, user.Id).IsLocked())
+
+	failedLoginIps, err := database.GetLockedFailedLoginIps(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !assert.Len(t, failedLoginIps, 1) {
+		return
+	}
+
+	resp = getPage(t, adminHttpClient, lib.GetBaseUrl()+"/admin/users/locked")
+	defer resp.Body.Close()
+	csrf := getCsrfValue(t, resp)
+
+	resp = getPage(t, adminHttpClient, lib.GetBaseUrl()+"/admin/users/locked")
+	defer resp.Body.Close()
+	doc, err := goquery.NewDocumentFromReader(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Contains(t, doc.Find("table#lockedIpAddresses tbody").Text(), failedLoginIps[0].IpAddress)
+
+	resp = postForm(t, adminHttpClient, lib.GetBaseUrl()+"/admin/users/locked", url.Values{
+		"unlockFailedLoginIpId": {strconv.FormatInt(failedLoginIps[0].Id, 10)},
+		"gorilla.csrf.Token":    {csrf},
+	})
+	defer resp.Body.Close()
+	assertRedirect(t, resp, "/admin/users/locked")
+
+	failedLoginIp, err := database.GetFailedLoginIpById(nil, failedLoginIps[0].Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Nil(t, failedLoginIp)
+
+	httpClient, resp := postPassword(t, user.Email, "abc123")
+	defer resp.Body.Close()
+	authCode := completeFederatedLogin(t, httpClient, resp)
+	assert.Equal(t, user.Id, authCode.User.Id)
+}
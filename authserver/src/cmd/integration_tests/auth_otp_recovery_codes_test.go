@@ -0,0 +1,178 @@
+package integrationtests
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/leodip/goiabada/internal/entities"
+	"github.com/leodip/goiabada/internal/enums"
+	"github.com/leodip/goiabada/internal/lib"
+	"github.com/pquerna/otp/totp"
+	"github.com/stretchr/testify/assert"
+)
+
+func getRecoveryCodes(t *testing.T, resp *http.Response) []string {
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	doc, err := goquery.NewDocumentFromReader(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	codes := []string{}
+	doc.Find("ul#recoveryCodes li").Each(func(i int, s *goquery.Selection) {
+		codes = append(codes, strings.TrimSpace(s.Text()))
+	})
+	return codes
+}
+
+// enrollOtp enables OTP in the account area, and returns the recovery codes displayed after the enrollment.
+func enrollOtp(t *testing.T, user *entities.User) (*http.Client, []string) {
+	httpClient := loginToAccountArea(t, user.Email, "abc123")
+
+	resp := getPage(t, httpClient, lib.GetBaseUrl()+"/account/otp")
+	defer resp.Body.Close()
+	csrf := getCsrfValue(t, resp)
+
+	doc, err := goquery.NewDocumentFromReader(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otpSecret := ""
+	doc.Find("form pre").Each(func(i int, s *goquery.Selection) {
+		if len(s.Text()) == 32 {
+			otpSecret = s.Text()
+		}
+	})
+	otpCode, err := totp.GenerateCode(otpSecret, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp = postForm(t, httpClient, lib.GetBaseUrl()+"/account/otp", url.Values{
+		"otp":                {otpCode},
+		"password":           {"abc123"},
+		"gorilla.csrf.Token": {csrf},
+	})
+	return httpClient, getRecoveryCodes(t, resp)
+}
+
+func TestOtpRecoveryCodes_GeneratedAtEnrollment(t *testing.T) {
+	setup()
+
+	user := createPasskeyTestUser(t, "abc123")
+	_, codes := enrollOtp(t, user)
+	assert.Len(t, codes, 10)
+
+	// only the hashes are stored
+	dbUser, err := database.GetUserById(nil, user.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, dbUser.OTPEnabled)
+	assert.Equal(t, 10, dbUser.GetOTPRecoveryCodesRemaining())
+	assert.NotContains(t, dbUser.OTPRecoveryCodesHashes, strings.ReplaceAll(codes[0], "-", ""))
+}
+
+func TestOtpRecoveryCodes_UsedInsteadOfOtp(t *testing.T) {
+	setup()
+
+	user := createPasskeyTestUser(t, "abc123")
+	_, codes := enrollOtp(t, user)
+
+	login := func(code string) *http.Response {
+		httpClient := startAuthorization(t, enums.AcrLevel3)
+		resp := getPage(t, httpClient, lib.GetBaseUrl()+"/auth/pwd")
+		defer resp.Body.Close()
+		resp = authenticateWithPassword(t, httpClient, user.Email, "abc123", getCsrfValue(t, resp))
+		defer resp.Body.Close()
+		assertRedirect(t, resp, "/auth/otp")
+
+		resp = getPage(t, httpClient, lib.GetBaseUrl()+"/auth/otp")
+		defer resp.Body.Close()
+		return authenticateWithOtp(t, httpClient, code, getCsrfValue(t, resp))
+	}
+
+	// the code is accepted in upper case and without the dash
+	resp := login(strings.ToUpper(strings.ReplaceAll(codes[0], "-", "")))
+	defer resp.Body.Close()
+	assertRedirect(t, resp, "/auth/consent")
+
+	dbUser, err := database.GetUserById(nil, user.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 9, dbUser.GetOTPRecoveryCodesRemaining())
+
+	// each code can only be used once
+	resp = login(codes[0])
+	defer resp.Body.Close()
+	assertPwdLoginError(t, resp, "Incorrect OTP Code.")
+
+	resp = login(codes[1])
+	defer resp.Body.Close()
+	assertRedirect(t, resp, "/auth/consent")
+}
+
+func TestOtpRecoveryCodes_Regenerate(t *testing.T) {
+	setup()
+
+	user := createPasskeyTestUser(t, "abc123")
+	httpClient, codes := enrollOtp(t, user)
+
+	// the password is not required, so users without one (federated or passwordless) can regenerate
+	dbUser := getDbUser(t, user.Id)
+	dbUser.PasswordHash = ""
+	err := database.UpdateUser(nil, dbUser)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp := getPage(t, httpClient, lib.GetBaseUrl()+"/account/otp")
+	defer resp.Body.Close()
+	csrf := getCsrfValue(t, resp)
+
+	resp = postForm(t, httpClient, lib.GetBaseUrl()+"/account/otp/recovery-codes", url.Values{
+		"otp":                {"000000"},
+		"gorilla.csrf.Token": {csrf},
+	})
+	defer resp.Body.Close()
+	doc, err := goquery.NewDocumentFromReader(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 1, doc.Find("div.text-error p:contains(\"Authentication failed\")").Length())
+
+	otpCode, err := totp.GenerateCode(dbUser.OTPSecret, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp = postForm(t, httpClient, lib.GetBaseUrl()+"/account/otp/recovery-codes", url.Values{
+		"otp":                {otpCode},
+		"gorilla.csrf.Token": {csrf},
+	})
+	newCodes := getRecoveryCodes(t, resp)
+	assert.Len(t, newCodes, 10)
+	assert.NotEqual(t, codes, newCodes)
+
+	// the previous codes stop working
+	dbUser, err = database.GetUserById(nil, user.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldHash, err := lib.HashString(strings.ReplaceAll(codes[0], "-", ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	newHash, err := lib.HashString(strings.ReplaceAll(newCodes[0], "-", ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.NotContains(t, dbUser.OTPRecoveryCodesHashes, oldHash)
+	assert.Contains(t, dbUser.OTPRecoveryCodesHashes, newHash)
+}
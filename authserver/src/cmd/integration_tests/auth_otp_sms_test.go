@@ -0,0 +1,262 @@
+package integrationtests
+
+import (
+	"database/sql"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/leodip/goiabada/internal/entities"
+	"github.com/leodip/goiabada/internal/enums"
+	"github.com/leodip/goiabada/internal/lib"
+	"github.com/pquerna/otp/totp"
+	"github.com/stretchr/testify/assert"
+)
+
+// setSMSOTPAllowedForMandatory2FA changes the SMS setting, and restores it when the test finishes.
+func setSMSOTPAllowedForMandatory2FA(t *testing.T, allowed bool) {
+	settings, err := database.GetSettingsById(nil, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	original := settings.SMSOTPAllowedForMandatory2FA
+	settings.SMSOTPAllowedForMandatory2FA = allowed
+	err = database.UpdateSettings(nil, settings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		settings.SMSOTPAllowedForMandatory2FA = original
+		_ = database.UpdateSettings(nil, settings)
+	})
+}
+
+func createSMSTestUser(t *testing.T) *entities.User {
+	user := createPasskeyTestUser(t, "abc123")
+	user.PhoneNumber = fmt.Sprintf("+1 555%07d", rand.Intn(10000000))
+	user.PhoneNumberVerified = true
+	err := database.UpdateUser(nil, user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return user
+}
+
+// startSMSOTP authenticates with the password and follows the OTP page to the SMS page.
+func startSMSOTP(t *testing.T, user *entities.User, acrLevel enums.AcrLevel) (*http.Client, string) {
+	httpClient := startAuthorization(t, acrLevel)
+	resp := getPage(t, httpClient, lib.GetBaseUrl()+"/auth/pwd")
+	defer resp.Body.Close()
+	resp = authenticateWithPassword(t, httpClient, user.Email, "abc123", getCsrfValue(t, resp))
+	defer resp.Body.Close()
+	assertRedirect(t, resp, "/auth/otp")
+
+	resp = getPage(t, httpClient, lib.GetBaseUrl()+"/auth/otp")
+	defer resp.Body.Close()
+	doc, err := goquery.NewDocumentFromReader(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 1, doc.Find("a[href='/auth/otp/sms']").Length())
+
+	resp = getPage(t, httpClient, lib.GetBaseUrl()+"/auth/otp/sms")
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	return httpClient, getCsrfValue(t, resp)
+}
+
+// setPhoneNumberVerified changes whether the phone number of the user is verified, and restores it
+// when the test finishes. Users with a verified phone number are asked for the optional second factor.
+func setPhoneNumberVerified(t *testing.T, email string, verified bool) {
+	user, err := database.GetUserByEmail(nil, email)
+	if err != nil {
+		t.Fatal(err)
+	}
+	original := user.PhoneNumberVerified
+	user.PhoneNumberVerified = verified
+	err = database.UpdateUser(nil, user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		user, err := database.GetUserByEmail(nil, email)
+		if err == nil && user != nil {
+			user.PhoneNumberVerified = original
+			_ = database.UpdateUser(nil, user)
+		}
+	})
+}
+
+func TestAuthOtpSMS_MandatoryOtp(t *testing.T) {
+	setup()
+	setSMSOTPAllowedForMandatory2FA(t, true)
+
+	user := createSMSTestUser(t)
+	httpClient, csrf := startSMSOTP(t, user, enums.AcrLevel3)
+
+	resp := sendSMSOTP(t, httpClient, csrf)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	code := getLastSMSOTPCode(t, user.PhoneNumber)
+
+	resp = postSMSOTP(t, httpClient, csrf, "wrong")
+	assertPwdLoginError(t, resp, "The code is invalid or has expired.")
+
+	resp = postSMSOTP(t, httpClient, csrf, code)
+	defer resp.Body.Close()
+	authCode := completeFederatedLogin(t, httpClient, resp)
+	assert.Equal(t, user.Id, authCode.User.Id)
+	assert.Equal(t, enums.AcrLevel3.String(), authCode.AcrLevel)
+	assert.Equal(t, "pwd sms", authCode.AuthMethods)
+
+	// the code can only be used once
+	dbUser, err := database.GetUserById(nil, user.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "", dbUser.SMSOTPCodeHash)
+	assert.False(t, dbUser.OTPEnabled)
+}
+
+func TestAuthOtpSMS_NotAllowedForMandatoryOtp(t *testing.T) {
+	setup()
+	setSMSOTPAllowedForMandatory2FA(t, false)
+
+	user := createSMSTestUser(t)
+
+	httpClient := startAuthorization(t, enums.AcrLevel3)
+	resp := getPage(t, httpClient, lib.GetBaseUrl()+"/auth/pwd")
+	defer resp.Body.Close()
+	resp = authenticateWithPassword(t, httpClient, user.Email, "abc123", getCsrfValue(t, resp))
+	defer resp.Body.Close()
+	assertRedirect(t, resp, "/auth/otp")
+
+	resp = getPage(t, httpClient, lib.GetBaseUrl()+"/auth/otp")
+	defer resp.Body.Close()
+	doc, err := goquery.NewDocumentFromReader(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 0, doc.Find("a[href='/auth/otp/sms']").Length())
+
+	resp = getPage(t, httpClient, lib.GetBaseUrl()+"/auth/otp/sms")
+	defer resp.Body.Close()
+	assertRedirect(t, resp, "/auth/otp")
+}
+
+func TestAuthOtpSMS_OptionalOtp(t *testing.T) {
+	setup()
+	setSMSOTPAllowedForMandatory2FA(t, false)
+
+	user := createSMSTestUser(t)
+	key, err := totp.Generate(totp.GenerateOpts{Issuer: "Goiabada", AccountName: user.Email})
+	if err != nil {
+		t.Fatal(err)
+	}
+	user.OTPSecret = key.Secret()
+	user.OTPEnabled = true
+	err = database.UpdateUser(nil, user)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// for the optional OTP level, an SMS can be used instead of the authenticator app
+	httpClient, csrf := startSMSOTP(t, user, enums.AcrLevel2)
+
+	resp := sendSMSOTP(t, httpClient, csrf)
+	defer resp.Body.Close()
+	code := getLastSMSOTPCode(t, user.PhoneNumber)
+
+	resp = postSMSOTP(t, httpClient, csrf, code)
+	defer resp.Body.Close()
+	authCode := completeFederatedLogin(t, httpClient, resp)
+	assert.Equal(t, enums.AcrLevel2.String(), authCode.AcrLevel)
+	assert.Equal(t, "pwd sms", authCode.AuthMethods)
+}
+
+func TestAuthOtpSMS_OptionalOtpWithPhoneOnly(t *testing.T) {
+	setup()
+	setSMSOTPAllowedForMandatory2FA(t, false)
+
+	// the user has no authenticator app or passkey, only a verified phone number
+	user := createSMSTestUser(t)
+
+	httpClient, csrf := startSMSOTP(t, user, enums.AcrLevel2)
+
+	resp := sendSMSOTP(t, httpClient, csrf)
+	defer resp.Body.Close()
+	code := getLastSMSOTPCode(t, user.PhoneNumber)
+
+	resp = postSMSOTP(t, httpClient, csrf, code)
+	defer resp.Body.Close()
+	authCode := completeFederatedLogin(t, httpClient, resp)
+	assert.Equal(t, enums.AcrLevel2.String(), authCode.AcrLevel)
+	assert.Equal(t, "pwd sms", authCode.AuthMethods)
+}
+
+func TestAuthOtpSMS_ResendCooldown(t *testing.T) {
+	setup()
+	setSMSOTPAllowedForMandatory2FA(t, true)
+
+	user := createSMSTestUser(t)
+	httpClient, csrf := startSMSOTP(t, user, enums.AcrLevel3)
+
+	resp := sendSMSOTP(t, httpClient, csrf)
+	defer resp.Body.Close()
+
+	resp = sendSMSOTP(t, httpClient, csrf)
+	assertPwdLoginError(t, resp, "before requesting a new code.")
+
+	assert.Len(t, getSMSMessages(t, user.PhoneNumber), 1)
+}
+
+func TestAuthOtpSMS_TooManyAttempts(t *testing.T) {
+	setup()
+	setSMSOTPAllowedForMandatory2FA(t, true)
+	// the failed attempts also count for the login lockout (see TestLoginLockout_SMSOtp), which is
+	// disabled here to test the limit of the code alone
+	setLoginLockoutSettings(t, 0, 0, 0)
+
+	user := createSMSTestUser(t)
+	httpClient, csrf := startSMSOTP(t, user, enums.AcrLevel3)
+
+	resp := sendSMSOTP(t, httpClient, csrf)
+	defer resp.Body.Close()
+	code := getLastSMSOTPCode(t, user.PhoneNumber)
+
+	for i := 0; i < 5; i++ {
+		resp = postSMSOTP(t, httpClient, csrf, "wrong")
+		assertPwdLoginError(t, resp, "The code is invalid or has expired.")
+	}
+
+	resp = postSMSOTP(t, httpClient, csrf, code)
+	assertPwdLoginError(t, resp, "The code is invalid or has expired.")
+}
+
+func TestAuthOtpSMS_Expired(t *testing.T) {
+	setup()
+	setSMSOTPAllowedForMandatory2FA(t, true)
+
+	user := createSMSTestUser(t)
+	httpClient, csrf := startSMSOTP(t, user, enums.AcrLevel3)
+
+	resp := sendSMSOTP(t, httpClient, csrf)
+	defer resp.Body.Close()
+	code := getLastSMSOTPCode(t, user.PhoneNumber)
+
+	dbUser, err := database.GetUserById(nil, user.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dbUser.SMSOTPCodeIssuedAt = sql.NullTime{Time: time.Now().UTC().Add(-6 * time.Minute), Valid: true}
+	err = database.UpdateUser(nil, dbUser)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp = postSMSOTP(t, httpClient, csrf, code)
+	assertPwdLoginError(t, resp, "The code is invalid or has expired.")
+}
@@ -0,0 +1,579 @@
+package integrationtests
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/brianvoe/gofakeit/v6"
+	"github.com/go-webauthn/webauthn/protocol/webauthncbor"
+	"github.com/go-webauthn/webauthn/protocol/webauthncose"
+	"github.com/google/uuid"
+	"github.com/leodip/goiabada/internal/constants"
+	"github.com/leodip/goiabada/internal/entities"
+	"github.com/leodip/goiabada/internal/enums"
+	"github.com/leodip/goiabada/internal/lib"
+	"github.com/stretchr/testify/assert"
+)
+
+// softAuthenticator is a WebAuthn authenticator implemented in software, used as a stand-in
+// for a security key or a platform authenticator during the integration tests.
+type softAuthenticator struct {
+	privateKey   *ecdsa.PrivateKey
+	credentialId []byte
+	signCount    uint32
+}
+
+func newSoftAuthenticator(t *testing.T) *softAuthenticator {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	credentialId := make([]byte, 16)
+	_, err = rand.Read(credentialId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &softAuthenticator{
+		privateKey:   privateKey,
+		credentialId: credentialId,
+	}
+}
+
+func (a *softAuthenticator) publicKey(t *testing.T) []byte {
+	publicKey, err := webauthncbor.Marshal(webauthncose.EC2PublicKeyData{
+		PublicKeyData: webauthncose.PublicKeyData{
+			KeyType:   int64(webauthncose.EllipticKey),
+			Algorithm: int64(webauthncose.AlgES256),
+		},
+		Curve:  1, // P-256
+		XCoord: a.privateKey.X.FillBytes(make([]byte, 32)),
+		YCoord: a.privateKey.Y.FillBytes(make([]byte, 32)),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return publicKey
+}
+
+// authenticatorData builds the authenticator data: user present, user verified, and backed up (synced).
+func (a *softAuthenticator) authenticatorData(t *testing.T, rpId string, attestedCredential bool) []byte {
+	rpIdHash := sha256.Sum256([]byte(rpId))
+	flags := byte(0x01 | 0x04 | 0x08 | 0x10)
+	if attestedCredential {
+		flags |= 0x40
+	}
+
+	authData := bytes.NewBuffer(rpIdHash[:])
+	authData.WriteByte(flags)
+	_ = binary.Write(authData, binary.BigEndian, a.signCount)
+	if attestedCredential {
+		authData.Write(make([]byte, 16)) // aaguid
+		_ = binary.Write(authData, binary.BigEndian, uint16(len(a.credentialId)))
+		authData.Write(a.credentialId)
+		authData.Write(a.publicKey(t))
+	}
+	return authData.Bytes()
+}
+
+func passkeyClientData(t *testing.T, ceremonyType string, challenge string) []byte {
+	baseUrl, err := url.Parse(lib.GetBaseUrl())
+	if err != nil {
+		t.Fatal(err)
+	}
+	clientData, err := json.Marshal(map[string]interface{}{
+		"type":        ceremonyType,
+		"challenge":   challenge,
+		"origin":      baseUrl.Scheme + "://" + baseUrl.Host,
+		"crossOrigin": false,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return clientData
+}
+
+// createCredential answers the options of navigator.credentials.create(), with the "none" attestation.
+func (a *softAuthenticator) createCredential(t *testing.T, options map[string]interface{}) map[string]interface{} {
+	publicKey := options["publicKey"].(map[string]interface{})
+	rpId := publicKey["rp"].(map[string]interface{})["id"].(string)
+
+	attestationObject, err := webauthncbor.Marshal(struct {
+		Format       string                 `cbor:"fmt"`
+		AttStatement map[string]interface{} `cbor:"attStmt"`
+		AuthData     []byte                 `cbor:"authData"`
+	}{
+		Format:       "none",
+		AttStatement: map[string]interface{}{},
+		AuthData:     a.authenticatorData(t, rpId, true),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	clientData := passkeyClientData(t, "webauthn.create", publicKey["challenge"].(string))
+	credentialId := base64.RawURLEncoding.EncodeToString(a.credentialId)
+
+	return map[string]interface{}{
+		"id":    credentialId,
+		"rawId": credentialId,
+		"type":  "public-key",
+		"response": map[string]interface{}{
+			"clientDataJSON":    base64.RawURLEncoding.EncodeToString(clientData),
+			"attestationObject": base64.RawURLEncoding.EncodeToString(attestationObject),
+			"transports":        []string{"internal"},
+		},
+	}
+}
+
+// getAssertion answers the options of navigator.credentials.get(), signing with the next counter value.
+func (a *softAuthenticator) getAssertion(t *testing.T, options map[string]interface{}, userHandle []byte) map[string]interface{} {
+	publicKey := options["publicKey"].(map[string]interface{})
+
+	a.signCount++
+	authData := a.authenticatorData(t, publicKey["rpId"].(string), false)
+	clientData := passkeyClientData(t, "webauthn.get", publicKey["challenge"].(string))
+
+	clientDataHash := sha256.Sum256(clientData)
+	digest := sha256.Sum256(append(authData, clientDataHash[:]...))
+	signature, err := ecdsa.SignASN1(rand.Reader, a.privateKey, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	credentialId := base64.RawURLEncoding.EncodeToString(a.credentialId)
+	return map[string]interface{}{
+		"id":    credentialId,
+		"rawId": credentialId,
+		"type":  "public-key",
+		"response": map[string]interface{}{
+			"clientDataJSON":    base64.RawURLEncoding.EncodeToString(clientData),
+			"authenticatorData": base64.RawURLEncoding.EncodeToString(authData),
+			"signature":         base64.RawURLEncoding.EncodeToString(signature),
+			"userHandle":        base64.RawURLEncoding.EncodeToString(userHandle),
+		},
+	}
+}
+
+func createPasskeyTestUser(t *testing.T, password string) *entities.User {
+	passwordHash, err := lib.HashPassword(password)
+	if err != nil {
+		t.Fatal(err)
+	}
+	user := &entities.User{
+		Subject:       uuid.New(),
+		Email:         strings.ToLower(gofakeit.Email()),
+		EmailVerified: true,
+		Enabled:       true,
+		PasswordHash:  passwordHash,
+	}
+	err = database.CreateUser(nil, user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = database.DeleteUser(nil, user.Id)
+	})
+
+	// needed to access the account area
+	err = database.CreateUserPermission(nil, &entities.UserPermission{
+		UserId:       user.Id,
+		PermissionId: getAuthServerPermission(t, constants.ManageAccountPermissionIdentifier).Id,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return user
+}
+
+// addPasskey stores the passkey of the authenticator for the user, as if it had been registered.
+func addPasskey(t *testing.T, user *entities.User, authenticator *softAuthenticator) *entities.UserPasskey {
+	passkey := &entities.UserPasskey{
+		UserId:          user.Id,
+		Name:            "Test key",
+		CredentialId:    authenticator.credentialId,
+		PublicKey:       authenticator.publicKey(t),
+		AttestationType: "none",
+		AAGUID:          make([]byte, 16),
+		SignCount:       int64(authenticator.signCount),
+		BackupEligible:  true,
+		BackupState:     true,
+	}
+	err := database.CreateUserPasskey(nil, passkey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return passkey
+}
+
+func postPasskeyJson(t *testing.T, httpClient *http.Client, destUrl string, csrf string, body interface{}) (*http.Response, map[string]interface{}) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, err := http.NewRequest("POST", destUrl, bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-CSRF-Token", csrf)
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	result := map[string]interface{}{}
+	err = json.NewDecoder(resp.Body).Decode(&result)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp, result
+}
+
+func startAuthorization(t *testing.T, acrLevel enums.AcrLevel) *http.Client {
+	destUrl := lib.GetBaseUrl() +
+		"/auth/authorize/?client_id=test-client-2&redirect_uri=https://goiabada-test-client:8090/callback.html&response_type=code" +
+		"&code_challenge_method=S256&code_challenge=bQCdz4Hkhb3ctpajAwCCN899mNNfQGmRvMwruYT1Y9Y" +
+		"&response_mode=query&scope=openid%20profile%20email&state=a1b2c3&nonce=m9n8b7" +
+		"&acr_values=" + acrLevel.String()
+
+	httpClient := createHttpClient(&createHttpClientInput{
+		T: t,
+	})
+
+	resp, err := httpClient.Get(destUrl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	assertRedirect(t, resp, "/auth/pwd")
+	return httpClient
+}
+
+func getCodeFromPasskeyLogin(t *testing.T, httpClient *http.Client, result map[string]interface{}) *entities.Code {
+	assert.Equal(t, lib.GetBaseUrl()+"/auth/consent", result["redirectUrl"])
+
+	resp := getPage(t, httpClient, result["redirectUrl"].(string))
+	defer resp.Body.Close()
+
+	assertRedirect(t, resp, "/callback.html")
+	codeVal, _ := getCodeAndStateFromUrl(t, resp)
+
+	codeHash, err := lib.HashString(codeVal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	code, err := database.GetCodeByCodeHash(nil, codeHash, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = database.CodeLoadUser(nil, code)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return code
+}
+
+func TestAccountPasskeys_Register(t *testing.T) {
+	setup()
+
+	user := createPasskeyTestUser(t, "abc123")
+	httpClient := loginToAccountArea(t, user.Email, "abc123")
+
+	resp := getPage(t, httpClient, lib.GetBaseUrl()+"/account/passkeys")
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	csrf := getCsrfValue(t, resp)
+
+	// the password is required
+	resp, result := postPasskeyJson(t, httpClient, lib.GetBaseUrl()+"/account/passkeys/register/begin", csrf,
+		map[string]string{"name": "My laptop", "password": "wrong"})
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	assert.Equal(t, "Authentication failed. Check your password and try again.", result["error_description"])
+
+	resp, result = postPasskeyJson(t, httpClient, lib.GetBaseUrl()+"/account/passkeys/register/begin", csrf,
+		map[string]string{"name": "", "password": "abc123"})
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	assert.Equal(t, "Please enter a name for the passkey.", result["error_description"])
+
+	resp, options := postPasskeyJson(t, httpClient, lib.GetBaseUrl()+"/account/passkeys/register/begin", csrf,
+		map[string]string{"name": "My laptop", "password": "abc123"})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	publicKey := options["publicKey"].(map[string]interface{})
+	userHandle, err := base64.RawURLEncoding.DecodeString(publicKey["user"].(map[string]interface{})["id"].(string))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, user.Subject[:], userHandle)
+	assert.Equal(t, "required", publicKey["authenticatorSelection"].(map[string]interface{})["residentKey"])
+
+	authenticator := newSoftAuthenticator(t)
+	resp, result = postPasskeyJson(t, httpClient, lib.GetBaseUrl()+"/account/passkeys/register/finish", csrf,
+		authenticator.createCredential(t, options))
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, true, result["Success"])
+
+	passkeys, err := database.GetUserPasskeysByUserId(nil, user.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(t, passkeys, 1)
+	assert.Equal(t, "My laptop", passkeys[0].Name)
+	assert.Equal(t, authenticator.credentialId, passkeys[0].CredentialId)
+	assert.Equal(t, "internal", passkeys[0].Transports)
+	assert.Equal(t, int64(0), passkeys[0].SignCount)
+	assert.True(t, passkeys[0].BackupState)
+
+	// the ceremony can't be finished twice
+	resp, _ = postPasskeyJson(t, httpClient, lib.GetBaseUrl()+"/account/passkeys/register/finish", csrf,
+		authenticator.createCredential(t, options))
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	resp = getPage(t, httpClient, lib.GetBaseUrl()+"/account/passkeys")
+	defer resp.Body.Close()
+	doc, err := goquery.NewDocumentFromReader(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Contains(t, doc.Find("table tbody").Text(), "My laptop")
+}
+
+func TestAccountPasskeys_Delete(t *testing.T) {
+	setup()
+
+	user := createPasskeyTestUser(t, "abc123")
+	httpClient := loginToAccountArea(t, user.Email, "abc123")
+
+	// added after the login, so the passkey is not asked as a second factor
+	passkey := addPasskey(t, user, newSoftAuthenticator(t))
+
+	otherUser := createPasskeyTestUser(t, "abc123")
+	otherPasskey := addPasskey(t, otherUser, newSoftAuthenticator(t))
+
+	resp := getPage(t, httpClient, lib.GetBaseUrl()+"/account/passkeys")
+	defer resp.Body.Close()
+	csrf := getCsrfValue(t, resp)
+
+	// a passkey of another user can't be deleted
+	resp, _ = postPasskeyJson(t, httpClient, lib.GetBaseUrl()+"/account/passkeys/delete", csrf,
+		map[string]interface{}{"passkeyId": otherPasskey.Id})
+	assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+
+	resp, result := postPasskeyJson(t, httpClient, lib.GetBaseUrl()+"/account/passkeys/delete", csrf,
+		map[string]interface{}{"passkeyId": passkey.Id})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, true, result["Success"])
+
+	deleted, err := database.GetUserPasskeyById(nil, passkey.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Nil(t, deleted)
+
+	notDeleted, err := database.GetUserPasskeyById(nil, otherPasskey.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.NotNil(t, notDeleted)
+}
+
+func TestAuthPasskey_SecondFactor(t *testing.T) {
+	setup()
+
+	user := createPasskeyTestUser(t, "abc123")
+	authenticator := newSoftAuthenticator(t)
+	addPasskey(t, user, authenticator)
+
+	// the user has a passkey, so it's requested as the second factor
+	httpClient := startAuthorization(t, enums.AcrLevel2)
+	resp := getPage(t, httpClient, lib.GetBaseUrl()+"/auth/pwd")
+	defer resp.Body.Close()
+	resp = authenticateWithPassword(t, httpClient, user.Email, "abc123", getCsrfValue(t, resp))
+	defer resp.Body.Close()
+	assertRedirect(t, resp, "/auth/passkey")
+
+	resp = getPage(t, httpClient, lib.GetBaseUrl()+"/auth/passkey")
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	csrf := getCsrfValue(t, resp)
+
+	resp, options := postPasskeyJson(t, httpClient, lib.GetBaseUrl()+"/auth/passkey/begin", csrf, nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	allowCredentials := options["publicKey"].(map[string]interface{})["allowCredentials"].([]interface{})
+	assert.Len(t, allowCredentials, 1)
+
+	resp, result := postPasskeyJson(t, httpClient, lib.GetBaseUrl()+"/auth/passkey/finish", csrf,
+		authenticator.getAssertion(t, options, user.Subject[:]))
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	code := getCodeFromPasskeyLogin(t, httpClient, result)
+	assert.Equal(t, user.Id, code.User.Id)
+	assert.Equal(t, enums.AcrLevel4.String(), code.AcrLevel)
+	assert.Equal(t, "pwd pop", code.AuthMethods)
+
+	passkeys, err := database.GetUserPasskeysByUserId(nil, user.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, int64(1), passkeys[0].SignCount)
+	assert.True(t, passkeys[0].LastUsedAt.Valid)
+}
+
+func TestAuthPasskey_Passwordless(t *testing.T) {
+	setup()
+
+	user := createPasskeyTestUser(t, "abc123")
+	authenticator := newSoftAuthenticator(t)
+	addPasskey(t, user, authenticator)
+
+	httpClient := startAuthorization(t, enums.AcrLevel3)
+	resp := getPage(t, httpClient, lib.GetBaseUrl()+"/auth/pwd")
+	defer resp.Body.Close()
+	csrf := getCsrfValue(t, resp)
+
+	resp, options := postPasskeyJson(t, httpClient, lib.GetBaseUrl()+"/auth/passkey/login/begin", csrf, nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	publicKey := options["publicKey"].(map[string]interface{})
+	assert.Equal(t, "required", publicKey["userVerification"])
+	assert.Nil(t, publicKey["allowCredentials"])
+
+	resp, result := postPasskeyJson(t, httpClient, lib.GetBaseUrl()+"/auth/passkey/login/finish", csrf,
+		authenticator.getAssertion(t, options, user.Subject[:]))
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	// a passkey satisfies the mandatory OTP level
+	code := getCodeFromPasskeyLogin(t, httpClient, result)
+	assert.Equal(t, user.Id, code.User.Id)
+	assert.Equal(t, enums.AcrLevel4.String(), code.AcrLevel)
+	assert.Equal(t, "pop", code.AuthMethods)
+}
+
+func TestAuthPasskey_SignCountNotIncreased(t *testing.T) {
+	setup()
+
+	user := createPasskeyTestUser(t, "abc123")
+	authenticator := newSoftAuthenticator(t)
+	authenticator.signCount = 10
+	addPasskey(t, user, authenticator)
+
+	// a cloned authenticator reports a counter that is not greater than the stored one
+	authenticator.signCount = 4
+
+	httpClient := startAuthorization(t, enums.AcrLevel1)
+	resp := getPage(t, httpClient, lib.GetBaseUrl()+"/auth/pwd")
+	defer resp.Body.Close()
+	csrf := getCsrfValue(t, resp)
+
+	resp, options := postPasskeyJson(t, httpClient, lib.GetBaseUrl()+"/auth/passkey/login/begin", csrf, nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp, result := postPasskeyJson(t, httpClient, lib.GetBaseUrl()+"/auth/passkey/login/finish", csrf,
+		authenticator.getAssertion(t, options, user.Subject[:]))
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	assert.Equal(t, "Authentication with the passkey failed. Please try again.", result["error_description"])
+
+	passkeys, err := database.GetUserPasskeysByUserId(nil, user.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, int64(10), passkeys[0].SignCount)
+}
+
+func TestAuthPasskey_InvalidSignature(t *testing.T) {
+	setup()
+
+	user := createPasskeyTestUser(t, "abc123")
+	authenticator := newSoftAuthenticator(t)
+	addPasskey(t, user, authenticator)
+
+	httpClient := startAuthorization(t, enums.AcrLevel1)
+	resp := getPage(t, httpClient, lib.GetBaseUrl()+"/auth/pwd")
+	defer resp.Body.Close()
+	csrf := getCsrfValue(t, resp)
+
+	resp, options := postPasskeyJson(t, httpClient, lib.GetBaseUrl()+"/auth/passkey/login/begin", csrf, nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	// signed by another key with the same credential id
+	impostor := newSoftAuthenticator(t)
+	impostor.credentialId = authenticator.credentialId
+
+	resp, result := postPasskeyJson(t, httpClient, lib.GetBaseUrl()+"/auth/passkey/login/finish", csrf,
+		impostor.getAssertion(t, options, user.Subject[:]))
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	assert.Equal(t, "Authentication with the passkey failed. Please try again.", result["error_description"])
+}
+
+func TestAuthPasskey_AcrLevel4RequiresPasskey(t *testing.T) {
+	setup()
+
+	user := createPasskeyTestUser(t, "abc123")
+
+	httpClient := startAuthorization(t, enums.AcrLevel4)
+	resp := getPage(t, httpClient, lib.GetBaseUrl()+"/auth/pwd")
+	defer resp.Body.Close()
+	resp = authenticateWithPassword(t, httpClient, user.Email, "abc123", getCsrfValue(t, resp))
+	defer resp.Body.Close()
+	assertRedirect(t, resp, "/auth/passkey")
+
+	resp = getPage(t, httpClient, lib.GetBaseUrl()+"/auth/passkey")
+	defer resp.Body.Close()
+	doc, err := goquery.NewDocumentFromReader(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Contains(t, doc.Text(), "you don't have any passkeys registered")
+	assert.Equal(t, 0, doc.Find("#btnPasskey").Length())
+	assert.Equal(t, 0, doc.Find("a[href='/auth/otp']").Length())
+
+	// an OTP can't satisfy the passkey ACR level
+	resp = getPage(t, httpClient, lib.GetBaseUrl()+"/auth/otp")
+	defer resp.Body.Close()
+	assertRedirect(t, resp, "/auth/passkey")
+}
+
+func TestAuthPasskey_SessionSatisfiesOtpLevel(t *testing.T) {
+	setup()
+
+	user := createPasskeyTestUser(t, "abc123")
+	authenticator := newSoftAuthenticator(t)
+	addPasskey(t, user, authenticator)
+
+	httpClient := startAuthorization(t, enums.AcrLevel1)
+	resp := getPage(t, httpClient, lib.GetBaseUrl()+"/auth/pwd")
+	defer resp.Body.Close()
+	csrf := getCsrfValue(t, resp)
+
+	_, options := postPasskeyJson(t, httpClient, lib.GetBaseUrl()+"/auth/passkey/login/begin", csrf, nil)
+	_, result := postPasskeyJson(t, httpClient, lib.GetBaseUrl()+"/auth/passkey/login/finish", csrf,
+		authenticator.getAssertion(t, options, user.Subject[:]))
+	getCodeFromPasskeyLogin(t, httpClient, result)
+
+	// the session authenticated with a passkey doesn't need an OTP for the mandatory OTP level
+	destUrl := lib.GetBaseUrl() +
+		"/auth/authorize/?client_id=test-client-2&redirect_uri=https://goiabada-test-client:8090/callback.html&response_type=code" +
+		"&code_challenge_method=S256&code_challenge=bQCdz4Hkhb3ctpajAwCCN899mNNfQGmRvMwruYT1Y9Y" +
+		"&response_mode=query&scope=openid%20profile%20email&state=a1b2c3&nonce=m9n8b7" +
+		"&acr_values=" + enums.AcrLevel3.String()
+
+	resp = getPage(t, httpClient, destUrl)
+	defer resp.Body.Close()
+	assertRedirect(t, resp, "/auth/consent")
+
+	code := getCodeFromPasskeyLogin(t, httpClient, map[string]interface{}{"redirectUrl": lib.GetBaseUrl() + "/auth/consent"})
+	assert.Equal(t, enums.AcrLevel4.String(), code.AcrLevel)
+	assert.Equal(t, "pop", code.AuthMethods)
+}
@@ -0,0 +1,645 @@
+package integrationtests
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/brianvoe/gofakeit/v6"
+	"github.com/google/uuid"
+	"github.com/jimlambrt/gldap"
+	"github.com/leodip/goiabada/internal/constants"
+	"github.com/leodip/goiabada/internal/entities"
+	"github.com/leodip/goiabada/internal/enums"
+	"github.com/leodip/goiabada/internal/lib"
+	"github.com/stretchr/testify/assert"
+)
+
+const fakeLDAPBindDN = "cn=admin,dc=example,dc=org"
+const fakeLDAPBindPassword = "admin-secret"
+
+// fakeLDAPDirectory is an LDAP server used as a stand-in for a directory during the integration tests.
+// It supports simple binds and searches with filters made of equality assertions.
+type fakeLDAPDirectory struct {
+	url string
+
+	mu      sync.Mutex
+	entries map[string]map[string][]string // DN -> attributes
+}
+
+func newFakeLDAPDirectory(t *testing.T) *fakeLDAPDirectory {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := listener.Addr().(*net.TCPAddr).Port
+	listener.Close()
+
+	d := &fakeLDAPDirectory{
+		url:     fmt.Sprintf("ldap://127.0.0.1:%v", port),
+		entries: map[string]map[string][]string{},
+	}
+
+	server, err := gldap.NewServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mux, err := gldap.NewMux()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = mux.Bind(d.handleBind)
+	_ = mux.Search(d.handleSearch)
+	_ = server.Router(mux)
+
+	go func() {
+		_ = server.Run(fmt.Sprintf("127.0.0.1:%v", port))
+	}()
+	t.Cleanup(func() {
+		_ = server.Stop()
+	})
+
+	for !server.Ready() {
+		time.Sleep(10 * time.Millisecond)
+	}
+	return d
+}
+
+func (d *fakeLDAPDirectory) addUser(uid string, password string, attributes map[string][]string) string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	dn := "uid=" + uid + ",ou=people,dc=example,dc=org"
+	attributes["objectClass"] = []string{"person"}
+	attributes["userPassword"] = []string{password}
+	d.entries[dn] = attributes
+	return dn
+}
+
+func (d *fakeLDAPDirectory) setAttribute(dn string, name string, values []string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.entries[dn][name] = values
+}
+
+func (d *fakeLDAPDirectory) handleBind(w *gldap.ResponseWriter, r *gldap.Request) {
+	resp := r.NewBindResponse(gldap.WithResponseCode(gldap.ResultInvalidCredentials))
+	defer func() {
+		_ = w.Write(resp)
+	}()
+
+	m, err := r.GetSimpleBindMessage()
+	if err != nil {
+		return
+	}
+
+	if m.UserName == fakeLDAPBindDN && string(m.Password) == fakeLDAPBindPassword {
+		resp.SetResultCode(gldap.ResultSuccess)
+		return
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if entry, ok := d.entries[m.UserName]; ok && len(m.Password) > 0 && entry["userPassword"][0] == string(m.Password) {
+		resp.SetResultCode(gldap.ResultSuccess)
+	}
+}
+
+var fakeLDAPEqualityRegex = regexp.MustCompile(`\(([A-Za-z]+)=([^()*]*)\)`)
+
+func (d *fakeLDAPDirectory) handleSearch(w *gldap.ResponseWriter, r *gldap.Request) {
+	resp := r.NewSearchDoneResponse(gldap.WithResponseCode(gldap.ResultSuccess))
+	defer func() {
+		_ = w.Write(resp)
+	}()
+
+	m, err := r.GetSearchMessage()
+	if err != nil {
+		resp.SetResultCode(gldap.ResultOperationsError)
+		return
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for dn, attributes := range d.entries {
+		if !strings.HasSuffix(dn, m.BaseDN) {
+			continue
+		}
+		matches := true
+		for _, assertion := range fakeLDAPEqualityRegex.FindAllStringSubmatch(m.Filter, -1) {
+			found := false
+			for _, value := range attributes[assertion[1]] {
+				if strings.EqualFold(value, assertion[2]) {
+					found = true
+				}
+			}
+			matches = matches && found
+		}
+		if !matches {
+			continue
+		}
+
+		entry := r.NewSearchResponseEntry(dn)
+		for name, values := range attributes {
+			if name != "userPassword" {
+				entry.AddAttribute(name, values)
+			}
+		}
+		_ = w.Write(entry)
+	}
+}
+
+func createLDAPIdentityProvider(t *testing.T, directory *fakeLDAPDirectory, linkByEmail bool, autoProvision bool,
+	localPasswordFallback bool) *entities.IdentityProvider {
+
+	settings, err := database.GetSettingsById(nil, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bindPasswordEncrypted, err := lib.EncryptText(fakeLDAPBindPassword, settings.AESEncryptionKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	idp := &entities.IdentityProvider{
+		Name:                       "LDAP " + gofakeit.LetterN(6),
+		IdentityProviderIdentifier: "ldap-" + strings.ToLower(gofakeit.LetterN(10)),
+		Type:                       enums.IdentityProviderTypeLDAP.String(),
+		Enabled:                    true,
+		LDAPURL:                    directory.url,
+		LDAPBindDN:                 fakeLDAPBindDN,
+		LDAPBindPasswordEncrypted:  bindPasswordEncrypted,
+		LDAPBaseDN:                 "ou=people,dc=example,dc=org",
+		LDAPUserFilter:             "(&(objectClass=person)(mail={email}))",
+		LDAPSubjectAttribute:       "entryUUID",
+		LDAPLocalPasswordFallback:  localPasswordFallback,
+		EmailClaim:                 "mail",
+		GivenNameClaim:             "givenName",
+		FamilyNameClaim:            "sn",
+		GroupsClaim:                "memberOf",
+		UserAttributesMapping:      "departmentNumber=department",
+		LinkByEmail:                linkByEmail,
+		AutoProvision:              autoProvision,
+	}
+	err = database.CreateIdentityProvider(nil, idp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = database.DeleteIdentityProvider(nil, idp.Id)
+	})
+	return idp
+}
+
+// startPwdLogin starts an authorization request and posts the email and password to the login form.
+func startPwdLogin(t *testing.T, email string, password string) (*http.Client, *http.Response) {
+	destUrl := lib.GetBaseUrl() +
+		"/auth/authorize/?client_id=test-client-2&redirect_uri=https://goiabada-test-client:8090/callback.html&response_type=code" +
+		"&code_challenge_method=S256&code_challenge=bQCdz4Hkhb3ctpajAwCCN899mNNfQGmRvMwruYT1Y9Y" +
+		"&response_mode=query&scope=openid%20profile%20email&state=a1b2c3&nonce=m9n8b7" +
+		"&acr_values=" + enums.AcrLevel1.String()
+
+	httpClient := createHttpClient(&createHttpClientInput{
+		T: t,
+	})
+
+	resp, err := httpClient.Get(destUrl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	assertRedirect(t, resp, "/auth/pwd")
+
+	resp = getPage(t, httpClient, lib.GetBaseUrl()+"/auth/pwd")
+	defer resp.Body.Close()
+
+	csrf := getCsrfValue(t, resp)
+	return httpClient, authenticateWithPassword(t, httpClient, email, password, csrf)
+}
+
+func assertPwdLoginError(t *testing.T, resp *http.Response, message string) {
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	doc, err := goquery.NewDocumentFromReader(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Contains(t, doc.Find("p.text-error").Text(), message)
+}
+
+func TestAuthPwd_LDAP_AutoProvision(t *testing.T) {
+	setup()
+
+	directory := newFakeLDAPDirectory(t)
+	idp := createLDAPIdentityProvider(t, directory, false, true, false)
+
+	group := &entities.Group{
+		GroupIdentifier: "ldap-group-" + strings.ToLower(gofakeit.LetterN(8)),
+		Description:     "Group synchronized from the LDAP directory",
+	}
+	err := database.CreateGroup(nil, group)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = database.DeleteGroup(nil, group.Id)
+	})
+
+	// the group of the directory matching a local group identifier is not synchronized, as it's not mapped
+	unmapped := createImportTestGroup(t)
+	idp.GroupsMapping = "engineering=" + group.GroupIdentifier
+	err = database.UpdateIdentityProvider(nil, idp)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	email := strings.ToLower(gofakeit.Email())
+	entryUUID := uuid.New().String()
+	dn := directory.addUser(gofakeit.Username(), "ldap-password", map[string][]string{
+		"entryUUID":        {entryUUID},
+		"mail":             {email},
+		"givenName":        {"Joana"},
+		"sn":               {"Pereira"},
+		"departmentNumber": {"42"},
+		"memberOf": {"cn=engineering,ou=groups,dc=example,dc=org", "cn=" + unmapped.GroupIdentifier + ",ou=groups,dc=example,dc=org",
+			"cn=unknown,ou=groups,dc=example,dc=org"},
+	})
+
+	httpClient, resp := startPwdLogin(t, email, "ldap-password")
+	defer resp.Body.Close()
+
+	code := completeFederatedLogin(t, httpClient, resp)
+	t.Cleanup(func() {
+		_ = database.DeleteUser(nil, code.User.Id)
+	})
+
+	assert.Equal(t, enums.AuthMethodPassword.String(), code.AuthMethods)
+	assert.Equal(t, email, code.User.Email)
+	assert.False(t, code.User.EmailVerified)
+	assert.Equal(t, "Joana", code.User.GivenName)
+	assert.Equal(t, "Pereira", code.User.FamilyName)
+	assert.Empty(t, code.User.PasswordHash)
+
+	federatedIdentity, err := database.GetUserFederatedIdentityByIdentityProviderIdAndSubject(nil, idp.Id, entryUUID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.NotNil(t, federatedIdentity)
+	assert.Equal(t, code.User.Id, federatedIdentity.UserId)
+
+	userGroups, err := database.GetUserGroupsByUserId(nil, code.User.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if assert.Len(t, userGroups, 1) {
+		assert.Equal(t, group.Id, userGroups[0].GroupId)
+	}
+
+	userAttributes, err := database.GetUserAttributesByUserId(nil, code.User.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(t, userAttributes, 1)
+	assert.Equal(t, "department", userAttributes[0].Key)
+	assert.Equal(t, "42", userAttributes[0].Value)
+
+	// the profile is synchronized on the next login
+	directory.setAttribute(dn, "givenName", []string{"Joanna"})
+	directory.setAttribute(dn, "departmentNumber", []string{"43"})
+
+	httpClient, resp = startPwdLogin(t, email, "ldap-password")
+	defer resp.Body.Close()
+	code = completeFederatedLogin(t, httpClient, resp)
+
+	assert.Equal(t, "Joanna", code.User.GivenName)
+	userAttributes, err = database.GetUserAttributesByUserId(nil, code.User.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(t, userAttributes, 1)
+	assert.Equal(t, "43", userAttributes[0].Value)
+}
+
+func TestAuthPwd_LDAP_InvalidPassword(t *testing.T) {
+	setup()
+
+	directory := newFakeLDAPDirectory(t)
+	idp := createLDAPIdentityProvider(t, directory, false, true, false)
+
+	email := strings.ToLower(gofakeit.Email())
+	directory.addUser(gofakeit.Username(), "ldap-password", map[string][]string{
+		"entryUUID": {uuid.New().String()},
+		"mail":      {email},
+	})
+
+	startedAt := time.Now().UTC().Add(-time.Second)
+	_, resp := startPwdLogin(t, email, "wrong-password")
+	assertPwdLoginError(t, resp, "Authentication failed.")
+
+	user, err := database.GetUserByEmail(nil, email)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Nil(t, user)
+
+	// a single failure is audited for the attempt, with the directory that rejected the password.
+	// The events are recorded in the background
+	getFailedPwdEvents := func() []entities.AuditEvent {
+		auditEvents, _, err := database.SearchAuditEventsPaginated(nil, &entities.AuditEventFilter{
+			EventType: constants.AuditAuthFailedPwd,
+			From:      startedAt,
+		}, 1, 100)
+		if err != nil {
+			t.Fatal(err)
+		}
+		failedPwdEvents := []entities.AuditEvent{}
+		for _, auditEvent := range auditEvents {
+			if strings.Contains(auditEvent.Details, email) {
+				failedPwdEvents = append(failedPwdEvents, auditEvent)
+			}
+		}
+		return failedPwdEvents
+	}
+	for i := 0; i < 20 && len(getFailedPwdEvents()) == 0; i++ {
+		time.Sleep(250 * time.Millisecond)
+	}
+	time.Sleep(500 * time.Millisecond)
+
+	failedPwdEvents := getFailedPwdEvents()
+	if assert.Len(t, failedPwdEvents, 1) {
+		assert.Contains(t, failedPwdEvents[0].Details, `"identityProvider":"`+idp.IdentityProviderIdentifier+`"`)
+	}
+}
+
+func TestAuthPwd_LDAP_UserNotInDirectoryUsesLocalPassword(t *testing.T) {
+	setup()
+
+	directory := newFakeLDAPDirectory(t)
+	createLDAPIdentityProvider(t, directory, false, true, false)
+
+	// mauro@outlook.com is a local user, created by the test seed
+	loginUserWithAcrLevel1(t, "mauro@outlook.com", "abc123")
+}
+
+func TestAuthPwd_LDAP_LocalPasswordFallback(t *testing.T) {
+	setup()
+
+	directory := newFakeLDAPDirectory(t)
+	idp := createLDAPIdentityProvider(t, directory, true, false, false)
+	idp.TrustEmail = true
+	err := database.UpdateIdentityProvider(nil, idp)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	user := createFederatedTestUser(t)
+	passwordHash, err := lib.HashPassword("local-password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	user.PasswordHash = passwordHash
+	err = database.UpdateUser(nil, user)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	directory.addUser(gofakeit.Username(), "ldap-password", map[string][]string{
+		"entryUUID": {uuid.New().String()},
+		"mail":      {user.Email},
+	})
+
+	// before the user is linked to the directory, the local password is accepted
+	httpClient, resp := startPwdLogin(t, user.Email, "local-password")
+	defer resp.Body.Close()
+	completeFederatedLogin(t, httpClient, resp)
+
+	// the first login with the directory password links the user (link by email)
+	httpClient, resp = startPwdLogin(t, user.Email, "ldap-password")
+	defer resp.Body.Close()
+	code := completeFederatedLogin(t, httpClient, resp)
+	assert.Equal(t, user.Id, code.User.Id)
+
+	// once linked, the local password is rejected
+	_, resp = startPwdLogin(t, user.Email, "local-password")
+	assertPwdLoginError(t, resp, "Authentication failed.")
+
+	// unless the identity provider allows falling back to the local password
+	idp.LDAPLocalPasswordFallback = true
+	err = database.UpdateIdentityProvider(nil, idp)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	httpClient, resp = startPwdLogin(t, user.Email, "local-password")
+	defer resp.Body.Close()
+	code = completeFederatedLogin(t, httpClient, resp)
+	assert.Equal(t, user.Id, code.User.Id)
+}
+
+func TestAuthPwd_LDAP_UntrustedEmailIsNotLinked(t *testing.T) {
+	setup()
+
+	directory := newFakeLDAPDirectory(t)
+	createLDAPIdentityProvider(t, directory, true, false, false)
+
+	user := createFederatedTestUser(t)
+	directory.addUser(gofakeit.Username(), "ldap-password", map[string][]string{
+		"entryUUID": {uuid.New().String()},
+		"mail":      {user.Email},
+	})
+
+	_, resp := startPwdLogin(t, user.Email, "ldap-password")
+	assertPwdLoginError(t, resp, "not linked to this identity provider")
+
+	federatedIdentities, err := database.GetUserFederatedIdentitiesByUserId(nil, user.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(t, federatedIdentities, 0)
+}
+
+func TestAuthPwd_LDAP_NotOfferedAsSignInOption(t *testing.T) {
+	setup()
+
+	directory := newFakeLDAPDirectory(t)
+	idp := createLDAPIdentityProvider(t, directory, false, true, false)
+
+	httpClient, resp := startPwdLogin(t, "", "")
+	defer resp.Body.Close()
+
+	doc, err := goquery.NewDocumentFromReader(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 0, doc.Find("a[href='/auth/federated/"+idp.IdentityProviderIdentifier+"']").Length())
+
+	resp = getPage(t, httpClient, lib.GetBaseUrl()+"/auth/federated/"+idp.IdentityProviderIdentifier)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+}
+
+func TestAdminIdentityProviderNew_Post_LDAP(t *testing.T) {
+	setup()
+
+	httpClient := loginToAdminArea(t, "admin@example.com", "changeme")
+
+	destUrl := lib.GetBaseUrl() + "/admin/identity-providers/new"
+	resp, err := httpClient.Get(destUrl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	csrf := getCsrfValue(t, resp)
+
+	identifier := "ldap-" + strings.ToLower(gofakeit.LetterN(10))
+	resp, err = httpClient.PostForm(destUrl, url.Values{
+		"type":                       {enums.IdentityProviderTypeLDAP.String()},
+		"name":                       {"Corporate directory"},
+		"identityProviderIdentifier": {identifier},
+		"ldapURL":                    {"ldaps://ldap.example.com:636"},
+		"ldapBaseDN":                 {"ou=people,dc=example,dc=org"},
+		"ldapBindDN":                 {fakeLDAPBindDN},
+		"ldapBindPassword":           {fakeLDAPBindPassword},
+		"gorilla.csrf.Token":         {csrf},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	idp, err := database.GetIdentityProviderByIdentifier(nil, identifier)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !assert.NotNil(t, idp) {
+		return
+	}
+	t.Cleanup(func() {
+		_ = database.DeleteIdentityProvider(nil, idp.Id)
+	})
+
+	assertRedirect(t, resp, "/admin/identity-providers/"+strconv.FormatInt(idp.Id, 10)+"/settings")
+	assert.Equal(t, enums.IdentityProviderTypeLDAP.String(), idp.Type)
+	assert.False(t, idp.Enabled)
+	assert.Equal(t, "ldaps://ldap.example.com:636", idp.LDAPURL)
+	assert.Equal(t, "(&(objectClass=person)(mail={email}))", idp.LDAPUserFilter)
+	assert.Equal(t, "memberOf", idp.GroupsClaim)
+
+	settings, err := database.GetSettingsById(nil, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bindPassword, err := lib.DecryptText(idp.LDAPBindPasswordEncrypted, settings.AESEncryptionKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, fakeLDAPBindPassword, bindPassword)
+
+	// the URL must use the ldap or ldaps scheme
+
+	resp, err = httpClient.PostForm(destUrl, url.Values{
+		"type":                       {enums.IdentityProviderTypeLDAP.String()},
+		"name":                       {"Invalid"},
+		"identityProviderIdentifier": {"ldap-" + strings.ToLower(gofakeit.LetterN(10))},
+		"ldapURL":                    {"https://ldap.example.com"},
+		"ldapBaseDN":                 {"ou=people,dc=example,dc=org"},
+		"gorilla.csrf.Token":         {csrf},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	doc, err := goquery.NewDocumentFromReader(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Contains(t, doc.Find("div.text-error p").Text(), "The LDAP URL must be a valid URL")
+}
+
+func TestAdminIdentityProviderSettings_Post_GroupsMapping(t *testing.T) {
+	setup()
+
+	directory := newFakeLDAPDirectory(t)
+	idp := createLDAPIdentityProvider(t, directory, false, true, false)
+	group := createImportTestGroup(t)
+	admins := createImportTestGroup(t)
+	err := database.CreateGroupPermission(nil, &entities.GroupPermission{
+		GroupId:      admins.Id,
+		PermissionId: getAuthServerPermission(t, constants.AdminWebsitePermissionIdentifier).Id,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	httpClient := loginToAdminArea(t, "admin@example.com", "changeme")
+
+	destUrl := lib.GetBaseUrl() + "/admin/identity-providers/" + strconv.FormatInt(idp.Id, 10) + "/settings"
+	resp, err := httpClient.Get(destUrl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	csrf := getCsrfValue(t, resp)
+
+	postSettings := func(groupsMapping string) *http.Response {
+		resp, err := httpClient.PostForm(destUrl, url.Values{
+			"name":                       {idp.Name},
+			"identityProviderIdentifier": {idp.IdentityProviderIdentifier},
+			"ldapURL":                    {idp.LDAPURL},
+			"ldapBaseDN":                 {idp.LDAPBaseDN},
+			"ldapUserFilter":             {idp.LDAPUserFilter},
+			"groupsClaim":                {"memberOf"},
+			"groupsMapping":              {groupsMapping},
+			"gorilla.csrf.Token":         {csrf},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+
+	testCases := []struct {
+		groupsMapping string
+		expectedError string
+	}{
+		{"engineering", "Line 1 of the groups mapping is invalid"},
+		{"engineering=group-that-does-not-exist", "The group group-that-does-not-exist of the groups mapping does not exist."},
+		{"admins=" + admins.GroupIdentifier, "The group " + admins.GroupIdentifier + " grants permissions of the authserver resource"},
+	}
+
+	for _, testCase := range testCases {
+		resp := postSettings(testCase.groupsMapping)
+		defer resp.Body.Close()
+
+		doc, err := goquery.NewDocumentFromReader(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Contains(t, doc.Find("div.text-error p").Text(), testCase.expectedError)
+	}
+
+	resp = postSettings("engineering=" + group.GroupIdentifier)
+	defer resp.Body.Close()
+	assertRedirect(t, resp, "/admin/identity-providers/"+strconv.FormatInt(idp.Id, 10)+"/settings")
+
+	idp, err = database.GetIdentityProviderById(nil, idp.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "engineering="+group.GroupIdentifier, idp.GroupsMapping)
+}
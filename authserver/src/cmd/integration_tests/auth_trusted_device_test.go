@@ -0,0 +1,229 @@
+package integrationtests
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/leodip/goiabada/internal/common"
+	"github.com/leodip/goiabada/internal/entities"
+	"github.com/leodip/goiabada/internal/enums"
+	"github.com/leodip/goiabada/internal/lib"
+	"github.com/pquerna/otp/totp"
+	"github.com/stretchr/testify/assert"
+)
+
+func createOtpTestUser(t *testing.T) *entities.User {
+	user := createPasskeyTestUser(t, "abc123")
+	key, err := totp.Generate(totp.GenerateOpts{Issuer: "Goiabada", AccountName: user.Email})
+	if err != nil {
+		t.Fatal(err)
+	}
+	user.OTPSecret = key.Secret()
+	user.OTPEnabled = true
+	err = database.UpdateUser(nil, user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return user
+}
+
+// authenticateWithPasswordOnDevice starts an authorization request in a new browser that has the
+// trusted device cookie (when not nil), and authenticates with the password.
+func authenticateWithPasswordOnDevice(t *testing.T, user *entities.User, acrLevel enums.AcrLevel,
+	trustedDeviceCookie *http.Cookie) (*http.Client, *http.Response) {
+
+	httpClient := startAuthorization(t, acrLevel)
+	if trustedDeviceCookie != nil {
+		baseUrl, err := url.Parse(lib.GetBaseUrl())
+		if err != nil {
+			t.Fatal(err)
+		}
+		httpClient.Jar.SetCookies(baseUrl, []*http.Cookie{trustedDeviceCookie})
+	}
+
+	resp := getPage(t, httpClient, lib.GetBaseUrl()+"/auth/pwd")
+	defer resp.Body.Close()
+	return httpClient, authenticateWithPassword(t, httpClient, user.Email, "abc123", getCsrfValue(t, resp))
+}
+
+// trustDevice authenticates with the password and the OTP, asking to trust the browser. It returns the
+// trusted device cookie.
+func trustDevice(t *testing.T, user *entities.User) *http.Cookie {
+	httpClient, resp := authenticateWithPasswordOnDevice(t, user, enums.AcrLevel2, nil)
+	defer resp.Body.Close()
+	assertRedirect(t, resp, "/auth/otp")
+
+	resp = getPage(t, httpClient, lib.GetBaseUrl()+"/auth/otp")
+	defer resp.Body.Close()
+	csrf := getCsrfValue(t, resp)
+
+	otpCode, err := totp.GenerateCode(user.OTPSecret, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp = postForm(t, httpClient, lib.GetBaseUrl()+"/auth/otp", url.Values{
+		"otp":                {otpCode},
+		"trustDevice":        {"on"},
+		"gorilla.csrf.Token": {csrf},
+	})
+	defer resp.Body.Close()
+	assertRedirect(t, resp, "/auth/consent")
+
+	for _, cookie := range resp.Cookies() {
+		if cookie.Name == common.TrustedDeviceCookieName {
+			return cookie
+		}
+	}
+	t.Fatal("expecting to find the trusted device cookie")
+	return nil
+}
+
+func TestTrustedDevice_SkipsOptionalOtp(t *testing.T) {
+	setup()
+
+	user := createOtpTestUser(t)
+
+	// the option is offered on the OTP page
+	httpClient, resp := authenticateWithPasswordOnDevice(t, user, enums.AcrLevel2, nil)
+	defer resp.Body.Close()
+	assertRedirect(t, resp, "/auth/otp")
+	resp = getPage(t, httpClient, lib.GetBaseUrl()+"/auth/otp")
+	defer resp.Body.Close()
+	doc, err := goquery.NewDocumentFromReader(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 1, doc.Find("input[name='trustDevice']").Length())
+
+	cookie := trustDevice(t, user)
+
+	trustedDevices, err := database.GetUserTrustedDevicesByUserId(nil, user.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(t, trustedDevices, 1)
+	assert.False(t, trustedDevices[0].LastUsedAt.Valid)
+	assert.NotContains(t, cookie.Value, trustedDevices[0].IdentifierHash)
+
+	// a new session in the trusted browser doesn't ask for the OTP
+	httpClient, resp = authenticateWithPasswordOnDevice(t, user, enums.AcrLevel2, cookie)
+	defer resp.Body.Close()
+	authCode := completeFederatedLogin(t, httpClient, resp)
+	assert.Equal(t, user.Id, authCode.User.Id)
+	assert.Equal(t, enums.AcrLevel2.String(), authCode.AcrLevel)
+	assert.Equal(t, "pwd", authCode.AuthMethods)
+
+	trustedDevice, err := database.GetUserTrustedDeviceById(nil, trustedDevices[0].Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, trustedDevice.LastUsedAt.Valid)
+}
+
+func TestTrustedDevice_MandatoryOtpStillRequired(t *testing.T) {
+	setup()
+
+	user := createOtpTestUser(t)
+	cookie := trustDevice(t, user)
+
+	_, resp := authenticateWithPasswordOnDevice(t, user, enums.AcrLevel3, cookie)
+	defer resp.Body.Close()
+	assertRedirect(t, resp, "/auth/otp")
+}
+
+func TestTrustedDevice_BoundToUser(t *testing.T) {
+	setup()
+
+	user := createOtpTestUser(t)
+	cookie := trustDevice(t, user)
+
+	otherUser := createOtpTestUser(t)
+	_, resp := authenticateWithPasswordOnDevice(t, otherUser, enums.AcrLevel2, cookie)
+	defer resp.Body.Close()
+	assertRedirect(t, resp, "/auth/otp")
+
+	// a tampered cookie is ignored
+	tampered := *cookie
+	tampered.Value = strings.ToUpper(cookie.Value)
+	_, resp = authenticateWithPasswordOnDevice(t, user, enums.AcrLevel2, &tampered)
+	defer resp.Body.Close()
+	assertRedirect(t, resp, "/auth/otp")
+}
+
+func TestTrustedDevice_Expired(t *testing.T) {
+	setup()
+
+	user := createOtpTestUser(t)
+	cookie := trustDevice(t, user)
+
+	trustedDevices, err := database.GetUserTrustedDevicesByUserId(nil, user.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	trustedDevices[0].ExpiresAt = time.Now().UTC().Add(-time.Minute)
+	err = database.UpdateUserTrustedDevice(nil, &trustedDevices[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, resp := authenticateWithPasswordOnDevice(t, user, enums.AcrLevel2, cookie)
+	defer resp.Body.Close()
+	assertRedirect(t, resp, "/auth/otp")
+}
+
+func TestTrustedDevice_RevokeInAccountArea(t *testing.T) {
+	setup()
+
+	user := createOtpTestUser(t)
+	cookie := trustDevice(t, user)
+
+	trustedDevices, err := database.GetUserTrustedDevicesByUserId(nil, user.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(t, trustedDevices, 1)
+
+	httpClient := loginToAccountArea(t, user.Email, "abc123")
+	resp := getPage(t, httpClient, lib.GetBaseUrl()+"/account/sessions")
+	defer resp.Body.Close()
+	csrf := getCsrfValue(t, resp)
+
+	resp = getPage(t, httpClient, lib.GetBaseUrl()+"/account/sessions")
+	defer resp.Body.Close()
+	doc, err := goquery.NewDocumentFromReader(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 1, doc.Find("table#trustedDevices tbody tr").Length())
+
+	req, err := http.NewRequest("POST", lib.GetBaseUrl()+"/account/sessions/trusted-devices",
+		strings.NewReader(fmt.Sprintf(`{"trustedDeviceId": %v}`, trustedDevices[0].Id)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-CSRF-Token", csrf)
+	resp, err = httpClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	result := unmarshalToMap(t, resp)
+	assert.True(t, result["Success"].(bool))
+
+	trustedDevices, err = database.GetUserTrustedDevicesByUserId(nil, user.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(t, trustedDevices, 0)
+
+	_, resp = authenticateWithPasswordOnDevice(t, user, enums.AcrLevel2, cookie)
+	defer resp.Body.Close()
+	assertRedirect(t, resp, "/auth/otp")
+}
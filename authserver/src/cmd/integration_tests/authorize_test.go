@@ -537,6 +537,9 @@ func TestAuthorize_PermissionNotGrantedToUser(t *testing.T) {
 func TestAuthorize_OneLogin_Pwd_WithFullConsent(t *testing.T) {
 	setup()
 
+	// without a verified phone number, the optional second factor is not requested by SMS
+	setPhoneNumberVerified(t, "viviane@gmail.com", false)
+
 	// make sure there's no prior user consent
 	deleteAllUserConsents(t)
 
@@ -614,6 +617,9 @@ func TestAuthorize_OneLogin_Pwd_WithFullConsent(t *testing.T) {
 func TestAuthorize_OneLogin_Pwd_CancelConsent(t *testing.T) {
 	setup()
 
+	// without a verified phone number, the optional second factor is not requested by SMS
+	setPhoneNumberVerified(t, "viviane@gmail.com", false)
+
 	deleteAllUserConsents(t)
 
 	codeChallenge := "bQCdz4Hkhb3ctpajAwCCN899mNNfQGmRvMwruYT1Y9Y"
@@ -1293,6 +1299,9 @@ func TestAuthorize_NoPreviousSession_TargetAcrLevel1_OTPDisabled(t *testing.T) {
 func TestAuthorize_NoPreviousSession_TargetAcrLevel2_OTPDisabled(t *testing.T) {
 	setup()
 
+	// without a verified phone number, the optional second factor is not requested by SMS
+	setPhoneNumberVerified(t, "viviane@gmail.com", false)
+
 	codeChallenge := "bQCdz4Hkhb3ctpajAwCCN899mNNfQGmRvMwruYT1Y9Y"
 	destUrl := lib.GetBaseUrl() +
 		"/auth/authorize/?client_id=test-client-2&redirect_uri=https://goiabada-test-client:8090/callback.html&response_type=code" +
@@ -1403,7 +1412,9 @@ func TestAuthorize_NoPreviousSession_TargetAcrLevel3_OTPDisabled(t *testing.T) {
 	resp = authenticateWithOtp(t, httpClient, otp, csrf)
 	defer resp.Body.Close()
 
-	assertRedirect(t, resp, "/auth/consent")
+	// the recovery codes of the new enrollment are displayed once
+	codes := assertOtpRecoveryCodes(t, resp, "/auth/consent")
+	assert.Len(t, codes, getDbUser(t, user.Id).GetOTPRecoveryCodesRemaining())
 	resp = getPage(t, httpClient, lib.GetBaseUrl()+"/auth/consent")
 	defer resp.Body.Close()
 
@@ -1819,7 +1830,7 @@ func TestAuthorize_PreviousAcrLevel1Session_TargetAcrLevel3_OTPDisabled(t *testi
 	resp = authenticateWithOtp(t, httpClient, otp, csrf)
 	defer resp.Body.Close()
 
-	assertRedirect(t, resp, "/auth/consent")
+	assertOtpRecoveryCodes(t, resp, "/auth/consent")
 	resp = getPage(t, httpClient, lib.GetBaseUrl()+"/auth/consent")
 	defer resp.Body.Close()
 
@@ -2202,7 +2213,7 @@ func TestAuthorize_PreviousAcrLevel2Session_TargetAcrLevel3_OTPDisabled(t *testi
 	resp = authenticateWithOtp(t, httpClient, otp, csrf)
 	defer resp.Body.Close()
 
-	assertRedirect(t, resp, "/auth/consent")
+	assertOtpRecoveryCodes(t, resp, "/auth/consent")
 	resp = getPage(t, httpClient, lib.GetBaseUrl()+"/auth/consent")
 	defer resp.Body.Close()
 
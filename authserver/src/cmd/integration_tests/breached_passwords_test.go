@@ -0,0 +1,346 @@
+package integrationtests
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/brianvoe/gofakeit/v6"
+	"github.com/leodip/goiabada/internal/customerrors"
+	"github.com/leodip/goiabada/internal/lib"
+	"github.com/stretchr/testify/assert"
+)
+
+func sha1Hex(password string) string {
+	hash := sha1.Sum([]byte(password))
+	return strings.ToUpper(hex.EncodeToString(hash[:]))
+}
+
+// setBreachedPasswordsSettings changes the breached passwords settings and clears the corpus when the test finishes.
+func setBreachedPasswordsSettings(t *testing.T, reject bool, forceChange bool) {
+	settings, err := database.GetSettingsById(nil, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	original := *settings
+
+	settings.RejectBreachedPasswords = reject
+	settings.ForceChangeOfBreachedPasswords = forceChange
+	err = database.UpdateSettings(nil, settings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = database.UpdateSettings(nil, &original)
+		_ = database.DeleteAllBreachedPasswordHashes(nil)
+	})
+}
+
+func addBreachedPasswords(t *testing.T, passwords ...string) {
+	hashes := []string{}
+	for _, password := range passwords {
+		hashes = append(hashes, sha1Hex(password))
+	}
+	_, err := database.CreateBreachedPasswordHashes(nil, hashes)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func postBreachedPasswordsFile(t *testing.T, httpClient *http.Client, csrf string, fileName string, content string) *http.Response {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	err := writer.WriteField("gorilla.csrf.Token", csrf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	part, err := writer.CreateFormFile("breachedPasswordsFile", fileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = part.Write([]byte(content))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = writer.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := httpClient.Post(lib.GetBaseUrl()+"/admin/settings/breached-passwords/import", writer.FormDataContentType(), body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp
+}
+
+func TestBreachedPasswords_AdminImportAndClear(t *testing.T) {
+	setup()
+	setBreachedPasswordsSettings(t, true, false)
+
+	httpClient := loginToAdminArea(t, "admin@example.com", "changeme")
+	resp := getPage(t, httpClient, lib.GetBaseUrl()+"/admin/settings/breached-passwords")
+	defer resp.Body.Close()
+	csrf := getCsrfValue(t, resp)
+
+	password1 := "breached-" + gofakeit.LetterN(12)
+	password2 := "breached-" + gofakeit.LetterN(12)
+	password3 := "breached-" + gofakeit.LetterN(12)
+
+	// a file of full hashes, with counts
+	resp = postBreachedPasswordsFile(t, httpClient, csrf, "pwned-passwords.txt",
+		sha1Hex(password1)+":12\r\n"+strings.ToLower(sha1Hex(password2))+"\r\n")
+	defer resp.Body.Close()
+	assertRedirect(t, resp, "/admin/settings/breached-passwords")
+
+	// a range file, named after the prefix
+	hash3 := sha1Hex(password3)
+	resp = postBreachedPasswordsFile(t, httpClient, csrf, hash3[:5]+".txt", hash3[5:]+":3\n")
+	defer resp.Body.Close()
+	assertRedirect(t, resp, "/admin/settings/breached-passwords")
+
+	count, err := database.CountBreachedPasswordHashes(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 3, count)
+	for _, password := range []string{password1, password2, password3} {
+		exists, err := database.BreachedPasswordHashExists(nil, sha1Hex(password))
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.True(t, exists)
+	}
+
+	resp = getPage(t, httpClient, lib.GetBaseUrl()+"/admin/settings/breached-passwords")
+	defer resp.Body.Close()
+	doc, err := goquery.NewDocumentFromReader(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "3", doc.Find("#hashCount").Text())
+
+	// nothing is imported from an invalid file
+	resp = postBreachedPasswordsFile(t, httpClient, csrf, "invalid.txt", sha1Hex("breached-"+gofakeit.LetterN(12))+"\nnot-a-hash\n")
+	defer resp.Body.Close()
+	doc, err = goquery.NewDocumentFromReader(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Contains(t, doc.Find("div.text-error p").Text(), "Line 2 of the file is not a SHA-1 hash")
+	count, err = database.CountBreachedPasswordHashes(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 3, count)
+
+	resp = postForm(t, httpClient, lib.GetBaseUrl()+"/admin/settings/breached-passwords/clear", url.Values{
+		"gorilla.csrf.Token": {csrf},
+	})
+	defer resp.Body.Close()
+	assertRedirect(t, resp, "/admin/settings/breached-passwords")
+
+	count, err = database.CountBreachedPasswordHashes(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 0, count)
+}
+
+func TestBreachedPasswords_CliImport(t *testing.T) {
+	setup()
+	setBreachedPasswordsSettings(t, true, false)
+
+	// more hashes than are committed in one transaction
+	lines := []string{}
+	for i := 0; i < 50010; i++ {
+		lines = append(lines, sha1Hex(fmt.Sprintf("breached-%v", i))+":1")
+	}
+	path := filepath.Join(t.TempDir(), "pwned-passwords.txt")
+	err := os.WriteFile(path, []byte(strings.Join(lines, "\n")), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	output, err := runCli(t, "", "breached-passwords", "import", "-file", path)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Contains(t, output, "Imported 50010 new hashes. The corpus has 50010 hashes.")
+
+	exists, err := database.BreachedPasswordHashExists(nil, sha1Hex("breached-50009"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, exists)
+
+	// the hashes already in the corpus are ignored
+	output, err = runCli(t, "", "breached-passwords", "import", "-file", path)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Contains(t, output, "Imported 0 new hashes. The corpus has 50010 hashes.")
+
+	// nothing is imported from an invalid file
+	invalidPath := filepath.Join(t.TempDir(), "invalid.txt")
+	err = os.WriteFile(invalidPath, []byte(sha1Hex("breached-"+gofakeit.LetterN(12))+"\nnot-a-hash\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = runCli(t, "", "breached-passwords", "import", "-file", invalidPath)
+	if assert.IsType(t, &customerrors.ValidationError{}, err) {
+		assert.Contains(t, err.(*customerrors.ValidationError).Description, "Line 2 of the file is not a SHA-1 hash")
+	}
+
+	count, err := database.CountBreachedPasswordHashes(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 50010, count)
+
+	_, err = runCli(t, "", "breached-passwords", "import")
+	assert.IsType(t, &customerrors.ValidationError{}, err)
+}
+
+func TestBreachedPasswords_RejectedAtChangePassword(t *testing.T) {
+	setup()
+	setBreachedPasswordsSettings(t, true, false)
+
+	breachedPassword := "Breached-" + gofakeit.LetterN(12) + "9$"
+	addBreachedPasswords(t, breachedPassword)
+
+	user := createPasskeyTestUser(t, "abc123")
+	httpClient := loginToAccountArea(t, user.Email, "abc123")
+
+	postChangePassword := func(newPassword string) *http.Response {
+		resp := getPage(t, httpClient, lib.GetBaseUrl()+"/account/change-password")
+		defer resp.Body.Close()
+		return postForm(t, httpClient, lib.GetBaseUrl()+"/account/change-password", url.Values{
+			"currentPassword":         {"abc123"},
+			"newPassword":             {newPassword},
+			"newPasswordConfirmation": {newPassword},
+			"gorilla.csrf.Token":      {getCsrfValue(t, resp)},
+		})
+	}
+
+	resp := postChangePassword(breachedPassword)
+	defer resp.Body.Close()
+	doc, err := goquery.NewDocumentFromReader(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Contains(t, doc.Find("div.text-error p").Text(), "This password has appeared in a data breach")
+
+	// the check can be disabled
+	setBreachedPasswordsSettings(t, false, false)
+	resp = postChangePassword(breachedPassword)
+	defer resp.Body.Close()
+	assert.True(t, lib.VerifyPasswordHash(getDbUser(t, user.Id).PasswordHash, breachedPassword))
+}
+
+func TestBreachedPasswords_RejectedAtRegistration(t *testing.T) {
+	setup()
+	setBreachedPasswordsSettings(t, true, false)
+
+	breachedPassword := "Breached-" + gofakeit.LetterN(12) + "9$"
+	addBreachedPasswords(t, breachedPassword)
+
+	settings, err := database.GetSettingsById(nil, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	settings.SelfRegistrationEnabled = true
+	err = database.UpdateSettings(nil, settings)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	httpClient := createHttpClient(&createHttpClientInput{
+		T: t,
+	})
+	resp := getPage(t, httpClient, lib.GetBaseUrl()+"/account/register")
+	defer resp.Body.Close()
+
+	email := gofakeit.Email()
+	resp = postForm(t, httpClient, lib.GetBaseUrl()+"/account/register", url.Values{
+		"email":                {email},
+		"password":             {breachedPassword},
+		"passwordConfirmation": {breachedPassword},
+		"gorilla.csrf.Token":   {getCsrfValue(t, resp)},
+	})
+	defer resp.Body.Close()
+	assertPwdLoginError(t, resp, "This password has appeared in a data breach")
+
+	user, err := database.GetUserByEmail(nil, email)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Nil(t, user)
+}
+
+func TestBreachedPasswords_ForcedChangeAtLogin(t *testing.T) {
+	setup()
+	setBreachedPasswordsSettings(t, true, true)
+
+	breachedPassword := "Breached-" + gofakeit.LetterN(12) + "9$"
+	addBreachedPasswords(t, breachedPassword)
+
+	user := createPasskeyTestUser(t, breachedPassword)
+
+	httpClient, resp := postPassword(t, user.Email, breachedPassword)
+	defer resp.Body.Close()
+	assertRedirect(t, resp, "/auth/change-password")
+	assert.True(t, getDbUser(t, user.Id).PasswordBreached)
+
+	// the session only starts after the password is changed
+	userSessions, err := database.GetUserSessionsByUserId(nil, user.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(t, userSessions, 0)
+
+	postChangePassword := func(newPassword string) *http.Response {
+		resp := getPage(t, httpClient, lib.GetBaseUrl()+"/auth/change-password")
+		defer resp.Body.Close()
+		return postForm(t, httpClient, lib.GetBaseUrl()+"/auth/change-password", url.Values{
+			"newPassword":             {newPassword},
+			"newPasswordConfirmation": {newPassword},
+			"gorilla.csrf.Token":      {getCsrfValue(t, resp)},
+		})
+	}
+
+	resp = postChangePassword(breachedPassword)
+	defer resp.Body.Close()
+	assertPwdLoginError(t, resp, "The new password must be different from the current password.")
+
+	otherBreachedPassword := "Breached-" + gofakeit.LetterN(12) + "9$"
+	addBreachedPasswords(t, otherBreachedPassword)
+	resp = postChangePassword(otherBreachedPassword)
+	defer resp.Body.Close()
+	assertPwdLoginError(t, resp, "This password has appeared in a data breach")
+
+	newPassword := "New-" + gofakeit.LetterN(12) + "9$"
+	resp = postChangePassword(newPassword)
+	defer resp.Body.Close()
+	authCode := completeFederatedLogin(t, httpClient, resp)
+	assert.Equal(t, user.Id, authCode.User.Id)
+
+	dbUser := getDbUser(t, user.Id)
+	assert.False(t, dbUser.PasswordBreached)
+	assert.True(t, lib.VerifyPasswordHash(dbUser.PasswordHash, newPassword))
+
+	// the next login goes straight to the client
+	httpClient, resp = postPassword(t, user.Email, newPassword)
+	defer resp.Body.Close()
+	authCode = completeFederatedLogin(t, httpClient, resp)
+	assert.Equal(t, user.Id, authCode.User.Id)
+}
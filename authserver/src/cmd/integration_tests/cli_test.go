@@ -0,0 +1,227 @@
+package integrationtests
+
+import (
+	"bytes"
+	"database/sql"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/brianvoe/gofakeit/v6"
+	"github.com/leodip/goiabada/internal/cli"
+	"github.com/leodip/goiabada/internal/constants"
+	"github.com/leodip/goiabada/internal/customerrors"
+	"github.com/leodip/goiabada/internal/entities"
+	"github.com/leodip/goiabada/internal/lib"
+	"github.com/stretchr/testify/assert"
+)
+
+func runCli(t *testing.T, stdin string, args ...string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	err := cli.NewCli(&stdout, &stderr, strings.NewReader(stdin)).Run(args)
+	return stdout.String(), err
+}
+
+func TestCli_UserCreate(t *testing.T) {
+	setup()
+
+	email := strings.ToLower(gofakeit.Email())
+	password := "Cli-" + gofakeit.Password(true, true, true, false, false, 12) + "1"
+	output, err := runCli(t, "", "user", "create", "-email", email, "-password", password,
+		"-given-name", "Ana", "-family-name", "Costa", "-email-verified", "-admin")
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Contains(t, output, "Created user")
+
+	user, err := database.GetUserByEmail(nil, email)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !assert.NotNil(t, user) {
+		return
+	}
+	t.Cleanup(func() {
+		_ = database.DeleteUser(nil, user.Id)
+	})
+	assert.True(t, user.EmailVerified)
+	assert.Equal(t, "Ana", user.GivenName)
+	assert.Equal(t, "Costa", user.FamilyName)
+	assert.True(t, lib.VerifyPasswordHash(user.PasswordHash, password))
+
+	err = database.UserLoadPermissions(nil, user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	permissionIdentifiers := []string{}
+	for _, permission := range user.Permissions {
+		permissionIdentifiers = append(permissionIdentifiers, permission.PermissionIdentifier)
+	}
+	assert.ElementsMatch(t, []string{constants.ManageAccountPermissionIdentifier, constants.AdminWebsitePermissionIdentifier},
+		permissionIdentifiers)
+
+	// the same validations of the admin area apply
+	_, err = runCli(t, "", "user", "create", "-email", email, "-password", password)
+	if assert.IsType(t, &customerrors.ValidationError{}, err) {
+		assert.Equal(t, "The email address is already in use.", err.(*customerrors.ValidationError).Description)
+	}
+
+	_, err = runCli(t, "", "user", "create", "-email", gofakeit.Email(), "-password", "a")
+	assert.IsType(t, &customerrors.ValidationError{}, err)
+
+	_, err = runCli(t, "", "user", "create", "-email", gofakeit.Email(), "unexpected")
+	assert.Error(t, err)
+}
+
+func TestCli_UserResetPasswordAndDisableOTP(t *testing.T) {
+	setup()
+
+	user := createPasskeyTestUser(t, gofakeit.Password(true, true, true, true, false, 12))
+	user.OTPEnabled = true
+	user.OTPSecret = "ABCDEFGHIJKLMNOP"
+	user.FailedLoginAttempts = 10
+	user.LastFailedLoginAt = sql.NullTime{Time: time.Now().UTC(), Valid: true}
+	user.LockedUntil = sql.NullTime{Time: time.Now().UTC().Add(time.Hour), Valid: true}
+	err := database.UpdateUser(nil, user)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the password is read from the standard input
+	newPassword := "Cli-" + gofakeit.Password(true, true, true, false, false, 12) + "2"
+	output, err := runCli(t, newPassword+"\n", "user", "reset-password", "-email", user.Email, "-password-stdin")
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Contains(t, output, "was reset")
+
+	user, err = database.GetUserById(nil, user.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, lib.VerifyPasswordHash(user.PasswordHash, newPassword))
+	assert.False(t, user.IsLocked())
+	assert.Equal(t, 0, user.FailedLoginAttempts)
+
+	output, err = runCli(t, "", "user", "disable-otp", "-email", user.Email)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Contains(t, output, "OTP was disabled")
+
+	user, err = database.GetUserById(nil, user.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.False(t, user.OTPEnabled)
+	assert.Equal(t, "", user.OTPSecret)
+
+	_, err = runCli(t, "", "user", "disable-otp", "-email", user.Email)
+	assert.IsType(t, &customerrors.ValidationError{}, err)
+
+	_, err = runCli(t, "", "user", "reset-password", "-email", "nobody@example.com", "-password", newPassword)
+	if assert.IsType(t, &customerrors.ValidationError{}, err) {
+		assert.Equal(t, "User not found: nobody@example.com.", err.(*customerrors.ValidationError).Description)
+	}
+}
+
+func TestCli_ClientCreateAndRotateSecret(t *testing.T) {
+	setup()
+
+	clientIdentifier := "cli-" + strings.ToLower(gofakeit.LetterN(10))
+	output, err := runCli(t, "", "client", "create", "-identifier", clientIdentifier, "-client-credentials")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	client, err := database.GetClientByClientIdentifier(nil, clientIdentifier)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !assert.NotNil(t, client) {
+		return
+	}
+	t.Cleanup(func() {
+		_ = database.DeleteClient(nil, client.Id)
+	})
+	assert.True(t, client.ClientCredentialsEnabled)
+	assert.False(t, client.AuthorizationCodeEnabled)
+
+	secretRegex := regexp.MustCompile(`Client secret: (\S+)`)
+	firstSecret := secretRegex.FindStringSubmatch(output)
+	if !assert.Len(t, firstSecret, 2) {
+		return
+	}
+
+	output, err = runCli(t, "", "client", "rotate-secret", "-identifier", clientIdentifier)
+	if !assert.NoError(t, err) {
+		return
+	}
+	secret := secretRegex.FindStringSubmatch(output)
+	if !assert.Len(t, secret, 2) {
+		return
+	}
+	assert.NotEqual(t, firstSecret[1], secret[1])
+
+	_, err = runCli(t, "", "client", "create", "-identifier", clientIdentifier)
+	assert.IsType(t, &customerrors.ValidationError{}, err)
+
+	// the new secret is accepted by the token endpoint
+	permission := getAuthServerPermission(t, constants.AdminApiSettingsReadPermissionIdentifier)
+	err = database.CreateClientPermission(nil, &entities.ClientPermission{
+		ClientId:     client.Id,
+		PermissionId: permission.Id,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	httpClient := createHttpClient(&createHttpClientInput{
+		T: t,
+	})
+	data := postToTokenEndpoint(t, httpClient, lib.GetBaseUrl()+"/auth/token", url.Values{
+		"grant_type":    {"client_credentials"},
+		"client_id":     {clientIdentifier},
+		"client_secret": {secret[1]},
+		"scope":         {constants.AuthServerResourceIdentifier + ":" + permission.PermissionIdentifier},
+	})
+	assert.NotEmpty(t, data["access_token"])
+}
+
+func TestCli_KeysRotateAndMigrateStatus(t *testing.T) {
+	setup()
+
+	currentKey, err := database.GetCurrentSigningKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	output, err := runCli(t, "", "keys", "rotate")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	newCurrentKey, err := database.GetCurrentSigningKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.NotEqual(t, currentKey.KeyIdentifier, newCurrentKey.KeyIdentifier)
+	assert.Contains(t, output, newCurrentKey.KeyIdentifier)
+
+	allSigningKeys, err := database.GetAllSigningKeys(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(t, allSigningKeys, 3)
+
+	output, err = runCli(t, "", "migrate", "status")
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Contains(t, output, "Status: up to date")
+
+	_, err = runCli(t, "", "keys", "unknown")
+	assert.Error(t, err)
+}
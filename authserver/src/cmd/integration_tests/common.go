@@ -1,6 +1,7 @@
 package integrationtests
 
 import (
+	"bufio"
 	"bytes"
 	"crypto/aes"
 	"crypto/cipher"
@@ -19,6 +20,8 @@ import (
 	"net/http/cookiejar"
 	"net/url"
 	"os"
+	"path/filepath"
+	"regexp"
 	"strings"
 	"testing"
 	"time"
@@ -147,6 +150,28 @@ func getOtpSecret(t *testing.T, response *http.Response) string {
 	return secret.Text()
 }
 
+// assertOtpRecoveryCodes asserts the page that displays the recovery codes after enrolling in OTP during
+// the login, and that its continue button points to location. It returns the recovery codes.
+func assertOtpRecoveryCodes(t *testing.T, response *http.Response, location string) []string {
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	doc, err := goquery.NewDocumentFromReader(response.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	codes := []string{}
+	doc.Find("#recoveryCodes li").Each(func(i int, sel *goquery.Selection) {
+		codes = append(codes, strings.TrimSpace(sel.Text()))
+	})
+	assert.NotEmpty(t, codes)
+
+	continueUrl, err := url.Parse(doc.Find("a:contains('Continue')").AttrOr("href", ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, location, continueUrl.Path)
+	return codes
+}
+
 func authenticateWithPassword(t *testing.T, client *http.Client, email string, password string, csrf string) *http.Response {
 	destUrl := lib.GetBaseUrl() + "/auth/pwd"
 	formData := url.Values{
@@ -229,6 +254,83 @@ func authenticateWithOtp(t *testing.T, client *http.Client, otp string, csrf str
 	return resp
 }
 
+func postForm(t *testing.T, httpClient *http.Client, destUrl string, formData url.Values) *http.Response {
+	request, err := http.NewRequest("POST", destUrl, strings.NewReader(formData.Encode()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := httpClient.Do(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp
+}
+
+// getSMSMessages returns the SMS messages sent to the phone number by the test SMS provider.
+func getSMSMessages(t *testing.T, phoneNumber string) []string {
+	file, err := os.Open(filepath.Join(os.TempDir(), "sms_messages.txt"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	messages := []string{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		parts := strings.Split(scanner.Text(), "|")
+		if len(parts) == 2 && parts[0] == phoneNumber {
+			messages = append(messages, parts[1])
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatal(err)
+	}
+	return messages
+}
+
+func getLastSMSOTPCode(t *testing.T, phoneNumber string) string {
+	messages := getSMSMessages(t, phoneNumber)
+	if len(messages) == 0 {
+		t.Fatal("expecting to find an SMS message sent to " + phoneNumber)
+	}
+	match := regexp.MustCompile(`Your sign-in code is (\d{6})`).FindStringSubmatch(messages[len(messages)-1])
+	if match == nil {
+		t.Fatal("unable to find the code in the SMS message: " + messages[len(messages)-1])
+	}
+	return match[1]
+}
+
+func sendSMSOTP(t *testing.T, httpClient *http.Client, csrf string) *http.Response {
+	return postForm(t, httpClient, lib.GetBaseUrl()+"/auth/otp/sms/send", url.Values{
+		"gorilla.csrf.Token": {csrf},
+	})
+}
+
+func postSMSOTP(t *testing.T, httpClient *http.Client, csrf string, code string) *http.Response {
+	return postForm(t, httpClient, lib.GetBaseUrl()+"/auth/otp/sms", url.Values{
+		"otp":                {code},
+		"gorilla.csrf.Token": {csrf},
+	})
+}
+
+// authenticateWithSMSOtp completes the second factor with a code sent by SMS, once the password
+// authentication redirected to the OTP page.
+func authenticateWithSMSOtp(t *testing.T, httpClient *http.Client, user *entities.User) *http.Response {
+	resp := getPage(t, httpClient, lib.GetBaseUrl()+"/auth/otp/sms")
+	defer resp.Body.Close()
+	csrf := getCsrfValue(t, resp)
+
+	resp = sendSMSOTP(t, httpClient, csrf)
+	defer resp.Body.Close()
+
+	return postSMSOTP(t, httpClient, csrf, getLastSMSOTPCode(t, user.PhoneNumber))
+}
+
 func grantConsent(t *testing.T, clientIdentifier string, email string, scope string) {
 	client, err := database.GetClientByClientIdentifier(nil, clientIdentifier)
 	if err != nil {
@@ -633,9 +735,12 @@ func loginUserWithAcrLevel3(t *testing.T, email string, password string) *http.C
 
 		resp = authenticateWithOtp(t, httpClient, otp, csrf)
 		defer resp.Body.Close()
+		assertOtpRecoveryCodes(t, resp, "/auth/consent")
 	}
 
-	assertRedirect(t, resp, "/auth/consent")
+	if !enrolledInOtp {
+		assertRedirect(t, resp, "/auth/consent")
+	}
 	resp = getPage(t, httpClient, lib.GetBaseUrl()+"/auth/consent")
 	defer resp.Body.Close()
 
@@ -829,6 +934,11 @@ func loginToAccountArea(t *testing.T, email string, password string) *http.Clien
 
 		resp = authenticateWithOtp(t, httpClient, otp, csrf)
 		defer resp.Body.Close()
+	} else if user.PhoneNumberVerified && resp.StatusCode == http.StatusFound &&
+		strings.HasSuffix(resp.Header.Get("Location"), "/auth/otp") {
+		// the optional second factor is sent by SMS to the users with a verified phone number
+		resp = authenticateWithSMSOtp(t, httpClient, user)
+		defer resp.Body.Close()
 	}
 
 	assertRedirect(t, resp, "/auth/consent")
@@ -961,6 +1071,11 @@ func loginToAdminArea(t *testing.T, email string, password string) *http.Client
 
 		resp = authenticateWithOtp(t, httpClient, otp, csrf)
 		defer resp.Body.Close()
+	} else if user.PhoneNumberVerified && resp.StatusCode == http.StatusFound &&
+		strings.HasSuffix(resp.Header.Get("Location"), "/auth/otp") {
+		// the optional second factor is sent by SMS to the users with a verified phone number
+		resp = authenticateWithSMSOtp(t, httpClient, user)
+		defer resp.Body.Close()
 	}
 
 	assertRedirect(t, resp, "/auth/consent")
@@ -1044,6 +1159,33 @@ func resetUserPassword(t *testing.T, email string, newPassword string) {
 	}
 }
 
+type passwordPolicy struct {
+	minLength           int
+	requiresUppercase   bool
+	requiresLowercase   bool
+	requiresNumber      bool
+	requiresSpecialChar bool
+}
+
+var passwordPolicyNone = passwordPolicy{minLength: 1}
+var passwordPolicyLow = passwordPolicy{minLength: 6}
+var passwordPolicyMedium = passwordPolicy{minLength: 8, requiresUppercase: true, requiresLowercase: true, requiresNumber: true}
+var passwordPolicyHigh = passwordPolicy{minLength: 10, requiresUppercase: true, requiresLowercase: true, requiresNumber: true, requiresSpecialChar: true}
+
+// applyTo sets the password policy in the settings, with the other password rules disabled.
+func (p passwordPolicy) applyTo(settings *entities.Settings) {
+	settings.PasswordMinLength = p.minLength
+	settings.PasswordMaxLength = 64
+	settings.PasswordRequiresUppercase = p.requiresUppercase
+	settings.PasswordRequiresLowercase = p.requiresLowercase
+	settings.PasswordRequiresNumber = p.requiresNumber
+	settings.PasswordRequiresSpecialChar = p.requiresSpecialChar
+	settings.PasswordDisallowUsernameOrEmail = false
+	settings.PasswordHistoryCount = 0
+	settings.PasswordMaxAgeInDays = 0
+	settings.PasswordMinAgeInDays = 0
+}
+
 func unmarshalToMap(t *testing.T, resp *http.Response) map[string]interface{} {
 	var result map[string]interface{}
 
@@ -1119,3 +1261,21 @@ func assertEmailSent(t *testing.T, to string, containing string) {
 	assert.True(t, strings.Contains(mailhogData.Items[0].Content.Headers.To[0], to))
 	assert.True(t, strings.Contains(mailhogData.Items[0].Content.Body, containing))
 }
+
+func assertEmailNotSent(t *testing.T, to string) {
+	destUrl := "http://mailhog:8025/api/v2/search?kind=to&query=" + to
+
+	resp, err := http.Get(destUrl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	var mailhogData MailhogData
+	err = json.NewDecoder(resp.Body).Decode(&mailhogData)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, 0, len(mailhogData.Items), "expecting no emails")
+}
@@ -0,0 +1,257 @@
+package integrationtests
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/brianvoe/gofakeit/v6"
+	"github.com/leodip/goiabada/internal/constants"
+	core_config "github.com/leodip/goiabada/internal/core/config"
+	"github.com/leodip/goiabada/internal/customerrors"
+	"github.com/leodip/goiabada/internal/lib"
+	"github.com/stretchr/testify/assert"
+)
+
+func exportConfig(t *testing.T, format string) *core_config.Document {
+	output, err := runCli(t, "", "config", "export", "-format", format)
+	if err != nil {
+		t.Fatal(err)
+	}
+	document, err := core_config.ParseDocument([]byte(output))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return document
+}
+
+func applyConfig(t *testing.T, document *core_config.Document, args ...string) (string, error) {
+	data, err := core_config.MarshalDocument(document, "yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(t.TempDir(), "goiabada.yaml")
+	err = os.WriteFile(file, data, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return runCli(t, "", append([]string{"config", "apply", "-file", file}, args...)...)
+}
+
+func TestConfig_ExportRoundTrip(t *testing.T) {
+	setup()
+
+	document := exportConfig(t, "json")
+	assert.NotNil(t, document.Settings)
+	for _, resource := range document.Resources {
+		assert.NotEqual(t, constants.AuthServerResourceIdentifier, resource.ResourceIdentifier)
+	}
+	for _, client := range document.Clients {
+		assert.NotEqual(t, constants.SystemClientIdentifier, client.ClientIdentifier)
+		assert.Empty(t, client.ClientSecret)
+	}
+
+	// applying the exported configuration doesn't change anything
+	output, err := applyConfig(t, exportConfig(t, "yaml"), "-dry-run")
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Contains(t, output, "No changes")
+}
+
+func TestConfig_Apply(t *testing.T) {
+	setup()
+
+	suffix := strings.ToLower(gofakeit.LetterN(8))
+	resourceIdentifier := "config-res-" + suffix
+	groupIdentifier := "config-grp-" + suffix
+	clientIdentifier := "config-cli-" + suffix
+
+	t.Setenv("CONFIG_TEST_CLIENT_SECRET", "secret-"+suffix)
+
+	document := exportConfig(t, "yaml")
+	original := exportConfig(t, "yaml")
+
+	document.Resources = append(document.Resources, core_config.Resource{
+		ResourceIdentifier: resourceIdentifier,
+		Description:        "Resource managed as code",
+		Permissions: []core_config.Permission{
+			{PermissionIdentifier: "read-" + suffix, Description: "Read"},
+			{PermissionIdentifier: "write-" + suffix, MinAcrLevel: "urn:goiabada:pwd:otp_mandatory"},
+		},
+	})
+	document.Groups = append(document.Groups, core_config.Group{
+		GroupIdentifier:  groupIdentifier,
+		IncludeInIdToken: true,
+		Attributes: []core_config.Attribute{
+			{Key: "department", Value: "sales", IncludeInAccessToken: true},
+		},
+		Permissions: []string{resourceIdentifier + ":read-" + suffix},
+	})
+	document.Clients = append(document.Clients, core_config.Client{
+		ClientIdentifier:         clientIdentifier,
+		Enabled:                  true,
+		AuthorizationCodeEnabled: true,
+		ClientCredentialsEnabled: true,
+		ClientSecret:             "${CONFIG_TEST_CLIENT_SECRET}",
+		RedirectURIs:             []string{"https://app.example.com/callback"},
+		WebOrigins:               []string{"https://APP.example.com"},
+		Permissions:              []string{resourceIdentifier + ":read-" + suffix, resourceIdentifier + ":write-" + suffix},
+	})
+
+	// the dry run shows the plan without changing anything
+	output, err := applyConfig(t, document, "-dry-run")
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Contains(t, output, "+ create resource "+resourceIdentifier)
+	assert.Contains(t, output, "+ create permission "+resourceIdentifier+":write-"+suffix)
+	assert.Contains(t, output, "+ create group "+groupIdentifier)
+	assert.Contains(t, output, "+ create client "+clientIdentifier)
+	assert.Contains(t, output, "Plan: 5 to create, 0 to update, 0 to delete.")
+
+	resource, err := database.GetResourceByResourceIdentifier(nil, resourceIdentifier)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Nil(t, resource)
+
+	output, err = applyConfig(t, document)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Contains(t, output, "Applied: 5 created, 0 updated, 0 deleted.")
+	t.Cleanup(func() {
+		if client, _ := database.GetClientByClientIdentifier(nil, clientIdentifier); client != nil {
+			_ = database.DeleteClient(nil, client.Id)
+		}
+		if group, _ := database.GetGroupByGroupIdentifier(nil, groupIdentifier); group != nil {
+			_ = database.DeleteGroup(nil, group.Id)
+		}
+		if resource, _ := database.GetResourceByResourceIdentifier(nil, resourceIdentifier); resource != nil {
+			_ = database.DeleteResource(nil, resource.Id)
+		}
+	})
+
+	client, err := database.GetClientByClientIdentifier(nil, clientIdentifier)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !assert.NotNil(t, client) {
+		return
+	}
+	settings, err := database.GetSettingsById(nil, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clientSecret, err := lib.DecryptText(client.ClientSecretEncrypted, settings.AESEncryptionKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "secret-"+suffix, clientSecret)
+
+	exported := exportConfig(t, "yaml")
+	for _, exportedClient := range exported.Clients {
+		if exportedClient.ClientIdentifier == clientIdentifier {
+			assert.Equal(t, []string{"https://app.example.com/callback"}, exportedClient.RedirectURIs)
+			assert.Equal(t, []string{"https://app.example.com"}, exportedClient.WebOrigins)
+			assert.Equal(t, []string{resourceIdentifier + ":read-" + suffix, resourceIdentifier + ":write-" + suffix}, exportedClient.Permissions)
+			assert.Equal(t, "urn:goiabada:pwd:otp_ifpossible", exportedClient.DefaultAcrLevel)
+		}
+	}
+	for _, exportedGroup := range exported.Groups {
+		if exportedGroup.GroupIdentifier == groupIdentifier {
+			assert.Equal(t, []string{resourceIdentifier + ":read-" + suffix}, exportedGroup.Permissions)
+			assert.Len(t, exportedGroup.Attributes, 1)
+		}
+	}
+
+	// applying the same document again is a no-op
+	output, err = applyConfig(t, document)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Contains(t, output, "No changes")
+
+	// updates are reported with the fields that changed
+	lastResource := &document.Resources[len(document.Resources)-1]
+	lastResource.Description = "Updated description"
+	lastResource.Permissions = lastResource.Permissions[:1]
+	document.Clients[len(document.Clients)-1].Permissions = []string{resourceIdentifier + ":read-" + suffix}
+	document.Groups[len(document.Groups)-1].Attributes[0].Value = "marketing"
+	output, err = applyConfig(t, document)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Contains(t, output, "~ update resource "+resourceIdentifier+": description")
+	assert.Contains(t, output, "- delete permission "+resourceIdentifier+":write-"+suffix)
+	assert.Contains(t, output, "~ update group "+groupIdentifier+": attributes")
+
+	// objects that are removed from the document are deleted
+	output, err = applyConfig(t, original)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Contains(t, output, "- delete resource "+resourceIdentifier)
+	assert.Contains(t, output, "- delete group "+groupIdentifier)
+	assert.Contains(t, output, "- delete client "+clientIdentifier)
+
+	client, err = database.GetClientByClientIdentifier(nil, clientIdentifier)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Nil(t, client)
+}
+
+func TestConfig_ApplyValidation(t *testing.T) {
+	setup()
+
+	suffix := strings.ToLower(gofakeit.LetterN(8))
+
+	// nothing is applied when a part of the document is invalid
+	document := exportConfig(t, "yaml")
+	document.Resources = append(document.Resources, core_config.Resource{
+		ResourceIdentifier: "config-res-" + suffix,
+	})
+	document.Clients = append([]core_config.Client{
+		{
+			ClientIdentifier: "config-cli-" + suffix,
+			ClientSecret:     "${CONFIG_TEST_UNSET_SECRET}",
+		},
+	}, document.Clients...)
+	_, err := applyConfig(t, document)
+	if assert.IsType(t, &customerrors.ValidationError{}, err) {
+		assert.Contains(t, err.(*customerrors.ValidationError).Description, "CONFIG_TEST_UNSET_SECRET")
+	}
+
+	document.Clients[0].ClientSecret = "plain-text-secret"
+	_, err = applyConfig(t, document)
+	assert.IsType(t, &customerrors.ValidationError{}, err)
+
+	document.Clients[0].ClientSecret = ""
+	document.Clients[0].Permissions = []string{"unknown-res:unknown-perm"}
+	_, err = applyConfig(t, document)
+	if assert.IsType(t, &customerrors.ValidationError{}, err) {
+		assert.Contains(t, err.(*customerrors.ValidationError).Description, "Permission not found")
+	}
+
+	// permission identifiers are unique across resources
+	document.Clients = document.Clients[1:]
+	document.Resources[len(document.Resources)-1].Permissions = []core_config.Permission{
+		{PermissionIdentifier: constants.AdminApiUsersReadPermissionIdentifier},
+	}
+	_, err = applyConfig(t, document)
+	if assert.IsType(t, &customerrors.ValidationError{}, err) {
+		assert.Contains(t, err.(*customerrors.ValidationError).Description, "is already in use by the resource "+constants.AuthServerResourceIdentifier)
+	}
+
+	resource, err := database.GetResourceByResourceIdentifier(nil, "config-res-"+suffix)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Nil(t, resource)
+
+	_, err = core_config.ParseDocument([]byte("resources: []\nunknownSection: true\n"))
+	assert.IsType(t, &customerrors.ValidationError{}, err)
+}
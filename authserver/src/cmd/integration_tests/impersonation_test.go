@@ -0,0 +1,188 @@
+package integrationtests
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+	core_token "github.com/leodip/goiabada/internal/core/token"
+	"github.com/leodip/goiabada/internal/enums"
+	"github.com/leodip/goiabada/internal/lib"
+	"github.com/stretchr/testify/assert"
+)
+
+// setAcceptImpersonatedTokens toggles impersonated tokens on test-client-2, and restores the setting
+// when the test finishes.
+func setAcceptImpersonatedTokens(t *testing.T, accept bool) {
+	client, err := database.GetClientByClientIdentifier(nil, "test-client-2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	original := client.AcceptImpersonatedTokens
+	client.AcceptImpersonatedTokens = accept
+	err = database.UpdateClient(nil, client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		client.AcceptImpersonatedTokens = original
+		_ = database.UpdateClient(nil, client)
+	})
+}
+
+func TestImpersonation_Refused(t *testing.T) {
+	setup()
+
+	httpClient := loginToAdminArea(t, "admin@example.com", "changeme")
+	admin, err := database.GetUserByEmail(nil, "admin@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	disabledUser := createPasskeyTestUser(t, "abc123")
+	disabledUser.Enabled = false
+	err = database.UpdateUser(nil, disabledUser)
+	if err != nil {
+		t.Fatal(err)
+	}
+	user := createPasskeyTestUser(t, "abc123")
+
+	testCases := []struct {
+		userId            int64
+		durationInMinutes string
+		message           string
+	}{
+		{user.Id, "0", "The duration of the impersonation must be between 1 and 480 minutes."},
+		{user.Id, "481", "The duration of the impersonation must be between 1 and 480 minutes."},
+		{disabledUser.Id, "30", "A disabled user can't be impersonated."},
+		{admin.Id, "30", "You can't impersonate yourself."},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.message, func(t *testing.T) {
+			destUrl := fmt.Sprintf("%v/admin/users/%v/details", lib.GetBaseUrl(), tc.userId)
+			resp := getPage(t, httpClient, destUrl)
+			defer resp.Body.Close()
+			resp = postForm(t, httpClient, fmt.Sprintf("%v/admin/users/%v/impersonate", lib.GetBaseUrl(), tc.userId), url.Values{
+				"durationInMinutes":  {tc.durationInMinutes},
+				"gorilla.csrf.Token": {getCsrfValue(t, resp)},
+			})
+			defer resp.Body.Close()
+			assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+			doc, err := goquery.NewDocumentFromReader(resp.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, tc.message, strings.TrimSpace(doc.Find("div.text-error p").Text()))
+		})
+	}
+}
+
+func TestImpersonation_Tokens(t *testing.T) {
+	setup()
+
+	user := createPasskeyTestUser(t, "abc123")
+	admin, err := database.GetUserByEmail(nil, "admin@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	httpClient := loginToAdminArea(t, "admin@example.com", "changeme")
+	destUrl := fmt.Sprintf("%v/admin/users/%v/details", lib.GetBaseUrl(), user.Id)
+	resp := getPage(t, httpClient, destUrl)
+	defer resp.Body.Close()
+	resp = postForm(t, httpClient, fmt.Sprintf("%v/admin/users/%v/impersonate", lib.GetBaseUrl(), user.Id), url.Values{
+		"durationInMinutes":  {"15"},
+		"gorilla.csrf.Token": {getCsrfValue(t, resp)},
+	})
+	defer resp.Body.Close()
+	assertRedirect(t, resp, "/")
+
+	// the banner is displayed in the pages of goiabada
+	resp = getPage(t, httpClient, lib.GetBaseUrl()+"/")
+	defer resp.Body.Close()
+	doc, err := goquery.NewDocumentFromReader(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	banner := doc.Find("#impersonationBanner")
+	assert.Equal(t, 1, banner.Length())
+	assert.Contains(t, banner.Text(), user.Email)
+	assert.Contains(t, banner.Text(), admin.Email)
+
+	authorize := func() *http.Response {
+		resp := getPage(t, httpClient, lib.GetBaseUrl()+
+			"/auth/authorize/?client_id=test-client-2&redirect_uri=https://goiabada-test-client:8090/callback.html&response_type=code"+
+			"&code_challenge_method=S256&code_challenge=0BnoD4e6xPCPip8rqZ9Zc2RqWOFfvryu9vzXJN4egoY"+
+			"&response_mode=query&scope=openid&state=a1b2c3&nonce=m9n8b7&acr_values="+enums.AcrLevel1.String())
+		for resp.StatusCode == http.StatusFound && !strings.Contains(resp.Header.Get("Location"), "/callback.html") {
+			resp.Body.Close()
+			resp = getPage(t, httpClient, resp.Header.Get("Location"))
+		}
+		return resp
+	}
+
+	// the client doesn't accept impersonated sessions
+	setAcceptImpersonatedTokens(t, false)
+	resp = authorize()
+	defer resp.Body.Close()
+	assertRedirect(t, resp, "/callback.html")
+	redirectLocation, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "access_denied", redirectLocation.Query().Get("error"))
+
+	setAcceptImpersonatedTokens(t, true)
+	resp = authorize()
+	defer resp.Body.Close()
+	assertRedirect(t, resp, "/callback.html")
+	codeVal, _ := getCodeAndStateFromUrl(t, resp)
+
+	respData := postToTokenEndpoint(t, httpClient, lib.GetBaseUrl()+"/auth/token", url.Values{
+		"client_id":     {"test-client-2"},
+		"grant_type":    {"authorization_code"},
+		"redirect_uri":  {"https://goiabada-test-client:8090/callback.html"},
+		"code":          {codeVal},
+		"code_verifier": {"DdazqdVNuDmRLGGRGQKKehEaoFeatACtNsM2UYGwuHkhBhDsTSzaCqWttcBc0kGx"},
+	})
+	assert.Nil(t, respData["error"])
+
+	// the tokens are for the user, acting on behalf of the admin
+	tokenParser := core_token.NewTokenParser(database)
+	for _, tokenName := range []string{"access_token", "id_token"} {
+		token, err := tokenParser.ParseToken(context.Background(), respData[tokenName].(string), true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, user.Subject.String(), token.GetStringClaim("sub"))
+		assert.Equal(t, map[string]interface{}{"sub": admin.Subject.String()}, token.Claims["act"])
+	}
+
+	// stopping the impersonation restores the session of the admin
+	resp = getPage(t, httpClient, lib.GetBaseUrl()+"/")
+	defer resp.Body.Close()
+	resp = postForm(t, httpClient, lib.GetBaseUrl()+"/impersonation/stop", url.Values{
+		"gorilla.csrf.Token": {getCsrfValue(t, resp)},
+	})
+	defer resp.Body.Close()
+	assertRedirect(t, resp, fmt.Sprintf("/admin/users/%v/details", user.Id))
+
+	userSessions, err := database.GetUserSessionsByUserId(nil, user.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(t, userSessions, 0)
+
+	resp = getPage(t, httpClient, lib.GetBaseUrl()+"/")
+	defer resp.Body.Close()
+	doc, err = goquery.NewDocumentFromReader(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 0, doc.Find("#impersonationBanner").Length())
+}
@@ -0,0 +1,101 @@
+package integrationtests
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/brianvoe/gofakeit/v6"
+	"github.com/leodip/goiabada/internal/lib"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+	"golang.org/x/crypto/pbkdf2"
+	"golang.org/x/crypto/scrypt"
+)
+
+func TestPasswordHashing_Argon2idIsTheDefault(t *testing.T) {
+	password := gofakeit.Password(true, true, true, true, false, 80)
+
+	passwordHash, err := lib.HashPassword(password)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, strings.HasPrefix(passwordHash, "$argon2id$v=19$m=65536,t=3,p=2$"))
+	assert.False(t, lib.PasswordHashNeedsRehash(passwordHash))
+
+	// unlike bcrypt, all the characters of long passwords are used
+	assert.True(t, lib.VerifyPasswordHash(passwordHash, password))
+	assert.False(t, lib.VerifyPasswordHash(passwordHash, password[:72]))
+}
+
+func TestPasswordHashing_ImportedHashesAreUpgradedAtLogin(t *testing.T) {
+	setup()
+
+	password := "imported-" + gofakeit.LetterN(10)
+	salt := []byte("saltsaltsalt1234")
+	b64 := base64.RawStdEncoding.EncodeToString
+	ab64 := func(b []byte) string {
+		return strings.ReplaceAll(b64(b), "+", ".")
+	}
+
+	bcryptHash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	scryptKey, err := scrypt.Key([]byte(password), salt, 1<<10, 8, 1, 32)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ssha := sha1.Sum(append([]byte(password), salt...))
+	ssha512 := sha512.Sum512(append([]byte(password), salt...))
+	sha := sha256.Sum256([]byte(password))
+
+	testCases := []struct {
+		name         string
+		passwordHash string
+	}{
+		{"bcrypt", string(bcryptHash)},
+		{"django pbkdf2", fmt.Sprintf("pbkdf2_sha256$1000$%s$%s", salt,
+			base64.StdEncoding.EncodeToString(pbkdf2.Key([]byte(password), salt, 1000, 32, sha256.New)))},
+		{"passlib pbkdf2", fmt.Sprintf("$pbkdf2-sha512$1000$%s$%s", ab64(salt),
+			ab64(pbkdf2.Key([]byte(password), salt, 1000, 64, sha512.New)))},
+		{"passlib scrypt", fmt.Sprintf("$scrypt$ln=10,r=8,p=1$%s$%s", b64(salt), b64(scryptKey))},
+		{"ldap ssha", "{SSHA}" + base64.StdEncoding.EncodeToString(append(ssha[:], salt...))},
+		{"ldap ssha512", "{SSHA512}" + base64.StdEncoding.EncodeToString(append(ssha512[:], salt...))},
+		{"ldap sha256", "{SHA256}" + base64.StdEncoding.EncodeToString(sha[:])},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.True(t, lib.VerifyPasswordHash(tc.passwordHash, password))
+			assert.False(t, lib.VerifyPasswordHash(tc.passwordHash, password+"x"))
+			assert.True(t, lib.PasswordHashNeedsRehash(tc.passwordHash))
+
+			user := createPasskeyTestUser(t, password)
+			user.PasswordHash = tc.passwordHash
+			err := database.UpdateUser(nil, user)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// a wrong password doesn't change the hash
+			_, resp := postPassword(t, user.Email, password+"x")
+			assertPwdLoginError(t, resp, "Authentication failed.")
+			assert.Equal(t, tc.passwordHash, getDbUser(t, user.Id).PasswordHash)
+
+			httpClient, resp := postPassword(t, user.Email, password)
+			defer resp.Body.Close()
+			authCode := completeFederatedLogin(t, httpClient, resp)
+			assert.Equal(t, user.Id, authCode.User.Id)
+
+			dbUser := getDbUser(t, user.Id)
+			assert.True(t, strings.HasPrefix(dbUser.PasswordHash, "$argon2id$"))
+			assert.False(t, lib.PasswordHashNeedsRehash(dbUser.PasswordHash))
+			assert.True(t, lib.VerifyPasswordHash(dbUser.PasswordHash, password))
+		})
+	}
+}
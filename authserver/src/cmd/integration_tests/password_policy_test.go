@@ -0,0 +1,257 @@
+package integrationtests
+
+import (
+	"database/sql"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/brianvoe/gofakeit/v6"
+	"github.com/leodip/goiabada/internal/entities"
+	"github.com/leodip/goiabada/internal/lib"
+	"github.com/stretchr/testify/assert"
+)
+
+// setPasswordPolicy changes the password policy settings and restores them when the test finishes.
+func setPasswordPolicy(t *testing.T, policy passwordPolicy, change func(settings *entities.Settings)) {
+	settings, err := database.GetSettingsById(nil, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	original := *settings
+
+	policy.applyTo(settings)
+	change(settings)
+	err = database.UpdateSettings(nil, settings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = database.UpdateSettings(nil, &original)
+	})
+}
+
+func postAccountChangePassword(t *testing.T, httpClient *http.Client, currentPassword string, newPassword string) string {
+	resp := getPage(t, httpClient, lib.GetBaseUrl()+"/account/change-password")
+	defer resp.Body.Close()
+	resp = postForm(t, httpClient, lib.GetBaseUrl()+"/account/change-password", url.Values{
+		"currentPassword":         {currentPassword},
+		"newPassword":             {newPassword},
+		"newPasswordConfirmation": {newPassword},
+		"gorilla.csrf.Token":      {getCsrfValue(t, resp)},
+	})
+	defer resp.Body.Close()
+	doc, err := goquery.NewDocumentFromReader(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return doc.Find("div.text-error p").Text()
+}
+
+func TestPasswordPolicy_AdminSettings(t *testing.T) {
+	setup()
+	setPasswordPolicy(t, passwordPolicyLow, func(settings *entities.Settings) {})
+
+	httpClient := loginToAdminArea(t, "admin@example.com", "changeme")
+	resp := getPage(t, httpClient, lib.GetBaseUrl()+"/admin/settings/password-policy")
+	defer resp.Body.Close()
+	csrf := getCsrfValue(t, resp)
+
+	formValues := url.Values{
+		"passwordMinLength":               {"12"},
+		"passwordMaxLength":               {"48"},
+		"passwordRequiresUppercase":       {"on"},
+		"passwordRequiresNumber":          {"on"},
+		"passwordDisallowUsernameOrEmail": {"on"},
+		"passwordHistoryCount":            {"5"},
+		"passwordMaxAgeInDays":            {"90"},
+		"passwordMinAgeInDays":            {"1"},
+		"gorilla.csrf.Token":              {csrf},
+	}
+	resp = postForm(t, httpClient, lib.GetBaseUrl()+"/admin/settings/password-policy", formValues)
+	defer resp.Body.Close()
+	assertRedirect(t, resp, "/admin/settings/password-policy")
+
+	settings, err := database.GetSettingsById(nil, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 12, settings.PasswordMinLength)
+	assert.Equal(t, 48, settings.PasswordMaxLength)
+	assert.True(t, settings.PasswordRequiresUppercase)
+	assert.False(t, settings.PasswordRequiresLowercase)
+	assert.True(t, settings.PasswordRequiresNumber)
+	assert.False(t, settings.PasswordRequiresSpecialChar)
+	assert.True(t, settings.PasswordDisallowUsernameOrEmail)
+	assert.Equal(t, 5, settings.PasswordHistoryCount)
+	assert.Equal(t, 90, settings.PasswordMaxAgeInDays)
+	assert.Equal(t, 1, settings.PasswordMinAgeInDays)
+
+	testCases := []struct {
+		field         string
+		value         string
+		expectedError string
+	}{
+		{"passwordMinLength", "0", "Minimum length must be between 1 and 64."},
+		{"passwordMaxLength", "8", "Maximum length must be between the minimum length and 64."},
+		{"passwordMaxLength", "65", "Maximum length must be between the minimum length and 64."},
+		{"passwordHistoryCount", "25", "Password history must be between 0 and 24."},
+		{"passwordMaxAgeInDays", "abc", "Invalid value for maximum password age in days."},
+		{"passwordMinAgeInDays", "90", "Minimum password age in days must be lower than the maximum password age."},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.field+"="+tc.value, func(t *testing.T) {
+			values := url.Values{}
+			for key, value := range formValues {
+				values[key] = value
+			}
+			values.Set(tc.field, tc.value)
+
+			resp := postForm(t, httpClient, lib.GetBaseUrl()+"/admin/settings/password-policy", values)
+			defer resp.Body.Close()
+			doc, err := goquery.NewDocumentFromReader(resp.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, tc.expectedError, strings.TrimSpace(doc.Find("div.text-error p").Text()))
+		})
+	}
+}
+
+func TestPasswordPolicy_DisallowEmailAndHistory(t *testing.T) {
+	setup()
+	setPasswordPolicy(t, passwordPolicyLow, func(settings *entities.Settings) {
+		settings.PasswordDisallowUsernameOrEmail = true
+		settings.PasswordHistoryCount = 3
+	})
+
+	password1 := "abc123"
+	user := createPasskeyTestUser(t, password1)
+	httpClient := loginToAccountArea(t, user.Email, password1)
+
+	localPart := strings.Split(user.Email, "@")[0]
+	errorMessage := postAccountChangePassword(t, httpClient, password1, "x"+strings.ToUpper(localPart)+"1")
+	assert.Contains(t, errorMessage, "the password can't contain your username or email address")
+
+	errorMessage = postAccountChangePassword(t, httpClient, password1, password1)
+	assert.Contains(t, errorMessage, "you can't reuse any of your last 3 passwords")
+
+	password2 := "pwd2-" + gofakeit.LetterN(10)
+	errorMessage = postAccountChangePassword(t, httpClient, password1, password2)
+	assert.Empty(t, errorMessage)
+
+	password3 := "pwd3-" + gofakeit.LetterN(10)
+	errorMessage = postAccountChangePassword(t, httpClient, password2, password3)
+	assert.Empty(t, errorMessage)
+
+	// the first password is still in the history
+	errorMessage = postAccountChangePassword(t, httpClient, password3, password1)
+	assert.Contains(t, errorMessage, "you can't reuse any of your last 3 passwords")
+
+	history, err := database.GetUserPasswordHistoryByUserId(nil, user.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(t, history, 2)
+
+	password4 := "pwd4-" + gofakeit.LetterN(10)
+	errorMessage = postAccountChangePassword(t, httpClient, password3, password4)
+	assert.Empty(t, errorMessage)
+
+	// only the last 2 previous passwords are kept
+	history, err = database.GetUserPasswordHistoryByUserId(nil, user.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(t, history, 2)
+
+	errorMessage = postAccountChangePassword(t, httpClient, password4, password1)
+	assert.Empty(t, errorMessage)
+	assert.True(t, lib.VerifyPasswordHash(getDbUser(t, user.Id).PasswordHash, password1))
+}
+
+func TestPasswordPolicy_MinimumAge(t *testing.T) {
+	setup()
+	setPasswordPolicy(t, passwordPolicyLow, func(settings *entities.Settings) {
+		settings.PasswordMinAgeInDays = 1
+	})
+
+	password := "abc123"
+	user := createPasskeyTestUser(t, password)
+	user.PasswordChangedAt = sql.NullTime{Time: time.Now().UTC().Add(-2 * time.Hour), Valid: true}
+	err := database.UpdateUser(nil, user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	httpClient := loginToAccountArea(t, user.Email, password)
+
+	newPassword := "new-" + gofakeit.LetterN(10)
+	errorMessage := postAccountChangePassword(t, httpClient, password, newPassword)
+	assert.Contains(t, errorMessage, "Your password was changed recently")
+	assert.True(t, lib.VerifyPasswordHash(getDbUser(t, user.Id).PasswordHash, password))
+
+	user = getDbUser(t, user.Id)
+	user.PasswordChangedAt = sql.NullTime{Time: time.Now().UTC().Add(-25 * time.Hour), Valid: true}
+	err = database.UpdateUser(nil, user)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	errorMessage = postAccountChangePassword(t, httpClient, password, newPassword)
+	assert.Empty(t, errorMessage)
+	assert.True(t, lib.VerifyPasswordHash(getDbUser(t, user.Id).PasswordHash, newPassword))
+}
+
+func TestPasswordPolicy_ExpiredPasswordAtLogin(t *testing.T) {
+	setup()
+	setPasswordPolicy(t, passwordPolicyLow, func(settings *entities.Settings) {
+		settings.PasswordMaxAgeInDays = 30
+	})
+
+	password := "abc123"
+	user := createPasskeyTestUser(t, password)
+	user.PasswordChangedAt = sql.NullTime{Time: time.Now().UTC().AddDate(0, 0, -31), Valid: true}
+	err := database.UpdateUser(nil, user)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	httpClient, resp := postPassword(t, user.Email, password)
+	defer resp.Body.Close()
+	assertRedirect(t, resp, "/auth/change-password")
+
+	resp = getPage(t, httpClient, lib.GetBaseUrl()+"/auth/change-password")
+	defer resp.Body.Close()
+	doc, err := goquery.NewDocumentFromReader(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Contains(t, doc.Text(), "Your password has expired")
+
+	resp = getPage(t, httpClient, lib.GetBaseUrl()+"/auth/change-password")
+	defer resp.Body.Close()
+	newPassword := "new-" + gofakeit.LetterN(10)
+	resp = postForm(t, httpClient, lib.GetBaseUrl()+"/auth/change-password", url.Values{
+		"newPassword":             {newPassword},
+		"newPasswordConfirmation": {newPassword},
+		"gorilla.csrf.Token":      {getCsrfValue(t, resp)},
+	})
+	defer resp.Body.Close()
+	authCode := completeFederatedLogin(t, httpClient, resp)
+	assert.Equal(t, user.Id, authCode.User.Id)
+
+	dbUser := getDbUser(t, user.Id)
+	assert.True(t, lib.VerifyPasswordHash(dbUser.PasswordHash, newPassword))
+	assert.True(t, dbUser.PasswordChangedAt.Valid)
+	assert.False(t, dbUser.IsPasswordExpired(30))
+
+	// the next login goes straight to the client
+	httpClient, resp = postPassword(t, user.Email, newPassword)
+	defer resp.Body.Close()
+	authCode = completeFederatedLogin(t, httpClient, resp)
+	assert.Equal(t, user.Id, authCode.User.Id)
+}
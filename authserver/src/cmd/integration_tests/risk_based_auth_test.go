@@ -0,0 +1,255 @@
+package integrationtests
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/brianvoe/gofakeit/v6"
+	"github.com/google/uuid"
+	"github.com/leodip/goiabada/internal/entities"
+	"github.com/leodip/goiabada/internal/enums"
+	"github.com/leodip/goiabada/internal/lib"
+	"github.com/stretchr/testify/assert"
+)
+
+// getThisIpAddress returns the IP address of the tests, as seen by the server.
+func getThisIpAddress(t *testing.T) string {
+	resp, err := http.Get(lib.GetBaseUrl() + "/test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	return unmarshalToMap(t, resp)["ipWithoutPort"].(string)
+}
+
+// setRiskSettings enables risk-based authentication with the thresholds and IP ranges, and restores
+// the settings when the test finishes.
+func setRiskSettings(t *testing.T, scoreForOtp int, scoreForBlock int, scoreForNotification int,
+	trustedIpRanges string, suspiciousIpRanges string) {
+
+	clearFailedLoginsFromThisIp(t)
+
+	settings, err := database.GetSettingsById(nil, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	original := *settings
+
+	settings.RiskBasedAuthEnabled = true
+	settings.RiskScoreForOTP = scoreForOtp
+	settings.RiskScoreForBlock = scoreForBlock
+	settings.RiskScoreForNotification = scoreForNotification
+	settings.RiskTrustedIpRanges = trustedIpRanges
+	settings.RiskSuspiciousIpRanges = suspiciousIpRanges
+	settings.SMTPEnabled = true
+	err = database.UpdateSettings(nil, settings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = database.UpdateSettings(nil, &original)
+		clearFailedLoginsFromThisIp(t)
+	})
+}
+
+// createPreviousSession creates a session of the user on another device and IP address.
+func createPreviousSession(t *testing.T, user *entities.User) {
+	utcNow := time.Now().UTC()
+	err := database.CreateUserSession(nil, &entities.UserSession{
+		SessionIdentifier: uuid.New().String(),
+		Started:           utcNow,
+		LastAccessed:      utcNow,
+		AuthMethods:       enums.AuthMethodPassword.String(),
+		AcrLevel:          enums.AcrLevel1.String(),
+		AuthTime:          utcNow,
+		IpAddress:         "198.51.100.7",
+		DeviceName:        "Other browser",
+		DeviceType:        "desktop",
+		DeviceOS:          "Other OS",
+		UserId:            user.Id,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRiskBasedAuth_AdminSettings(t *testing.T) {
+	setup()
+
+	settings, err := database.GetSettingsById(nil, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	original := *settings
+	t.Cleanup(func() {
+		_ = database.UpdateSettings(nil, &original)
+	})
+
+	httpClient := loginToAdminArea(t, "admin@example.com", "changeme")
+	destUrl := lib.GetBaseUrl() + "/admin/settings/login-security"
+
+	postSettings := func(scoreForBlock string, suspiciousIpRanges string) *http.Response {
+		resp := getPage(t, httpClient, destUrl)
+		defer resp.Body.Close()
+		return postForm(t, httpClient, destUrl, url.Values{
+			"loginDelayAfterFailedAttempts":     {strconv.Itoa(settings.LoginDelayAfterFailedAttempts)},
+			"loginLockoutAfterFailedAttempts":   {strconv.Itoa(settings.LoginLockoutAfterFailedAttempts)},
+			"loginIpLockoutAfterFailedAttempts": {strconv.Itoa(settings.LoginIpLockoutAfterFailedAttempts)},
+			"loginLockoutDurationInSeconds":     {strconv.Itoa(settings.LoginLockoutDurationInSeconds)},
+			"riskBasedAuthEnabled":              {"on"},
+			"riskScoreForOTP":                   {"35"},
+			"riskScoreForBlock":                 {scoreForBlock},
+			"riskScoreForNotification":          {"0"},
+			"riskTrustedIpRanges":               {"10.0.0.0/8, 192.168.1.1"},
+			"riskSuspiciousIpRanges":            {suspiciousIpRanges},
+			"gorilla.csrf.Token":                {getCsrfValue(t, resp)},
+		})
+	}
+
+	resp := postSettings("101", "")
+	assertAdminSettingsError(t, resp, "Risk score to block the login must be between 0 and 100.")
+
+	resp = postSettings("80", "203.0.113.0/33")
+	assertAdminSettingsError(t, resp, "Invalid IP address or range: 203.0.113.0/33.")
+
+	resp = postSettings("80", "2001:db8::/32\n203.0.113.0/24")
+	defer resp.Body.Close()
+	assertRedirect(t, resp, "/admin/settings/login-security")
+
+	settings, err = database.GetSettingsById(nil, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, settings.RiskBasedAuthEnabled)
+	assert.Equal(t, 35, settings.RiskScoreForOTP)
+	assert.Equal(t, 80, settings.RiskScoreForBlock)
+	assert.Equal(t, 0, settings.RiskScoreForNotification)
+	assert.Equal(t, "10.0.0.0/8, 192.168.1.1", settings.RiskTrustedIpRanges)
+	assert.Equal(t, "2001:db8::/32\n203.0.113.0/24", settings.RiskSuspiciousIpRanges)
+}
+
+func assertAdminSettingsError(t *testing.T, resp *http.Response, message string) {
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	doc, err := goquery.NewDocumentFromReader(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, message, strings.TrimSpace(doc.Find("div.text-error p").Text()))
+}
+
+func TestRiskBasedAuth_NewDeviceRequiresOtp(t *testing.T) {
+	setup()
+	setRiskSettings(t, 50, 0, 0, "", "")
+
+	user := createOtpTestUser(t)
+
+	// without previous sessions there's nothing to compare with
+	httpClient, resp := authenticateWithPasswordOnDevice(t, user, enums.AcrLevel1, nil)
+	defer resp.Body.Close()
+	authCode := completeFederatedLogin(t, httpClient, resp)
+	assert.Equal(t, "pwd", authCode.AuthMethods)
+
+	// the sessions are now on another device and IP address
+	err := database.DeleteUserSession(nil, getUserSessionByUserId(t, user.Id).Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	createPreviousSession(t, user)
+
+	httpClient, resp = authenticateWithPasswordOnDevice(t, user, enums.AcrLevel1, nil)
+	defer resp.Body.Close()
+	assertRedirect(t, resp, "/auth/otp")
+
+	// logins from trusted networks are never considered risky
+	setRiskSettings(t, 50, 0, 0, getThisIpAddress(t), "")
+
+	httpClient, resp = authenticateWithPasswordOnDevice(t, user, enums.AcrLevel1, nil)
+	defer resp.Body.Close()
+	authCode = completeFederatedLogin(t, httpClient, resp)
+	assert.Equal(t, user.Id, authCode.User.Id)
+}
+
+func getUserSessionByUserId(t *testing.T, userId int64) *entities.UserSession {
+	userSessions, err := database.GetUserSessionsByUserId(nil, userId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(t, userSessions, 1)
+	return &userSessions[0]
+}
+
+func TestRiskBasedAuth_FailedAttemptsRequireOtp(t *testing.T) {
+	setup()
+
+	// the failed attempts are counted for the user and for the IP address
+	setRiskSettings(t, 40, 0, 0, "", "")
+
+	user := createOtpTestUser(t)
+
+	for i := 0; i < 2; i++ {
+		_, resp := postPassword(t, user.Email, "wrong-password")
+		assertPwdLoginError(t, resp, "Authentication failed.")
+	}
+
+	httpClient, resp := authenticateWithPasswordOnDevice(t, user, enums.AcrLevel1, nil)
+	defer resp.Body.Close()
+	assertRedirect(t, resp, "/auth/otp")
+
+	resp = getPage(t, httpClient, lib.GetBaseUrl()+"/auth/otp")
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestRiskBasedAuth_SuspiciousIpRangeBlocksLogin(t *testing.T) {
+	setup()
+	setRiskSettings(t, 0, 50, 50, "", getThisIpAddress(t))
+
+	user := createPasskeyTestUser(t, "abc123")
+
+	_, resp := postPassword(t, user.Email, "abc123")
+	assertPwdLoginError(t, resp, "This sign-in looks unusual and was blocked. Please contact the administrator.")
+	assertEmailSent(t, user.Email, "because it looked unusual")
+
+	userSessions, err := database.GetUserSessionsByUserId(nil, user.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(t, userSessions, 0)
+}
+
+func TestRiskBasedAuth_SuspiciousIpRangeBlocksLDAPLogin(t *testing.T) {
+	setup()
+	setRiskSettings(t, 0, 50, 50, "", getThisIpAddress(t))
+
+	directory := newFakeLDAPDirectory(t)
+	createLDAPIdentityProvider(t, directory, false, true, false)
+
+	email := strings.ToLower(gofakeit.Email())
+	directory.addUser(gofakeit.Username(), "ldap-password", map[string][]string{
+		"entryUUID": {uuid.New().String()},
+		"mail":      {email},
+	})
+
+	deleteUserOnCleanup(t, email)
+
+	_, resp := startPwdLogin(t, email, "ldap-password")
+	assertPwdLoginError(t, resp, "This sign-in looks unusual and was blocked. Please contact the administrator.")
+
+	user, err := database.GetUserByEmail(nil, email)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.NotNil(t, user)
+
+	userSessions, err := database.GetUserSessionsByUserId(nil, user.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(t, userSessions, 0)
+}
@@ -0,0 +1,729 @@
+package integrationtests
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/brianvoe/gofakeit/v6"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/leodip/goiabada/internal/constants"
+	"github.com/leodip/goiabada/internal/entities"
+	"github.com/leodip/goiabada/internal/enums"
+	"github.com/leodip/goiabada/internal/lib"
+	"github.com/stretchr/testify/assert"
+)
+
+// getScimAccessToken creates a client with the authserver:scim permission and returns an access
+// token obtained with the client credentials flow.
+func getScimAccessToken(t *testing.T) string {
+	settings, err := database.GetSettingsById(nil, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	clientSecret := lib.GenerateSecureRandomString(60)
+	encClientSecret, err := lib.EncryptText(clientSecret, settings.AESEncryptionKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := &entities.Client{
+		ClientIdentifier:                        "scim-" + strings.ToLower(gofakeit.LetterN(12)),
+		Enabled:                                 true,
+		ClientSecretEncrypted:                   encClientSecret,
+		DefaultAcrLevel:                         enums.AcrLevel1,
+		IncludeOpenIDConnectClaimsInAccessToken: enums.ThreeStateSettingDefault.String(),
+		ClientCredentialsEnabled:                true,
+	}
+	err = database.CreateClient(nil, client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = database.DeleteClient(nil, client.Id)
+	})
+
+	permission := getAuthServerPermission(t, constants.ScimPermissionIdentifier)
+	err = database.CreateClientPermission(nil, &entities.ClientPermission{
+		ClientId:     client.Id,
+		PermissionId: permission.Id,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	httpClient := createHttpClient(&createHttpClientInput{
+		T: t,
+	})
+	data := postToTokenEndpoint(t, httpClient, lib.GetBaseUrl()+"/auth/token", url.Values{
+		"grant_type":    {"client_credentials"},
+		"client_id":     {client.ClientIdentifier},
+		"client_secret": {clientSecret},
+		"scope":         {constants.AuthServerResourceIdentifier + ":" + constants.ScimPermissionIdentifier},
+	})
+	accessToken, ok := data["access_token"].(string)
+	if !ok {
+		t.Fatalf("unable to get an access token: %v", data)
+	}
+	return accessToken
+}
+
+func getAuthServerPermission(t *testing.T, permissionIdentifier string) *entities.Permission {
+	resource, err := database.GetResourceByResourceIdentifier(nil, constants.AuthServerResourceIdentifier)
+	if err != nil {
+		t.Fatal(err)
+	}
+	permissions, err := database.GetPermissionsByResourceId(nil, resource.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for idx, permission := range permissions {
+		if permission.PermissionIdentifier == permissionIdentifier {
+			return &permissions[idx]
+		}
+	}
+	t.Fatalf("permission %v not found", permissionIdentifier)
+	return nil
+}
+
+// scimRequest sends a request to the SCIM API and returns the response, with the body decoded.
+func scimRequest(t *testing.T, accessToken string, method string, path string, body any,
+	headers map[string]string) (*http.Response, map[string]any) {
+
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequest(method, lib.GetBaseUrl()+"/scim/v2"+path, reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(accessToken) > 0 {
+		req.Header.Set("Authorization", "Bearer "+accessToken)
+	}
+	req.Header.Set("Content-Type", "application/scim+json")
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	httpClient := createHttpClient(&createHttpClientInput{
+		T: t,
+	})
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var result map[string]any
+	if len(respBody) > 0 {
+		err = json.Unmarshal(respBody, &result)
+		if err != nil {
+			t.Fatalf("unable to decode %v: %v", string(respBody), err)
+		}
+	}
+	return resp, result
+}
+
+func createScimUser(t *testing.T, accessToken string, body map[string]any) map[string]any {
+	resp, data := scimRequest(t, accessToken, "POST", "/Users", body, nil)
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("unable to create the user: %v", data)
+	}
+	t.Cleanup(func() {
+		user, _ := database.GetUserBySubject(nil, data["id"].(string))
+		if user != nil {
+			_ = database.DeleteUser(nil, user.Id)
+		}
+	})
+	return data
+}
+
+func newScimUser() map[string]any {
+	return map[string]any{
+		"schemas":    []string{"urn:ietf:params:scim:schemas:core:2.0:User"},
+		"userName":   strings.ToLower(gofakeit.Email()),
+		"externalId": gofakeit.UUID(),
+		"name": map[string]any{
+			"givenName":  gofakeit.FirstName(),
+			"familyName": gofakeit.LastName(),
+		},
+		"active": true,
+	}
+}
+
+func getResources(t *testing.T, data map[string]any) []map[string]any {
+	resources := []map[string]any{}
+	items, ok := data["Resources"].([]any)
+	if !ok {
+		t.Fatalf("no Resources in %v", data)
+	}
+	for _, item := range items {
+		resources = append(resources, item.(map[string]any))
+	}
+	return resources
+}
+
+func TestScim_AccessTokenRequired(t *testing.T) {
+	setup()
+
+	resp, data := scimRequest(t, "", "GET", "/Users", nil, nil)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	assert.Equal(t, "401", data["status"])
+	assert.Equal(t, []any{"urn:ietf:params:scim:api:messages:2.0:Error"}, data["schemas"])
+
+	// test-client-1 doesn't have the authserver:scim permission
+	httpClient := createHttpClient(&createHttpClientInput{
+		T: t,
+	})
+	tokenData := postToTokenEndpoint(t, httpClient, lib.GetBaseUrl()+"/auth/token", url.Values{
+		"grant_type":    {"client_credentials"},
+		"client_id":     {"test-client-1"},
+		"client_secret": {getClientSecret(t, "test-client-1")},
+		"scope":         {"backend-svcA:create-product"},
+	})
+
+	resp, data = scimRequest(t, tokenData["access_token"].(string), "GET", "/Users", nil, nil)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	assert.Equal(t, "403", data["status"])
+
+	// refresh tokens carry the same scopes, but are not access tokens
+	refreshToken := signTestToken(t, jwt.MapClaims{
+		"iss":   lib.GetBaseUrl(),
+		"sub":   gofakeit.UUID(),
+		"typ":   enums.TokenTypeRefresh.String(),
+		"exp":   time.Now().Add(5 * time.Minute).Unix(),
+		"scope": constants.AuthServerResourceIdentifier + ":" + constants.ScimPermissionIdentifier,
+	})
+	resp, data = scimRequest(t, refreshToken, "GET", "/Users", nil, nil)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	assert.Equal(t, "401", data["status"])
+}
+
+func TestScim_Discovery(t *testing.T) {
+	setup()
+	accessToken := getScimAccessToken(t)
+
+	resp, data := scimRequest(t, accessToken, "GET", "/ServiceProviderConfig", nil, nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "application/scim+json", resp.Header.Get("Content-Type"))
+	assert.Equal(t, true, data["patch"].(map[string]any)["supported"])
+	assert.Equal(t, true, data["filter"].(map[string]any)["supported"])
+	assert.Equal(t, true, data["etag"].(map[string]any)["supported"])
+	assert.Equal(t, false, data["bulk"].(map[string]any)["supported"])
+
+	resp, data = scimRequest(t, accessToken, "GET", "/ResourceTypes", nil, nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, float64(2), data["totalResults"])
+
+	resp, data = scimRequest(t, accessToken, "GET", "/ResourceTypes/Group", nil, nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "/Groups", data["endpoint"])
+
+	resp, data = scimRequest(t, accessToken, "GET", "/Schemas", nil, nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, float64(2), data["totalResults"])
+
+	resp, data = scimRequest(t, accessToken, "GET", "/Schemas/urn:ietf:params:scim:schemas:core:2.0:User", nil, nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "User", data["name"])
+
+	resp, _ = scimRequest(t, accessToken, "GET", "/Schemas/urn:invalid", nil, nil)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestScim_Users_Create(t *testing.T) {
+	setup()
+	accessToken := getScimAccessToken(t)
+
+	body := newScimUser()
+	body["password"] = "Sc1m-Password!"
+	body["locale"] = "pt-BR"
+	body["timezone"] = "America/Sao_Paulo"
+	body["phoneNumbers"] = []map[string]any{{"value": "+55 11987654321", "type": "mobile"}}
+	body["addresses"] = []map[string]any{{
+		"streetAddress": "Rua das Flores 100\nApt 12",
+		"locality":      "Sao Paulo",
+		"region":        "SP",
+		"postalCode":    "01000-000",
+		"country":       "BR",
+		"primary":       true,
+	}}
+
+	resp, data := scimRequest(t, accessToken, "POST", "/Users", body, nil)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.NotEmpty(t, resp.Header.Get("ETag"))
+	assert.Equal(t, lib.GetBaseUrl()+"/scim/v2/Users/"+data["id"].(string), resp.Header.Get("Location"))
+
+	user, err := database.GetUserBySubject(nil, data["id"].(string))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = database.DeleteUser(nil, user.Id)
+	}()
+
+	assert.Equal(t, body["userName"], user.Email)
+	assert.True(t, user.EmailVerified)
+	assert.True(t, user.Enabled)
+	assert.Equal(t, body["externalId"], user.ExternalId)
+	assert.Equal(t, body["name"].(map[string]any)["givenName"], user.GivenName)
+	assert.Equal(t, body["name"].(map[string]any)["familyName"], user.FamilyName)
+	assert.Equal(t, "pt-BR", user.Locale)
+	assert.Equal(t, "America/Sao_Paulo", user.ZoneInfo)
+	assert.Equal(t, "Brazil", user.ZoneInfoCountryName)
+	assert.Equal(t, "+55 11987654321", user.PhoneNumber)
+	assert.Equal(t, "Rua das Flores 100", user.AddressLine1)
+	assert.Equal(t, "Apt 12", user.AddressLine2)
+	assert.Equal(t, "BRA", user.AddressCountry)
+	assert.True(t, lib.VerifyPasswordHash(user.PasswordHash, "Sc1m-Password!"))
+
+	// the account permission is granted, as with any new user
+	err = database.UserLoadPermissions(nil, user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(t, user.Permissions, 1)
+	assert.Equal(t, constants.ManageAccountPermissionIdentifier, user.Permissions[0].PermissionIdentifier)
+
+	assert.Equal(t, body["userName"], data["userName"])
+	assert.Nil(t, data["password"])
+	assert.Equal(t, "BR", data["addresses"].([]any)[0].(map[string]any)["country"])
+	meta := data["meta"].(map[string]any)
+	assert.Equal(t, "User", meta["resourceType"])
+	assert.Equal(t, resp.Header.Get("ETag"), meta["version"])
+}
+
+func TestScim_Users_Create_Invalid(t *testing.T) {
+	setup()
+	accessToken := getScimAccessToken(t)
+
+	body := newScimUser()
+	body["userName"] = "mauro@outlook.com"
+	resp, data := scimRequest(t, accessToken, "POST", "/Users", body, nil)
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+	assert.Equal(t, "uniqueness", data["scimType"])
+
+	body = newScimUser()
+	body["userName"] = "jdoe"
+	resp, data = scimRequest(t, accessToken, "POST", "/Users", body, nil)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	assert.Equal(t, "invalidValue", data["scimType"])
+
+	body = newScimUser()
+	body["password"] = "a"
+	resp, data = scimRequest(t, accessToken, "POST", "/Users", body, nil)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	assert.Equal(t, "invalidValue", data["scimType"])
+
+	user, err := database.GetUserByEmail(nil, body["userName"].(string))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Nil(t, user)
+}
+
+func TestScim_Users_Get(t *testing.T) {
+	setup()
+	accessToken := getScimAccessToken(t)
+
+	created := createScimUser(t, accessToken, newScimUser())
+	id := created["id"].(string)
+
+	resp, data := scimRequest(t, accessToken, "GET", "/Users/"+id, nil, nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, created["userName"], data["userName"])
+	etag := resp.Header.Get("ETag")
+	assert.True(t, strings.HasPrefix(etag, `W/"`))
+
+	resp, _ = scimRequest(t, accessToken, "GET", "/Users/"+id, nil, map[string]string{"If-None-Match": etag})
+	assert.Equal(t, http.StatusNotModified, resp.StatusCode)
+
+	resp, data = scimRequest(t, accessToken, "GET", "/Users/"+id+"?attributes=userName,name.familyName", nil, nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, id, data["id"])
+	assert.Equal(t, created["userName"], data["userName"])
+	assert.Equal(t, map[string]any{"familyName": created["name"].(map[string]any)["familyName"]}, data["name"])
+	assert.Nil(t, data["emails"])
+
+	resp, data = scimRequest(t, accessToken, "GET", "/Users/00000000-0000-0000-0000-000000000000", nil, nil)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	assert.Equal(t, "404", data["status"])
+}
+
+func TestScim_Users_Filter(t *testing.T) {
+	setup()
+	accessToken := getScimAccessToken(t)
+
+	familyName := "Scim" + gofakeit.LetterN(10)
+	user1 := newScimUser()
+	user1["name"] = map[string]any{"givenName": "Ana", "familyName": familyName}
+	created1 := createScimUser(t, accessToken, user1)
+	user2 := newScimUser()
+	user2["name"] = map[string]any{"givenName": "Bruno", "familyName": familyName}
+	user2["active"] = false
+	created2 := createScimUser(t, accessToken, user2)
+
+	resp, data := scimRequest(t, accessToken, "GET", "/Users?filter="+
+		url.QueryEscape(`userName eq "`+strings.ToUpper(user1["userName"].(string))+`"`), nil, nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, float64(1), data["totalResults"])
+	assert.Equal(t, created1["id"], getResources(t, data)[0]["id"])
+
+	resp, data = scimRequest(t, accessToken, "GET", "/Users?filter="+
+		url.QueryEscape(`externalId eq "`+user2["externalId"].(string)+`"`), nil, nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, float64(1), data["totalResults"])
+	assert.Equal(t, created2["id"], getResources(t, data)[0]["id"])
+
+	resp, data = scimRequest(t, accessToken, "GET", "/Users?filter="+
+		url.QueryEscape(`name.familyName eq "`+familyName+`" and not (active eq false)`), nil, nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, float64(1), data["totalResults"])
+	assert.Equal(t, created1["id"], getResources(t, data)[0]["id"])
+
+	resp, data = scimRequest(t, accessToken, "GET", "/Users?filter="+
+		url.QueryEscape(`name.familyName sw "`+familyName[:8]+`" and (emails[type eq "work" and value co "@"])`)+
+		"&startIndex=2&count=1", nil, nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, float64(2), data["totalResults"])
+	assert.Equal(t, float64(2), data["startIndex"])
+	assert.Equal(t, float64(1), data["itemsPerPage"])
+
+	resp, data = scimRequest(t, accessToken, "GET", "/Users?filter="+url.QueryEscape(`userName xx "a"`), nil, nil)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	assert.Equal(t, "invalidFilter", data["scimType"])
+}
+
+func TestScim_Users_Pagination(t *testing.T) {
+	setup()
+	accessToken := getScimAccessToken(t)
+
+	resp, data := scimRequest(t, accessToken, "GET", "/Users?startIndex=1&count=2", nil, nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	total := data["totalResults"].(float64)
+	assert.Greater(t, total, float64(2))
+	assert.Equal(t, float64(2), data["itemsPerPage"])
+	assert.Len(t, getResources(t, data), 2)
+
+	resp, data = scimRequest(t, accessToken, "GET", "/Users?startIndex=2&count=3", nil, nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, total, data["totalResults"])
+	assert.Len(t, getResources(t, data), 3)
+
+	resp, data = scimRequest(t, accessToken, "GET", "/Users?count=0", nil, nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, total, data["totalResults"])
+	assert.Len(t, getResources(t, data), 0)
+}
+
+func TestScim_Users_Patch(t *testing.T) {
+	setup()
+	accessToken := getScimAccessToken(t)
+
+	created := createScimUser(t, accessToken, newScimUser())
+	id := created["id"].(string)
+	etag := created["meta"].(map[string]any)["version"].(string)
+	newEmail := strings.ToLower(gofakeit.Email())
+
+	// the format some identity providers use (capitalized operations, booleans as strings)
+	patch := map[string]any{
+		"schemas": []string{"urn:ietf:params:scim:api:messages:2.0:PatchOp"},
+		"Operations": []map[string]any{
+			{"op": "Replace", "path": "active", "value": "False"},
+			{"op": "Replace", "path": `emails[type eq "work"].value`, "value": newEmail},
+			{"op": "Add", "value": map[string]any{"name.middleName": "Maria", "nickName": "mary"}},
+			{"op": "Remove", "path": "externalId"},
+		},
+	}
+	resp, data := scimRequest(t, accessToken, "PATCH", "/Users/"+id, patch, map[string]string{"If-Match": etag})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, newEmail, data["userName"])
+	assert.Equal(t, false, data["active"])
+	assert.NotEqual(t, etag, resp.Header.Get("ETag"))
+
+	user, err := database.GetUserBySubject(nil, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, newEmail, user.Email)
+	assert.False(t, user.Enabled)
+	assert.Equal(t, "Maria", user.MiddleName)
+	assert.Equal(t, "mary", user.Nickname)
+	assert.Equal(t, "", user.ExternalId)
+	assert.Equal(t, created["name"].(map[string]any)["givenName"], user.GivenName)
+
+	// the version is outdated
+	resp, data = scimRequest(t, accessToken, "PATCH", "/Users/"+id, patch, map[string]string{"If-Match": etag})
+	assert.Equal(t, http.StatusPreconditionFailed, resp.StatusCode)
+	assert.Equal(t, "412", data["status"])
+
+	patch = map[string]any{
+		"schemas":    []string{"urn:ietf:params:scim:api:messages:2.0:PatchOp"},
+		"Operations": []map[string]any{{"op": "replace", "path": "groups", "value": []any{}}},
+	}
+	resp, data = scimRequest(t, accessToken, "PATCH", "/Users/"+id, patch, nil)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	assert.Equal(t, "mutability", data["scimType"])
+}
+
+func TestScim_Users_Put(t *testing.T) {
+	setup()
+	accessToken := getScimAccessToken(t)
+
+	created := createScimUser(t, accessToken, newScimUser())
+	id := created["id"].(string)
+
+	body := map[string]any{
+		"schemas":  []string{"urn:ietf:params:scim:schemas:core:2.0:User"},
+		"userName": created["userName"],
+		"name":     map[string]any{"givenName": "Carla"},
+		"active":   true,
+	}
+	resp, data := scimRequest(t, accessToken, "PUT", "/Users/"+id, body, nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "Carla", data["name"].(map[string]any)["givenName"])
+
+	user, err := database.GetUserBySubject(nil, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "Carla", user.GivenName)
+	// attributes that are not present are cleared
+	assert.Equal(t, "", user.FamilyName)
+	assert.Equal(t, "", user.ExternalId)
+
+	resp, _ = scimRequest(t, accessToken, "PUT", "/Users/"+id, body, map[string]string{"If-Match": `W/"outdated"`})
+	assert.Equal(t, http.StatusPreconditionFailed, resp.StatusCode)
+}
+
+func TestScim_Users_Delete(t *testing.T) {
+	setup()
+	accessToken := getScimAccessToken(t)
+
+	created := createScimUser(t, accessToken, newScimUser())
+	id := created["id"].(string)
+
+	resp, _ := scimRequest(t, accessToken, "DELETE", "/Users/"+id, nil, nil)
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	user, err := database.GetUserBySubject(nil, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Nil(t, user)
+
+	resp, _ = scimRequest(t, accessToken, "GET", "/Users/"+id, nil, nil)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestScim_AdminsCantBeTakenOver(t *testing.T) {
+	setup()
+	accessToken := getScimAccessToken(t)
+
+	admins := createImportTestGroup(t)
+	err := database.CreateGroupPermission(nil, &entities.GroupPermission{
+		GroupId:      admins.Id,
+		PermissionId: getAuthServerPermission(t, constants.AdminWebsitePermissionIdentifier).Id,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	admin := createCliTestUser(t)
+	err = database.CreateUserGroup(nil, &entities.UserGroup{
+		UserId:  admin.Id,
+		GroupId: admins.Id,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := admin.Subject.String()
+
+	// the password of an admin can't be reset
+	resp, data := scimRequest(t, accessToken, "PUT", "/Users/"+id, map[string]any{
+		"schemas":  []string{"urn:ietf:params:scim:schemas:core:2.0:User"},
+		"userName": admin.Email,
+		"password": "Scim-" + gofakeit.Password(true, true, true, false, false, 12) + "1",
+		"active":   true,
+	}, nil)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	assert.Contains(t, data["detail"], "authserver:"+constants.AdminWebsitePermissionIdentifier)
+
+	// nor the email
+	resp, _ = scimRequest(t, accessToken, "PUT", "/Users/"+id, map[string]any{
+		"schemas":  []string{"urn:ietf:params:scim:schemas:core:2.0:User"},
+		"userName": strings.ToLower(gofakeit.Email()),
+		"active":   true,
+	}, nil)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	resp, _ = scimRequest(t, accessToken, "DELETE", "/Users/"+id, nil, nil)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	user, err := database.GetUserById(nil, admin.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !assert.NotNil(t, user) {
+		return
+	}
+	assert.Equal(t, admin.Email, user.Email)
+	assert.Equal(t, admin.PasswordHash, user.PasswordHash)
+
+	// the rest of the profile can still be provisioned
+	resp, _ = scimRequest(t, accessToken, "PUT", "/Users/"+id, map[string]any{
+		"schemas":  []string{"urn:ietf:params:scim:schemas:core:2.0:User"},
+		"userName": admin.Email,
+		"name":     map[string]any{"givenName": "Carla"},
+		"active":   true,
+	}, nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	// no one can be added to the admins group
+	member := createScimUser(t, accessToken, newScimUser())
+	resp, _ = scimRequest(t, accessToken, "PATCH", fmt.Sprintf("/Groups/%v", admins.Id), map[string]any{
+		"schemas": []string{"urn:ietf:params:scim:api:messages:2.0:PatchOp"},
+		"Operations": []map[string]any{
+			{"op": "add", "path": "members", "value": []map[string]any{{"value": member["id"]}}},
+		},
+	}, nil)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	assert.Equal(t, []string{id}, getMemberIds(t, fmt.Sprint(admins.Id)))
+}
+
+func createScimGroup(t *testing.T, accessToken string, body map[string]any) map[string]any {
+	resp, data := scimRequest(t, accessToken, "POST", "/Groups", body, nil)
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("unable to create the group: %v", data)
+	}
+	t.Cleanup(func() {
+		id, _ := strconv.ParseInt(data["id"].(string), 10, 64)
+		_ = database.DeleteGroup(nil, id)
+	})
+	return data
+}
+
+func getMemberIds(t *testing.T, groupId string) []string {
+	id, err := strconv.ParseInt(groupId, 10, 64)
+	if err != nil {
+		t.Fatal(err)
+	}
+	members, _, err := database.GetGroupMembersPaginated(nil, id, 1, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := []string{}
+	for _, member := range members {
+		ids = append(ids, member.Subject.String())
+	}
+	return ids
+}
+
+func TestScim_Groups(t *testing.T) {
+	setup()
+	accessToken := getScimAccessToken(t)
+
+	user1 := createScimUser(t, accessToken, newScimUser())
+	user2 := createScimUser(t, accessToken, newScimUser())
+	user3 := createScimUser(t, accessToken, newScimUser())
+
+	displayName := "scim-" + strings.ToLower(gofakeit.LetterN(10))
+	group := createScimGroup(t, accessToken, map[string]any{
+		"schemas":     []string{"urn:ietf:params:scim:schemas:core:2.0:Group"},
+		"displayName": displayName,
+		"externalId":  "hr-123",
+		"members":     []map[string]any{{"value": user1["id"]}, {"value": user2["id"]}},
+	})
+	groupId := group["id"].(string)
+	assert.Len(t, group["members"], 2)
+	assert.ElementsMatch(t, []string{user1["id"].(string), user2["id"].(string)}, getMemberIds(t, groupId))
+
+	// the groups of the user are listed on the user
+	_, data := scimRequest(t, accessToken, "GET", "/Users/"+user1["id"].(string), nil, nil)
+	assert.Equal(t, groupId, data["groups"].([]any)[0].(map[string]any)["value"])
+	assert.Equal(t, displayName, data["groups"].([]any)[0].(map[string]any)["display"])
+
+	resp, data := scimRequest(t, accessToken, "GET", "/Groups?excludedAttributes=members&filter="+
+		url.QueryEscape(`displayName eq "`+displayName+`"`), nil, nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, float64(1), data["totalResults"])
+	resources := getResources(t, data)
+	assert.Equal(t, groupId, resources[0]["id"])
+	assert.Equal(t, "hr-123", resources[0]["externalId"])
+	assert.Nil(t, resources[0]["members"])
+
+	resp, data = scimRequest(t, accessToken, "GET", "/Groups?filter="+
+		url.QueryEscape(`members[value eq "`+user2["id"].(string)+`"]`), nil, nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, float64(1), data["totalResults"])
+
+	resp, _ = scimRequest(t, accessToken, "GET", "/Groups/"+groupId, nil, nil)
+	etag := resp.Header.Get("ETag")
+
+	patch := map[string]any{
+		"schemas": []string{"urn:ietf:params:scim:api:messages:2.0:PatchOp"},
+		"Operations": []map[string]any{
+			{"op": "add", "path": "members", "value": []map[string]any{{"value": user3["id"]}}},
+			{"op": "remove", "path": `members[value eq "` + user1["id"].(string) + `"]`},
+		},
+	}
+	resp, data = scimRequest(t, accessToken, "PATCH", "/Groups/"+groupId, patch, map[string]string{"If-Match": etag})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Len(t, data["members"], 2)
+	assert.ElementsMatch(t, []string{user2["id"].(string), user3["id"].(string)}, getMemberIds(t, groupId))
+
+	// the membership changed, so the version did too
+	resp, _ = scimRequest(t, accessToken, "PATCH", "/Groups/"+groupId, patch, map[string]string{"If-Match": etag})
+	assert.Equal(t, http.StatusPreconditionFailed, resp.StatusCode)
+
+	patch = map[string]any{
+		"schemas": []string{"urn:ietf:params:scim:api:messages:2.0:PatchOp"},
+		"Operations": []map[string]any{
+			{"op": "add", "path": "members", "value": []map[string]any{{"value": "00000000-0000-0000-0000-000000000000"}}},
+		},
+	}
+	resp, data = scimRequest(t, accessToken, "PATCH", "/Groups/"+groupId, patch, nil)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	assert.Equal(t, "invalidValue", data["scimType"])
+	assert.Len(t, getMemberIds(t, groupId), 2)
+
+	// PUT replaces the members
+	resp, data = scimRequest(t, accessToken, "PUT", "/Groups/"+groupId, map[string]any{
+		"schemas":     []string{"urn:ietf:params:scim:schemas:core:2.0:Group"},
+		"displayName": displayName + "-renamed",
+		"members":     []map[string]any{{"value": user1["id"]}},
+	}, nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, displayName+"-renamed", data["displayName"])
+	assert.Equal(t, []string{user1["id"].(string)}, getMemberIds(t, groupId))
+
+	resp, data = scimRequest(t, accessToken, "POST", "/Groups", map[string]any{
+		"schemas":     []string{"urn:ietf:params:scim:schemas:core:2.0:Group"},
+		"displayName": displayName + "-renamed",
+	}, nil)
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+	assert.Equal(t, "uniqueness", data["scimType"])
+
+	resp, _ = scimRequest(t, accessToken, "DELETE", "/Groups/"+groupId, nil, nil)
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	resp, _ = scimRequest(t, accessToken, "GET", "/Groups/"+groupId, nil, nil)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
@@ -0,0 +1,183 @@
+package integrationtests
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/brianvoe/gofakeit/v6"
+	"github.com/leodip/goiabada/internal/constants"
+	"github.com/leodip/goiabada/internal/enums"
+	"github.com/leodip/goiabada/internal/lib"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSecurityNotifications_AccountPreferences(t *testing.T) {
+	setup()
+
+	user := createPasskeyTestUser(t, "abc123")
+	httpClient := loginToAccountArea(t, user.Email, "abc123")
+	destUrl := lib.GetBaseUrl() + "/account/notifications"
+
+	resp := getPage(t, httpClient, destUrl)
+	defer resp.Body.Close()
+	resp = postForm(t, httpClient, destUrl, url.Values{
+		"emailChanged":       {"on"},
+		"newDeviceLogin":     {"on"},
+		"gorilla.csrf.Token": {getCsrfValue(t, resp)},
+	})
+	defer resp.Body.Close()
+	assertRedirect(t, resp, "/account/notifications")
+
+	user = getDbUser(t, user.Id)
+	assert.True(t, user.DisablePasswordChangeNotification)
+	assert.False(t, user.DisableEmailChangeNotification)
+	assert.True(t, user.DisableOTPChangeNotification)
+	assert.False(t, user.DisableNewDeviceNotification)
+
+	// the password change is no longer notified
+	resp = getPage(t, httpClient, lib.GetBaseUrl()+"/account/change-password")
+	defer resp.Body.Close()
+	resp = postForm(t, httpClient, lib.GetBaseUrl()+"/account/change-password", url.Values{
+		"currentPassword":         {"abc123"},
+		"newPassword":             {"Xk9#mPq2vL7z"},
+		"newPasswordConfirmation": {"Xk9#mPq2vL7z"},
+		"gorilla.csrf.Token":      {getCsrfValue(t, resp)},
+	})
+	defer resp.Body.Close()
+	assert.True(t, lib.VerifyPasswordHash(getDbUser(t, user.Id).PasswordHash, "Xk9#mPq2vL7z"))
+	assertEmailNotSent(t, user.Email)
+}
+
+func TestSecurityNotifications_EmailChanged(t *testing.T) {
+	setup()
+
+	user := createPasskeyTestUser(t, "abc123")
+	oldEmail := user.Email
+	newEmail := strings.ToLower(gofakeit.Email())
+
+	httpClient := loginToAccountArea(t, user.Email, "abc123")
+	destUrl := lib.GetBaseUrl() + "/account/email"
+
+	resp := getPage(t, httpClient, destUrl)
+	defer resp.Body.Close()
+	resp = postForm(t, httpClient, destUrl, url.Values{
+		"email":              {newEmail},
+		"emailConfirmation":  {newEmail},
+		"gorilla.csrf.Token": {getCsrfValue(t, resp)},
+	})
+	defer resp.Body.Close()
+	assertRedirect(t, resp, "/account/email")
+
+	// both the old and the new address are notified
+	assertEmailSent(t, oldEmail, "was changed to")
+	assertEmailSent(t, newEmail, "was changed to")
+}
+
+func TestSecurityNotifications_NewDeviceLogin(t *testing.T) {
+	setup()
+
+	user := createPasskeyTestUser(t, "abc123")
+
+	// the first device of the user is not considered new
+	_, resp := authenticateWithPasswordOnDevice(t, user, enums.AcrLevel1, nil)
+	defer resp.Body.Close()
+	assertRedirect(t, resp, "/auth/consent")
+	assertEmailNotSent(t, user.Email)
+
+	knownDevices, err := database.GetUserKnownDevicesByUserId(nil, user.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(t, knownDevices, 1)
+
+	// the known device is now another one
+	knownDevices[0].Fingerprint = strings.Repeat("0", 64)
+	err = database.UpdateUserKnownDevice(nil, &knownDevices[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, resp = authenticateWithPasswordOnDevice(t, user, enums.AcrLevel1, nil)
+	defer resp.Body.Close()
+	assertRedirect(t, resp, "/auth/consent")
+	assertEmailSent(t, user.Email, "signed in from a new device")
+
+	knownDevices, err = database.GetUserKnownDevicesByUserId(nil, user.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(t, knownDevices, 2)
+}
+
+func TestSecurityNotifications_EmailChanged_OldAddressAlwaysNotified(t *testing.T) {
+	setup()
+
+	user := createPasskeyTestUser(t, "abc123")
+	user.DisableEmailChangeNotification = true
+	err := database.UpdateUser(nil, user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldEmail := user.Email
+	newEmail := strings.ToLower(gofakeit.Email())
+
+	httpClient := loginToAccountArea(t, user.Email, "abc123")
+	destUrl := lib.GetBaseUrl() + "/account/email"
+
+	resp := getPage(t, httpClient, destUrl)
+	defer resp.Body.Close()
+	resp = postForm(t, httpClient, destUrl, url.Values{
+		"email":              {newEmail},
+		"emailConfirmation":  {newEmail},
+		"gorilla.csrf.Token": {getCsrfValue(t, resp)},
+	})
+	defer resp.Body.Close()
+	assertRedirect(t, resp, "/account/email")
+
+	// the notice to the old address can't be turned off
+	assertEmailSent(t, oldEmail, "was changed to")
+	assertEmailNotSent(t, newEmail)
+}
+
+func TestSecurityNotifications_PasswordSetByAdmin(t *testing.T) {
+	setup()
+
+	user := createPasskeyTestUser(t, "abc123")
+
+	httpClient := loginToAdminArea(t, "admin@example.com", "changeme")
+	destUrl := lib.GetBaseUrl() + fmt.Sprintf("/admin/users/%v/authentication", user.Id)
+
+	resp := getPage(t, httpClient, destUrl)
+	defer resp.Body.Close()
+	resp = postForm(t, httpClient, destUrl, url.Values{
+		"newPassword":        {"Xk9#mPq2vL7z"},
+		"gorilla.csrf.Token": {getCsrfValue(t, resp)},
+	})
+	defer resp.Body.Close()
+	assertRedirect(t, resp, fmt.Sprintf("/admin/users/%v/authentication", user.Id))
+
+	assert.True(t, lib.VerifyPasswordHash(getDbUser(t, user.Id).PasswordHash, "Xk9#mPq2vL7z"))
+	assertEmailSent(t, user.Email, "password of your account was changed")
+}
+
+func TestSecurityNotifications_PasswordSetByApi(t *testing.T) {
+	setup()
+
+	user := createPasskeyTestUser(t, "abc123")
+	accessToken := getAdminApiAccessToken(t, constants.AdminApiUsersReadPermissionIdentifier,
+		constants.AdminApiUsersWritePermissionIdentifier)
+
+	resp, data := apiRequest(t, accessToken, "PUT", fmt.Sprintf("/users/%v", user.Id), map[string]any{
+		"email":         user.Email,
+		"emailVerified": true,
+		"enabled":       true,
+		"password":      "Xk9#mPq2vL7z",
+	})
+	assert.Equal(t, http.StatusOK, resp.StatusCode, data)
+
+	assert.True(t, lib.VerifyPasswordHash(getDbUser(t, user.Id).PasswordHash, "Xk9#mPq2vL7z"))
+	assertEmailSent(t, user.Email, "password of your account was changed")
+}
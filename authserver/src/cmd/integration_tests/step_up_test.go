@@ -0,0 +1,260 @@
+package integrationtests
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/brianvoe/gofakeit/v6"
+	"github.com/leodip/goiabada/internal/entities"
+	"github.com/leodip/goiabada/internal/enums"
+	"github.com/leodip/goiabada/internal/lib"
+	"github.com/pquerna/otp/totp"
+	"github.com/stretchr/testify/assert"
+)
+
+// createStepUpResource creates a resource with one permission, granted to the user (when not nil).
+func createStepUpResource(t *testing.T, user *entities.User) (*entities.Resource, *entities.Permission) {
+	resource := &entities.Resource{
+		ResourceIdentifier: "finance-" + strings.ToLower(gofakeit.LetterN(8)),
+		Description:        "Finance API",
+	}
+	err := database.CreateResource(nil, resource)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = database.DeleteResource(nil, resource.Id)
+	})
+
+	permission := &entities.Permission{
+		ResourceId:           resource.Id,
+		PermissionIdentifier: "approve-" + strings.ToLower(gofakeit.LetterN(8)),
+		Description:          "Approve payments",
+	}
+	err = database.CreatePermission(nil, permission)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = database.DeletePermission(nil, permission.Id)
+	})
+
+	if user != nil {
+		err = database.CreateUserPermission(nil, &entities.UserPermission{
+			UserId:       user.Id,
+			PermissionId: permission.Id,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return resource, permission
+}
+
+// authenticateForScope starts an authorization request for the scope with test-client-2, asking
+// for the ACR level 1, and authenticates with the password.
+func authenticateForScope(t *testing.T, user *entities.User, scope string) (*http.Client, *http.Response) {
+	destUrl := lib.GetBaseUrl() +
+		"/auth/authorize/?client_id=test-client-2&redirect_uri=https://goiabada-test-client:8090/callback.html&response_type=code" +
+		"&code_challenge_method=S256&code_challenge=0BnoD4e6xPCPip8rqZ9Zc2RqWOFfvryu9vzXJN4egoY" +
+		"&response_mode=query&scope=" + url.QueryEscape(scope) + "&state=a1b2c3&nonce=m9n8b7" +
+		"&acr_values=" + enums.AcrLevel1.String()
+
+	httpClient := createHttpClient(&createHttpClientInput{
+		T: t,
+	})
+
+	resp, err := httpClient.Get(destUrl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	assertRedirect(t, resp, "/auth/pwd")
+
+	resp = getPage(t, httpClient, lib.GetBaseUrl()+"/auth/pwd")
+	defer resp.Body.Close()
+	return httpClient, authenticateWithPassword(t, httpClient, user.Email, "abc123", getCsrfValue(t, resp))
+}
+
+func TestStepUp_AdminResourceSettings(t *testing.T) {
+	setup()
+
+	resource, _ := createStepUpResource(t, nil)
+	httpClient := loginToAdminArea(t, "admin@example.com", "changeme")
+	destUrl := fmt.Sprintf("%v/admin/resources/%v/settings", lib.GetBaseUrl(), resource.Id)
+
+	postSettings := func(minAcrLevel string, maxAuthAgeInSeconds string) *http.Response {
+		resp := getPage(t, httpClient, destUrl)
+		defer resp.Body.Close()
+		return postForm(t, httpClient, destUrl, url.Values{
+			"resourceIdentifier":  {resource.ResourceIdentifier},
+			"description":         {resource.Description},
+			"minAcrLevel":         {minAcrLevel},
+			"maxAuthAgeInSeconds": {maxAuthAgeInSeconds},
+			"gorilla.csrf.Token":  {getCsrfValue(t, resp)},
+		})
+	}
+
+	invalidValues := []struct {
+		minAcrLevel         string
+		maxAuthAgeInSeconds string
+		message             string
+	}{
+		{"urn:goiabada:invalid", "0", "Invalid minimum ACR level."},
+		{enums.AcrLevel3.String(), "abc", "The maximum authentication age must be between 0 and 31536000 seconds."},
+		{enums.AcrLevel3.String(), "-1", "The maximum authentication age must be between 0 and 31536000 seconds."},
+	}
+	for _, tc := range invalidValues {
+		t.Run(tc.message+" "+tc.maxAuthAgeInSeconds, func(t *testing.T) {
+			resp := postSettings(tc.minAcrLevel, tc.maxAuthAgeInSeconds)
+			defer resp.Body.Close()
+			assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+			doc, err := goquery.NewDocumentFromReader(resp.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, tc.message, strings.TrimSpace(doc.Find("div.text-error p").Text()))
+		})
+	}
+
+	resp := postSettings(enums.AcrLevel3.String(), "600")
+	defer resp.Body.Close()
+	assertRedirect(t, resp, fmt.Sprintf("/admin/resources/%v/settings", resource.Id))
+
+	dbResource, err := database.GetResourceById(nil, resource.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, enums.AcrLevel3, dbResource.MinAcrLevel)
+	assert.Equal(t, 600, dbResource.MaxAuthAgeInSeconds)
+
+	// blank values remove the requirements
+	resp = postSettings("", "")
+	defer resp.Body.Close()
+	assertRedirect(t, resp, fmt.Sprintf("/admin/resources/%v/settings", resource.Id))
+
+	dbResource, err = database.GetResourceById(nil, resource.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, enums.AcrLevel(""), dbResource.MinAcrLevel)
+	assert.Equal(t, 0, dbResource.MaxAuthAgeInSeconds)
+}
+
+func TestStepUp_PermissionForcesOtp(t *testing.T) {
+	setup()
+
+	user := createOtpTestUser(t)
+	resource, permission := createStepUpResource(t, user)
+	scope := "openid " + resource.ResourceIdentifier + ":" + permission.PermissionIdentifier
+
+	// without requirements, the ACR level 1 doesn't ask for the OTP
+	httpClient, resp := authenticateForScope(t, user, scope)
+	defer resp.Body.Close()
+	authCode := completeFederatedLogin(t, httpClient, resp)
+	assert.Equal(t, enums.AcrLevel1.String(), authCode.AcrLevel)
+
+	permission.MinAcrLevel = enums.AcrLevel3
+	err := database.UpdatePermission(nil, permission)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the permission raises the ACR level requested by the client
+	httpClient, resp = authenticateForScope(t, user, scope)
+	defer resp.Body.Close()
+	assertRedirect(t, resp, "/auth/otp")
+
+	resp = getPage(t, httpClient, lib.GetBaseUrl()+"/auth/otp")
+	defer resp.Body.Close()
+	otpCode, err := totp.GenerateCode(user.OTPSecret, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp = authenticateWithOtp(t, httpClient, otpCode, getCsrfValue(t, resp))
+	defer resp.Body.Close()
+
+	authCode = completeFederatedLogin(t, httpClient, resp)
+	assert.Equal(t, user.Id, authCode.User.Id)
+	assert.Equal(t, enums.AcrLevel3.String(), authCode.AcrLevel)
+	assert.Equal(t, "pwd otp", authCode.AuthMethods)
+	assert.Equal(t, scope, authCode.Scope)
+}
+
+func TestStepUp_RefreshTokenRefused(t *testing.T) {
+	setup()
+
+	user := createPasskeyTestUser(t, "abc123")
+	resource, permission := createStepUpResource(t, user)
+	scope := "openid " + resource.ResourceIdentifier + ":" + permission.PermissionIdentifier
+
+	httpClient, resp := authenticateForScope(t, user, scope)
+	defer resp.Body.Close()
+	assertRedirect(t, resp, "/auth/consent")
+	resp = getPage(t, httpClient, lib.GetBaseUrl()+"/auth/consent")
+	defer resp.Body.Close()
+	assertRedirect(t, resp, "/callback.html")
+	codeVal, _ := getCodeAndStateFromUrl(t, resp)
+
+	destUrl := lib.GetBaseUrl() + "/auth/token"
+	respData := postToTokenEndpoint(t, httpClient, destUrl, url.Values{
+		"client_id":     {"test-client-2"},
+		"grant_type":    {"authorization_code"},
+		"redirect_uri":  {"https://goiabada-test-client:8090/callback.html"},
+		"code":          {codeVal},
+		"code_verifier": {"DdazqdVNuDmRLGGRGQKKehEaoFeatACtNsM2UYGwuHkhBhDsTSzaCqWttcBc0kGx"},
+	})
+	assert.NotEmpty(t, respData["refresh_token"])
+	refreshToken := respData["refresh_token"].(string)
+
+	refresh := func() map[string]interface{} {
+		return postToTokenEndpoint(t, httpClient, destUrl, url.Values{
+			"client_id":     {"test-client-2"},
+			"grant_type":    {"refresh_token"},
+			"refresh_token": {refreshToken},
+		})
+	}
+	expectedError := fmt.Sprintf("Scope '%v' requires a stronger or more recent authentication than the one of the refresh token. Please authenticate the user again.",
+		resource.ResourceIdentifier+":"+permission.PermissionIdentifier)
+
+	// the session was authenticated with the ACR level 1
+	permission.MinAcrLevel = enums.AcrLevel2
+	err := database.UpdatePermission(nil, permission)
+	if err != nil {
+		t.Fatal(err)
+	}
+	respData = refresh()
+	assert.Equal(t, "invalid_grant", respData["error"])
+	assert.Equal(t, expectedError, respData["error_description"])
+
+	// the requirement of the resource applies to all of its permissions
+	permission.MinAcrLevel = ""
+	err = database.UpdatePermission(nil, permission)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resource.MaxAuthAgeInSeconds = 1
+	err = database.UpdateResource(nil, resource)
+	if err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(2 * time.Second)
+	respData = refresh()
+	assert.Equal(t, "invalid_grant", respData["error"])
+	assert.Equal(t, expectedError, respData["error_description"])
+
+	resource.MaxAuthAgeInSeconds = 0
+	err = database.UpdateResource(nil, resource)
+	if err != nil {
+		t.Fatal(err)
+	}
+	respData = refresh()
+	assert.Nil(t, respData["error"])
+	assert.NotEmpty(t, respData["access_token"])
+}
@@ -0,0 +1,73 @@
+package cli
+
+import (
+	"log/slog"
+	"time"
+
+	core_audit "github.com/leodip/goiabada/internal/core/audit"
+	"github.com/leodip/goiabada/internal/data"
+	"github.com/leodip/goiabada/internal/lib"
+	"github.com/spf13/viper"
+)
+
+// startAuditSinks starts the audit sinks enabled in the configuration. The expired events in the
+// database are deleted at the cleanup interval, when it's greater than zero. The sinks are closed
+// in Run, delivering the pending events.
+func startAuditSinks(database data.Database, cleanupInterval time.Duration) error {
+
+	if viper.GetBool("Auditing.Database.Enabled") {
+		recorder := core_audit.NewRecorder(database, viper.GetInt("Auditing.Database.RetentionInDays"))
+		recorder.Start(cleanupInterval)
+		lib.AddAuditSink(recorder)
+		slog.Debug("recording the audit events in the database")
+	}
+
+	if viper.GetBool("Auditing.File.Enabled") {
+		fileSink, err := core_audit.NewFileSink(core_audit.FileSinkConfig{
+			Path:       viper.GetString("Auditing.File.Path"),
+			MaxSize:    viper.GetInt64("Auditing.File.MaxSizeInMB") * 1024 * 1024,
+			MaxBackups: viper.GetInt("Auditing.File.MaxBackups"),
+		})
+		if err != nil {
+			return err
+		}
+		fileSink.Start()
+		lib.AddAuditSink(fileSink)
+		slog.Debug("writing the audit events to " + viper.GetString("Auditing.File.Path"))
+	}
+
+	if viper.GetBool("Auditing.Syslog.Enabled") {
+		syslogSink, err := core_audit.NewSyslogSink(core_audit.SyslogSinkConfig{
+			Network:  viper.GetString("Auditing.Syslog.Network"),
+			Address:  viper.GetString("Auditing.Syslog.Address"),
+			Facility: viper.GetString("Auditing.Syslog.Facility"),
+			AppName:  viper.GetString("Auditing.Syslog.AppName"),
+		})
+		if err != nil {
+			return err
+		}
+		syslogSink.Start()
+		lib.AddAuditSink(syslogSink)
+		slog.Debug("sending the audit events to syslog at " + viper.GetString("Auditing.Syslog.Address"))
+	}
+
+	if viper.GetBool("Auditing.Webhook.Enabled") {
+		webhookSink, err := core_audit.NewWebhookSink(core_audit.WebhookSinkConfig{
+			Url:            viper.GetString("Auditing.Webhook.Url"),
+			Secret:         viper.GetString("Auditing.Webhook.Secret"),
+			BatchSize:      viper.GetInt("Auditing.Webhook.BatchSize"),
+			FlushInterval:  time.Duration(viper.GetInt("Auditing.Webhook.FlushIntervalInSeconds")) * time.Second,
+			Timeout:        time.Duration(viper.GetInt("Auditing.Webhook.TimeoutInSeconds")) * time.Second,
+			MaxRetries:     viper.GetInt("Auditing.Webhook.MaxRetries"),
+			DeadLetterPath: viper.GetString("Auditing.Webhook.DeadLetterPath"),
+		})
+		if err != nil {
+			return err
+		}
+		webhookSink.Start()
+		lib.AddAuditSink(webhookSink)
+		slog.Debug("posting the audit events to " + viper.GetString("Auditing.Webhook.Url"))
+	}
+
+	return nil
+}
@@ -0,0 +1,59 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/leodip/goiabada/internal/constants"
+	"github.com/leodip/goiabada/internal/core"
+	"github.com/leodip/goiabada/internal/customerrors"
+	"github.com/leodip/goiabada/internal/lib"
+)
+
+func (c *Cli) breachedPasswordsImport(args []string) error {
+
+	flagSet := c.newFlagSet("breached-passwords import")
+	file := flagSet.String("file", "", "Have I Been Pwned file to import (required)")
+	err := parseFlags(flagSet, args)
+	if err != nil {
+		return err
+	}
+
+	if strings.TrimSpace(*file) == "" {
+		return customerrors.NewValidationError("", "The file is required (-file).")
+	}
+
+	reader, err := os.Open(*file)
+	if err != nil {
+		return customerrors.NewValidationError("", fmt.Sprintf("Unable to read the file: %v.", err))
+	}
+	defer reader.Close()
+
+	database, ctx, err := openDatabase()
+	if err != nil {
+		return err
+	}
+	breachedPasswordChecker := core.NewBreachedPasswordChecker(database)
+
+	importedCount, err := breachedPasswordChecker.ImportCorpus(reader, *file)
+	if err != nil {
+		if importedCount > 0 {
+			fmt.Fprintf(c.stderr, "%v hashes were imported before the error. Import the file again to continue.\n", importedCount)
+		}
+		return err
+	}
+
+	lib.LogAudit(ctx, constants.AuditImportedBreachedPasswords, map[string]interface{}{
+		"fileName":      *file,
+		"importedCount": importedCount,
+		"cliUser":       getCliUser(),
+	})
+
+	hashCount, err := breachedPasswordChecker.CountHashes()
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(c.stdout, "Imported %v new hashes. The corpus has %v hashes.\n", importedCount, hashCount)
+	return nil
+}
@@ -0,0 +1,200 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/user"
+	"strings"
+
+	"github.com/leodip/goiabada/internal/common"
+	"github.com/leodip/goiabada/internal/constants"
+	"github.com/leodip/goiabada/internal/customerrors"
+	"github.com/leodip/goiabada/internal/data"
+	"github.com/leodip/goiabada/internal/initialization"
+	"github.com/leodip/goiabada/internal/lib"
+	"github.com/pkg/errors"
+)
+
+const usage = `Usage: goiabada <command> [arguments]
+
+Commands:
+  serve                    start the server (default when no command is given)
+  user create              create a user
+  user reset-password      set a new password for a user, and unlock the user
+  user disable-otp         disable the one-time password (OTP) of a user
+  client create            create a client
+  client rotate-secret     generate a new secret for a client
+  keys rotate              rotate the signing keys
+  breached-passwords import
+                           import a file of breached password hashes
+  config export            export the configuration to a YAML or JSON document
+  config apply             apply a configuration document (-dry-run shows the changes)
+  migrate up               apply the pending database migrations
+  migrate down             revert the last database migrations
+  migrate status           show the version of the database schema
+  version                  show the version of goiabada
+
+Run 'goiabada <command> -h' for the arguments of a command.
+`
+
+// Cli runs the commands of the goiabada binary. The commands operate directly on the configured
+// database, with the same validations and audit events of the admin area.
+type Cli struct {
+	stdout io.Writer
+	stderr io.Writer
+	stdin  io.Reader
+}
+
+func NewCli(stdout io.Writer, stderr io.Writer, stdin io.Reader) *Cli {
+	return &Cli{
+		stdout: stdout,
+		stderr: stderr,
+		stdin:  stdin,
+	}
+}
+
+// Run executes the command in the arguments, which don't include the name of the binary.
+func (c *Cli) Run(args []string) error {
+
+	initialization.InitViper()
+	defer lib.CloseAuditSinks()
+
+	if len(args) == 0 {
+		return c.serve()
+	}
+
+	command := args[0]
+	subcommand := ""
+	if len(args) > 1 {
+		subcommand = args[1]
+	}
+
+	switch command {
+	case "serve":
+		return c.serve()
+	case "version":
+		fmt.Fprintf(c.stdout, "goiabada %v (build date: %v, git commit: %v)\n", constants.Version, constants.BuildDate, constants.GitCommit)
+		return nil
+	case "help", "-h", "--help":
+		fmt.Fprint(c.stdout, usage)
+		return nil
+	case "user":
+		switch subcommand {
+		case "create":
+			return c.userCreate(args[2:])
+		case "reset-password":
+			return c.userResetPassword(args[2:])
+		case "disable-otp":
+			return c.userDisableOTP(args[2:])
+		}
+	case "client":
+		switch subcommand {
+		case "create":
+			return c.clientCreate(args[2:])
+		case "rotate-secret":
+			return c.clientRotateSecret(args[2:])
+		}
+	case "keys":
+		if subcommand == "rotate" {
+			return c.keysRotate(args[2:])
+		}
+	case "breached-passwords":
+		if subcommand == "import" {
+			return c.breachedPasswordsImport(args[2:])
+		}
+	case "config":
+		switch subcommand {
+		case "export":
+			return c.configExport(args[2:])
+		case "apply":
+			return c.configApply(args[2:])
+		}
+	case "migrate":
+		switch subcommand {
+		case "up":
+			return c.migrateUp(args[2:])
+		case "down":
+			return c.migrateDown(args[2:])
+		case "status":
+			return c.migrateStatus(args[2:])
+		}
+	}
+
+	fmt.Fprint(c.stderr, usage)
+	return customerrors.NewValidationError("", fmt.Sprintf("Unknown command: %v.", strings.TrimSpace(command+" "+subcommand)))
+}
+
+func (c *Cli) newFlagSet(name string) *flag.FlagSet {
+	flagSet := flag.NewFlagSet("goiabada "+name, flag.ContinueOnError)
+	flagSet.SetOutput(c.stderr)
+	return flagSet
+}
+
+// parseFlags parses the arguments of a command. Positional arguments are not accepted.
+func parseFlags(flagSet *flag.FlagSet, args []string) error {
+	err := flagSet.Parse(args)
+	if err != nil {
+		return err
+	}
+	if flagSet.NArg() > 0 {
+		return customerrors.NewValidationError("", fmt.Sprintf("Unexpected argument: %v.", flagSet.Arg(0)))
+	}
+	return nil
+}
+
+// openDatabase connects to the database, applying the pending migrations as the server does, and
+// returns a context with the settings, as expected by the validators.
+func openDatabase() (data.Database, context.Context, error) {
+	database, err := data.NewDatabase()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	settings, err := database.GetSettingsById(nil, 1)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// the events of the command are recorded before it exits, see Run
+	err = startAuditSinks(database, 0)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ctx := context.WithValue(context.Background(), common.ContextKeySettings, settings)
+	return database, ctx, nil
+}
+
+// readPassword returns the password passed as argument or, when requested, the first line of the
+// standard input, so the password doesn't show in the list of processes.
+func (c *Cli) readPassword(password string, fromStdin bool) (string, error) {
+	if !fromStdin {
+		return password, nil
+	}
+	if len(password) > 0 {
+		return "", customerrors.NewValidationError("", "Use either -password or -password-stdin.")
+	}
+
+	line, err := bufio.NewReader(c.stdin).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return "", errors.Wrap(err, "unable to read the password from the standard input")
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// getCliUser returns the name of the operating system user running the command, which is recorded
+// in the audit events.
+func getCliUser() string {
+	currentUser, err := user.Current()
+	if err == nil && len(currentUser.Username) > 0 {
+		return currentUser.Username
+	}
+	if name := os.Getenv("USER"); len(name) > 0 {
+		return name
+	}
+	return "unknown"
+}
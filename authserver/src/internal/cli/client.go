@@ -0,0 +1,142 @@
+package cli
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/leodip/goiabada/internal/common"
+	"github.com/leodip/goiabada/internal/constants"
+	"github.com/leodip/goiabada/internal/core"
+	core_validators "github.com/leodip/goiabada/internal/core/validators"
+	"github.com/leodip/goiabada/internal/customerrors"
+	"github.com/leodip/goiabada/internal/entities"
+	"github.com/leodip/goiabada/internal/enums"
+	"github.com/leodip/goiabada/internal/lib"
+)
+
+func (c *Cli) clientCreate(args []string) error {
+
+	flagSet := c.newFlagSet("client create")
+	clientIdentifier := flagSet.String("identifier", "", "client identifier (required)")
+	description := flagSet.String("description", "", "description of the client")
+	authorizationCodeEnabled := flagSet.Bool("authorization-code", false, "enable the authorization code flow")
+	clientCredentialsEnabled := flagSet.Bool("client-credentials", false, "enable the client credentials flow")
+	err := parseFlags(flagSet, args)
+	if err != nil {
+		return err
+	}
+
+	database, ctx, err := openDatabase()
+	if err != nil {
+		return err
+	}
+	identifierValidator := core_validators.NewIdentifierValidator(database)
+	inputSanitizer := core.NewInputSanitizer()
+
+	if strings.TrimSpace(*clientIdentifier) == "" {
+		return customerrors.NewValidationError("", "Client identifier is required (-identifier).")
+	}
+
+	const maxLengthDescription = 100
+	if len(*description) > maxLengthDescription {
+		return customerrors.NewValidationError("", "The description cannot exceed a maximum length of "+strconv.Itoa(maxLengthDescription)+" characters.")
+	}
+
+	err = identifierValidator.ValidateIdentifier(*clientIdentifier, true)
+	if err != nil {
+		return err
+	}
+
+	existingClient, err := database.GetClientByClientIdentifier(nil, *clientIdentifier)
+	if err != nil {
+		return err
+	}
+	if existingClient != nil {
+		return customerrors.NewValidationError("", "The client identifier is already in use.")
+	}
+
+	settings := ctx.Value(common.ContextKeySettings).(*entities.Settings)
+
+	clientSecret := lib.GenerateSecureRandomString(60)
+	clientSecretEncrypted, err := lib.EncryptText(clientSecret, settings.AESEncryptionKey)
+	if err != nil {
+		return err
+	}
+
+	client := &entities.Client{
+		ClientIdentifier:         strings.TrimSpace(inputSanitizer.Sanitize(*clientIdentifier)),
+		Description:              strings.TrimSpace(inputSanitizer.Sanitize(*description)),
+		ClientSecretEncrypted:    clientSecretEncrypted,
+		IsPublic:                 false,
+		ConsentRequired:          false,
+		Enabled:                  true,
+		DefaultAcrLevel:          enums.AcrLevel2,
+		AuthorizationCodeEnabled: *authorizationCodeEnabled,
+		ClientCredentialsEnabled: *clientCredentialsEnabled,
+	}
+	err = database.CreateClient(nil, client)
+	if err != nil {
+		return err
+	}
+
+	lib.LogAudit(ctx, constants.AuditCreatedClient, map[string]interface{}{
+		"clientId":         client.Id,
+		"clientIdentifier": client.ClientIdentifier,
+		"cliUser":          getCliUser(),
+	})
+
+	fmt.Fprintf(c.stdout, "Created client %v (%v).\nClient secret: %v\n", client.Id, client.ClientIdentifier, clientSecret)
+	return nil
+}
+
+func (c *Cli) clientRotateSecret(args []string) error {
+
+	flagSet := c.newFlagSet("client rotate-secret")
+	clientIdentifier := flagSet.String("identifier", "", "client identifier (required)")
+	err := parseFlags(flagSet, args)
+	if err != nil {
+		return err
+	}
+
+	database, ctx, err := openDatabase()
+	if err != nil {
+		return err
+	}
+
+	if strings.TrimSpace(*clientIdentifier) == "" {
+		return customerrors.NewValidationError("", "Client identifier is required (-identifier).")
+	}
+
+	client, err := database.GetClientByClientIdentifier(nil, strings.TrimSpace(*clientIdentifier))
+	if err != nil {
+		return err
+	}
+	if client == nil {
+		return customerrors.NewValidationError("", fmt.Sprintf("Client not found: %v.", *clientIdentifier))
+	}
+	if client.IsPublic {
+		return customerrors.NewValidationError("", "Public clients don't have a client secret.")
+	}
+
+	settings := ctx.Value(common.ContextKeySettings).(*entities.Settings)
+
+	clientSecret := lib.GenerateSecureRandomString(60)
+	client.ClientSecretEncrypted, err = lib.EncryptText(clientSecret, settings.AESEncryptionKey)
+	if err != nil {
+		return err
+	}
+
+	err = database.UpdateClient(nil, client)
+	if err != nil {
+		return err
+	}
+
+	lib.LogAudit(ctx, constants.AuditUpdatedClientAuthentication, map[string]interface{}{
+		"clientId": client.Id,
+		"cliUser":  getCliUser(),
+	})
+
+	fmt.Fprintf(c.stdout, "Client secret: %v\n", clientSecret)
+	return nil
+}
@@ -0,0 +1,122 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/leodip/goiabada/internal/constants"
+	"github.com/leodip/goiabada/internal/core"
+	core_config "github.com/leodip/goiabada/internal/core/config"
+	core_validators "github.com/leodip/goiabada/internal/core/validators"
+	"github.com/leodip/goiabada/internal/customerrors"
+	"github.com/leodip/goiabada/internal/lib"
+	"github.com/pkg/errors"
+)
+
+func (c *Cli) configExport(args []string) error {
+
+	flagSet := c.newFlagSet("config export")
+	format := flagSet.String("format", "yaml", "format of the document (yaml or json)")
+	output := flagSet.String("output", "", "file to write the document to (default: standard output)")
+	err := parseFlags(flagSet, args)
+	if err != nil {
+		return err
+	}
+
+	database, _, err := openDatabase()
+	if err != nil {
+		return err
+	}
+
+	document, err := core_config.NewExporter(database).Export()
+	if err != nil {
+		return err
+	}
+
+	data, err := core_config.MarshalDocument(document, strings.ToLower(*format))
+	if err != nil {
+		return err
+	}
+
+	if len(*output) == 0 {
+		_, err = c.stdout.Write(data)
+		return errors.WithStack(err)
+	}
+
+	err = os.WriteFile(*output, data, 0600)
+	if err != nil {
+		return errors.Wrap(err, "unable to write the document")
+	}
+	fmt.Fprintf(c.stdout, "The configuration was exported to %v.\n", *output)
+	return nil
+}
+
+func (c *Cli) configApply(args []string) error {
+
+	flagSet := c.newFlagSet("config apply")
+	file := flagSet.String("file", "", "YAML or JSON document to apply (required)")
+	dryRun := flagSet.Bool("dry-run", false, "show the changes without applying them")
+	err := parseFlags(flagSet, args)
+	if err != nil {
+		return err
+	}
+
+	if strings.TrimSpace(*file) == "" {
+		return customerrors.NewValidationError("", "The document is required (-file).")
+	}
+
+	data, err := os.ReadFile(*file)
+	if err != nil {
+		return customerrors.NewValidationError("", fmt.Sprintf("Unable to read the document: %v.", err))
+	}
+
+	document, err := core_config.ParseDocument(data)
+	if err != nil {
+		return err
+	}
+
+	database, ctx, err := openDatabase()
+	if err != nil {
+		return err
+	}
+
+	reconciler := core_config.NewReconciler(database, core_validators.NewIdentifierValidator(database), core.NewInputSanitizer())
+	changes, err := reconciler.Apply(document, *dryRun)
+	if err != nil {
+		return err
+	}
+
+	created, updated, deleted := 0, 0, 0
+	for _, change := range changes {
+		fmt.Fprintln(c.stdout, change.String())
+		switch change.Action {
+		case core_config.ChangeActionCreate:
+			created++
+		case core_config.ChangeActionUpdate:
+			updated++
+		case core_config.ChangeActionDelete:
+			deleted++
+		}
+	}
+
+	if len(changes) == 0 {
+		fmt.Fprintln(c.stdout, "No changes. The configuration is up to date.")
+		return nil
+	}
+
+	if *dryRun {
+		fmt.Fprintf(c.stdout, "Plan: %v to create, %v to update, %v to delete. Nothing was changed (dry run).\n", created, updated, deleted)
+		return nil
+	}
+
+	lib.LogAudit(ctx, constants.AuditAppliedConfiguration, map[string]interface{}{
+		"created": created,
+		"updated": updated,
+		"deleted": deleted,
+		"cliUser": getCliUser(),
+	})
+
+	fmt.Fprintf(c.stdout, "Applied: %v created, %v updated, %v deleted.\n", created, updated, deleted)
+	return nil
+}
@@ -0,0 +1,39 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/leodip/goiabada/internal/constants"
+	"github.com/leodip/goiabada/internal/core"
+	"github.com/leodip/goiabada/internal/lib"
+)
+
+func (c *Cli) keysRotate(args []string) error {
+
+	flagSet := c.newFlagSet("keys rotate")
+	err := parseFlags(flagSet, args)
+	if err != nil {
+		return err
+	}
+
+	database, ctx, err := openDatabase()
+	if err != nil {
+		return err
+	}
+
+	err = core.NewKeyRotator(database).RotateKeys()
+	if err != nil {
+		return err
+	}
+
+	lib.LogAudit(ctx, constants.AuditRotatedKeys, map[string]interface{}{
+		"cliUser": getCliUser(),
+	})
+
+	currentKey, err := database.GetCurrentSigningKey(nil)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(c.stdout, "The signing keys were rotated. The current key is %v.\n", currentKey.KeyIdentifier)
+	return nil
+}
@@ -0,0 +1,101 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/leodip/goiabada/internal/customerrors"
+	"github.com/leodip/goiabada/internal/data"
+)
+
+// The migrate commands don't seed the database, which happens when the server starts.
+
+func (c *Cli) migrateUp(args []string) error {
+
+	flagSet := c.newFlagSet("migrate up")
+	err := parseFlags(flagSet, args)
+	if err != nil {
+		return err
+	}
+
+	database, err := data.OpenDatabase()
+	if err != nil {
+		return err
+	}
+
+	err = database.Migrate()
+	if err != nil {
+		return err
+	}
+	return c.printMigrationStatus(database)
+}
+
+func (c *Cli) migrateDown(args []string) error {
+
+	flagSet := c.newFlagSet("migrate down")
+	steps := flagSet.Int("steps", 1, "number of migrations to revert")
+	err := parseFlags(flagSet, args)
+	if err != nil {
+		return err
+	}
+
+	if *steps < 1 {
+		return customerrors.NewValidationError("", "The number of steps must be at least 1.")
+	}
+
+	database, err := data.OpenDatabase()
+	if err != nil {
+		return err
+	}
+
+	version, _, err := database.GetMigrationVersion()
+	if err != nil {
+		return err
+	}
+	if uint(*steps) > version {
+		return customerrors.NewValidationError("", fmt.Sprintf("Unable to revert %v migrations, the database is at version %v.", *steps, version))
+	}
+
+	err = database.MigrateDown(*steps)
+	if err != nil {
+		return err
+	}
+	return c.printMigrationStatus(database)
+}
+
+func (c *Cli) migrateStatus(args []string) error {
+
+	flagSet := c.newFlagSet("migrate status")
+	err := parseFlags(flagSet, args)
+	if err != nil {
+		return err
+	}
+
+	database, err := data.OpenDatabase()
+	if err != nil {
+		return err
+	}
+	return c.printMigrationStatus(database)
+}
+
+func (c *Cli) printMigrationStatus(database data.Database) error {
+
+	version, dirty, err := database.GetMigrationVersion()
+	if err != nil {
+		return err
+	}
+	latestVersion, err := database.GetLatestMigrationVersion()
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(c.stdout, "Database version: %v\n", version)
+	fmt.Fprintf(c.stdout, "Latest version: %v\n", latestVersion)
+	if dirty {
+		fmt.Fprintln(c.stdout, "Status: dirty, the last migration failed and must be fixed manually")
+	} else if version < latestVersion {
+		fmt.Fprintf(c.stdout, "Status: %v pending migrations\n", latestVersion-version)
+	} else {
+		fmt.Fprintln(c.stdout, "Status: up to date")
+	}
+	return nil
+}
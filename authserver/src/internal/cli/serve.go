@@ -0,0 +1,90 @@
+package cli
+
+import (
+	"encoding/gob"
+	"log/slog"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/leodip/goiabada/internal/constants"
+	core_webhooks "github.com/leodip/goiabada/internal/core/webhooks"
+	"github.com/leodip/goiabada/internal/data"
+	"github.com/leodip/goiabada/internal/dtos"
+	"github.com/leodip/goiabada/internal/initialization"
+	"github.com/leodip/goiabada/internal/lib"
+	"github.com/leodip/goiabada/internal/server"
+	"github.com/leodip/goiabada/internal/sessionstore"
+	"github.com/spf13/viper"
+)
+
+func (c *Cli) serve() error {
+
+	slog.Info("application starting")
+
+	dir, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	slog.Info("current directory: " + dir)
+
+	slog.Info("goiabada version: " + constants.Version)
+	slog.Info("build date: " + constants.BuildDate)
+	slog.Info("git commit: " + constants.GitCommit)
+
+	initialization.InitTimeZones()
+
+	// gob registration
+	gob.Register(dtos.TokenResponse{})
+
+	database, err := data.NewDatabase()
+	if err != nil {
+		return err
+	}
+	slog.Info("created database connection")
+
+	err = startAuditSinks(database, time.Hour)
+	if err != nil {
+		return err
+	}
+	slog.Info("started audit sinks")
+
+	webhookDispatcher := core_webhooks.NewDispatcher(database, core_webhooks.DispatcherConfig{
+		PollInterval:    time.Duration(viper.GetInt("Webhooks.PollIntervalInSeconds")) * time.Second,
+		Timeout:         time.Duration(viper.GetInt("Webhooks.TimeoutInSeconds")) * time.Second,
+		MaxAttempts:     viper.GetInt("Webhooks.MaxAttempts"),
+		RetryInterval:   time.Duration(viper.GetInt("Webhooks.RetryIntervalInSeconds")) * time.Second,
+		RetentionInDays: viper.GetInt("Webhooks.DeliveryRetentionInDays"),
+	})
+	webhookDispatcher.Start()
+	defer webhookDispatcher.Close()
+	slog.Info("started webhook dispatcher")
+
+	settings, err := database.GetSettingsById(nil, 1)
+	if err != nil {
+		return err
+	}
+
+	sqlStore, err := sessionstore.NewSQLStore(
+		database,
+		"/",
+		86400*365*2,          // max age
+		true,                 // http only
+		lib.IsHttpsEnabled(), // secure
+		http.SameSiteLaxMode, // same site
+		settings.SessionAuthenticationKey,
+		settings.SessionEncryptionKey)
+
+	if err != nil {
+		return err
+	}
+	sqlStore.Cleanup(time.Minute * 10)
+	slog.Info("initialized session store")
+
+	r := chi.NewRouter()
+	s := server.NewServer(r, database, sqlStore)
+
+	s.Start(settings)
+	return nil
+}
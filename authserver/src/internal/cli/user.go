@@ -0,0 +1,285 @@
+package cli
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/leodip/goiabada/internal/constants"
+	"github.com/leodip/goiabada/internal/core"
+	core_validators "github.com/leodip/goiabada/internal/core/validators"
+	core_webhooks "github.com/leodip/goiabada/internal/core/webhooks"
+	"github.com/leodip/goiabada/internal/customerrors"
+	"github.com/leodip/goiabada/internal/data"
+	"github.com/leodip/goiabada/internal/entities"
+	"github.com/leodip/goiabada/internal/lib"
+	"github.com/pkg/errors"
+)
+
+func getUserByEmail(database data.Database, email string) (*entities.User, error) {
+	email = strings.ToLower(strings.TrimSpace(email))
+	if len(email) == 0 {
+		return nil, customerrors.NewValidationError("", "The email address is required (-email).")
+	}
+	user, err := database.GetUserByEmail(nil, email)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, customerrors.NewValidationError("", fmt.Sprintf("User not found: %v.", email))
+	}
+	return user, nil
+}
+
+func (c *Cli) userCreate(args []string) error {
+
+	flagSet := c.newFlagSet("user create")
+	email := flagSet.String("email", "", "email address of the user (required)")
+	emailVerified := flagSet.Bool("email-verified", false, "mark the email address as verified")
+	password := flagSet.String("password", "", "password of the user")
+	passwordStdin := flagSet.Bool("password-stdin", false, "read the password from the standard input")
+	givenName := flagSet.String("given-name", "", "given name of the user")
+	middleName := flagSet.String("middle-name", "", "middle name of the user")
+	familyName := flagSet.String("family-name", "", "family name of the user")
+	admin := flagSet.Bool("admin", false, "grant access to the admin area")
+	err := parseFlags(flagSet, args)
+	if err != nil {
+		return err
+	}
+
+	newPassword, err := c.readPassword(*password, *passwordStdin)
+	if err != nil {
+		return err
+	}
+
+	database, ctx, err := openDatabase()
+	if err != nil {
+		return err
+	}
+	emailValidator := core_validators.NewEmailValidator(database)
+	profileValidator := core_validators.NewProfileValidator(database)
+	passwordValidator := core_validators.NewPasswordValidator(database, core.NewBreachedPasswordChecker(database))
+	inputSanitizer := core.NewInputSanitizer()
+	userCreator := core.NewUserCreator(database)
+
+	emailAddress := strings.ToLower(strings.TrimSpace(*email))
+	if len(emailAddress) == 0 {
+		return customerrors.NewValidationError("", "The email address is required (-email).")
+	}
+
+	err = emailValidator.ValidateEmailAddress(ctx, emailAddress)
+	if err != nil {
+		return err
+	}
+
+	if len(emailAddress) > 60 {
+		return customerrors.NewValidationError("", "The email address cannot exceed a maximum length of 60 characters.")
+	}
+
+	existingUser, err := database.GetUserByEmail(nil, emailAddress)
+	if err != nil {
+		return err
+	}
+	if existingUser != nil {
+		return customerrors.NewValidationError("", "The email address is already in use.")
+	}
+
+	err = profileValidator.ValidateName(ctx, *givenName, "given name")
+	if err != nil {
+		return err
+	}
+	err = profileValidator.ValidateName(ctx, *middleName, "middle name")
+	if err != nil {
+		return err
+	}
+	err = profileValidator.ValidateName(ctx, *familyName, "family name")
+	if err != nil {
+		return err
+	}
+
+	if len(newPassword) == 0 {
+		return customerrors.NewValidationError("", "The password is required (-password or -password-stdin).")
+	}
+	err = passwordValidator.ValidatePassword(ctx, newPassword, &entities.User{Email: emailAddress})
+	if err != nil {
+		return err
+	}
+
+	passwordHash, err := lib.HashPassword(newPassword)
+	if err != nil {
+		return err
+	}
+
+	user, err := userCreator.CreateUser(ctx, &core.CreateUserInput{
+		Email:         emailAddress,
+		EmailVerified: *emailVerified,
+		PasswordHash:  passwordHash,
+		GivenName:     inputSanitizer.Sanitize(*givenName),
+		MiddleName:    inputSanitizer.Sanitize(*middleName),
+		FamilyName:    inputSanitizer.Sanitize(*familyName),
+	})
+	if err != nil {
+		return err
+	}
+
+	lib.LogAudit(ctx, constants.AuditCreatedUser, map[string]interface{}{
+		"email":   user.Email,
+		"cliUser": getCliUser(),
+	})
+
+	// the event is delivered by the server, as the deliveries are stored in the database
+	err = core_webhooks.NewPublisher(database).PublishUserEvent(nil, constants.WebhookEventUserCreated, user, nil)
+	if err != nil {
+		fmt.Fprintf(c.stderr, "Unable to publish the %v webhook event: %v\n", constants.WebhookEventUserCreated, err)
+	}
+
+	if *admin {
+		authServerResource, err := database.GetResourceByResourceIdentifier(nil, constants.AuthServerResourceIdentifier)
+		if err != nil {
+			return err
+		}
+		permissions, err := database.GetPermissionsByResourceId(nil, authServerResource.Id)
+		if err != nil {
+			return err
+		}
+		var adminPermission *entities.Permission
+		for idx, permission := range permissions {
+			if permission.PermissionIdentifier == constants.AdminWebsitePermissionIdentifier {
+				adminPermission = &permissions[idx]
+				break
+			}
+		}
+		if adminPermission == nil {
+			return errors.WithStack(errors.New("unable to find the admin website permission"))
+		}
+
+		err = database.CreateUserPermission(nil, &entities.UserPermission{
+			UserId:       user.Id,
+			PermissionId: adminPermission.Id,
+		})
+		if err != nil {
+			return err
+		}
+
+		lib.LogAudit(ctx, constants.AuditAddedUserPermission, map[string]interface{}{
+			"userId":       user.Id,
+			"permissionId": adminPermission.Id,
+			"cliUser":      getCliUser(),
+		})
+	}
+
+	fmt.Fprintf(c.stdout, "Created user %v (%v), with subject %v.\n", user.Id, user.Email, user.Subject)
+	return nil
+}
+
+func (c *Cli) userResetPassword(args []string) error {
+
+	flagSet := c.newFlagSet("user reset-password")
+	email := flagSet.String("email", "", "email address of the user (required)")
+	password := flagSet.String("password", "", "new password of the user")
+	passwordStdin := flagSet.Bool("password-stdin", false, "read the new password from the standard input")
+	err := parseFlags(flagSet, args)
+	if err != nil {
+		return err
+	}
+
+	newPassword, err := c.readPassword(*password, *passwordStdin)
+	if err != nil {
+		return err
+	}
+
+	database, ctx, err := openDatabase()
+	if err != nil {
+		return err
+	}
+	passwordValidator := core_validators.NewPasswordValidator(database, core.NewBreachedPasswordChecker(database))
+	userPasswordManager := core.NewUserPasswordManager(database)
+	loginLockoutManager := core.NewLoginLockoutManager(database)
+
+	user, err := getUserByEmail(database, *email)
+	if err != nil {
+		return err
+	}
+
+	if len(newPassword) == 0 {
+		return customerrors.NewValidationError("", "The new password is required (-password or -password-stdin).")
+	}
+	err = passwordValidator.ValidatePassword(ctx, newPassword, user)
+	if err != nil {
+		return err
+	}
+
+	err = userPasswordManager.SetPassword(ctx, user, newPassword)
+	if err != nil {
+		return err
+	}
+	user.ForgotPasswordCodeEncrypted = nil
+	user.ForgotPasswordCodeIssuedAt = sql.NullTime{Valid: false}
+
+	err = database.UpdateUser(nil, user)
+	if err != nil {
+		return err
+	}
+
+	lib.LogAudit(ctx, constants.AuditUpdatedUserAuthentication, map[string]interface{}{
+		"userId":  user.Id,
+		"cliUser": getCliUser(),
+	})
+
+	// a locked-out user can log in with the new password right away
+	if user.IsLocked() || user.FailedLoginAttempts > 0 {
+		err = loginLockoutManager.UnlockUser(user)
+		if err != nil {
+			return err
+		}
+
+		lib.LogAudit(ctx, constants.AuditUnlockedUser, map[string]interface{}{
+			"userId":  user.Id,
+			"cliUser": getCliUser(),
+		})
+	}
+
+	fmt.Fprintf(c.stdout, "The password of %v was reset.\n", user.Email)
+	return nil
+}
+
+func (c *Cli) userDisableOTP(args []string) error {
+
+	flagSet := c.newFlagSet("user disable-otp")
+	email := flagSet.String("email", "", "email address of the user (required)")
+	err := parseFlags(flagSet, args)
+	if err != nil {
+		return err
+	}
+
+	database, ctx, err := openDatabase()
+	if err != nil {
+		return err
+	}
+
+	user, err := getUserByEmail(database, *email)
+	if err != nil {
+		return err
+	}
+
+	if !user.OTPEnabled {
+		return customerrors.NewValidationError("", fmt.Sprintf("OTP is not enabled for %v.", user.Email))
+	}
+
+	user.OTPEnabled = false
+	user.OTPSecret = ""
+	user.OTPRecoveryCodesHashes = ""
+
+	err = database.UpdateUser(nil, user)
+	if err != nil {
+		return err
+	}
+
+	lib.LogAudit(ctx, constants.AuditUpdatedUserAuthentication, map[string]interface{}{
+		"userId":  user.Id,
+		"cliUser": getCliUser(),
+	})
+
+	fmt.Fprintf(c.stdout, "OTP was disabled for %v.\n", user.Email)
+	return nil
+}
@@ -4,5 +4,7 @@ type ctxKey string
 
 const ContextKeySettings ctxKey = "Settings"
 const ContextKeySessionIdentifier ctxKey = "SessionIdentifier"
+const ContextKeyImpersonatedUserSession ctxKey = "ImpersonatedUserSession"
 
 const ContextKeyJwtInfo ctxKey = "JwtInfo"
+const ContextKeyIpAddress ctxKey = "IpAddress"
@@ -1,12 +1,14 @@
 package common
 
 const SessionName string = "goiabada"
+const TrustedDeviceCookieName string = "goiabada_trusted_device"
 
 const SessionKeySessionIdentifier string = "SessionIdentifier"
 const SessionKeyOTPImage string = "OTPImage"
 const SessionKeyOTPSecret string = "OTPSecret"
 const SessionKeyAuthContext string = "AuthContext"
 const SessionKeyJwt string = "Jwt"
+const SessionKeyImpersonatorSessionIdentifier string = "ImpersonatorSessionIdentifier"
 
 const SessionKeyState string = "State"
 const SessionKeyNonce string = "Nonce"
@@ -15,3 +17,8 @@ const SessionKeyCodeVerifier string = "CodeVerifier"
 const SessionKeyReferrer string = "Referrer"
 
 const SessionKeyRedirToAuthorizeCount string = "RedirToAuthorizeCount"
+
+const SessionKeyFederationContext string = "FederationContext"
+
+const SessionKeyPasskeyCeremony string = "PasskeyCeremony"
+const SessionKeyPasskeyName string = "PasskeyName"
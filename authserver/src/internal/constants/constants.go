@@ -7,6 +7,18 @@ const AuthServerResourceIdentifier = "authserver"
 const UserinfoPermissionIdentifier = "userinfo"
 const ManageAccountPermissionIdentifier = "manage-account"
 const AdminWebsitePermissionIdentifier = "admin-website"
+const ScimPermissionIdentifier = "scim"
+
+const AdminApiUsersReadPermissionIdentifier = "admin-api-users-read"
+const AdminApiUsersWritePermissionIdentifier = "admin-api-users-write"
+const AdminApiGroupsReadPermissionIdentifier = "admin-api-groups-read"
+const AdminApiGroupsWritePermissionIdentifier = "admin-api-groups-write"
+const AdminApiClientsReadPermissionIdentifier = "admin-api-clients-read"
+const AdminApiClientsWritePermissionIdentifier = "admin-api-clients-write"
+const AdminApiResourcesReadPermissionIdentifier = "admin-api-resources-read"
+const AdminApiResourcesWritePermissionIdentifier = "admin-api-resources-write"
+const AdminApiSettingsReadPermissionIdentifier = "admin-api-settings-read"
+const AdminApiSettingsWritePermissionIdentifier = "admin-api-settings-write"
 
 const AuditAuthFailedPwd = "auth_failed_pwd"
 const AuditAuthFailedOtp = "auth_failed_otp"
@@ -74,4 +86,73 @@ const AuditVerifiedPhone = "verified_phone"
 const AuditSentPhoneVerificationMessage = "sent_phone_verification_message"
 const AuditChangedPassword = "changed_password"
 const AuditEnrolledOTP = "enrolled_otp"
+const AuditDisabledOTP = "disabled_otp"
+const AuditUpdatedUserNotifications = "updated_user_notifications"
 const AuditLogout = "logout"
+const AuditAuthFailedFederated = "auth_failed_federated"
+const AuditAuthSuccessFederated = "auth_success_federated"
+const AuditLinkedFederatedIdentity = "linked_federated_identity"
+const AuditUnlinkedFederatedIdentity = "unlinked_federated_identity"
+const AuditCreatedIdentityProvider = "created_identity_provider"
+const AuditUpdatedIdentityProvider = "updated_identity_provider"
+const AuditDeletedIdentityProvider = "deleted_identity_provider"
+const AuditAuthFailedPasskey = "auth_failed_passkey"
+const AuditAuthSuccessPasskey = "auth_success_passkey"
+const AuditRegisteredPasskey = "registered_passkey"
+const AuditDeletedPasskey = "deleted_passkey"
+const AuditAuthFailedEmail = "auth_failed_email"
+const AuditAuthSuccessEmail = "auth_success_email"
+const AuditSentEmailLoginCode = "sent_email_login_code"
+const AuditAuthFailedSMSOtp = "auth_failed_sms_otp"
+const AuditAuthSuccessSMSOtp = "auth_success_sms_otp"
+const AuditSentSMSOtp = "sent_sms_otp"
+const AuditAuthSuccessOtpRecoveryCode = "auth_success_otp_recovery_code"
+const AuditGeneratedOTPRecoveryCodes = "generated_otp_recovery_codes"
+const AuditTrustedDevice = "trusted_device"
+const AuditRevokedTrustedDevice = "revoked_trusted_device"
+const AuditAuthBlockedLockout = "auth_blocked_lockout"
+const AuditLockedUser = "locked_user"
+const AuditLockedIpAddress = "locked_ip_address"
+const AuditUnlockedUser = "unlocked_user"
+const AuditUnlockedIpAddress = "unlocked_ip_address"
+const AuditUpdatedLoginSecuritySettings = "updated_login_security_settings"
+const AuditFlaggedBreachedPassword = "flagged_breached_password"
+const AuditImportedBreachedPasswords = "imported_breached_passwords"
+const AuditClearedBreachedPasswords = "cleared_breached_passwords"
+const AuditUpdatedBreachedPasswordsSettings = "updated_breached_passwords_settings"
+const AuditUpdatedPasswordPolicySettings = "updated_password_policy_settings"
+const AuditUpgradedPasswordHash = "upgraded_password_hash"
+const AuditRiskyLogin = "risky_login"
+const AuditAuthBlockedRisk = "auth_blocked_risk"
+const AuditStartedImpersonation = "started_impersonation"
+const AuditEndedImpersonation = "ended_impersonation"
+const AuditImpersonatedRequest = "impersonated_request"
+const AuditAppliedConfiguration = "applied_configuration"
+const AuditImportedUsers = "imported_users"
+const AuditExportedUsers = "exported_users"
+const AuditExportedAuditEvents = "exported_audit_events"
+const AuditCreatedWebhook = "created_webhook"
+const AuditUpdatedWebhook = "updated_webhook"
+const AuditDeletedWebhook = "deleted_webhook"
+const AuditRedeliveredWebhookEvent = "redelivered_webhook_event"
+
+const WebhookEventUserCreated = "user.created"
+const WebhookEventUserActivated = "user.activated"
+const WebhookEventUserUpdated = "user.updated"
+const WebhookEventUserDisabled = "user.disabled"
+const WebhookEventUserDeleted = "user.deleted"
+const WebhookEventUserAddedToGroup = "user.added_to_group"
+
+// the events the webhooks can subscribe to
+var WebhookEventTypes = []string{
+	WebhookEventUserCreated,
+	WebhookEventUserActivated,
+	WebhookEventUserUpdated,
+	WebhookEventUserDisabled,
+	WebhookEventUserDeleted,
+	WebhookEventUserAddedToGroup,
+}
+
+const WebhookDeliveryStatusPending = "pending"
+const WebhookDeliveryStatusDelivered = "delivered"
+const WebhookDeliveryStatusFailed = "failed"
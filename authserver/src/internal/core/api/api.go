@@ -0,0 +1,64 @@
+package core
+
+import (
+	"net/http"
+)
+
+const ContentType = "application/json"
+
+// DefaultPageSize is the page size used when the client does not specify one, and MaxPageSize
+// is the largest page size the server returns.
+const DefaultPageSize = 20
+const MaxPageSize = 100
+
+// Error is an error of the admin API, returned with the status of the response. Validation
+// errors (customerrors.ValidationError) are returned with the status 400 and the same body.
+type Error struct {
+	Status      int
+	Code        string
+	Description string
+}
+
+func NewError(status int, code string, description string) *Error {
+	return &Error{
+		Status:      status,
+		Code:        code,
+		Description: description,
+	}
+}
+
+func NewNotFoundError(description string) *Error {
+	return NewError(http.StatusNotFound, "not_found", description)
+}
+
+func NewForbiddenError(description string) *Error {
+	return NewError(http.StatusForbidden, "forbidden", description)
+}
+
+func NewConflictError(description string) *Error {
+	return NewError(http.StatusConflict, "conflict", description)
+}
+
+func (e *Error) Error() string {
+	return e.Description
+}
+
+// ErrorResponse is the body of all error responses.
+type ErrorResponse struct {
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// ListResponse is the body of the responses listing resources. The page and the page size
+// are only set when the list is paginated.
+type ListResponse[T any] struct {
+	Items    []T `json:"items"`
+	Total    int `json:"total"`
+	Page     int `json:"page,omitempty"`
+	PageSize int `json:"pageSize,omitempty"`
+}
+
+// IdList is the body of the requests replacing the groups or the permissions of a user, a group or a client.
+type IdList struct {
+	Ids []int64 `json:"ids"`
+}
@@ -0,0 +1,10 @@
+package core
+
+import (
+	_ "embed"
+)
+
+// OpenAPIDocument is the OpenAPI 3 description of the admin API, served at /api/v1/openapi.json.
+//
+//go:embed openapi.json
+var OpenAPIDocument []byte
@@ -0,0 +1,418 @@
+package core
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/leodip/goiabada/internal/entities"
+)
+
+type Address struct {
+	Line1      string `json:"line1"`
+	Line2      string `json:"line2"`
+	Locality   string `json:"locality"`
+	Region     string `json:"region"`
+	PostalCode string `json:"postalCode"`
+	Country    string `json:"country"`
+}
+
+// User is the representation of a user. The gender is female, male, other or empty, and the
+// birth date has the format YYYY-MM-DD.
+type User struct {
+	Id                  int64      `json:"id"`
+	Subject             string     `json:"subject"`
+	Enabled             bool       `json:"enabled"`
+	Email               string     `json:"email"`
+	EmailVerified       bool       `json:"emailVerified"`
+	Username            string     `json:"username"`
+	GivenName           string     `json:"givenName"`
+	MiddleName          string     `json:"middleName"`
+	FamilyName          string     `json:"familyName"`
+	Nickname            string     `json:"nickname"`
+	Website             string     `json:"website"`
+	Gender              string     `json:"gender"`
+	BirthDate           string     `json:"birthDate"`
+	ZoneInfo            string     `json:"zoneInfo"`
+	Locale              string     `json:"locale"`
+	PhoneNumberCountry  string     `json:"phoneNumberCountry"`
+	PhoneNumber         string     `json:"phoneNumber"`
+	PhoneNumberVerified bool       `json:"phoneNumberVerified"`
+	Address             Address    `json:"address"`
+	OTPEnabled          bool       `json:"otpEnabled"`
+	CreatedAt           *time.Time `json:"createdAt,omitempty"`
+	UpdatedAt           *time.Time `json:"updatedAt,omitempty"`
+}
+
+// UserInput is the body of the requests creating (POST) or replacing (PUT) a user. When enabled is
+// omitted, new users are enabled and existing users keep their state. The password is only changed
+// when it's not empty.
+type UserInput struct {
+	Enabled             *bool   `json:"enabled"`
+	Email               string  `json:"email"`
+	EmailVerified       bool    `json:"emailVerified"`
+	Password            string  `json:"password"`
+	Username            string  `json:"username"`
+	GivenName           string  `json:"givenName"`
+	MiddleName          string  `json:"middleName"`
+	FamilyName          string  `json:"familyName"`
+	Nickname            string  `json:"nickname"`
+	Website             string  `json:"website"`
+	Gender              string  `json:"gender"`
+	BirthDate           string  `json:"birthDate"`
+	ZoneInfo            string  `json:"zoneInfo"`
+	Locale              string  `json:"locale"`
+	PhoneNumberCountry  string  `json:"phoneNumberCountry"`
+	PhoneNumber         string  `json:"phoneNumber"`
+	PhoneNumberVerified bool    `json:"phoneNumberVerified"`
+	Address             Address `json:"address"`
+}
+
+func NewUser(user *entities.User) *User {
+	result := &User{
+		Id:                  user.Id,
+		Subject:             user.Subject.String(),
+		Enabled:             user.Enabled,
+		Email:               user.Email,
+		EmailVerified:       user.EmailVerified,
+		Username:            user.Username,
+		GivenName:           user.GivenName,
+		MiddleName:          user.MiddleName,
+		FamilyName:          user.FamilyName,
+		Nickname:            user.Nickname,
+		Website:             user.Website,
+		Gender:              user.Gender,
+		ZoneInfo:            user.ZoneInfo,
+		Locale:              user.Locale,
+		PhoneNumberVerified: user.PhoneNumberVerified,
+		Address: Address{
+			Line1:      user.AddressLine1,
+			Line2:      user.AddressLine2,
+			Locality:   user.AddressLocality,
+			Region:     user.AddressRegion,
+			PostalCode: user.AddressPostalCode,
+			Country:    user.AddressCountry,
+		},
+		OTPEnabled: user.OTPEnabled,
+		CreatedAt:  toTime(user.CreatedAt),
+		UpdatedAt:  toTime(user.UpdatedAt),
+	}
+	if user.BirthDate.Valid {
+		result.BirthDate = user.BirthDate.Time.Format("2006-01-02")
+	}
+	// the phone number is stored with the country code, separated by a space
+	if len(user.PhoneNumber) > 0 {
+		parts := strings.SplitN(user.PhoneNumber, " ", 2)
+		if len(parts) == 2 {
+			result.PhoneNumberCountry = parts[0]
+			result.PhoneNumber = parts[1]
+		} else {
+			result.PhoneNumber = user.PhoneNumber
+		}
+	}
+	return result
+}
+
+type Group struct {
+	Id                   int64      `json:"id"`
+	GroupIdentifier      string     `json:"groupIdentifier"`
+	Description          string     `json:"description"`
+	IncludeInIdToken     bool       `json:"includeInIdToken"`
+	IncludeInAccessToken bool       `json:"includeInAccessToken"`
+	CreatedAt            *time.Time `json:"createdAt,omitempty"`
+	UpdatedAt            *time.Time `json:"updatedAt,omitempty"`
+}
+
+type GroupInput struct {
+	GroupIdentifier      string `json:"groupIdentifier"`
+	Description          string `json:"description"`
+	IncludeInIdToken     bool   `json:"includeInIdToken"`
+	IncludeInAccessToken bool   `json:"includeInAccessToken"`
+}
+
+func NewGroup(group *entities.Group) *Group {
+	return &Group{
+		Id:                   group.Id,
+		GroupIdentifier:      group.GroupIdentifier,
+		Description:          group.Description,
+		IncludeInIdToken:     group.IncludeInIdToken,
+		IncludeInAccessToken: group.IncludeInAccessToken,
+		CreatedAt:            toTime(group.CreatedAt),
+		UpdatedAt:            toTime(group.UpdatedAt),
+	}
+}
+
+// Attribute is the representation of the attributes of users and groups.
+type Attribute struct {
+	Id                   int64  `json:"id"`
+	Key                  string `json:"key"`
+	Value                string `json:"value"`
+	IncludeInIdToken     bool   `json:"includeInIdToken"`
+	IncludeInAccessToken bool   `json:"includeInAccessToken"`
+}
+
+type AttributeInput struct {
+	Key                  string `json:"key"`
+	Value                string `json:"value"`
+	IncludeInIdToken     bool   `json:"includeInIdToken"`
+	IncludeInAccessToken bool   `json:"includeInAccessToken"`
+}
+
+func NewUserAttribute(attribute *entities.UserAttribute) *Attribute {
+	return &Attribute{
+		Id:                   attribute.Id,
+		Key:                  attribute.Key,
+		Value:                attribute.Value,
+		IncludeInIdToken:     attribute.IncludeInIdToken,
+		IncludeInAccessToken: attribute.IncludeInAccessToken,
+	}
+}
+
+func NewGroupAttribute(attribute *entities.GroupAttribute) *Attribute {
+	return &Attribute{
+		Id:                   attribute.Id,
+		Key:                  attribute.Key,
+		Value:                attribute.Value,
+		IncludeInIdToken:     attribute.IncludeInIdToken,
+		IncludeInAccessToken: attribute.IncludeInAccessToken,
+	}
+}
+
+type Resource struct {
+	Id                  int64      `json:"id"`
+	ResourceIdentifier  string     `json:"resourceIdentifier"`
+	Description         string     `json:"description"`
+	MinAcrLevel         string     `json:"minAcrLevel"`
+	MaxAuthAgeInSeconds int        `json:"maxAuthAgeInSeconds"`
+	IsSystemLevel       bool       `json:"isSystemLevel"`
+	CreatedAt           *time.Time `json:"createdAt,omitempty"`
+	UpdatedAt           *time.Time `json:"updatedAt,omitempty"`
+}
+
+type ResourceInput struct {
+	ResourceIdentifier  string `json:"resourceIdentifier"`
+	Description         string `json:"description"`
+	MinAcrLevel         string `json:"minAcrLevel"`
+	MaxAuthAgeInSeconds int    `json:"maxAuthAgeInSeconds"`
+}
+
+func NewResource(resource *entities.Resource) *Resource {
+	return &Resource{
+		Id:                  resource.Id,
+		ResourceIdentifier:  resource.ResourceIdentifier,
+		Description:         resource.Description,
+		MinAcrLevel:         resource.MinAcrLevel.String(),
+		MaxAuthAgeInSeconds: resource.MaxAuthAgeInSeconds,
+		IsSystemLevel:       resource.IsSystemLevelResource(),
+		CreatedAt:           toTime(resource.CreatedAt),
+		UpdatedAt:           toTime(resource.UpdatedAt),
+	}
+}
+
+// Permission is the representation of a permission. The scope is the value clients request
+// (resource identifier and permission identifier, separated by a colon).
+type Permission struct {
+	Id                   int64  `json:"id"`
+	PermissionIdentifier string `json:"permissionIdentifier"`
+	Description          string `json:"description"`
+	ResourceId           int64  `json:"resourceId"`
+	Scope                string `json:"scope"`
+	MinAcrLevel          string `json:"minAcrLevel"`
+	MaxAuthAgeInSeconds  int    `json:"maxAuthAgeInSeconds"`
+}
+
+type PermissionInput struct {
+	PermissionIdentifier string `json:"permissionIdentifier"`
+	Description          string `json:"description"`
+	MinAcrLevel          string `json:"minAcrLevel"`
+	MaxAuthAgeInSeconds  int    `json:"maxAuthAgeInSeconds"`
+}
+
+// NewPermission returns the representation of a permission, which must have its resource loaded.
+func NewPermission(permission *entities.Permission) *Permission {
+	return &Permission{
+		Id:                   permission.Id,
+		PermissionIdentifier: permission.PermissionIdentifier,
+		Description:          permission.Description,
+		ResourceId:           permission.ResourceId,
+		Scope:                permission.Resource.ResourceIdentifier + ":" + permission.PermissionIdentifier,
+		MinAcrLevel:          permission.MinAcrLevel.String(),
+		MaxAuthAgeInSeconds:  permission.MaxAuthAgeInSeconds,
+	}
+}
+
+// Client is the representation of a client. The secret of confidential clients is only returned
+// when the client is created, or when a new secret is generated.
+type Client struct {
+	Id                                      int64      `json:"id"`
+	ClientIdentifier                        string     `json:"clientIdentifier"`
+	Description                             string     `json:"description"`
+	Enabled                                 bool       `json:"enabled"`
+	ConsentRequired                         bool       `json:"consentRequired"`
+	IsPublic                                bool       `json:"isPublic"`
+	ClientSecret                            string     `json:"clientSecret,omitempty"`
+	AuthorizationCodeEnabled                bool       `json:"authorizationCodeEnabled"`
+	ClientCredentialsEnabled                bool       `json:"clientCredentialsEnabled"`
+	DefaultAcrLevel                         string     `json:"defaultAcrLevel"`
+	EmailLoginEnabled                       bool       `json:"emailLoginEnabled"`
+	AcceptImpersonatedTokens                bool       `json:"acceptImpersonatedTokens"`
+	TokenExpirationInSeconds                int        `json:"tokenExpirationInSeconds"`
+	RefreshTokenOfflineIdleTimeoutInSeconds int        `json:"refreshTokenOfflineIdleTimeoutInSeconds"`
+	RefreshTokenOfflineMaxLifetimeInSeconds int        `json:"refreshTokenOfflineMaxLifetimeInSeconds"`
+	IncludeOpenIDConnectClaimsInAccessToken string     `json:"includeOpenIDConnectClaimsInAccessToken"`
+	RedirectURIs                            []string   `json:"redirectURIs"`
+	WebOrigins                              []string   `json:"webOrigins"`
+	IsSystemLevel                           bool       `json:"isSystemLevel"`
+	CreatedAt                               *time.Time `json:"createdAt,omitempty"`
+	UpdatedAt                               *time.Time `json:"updatedAt,omitempty"`
+}
+
+// ClientInput is the body of the requests creating (POST) or replacing (PUT) a client. The
+// token settings set to 0 fall back to the global settings, and includeOpenIDConnectClaimsInAccessToken
+// is on, off or default.
+type ClientInput struct {
+	ClientIdentifier                        string   `json:"clientIdentifier"`
+	Description                             string   `json:"description"`
+	Enabled                                 bool     `json:"enabled"`
+	ConsentRequired                         bool     `json:"consentRequired"`
+	IsPublic                                bool     `json:"isPublic"`
+	AuthorizationCodeEnabled                bool     `json:"authorizationCodeEnabled"`
+	ClientCredentialsEnabled                bool     `json:"clientCredentialsEnabled"`
+	DefaultAcrLevel                         string   `json:"defaultAcrLevel"`
+	EmailLoginEnabled                       bool     `json:"emailLoginEnabled"`
+	AcceptImpersonatedTokens                bool     `json:"acceptImpersonatedTokens"`
+	TokenExpirationInSeconds                int      `json:"tokenExpirationInSeconds"`
+	RefreshTokenOfflineIdleTimeoutInSeconds int      `json:"refreshTokenOfflineIdleTimeoutInSeconds"`
+	RefreshTokenOfflineMaxLifetimeInSeconds int      `json:"refreshTokenOfflineMaxLifetimeInSeconds"`
+	IncludeOpenIDConnectClaimsInAccessToken string   `json:"includeOpenIDConnectClaimsInAccessToken"`
+	RedirectURIs                            []string `json:"redirectURIs"`
+	WebOrigins                              []string `json:"webOrigins"`
+}
+
+// NewClient returns the representation of a client, which must have its redirect URIs and web origins loaded.
+func NewClient(client *entities.Client) *Client {
+	result := &Client{
+		Id:                                      client.Id,
+		ClientIdentifier:                        client.ClientIdentifier,
+		Description:                             client.Description,
+		Enabled:                                 client.Enabled,
+		ConsentRequired:                         client.ConsentRequired,
+		IsPublic:                                client.IsPublic,
+		AuthorizationCodeEnabled:                client.AuthorizationCodeEnabled,
+		ClientCredentialsEnabled:                client.ClientCredentialsEnabled,
+		DefaultAcrLevel:                         client.DefaultAcrLevel.String(),
+		EmailLoginEnabled:                       client.EmailLoginEnabled,
+		AcceptImpersonatedTokens:                client.AcceptImpersonatedTokens,
+		TokenExpirationInSeconds:                client.TokenExpirationInSeconds,
+		RefreshTokenOfflineIdleTimeoutInSeconds: client.RefreshTokenOfflineIdleTimeoutInSeconds,
+		RefreshTokenOfflineMaxLifetimeInSeconds: client.RefreshTokenOfflineMaxLifetimeInSeconds,
+		IncludeOpenIDConnectClaimsInAccessToken: client.IncludeOpenIDConnectClaimsInAccessToken,
+		RedirectURIs:                            []string{},
+		WebOrigins:                              []string{},
+		IsSystemLevel:                           client.IsSystemLevelClient(),
+		CreatedAt:                               toTime(client.CreatedAt),
+		UpdatedAt:                               toTime(client.UpdatedAt),
+	}
+	for _, redirectURI := range client.RedirectURIs {
+		result.RedirectURIs = append(result.RedirectURIs, redirectURI.URI)
+	}
+	for _, webOrigin := range client.WebOrigins {
+		result.WebOrigins = append(result.WebOrigins, webOrigin.Origin)
+	}
+	return result
+}
+
+type UserSession struct {
+	Id                 int64     `json:"id"`
+	Started            time.Time `json:"started"`
+	LastAccessed       time.Time `json:"lastAccessed"`
+	AuthTime           time.Time `json:"authTime"`
+	AuthMethods        string    `json:"authMethods"`
+	AcrLevel           string    `json:"acrLevel"`
+	IpAddress          string    `json:"ipAddress"`
+	DeviceName         string    `json:"deviceName"`
+	DeviceType         string    `json:"deviceType"`
+	DeviceOS           string    `json:"deviceOS"`
+	ImpersonatorUserId int64     `json:"impersonatorUserId,omitempty"`
+	Clients            []string  `json:"clients"`
+}
+
+// NewUserSession returns the representation of a user session, which must have its clients loaded.
+func NewUserSession(userSession *entities.UserSession) *UserSession {
+	result := &UserSession{
+		Id:                 userSession.Id,
+		Started:            userSession.Started,
+		LastAccessed:       userSession.LastAccessed,
+		AuthTime:           userSession.AuthTime,
+		AuthMethods:        userSession.AuthMethods,
+		AcrLevel:           userSession.AcrLevel,
+		IpAddress:          userSession.IpAddress,
+		DeviceName:         userSession.DeviceName,
+		DeviceType:         userSession.DeviceType,
+		DeviceOS:           userSession.DeviceOS,
+		ImpersonatorUserId: userSession.ImpersonatorUserId.Int64,
+		Clients:            []string{},
+	}
+	for _, userSessionClient := range userSession.Clients {
+		result.Clients = append(result.Clients, userSessionClient.Client.ClientIdentifier)
+	}
+	return result
+}
+
+type UserConsent struct {
+	Id               int64      `json:"id"`
+	ClientId         int64      `json:"clientId"`
+	ClientIdentifier string     `json:"clientIdentifier"`
+	Scope            string     `json:"scope"`
+	GrantedAt        *time.Time `json:"grantedAt,omitempty"`
+}
+
+// NewUserConsent returns the representation of a consent, which must have its client loaded.
+func NewUserConsent(userConsent *entities.UserConsent) *UserConsent {
+	return &UserConsent{
+		Id:               userConsent.Id,
+		ClientId:         userConsent.ClientId,
+		ClientIdentifier: userConsent.Client.ClientIdentifier,
+		Scope:            userConsent.Scope,
+		GrantedAt:        toTime(userConsent.GrantedAt),
+	}
+}
+
+// Settings is the representation of the general, token and session settings. It's also the body of
+// the requests replacing them.
+type Settings struct {
+	AppName                                   string `json:"appName"`
+	Issuer                                    string `json:"issuer"`
+	SelfRegistrationEnabled                   bool   `json:"selfRegistrationEnabled"`
+	SelfRegistrationRequiresEmailVerification bool   `json:"selfRegistrationRequiresEmailVerification"`
+	TokenExpirationInSeconds                  int    `json:"tokenExpirationInSeconds"`
+	RefreshTokenOfflineIdleTimeoutInSeconds   int    `json:"refreshTokenOfflineIdleTimeoutInSeconds"`
+	RefreshTokenOfflineMaxLifetimeInSeconds   int    `json:"refreshTokenOfflineMaxLifetimeInSeconds"`
+	IncludeOpenIDConnectClaimsInAccessToken   bool   `json:"includeOpenIDConnectClaimsInAccessToken"`
+	UserSessionIdleTimeoutInSeconds           int    `json:"userSessionIdleTimeoutInSeconds"`
+	UserSessionMaxLifetimeInSeconds           int    `json:"userSessionMaxLifetimeInSeconds"`
+	TrustedDeviceLifetimeInDays               int    `json:"trustedDeviceLifetimeInDays"`
+}
+
+func NewSettings(settings *entities.Settings) *Settings {
+	return &Settings{
+		AppName:                 settings.AppName,
+		Issuer:                  settings.Issuer,
+		SelfRegistrationEnabled: settings.SelfRegistrationEnabled,
+		SelfRegistrationRequiresEmailVerification: settings.SelfRegistrationRequiresEmailVerification,
+		TokenExpirationInSeconds:                  settings.TokenExpirationInSeconds,
+		RefreshTokenOfflineIdleTimeoutInSeconds:   settings.RefreshTokenOfflineIdleTimeoutInSeconds,
+		RefreshTokenOfflineMaxLifetimeInSeconds:   settings.RefreshTokenOfflineMaxLifetimeInSeconds,
+		IncludeOpenIDConnectClaimsInAccessToken:   settings.IncludeOpenIDConnectClaimsInAccessToken,
+		UserSessionIdleTimeoutInSeconds:           settings.UserSessionIdleTimeoutInSeconds,
+		UserSessionMaxLifetimeInSeconds:           settings.UserSessionMaxLifetimeInSeconds,
+		TrustedDeviceLifetimeInDays:               settings.TrustedDeviceLifetimeInDays,
+	}
+}
+
+func toTime(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
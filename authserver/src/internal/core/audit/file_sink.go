@@ -0,0 +1,142 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+
+	"github.com/leodip/goiabada/internal/lib"
+	"github.com/pkg/errors"
+)
+
+type FileSinkConfig struct {
+	Path       string
+	MaxSize    int64
+	MaxBackups int
+}
+
+// FileSink writes the audit events to a file, one JSON object per line. When the file reaches the
+// maximum size, in bytes, it's rotated: the current file is renamed to path.1, the previous path.1
+// to path.2, and so on, keeping up to the maximum number of backups.
+type FileSink struct {
+	config FileSinkConfig
+	file   *os.File
+	size   int64
+	queue  *eventQueue
+}
+
+func NewFileSink(config FileSinkConfig) (*FileSink, error) {
+	if len(config.Path) == 0 {
+		return nil, errors.WithStack(errors.New("the path of the audit file is required"))
+	}
+
+	err := os.MkdirAll(filepath.Dir(config.Path), 0750)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to create the directory of the audit file")
+	}
+
+	sink := &FileSink{
+		config: config,
+		queue:  newEventQueue("file", defaultQueueSize),
+	}
+	err = sink.open()
+	if err != nil {
+		return nil, err
+	}
+	return sink, nil
+}
+
+func (s *FileSink) Start() {
+	go s.run()
+}
+
+func (s *FileSink) WriteAuditEvent(auditEvent *lib.AuditEvent) {
+	s.queue.push(auditEvent)
+}
+
+// Close writes the pending events and closes the file.
+func (s *FileSink) Close() {
+	s.queue.close()
+}
+
+func (s *FileSink) run() {
+	defer s.queue.finished()
+	defer s.file.Close()
+
+	for auditEvent := range s.queue.events {
+		err := s.write(auditEvent)
+		if err != nil {
+			slog.Error(fmt.Sprintf("unable to write the audit event %v to the file: %+v", auditEvent.Event, err))
+		}
+	}
+}
+
+func (s *FileSink) write(auditEvent *lib.AuditEvent) error {
+	line, err := json.Marshal(auditEvent)
+	if err != nil {
+		return errors.Wrap(err, "unable to marshal the audit event")
+	}
+	line = append(line, '\n')
+
+	if s.config.MaxSize > 0 && s.size > 0 && s.size+int64(len(line)) > s.config.MaxSize {
+		err = s.rotate()
+		if err != nil {
+			return err
+		}
+	}
+
+	n, err := s.file.Write(line)
+	s.size += int64(n)
+	if err != nil {
+		return errors.Wrap(err, "unable to write to the audit file")
+	}
+	return nil
+}
+
+func (s *FileSink) open() error {
+	file, err := os.OpenFile(s.config.Path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0640)
+	if err != nil {
+		return errors.Wrap(err, "unable to open the audit file")
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return errors.Wrap(err, "unable to stat the audit file")
+	}
+
+	s.file = file
+	s.size = info.Size()
+	return nil
+}
+
+func (s *FileSink) rotate() error {
+	err := s.file.Close()
+	if err != nil {
+		return errors.Wrap(err, "unable to close the audit file")
+	}
+
+	if s.config.MaxBackups > 0 {
+		// the oldest backup is overwritten by the next one
+		for i := s.config.MaxBackups - 1; i >= 1; i-- {
+			err = os.Rename(backupPath(s.config.Path, i), backupPath(s.config.Path, i+1))
+			if err != nil && !os.IsNotExist(err) {
+				return errors.Wrap(err, "unable to rename the audit file backup")
+			}
+		}
+		err = os.Rename(s.config.Path, backupPath(s.config.Path, 1))
+	} else {
+		err = os.Remove(s.config.Path)
+	}
+	if err != nil && !os.IsNotExist(err) {
+		return errors.Wrap(err, "unable to rotate the audit file")
+	}
+
+	return s.open()
+}
+
+func backupPath(path string, index int) string {
+	return fmt.Sprintf("%v.%v", path, index)
+}
@@ -0,0 +1,73 @@
+package core
+
+import (
+	"fmt"
+	"log/slog"
+	"sync"
+
+	"github.com/leodip/goiabada/internal/lib"
+)
+
+const defaultQueueSize = 1000
+
+// eventQueue buffers the audit events of a sink, so logging an event never waits for the
+// destination. When the queue is full the events are handed to the overflow function, if the sink
+// has one, otherwise they are dropped and an error is logged.
+type eventQueue struct {
+	sinkName string
+	events   chan *lib.AuditEvent
+	overflow func(auditEvent *lib.AuditEvent)
+	done     chan struct{}
+	mutex    sync.RWMutex
+	closed   bool
+}
+
+func newEventQueue(sinkName string, size int) *eventQueue {
+	if size <= 0 {
+		size = defaultQueueSize
+	}
+	return &eventQueue{
+		sinkName: sinkName,
+		events:   make(chan *lib.AuditEvent, size),
+		done:     make(chan struct{}),
+	}
+}
+
+func (q *eventQueue) push(auditEvent *lib.AuditEvent) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	if q.closed {
+		return
+	}
+
+	select {
+	case q.events <- auditEvent:
+	default:
+		if q.overflow != nil {
+			q.overflow(auditEvent)
+			return
+		}
+		slog.Error(fmt.Sprintf("the audit event queue of the %v sink is full, unable to write the audit event %v",
+			q.sinkName, auditEvent.Event))
+	}
+}
+
+// close stops accepting events and waits for the consumer to finish, which must call finished
+// after reading the last event.
+func (q *eventQueue) close() {
+	q.mutex.Lock()
+	if q.closed {
+		q.mutex.Unlock()
+		return
+	}
+	q.closed = true
+	close(q.events)
+	q.mutex.Unlock()
+
+	<-q.done
+}
+
+func (q *eventQueue) finished() {
+	close(q.done)
+}
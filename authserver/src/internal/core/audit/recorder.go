@@ -0,0 +1,142 @@
+package core
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/leodip/goiabada/internal/data"
+	"github.com/leodip/goiabada/internal/entities"
+	"github.com/leodip/goiabada/internal/lib"
+	"github.com/pkg/errors"
+)
+
+// Recorder persists the audit events in the database. The events are written in the background,
+// so logging an event never waits for the database, and the events older than the retention
+// period are deleted periodically.
+type Recorder struct {
+	database        data.Database
+	retentionInDays int
+	queue           *eventQueue
+}
+
+// NewRecorder creates a recorder that keeps the events for the given number of days. With zero
+// days the events are kept forever.
+func NewRecorder(database data.Database, retentionInDays int) *Recorder {
+	return &Recorder{
+		database:        database,
+		retentionInDays: retentionInDays,
+		queue:           newEventQueue("database", defaultQueueSize),
+	}
+}
+
+// Start starts writing the events to the database. When the cleanup interval is greater than zero,
+// the expired events are deleted at that interval.
+func (r *Recorder) Start(cleanupInterval time.Duration) {
+	go r.run(cleanupInterval)
+}
+
+func (r *Recorder) WriteAuditEvent(auditEvent *lib.AuditEvent) {
+	r.queue.push(auditEvent)
+}
+
+// Close writes the pending events and stops the recorder.
+func (r *Recorder) Close() {
+	r.queue.close()
+}
+
+func (r *Recorder) run(cleanupInterval time.Duration) {
+	defer r.queue.finished()
+
+	var cleanup <-chan time.Time
+	if cleanupInterval > 0 && r.retentionInDays > 0 {
+		ticker := time.NewTicker(cleanupInterval)
+		defer ticker.Stop()
+		cleanup = ticker.C
+	}
+
+	for {
+		select {
+		case auditEvent, ok := <-r.queue.events:
+			if !ok {
+				return
+			}
+			err := r.writeEvent(auditEvent)
+			if err != nil {
+				slog.Error(fmt.Sprintf("unable to record the audit event %v: %+v", auditEvent.Event, err))
+			}
+		case <-cleanup:
+			err := r.DeleteExpiredEvents()
+			if err != nil {
+				slog.Error(fmt.Sprintf("unable to delete the expired audit events: %+v", err))
+			}
+		}
+	}
+}
+
+func (r *Recorder) writeEvent(auditEvent *lib.AuditEvent) error {
+	entity, err := NewAuditEventEntity(auditEvent)
+	if err != nil {
+		return err
+	}
+	return r.database.CreateAuditEvent(nil, entity)
+}
+
+// DeleteExpiredEvents deletes the events older than the retention period.
+func (r *Recorder) DeleteExpiredEvents() error {
+	if r.retentionInDays <= 0 {
+		return nil
+	}
+
+	before := time.Now().UTC().AddDate(0, 0, -r.retentionInDays)
+	deleted, err := r.database.DeleteAuditEventsCreatedBefore(nil, before)
+	if err != nil {
+		return err
+	}
+	if deleted > 0 {
+		slog.Info(fmt.Sprintf("deleted %v audit events older than %v days", deleted, r.retentionInDays))
+	}
+	return nil
+}
+
+// NewAuditEventEntity converts an audit event to the entity stored in the database. The user and
+// the client the event is about are taken from the details, so the events can be filtered by them.
+func NewAuditEventEntity(auditEvent *lib.AuditEvent) (*entities.AuditEvent, error) {
+	details, err := json.Marshal(auditEvent.Details)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to marshal the audit details")
+	}
+
+	return &entities.AuditEvent{
+		CreatedAt:    sql.NullTime{Time: auditEvent.Timestamp, Valid: !auditEvent.Timestamp.IsZero()},
+		EventType:    auditEvent.Event,
+		ActorSubject: truncate(auditEvent.ActorSubject, 256),
+		IpAddress:    truncate(auditEvent.IpAddress, 64),
+		RequestId:    truncate(auditEvent.RequestId, 128),
+		UserId:       getDetailsId(auditEvent.Details, "userId"),
+		ClientId:     getDetailsId(auditEvent.Details, "clientId"),
+		Details:      string(details),
+	}, nil
+}
+
+func getDetailsId(details map[string]interface{}, key string) sql.NullInt64 {
+	var id int64
+	switch value := details[key].(type) {
+	case int64:
+		id = value
+	case int:
+		id = int64(value)
+	case int32:
+		id = int64(value)
+	}
+	return sql.NullInt64{Int64: id, Valid: id > 0}
+}
+
+func truncate(s string, maxLength int) string {
+	if len(s) > maxLength {
+		return s[:maxLength]
+	}
+	return s
+}
@@ -0,0 +1,187 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/leodip/goiabada/internal/lib"
+	"github.com/pkg/errors"
+)
+
+// the enterprise number reserved for documentation (RFC 5612), used in the id of the structured
+// data, as goiabada doesn't have its own
+const syslogStructuredDataId = "goiabada@32473"
+
+const syslogSeverityInformational = 6
+
+var syslogFacilities = map[string]int{
+	"kern": 0, "user": 1, "mail": 2, "daemon": 3, "auth": 4, "syslog": 5, "lpr": 6, "news": 7,
+	"uucp": 8, "cron": 9, "authpriv": 10, "ftp": 11, "local0": 16, "local1": 17, "local2": 18,
+	"local3": 19, "local4": 20, "local5": 21, "local6": 22, "local7": 23,
+}
+
+type SyslogSinkConfig struct {
+	Network  string
+	Address  string
+	Facility string
+	AppName  string
+}
+
+// SyslogSink sends the audit events to a syslog server, in the RFC 5424 format. The event type is
+// the message id, the request id, IP address and actor are sent as structured data, and the message
+// is the event as JSON. Over TCP the messages are framed with octet counting (RFC 6587).
+type SyslogSink struct {
+	config   SyslogSinkConfig
+	facility int
+	hostname string
+	conn     net.Conn
+	queue    *eventQueue
+}
+
+func NewSyslogSink(config SyslogSinkConfig) (*SyslogSink, error) {
+	if config.Network != "udp" && config.Network != "tcp" {
+		return nil, errors.WithStack(fmt.Errorf("invalid syslog network %v, expecting udp or tcp", config.Network))
+	}
+	if len(config.Address) == 0 {
+		return nil, errors.WithStack(errors.New("the address of the syslog server is required"))
+	}
+
+	facility, ok := syslogFacilities[strings.ToLower(config.Facility)]
+	if !ok {
+		return nil, errors.WithStack(fmt.Errorf("invalid syslog facility %v", config.Facility))
+	}
+
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = ""
+	}
+
+	return &SyslogSink{
+		config:   config,
+		facility: facility,
+		hostname: hostname,
+		queue:    newEventQueue("syslog", defaultQueueSize),
+	}, nil
+}
+
+func (s *SyslogSink) Start() {
+	go s.run()
+}
+
+func (s *SyslogSink) WriteAuditEvent(auditEvent *lib.AuditEvent) {
+	s.queue.push(auditEvent)
+}
+
+// Close sends the pending events and closes the connection.
+func (s *SyslogSink) Close() {
+	s.queue.close()
+}
+
+func (s *SyslogSink) run() {
+	defer s.queue.finished()
+
+	for auditEvent := range s.queue.events {
+		err := s.send(auditEvent)
+		if err != nil {
+			slog.Error(fmt.Sprintf("unable to send the audit event %v to syslog: %+v", auditEvent.Event, err))
+		}
+	}
+
+	if s.conn != nil {
+		s.conn.Close()
+	}
+}
+
+func (s *SyslogSink) send(auditEvent *lib.AuditEvent) error {
+	message, err := s.formatMessage(auditEvent)
+	if err != nil {
+		return err
+	}
+	if s.config.Network == "tcp" {
+		message = fmt.Sprintf("%v %v", len(message), message)
+	}
+
+	// the connection may have been closed by the server, so it's opened again once
+	for attempt := 0; ; attempt++ {
+		if s.conn == nil {
+			s.conn, err = net.DialTimeout(s.config.Network, s.config.Address, 10*time.Second)
+			if err != nil {
+				s.conn = nil
+				return errors.Wrap(err, "unable to connect to the syslog server")
+			}
+		}
+
+		s.conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+		_, err = s.conn.Write([]byte(message))
+		if err == nil {
+			return nil
+		}
+
+		s.conn.Close()
+		s.conn = nil
+		if attempt > 0 {
+			return errors.Wrap(err, "unable to write to the syslog server")
+		}
+	}
+}
+
+// formatMessage returns the event as a RFC 5424 message.
+func (s *SyslogSink) formatMessage(auditEvent *lib.AuditEvent) (string, error) {
+	eventJson, err := json.Marshal(auditEvent)
+	if err != nil {
+		return "", errors.Wrap(err, "unable to marshal the audit event")
+	}
+
+	priority := s.facility*8 + syslogSeverityInformational
+	timestamp := auditEvent.Timestamp.UTC().Format("2006-01-02T15:04:05.000000Z07:00")
+
+	header := fmt.Sprintf("<%v>1 %v %v %v %v %v", priority, timestamp,
+		syslogHeaderField(s.hostname, 255),
+		syslogHeaderField(s.config.AppName, 48),
+		syslogHeaderField(fmt.Sprint(os.Getpid()), 128),
+		syslogHeaderField(auditEvent.Event, 32))
+
+	params := []string{}
+	for _, param := range []struct{ name, value string }{
+		{"requestId", auditEvent.RequestId},
+		{"ipAddress", auditEvent.IpAddress},
+		{"actorSubject", auditEvent.ActorSubject},
+	} {
+		if len(param.value) > 0 {
+			params = append(params, fmt.Sprintf("%v=\"%v\"", param.name, syslogParamValue(param.value)))
+		}
+	}
+	structuredData := "-"
+	if len(params) > 0 {
+		structuredData = fmt.Sprintf("[%v %v]", syslogStructuredDataId, strings.Join(params, " "))
+	}
+
+	// the message starts with the BOM, as it's UTF-8
+	return fmt.Sprintf("%v %v \xEF\xBB\xBF%s", header, structuredData, eventJson), nil
+}
+
+// syslogHeaderField returns the value with only printable US-ASCII characters, as required in the
+// header, or the nil value when it's empty.
+func syslogHeaderField(value string, maxLength int) string {
+	var sb strings.Builder
+	for _, c := range value {
+		if c >= 33 && c <= 126 {
+			sb.WriteRune(c)
+		}
+	}
+	field := sb.String()
+	if len(field) == 0 {
+		return "-"
+	}
+	return truncate(field, maxLength)
+}
+
+func syslogParamValue(value string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `"`, `\"`, `]`, `\]`)
+	return replacer.Replace(value)
+}
@@ -0,0 +1,244 @@
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/leodip/goiabada/internal/lib"
+	"github.com/pkg/errors"
+)
+
+const maxWebhookRetryInterval = time.Minute
+
+// the batches waiting while a delivery is being retried
+const maxPendingWebhookBatches = 10
+
+type WebhookSinkConfig struct {
+	Url            string
+	Secret         string
+	BatchSize      int
+	FlushInterval  time.Duration
+	Timeout        time.Duration
+	MaxRetries     int
+	RetryInterval  time.Duration
+	DeadLetterPath string
+}
+
+// WebhookSink posts the audit events to an HTTP endpoint, in batches. A batch is sent when it reaches
+// the batch size or at the flush interval, as {"events": [...]}, signed with the shared secret (see
+// lib.SignWebhookPayload). Failed deliveries are retried with exponential backoff, and the batches
+// that can't be delivered are appended to the dead-letter file, one event per line.
+//
+// The batches are delivered by a separate goroutine, so the retries don't stop the sink from reading
+// the queue. The batches that can't wait for the delivery (when too many are pending), and the events
+// that don't fit in the queue, also go to the dead-letter file.
+type WebhookSink struct {
+	config          WebhookSinkConfig
+	httpClient      *http.Client
+	queue           *eventQueue
+	batches         chan []*lib.AuditEvent
+	delivered       chan struct{}
+	deadLetterMutex sync.Mutex
+}
+
+type webhookPayload struct {
+	Events []*lib.AuditEvent `json:"events"`
+}
+
+func NewWebhookSink(config WebhookSinkConfig) (*WebhookSink, error) {
+	if len(config.Url) == 0 {
+		return nil, errors.WithStack(errors.New("the url of the audit webhook is required"))
+	}
+	if len(config.Secret) == 0 {
+		return nil, errors.WithStack(errors.New("the secret of the audit webhook is required"))
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = 100
+	}
+	if config.FlushInterval <= 0 {
+		config.FlushInterval = 5 * time.Second
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = 10 * time.Second
+	}
+	if config.RetryInterval <= 0 {
+		config.RetryInterval = time.Second
+	}
+
+	if len(config.DeadLetterPath) > 0 {
+		err := os.MkdirAll(filepath.Dir(config.DeadLetterPath), 0750)
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to create the directory of the dead-letter file")
+		}
+	}
+
+	sink := &WebhookSink{
+		config:     config,
+		httpClient: &http.Client{Timeout: config.Timeout},
+		queue:      newEventQueue("webhook", defaultQueueSize),
+		batches:    make(chan []*lib.AuditEvent, maxPendingWebhookBatches),
+		delivered:  make(chan struct{}),
+	}
+	if len(config.DeadLetterPath) > 0 {
+		sink.queue.overflow = func(auditEvent *lib.AuditEvent) {
+			slog.Error(fmt.Sprintf("the audit event queue of the webhook sink is full, writing the audit event %v to the dead-letter file",
+				auditEvent.Event))
+			sink.writeDeadLetters([]*lib.AuditEvent{auditEvent})
+		}
+	}
+	return sink, nil
+}
+
+func (s *WebhookSink) Start() {
+	go s.deliverBatches()
+	go s.run()
+}
+
+func (s *WebhookSink) WriteAuditEvent(auditEvent *lib.AuditEvent) {
+	s.queue.push(auditEvent)
+}
+
+// Close delivers the pending events and stops the sink.
+func (s *WebhookSink) Close() {
+	s.queue.close()
+}
+
+func (s *WebhookSink) run() {
+	defer s.queue.finished()
+
+	ticker := time.NewTicker(s.config.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]*lib.AuditEvent, 0, s.config.BatchSize)
+	flush := func() {
+		if len(batch) > 0 {
+			select {
+			case s.batches <- batch:
+			default:
+				slog.Error(fmt.Sprintf("too many audit event batches are waiting for the webhook, unable to deliver %v audit events",
+					len(batch)))
+				s.writeDeadLetters(batch)
+			}
+			batch = make([]*lib.AuditEvent, 0, s.config.BatchSize)
+		}
+	}
+
+	for {
+		select {
+		case auditEvent, ok := <-s.queue.events:
+			if !ok {
+				flush()
+				close(s.batches)
+				<-s.delivered
+				return
+			}
+			batch = append(batch, auditEvent)
+			if len(batch) >= s.config.BatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
+
+func (s *WebhookSink) deliverBatches() {
+	defer close(s.delivered)
+
+	for batch := range s.batches {
+		s.deliver(batch)
+	}
+}
+
+func (s *WebhookSink) deliver(batch []*lib.AuditEvent) {
+	body, err := json.Marshal(webhookPayload{Events: batch})
+	if err != nil {
+		slog.Error(fmt.Sprintf("unable to marshal the audit events for the webhook: %+v", err))
+		return
+	}
+
+	retryInterval := s.config.RetryInterval
+	for attempt := 0; ; attempt++ {
+		retry, err := s.post(body)
+		if err == nil {
+			return
+		}
+
+		if !retry || attempt >= s.config.MaxRetries {
+			slog.Error(fmt.Sprintf("unable to deliver %v audit events to the webhook after %v attempts: %+v",
+				len(batch), attempt+1, err))
+			s.writeDeadLetters(batch)
+			return
+		}
+
+		time.Sleep(retryInterval)
+		retryInterval = min(retryInterval*2, maxWebhookRetryInterval)
+	}
+}
+
+// post sends the body to the webhook. When it fails, it returns whether the delivery can be retried.
+func (s *WebhookSink) post(body []byte) (bool, error) {
+	timestamp := time.Now().UTC().Unix()
+
+	req, err := http.NewRequest(http.MethodPost, s.config.Url, bytes.NewReader(body))
+	if err != nil {
+		return false, errors.Wrap(err, "unable to create the webhook request")
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Goiabada-Timestamp", fmt.Sprint(timestamp))
+	req.Header.Set("X-Goiabada-Signature", lib.SignWebhookPayload(s.config.Secret, timestamp, body))
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return true, errors.Wrap(err, "unable to post to the webhook")
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+
+	// the other client errors won't succeed when retried
+	retry := resp.StatusCode >= 500 || resp.StatusCode == http.StatusRequestTimeout ||
+		resp.StatusCode == http.StatusTooManyRequests
+	return retry, errors.WithStack(fmt.Errorf("the webhook responded with status code %v", resp.StatusCode))
+}
+
+func (s *WebhookSink) writeDeadLetters(batch []*lib.AuditEvent) {
+	if len(s.config.DeadLetterPath) == 0 {
+		return
+	}
+
+	// the dead letters are written by the sink, the delivery and the overflow of the queue
+	s.deadLetterMutex.Lock()
+	defer s.deadLetterMutex.Unlock()
+
+	file, err := os.OpenFile(s.config.DeadLetterPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0640)
+	if err != nil {
+		slog.Error(fmt.Sprintf("unable to open the dead-letter file of the audit webhook: %+v", err))
+		return
+	}
+	defer file.Close()
+
+	for _, auditEvent := range batch {
+		line, err := json.Marshal(auditEvent)
+		if err != nil {
+			slog.Error(fmt.Sprintf("unable to marshal the audit event %v: %+v", auditEvent.Event, err))
+			continue
+		}
+		_, err = file.Write(append(line, '\n'))
+		if err != nil {
+			slog.Error(fmt.Sprintf("unable to write to the dead-letter file of the audit webhook: %+v", err))
+			return
+		}
+	}
+}
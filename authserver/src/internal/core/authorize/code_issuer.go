@@ -14,6 +14,7 @@ import (
 	"github.com/leodip/goiabada/internal/dtos"
 
 	"github.com/leodip/goiabada/internal/lib"
+	"github.com/pkg/errors"
 )
 
 type CodeIssuer struct {
@@ -79,15 +80,35 @@ func (ci *CodeIssuer) CreateAuthCode(ctx context.Context, input *CreateCodeInput
 		Used:                false,
 	}
 
+	// codes of impersonated sessions name the admin, for the act claim of the tokens
+	userSession, err := ci.database.GetUserSessionBySessionIdentifier(nil, input.SessionIdentifier)
+	if err != nil {
+		return nil, err
+	}
+	if userSession != nil && userSession.IsImpersonated() {
+		impersonator, err := ci.database.GetUserById(nil, userSession.ImpersonatorUserId.Int64)
+		if err != nil {
+			return nil, err
+		}
+		if impersonator == nil {
+			return nil, errors.WithStack(errors.New("impersonator not found"))
+		}
+		code.ImpersonatorSubject = impersonator.Subject.String()
+	}
+
 	err = ci.database.CreateCode(nil, code)
 	if err != nil {
 		return nil, err
 	}
 
-	lib.LogAudit(constants.AuditCreatedAuthCode, map[string]interface{}{
+	auditDetails := map[string]interface{}{
 		"userId":   input.UserId,
 		"clientId": client.Id,
-	})
+	}
+	if len(code.ImpersonatorSubject) > 0 {
+		auditDetails["impersonatorSubject"] = code.ImpersonatorSubject
+	}
+	lib.LogAudit(ctx, constants.AuditCreatedAuthCode, auditDetails)
 
 	return code, nil
 }
@@ -17,6 +17,7 @@ type LoginManager struct {
 Acr Level 0 - cookie (no active authentication)
 Acr Level 1 - pwd
 Acr Level 2 - pwd + otp
+Acr Level 4 - passkey (pwd + passkey, or passkey alone)
 
 */
 
@@ -47,24 +48,50 @@ func (lm *LoginManager) HasValidUserSession(ctx context.Context, userSession *en
 	return isValid
 }
 
+// MustPerformOTPAuth returns true when the user session doesn't satisfy the target ACR level and a
+// second factor (OTP or passkey) is required. A passkey satisfies or exceeds any ACR level, while the
+// passkey ACR level is only satisfied by a passkey. The passkeys of the user must be loaded.
+// The optional second factor is skipped on a trusted device (it can be nil).
 func (lm *LoginManager) MustPerformOTPAuth(ctx context.Context, client *entities.Client,
-	userSession *entities.UserSession, targetAcrLevel enums.AcrLevel) bool {
+	userSession *entities.UserSession, targetAcrLevel enums.AcrLevel, trustedDevice *entities.UserTrustedDevice) bool {
 
 	currentAcrLevel, err := enums.AcrLevelFromString(userSession.AcrLevel)
 	if err != nil {
 		return false
 	}
 
+	hasSecondFactor := userSession.User.OTPEnabled || len(userSession.User.Passkeys) > 0
+	if lm.IsTrustedDevice(trustedDevice, userSession.User.Id, targetAcrLevel) {
+		hasSecondFactor = false
+	}
+
 	if currentAcrLevel == enums.AcrLevel1 {
-		if (targetAcrLevel == enums.AcrLevel2 && userSession.User.OTPEnabled) ||
-			(targetAcrLevel == enums.AcrLevel3) {
+		if (targetAcrLevel == enums.AcrLevel2 && hasSecondFactor) ||
+			(targetAcrLevel == enums.AcrLevel3) ||
+			(targetAcrLevel == enums.AcrLevel4) {
 			return true
 		}
 	} else if currentAcrLevel == enums.AcrLevel2 {
-		if targetAcrLevel == enums.AcrLevel3 {
+		if targetAcrLevel == enums.AcrLevel3 || targetAcrLevel == enums.AcrLevel4 {
+			return true
+		}
+	} else if currentAcrLevel == enums.AcrLevel3 {
+		if targetAcrLevel == enums.AcrLevel4 {
 			return true
 		}
 	}
 
 	return false
 }
+
+// IsTrustedDevice returns true when the user chose to trust the device for the second factor, and the
+// trust can be used for the target ACR level. Only the optional OTP level can be satisfied by a trusted
+// device - clients that demand a second factor (or a passkey) always prompt for it.
+func (lm *LoginManager) IsTrustedDevice(trustedDevice *entities.UserTrustedDevice, userId int64,
+	targetAcrLevel enums.AcrLevel) bool {
+
+	if trustedDevice == nil || trustedDevice.UserId != userId || trustedDevice.IsExpired() {
+		return false
+	}
+	return targetAcrLevel == enums.AcrLevel2
+}
@@ -0,0 +1,155 @@
+package core
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/leodip/goiabada/internal/customerrors"
+	"github.com/leodip/goiabada/internal/data"
+	"github.com/pkg/errors"
+)
+
+// number of hashes inserted per statement when importing a corpus
+const breachedPasswordsBatchSize = 500
+
+// number of hashes committed per transaction when importing a corpus
+const breachedPasswordsChunkSize = 50000
+
+var sha1HashRegex = regexp.MustCompile(`^[0-9A-F]{40}$`)
+var sha1SuffixRegex = regexp.MustCompile(`^[0-9A-F]{35}$`)
+var sha1PrefixRegex = regexp.MustCompile(`^[0-9A-F]{5}$`)
+
+type BreachedPasswordChecker struct {
+	database data.Database
+}
+
+func NewBreachedPasswordChecker(database data.Database) *BreachedPasswordChecker {
+	return &BreachedPasswordChecker{
+		database: database,
+	}
+}
+
+// IsBreached returns true when the SHA-1 hash of the password is in the breached password corpus.
+func (c *BreachedPasswordChecker) IsBreached(password string) (bool, error) {
+	hash := sha1.Sum([]byte(password))
+	return c.database.BreachedPasswordHashExists(nil, strings.ToUpper(hex.EncodeToString(hash[:])))
+}
+
+// ImportCorpus adds the hashes of a Have I Been Pwned file to the breached password corpus, and returns how
+// many were new. Each line has a SHA-1 hash, optionally followed by ":count". In a range file (the response
+// of the range API) the lines only have the last 35 characters of the hash, so the file must be named after
+// the 5 characters prefix, for example 5BAA6.txt. The file is validated before the import, so nothing is
+// imported if any line is invalid. The hashes are committed in chunks, so a large file doesn't hold a
+// transaction open; when the import fails midway the file can be imported again, as the hashes already in the
+// corpus are ignored.
+func (c *BreachedPasswordChecker) ImportCorpus(reader io.ReadSeeker, fileName string) (int64, error) {
+
+	prefix := strings.ToUpper(strings.TrimSuffix(filepath.Base(fileName), filepath.Ext(fileName)))
+	if !sha1PrefixRegex.MatchString(prefix) {
+		prefix = ""
+	}
+
+	err := scanCorpus(reader, prefix, func(hash string) error { return nil })
+	if err != nil {
+		return 0, err
+	}
+	_, err = reader.Seek(0, io.SeekStart)
+	if err != nil {
+		return 0, errors.Wrap(err, "unable to rewind the breached passwords file")
+	}
+
+	imported := int64(0)
+	chunk := make([]string, 0, breachedPasswordsChunkSize)
+	commitChunk := func() error {
+		if len(chunk) == 0 {
+			return nil
+		}
+
+		tx, err := c.database.BeginTransaction()
+		if err != nil {
+			return err
+		}
+		defer c.database.RollbackTransaction(tx)
+
+		count := int64(0)
+		for start := 0; start < len(chunk); start += breachedPasswordsBatchSize {
+			batchCount, err := c.database.CreateBreachedPasswordHashes(tx, chunk[start:min(start+breachedPasswordsBatchSize, len(chunk))])
+			if err != nil {
+				return err
+			}
+			count += batchCount
+		}
+
+		err = c.database.CommitTransaction(tx)
+		if err != nil {
+			return err
+		}
+		imported += count
+		chunk = chunk[:0]
+		return nil
+	}
+
+	err = scanCorpus(reader, prefix, func(hash string) error {
+		chunk = append(chunk, hash)
+		if len(chunk) == breachedPasswordsChunkSize {
+			return commitChunk()
+		}
+		return nil
+	})
+	if err != nil {
+		return imported, err
+	}
+
+	err = commitChunk()
+	if err != nil {
+		return imported, err
+	}
+	return imported, nil
+}
+
+// scanCorpus calls fn with the full hash of each line of the file, and returns a validation error for the first
+// invalid line.
+func scanCorpus(reader io.Reader, prefix string, fn func(hash string) error) error {
+	scanner := bufio.NewScanner(reader)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+
+		hash, _, _ := strings.Cut(strings.TrimSpace(scanner.Text()), ":")
+		hash = strings.ToUpper(hash)
+		if len(hash) == 0 {
+			continue
+		}
+
+		if len(prefix) > 0 && sha1SuffixRegex.MatchString(hash) {
+			hash = prefix + hash
+		}
+		if !sha1HashRegex.MatchString(hash) {
+			return customerrors.NewValidationError("",
+				fmt.Sprintf("Line %v of the file is not a SHA-1 hash (or the suffix of a hash, in a range file named after the prefix).", lineNumber))
+		}
+
+		err := fn(hash)
+		if err != nil {
+			return err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return errors.Wrap(err, "unable to read the breached passwords file")
+	}
+	return nil
+}
+
+func (c *BreachedPasswordChecker) CountHashes() (int, error) {
+	return c.database.CountBreachedPasswordHashes(nil)
+}
+
+func (c *BreachedPasswordChecker) ClearCorpus() error {
+	return c.database.DeleteAllBreachedPasswordHashes(nil)
+}
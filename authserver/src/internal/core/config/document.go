@@ -0,0 +1,192 @@
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/leodip/goiabada/internal/customerrors"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// Document is the declarative configuration of an instance. Sections that are left out of the document
+// are not managed. When a section is present, the objects that are not in it are deleted, so an empty
+// list deletes all the objects of the section. The system-level resource and client are never managed.
+type Document struct {
+	Settings  *Settings  `json:"settings,omitempty" yaml:"settings,omitempty"`
+	Resources []Resource `json:"resources" yaml:"resources"`
+	Groups    []Group    `json:"groups" yaml:"groups"`
+	Clients   []Client   `json:"clients" yaml:"clients"`
+}
+
+type Resource struct {
+	ResourceIdentifier  string       `json:"resourceIdentifier" yaml:"resourceIdentifier"`
+	Description         string       `json:"description,omitempty" yaml:"description,omitempty"`
+	MinAcrLevel         string       `json:"minAcrLevel,omitempty" yaml:"minAcrLevel,omitempty"`
+	MaxAuthAgeInSeconds int          `json:"maxAuthAgeInSeconds,omitempty" yaml:"maxAuthAgeInSeconds,omitempty"`
+	Permissions         []Permission `json:"permissions" yaml:"permissions"`
+}
+
+type Permission struct {
+	PermissionIdentifier string `json:"permissionIdentifier" yaml:"permissionIdentifier"`
+	Description          string `json:"description,omitempty" yaml:"description,omitempty"`
+	MinAcrLevel          string `json:"minAcrLevel,omitempty" yaml:"minAcrLevel,omitempty"`
+	MaxAuthAgeInSeconds  int    `json:"maxAuthAgeInSeconds,omitempty" yaml:"maxAuthAgeInSeconds,omitempty"`
+}
+
+type Group struct {
+	GroupIdentifier      string      `json:"groupIdentifier" yaml:"groupIdentifier"`
+	Description          string      `json:"description,omitempty" yaml:"description,omitempty"`
+	IncludeInIdToken     bool        `json:"includeInIdToken" yaml:"includeInIdToken"`
+	IncludeInAccessToken bool        `json:"includeInAccessToken" yaml:"includeInAccessToken"`
+	Attributes           []Attribute `json:"attributes" yaml:"attributes"`
+	// the permissions are in the resourceIdentifier:permissionIdentifier format
+	Permissions []string `json:"permissions" yaml:"permissions"`
+}
+
+type Attribute struct {
+	Key                  string `json:"key" yaml:"key"`
+	Value                string `json:"value" yaml:"value"`
+	IncludeInIdToken     bool   `json:"includeInIdToken" yaml:"includeInIdToken"`
+	IncludeInAccessToken bool   `json:"includeInAccessToken" yaml:"includeInAccessToken"`
+}
+
+type Client struct {
+	ClientIdentifier         string `json:"clientIdentifier" yaml:"clientIdentifier"`
+	Description              string `json:"description,omitempty" yaml:"description,omitempty"`
+	Enabled                  bool   `json:"enabled" yaml:"enabled"`
+	ConsentRequired          bool   `json:"consentRequired" yaml:"consentRequired"`
+	IsPublic                 bool   `json:"isPublic" yaml:"isPublic"`
+	AuthorizationCodeEnabled bool   `json:"authorizationCodeEnabled" yaml:"authorizationCodeEnabled"`
+	ClientCredentialsEnabled bool   `json:"clientCredentialsEnabled" yaml:"clientCredentialsEnabled"`
+	// a reference to an environment variable, like ${MY_CLIENT_SECRET}. When empty, a new confidential
+	// client gets a random secret and the secret of an existing client is kept.
+	ClientSecret                            string   `json:"clientSecret,omitempty" yaml:"clientSecret,omitempty"`
+	DefaultAcrLevel                         string   `json:"defaultAcrLevel,omitempty" yaml:"defaultAcrLevel,omitempty"`
+	TokenExpirationInSeconds                int      `json:"tokenExpirationInSeconds" yaml:"tokenExpirationInSeconds"`
+	RefreshTokenOfflineIdleTimeoutInSeconds int      `json:"refreshTokenOfflineIdleTimeoutInSeconds" yaml:"refreshTokenOfflineIdleTimeoutInSeconds"`
+	RefreshTokenOfflineMaxLifetimeInSeconds int      `json:"refreshTokenOfflineMaxLifetimeInSeconds" yaml:"refreshTokenOfflineMaxLifetimeInSeconds"`
+	IncludeOpenIDConnectClaimsInAccessToken string   `json:"includeOpenIDConnectClaimsInAccessToken,omitempty" yaml:"includeOpenIDConnectClaimsInAccessToken,omitempty"`
+	EmailLoginEnabled                       bool     `json:"emailLoginEnabled" yaml:"emailLoginEnabled"`
+	AcceptImpersonatedTokens                bool     `json:"acceptImpersonatedTokens" yaml:"acceptImpersonatedTokens"`
+	RedirectURIs                            []string `json:"redirectURIs" yaml:"redirectURIs"`
+	WebOrigins                              []string `json:"webOrigins" yaml:"webOrigins"`
+	// the permissions are in the resourceIdentifier:permissionIdentifier format
+	Permissions []string `json:"permissions" yaml:"permissions"`
+}
+
+// Settings holds the settings that are not secret. Only the settings present in the document are changed.
+type Settings struct {
+	AppName                                   *string `json:"appName,omitempty" yaml:"appName,omitempty"`
+	Issuer                                    *string `json:"issuer,omitempty" yaml:"issuer,omitempty"`
+	UITheme                                   *string `json:"uiTheme,omitempty" yaml:"uiTheme,omitempty"`
+	SelfRegistrationEnabled                   *bool   `json:"selfRegistrationEnabled,omitempty" yaml:"selfRegistrationEnabled,omitempty"`
+	SelfRegistrationRequiresEmailVerification *bool   `json:"selfRegistrationRequiresEmailVerification,omitempty" yaml:"selfRegistrationRequiresEmailVerification,omitempty"`
+	TokenExpirationInSeconds                  *int    `json:"tokenExpirationInSeconds,omitempty" yaml:"tokenExpirationInSeconds,omitempty"`
+	RefreshTokenOfflineIdleTimeoutInSeconds   *int    `json:"refreshTokenOfflineIdleTimeoutInSeconds,omitempty" yaml:"refreshTokenOfflineIdleTimeoutInSeconds,omitempty"`
+	RefreshTokenOfflineMaxLifetimeInSeconds   *int    `json:"refreshTokenOfflineMaxLifetimeInSeconds,omitempty" yaml:"refreshTokenOfflineMaxLifetimeInSeconds,omitempty"`
+	UserSessionIdleTimeoutInSeconds           *int    `json:"userSessionIdleTimeoutInSeconds,omitempty" yaml:"userSessionIdleTimeoutInSeconds,omitempty"`
+	UserSessionMaxLifetimeInSeconds           *int    `json:"userSessionMaxLifetimeInSeconds,omitempty" yaml:"userSessionMaxLifetimeInSeconds,omitempty"`
+	IncludeOpenIDConnectClaimsInAccessToken   *bool   `json:"includeOpenIDConnectClaimsInAccessToken,omitempty" yaml:"includeOpenIDConnectClaimsInAccessToken,omitempty"`
+	TrustedDeviceLifetimeInDays               *int    `json:"trustedDeviceLifetimeInDays,omitempty" yaml:"trustedDeviceLifetimeInDays,omitempty"`
+	SMTPEnabled                               *bool   `json:"smtpEnabled,omitempty" yaml:"smtpEnabled,omitempty"`
+	SMTPHost                                  *string `json:"smtpHost,omitempty" yaml:"smtpHost,omitempty"`
+	SMTPPort                                  *int    `json:"smtpPort,omitempty" yaml:"smtpPort,omitempty"`
+	SMTPUsername                              *string `json:"smtpUsername,omitempty" yaml:"smtpUsername,omitempty"`
+	// a reference to an environment variable, like ${SMTP_PASSWORD}
+	SMTPPassword                      *string `json:"smtpPassword,omitempty" yaml:"smtpPassword,omitempty"`
+	SMTPEncryption                    *string `json:"smtpEncryption,omitempty" yaml:"smtpEncryption,omitempty"`
+	SMTPFromName                      *string `json:"smtpFromName,omitempty" yaml:"smtpFromName,omitempty"`
+	SMTPFromEmail                     *string `json:"smtpFromEmail,omitempty" yaml:"smtpFromEmail,omitempty"`
+	SMSOTPAllowedForMandatory2FA      *bool   `json:"smsOTPAllowedForMandatory2FA,omitempty" yaml:"smsOTPAllowedForMandatory2FA,omitempty"`
+	LoginDelayAfterFailedAttempts     *int    `json:"loginDelayAfterFailedAttempts,omitempty" yaml:"loginDelayAfterFailedAttempts,omitempty"`
+	LoginLockoutAfterFailedAttempts   *int    `json:"loginLockoutAfterFailedAttempts,omitempty" yaml:"loginLockoutAfterFailedAttempts,omitempty"`
+	LoginIpLockoutAfterFailedAttempts *int    `json:"loginIpLockoutAfterFailedAttempts,omitempty" yaml:"loginIpLockoutAfterFailedAttempts,omitempty"`
+	LoginLockoutDurationInSeconds     *int    `json:"loginLockoutDurationInSeconds,omitempty" yaml:"loginLockoutDurationInSeconds,omitempty"`
+	RiskBasedAuthEnabled              *bool   `json:"riskBasedAuthEnabled,omitempty" yaml:"riskBasedAuthEnabled,omitempty"`
+	RiskScoreForOTP                   *int    `json:"riskScoreForOTP,omitempty" yaml:"riskScoreForOTP,omitempty"`
+	RiskScoreForBlock                 *int    `json:"riskScoreForBlock,omitempty" yaml:"riskScoreForBlock,omitempty"`
+	RiskScoreForNotification          *int    `json:"riskScoreForNotification,omitempty" yaml:"riskScoreForNotification,omitempty"`
+	RiskTrustedIpRanges               *string `json:"riskTrustedIpRanges,omitempty" yaml:"riskTrustedIpRanges,omitempty"`
+	RiskSuspiciousIpRanges            *string `json:"riskSuspiciousIpRanges,omitempty" yaml:"riskSuspiciousIpRanges,omitempty"`
+	RejectBreachedPasswords           *bool   `json:"rejectBreachedPasswords,omitempty" yaml:"rejectBreachedPasswords,omitempty"`
+	ForceChangeOfBreachedPasswords    *bool   `json:"forceChangeOfBreachedPasswords,omitempty" yaml:"forceChangeOfBreachedPasswords,omitempty"`
+	PasswordMinLength                 *int    `json:"passwordMinLength,omitempty" yaml:"passwordMinLength,omitempty"`
+	PasswordMaxLength                 *int    `json:"passwordMaxLength,omitempty" yaml:"passwordMaxLength,omitempty"`
+	PasswordRequiresUppercase         *bool   `json:"passwordRequiresUppercase,omitempty" yaml:"passwordRequiresUppercase,omitempty"`
+	PasswordRequiresLowercase         *bool   `json:"passwordRequiresLowercase,omitempty" yaml:"passwordRequiresLowercase,omitempty"`
+	PasswordRequiresNumber            *bool   `json:"passwordRequiresNumber,omitempty" yaml:"passwordRequiresNumber,omitempty"`
+	PasswordRequiresSpecialChar       *bool   `json:"passwordRequiresSpecialChar,omitempty" yaml:"passwordRequiresSpecialChar,omitempty"`
+	PasswordDisallowUsernameOrEmail   *bool   `json:"passwordDisallowUsernameOrEmail,omitempty" yaml:"passwordDisallowUsernameOrEmail,omitempty"`
+	PasswordHistoryCount              *int    `json:"passwordHistoryCount,omitempty" yaml:"passwordHistoryCount,omitempty"`
+	PasswordMaxAgeInDays              *int    `json:"passwordMaxAgeInDays,omitempty" yaml:"passwordMaxAgeInDays,omitempty"`
+	PasswordMinAgeInDays              *int    `json:"passwordMinAgeInDays,omitempty" yaml:"passwordMinAgeInDays,omitempty"`
+}
+
+// ParseDocument reads a document in the YAML or JSON format. Unknown fields are rejected, so typos
+// don't go unnoticed.
+func ParseDocument(data []byte) (*Document, error) {
+	var document Document
+
+	trimmed := bytes.TrimSpace(data)
+	if bytes.HasPrefix(trimmed, []byte("{")) {
+		decoder := json.NewDecoder(bytes.NewReader(trimmed))
+		decoder.DisallowUnknownFields()
+		err := decoder.Decode(&document)
+		if err != nil {
+			return nil, customerrors.NewValidationError("", fmt.Sprintf("Unable to parse the document: %v.", err))
+		}
+		return &document, nil
+	}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(trimmed))
+	decoder.KnownFields(true)
+	err := decoder.Decode(&document)
+	if err != nil && err != io.EOF {
+		return nil, customerrors.NewValidationError("", fmt.Sprintf("Unable to parse the document: %v.", err))
+	}
+	return &document, nil
+}
+
+// MarshalDocument writes the document in the YAML or JSON format.
+func MarshalDocument(document *Document, format string) ([]byte, error) {
+	switch format {
+	case "json":
+		data, err := json.MarshalIndent(document, "", "  ")
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		return append(data, '\n'), nil
+	case "yaml":
+		var buf bytes.Buffer
+		encoder := yaml.NewEncoder(&buf)
+		encoder.SetIndent(2)
+		err := encoder.Encode(document)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		return buf.Bytes(), nil
+	}
+	return nil, customerrors.NewValidationError("", fmt.Sprintf("Invalid format %v. Use yaml or json.", format))
+}
+
+var secretReferenceRegex = regexp.MustCompile(`^\$\{([A-Za-z_][A-Za-z0-9_]*)\}$`)
+
+// resolveSecret returns the value of the environment variable referenced by the secret. Secrets must
+// be references, so they are never stored in the document.
+func resolveSecret(reference string, field string) (string, error) {
+	matches := secretReferenceRegex.FindStringSubmatch(strings.TrimSpace(reference))
+	if matches == nil {
+		return "", customerrors.NewValidationError("", fmt.Sprintf("The %v must reference an environment variable, like ${MY_SECRET}.", field))
+	}
+	value, ok := os.LookupEnv(matches[1])
+	if !ok || len(value) == 0 {
+		return "", customerrors.NewValidationError("", fmt.Sprintf("The environment variable %v, referenced by the %v, is not set.", matches[1], field))
+	}
+	return value, nil
+}
@@ -0,0 +1,230 @@
+package core
+
+import (
+	"sort"
+
+	"github.com/leodip/goiabada/internal/data"
+	"github.com/leodip/goiabada/internal/entities"
+)
+
+type Exporter struct {
+	database data.Database
+}
+
+func NewExporter(database data.Database) *Exporter {
+	return &Exporter{
+		database: database,
+	}
+}
+
+// Export returns the configuration of the instance. Secrets are not exported.
+func (e *Exporter) Export() (*Document, error) {
+
+	document := &Document{
+		Resources: []Resource{},
+		Groups:    []Group{},
+		Clients:   []Client{},
+	}
+
+	settings, err := e.database.GetSettingsById(nil, 1)
+	if err != nil {
+		return nil, err
+	}
+	document.Settings = newSettings(settings)
+
+	resources, err := e.database.GetAllResources(nil)
+	if err != nil {
+		return nil, err
+	}
+	for _, resource := range resources {
+		if resource.IsSystemLevelResource() {
+			continue
+		}
+		permissions, err := e.database.GetPermissionsByResourceId(nil, resource.Id)
+		if err != nil {
+			return nil, err
+		}
+		document.Resources = append(document.Resources, newResource(&resource, permissions))
+	}
+
+	groups, err := e.database.GetAllGroups(nil)
+	if err != nil {
+		return nil, err
+	}
+	for _, group := range groups {
+		attributes, err := e.database.GetGroupAttributesByGroupId(nil, group.Id)
+		if err != nil {
+			return nil, err
+		}
+		err = e.database.GroupLoadPermissions(nil, group)
+		if err != nil {
+			return nil, err
+		}
+		err = e.database.PermissionsLoadResources(nil, group.Permissions)
+		if err != nil {
+			return nil, err
+		}
+		document.Groups = append(document.Groups, newGroup(group, attributes))
+	}
+
+	clients, err := e.database.GetAllClients(nil)
+	if err != nil {
+		return nil, err
+	}
+	for _, client := range clients {
+		if client.IsSystemLevelClient() {
+			continue
+		}
+		err = e.loadClient(client)
+		if err != nil {
+			return nil, err
+		}
+		document.Clients = append(document.Clients, newClient(client))
+	}
+
+	return document, nil
+}
+
+func (e *Exporter) loadClient(client *entities.Client) error {
+	err := e.database.ClientLoadRedirectURIs(nil, client)
+	if err != nil {
+		return err
+	}
+	err = e.database.ClientLoadWebOrigins(nil, client)
+	if err != nil {
+		return err
+	}
+	err = e.database.ClientLoadPermissions(nil, client)
+	if err != nil {
+		return err
+	}
+	return e.database.PermissionsLoadResources(nil, client.Permissions)
+}
+
+func newResource(resource *entities.Resource, permissions []entities.Permission) Resource {
+	result := Resource{
+		ResourceIdentifier:  resource.ResourceIdentifier,
+		Description:         resource.Description,
+		MinAcrLevel:         resource.MinAcrLevel.String(),
+		MaxAuthAgeInSeconds: resource.MaxAuthAgeInSeconds,
+		Permissions:         []Permission{},
+	}
+	for _, permission := range permissions {
+		result.Permissions = append(result.Permissions, Permission{
+			PermissionIdentifier: permission.PermissionIdentifier,
+			Description:          permission.Description,
+			MinAcrLevel:          permission.MinAcrLevel.String(),
+			MaxAuthAgeInSeconds:  permission.MaxAuthAgeInSeconds,
+		})
+	}
+	return result
+}
+
+func newGroup(group *entities.Group, attributes []entities.GroupAttribute) Group {
+	result := Group{
+		GroupIdentifier:      group.GroupIdentifier,
+		Description:          group.Description,
+		IncludeInIdToken:     group.IncludeInIdToken,
+		IncludeInAccessToken: group.IncludeInAccessToken,
+		Attributes:           []Attribute{},
+		Permissions:          getScopes(group.Permissions),
+	}
+	for _, attribute := range attributes {
+		result.Attributes = append(result.Attributes, Attribute{
+			Key:                  attribute.Key,
+			Value:                attribute.Value,
+			IncludeInIdToken:     attribute.IncludeInIdToken,
+			IncludeInAccessToken: attribute.IncludeInAccessToken,
+		})
+	}
+	return result
+}
+
+func newClient(client *entities.Client) Client {
+	result := Client{
+		ClientIdentifier:                        client.ClientIdentifier,
+		Description:                             client.Description,
+		Enabled:                                 client.Enabled,
+		ConsentRequired:                         client.ConsentRequired,
+		IsPublic:                                client.IsPublic,
+		AuthorizationCodeEnabled:                client.AuthorizationCodeEnabled,
+		ClientCredentialsEnabled:                client.ClientCredentialsEnabled,
+		DefaultAcrLevel:                         client.DefaultAcrLevel.String(),
+		TokenExpirationInSeconds:                client.TokenExpirationInSeconds,
+		RefreshTokenOfflineIdleTimeoutInSeconds: client.RefreshTokenOfflineIdleTimeoutInSeconds,
+		RefreshTokenOfflineMaxLifetimeInSeconds: client.RefreshTokenOfflineMaxLifetimeInSeconds,
+		IncludeOpenIDConnectClaimsInAccessToken: client.IncludeOpenIDConnectClaimsInAccessToken,
+		EmailLoginEnabled:                       client.EmailLoginEnabled,
+		AcceptImpersonatedTokens:                client.AcceptImpersonatedTokens,
+		RedirectURIs:                            []string{},
+		WebOrigins:                              []string{},
+		Permissions:                             getScopes(client.Permissions),
+	}
+	for _, redirectURI := range client.RedirectURIs {
+		result.RedirectURIs = append(result.RedirectURIs, redirectURI.URI)
+	}
+	for _, webOrigin := range client.WebOrigins {
+		result.WebOrigins = append(result.WebOrigins, webOrigin.Origin)
+	}
+	sort.Strings(result.RedirectURIs)
+	sort.Strings(result.WebOrigins)
+	return result
+}
+
+// getScopes returns the permissions in the resourceIdentifier:permissionIdentifier format. The
+// resources of the permissions must be loaded.
+func getScopes(permissions []entities.Permission) []string {
+	scopes := []string{}
+	for _, permission := range permissions {
+		scopes = append(scopes, permission.Resource.ResourceIdentifier+":"+permission.PermissionIdentifier)
+	}
+	sort.Strings(scopes)
+	return scopes
+}
+
+func newSettings(settings *entities.Settings) *Settings {
+	return &Settings{
+		AppName:                 &settings.AppName,
+		Issuer:                  &settings.Issuer,
+		UITheme:                 &settings.UITheme,
+		SelfRegistrationEnabled: &settings.SelfRegistrationEnabled,
+		SelfRegistrationRequiresEmailVerification: &settings.SelfRegistrationRequiresEmailVerification,
+		TokenExpirationInSeconds:                  &settings.TokenExpirationInSeconds,
+		RefreshTokenOfflineIdleTimeoutInSeconds:   &settings.RefreshTokenOfflineIdleTimeoutInSeconds,
+		RefreshTokenOfflineMaxLifetimeInSeconds:   &settings.RefreshTokenOfflineMaxLifetimeInSeconds,
+		UserSessionIdleTimeoutInSeconds:           &settings.UserSessionIdleTimeoutInSeconds,
+		UserSessionMaxLifetimeInSeconds:           &settings.UserSessionMaxLifetimeInSeconds,
+		IncludeOpenIDConnectClaimsInAccessToken:   &settings.IncludeOpenIDConnectClaimsInAccessToken,
+		TrustedDeviceLifetimeInDays:               &settings.TrustedDeviceLifetimeInDays,
+		SMTPEnabled:                               &settings.SMTPEnabled,
+		SMTPHost:                                  &settings.SMTPHost,
+		SMTPPort:                                  &settings.SMTPPort,
+		SMTPUsername:                              &settings.SMTPUsername,
+		SMTPEncryption:                            &settings.SMTPEncryption,
+		SMTPFromName:                              &settings.SMTPFromName,
+		SMTPFromEmail:                             &settings.SMTPFromEmail,
+		SMSOTPAllowedForMandatory2FA:              &settings.SMSOTPAllowedForMandatory2FA,
+		LoginDelayAfterFailedAttempts:             &settings.LoginDelayAfterFailedAttempts,
+		LoginLockoutAfterFailedAttempts:           &settings.LoginLockoutAfterFailedAttempts,
+		LoginIpLockoutAfterFailedAttempts:         &settings.LoginIpLockoutAfterFailedAttempts,
+		LoginLockoutDurationInSeconds:             &settings.LoginLockoutDurationInSeconds,
+		RiskBasedAuthEnabled:                      &settings.RiskBasedAuthEnabled,
+		RiskScoreForOTP:                           &settings.RiskScoreForOTP,
+		RiskScoreForBlock:                         &settings.RiskScoreForBlock,
+		RiskScoreForNotification:                  &settings.RiskScoreForNotification,
+		RiskTrustedIpRanges:                       &settings.RiskTrustedIpRanges,
+		RiskSuspiciousIpRanges:                    &settings.RiskSuspiciousIpRanges,
+		RejectBreachedPasswords:                   &settings.RejectBreachedPasswords,
+		ForceChangeOfBreachedPasswords:            &settings.ForceChangeOfBreachedPasswords,
+		PasswordMinLength:                         &settings.PasswordMinLength,
+		PasswordMaxLength:                         &settings.PasswordMaxLength,
+		PasswordRequiresUppercase:                 &settings.PasswordRequiresUppercase,
+		PasswordRequiresLowercase:                 &settings.PasswordRequiresLowercase,
+		PasswordRequiresNumber:                    &settings.PasswordRequiresNumber,
+		PasswordRequiresSpecialChar:               &settings.PasswordRequiresSpecialChar,
+		PasswordDisallowUsernameOrEmail:           &settings.PasswordDisallowUsernameOrEmail,
+		PasswordHistoryCount:                      &settings.PasswordHistoryCount,
+		PasswordMaxAgeInDays:                      &settings.PasswordMaxAgeInDays,
+		PasswordMinAgeInDays:                      &settings.PasswordMinAgeInDays,
+	}
+}
@@ -0,0 +1,1247 @@
+package core
+
+import (
+	"database/sql"
+	"fmt"
+	"net/url"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/leodip/goiabada/internal/core"
+	core_validators "github.com/leodip/goiabada/internal/core/validators"
+	"github.com/leodip/goiabada/internal/customerrors"
+	"github.com/leodip/goiabada/internal/data"
+	"github.com/leodip/goiabada/internal/entities"
+	"github.com/leodip/goiabada/internal/enums"
+	"github.com/leodip/goiabada/internal/lib"
+	"github.com/pkg/errors"
+)
+
+const (
+	ChangeActionCreate = "create"
+	ChangeActionUpdate = "update"
+	ChangeActionDelete = "delete"
+)
+
+// Change is an entry of the plan computed by the reconciler.
+type Change struct {
+	Action     string
+	Kind       string
+	Identifier string
+	// the fields that were changed, for updates
+	Fields []string
+}
+
+func (c Change) String() string {
+	symbol := "~"
+	switch c.Action {
+	case ChangeActionCreate:
+		symbol = "+"
+	case ChangeActionDelete:
+		symbol = "-"
+	}
+	result := fmt.Sprintf("%v %v %v %v", symbol, c.Action, c.Kind, c.Identifier)
+	if len(c.Fields) > 0 {
+		result += ": " + strings.Join(c.Fields, ", ")
+	}
+	return strings.TrimSpace(result)
+}
+
+type Reconciler struct {
+	database            data.Database
+	identifierValidator *core_validators.IdentifierValidator
+	inputSanitizer      *core.InputSanitizer
+}
+
+func NewReconciler(database data.Database, identifierValidator *core_validators.IdentifierValidator,
+	inputSanitizer *core.InputSanitizer) *Reconciler {
+	return &Reconciler{
+		database:            database,
+		identifierValidator: identifierValidator,
+		inputSanitizer:      inputSanitizer,
+	}
+}
+
+// Apply changes the instance to match the document and returns the changes that were made. All the
+// changes are made in a single transaction, so either the whole document is applied or nothing is.
+// On a dry run the transaction is rolled back, and the changes are only computed. Applying the same
+// document again results in no changes.
+func (r *Reconciler) Apply(document *Document, dryRun bool) ([]Change, error) {
+
+	err := r.validateDocument(document)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := r.database.BeginTransaction()
+	if err != nil {
+		return nil, err
+	}
+	committed := false
+	defer func() {
+		if !committed {
+			_ = r.database.RollbackTransaction(tx)
+		}
+	}()
+
+	settings, err := r.database.GetSettingsById(tx, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := []Change{}
+	if document.Resources != nil {
+		resourceChanges, err := r.reconcileResources(tx, document.Resources)
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, resourceChanges...)
+	}
+
+	// the scopes are loaded after the resources are reconciled, so groups and clients can
+	// reference the permissions created by the document
+	permissionsByScope, err := r.getPermissionsByScope(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	if document.Groups != nil {
+		groupChanges, err := r.reconcileGroups(tx, document.Groups, permissionsByScope)
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, groupChanges...)
+	}
+
+	if document.Clients != nil {
+		clientChanges, err := r.reconcileClients(tx, document.Clients, permissionsByScope, settings)
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, clientChanges...)
+	}
+
+	if document.Settings != nil {
+		settingsChange, err := r.reconcileSettings(tx, document.Settings, settings)
+		if err != nil {
+			return nil, err
+		}
+		if settingsChange != nil {
+			changes = append(changes, *settingsChange)
+		}
+	}
+
+	if dryRun {
+		return changes, nil
+	}
+
+	err = r.database.CommitTransaction(tx)
+	if err != nil {
+		return nil, err
+	}
+	committed = true
+	return changes, nil
+}
+
+// validateDocument checks the document before the transaction starts, and normalizes its values the
+// same way the admin area does, so they can be compared with the stored ones.
+func (r *Reconciler) validateDocument(document *Document) error {
+
+	resourceIdentifiers := map[string]bool{}
+	for idx := range document.Resources {
+		resource := &document.Resources[idx]
+		err := r.validateResource(resource)
+		if err != nil {
+			return customerrors.NewValidationError("", fmt.Sprintf("Resource %v: %v", resource.ResourceIdentifier, getDescription(err)))
+		}
+		if resourceIdentifiers[resource.ResourceIdentifier] {
+			return customerrors.NewValidationError("", fmt.Sprintf("Resource %v is duplicated.", resource.ResourceIdentifier))
+		}
+		resourceIdentifiers[resource.ResourceIdentifier] = true
+	}
+
+	groupIdentifiers := map[string]bool{}
+	for idx := range document.Groups {
+		group := &document.Groups[idx]
+		err := r.validateGroup(group)
+		if err != nil {
+			return customerrors.NewValidationError("", fmt.Sprintf("Group %v: %v", group.GroupIdentifier, getDescription(err)))
+		}
+		if groupIdentifiers[group.GroupIdentifier] {
+			return customerrors.NewValidationError("", fmt.Sprintf("Group %v is duplicated.", group.GroupIdentifier))
+		}
+		groupIdentifiers[group.GroupIdentifier] = true
+	}
+
+	clientIdentifiers := map[string]bool{}
+	for idx := range document.Clients {
+		client := &document.Clients[idx]
+		err := r.validateClient(client)
+		if err != nil {
+			return customerrors.NewValidationError("", fmt.Sprintf("Client %v: %v", client.ClientIdentifier, getDescription(err)))
+		}
+		if clientIdentifiers[client.ClientIdentifier] {
+			return customerrors.NewValidationError("", fmt.Sprintf("Client %v is duplicated.", client.ClientIdentifier))
+		}
+		clientIdentifiers[client.ClientIdentifier] = true
+	}
+	return nil
+}
+
+func getDescription(err error) string {
+	if valError, ok := err.(*customerrors.ValidationError); ok {
+		return valError.Description
+	}
+	return err.Error()
+}
+
+func (r *Reconciler) validateResource(resource *Resource) error {
+
+	resource.ResourceIdentifier = strings.TrimSpace(resource.ResourceIdentifier)
+	err := r.identifierValidator.ValidateIdentifier(resource.ResourceIdentifier, true)
+	if err != nil {
+		return err
+	}
+	if (&entities.Resource{ResourceIdentifier: resource.ResourceIdentifier}).IsSystemLevelResource() {
+		return customerrors.NewValidationError("", "The system-level resource can't be managed.")
+	}
+
+	resource.Description, err = r.validateDescription(resource.Description)
+	if err != nil {
+		return err
+	}
+	err = validateStepUp(resource.MinAcrLevel, resource.MaxAuthAgeInSeconds)
+	if err != nil {
+		return err
+	}
+
+	permissionIdentifiers := map[string]bool{}
+	for idx := range resource.Permissions {
+		permission := &resource.Permissions[idx]
+		permission.PermissionIdentifier = strings.TrimSpace(permission.PermissionIdentifier)
+		err = r.identifierValidator.ValidateIdentifier(permission.PermissionIdentifier, true)
+		if err != nil {
+			return err
+		}
+		if permissionIdentifiers[permission.PermissionIdentifier] {
+			return customerrors.NewValidationError("", fmt.Sprintf("Permission %v is duplicated.", permission.PermissionIdentifier))
+		}
+		permissionIdentifiers[permission.PermissionIdentifier] = true
+
+		permission.Description, err = r.validateDescription(permission.Description)
+		if err != nil {
+			return err
+		}
+		err = validateStepUp(permission.MinAcrLevel, permission.MaxAuthAgeInSeconds)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *Reconciler) validateGroup(group *Group) error {
+
+	group.GroupIdentifier = strings.TrimSpace(group.GroupIdentifier)
+	err := r.identifierValidator.ValidateIdentifier(group.GroupIdentifier, true)
+	if err != nil {
+		return err
+	}
+
+	group.Description, err = r.validateDescription(group.Description)
+	if err != nil {
+		return err
+	}
+
+	const maxLengthAttrValue = 250
+	for idx := range group.Attributes {
+		attribute := &group.Attributes[idx]
+		attribute.Key = strings.TrimSpace(attribute.Key)
+		if len(attribute.Key) == 0 {
+			return customerrors.NewValidationError("", "Attribute key is required.")
+		}
+		err = r.identifierValidator.ValidateIdentifier(attribute.Key, false)
+		if err != nil {
+			return err
+		}
+		if len(attribute.Value) > maxLengthAttrValue {
+			return customerrors.NewValidationError("", "The attribute value cannot exceed a maximum length of "+strconv.Itoa(maxLengthAttrValue)+" characters. Please make the value shorter.")
+		}
+		attribute.Key = r.inputSanitizer.Sanitize(attribute.Key)
+		attribute.Value = r.inputSanitizer.Sanitize(attribute.Value)
+	}
+
+	return validateScopes(group.Permissions)
+}
+
+func (r *Reconciler) validateClient(client *Client) error {
+
+	client.ClientIdentifier = strings.TrimSpace(client.ClientIdentifier)
+	err := r.identifierValidator.ValidateIdentifier(client.ClientIdentifier, true)
+	if err != nil {
+		return err
+	}
+	if (&entities.Client{ClientIdentifier: client.ClientIdentifier}).IsSystemLevelClient() {
+		return customerrors.NewValidationError("", "The system-level client can't be managed.")
+	}
+
+	client.Description, err = r.validateDescription(client.Description)
+	if err != nil {
+		return err
+	}
+
+	if client.IsPublic && client.ClientCredentialsEnabled {
+		return customerrors.NewValidationError("", "Public clients can't use the client credentials flow.")
+	}
+	if client.IsPublic && len(client.ClientSecret) > 0 {
+		return customerrors.NewValidationError("", "Public clients don't have a client secret.")
+	}
+	if len(client.ClientSecret) > 0 {
+		_, err = resolveSecret(client.ClientSecret, "client secret")
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(client.DefaultAcrLevel) == 0 {
+		client.DefaultAcrLevel = enums.AcrLevel2.String()
+	}
+	_, err = enums.AcrLevelFromString(client.DefaultAcrLevel)
+	if err != nil {
+		return customerrors.NewValidationError("", "Invalid default ACR level.")
+	}
+
+	const maxValue = 160000000
+	if client.TokenExpirationInSeconds < 0 || client.TokenExpirationInSeconds > maxValue {
+		return customerrors.NewValidationError("", fmt.Sprintf("Token expiration in seconds must be between 0 and %v.", maxValue))
+	}
+	if client.RefreshTokenOfflineIdleTimeoutInSeconds < 0 || client.RefreshTokenOfflineIdleTimeoutInSeconds > maxValue {
+		return customerrors.NewValidationError("", fmt.Sprintf("Refresh token offline - idle timeout in seconds must be between 0 and %v.", maxValue))
+	}
+	if client.RefreshTokenOfflineMaxLifetimeInSeconds < 0 || client.RefreshTokenOfflineMaxLifetimeInSeconds > maxValue {
+		return customerrors.NewValidationError("", fmt.Sprintf("Refresh token offline - max lifetime in seconds must be between 0 and %v.", maxValue))
+	}
+	if client.RefreshTokenOfflineIdleTimeoutInSeconds > client.RefreshTokenOfflineMaxLifetimeInSeconds {
+		return customerrors.NewValidationError("", "Refresh token offline - idle timeout cannot be greater than max lifetime.")
+	}
+
+	if len(client.IncludeOpenIDConnectClaimsInAccessToken) == 0 {
+		client.IncludeOpenIDConnectClaimsInAccessToken = enums.ThreeStateSettingDefault.String()
+	}
+	_, err = enums.ThreeStateSettingFromString(client.IncludeOpenIDConnectClaimsInAccessToken)
+	if err != nil {
+		return customerrors.NewValidationError("", "The value of includeOpenIDConnectClaimsInAccessToken must be on, off or default.")
+	}
+
+	for idx, redirectURI := range client.RedirectURIs {
+		client.RedirectURIs[idx] = strings.TrimSpace(redirectURI)
+		_, err := url.ParseRequestURI(client.RedirectURIs[idx])
+		if err != nil {
+			return customerrors.NewValidationError("", fmt.Sprintf("Invalid redirect URI: %v.", redirectURI))
+		}
+	}
+	for idx, webOrigin := range client.WebOrigins {
+		client.WebOrigins[idx] = strings.ToLower(strings.TrimSpace(webOrigin))
+		_, err := url.ParseRequestURI(client.WebOrigins[idx])
+		if err != nil {
+			return customerrors.NewValidationError("", fmt.Sprintf("Invalid web origin: %v.", webOrigin))
+		}
+	}
+
+	return validateScopes(client.Permissions)
+}
+
+func (r *Reconciler) validateDescription(description string) (string, error) {
+	description = strings.TrimSpace(description)
+	const maxLengthDescription = 100
+	if len(description) > maxLengthDescription {
+		return "", customerrors.NewValidationError("", "The description cannot exceed a maximum length of "+strconv.Itoa(maxLengthDescription)+" characters.")
+	}
+	return r.inputSanitizer.Sanitize(description), nil
+}
+
+func validateStepUp(minAcrLevel string, maxAuthAgeInSeconds int) error {
+	if len(minAcrLevel) > 0 {
+		_, err := enums.AcrLevelFromString(minAcrLevel)
+		if err != nil {
+			return customerrors.NewValidationError("", "Invalid minimum ACR level.")
+		}
+	}
+	const maxAuthAgeLimitInSeconds = 31536000
+	if maxAuthAgeInSeconds < 0 || maxAuthAgeInSeconds > maxAuthAgeLimitInSeconds {
+		return customerrors.NewValidationError("",
+			fmt.Sprintf("The maximum authentication age must be between 0 and %v seconds.", maxAuthAgeLimitInSeconds))
+	}
+	return nil
+}
+
+func validateScopes(scopes []string) error {
+	for idx, scope := range scopes {
+		scopes[idx] = strings.TrimSpace(scope)
+		parts := strings.Split(scopes[idx], ":")
+		if len(parts) != 2 || len(parts[0]) == 0 || len(parts[1]) == 0 {
+			return customerrors.NewValidationError("", fmt.Sprintf("Invalid permission %v. The format is resourceIdentifier:permissionIdentifier.", scope))
+		}
+	}
+	return nil
+}
+
+// setField sets the target to the value and records the name of the field when the value changes.
+func setField[T comparable](fields *[]string, name string, target *T, value T) {
+	if *target != value {
+		*target = value
+		*fields = append(*fields, name)
+	}
+}
+
+// setOptionalField is like setField, but leaves the target unchanged when the value is not present.
+func setOptionalField[T comparable](fields *[]string, name string, target *T, value *T) {
+	if value != nil {
+		setField(fields, name, target, *value)
+	}
+}
+
+func (r *Reconciler) reconcileResources(tx *sql.Tx, resources []Resource) ([]Change, error) {
+
+	allResources, err := r.database.GetAllResources(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	// the resources are deleted first, so their permission identifiers can be used by other resources
+	changes := []Change{}
+	existingResources := []entities.Resource{}
+	for _, existingResource := range allResources {
+		found := slices.ContainsFunc(resources, func(resource Resource) bool {
+			return resource.ResourceIdentifier == existingResource.ResourceIdentifier
+		})
+		if found || existingResource.IsSystemLevelResource() {
+			existingResources = append(existingResources, existingResource)
+			continue
+		}
+		err = r.database.DeleteResource(tx, existingResource.Id)
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, Change{Action: ChangeActionDelete, Kind: "resource", Identifier: existingResource.ResourceIdentifier})
+	}
+
+	// permission identifiers are unique across resources
+	resourcesByPermission := map[string]string{}
+	for _, existingResource := range existingResources {
+		permissions, err := r.database.GetPermissionsByResourceId(tx, existingResource.Id)
+		if err != nil {
+			return nil, err
+		}
+		for _, permission := range permissions {
+			resourcesByPermission[permission.PermissionIdentifier] = existingResource.ResourceIdentifier
+		}
+	}
+
+	for _, resource := range resources {
+
+		var existingResource *entities.Resource
+		for idx := range existingResources {
+			if existingResources[idx].ResourceIdentifier == resource.ResourceIdentifier {
+				existingResource = &existingResources[idx]
+				break
+			}
+		}
+
+		action := ChangeActionUpdate
+		if existingResource == nil {
+			action = ChangeActionCreate
+			existingResource = &entities.Resource{
+				ResourceIdentifier: resource.ResourceIdentifier,
+			}
+		}
+
+		fields := []string{}
+		setField(&fields, "description", &existingResource.Description, resource.Description)
+		setField(&fields, "minAcrLevel", &existingResource.MinAcrLevel, enums.AcrLevel(resource.MinAcrLevel))
+		setField(&fields, "maxAuthAgeInSeconds", &existingResource.MaxAuthAgeInSeconds, resource.MaxAuthAgeInSeconds)
+
+		if action == ChangeActionCreate {
+			err = r.database.CreateResource(tx, existingResource)
+			if err != nil {
+				return nil, err
+			}
+			changes = append(changes, Change{Action: action, Kind: "resource", Identifier: resource.ResourceIdentifier})
+		} else if len(fields) > 0 {
+			err = r.database.UpdateResource(tx, existingResource)
+			if err != nil {
+				return nil, err
+			}
+			changes = append(changes, Change{Action: action, Kind: "resource", Identifier: resource.ResourceIdentifier, Fields: fields})
+		}
+
+		permissionChanges, err := r.reconcilePermissions(tx, existingResource, resource.Permissions, resourcesByPermission)
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, permissionChanges...)
+	}
+	return changes, nil
+}
+
+func (r *Reconciler) reconcilePermissions(tx *sql.Tx, resource *entities.Resource, permissions []Permission,
+	resourcesByPermission map[string]string) ([]Change, error) {
+
+	existingPermissions, err := r.database.GetPermissionsByResourceId(tx, resource.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := []Change{}
+	for _, existingPermission := range existingPermissions {
+		found := slices.ContainsFunc(permissions, func(permission Permission) bool {
+			return permission.PermissionIdentifier == existingPermission.PermissionIdentifier
+		})
+		if !found {
+			err = r.database.DeletePermission(tx, existingPermission.Id)
+			if err != nil {
+				return nil, err
+			}
+			delete(resourcesByPermission, existingPermission.PermissionIdentifier)
+			changes = append(changes, Change{Action: ChangeActionDelete, Kind: "permission",
+				Identifier: resource.ResourceIdentifier + ":" + existingPermission.PermissionIdentifier})
+		}
+	}
+
+	for _, permission := range permissions {
+
+		scope := resource.ResourceIdentifier + ":" + permission.PermissionIdentifier
+
+		var existingPermission *entities.Permission
+		for idx := range existingPermissions {
+			if existingPermissions[idx].PermissionIdentifier == permission.PermissionIdentifier {
+				existingPermission = &existingPermissions[idx]
+				break
+			}
+		}
+
+		action := ChangeActionUpdate
+		if existingPermission == nil {
+			if resourceIdentifier, ok := resourcesByPermission[permission.PermissionIdentifier]; ok {
+				return nil, customerrors.NewValidationError("", fmt.Sprintf("Resource %v: the permission identifier %v is already in use by the resource %v.",
+					resource.ResourceIdentifier, permission.PermissionIdentifier, resourceIdentifier))
+			}
+			action = ChangeActionCreate
+			existingPermission = &entities.Permission{
+				PermissionIdentifier: permission.PermissionIdentifier,
+				ResourceId:           resource.Id,
+			}
+		}
+
+		fields := []string{}
+		setField(&fields, "description", &existingPermission.Description, permission.Description)
+		setField(&fields, "minAcrLevel", &existingPermission.MinAcrLevel, enums.AcrLevel(permission.MinAcrLevel))
+		setField(&fields, "maxAuthAgeInSeconds", &existingPermission.MaxAuthAgeInSeconds, permission.MaxAuthAgeInSeconds)
+
+		if action == ChangeActionCreate {
+			err = r.database.CreatePermission(tx, existingPermission)
+			if err != nil {
+				return nil, err
+			}
+			resourcesByPermission[permission.PermissionIdentifier] = resource.ResourceIdentifier
+			changes = append(changes, Change{Action: action, Kind: "permission", Identifier: scope})
+		} else if len(fields) > 0 {
+			err = r.database.UpdatePermission(tx, existingPermission)
+			if err != nil {
+				return nil, err
+			}
+			changes = append(changes, Change{Action: action, Kind: "permission", Identifier: scope, Fields: fields})
+		}
+	}
+	return changes, nil
+}
+
+func (r *Reconciler) getPermissionsByScope(tx *sql.Tx) (map[string]int64, error) {
+
+	resources, err := r.database.GetAllResources(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	permissionsByScope := map[string]int64{}
+	for _, resource := range resources {
+		permissions, err := r.database.GetPermissionsByResourceId(tx, resource.Id)
+		if err != nil {
+			return nil, err
+		}
+		for _, permission := range permissions {
+			permissionsByScope[resource.ResourceIdentifier+":"+permission.PermissionIdentifier] = permission.Id
+		}
+	}
+	return permissionsByScope, nil
+}
+
+// getPermissionIds returns the ids of the permissions referenced by the scopes.
+func getPermissionIds(scopes []string, permissionsByScope map[string]int64) ([]int64, error) {
+	permissionIds := []int64{}
+	for _, scope := range scopes {
+		permissionId, ok := permissionsByScope[scope]
+		if !ok {
+			return nil, customerrors.NewValidationError("", fmt.Sprintf("Permission not found: %v.", scope))
+		}
+		if !slices.Contains(permissionIds, permissionId) {
+			permissionIds = append(permissionIds, permissionId)
+		}
+	}
+	return permissionIds, nil
+}
+
+func (r *Reconciler) reconcileGroups(tx *sql.Tx, groups []Group, permissionsByScope map[string]int64) ([]Change, error) {
+
+	existingGroups, err := r.database.GetAllGroups(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := []Change{}
+	for _, group := range groups {
+
+		permissionIds, err := getPermissionIds(group.Permissions, permissionsByScope)
+		if err != nil {
+			return nil, customerrors.NewValidationError("", fmt.Sprintf("Group %v: %v", group.GroupIdentifier, getDescription(err)))
+		}
+
+		var existingGroup *entities.Group
+		for _, g := range existingGroups {
+			if g.GroupIdentifier == group.GroupIdentifier {
+				existingGroup = g
+				break
+			}
+		}
+
+		action := ChangeActionUpdate
+		if existingGroup == nil {
+			action = ChangeActionCreate
+			existingGroup = &entities.Group{
+				GroupIdentifier: group.GroupIdentifier,
+			}
+		}
+
+		fields := []string{}
+		setField(&fields, "description", &existingGroup.Description, group.Description)
+		setField(&fields, "includeInIdToken", &existingGroup.IncludeInIdToken, group.IncludeInIdToken)
+		setField(&fields, "includeInAccessToken", &existingGroup.IncludeInAccessToken, group.IncludeInAccessToken)
+
+		if action == ChangeActionCreate {
+			err = r.database.CreateGroup(tx, existingGroup)
+		} else if len(fields) > 0 {
+			err = r.database.UpdateGroup(tx, existingGroup)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		attributesChanged, err := r.reconcileGroupAttributes(tx, existingGroup, group.Attributes)
+		if err != nil {
+			return nil, err
+		}
+		if attributesChanged {
+			fields = append(fields, "attributes")
+		}
+
+		existingGroupPermissions, err := r.database.GetGroupPermissionsByGroupId(tx, existingGroup.Id)
+		if err != nil {
+			return nil, err
+		}
+		existingPermissionIds := []int64{}
+		for _, groupPermission := range existingGroupPermissions {
+			existingPermissionIds = append(existingPermissionIds, groupPermission.PermissionId)
+		}
+		permissionsChanged := false
+		for _, groupPermission := range existingGroupPermissions {
+			if !slices.Contains(permissionIds, groupPermission.PermissionId) {
+				err = r.database.DeleteGroupPermission(tx, groupPermission.Id)
+				if err != nil {
+					return nil, err
+				}
+				permissionsChanged = true
+			}
+		}
+		for _, permissionId := range permissionIds {
+			if !slices.Contains(existingPermissionIds, permissionId) {
+				err = r.database.CreateGroupPermission(tx, &entities.GroupPermission{
+					GroupId:      existingGroup.Id,
+					PermissionId: permissionId,
+				})
+				if err != nil {
+					return nil, err
+				}
+				permissionsChanged = true
+			}
+		}
+		if permissionsChanged {
+			fields = append(fields, "permissions")
+		}
+
+		if action == ChangeActionCreate {
+			changes = append(changes, Change{Action: action, Kind: "group", Identifier: group.GroupIdentifier})
+		} else if len(fields) > 0 {
+			changes = append(changes, Change{Action: action, Kind: "group", Identifier: group.GroupIdentifier, Fields: fields})
+		}
+	}
+
+	for _, existingGroup := range existingGroups {
+		found := slices.ContainsFunc(groups, func(group Group) bool {
+			return group.GroupIdentifier == existingGroup.GroupIdentifier
+		})
+		if !found {
+			err = r.database.DeleteGroup(tx, existingGroup.Id)
+			if err != nil {
+				return nil, err
+			}
+			changes = append(changes, Change{Action: ChangeActionDelete, Kind: "group", Identifier: existingGroup.GroupIdentifier})
+		}
+	}
+	return changes, nil
+}
+
+// reconcileGroupAttributes replaces the attributes of the group when they differ from the document.
+func (r *Reconciler) reconcileGroupAttributes(tx *sql.Tx, group *entities.Group, attributes []Attribute) (bool, error) {
+
+	existingAttributes, err := r.database.GetGroupAttributesByGroupId(tx, group.Id)
+	if err != nil {
+		return false, err
+	}
+
+	current := []Attribute{}
+	for _, attribute := range existingAttributes {
+		current = append(current, Attribute{
+			Key:                  attribute.Key,
+			Value:                attribute.Value,
+			IncludeInIdToken:     attribute.IncludeInIdToken,
+			IncludeInAccessToken: attribute.IncludeInAccessToken,
+		})
+	}
+	desired := slices.Clone(attributes)
+	sortAttributes(current)
+	sortAttributes(desired)
+	if slices.Equal(current, desired) {
+		return false, nil
+	}
+
+	for _, attribute := range existingAttributes {
+		err = r.database.DeleteGroupAttribute(tx, attribute.Id)
+		if err != nil {
+			return false, err
+		}
+	}
+	for _, attribute := range attributes {
+		err = r.database.CreateGroupAttribute(tx, &entities.GroupAttribute{
+			Key:                  attribute.Key,
+			Value:                attribute.Value,
+			IncludeInIdToken:     attribute.IncludeInIdToken,
+			IncludeInAccessToken: attribute.IncludeInAccessToken,
+			GroupId:              group.Id,
+		})
+		if err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+func sortAttributes(attributes []Attribute) {
+	sort.SliceStable(attributes, func(i, j int) bool {
+		if attributes[i].Key != attributes[j].Key {
+			return attributes[i].Key < attributes[j].Key
+		}
+		return attributes[i].Value < attributes[j].Value
+	})
+}
+
+func (r *Reconciler) reconcileClients(tx *sql.Tx, clients []Client, permissionsByScope map[string]int64,
+	settings *entities.Settings) ([]Change, error) {
+
+	existingClients, err := r.database.GetAllClients(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := []Change{}
+	for _, client := range clients {
+
+		permissionIds, err := getPermissionIds(client.Permissions, permissionsByScope)
+		if err != nil {
+			return nil, customerrors.NewValidationError("", fmt.Sprintf("Client %v: %v", client.ClientIdentifier, getDescription(err)))
+		}
+
+		var existingClient *entities.Client
+		for _, c := range existingClients {
+			if c.ClientIdentifier == client.ClientIdentifier {
+				existingClient = c
+				break
+			}
+		}
+
+		action := ChangeActionUpdate
+		if existingClient == nil {
+			action = ChangeActionCreate
+			existingClient = &entities.Client{
+				ClientIdentifier: client.ClientIdentifier,
+			}
+		}
+
+		// empty values are read as the defaults, so they are not reported as changes
+		if len(existingClient.DefaultAcrLevel) == 0 {
+			existingClient.DefaultAcrLevel = enums.AcrLevel2
+		}
+		if len(existingClient.IncludeOpenIDConnectClaimsInAccessToken) == 0 {
+			existingClient.IncludeOpenIDConnectClaimsInAccessToken = enums.ThreeStateSettingDefault.String()
+		}
+
+		fields := []string{}
+		setField(&fields, "description", &existingClient.Description, client.Description)
+		setField(&fields, "enabled", &existingClient.Enabled, client.Enabled)
+		setField(&fields, "consentRequired", &existingClient.ConsentRequired, client.ConsentRequired)
+		setField(&fields, "isPublic", &existingClient.IsPublic, client.IsPublic)
+		setField(&fields, "authorizationCodeEnabled", &existingClient.AuthorizationCodeEnabled, client.AuthorizationCodeEnabled)
+		setField(&fields, "clientCredentialsEnabled", &existingClient.ClientCredentialsEnabled, client.ClientCredentialsEnabled)
+		setField(&fields, "defaultAcrLevel", &existingClient.DefaultAcrLevel, enums.AcrLevel(client.DefaultAcrLevel))
+		setField(&fields, "tokenExpirationInSeconds", &existingClient.TokenExpirationInSeconds, client.TokenExpirationInSeconds)
+		setField(&fields, "refreshTokenOfflineIdleTimeoutInSeconds", &existingClient.RefreshTokenOfflineIdleTimeoutInSeconds, client.RefreshTokenOfflineIdleTimeoutInSeconds)
+		setField(&fields, "refreshTokenOfflineMaxLifetimeInSeconds", &existingClient.RefreshTokenOfflineMaxLifetimeInSeconds, client.RefreshTokenOfflineMaxLifetimeInSeconds)
+		setField(&fields, "includeOpenIDConnectClaimsInAccessToken", &existingClient.IncludeOpenIDConnectClaimsInAccessToken, client.IncludeOpenIDConnectClaimsInAccessToken)
+		setField(&fields, "emailLoginEnabled", &existingClient.EmailLoginEnabled, client.EmailLoginEnabled)
+		setField(&fields, "acceptImpersonatedTokens", &existingClient.AcceptImpersonatedTokens, client.AcceptImpersonatedTokens)
+
+		secretChanged, err := setClientSecret(existingClient, client.ClientSecret, settings)
+		if err != nil {
+			return nil, err
+		}
+		if secretChanged && action == ChangeActionUpdate {
+			fields = append(fields, "clientSecret")
+		}
+
+		if action == ChangeActionCreate {
+			err = r.database.CreateClient(tx, existingClient)
+		} else if len(fields) > 0 {
+			err = r.database.UpdateClient(tx, existingClient)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		urisChanged, err := r.reconcileClientURIs(tx, existingClient, client.RedirectURIs, client.WebOrigins)
+		if err != nil {
+			return nil, err
+		}
+		fields = append(fields, urisChanged...)
+
+		existingClientPermissions, err := r.database.GetClientPermissionsByClientId(tx, existingClient.Id)
+		if err != nil {
+			return nil, err
+		}
+		existingPermissionIds := []int64{}
+		for _, clientPermission := range existingClientPermissions {
+			existingPermissionIds = append(existingPermissionIds, clientPermission.PermissionId)
+		}
+		permissionsChanged := false
+		for _, clientPermission := range existingClientPermissions {
+			if !slices.Contains(permissionIds, clientPermission.PermissionId) {
+				err = r.database.DeleteClientPermission(tx, clientPermission.Id)
+				if err != nil {
+					return nil, err
+				}
+				permissionsChanged = true
+			}
+		}
+		for _, permissionId := range permissionIds {
+			if !slices.Contains(existingPermissionIds, permissionId) {
+				err = r.database.CreateClientPermission(tx, &entities.ClientPermission{
+					ClientId:     existingClient.Id,
+					PermissionId: permissionId,
+				})
+				if err != nil {
+					return nil, err
+				}
+				permissionsChanged = true
+			}
+		}
+		if permissionsChanged {
+			fields = append(fields, "permissions")
+		}
+
+		if action == ChangeActionCreate {
+			changes = append(changes, Change{Action: action, Kind: "client", Identifier: client.ClientIdentifier})
+		} else if len(fields) > 0 {
+			changes = append(changes, Change{Action: action, Kind: "client", Identifier: client.ClientIdentifier, Fields: fields})
+		}
+	}
+
+	for _, existingClient := range existingClients {
+		if existingClient.IsSystemLevelClient() {
+			continue
+		}
+		found := slices.ContainsFunc(clients, func(client Client) bool {
+			return client.ClientIdentifier == existingClient.ClientIdentifier
+		})
+		if !found {
+			err = r.database.DeleteClient(tx, existingClient.Id)
+			if err != nil {
+				return nil, err
+			}
+			changes = append(changes, Change{Action: ChangeActionDelete, Kind: "client", Identifier: existingClient.ClientIdentifier})
+		}
+	}
+	return changes, nil
+}
+
+// setClientSecret sets the secret referenced by the document. Without a reference, a new client gets
+// a random secret and the secret of an existing client is kept.
+func setClientSecret(client *entities.Client, reference string, settings *entities.Settings) (bool, error) {
+
+	if len(reference) == 0 {
+		if client.Id == 0 {
+			clientSecretEncrypted, err := lib.EncryptText(lib.GenerateSecureRandomString(60), settings.AESEncryptionKey)
+			if err != nil {
+				return false, err
+			}
+			client.ClientSecretEncrypted = clientSecretEncrypted
+		}
+		return false, nil
+	}
+
+	clientSecret, err := resolveSecret(reference, "client secret")
+	if err != nil {
+		return false, err
+	}
+	if client.ClientSecretEncrypted != nil {
+		currentClientSecret, err := lib.DecryptText(client.ClientSecretEncrypted, settings.AESEncryptionKey)
+		if err != nil {
+			return false, errors.Wrap(err, "unable to decrypt the client secret")
+		}
+		if currentClientSecret == clientSecret {
+			return false, nil
+		}
+	}
+
+	client.ClientSecretEncrypted, err = lib.EncryptText(clientSecret, settings.AESEncryptionKey)
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// reconcileClientURIs replaces the redirect URIs and the web origins of the client, and returns the
+// names of the fields that changed.
+func (r *Reconciler) reconcileClientURIs(tx *sql.Tx, client *entities.Client, redirectURIs []string,
+	webOrigins []string) ([]string, error) {
+
+	fields := []string{}
+
+	existingRedirectURIs, err := r.database.GetRedirectURIsByClientId(tx, client.Id)
+	if err != nil {
+		return nil, err
+	}
+	changed := false
+	for _, redirectURI := range existingRedirectURIs {
+		if !slices.Contains(redirectURIs, redirectURI.URI) {
+			err = r.database.DeleteRedirectURI(tx, redirectURI.Id)
+			if err != nil {
+				return nil, err
+			}
+			changed = true
+		}
+	}
+	for idx, uri := range redirectURIs {
+		found := slices.ContainsFunc(existingRedirectURIs, func(redirectURI entities.RedirectURI) bool {
+			return redirectURI.URI == uri
+		})
+		// the same URI is only added once
+		if !found && !slices.Contains(redirectURIs[:idx], uri) {
+			err = r.database.CreateRedirectURI(tx, &entities.RedirectURI{
+				ClientId: client.Id,
+				URI:      uri,
+			})
+			if err != nil {
+				return nil, err
+			}
+			changed = true
+		}
+	}
+	if changed {
+		fields = append(fields, "redirectURIs")
+	}
+
+	existingWebOrigins, err := r.database.GetWebOriginsByClientId(tx, client.Id)
+	if err != nil {
+		return nil, err
+	}
+	changed = false
+	for _, webOrigin := range existingWebOrigins {
+		if !slices.Contains(webOrigins, webOrigin.Origin) {
+			err = r.database.DeleteWebOrigin(tx, webOrigin.Id)
+			if err != nil {
+				return nil, err
+			}
+			changed = true
+		}
+	}
+	for idx, origin := range webOrigins {
+		found := slices.ContainsFunc(existingWebOrigins, func(webOrigin entities.WebOrigin) bool {
+			return webOrigin.Origin == origin
+		})
+		if !found && !slices.Contains(webOrigins[:idx], origin) {
+			err = r.database.CreateWebOrigin(tx, &entities.WebOrigin{
+				ClientId: client.Id,
+				Origin:   origin,
+			})
+			if err != nil {
+				return nil, err
+			}
+			changed = true
+		}
+	}
+	if changed {
+		fields = append(fields, "webOrigins")
+	}
+	return fields, nil
+}
+
+func (r *Reconciler) reconcileSettings(tx *sql.Tx, document *Settings, settings *entities.Settings) (*Change, error) {
+
+	fields := []string{}
+	if document.AppName != nil {
+		setField(&fields, "appName", &settings.AppName, r.inputSanitizer.Sanitize(strings.TrimSpace(*document.AppName)))
+	}
+	if document.Issuer != nil {
+		setField(&fields, "issuer", &settings.Issuer, r.inputSanitizer.Sanitize(strings.TrimSpace(*document.Issuer)))
+	}
+	setOptionalField(&fields, "uiTheme", &settings.UITheme, document.UITheme)
+	setOptionalField(&fields, "selfRegistrationEnabled", &settings.SelfRegistrationEnabled, document.SelfRegistrationEnabled)
+	setOptionalField(&fields, "selfRegistrationRequiresEmailVerification", &settings.SelfRegistrationRequiresEmailVerification, document.SelfRegistrationRequiresEmailVerification)
+	setOptionalField(&fields, "tokenExpirationInSeconds", &settings.TokenExpirationInSeconds, document.TokenExpirationInSeconds)
+	setOptionalField(&fields, "refreshTokenOfflineIdleTimeoutInSeconds", &settings.RefreshTokenOfflineIdleTimeoutInSeconds, document.RefreshTokenOfflineIdleTimeoutInSeconds)
+	setOptionalField(&fields, "refreshTokenOfflineMaxLifetimeInSeconds", &settings.RefreshTokenOfflineMaxLifetimeInSeconds, document.RefreshTokenOfflineMaxLifetimeInSeconds)
+	setOptionalField(&fields, "userSessionIdleTimeoutInSeconds", &settings.UserSessionIdleTimeoutInSeconds, document.UserSessionIdleTimeoutInSeconds)
+	setOptionalField(&fields, "userSessionMaxLifetimeInSeconds", &settings.UserSessionMaxLifetimeInSeconds, document.UserSessionMaxLifetimeInSeconds)
+	setOptionalField(&fields, "includeOpenIDConnectClaimsInAccessToken", &settings.IncludeOpenIDConnectClaimsInAccessToken, document.IncludeOpenIDConnectClaimsInAccessToken)
+	setOptionalField(&fields, "trustedDeviceLifetimeInDays", &settings.TrustedDeviceLifetimeInDays, document.TrustedDeviceLifetimeInDays)
+	setOptionalField(&fields, "smtpEnabled", &settings.SMTPEnabled, document.SMTPEnabled)
+	setOptionalField(&fields, "smtpHost", &settings.SMTPHost, document.SMTPHost)
+	setOptionalField(&fields, "smtpPort", &settings.SMTPPort, document.SMTPPort)
+	setOptionalField(&fields, "smtpUsername", &settings.SMTPUsername, document.SMTPUsername)
+	setOptionalField(&fields, "smtpEncryption", &settings.SMTPEncryption, document.SMTPEncryption)
+	setOptionalField(&fields, "smtpFromName", &settings.SMTPFromName, document.SMTPFromName)
+	setOptionalField(&fields, "smtpFromEmail", &settings.SMTPFromEmail, document.SMTPFromEmail)
+	setOptionalField(&fields, "smsOTPAllowedForMandatory2FA", &settings.SMSOTPAllowedForMandatory2FA, document.SMSOTPAllowedForMandatory2FA)
+	setOptionalField(&fields, "loginDelayAfterFailedAttempts", &settings.LoginDelayAfterFailedAttempts, document.LoginDelayAfterFailedAttempts)
+	setOptionalField(&fields, "loginLockoutAfterFailedAttempts", &settings.LoginLockoutAfterFailedAttempts, document.LoginLockoutAfterFailedAttempts)
+	setOptionalField(&fields, "loginIpLockoutAfterFailedAttempts", &settings.LoginIpLockoutAfterFailedAttempts, document.LoginIpLockoutAfterFailedAttempts)
+	setOptionalField(&fields, "loginLockoutDurationInSeconds", &settings.LoginLockoutDurationInSeconds, document.LoginLockoutDurationInSeconds)
+	setOptionalField(&fields, "riskBasedAuthEnabled", &settings.RiskBasedAuthEnabled, document.RiskBasedAuthEnabled)
+	setOptionalField(&fields, "riskScoreForOTP", &settings.RiskScoreForOTP, document.RiskScoreForOTP)
+	setOptionalField(&fields, "riskScoreForBlock", &settings.RiskScoreForBlock, document.RiskScoreForBlock)
+	setOptionalField(&fields, "riskScoreForNotification", &settings.RiskScoreForNotification, document.RiskScoreForNotification)
+	setOptionalField(&fields, "riskTrustedIpRanges", &settings.RiskTrustedIpRanges, document.RiskTrustedIpRanges)
+	setOptionalField(&fields, "riskSuspiciousIpRanges", &settings.RiskSuspiciousIpRanges, document.RiskSuspiciousIpRanges)
+	setOptionalField(&fields, "rejectBreachedPasswords", &settings.RejectBreachedPasswords, document.RejectBreachedPasswords)
+	setOptionalField(&fields, "forceChangeOfBreachedPasswords", &settings.ForceChangeOfBreachedPasswords, document.ForceChangeOfBreachedPasswords)
+	setOptionalField(&fields, "passwordMinLength", &settings.PasswordMinLength, document.PasswordMinLength)
+	setOptionalField(&fields, "passwordMaxLength", &settings.PasswordMaxLength, document.PasswordMaxLength)
+	setOptionalField(&fields, "passwordRequiresUppercase", &settings.PasswordRequiresUppercase, document.PasswordRequiresUppercase)
+	setOptionalField(&fields, "passwordRequiresLowercase", &settings.PasswordRequiresLowercase, document.PasswordRequiresLowercase)
+	setOptionalField(&fields, "passwordRequiresNumber", &settings.PasswordRequiresNumber, document.PasswordRequiresNumber)
+	setOptionalField(&fields, "passwordRequiresSpecialChar", &settings.PasswordRequiresSpecialChar, document.PasswordRequiresSpecialChar)
+	setOptionalField(&fields, "passwordDisallowUsernameOrEmail", &settings.PasswordDisallowUsernameOrEmail, document.PasswordDisallowUsernameOrEmail)
+	setOptionalField(&fields, "passwordHistoryCount", &settings.PasswordHistoryCount, document.PasswordHistoryCount)
+	setOptionalField(&fields, "passwordMaxAgeInDays", &settings.PasswordMaxAgeInDays, document.PasswordMaxAgeInDays)
+	setOptionalField(&fields, "passwordMinAgeInDays", &settings.PasswordMinAgeInDays, document.PasswordMinAgeInDays)
+
+	if document.SMTPPassword != nil {
+		changed, err := setSMTPPassword(settings, *document.SMTPPassword)
+		if err != nil {
+			return nil, err
+		}
+		if changed {
+			fields = append(fields, "smtpPassword")
+		}
+	}
+
+	if len(fields) == 0 {
+		return nil, nil
+	}
+
+	err := validateSettings(settings)
+	if err != nil {
+		return nil, customerrors.NewValidationError("", "Settings: "+getDescription(err))
+	}
+
+	err = r.database.UpdateSettings(tx, settings)
+	if err != nil {
+		return nil, err
+	}
+	return &Change{Action: ChangeActionUpdate, Kind: "settings", Fields: fields}, nil
+}
+
+// setSMTPPassword sets the password referenced by the document. An empty reference removes the password.
+func setSMTPPassword(settings *entities.Settings, reference string) (bool, error) {
+
+	if len(reference) == 0 {
+		if settings.SMTPPasswordEncrypted == nil {
+			return false, nil
+		}
+		settings.SMTPPasswordEncrypted = nil
+		return true, nil
+	}
+
+	smtpPassword, err := resolveSecret(reference, "SMTP password")
+	if err != nil {
+		return false, customerrors.NewValidationError("", "Settings: "+getDescription(err))
+	}
+	if settings.SMTPPasswordEncrypted != nil {
+		currentSMTPPassword, err := lib.DecryptText(settings.SMTPPasswordEncrypted, settings.AESEncryptionKey)
+		if err != nil {
+			return false, errors.Wrap(err, "unable to decrypt the SMTP password")
+		}
+		if currentSMTPPassword == smtpPassword {
+			return false, nil
+		}
+	}
+
+	settings.SMTPPasswordEncrypted, err = lib.EncryptText(smtpPassword, settings.AESEncryptionKey)
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// validateSettings applies the rules of the settings pages of the admin area to the resulting settings.
+func validateSettings(settings *entities.Settings) error {
+
+	maxLength := 30
+	if len(settings.AppName) > maxLength {
+		return customerrors.NewValidationError("", fmt.Sprintf("App name is too long. The maximum length is %v characters.", maxLength))
+	}
+
+	// any value containing a ":" character MUST be a URI
+	if strings.Contains(settings.Issuer, ":") {
+		_, err := url.ParseRequestURI(settings.Issuer)
+		if err != nil {
+			return customerrors.NewValidationError("", "Invalid issuer. Please enter a valid URI.")
+		}
+	} else {
+		errorMsg := "Invalid issuer. It must start with a letter, can include letters, numbers, dashes, and underscores, but cannot end with a dash or underscore, or have two consecutive dashes or underscores."
+		match, _ := regexp.MatchString("^[a-zA-Z]([a-zA-Z0-9_-]*[a-zA-Z0-9])?$", settings.Issuer)
+		if !match || strings.Contains(settings.Issuer, "--") || strings.Contains(settings.Issuer, "__") {
+			return customerrors.NewValidationError("", errorMsg)
+		}
+		minLength := 3
+		if len(settings.Issuer) < minLength {
+			return customerrors.NewValidationError("", fmt.Sprintf("Issuer is too short. The minimum length is %v characters.", minLength))
+		}
+	}
+	maxLength = 60
+	if len(settings.Issuer) > maxLength {
+		return customerrors.NewValidationError("", fmt.Sprintf("Issuer is too long. The maximum length is %v characters.", maxLength))
+	}
+
+	if !slices.Contains(lib.GetUIThemes(), settings.UITheme) && settings.UITheme != "" {
+		return customerrors.NewValidationError("", "Invalid theme.")
+	}
+
+	const maxValue = 160000000
+	if settings.TokenExpirationInSeconds <= 0 || settings.TokenExpirationInSeconds > maxValue {
+		return customerrors.NewValidationError("", fmt.Sprintf("Token expiration in seconds must be between 1 and %v.", maxValue))
+	}
+	if settings.RefreshTokenOfflineIdleTimeoutInSeconds <= 0 || settings.RefreshTokenOfflineIdleTimeoutInSeconds > maxValue {
+		return customerrors.NewValidationError("", fmt.Sprintf("Refresh token offline - idle timeout in seconds must be between 1 and %v.", maxValue))
+	}
+	if settings.RefreshTokenOfflineMaxLifetimeInSeconds <= 0 || settings.RefreshTokenOfflineMaxLifetimeInSeconds > maxValue {
+		return customerrors.NewValidationError("", fmt.Sprintf("Refresh token offline - max lifetime in seconds must be between 1 and %v.", maxValue))
+	}
+	if settings.RefreshTokenOfflineIdleTimeoutInSeconds > settings.RefreshTokenOfflineMaxLifetimeInSeconds {
+		return customerrors.NewValidationError("", "Refresh token offline - idle timeout cannot be greater than max lifetime.")
+	}
+	if settings.UserSessionIdleTimeoutInSeconds <= 0 || settings.UserSessionIdleTimeoutInSeconds > maxValue {
+		return customerrors.NewValidationError("", fmt.Sprintf("User session - idle timeout in seconds must be between 1 and %v.", maxValue))
+	}
+	if settings.UserSessionMaxLifetimeInSeconds <= 0 || settings.UserSessionMaxLifetimeInSeconds > maxValue {
+		return customerrors.NewValidationError("", fmt.Sprintf("User session - max lifetime in seconds must be between 1 and %v.", maxValue))
+	}
+	if settings.UserSessionIdleTimeoutInSeconds > settings.UserSessionMaxLifetimeInSeconds {
+		return customerrors.NewValidationError("", "User session - the idle timeout cannot be greater than the max lifetime.")
+	}
+
+	const maxTrustedDeviceLifetimeInDays = 365
+	if settings.TrustedDeviceLifetimeInDays < 0 || settings.TrustedDeviceLifetimeInDays > maxTrustedDeviceLifetimeInDays {
+		return customerrors.NewValidationError("", fmt.Sprintf("Trusted devices - lifetime in days must be between 0 and %v.", maxTrustedDeviceLifetimeInDays))
+	}
+
+	if settings.SMTPEnabled {
+		if len(settings.SMTPHost) == 0 {
+			return customerrors.NewValidationError("", "SMTP host is required.")
+		}
+		if settings.SMTPPort < 1 || settings.SMTPPort > 65535 {
+			return customerrors.NewValidationError("", "SMTP port must be between 1 and 65535.")
+		}
+		if len(settings.SMTPFromEmail) == 0 {
+			return customerrors.NewValidationError("", "SMTP from email is required.")
+		}
+	}
+	if len(settings.SMTPEncryption) > 0 {
+		_, err := enums.SMTPEncryptionFromString(settings.SMTPEncryption)
+		if err != nil {
+			return customerrors.NewValidationError("", "Invalid SMTP encryption.")
+		}
+	}
+
+	const maxAttempts = 1000
+	if settings.LoginDelayAfterFailedAttempts < 0 || settings.LoginDelayAfterFailedAttempts > maxAttempts {
+		return customerrors.NewValidationError("", fmt.Sprintf("Progressive delay - after failed attempts must be between 0 and %v.", maxAttempts))
+	}
+	if settings.LoginLockoutAfterFailedAttempts < 0 || settings.LoginLockoutAfterFailedAttempts > maxAttempts {
+		return customerrors.NewValidationError("", fmt.Sprintf("Account lockout - after failed attempts must be between 0 and %v.", maxAttempts))
+	}
+	const maxIpAttempts = 100000
+	if settings.LoginIpLockoutAfterFailedAttempts < 0 || settings.LoginIpLockoutAfterFailedAttempts > maxIpAttempts {
+		return customerrors.NewValidationError("", fmt.Sprintf("IP address lockout - after failed attempts must be between 0 and %v.", maxIpAttempts))
+	}
+	const maxLockoutDurationInSeconds = 86400
+	if settings.LoginLockoutDurationInSeconds <= 0 || settings.LoginLockoutDurationInSeconds > maxLockoutDurationInSeconds {
+		return customerrors.NewValidationError("", fmt.Sprintf("Lockout duration in seconds must be between 1 and %v.", maxLockoutDurationInSeconds))
+	}
+
+	for _, riskScore := range []int{settings.RiskScoreForOTP, settings.RiskScoreForBlock, settings.RiskScoreForNotification} {
+		if riskScore < 0 || riskScore > 100 {
+			return customerrors.NewValidationError("", "Risk scores must be between 0 and 100.")
+		}
+	}
+	for _, ipRanges := range []string{settings.RiskTrustedIpRanges, settings.RiskSuspiciousIpRanges} {
+		_, err := core.ParseIpRanges(ipRanges)
+		if err != nil {
+			return err
+		}
+	}
+
+	const maxPasswordLength = 64
+	if settings.PasswordMinLength < 1 || settings.PasswordMinLength > maxPasswordLength {
+		return customerrors.NewValidationError("", fmt.Sprintf("Minimum length must be between 1 and %v.", maxPasswordLength))
+	}
+	if settings.PasswordMaxLength < settings.PasswordMinLength || settings.PasswordMaxLength > maxPasswordLength {
+		return customerrors.NewValidationError("", fmt.Sprintf("Maximum length must be between the minimum length and %v.", maxPasswordLength))
+	}
+	const maxPasswordHistoryCount = 24
+	if settings.PasswordHistoryCount < 0 || settings.PasswordHistoryCount > maxPasswordHistoryCount {
+		return customerrors.NewValidationError("", fmt.Sprintf("Password history must be between 0 and %v.", maxPasswordHistoryCount))
+	}
+	const maxPasswordAgeInDays = 3650
+	if settings.PasswordMaxAgeInDays < 0 || settings.PasswordMaxAgeInDays > maxPasswordAgeInDays {
+		return customerrors.NewValidationError("", fmt.Sprintf("Maximum password age in days must be between 0 and %v.", maxPasswordAgeInDays))
+	}
+	if settings.PasswordMinAgeInDays < 0 || settings.PasswordMinAgeInDays > maxPasswordAgeInDays {
+		return customerrors.NewValidationError("", fmt.Sprintf("Minimum password age in days must be between 0 and %v.", maxPasswordAgeInDays))
+	}
+	if settings.PasswordMaxAgeInDays > 0 && settings.PasswordMinAgeInDays >= settings.PasswordMaxAgeInDays {
+		return customerrors.NewValidationError("", "Minimum password age in days must be lower than the maximum password age.")
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+package core
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/leodip/goiabada/internal/entities"
+)
+
+// FederatedIdentity is the protocol independent view of a user authenticated by an upstream
+// identity provider. It is built from the id_token claims (OpenID Connect) or from the
+// assertion (SAML).
+type FederatedIdentity struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	GivenName     string
+	FamilyName    string
+	Groups        []string
+	// user attribute key -> value, already mapped according to the identity provider settings
+	Attributes map[string]string
+}
+
+// ParseUserAttributesMapping parses the user attributes mapping of an identity provider.
+// Each line has the format "claimOrAttributeName=userAttributeKey".
+func ParseUserAttributesMapping(mapping string) (map[string]string, error) {
+	result := map[string]string{}
+	for i, line := range strings.Split(mapping, "\n") {
+		line = strings.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+		idx := strings.LastIndex(line, "=")
+		if idx <= 0 || idx == len(line)-1 {
+			return nil, fmt.Errorf("line %v of the user attributes mapping is invalid, the expected format is name=key", i+1)
+		}
+		result[strings.TrimSpace(line[:idx])] = strings.TrimSpace(line[idx+1:])
+	}
+	return result, nil
+}
+
+// ParseGroupsMapping parses the groups mapping of an identity provider, and returns the identifiers of
+// the local groups for each group asserted by the identity provider. Each line has the format
+// "assertedGroup=groupIdentifier", and a group can be mapped to several local groups, in separate lines.
+func ParseGroupsMapping(mapping string) (map[string][]string, error) {
+	result := map[string][]string{}
+	for i, line := range strings.Split(mapping, "\n") {
+		line = strings.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+		idx := strings.LastIndex(line, "=")
+		if idx <= 0 || idx == len(line)-1 {
+			return nil, fmt.Errorf("line %v of the groups mapping is invalid, the expected format is group=groupIdentifier", i+1)
+		}
+		assertedGroup := strings.TrimSpace(line[:idx])
+		result[assertedGroup] = append(result[assertedGroup], strings.TrimSpace(line[idx+1:]))
+	}
+	return result, nil
+}
+
+func NewFederatedIdentityFromClaims(idp *entities.IdentityProvider, claims map[string]interface{}) *FederatedIdentity {
+
+	identity := &FederatedIdentity{
+		Subject:       getStringClaim(claims, "sub"),
+		Email:         getStringClaim(claims, idp.EmailClaim),
+		EmailVerified: getBoolClaim(claims, "email_verified"),
+		GivenName:     getStringClaim(claims, idp.GivenNameClaim),
+		FamilyName:    getStringClaim(claims, idp.FamilyNameClaim),
+		Groups:        getStringsClaim(claims, idp.GroupsClaim),
+		Attributes:    map[string]string{},
+	}
+
+	// the mapping is validated when the identity provider is saved
+	mapping, _ := ParseUserAttributesMapping(idp.UserAttributesMapping)
+	for claimName, key := range mapping {
+		values := getStringsClaim(claims, claimName)
+		if len(values) > 0 {
+			identity.Attributes[key] = strings.Join(values, " ")
+		}
+	}
+
+	return identity
+}
+
+func getStringClaim(claims map[string]interface{}, name string) string {
+	if len(name) == 0 {
+		return ""
+	}
+	if value, ok := claims[name].(string); ok {
+		return strings.TrimSpace(value)
+	}
+	return ""
+}
+
+func getStringsClaim(claims map[string]interface{}, name string) []string {
+	if len(name) == 0 {
+		return nil
+	}
+	switch value := claims[name].(type) {
+	case string:
+		return strings.Fields(value)
+	case []interface{}:
+		result := []string{}
+		for _, v := range value {
+			if s, ok := v.(string); ok && len(strings.TrimSpace(s)) > 0 {
+				result = append(result, strings.TrimSpace(s))
+			}
+		}
+		return result
+	case bool, float64:
+		return []string{fmt.Sprintf("%v", value)}
+	}
+	return nil
+}
+
+func getBoolClaim(claims map[string]interface{}, name string) bool {
+	switch value := claims[name].(type) {
+	case bool:
+		return value
+	case string:
+		return strings.EqualFold(value, "true")
+	}
+	return false
+}
+
+func truncate(s string, maxLength int) string {
+	runes := []rune(s)
+	if len(runes) > maxLength {
+		return string(runes[:maxLength])
+	}
+	return s
+}
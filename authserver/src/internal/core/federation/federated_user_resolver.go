@@ -0,0 +1,292 @@
+package core
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/leodip/goiabada/internal/constants"
+	"github.com/leodip/goiabada/internal/core"
+	core_webhooks "github.com/leodip/goiabada/internal/core/webhooks"
+	"github.com/leodip/goiabada/internal/customerrors"
+	"github.com/leodip/goiabada/internal/data"
+	"github.com/leodip/goiabada/internal/entities"
+	"github.com/leodip/goiabada/internal/lib"
+)
+
+type FederatedUserResolver struct {
+	database          data.Database
+	userCreator       *core.UserCreator
+	permissionChecker *core.PermissionChecker
+	webhookPublisher  *core_webhooks.Publisher
+}
+
+func NewFederatedUserResolver(database data.Database, userCreator *core.UserCreator,
+	webhookPublisher *core_webhooks.Publisher) *FederatedUserResolver {
+	return &FederatedUserResolver{
+		database:          database,
+		userCreator:       userCreator,
+		permissionChecker: core.NewPermissionChecker(database),
+		webhookPublisher:  webhookPublisher,
+	}
+}
+
+type ResolveUserResult struct {
+	User        *entities.User
+	Subject     string
+	Linked      bool
+	Provisioned bool
+}
+
+// ResolveUser finds the local user for an identity asserted by an upstream identity provider.
+// An existing link (by subject) is used first. Otherwise, depending on the identity provider settings,
+// the user is linked by verified email or provisioned just-in-time. The profile (given and family name),
+// the mapped user attributes and the mapped group memberships are synchronized on every login. Disabled users
+// are returned without being linked or synchronized, for the caller to refuse them.
+func (r *FederatedUserResolver) ResolveUser(ctx context.Context, idp *entities.IdentityProvider,
+	identity *FederatedIdentity) (*ResolveUserResult, error) {
+
+	if len(identity.Subject) == 0 {
+		return nil, customerrors.NewValidationError("", "The identity provider did not return a subject identifier.")
+	}
+
+	result := &ResolveUserResult{
+		Subject: identity.Subject,
+	}
+
+	federatedIdentity, err := r.database.GetUserFederatedIdentityByIdentityProviderIdAndSubject(nil, idp.Id, identity.Subject)
+	if err != nil {
+		return nil, err
+	}
+
+	if federatedIdentity != nil {
+		user, err := r.database.GetUserById(nil, federatedIdentity.UserId)
+		if err != nil {
+			return nil, err
+		}
+		if user != nil {
+			result.User = user
+			if !user.Enabled {
+				return result, nil
+			}
+			federatedIdentity.LastLoginAt = sql.NullTime{Time: time.Now().UTC(), Valid: true}
+			err = r.database.UpdateUserFederatedIdentity(nil, federatedIdentity)
+			if err != nil {
+				return nil, err
+			}
+			err = r.syncUser(ctx, idp, user, identity)
+			if err != nil {
+				return nil, err
+			}
+			return result, nil
+		}
+	}
+
+	email := strings.ToLower(strings.TrimSpace(identity.Email))
+
+	var user *entities.User
+	if len(email) > 0 {
+		user, err = r.database.GetUserByEmail(nil, email)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if user != nil {
+		if !idp.LinkByEmail || !identity.EmailVerified {
+			return nil, customerrors.NewValidationError("",
+				"An account with this email address already exists, but it's not linked to this identity provider.")
+		}
+		if !user.Enabled {
+			result.User = user
+			return result, nil
+		}
+		result.Linked = true
+	} else {
+		if !idp.AutoProvision {
+			return nil, customerrors.NewValidationError("",
+				"There is no account linked to this identity provider.")
+		}
+		if len(email) == 0 {
+			return nil, customerrors.NewValidationError("",
+				"The identity provider did not return an email address, which is required to create an account.")
+		}
+
+		user, err = r.userCreator.CreateUser(ctx, &core.CreateUserInput{
+			Email:         email,
+			EmailVerified: identity.EmailVerified,
+			GivenName:     truncate(identity.GivenName, 64),
+			FamilyName:    truncate(identity.FamilyName, 64),
+		})
+		if err != nil {
+			return nil, err
+		}
+		result.Provisioned = true
+		r.publishUserEvent(constants.WebhookEventUserCreated, user, nil)
+	}
+
+	err = r.database.CreateUserFederatedIdentity(nil, &entities.UserFederatedIdentity{
+		UserId:             user.Id,
+		IdentityProviderId: idp.Id,
+		Subject:            identity.Subject,
+		LastLoginAt:        sql.NullTime{Time: time.Now().UTC(), Valid: true},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = r.syncUser(ctx, idp, user, identity)
+	if err != nil {
+		return nil, err
+	}
+
+	result.User = user
+	return result, nil
+}
+
+// syncUser updates the given and family name of the user, stores the mapped attributes as user attributes
+// and adds the user to the local groups mapped to the groups asserted by the identity provider. Only the
+// groups in the mapping of the identity provider are considered, and never the groups granting authserver
+// permissions, so whoever controls the assertion can't make a user an admin. Names are only updated when
+// asserted, and memberships are never removed, as they may have been granted locally.
+func (r *FederatedUserResolver) syncUser(ctx context.Context, idp *entities.IdentityProvider, user *entities.User,
+	identity *FederatedIdentity) error {
+
+	givenName := truncate(identity.GivenName, 64)
+	familyName := truncate(identity.FamilyName, 64)
+	if (len(givenName) > 0 && givenName != user.GivenName) || (len(familyName) > 0 && familyName != user.FamilyName) {
+		if len(givenName) > 0 {
+			user.GivenName = givenName
+		}
+		if len(familyName) > 0 {
+			user.FamilyName = familyName
+		}
+		err := r.database.UpdateUser(nil, user)
+		if err != nil {
+			return err
+		}
+		lib.LogAudit(ctx, constants.AuditUpdatedUserProfile, map[string]interface{}{
+			"userId":           user.Id,
+			"identityProvider": idp.IdentityProviderIdentifier,
+		})
+		r.publishUserEvent(constants.WebhookEventUserUpdated, user, nil)
+	}
+
+	if len(identity.Attributes) > 0 {
+		userAttributes, err := r.database.GetUserAttributesByUserId(nil, user.Id)
+		if err != nil {
+			return err
+		}
+
+		for key, value := range identity.Attributes {
+			value = truncate(value, 256)
+
+			var existing *entities.UserAttribute
+			for i := range userAttributes {
+				if userAttributes[i].Key == key {
+					existing = &userAttributes[i]
+					break
+				}
+			}
+
+			if existing == nil {
+				userAttribute := &entities.UserAttribute{
+					Key:                  key,
+					Value:                value,
+					IncludeInIdToken:     true,
+					IncludeInAccessToken: true,
+					UserId:               user.Id,
+				}
+				err = r.database.CreateUserAttribute(nil, userAttribute)
+				if err != nil {
+					return err
+				}
+				lib.LogAudit(ctx, constants.AuditAddedUserAttribute, map[string]interface{}{
+					"userId":           user.Id,
+					"userAttributeId":  userAttribute.Id,
+					"identityProvider": idp.IdentityProviderIdentifier,
+				})
+			} else if existing.Value != value {
+				existing.Value = value
+				err = r.database.UpdateUserAttribute(nil, existing)
+				if err != nil {
+					return err
+				}
+				lib.LogAudit(ctx, constants.AuditUpdatedUserAttribute, map[string]interface{}{
+					"userId":           user.Id,
+					"userAttributeId":  existing.Id,
+					"identityProvider": idp.IdentityProviderIdentifier,
+				})
+			}
+		}
+	}
+
+	// the mapping is validated when the identity provider is saved
+	groupsMapping, _ := ParseGroupsMapping(idp.GroupsMapping)
+	groupIdentifiers := []string{}
+	for _, assertedGroup := range identity.Groups {
+		for _, groupIdentifier := range groupsMapping[assertedGroup] {
+			if !slices.Contains(groupIdentifiers, groupIdentifier) {
+				groupIdentifiers = append(groupIdentifiers, groupIdentifier)
+			}
+		}
+	}
+
+	for _, groupIdentifier := range groupIdentifiers {
+		group, err := r.database.GetGroupByGroupIdentifier(nil, groupIdentifier)
+		if err != nil {
+			return err
+		}
+		if group == nil {
+			continue
+		}
+
+		// the permissions of the group may have changed after the mapping was saved
+		privileged, err := r.permissionChecker.GroupGrantsAuthServerPermissions(group)
+		if err != nil {
+			return err
+		}
+		if privileged {
+			slog.Warn(fmt.Sprintf("the group %v is mapped in the identity provider %v, but it grants authserver permissions, so it was not synchronized",
+				group.GroupIdentifier, idp.IdentityProviderIdentifier))
+			continue
+		}
+
+		userGroup, err := r.database.GetUserGroupByUserIdAndGroupId(nil, user.Id, group.Id)
+		if err != nil {
+			return err
+		}
+		if userGroup != nil {
+			continue
+		}
+
+		err = r.database.CreateUserGroup(nil, &entities.UserGroup{
+			UserId:  user.Id,
+			GroupId: group.Id,
+		})
+		if err != nil {
+			return err
+		}
+		lib.LogAudit(ctx, constants.AuditUserAddedToGroup, map[string]interface{}{
+			"userId":           user.Id,
+			"groupId":          group.Id,
+			"identityProvider": idp.IdentityProviderIdentifier,
+		})
+		r.publishUserEvent(constants.WebhookEventUserAddedToGroup, user, group)
+	}
+
+	return nil
+}
+
+// publishUserEvent publishes the event to the webhooks. Failures are logged and don't interrupt the
+// authentication.
+func (r *FederatedUserResolver) publishUserEvent(eventType string, user *entities.User, group *entities.Group) {
+	err := r.webhookPublisher.PublishUserEvent(nil, eventType, user, group)
+	if err != nil {
+		slog.Error(fmt.Sprintf("unable to publish the webhook event %v of user %v: %+v", eventType, user.Subject, err))
+	}
+}
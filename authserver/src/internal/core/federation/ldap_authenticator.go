@@ -0,0 +1,214 @@
+package core
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/go-ldap/ldap/v3"
+	"github.com/leodip/goiabada/internal/common"
+	"github.com/leodip/goiabada/internal/entities"
+	"github.com/leodip/goiabada/internal/lib"
+	"github.com/pkg/errors"
+)
+
+var ErrLDAPUserNotFound = errors.New("user not found in the LDAP directory")
+var ErrLDAPInvalidCredentials = errors.New("invalid credentials")
+
+// LDAPAuthenticator authenticates users against an LDAP directory (or Active Directory).
+// The user entry is located with a search (using the service account, or an anonymous bind when
+// no bind DN is configured), and the password is verified by binding as that entry.
+type LDAPAuthenticator struct {
+	timeout time.Duration
+}
+
+func NewLDAPAuthenticator() *LDAPAuthenticator {
+	return &LDAPAuthenticator{
+		timeout: 10 * time.Second,
+	}
+}
+
+func (a *LDAPAuthenticator) dial(idp *entities.IdentityProvider) (*ldap.Conn, error) {
+
+	ldapURL, err := url.Parse(idp.LDAPURL)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to parse the LDAP URL")
+	}
+
+	conn, err := ldap.DialURL(idp.LDAPURL, ldap.DialWithDialer(&net.Dialer{Timeout: a.timeout}))
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to connect to "+idp.LDAPURL)
+	}
+	conn.SetTimeout(a.timeout)
+
+	if idp.LDAPStartTLS && ldapURL.Scheme == "ldap" {
+		err = conn.StartTLS(&tls.Config{ServerName: ldapURL.Hostname()})
+		if err != nil {
+			conn.Close()
+			return nil, errors.Wrap(err, "unable to start TLS with "+idp.LDAPURL)
+		}
+	}
+
+	return conn, nil
+}
+
+func (a *LDAPAuthenticator) bindServiceAccount(ctx context.Context, conn *ldap.Conn, idp *entities.IdentityProvider) error {
+
+	if len(idp.LDAPBindDN) == 0 {
+		return nil
+	}
+
+	bindPassword := ""
+	if len(idp.LDAPBindPasswordEncrypted) > 0 {
+		settings := ctx.Value(common.ContextKeySettings).(*entities.Settings)
+		var err error
+		bindPassword, err = lib.DecryptText(idp.LDAPBindPasswordEncrypted, settings.AESEncryptionKey)
+		if err != nil {
+			return errors.Wrap(err, "unable to decrypt the bind password")
+		}
+	}
+
+	err := conn.Bind(idp.LDAPBindDN, bindPassword)
+	if err != nil {
+		return errors.Wrap(err, "unable to bind with "+idp.LDAPBindDN)
+	}
+	return nil
+}
+
+// getLDAPSearchAttributes returns the attributes to be read from the user entry.
+func getLDAPSearchAttributes(idp *entities.IdentityProvider) []string {
+	attributes := []string{}
+	for _, name := range []string{idp.LDAPSubjectAttribute, idp.EmailClaim, idp.GivenNameClaim,
+		idp.FamilyNameClaim, idp.GroupsClaim} {
+		if len(name) > 0 {
+			attributes = append(attributes, name)
+		}
+	}
+
+	// the mapping is validated when the identity provider is saved
+	mapping, _ := ParseUserAttributesMapping(idp.UserAttributesMapping)
+	for name := range mapping {
+		attributes = append(attributes, name)
+	}
+	return attributes
+}
+
+// Authenticate verifies the email and password of a user against the directory, and returns the
+// identity of the user. ErrLDAPUserNotFound is returned when the directory has no entry for the email,
+// and ErrLDAPInvalidCredentials when the entry exists but the password is wrong.
+func (a *LDAPAuthenticator) Authenticate(ctx context.Context, idp *entities.IdentityProvider,
+	email string, password string) (*FederatedIdentity, error) {
+
+	// an empty password would result in an unauthenticated bind, which many servers accept
+	if len(password) == 0 {
+		return nil, ErrLDAPInvalidCredentials
+	}
+
+	conn, err := a.dial(idp)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	err = a.bindServiceAccount(ctx, conn, idp)
+	if err != nil {
+		return nil, err
+	}
+
+	filter := strings.ReplaceAll(idp.LDAPUserFilter, "{email}", ldap.EscapeFilter(email))
+	searchRequest := ldap.NewSearchRequest(idp.LDAPBaseDN, ldap.ScopeWholeSubtree, ldap.NeverDerefAliases,
+		2, int(a.timeout.Seconds()), false, filter, getLDAPSearchAttributes(idp), nil)
+
+	searchResult, err := conn.Search(searchRequest)
+	if err != nil {
+		if ldap.IsErrorWithCode(err, ldap.LDAPResultNoSuchObject) {
+			return nil, ErrLDAPUserNotFound
+		}
+		if ldap.IsErrorWithCode(err, ldap.LDAPResultSizeLimitExceeded) {
+			return nil, errors.WithStack(fmt.Errorf("more than one entry in the LDAP directory matches %v", email))
+		}
+		return nil, errors.Wrap(err, "unable to search the LDAP directory")
+	}
+
+	if len(searchResult.Entries) == 0 {
+		return nil, ErrLDAPUserNotFound
+	}
+	if len(searchResult.Entries) > 1 {
+		return nil, errors.WithStack(fmt.Errorf("more than one entry in the LDAP directory matches %v", email))
+	}
+	entry := searchResult.Entries[0]
+
+	err = conn.Bind(entry.DN, password)
+	if err != nil {
+		if ldap.IsErrorWithCode(err, ldap.LDAPResultInvalidCredentials) {
+			return nil, ErrLDAPInvalidCredentials
+		}
+		return nil, errors.Wrap(err, "unable to bind with "+entry.DN)
+	}
+
+	identity := NewFederatedIdentityFromLDAPEntry(idp, entry)
+	if len(identity.Email) == 0 {
+		identity.Email = email
+	}
+	return identity, nil
+}
+
+// NewFederatedIdentityFromLDAPEntry maps the attributes of a directory entry. The subject is the value of
+// the subject attribute (binary values, like the objectGUID of Active Directory, are hex encoded), or the
+// DN of the entry when no subject attribute is configured. Groups given as DNs (like memberOf) are mapped
+// to the value of their first RDN, for example "cn=finance,ou=groups,dc=example,dc=org" becomes "finance".
+// The email is only considered verified when the administrator trusts the emails of the directory.
+func NewFederatedIdentityFromLDAPEntry(idp *entities.IdentityProvider, entry *ldap.Entry) *FederatedIdentity {
+
+	getFirst := func(name string) string {
+		if len(name) == 0 {
+			return ""
+		}
+		return strings.TrimSpace(entry.GetAttributeValue(name))
+	}
+
+	identity := &FederatedIdentity{
+		Subject:       entry.DN,
+		Email:         getFirst(idp.EmailClaim),
+		EmailVerified: idp.TrustEmail,
+		GivenName:     getFirst(idp.GivenNameClaim),
+		FamilyName:    getFirst(idp.FamilyNameClaim),
+		Attributes:    map[string]string{},
+	}
+
+	if len(idp.LDAPSubjectAttribute) > 0 {
+		subject := entry.GetRawAttributeValue(idp.LDAPSubjectAttribute)
+		if utf8.Valid(subject) {
+			identity.Subject = strings.TrimSpace(string(subject))
+		} else {
+			identity.Subject = hex.EncodeToString(subject)
+		}
+	}
+
+	if len(idp.GroupsClaim) > 0 {
+		for _, value := range entry.GetAttributeValues(idp.GroupsClaim) {
+			if dn, err := ldap.ParseDN(value); err == nil && len(dn.RDNs) > 0 && len(dn.RDNs[0].Attributes) > 0 {
+				value = dn.RDNs[0].Attributes[0].Value
+			}
+			if value = strings.TrimSpace(value); len(value) > 0 {
+				identity.Groups = append(identity.Groups, value)
+			}
+		}
+	}
+
+	// the mapping is validated when the identity provider is saved
+	mapping, _ := ParseUserAttributesMapping(idp.UserAttributesMapping)
+	for attributeName, key := range mapping {
+		if values := entry.GetAttributeValues(attributeName); len(values) > 0 {
+			identity.Attributes[key] = strings.Join(values, " ")
+		}
+	}
+
+	return identity
+}
@@ -0,0 +1,270 @@
+package core
+
+import (
+	"context"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/leodip/goiabada/internal/common"
+	"github.com/leodip/goiabada/internal/entities"
+	"github.com/leodip/goiabada/internal/lib"
+	"github.com/pkg/errors"
+)
+
+type OIDCClient struct {
+	httpClient *http.Client
+}
+
+func NewOIDCClient() *OIDCClient {
+	return &OIDCClient{
+		httpClient: &http.Client{
+			Timeout: 15 * time.Second,
+		},
+	}
+}
+
+type OIDCDiscoveryDocument struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	UserinfoEndpoint      string `json:"userinfo_endpoint"`
+	JwksURI               string `json:"jwks_uri"`
+}
+
+type BuildAuthorizationURLInput struct {
+	IdentityProvider *entities.IdentityProvider
+	RedirectURI      string
+	State            string
+	Nonce            string
+	CodeChallenge    string
+}
+
+type ExchangeCodeInput struct {
+	IdentityProvider *entities.IdentityProvider
+	Code             string
+	RedirectURI      string
+	CodeVerifier     string
+	Nonce            string
+}
+
+type jsonWebKey struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+type jsonWebKeySet struct {
+	Keys []jsonWebKey `json:"keys"`
+}
+
+func (c *OIDCClient) getJson(ctx context.Context, endpoint string, v interface{}) error {
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return errors.Wrap(err, "unable to create request")
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "unable to send request to "+endpoint)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return errors.WithStack(fmt.Errorf("unexpected status code %v from %v", resp.StatusCode, endpoint))
+	}
+
+	err = json.NewDecoder(resp.Body).Decode(v)
+	if err != nil {
+		return errors.Wrap(err, "unable to decode response from "+endpoint)
+	}
+	return nil
+}
+
+func (c *OIDCClient) GetDiscoveryDocument(ctx context.Context, issuer string) (*OIDCDiscoveryDocument, error) {
+
+	discoveryURL := strings.TrimSuffix(issuer, "/") + "/.well-known/openid-configuration"
+
+	var discoveryDocument OIDCDiscoveryDocument
+	err := c.getJson(ctx, discoveryURL, &discoveryDocument)
+	if err != nil {
+		return nil, err
+	}
+
+	if strings.TrimSuffix(discoveryDocument.Issuer, "/") != strings.TrimSuffix(issuer, "/") {
+		return nil, errors.WithStack(fmt.Errorf("the issuer in the discovery document (%v) does not match the configured issuer (%v)",
+			discoveryDocument.Issuer, issuer))
+	}
+
+	if len(discoveryDocument.AuthorizationEndpoint) == 0 || len(discoveryDocument.TokenEndpoint) == 0 ||
+		len(discoveryDocument.JwksURI) == 0 {
+		return nil, errors.WithStack(errors.New("the discovery document is missing required endpoints"))
+	}
+
+	return &discoveryDocument, nil
+}
+
+func (c *OIDCClient) BuildAuthorizationURL(ctx context.Context, input *BuildAuthorizationURLInput) (string, error) {
+
+	discoveryDocument, err := c.GetDiscoveryDocument(ctx, input.IdentityProvider.Issuer)
+	if err != nil {
+		return "", err
+	}
+
+	authorizationURL, err := url.Parse(discoveryDocument.AuthorizationEndpoint)
+	if err != nil {
+		return "", errors.Wrap(err, "unable to parse the authorization endpoint")
+	}
+
+	values := authorizationURL.Query()
+	values.Set("client_id", input.IdentityProvider.ClientIdentifier)
+	values.Set("redirect_uri", input.RedirectURI)
+	values.Set("response_type", "code")
+	values.Set("response_mode", "query")
+	values.Set("scope", input.IdentityProvider.Scopes)
+	values.Set("state", input.State)
+	values.Set("nonce", input.Nonce)
+	values.Set("code_challenge", input.CodeChallenge)
+	values.Set("code_challenge_method", "S256")
+	authorizationURL.RawQuery = values.Encode()
+
+	return authorizationURL.String(), nil
+}
+
+// ExchangeCode redeems the authorization code at the upstream token endpoint, validates the
+// returned id_token and returns its claims.
+func (c *OIDCClient) ExchangeCode(ctx context.Context, input *ExchangeCodeInput) (jwt.MapClaims, error) {
+
+	idp := input.IdentityProvider
+
+	discoveryDocument, err := c.GetDiscoveryDocument(ctx, idp.Issuer)
+	if err != nil {
+		return nil, err
+	}
+
+	clientSecret := ""
+	if len(idp.ClientSecretEncrypted) > 0 {
+		settings := ctx.Value(common.ContextKeySettings).(*entities.Settings)
+		clientSecret, err = lib.DecryptText(idp.ClientSecretEncrypted, settings.AESEncryptionKey)
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to decrypt the client secret")
+		}
+	}
+
+	formData := url.Values{
+		"grant_type":    {"authorization_code"},
+		"client_id":     {idp.ClientIdentifier},
+		"code":          {input.Code},
+		"redirect_uri":  {input.RedirectURI},
+		"code_verifier": {input.CodeVerifier},
+	}
+	if len(clientSecret) > 0 {
+		formData.Set("client_secret", clientSecret)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, discoveryDocument.TokenEndpoint, strings.NewReader(formData.Encode()))
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to create token request")
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to send token request")
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to read token response")
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.WithStack(fmt.Errorf("the token endpoint returned status code %v: %v", resp.StatusCode, string(body)))
+	}
+
+	var tokenResponse struct {
+		IdToken string `json:"id_token"`
+	}
+	err = json.Unmarshal(body, &tokenResponse)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to unmarshal token response")
+	}
+
+	if len(tokenResponse.IdToken) == 0 {
+		return nil, errors.WithStack(errors.New("the token response does not contain an id_token"))
+	}
+
+	return c.validateIdToken(ctx, discoveryDocument, idp, tokenResponse.IdToken, input.Nonce)
+}
+
+func (c *OIDCClient) validateIdToken(ctx context.Context, discoveryDocument *OIDCDiscoveryDocument,
+	idp *entities.IdentityProvider, idToken string, nonce string) (jwt.MapClaims, error) {
+
+	var keySet jsonWebKeySet
+	err := c.getJson(ctx, discoveryDocument.JwksURI, &keySet)
+	if err != nil {
+		return nil, err
+	}
+
+	claims := jwt.MapClaims{}
+	_, err = jwt.ParseWithClaims(idToken, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		for _, key := range keySet.Keys {
+			if key.Kty != "RSA" || (len(kid) > 0 && key.Kid != kid) {
+				continue
+			}
+			return parseRSAPublicKey(key)
+		}
+		return nil, fmt.Errorf("unable to find a signing key for kid %v", kid)
+	},
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512"}),
+		jwt.WithIssuer(discoveryDocument.Issuer),
+		jwt.WithAudience(idp.ClientIdentifier),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to validate the id_token")
+	}
+
+	if claims["nonce"] != nonce {
+		return nil, errors.WithStack(errors.New("the nonce in the id_token does not match"))
+	}
+
+	sub, _ := claims["sub"].(string)
+	if len(sub) == 0 {
+		return nil, errors.WithStack(errors.New("the id_token does not contain a sub claim"))
+	}
+
+	return claims, nil
+}
+
+func parseRSAPublicKey(key jsonWebKey) (*rsa.PublicKey, error) {
+	nBytes, err := base64.RawURLEncoding.DecodeString(key.N)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to decode the modulus of the key")
+	}
+	eBytes, err := base64.RawURLEncoding.DecodeString(key.E)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to decode the exponent of the key")
+	}
+
+	return &rsa.PublicKey{
+		N: new(big.Int).SetBytes(nBytes),
+		E: int(new(big.Int).SetBytes(eBytes).Int64()),
+	}, nil
+}
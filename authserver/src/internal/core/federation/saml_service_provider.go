@@ -0,0 +1,255 @@
+package core
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/crewjam/saml"
+	"github.com/crewjam/saml/samlsp"
+	"github.com/leodip/goiabada/internal/entities"
+	"github.com/leodip/goiabada/internal/lib"
+	"github.com/pkg/errors"
+)
+
+// SAMLServiceProvider implements the service provider side of the SAML 2.0 web browser SSO profile,
+// with goiabada acting as the service provider of an upstream SAML identity provider.
+// Authentication requests use the HTTP-Redirect binding and responses are received with the HTTP-POST binding.
+type SAMLServiceProvider struct {
+	httpClient *http.Client
+}
+
+func NewSAMLServiceProvider() *SAMLServiceProvider {
+	return &SAMLServiceProvider{
+		httpClient: &http.Client{
+			Timeout: 15 * time.Second,
+		},
+	}
+}
+
+// GetSAMLMetadataURL returns the URL of the service provider metadata, which is also the entity ID
+// of goiabada for that identity provider.
+func GetSAMLMetadataURL(identityProviderIdentifier string) string {
+	return fmt.Sprintf("%v/auth/federated/%v/saml/metadata", lib.GetBaseUrl(), identityProviderIdentifier)
+}
+
+// GetSAMLAcsURL returns the URL of the assertion consumer service.
+func GetSAMLAcsURL(identityProviderIdentifier string) string {
+	return fmt.Sprintf("%v/auth/federated/%v/saml/acs", lib.GetBaseUrl(), identityProviderIdentifier)
+}
+
+func (p *SAMLServiceProvider) FetchMetadata(ctx context.Context, metadataURL string) ([]byte, error) {
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, metadataURL, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to create request")
+	}
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to send request to "+metadataURL)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.WithStack(fmt.Errorf("unexpected status code %v from %v", resp.StatusCode, metadataURL))
+	}
+
+	metadataXML, err := io.ReadAll(io.LimitReader(resp.Body, 1024*1024))
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to read response from "+metadataURL)
+	}
+	return metadataXML, nil
+}
+
+// ParseMetadata parses the metadata of an identity provider and checks that it can be used
+// by goiabada (it must have a signing certificate and support the HTTP-Redirect binding).
+func (p *SAMLServiceProvider) ParseMetadata(metadataXML []byte) (*saml.EntityDescriptor, error) {
+
+	entityDescriptor, err := samlsp.ParseMetadata(metadataXML)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to parse the metadata")
+	}
+
+	if len(entityDescriptor.EntityID) == 0 {
+		return nil, errors.WithStack(errors.New("the metadata does not have an entity ID"))
+	}
+
+	if len(entityDescriptor.IDPSSODescriptors) == 0 {
+		return nil, errors.WithStack(errors.New("the metadata does not describe an identity provider"))
+	}
+
+	sp := saml.ServiceProvider{IDPMetadata: entityDescriptor}
+	if len(sp.GetSSOBindingLocation(saml.HTTPRedirectBinding)) == 0 {
+		return nil, errors.WithStack(errors.New("the identity provider does not support the HTTP-Redirect binding"))
+	}
+
+	hasSigningKey := false
+	for _, idpSSODescriptor := range entityDescriptor.IDPSSODescriptors {
+		for _, keyDescriptor := range idpSSODescriptor.KeyDescriptors {
+			if (keyDescriptor.Use == "" || keyDescriptor.Use == "signing") &&
+				len(keyDescriptor.KeyInfo.X509Data.X509Certificates) > 0 {
+				hasSigningKey = true
+			}
+		}
+	}
+	if !hasSigningKey {
+		return nil, errors.WithStack(errors.New("the metadata does not contain a signing certificate"))
+	}
+
+	return entityDescriptor, nil
+}
+
+func (p *SAMLServiceProvider) newServiceProvider(idp *entities.IdentityProvider) (*saml.ServiceProvider, error) {
+
+	idpMetadata, err := p.ParseMetadata(idp.SAMLMetadataXML)
+	if err != nil {
+		return nil, err
+	}
+
+	metadataURL, err := url.Parse(GetSAMLMetadataURL(idp.IdentityProviderIdentifier))
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to parse the metadata URL")
+	}
+
+	acsURL, err := url.Parse(GetSAMLAcsURL(idp.IdentityProviderIdentifier))
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to parse the assertion consumer service URL")
+	}
+
+	nameIdFormat := saml.NameIDFormat(idp.SAMLNameIdFormat)
+	if len(nameIdFormat) == 0 {
+		nameIdFormat = saml.UnspecifiedNameIDFormat
+	}
+
+	return &saml.ServiceProvider{
+		EntityID:          metadataURL.String(),
+		MetadataURL:       *metadataURL,
+		AcsURL:            *acsURL,
+		IDPMetadata:       idpMetadata,
+		AuthnNameIDFormat: nameIdFormat,
+		HTTPClient:        p.httpClient,
+	}, nil
+}
+
+// GetServiceProviderMetadata returns the metadata of goiabada as a service provider of the identity provider.
+func (p *SAMLServiceProvider) GetServiceProviderMetadata(idp *entities.IdentityProvider) ([]byte, error) {
+
+	sp, err := p.newServiceProvider(idp)
+	if err != nil {
+		return nil, err
+	}
+
+	metadataXML, err := xml.MarshalIndent(sp.Metadata(), "", "  ")
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to marshal the service provider metadata")
+	}
+	return metadataXML, nil
+}
+
+// BuildAuthnRequestURL returns the URL that starts the authentication at the identity provider,
+// and the ID of the authentication request, to be matched against the InResponseTo of the response.
+func (p *SAMLServiceProvider) BuildAuthnRequestURL(idp *entities.IdentityProvider, relayState string) (string, string, error) {
+
+	sp, err := p.newServiceProvider(idp)
+	if err != nil {
+		return "", "", err
+	}
+
+	authnRequest, err := sp.MakeAuthenticationRequest(sp.GetSSOBindingLocation(saml.HTTPRedirectBinding),
+		saml.HTTPRedirectBinding, saml.HTTPPostBinding)
+	if err != nil {
+		return "", "", errors.Wrap(err, "unable to create the authentication request")
+	}
+
+	redirectURL, err := authnRequest.Redirect(url.QueryEscape(relayState), sp)
+	if err != nil {
+		return "", "", errors.Wrap(err, "unable to create the redirect URL")
+	}
+
+	return redirectURL.String(), authnRequest.ID, nil
+}
+
+// ParseResponse validates the SAML response posted to the assertion consumer service (signature,
+// issuer, audience, recipient, validity window and InResponseTo) and maps the assertion to a federated identity.
+func (p *SAMLServiceProvider) ParseResponse(r *http.Request, idp *entities.IdentityProvider, requestId string) (*FederatedIdentity, error) {
+
+	sp, err := p.newServiceProvider(idp)
+	if err != nil {
+		return nil, err
+	}
+
+	assertion, err := sp.ParseResponse(r, []string{requestId})
+	if err != nil {
+		if invalidResponseError, ok := err.(*saml.InvalidResponseError); ok && invalidResponseError.PrivateErr != nil {
+			return nil, errors.Wrap(invalidResponseError.PrivateErr, "invalid SAML response")
+		}
+		return nil, errors.Wrap(err, "invalid SAML response")
+	}
+
+	return NewFederatedIdentityFromAssertion(idp, assertion), nil
+}
+
+// NewFederatedIdentityFromAssertion maps the NameID and the attributes of a validated assertion.
+// Attributes are matched by name or by friendly name. SAML has no standard attribute for the email
+// verification, so the email is only considered verified when the administrator trusts the emails
+// asserted by the identity provider.
+func NewFederatedIdentityFromAssertion(idp *entities.IdentityProvider, assertion *saml.Assertion) *FederatedIdentity {
+
+	attributes := map[string][]string{}
+	for _, attributeStatement := range assertion.AttributeStatements {
+		for _, attribute := range attributeStatement.Attributes {
+			values := []string{}
+			for _, value := range attribute.Values {
+				if v := strings.TrimSpace(value.Value); len(v) > 0 {
+					values = append(values, v)
+				}
+			}
+			attributes[attribute.Name] = append(attributes[attribute.Name], values...)
+			if len(attribute.FriendlyName) > 0 && attribute.FriendlyName != attribute.Name {
+				attributes[attribute.FriendlyName] = append(attributes[attribute.FriendlyName], values...)
+			}
+		}
+	}
+
+	getFirst := func(name string) string {
+		if values := attributes[name]; len(name) > 0 && len(values) > 0 {
+			return values[0]
+		}
+		return ""
+	}
+
+	identity := &FederatedIdentity{
+		Email:         getFirst(idp.EmailClaim),
+		EmailVerified: idp.TrustEmail,
+		GivenName:     getFirst(idp.GivenNameClaim),
+		FamilyName:    getFirst(idp.FamilyNameClaim),
+		Attributes:    map[string]string{},
+	}
+
+	if assertion.Subject != nil && assertion.Subject.NameID != nil {
+		identity.Subject = strings.TrimSpace(assertion.Subject.NameID.Value)
+		if len(identity.Email) == 0 && assertion.Subject.NameID.Format == string(saml.EmailAddressNameIDFormat) {
+			identity.Email = identity.Subject
+		}
+	}
+
+	if len(idp.GroupsClaim) > 0 {
+		identity.Groups = attributes[idp.GroupsClaim]
+	}
+
+	// the mapping is validated when the identity provider is saved
+	mapping, _ := ParseUserAttributesMapping(idp.UserAttributesMapping)
+	for attributeName, key := range mapping {
+		if values := attributes[attributeName]; len(values) > 0 {
+			identity.Attributes[key] = strings.Join(values, " ")
+		}
+	}
+
+	return identity
+}
@@ -0,0 +1,137 @@
+package core
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/leodip/goiabada/internal/data"
+	"github.com/leodip/goiabada/internal/entities"
+	"github.com/leodip/goiabada/internal/enums"
+	"github.com/leodip/goiabada/internal/lib"
+	"github.com/pkg/errors"
+)
+
+type KeyRotator struct {
+	database data.Database
+}
+
+func NewKeyRotator(database data.Database) *KeyRotator {
+	return &KeyRotator{
+		database: database,
+	}
+}
+
+// RotateKeys makes the next signing key the current one. The current key becomes the previous key,
+// so tokens signed with it can still be verified, the previous key is deleted and a new next key is created.
+func (kr *KeyRotator) RotateKeys() error {
+
+	allSigningKeys, err := kr.database.GetAllSigningKeys(nil)
+	if err != nil {
+		return err
+	}
+
+	var currentKey *entities.KeyPair
+	var nextKey *entities.KeyPair
+	var previousKey *entities.KeyPair
+	for i, signingKey := range allSigningKeys {
+		keyState, err := enums.KeyStateFromString(signingKey.State)
+		if err != nil {
+			return err
+		}
+		switch keyState {
+		case enums.KeyStateCurrent:
+			currentKey = &allSigningKeys[i]
+		case enums.KeyStateNext:
+			nextKey = &allSigningKeys[i]
+		case enums.KeyStatePrevious:
+			previousKey = &allSigningKeys[i]
+		}
+	}
+
+	if currentKey == nil {
+		return errors.WithStack(fmt.Errorf("no current key found"))
+	}
+
+	if nextKey == nil {
+		return errors.WithStack(fmt.Errorf("no next key found"))
+	}
+
+	// the new key is generated first, so nothing changes if it fails
+	keyPair, err := kr.generateKeyPair()
+	if err != nil {
+		return err
+	}
+
+	tx, err := kr.database.BeginTransaction()
+	if err != nil {
+		return err
+	}
+	defer kr.database.RollbackTransaction(tx)
+
+	if previousKey != nil {
+		err = kr.database.DeleteKeyPair(tx, previousKey.Id)
+		if err != nil {
+			return err
+		}
+	}
+
+	// current key becomes previous
+	currentKey.State = enums.KeyStatePrevious.String()
+	err = kr.database.UpdateKeyPair(tx, currentKey)
+	if err != nil {
+		return err
+	}
+
+	// next key becomes current
+	nextKey.State = enums.KeyStateCurrent.String()
+	err = kr.database.UpdateKeyPair(tx, nextKey)
+	if err != nil {
+		return err
+	}
+
+	err = kr.database.CreateKeyPair(tx, keyPair)
+	if err != nil {
+		return err
+	}
+
+	return kr.database.CommitTransaction(tx)
+}
+
+func (kr *KeyRotator) generateKeyPair() (*entities.KeyPair, error) {
+	privateKey, err := lib.GeneratePrivateKey(4096)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to generate a private key")
+	}
+	privateKeyPEM := lib.EncodePrivateKeyToPEM(privateKey)
+
+	publicKeyASN1_DER, err := x509.MarshalPKIXPublicKey(&privateKey.PublicKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to marshal public key to PKIX")
+	}
+
+	publicKeyPEM := pem.EncodeToMemory(
+		&pem.Block{
+			Type:  "RSA PUBLIC KEY",
+			Bytes: publicKeyASN1_DER,
+		},
+	)
+
+	kid := uuid.New().String()
+	publicKeyJWK, err := lib.MarshalRSAPublicKeyToJWK(&privateKey.PublicKey, kid)
+	if err != nil {
+		return nil, err
+	}
+
+	return &entities.KeyPair{
+		State:             enums.KeyStateNext.String(),
+		KeyIdentifier:     kid,
+		Type:              "RSA",
+		Algorithm:         "RS256",
+		PrivateKeyPEM:     privateKeyPEM,
+		PublicKeyPEM:      publicKeyPEM,
+		PublicKeyASN1_DER: publicKeyASN1_DER,
+		PublicKeyJWK:      publicKeyJWK,
+	}, nil
+}
@@ -0,0 +1,156 @@
+package core
+
+import (
+	"context"
+	"database/sql"
+	"math"
+	"time"
+
+	"github.com/leodip/goiabada/internal/common"
+	"github.com/leodip/goiabada/internal/data"
+	"github.com/leodip/goiabada/internal/entities"
+)
+
+// the progressive delay doubles after each failed attempt, up to this value
+const maxLoginDelay = 60 * time.Second
+
+type LoginLockoutManager struct {
+	database data.Database
+}
+
+func NewLoginLockoutManager(database data.Database) *LoginLockoutManager {
+	return &LoginLockoutManager{
+		database: database,
+	}
+}
+
+type LoginCheckResult struct {
+	UserLocked      bool
+	IpAddressLocked bool
+	RetryAfter      time.Duration
+}
+
+func (r *LoginCheckResult) Allowed() bool {
+	return !r.UserLocked && !r.IpAddressLocked && r.RetryAfter <= 0
+}
+
+// CheckLogin verifies if a login attempt (password or OTP) can be made, before checking the credentials.
+// The attempt is rejected while the user or the IP address is locked, or during the delay that follows
+// the failed attempts of the user. The user can be nil, when unknown.
+func (m *LoginLockoutManager) CheckLogin(ctx context.Context, user *entities.User, ipAddress string) (*LoginCheckResult, error) {
+
+	settings := ctx.Value(common.ContextKeySettings).(*entities.Settings)
+	result := &LoginCheckResult{}
+
+	if settings.LoginIpLockoutAfterFailedAttempts > 0 && len(ipAddress) > 0 {
+		failedLoginIp, err := m.database.GetFailedLoginIpByIpAddress(nil, ipAddress)
+		if err != nil {
+			return nil, err
+		}
+		result.IpAddressLocked = failedLoginIp != nil && failedLoginIp.IsLocked()
+	}
+
+	if user == nil {
+		return result, nil
+	}
+
+	result.UserLocked = user.IsLocked()
+
+	if settings.LoginDelayAfterFailedAttempts > 0 && user.LastFailedLoginAt.Valid &&
+		user.FailedLoginAttempts >= settings.LoginDelayAfterFailedAttempts {
+
+		delay := getLoginDelay(user.FailedLoginAttempts - settings.LoginDelayAfterFailedAttempts)
+		result.RetryAfter = time.Until(user.LastFailedLoginAt.Time.Add(delay)).Round(time.Second)
+	}
+
+	return result, nil
+}
+
+// RegisterFailedLogin counts a failed login attempt for the user (when not nil) and for the IP address.
+// The failed attempts are forgotten after the lockout duration without failures. It returns whether
+// the user and the IP address were locked by this attempt.
+func (m *LoginLockoutManager) RegisterFailedLogin(ctx context.Context, user *entities.User, ipAddress string) (userLocked bool, ipAddressLocked bool, err error) {
+
+	settings := ctx.Value(common.ContextKeySettings).(*entities.Settings)
+	now := time.Now().UTC()
+	lockoutDuration := time.Duration(settings.LoginLockoutDurationInSeconds) * time.Second
+
+	if settings.LoginIpLockoutAfterFailedAttempts > 0 && len(ipAddress) > 0 {
+		failedLoginIp, err := m.database.GetFailedLoginIpByIpAddress(nil, ipAddress)
+		if err != nil {
+			return false, false, err
+		}
+
+		if failedLoginIp == nil {
+			failedLoginIp = &entities.FailedLoginIp{
+				IpAddress: ipAddress,
+			}
+		} else if !failedLoginIp.IsLocked() && now.Sub(failedLoginIp.LastFailedAt) > lockoutDuration {
+			failedLoginIp.FailedAttempts = 0
+			failedLoginIp.LockedUntil = sql.NullTime{Valid: false}
+		}
+
+		failedLoginIp.FailedAttempts++
+		failedLoginIp.LastFailedAt = now
+		if failedLoginIp.FailedAttempts >= settings.LoginIpLockoutAfterFailedAttempts && !failedLoginIp.IsLocked() {
+			failedLoginIp.FailedAttempts = 0
+			failedLoginIp.LockedUntil = sql.NullTime{Time: now.Add(lockoutDuration), Valid: true}
+			ipAddressLocked = true
+		}
+
+		if failedLoginIp.Id == 0 {
+			err = m.database.CreateFailedLoginIp(nil, failedLoginIp)
+		} else {
+			err = m.database.UpdateFailedLoginIp(nil, failedLoginIp)
+		}
+		if err != nil {
+			return false, false, err
+		}
+	}
+
+	if user == nil {
+		return false, ipAddressLocked, nil
+	}
+
+	if user.LastFailedLoginAt.Valid && now.Sub(user.LastFailedLoginAt.Time) > lockoutDuration {
+		user.FailedLoginAttempts = 0
+	}
+
+	user.FailedLoginAttempts++
+	user.LastFailedLoginAt = sql.NullTime{Time: now, Valid: true}
+
+	if settings.LoginLockoutAfterFailedAttempts > 0 && !user.IsLocked() &&
+		user.FailedLoginAttempts >= settings.LoginLockoutAfterFailedAttempts {
+		// the delays start over when the lockout ends
+		user.FailedLoginAttempts = 0
+		user.LockedUntil = sql.NullTime{Time: now.Add(lockoutDuration), Valid: true}
+		userLocked = true
+	}
+
+	err = m.database.UpdateUser(nil, user)
+	if err != nil {
+		return false, false, err
+	}
+	return userLocked, ipAddressLocked, nil
+}
+
+// UnlockUser clears the lockout and the failed login attempts of the user.
+func (m *LoginLockoutManager) UnlockUser(user *entities.User) error {
+	user.FailedLoginAttempts = 0
+	user.LastFailedLoginAt = sql.NullTime{Valid: false}
+	user.LockedUntil = sql.NullTime{Valid: false}
+	return m.database.UpdateUser(nil, user)
+}
+
+// UnlockIpAddress forgets the failed login attempts from the IP address.
+func (m *LoginLockoutManager) UnlockIpAddress(failedLoginIpId int64) error {
+	return m.database.DeleteFailedLoginIp(nil, failedLoginIpId)
+}
+
+func getLoginDelay(attemptsOverLimit int) time.Duration {
+	if attemptsOverLimit > 6 {
+		return maxLoginDelay
+	}
+	delay := time.Duration(math.Pow(2, float64(attemptsOverLimit))) * time.Second
+	return min(delay, maxLoginDelay)
+}
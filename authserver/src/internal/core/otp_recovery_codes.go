@@ -0,0 +1,76 @@
+package core
+
+import (
+	"crypto/rand"
+	"math/big"
+	"strings"
+
+	"github.com/leodip/goiabada/internal/entities"
+	"github.com/leodip/goiabada/internal/lib"
+	"github.com/pkg/errors"
+)
+
+const otpRecoveryCodesCount = 10
+
+// ambiguous characters (0/o, 1/l/i) are left out, to make the codes easier to type
+const otpRecoveryCodeChars = "abcdefghjkmnpqrstuvwxyz23456789"
+
+type OTPRecoveryCodeManager struct {
+}
+
+func NewOTPRecoveryCodeManager() *OTPRecoveryCodeManager {
+	return &OTPRecoveryCodeManager{}
+}
+
+// GenerateRecoveryCodes replaces the recovery codes of the user, and returns the new codes in plain text.
+// Only the hashes are kept in the user, so the codes can't be displayed again.
+func (m *OTPRecoveryCodeManager) GenerateRecoveryCodes(user *entities.User) ([]string, error) {
+
+	codes := make([]string, 0, otpRecoveryCodesCount)
+	hashes := make([]string, 0, otpRecoveryCodesCount)
+
+	for i := 0; i < otpRecoveryCodesCount; i++ {
+		chars := make([]byte, 10)
+		for j := range chars {
+			n, err := rand.Int(rand.Reader, big.NewInt(int64(len(otpRecoveryCodeChars))))
+			if err != nil {
+				return nil, errors.Wrap(err, "unable to generate recovery code")
+			}
+			chars[j] = otpRecoveryCodeChars[n.Int64()]
+		}
+		code := string(chars)
+
+		hash, err := lib.HashString(code)
+		if err != nil {
+			return nil, err
+		}
+
+		codes = append(codes, code[:5]+"-"+code[5:])
+		hashes = append(hashes, hash)
+	}
+
+	user.OTPRecoveryCodesHashes = strings.Join(hashes, " ")
+	return codes, nil
+}
+
+// UseRecoveryCode returns true if the code is one of the recovery codes of the user. The code is
+// removed from the user, as each recovery code can only be used once.
+func (m *OTPRecoveryCodeManager) UseRecoveryCode(user *entities.User, code string) bool {
+
+	// users may type the code without the dash, with spaces or in upper case
+	code = strings.ToLower(code)
+	code = strings.NewReplacer("-", "", " ", "").Replace(code)
+	if len(code) == 0 {
+		return false
+	}
+
+	hashes := strings.Fields(user.OTPRecoveryCodesHashes)
+	for i, hash := range hashes {
+		if lib.VerifyStringHash(hash, code) {
+			hashes = append(hashes[:i], hashes[i+1:]...)
+			user.OTPRecoveryCodesHashes = strings.Join(hashes, " ")
+			return true
+		}
+	}
+	return false
+}
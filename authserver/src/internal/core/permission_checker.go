@@ -3,6 +3,7 @@ package core
 import (
 	"strings"
 
+	"github.com/leodip/goiabada/internal/constants"
 	"github.com/leodip/goiabada/internal/data"
 	"github.com/leodip/goiabada/internal/entities"
 	"github.com/pkg/errors"
@@ -18,6 +19,28 @@ func NewPermissionChecker(database data.Database) *PermissionChecker {
 	}
 }
 
+// GroupGrantsAuthServerPermissions returns true when the group grants permissions of the authserver
+// resource, other than manage-account (which only gives access to the account of the user itself).
+func (pc *PermissionChecker) GroupGrantsAuthServerPermissions(group *entities.Group) (bool, error) {
+	err := pc.database.GroupLoadPermissions(nil, group)
+	if err != nil {
+		return false, err
+	}
+
+	authServerResource, err := pc.database.GetResourceByResourceIdentifier(nil, constants.AuthServerResourceIdentifier)
+	if err != nil {
+		return false, err
+	}
+
+	for _, permission := range group.Permissions {
+		if permission.ResourceId == authServerResource.Id &&
+			permission.PermissionIdentifier != constants.ManageAccountPermissionIdentifier {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
 func (pc *PermissionChecker) UserHasScopePermission(userId int64, scope string) (bool, error) {
 	user, err := pc.database.GetUserById(nil, userId)
 	if err != nil {
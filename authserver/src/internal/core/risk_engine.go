@@ -0,0 +1,201 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/leodip/goiabada/internal/common"
+	"github.com/leodip/goiabada/internal/customerrors"
+	"github.com/leodip/goiabada/internal/data"
+	"github.com/leodip/goiabada/internal/entities"
+)
+
+// the score added by each signal. The total is capped at 100.
+const (
+	riskScoreNewDevice         = 30
+	riskScoreNewIpAddress      = 20
+	riskScorePerFailedAttempt  = 10
+	riskScoreMaxFailedAttempts = 40
+	riskScoreSuspiciousIpRange = 50
+	riskScoreMax               = 100
+)
+
+const (
+	RiskSignalNewDevice         = "new_device"
+	RiskSignalNewIpAddress      = "new_ip_address"
+	RiskSignalFailedAttempts    = "failed_attempts"
+	RiskSignalSuspiciousIpRange = "suspicious_ip_range"
+	RiskSignalTrustedIpRange    = "trusted_ip_range"
+)
+
+type RiskEngine struct {
+	database data.Database
+}
+
+func NewRiskEngine(database data.Database) *RiskEngine {
+	return &RiskEngine{
+		database: database,
+	}
+}
+
+type LoginRiskInput struct {
+	User       *entities.User
+	IpAddress  string
+	DeviceName string
+	DeviceType string
+	DeviceOS   string
+}
+
+type LoginRiskAssessment struct {
+	Score   int
+	Signals []string
+	Block   bool
+	StepUp  bool
+	Notify  bool
+}
+
+// AssessLogin scores a login of the user that was authenticated with the password, and decides the
+// actions to take according to the thresholds in the settings. Nothing is done when risk-based
+// authentication is disabled.
+func (re *RiskEngine) AssessLogin(ctx context.Context, input *LoginRiskInput) (*LoginRiskAssessment, error) {
+
+	settings := ctx.Value(common.ContextKeySettings).(*entities.Settings)
+	assessment := &LoginRiskAssessment{
+		Signals: []string{},
+	}
+
+	if !settings.RiskBasedAuthEnabled {
+		return assessment, nil
+	}
+
+	ipAddress := net.ParseIP(input.IpAddress)
+
+	// logins from the trusted networks are never considered risky
+	trustedIpRanges, err := ParseIpRanges(settings.RiskTrustedIpRanges)
+	if err != nil {
+		return nil, err
+	}
+	if ipInRanges(ipAddress, trustedIpRanges) {
+		assessment.Signals = append(assessment.Signals, RiskSignalTrustedIpRange)
+		return assessment, nil
+	}
+
+	suspiciousIpRanges, err := ParseIpRanges(settings.RiskSuspiciousIpRanges)
+	if err != nil {
+		return nil, err
+	}
+	if ipInRanges(ipAddress, suspiciousIpRanges) {
+		assessment.add(RiskSignalSuspiciousIpRange, riskScoreSuspiciousIpRange)
+	}
+
+	// the device and the IP address are compared with the ones of the previous sessions.
+	// There's nothing to compare with when the user has no sessions.
+	userSessions, err := re.database.GetUserSessionsByUserId(nil, input.User.Id)
+	if err != nil {
+		return nil, err
+	}
+	if len(userSessions) > 0 {
+		knownDevice, knownIpAddress := false, false
+		for _, userSession := range userSessions {
+			if userSession.DeviceName == input.DeviceName && userSession.DeviceType == input.DeviceType &&
+				userSession.DeviceOS == input.DeviceOS {
+				knownDevice = true
+			}
+			if userSession.IpAddress == input.IpAddress {
+				knownIpAddress = true
+			}
+		}
+		if !knownDevice {
+			assessment.add(RiskSignalNewDevice, riskScoreNewDevice)
+		}
+		if !knownIpAddress {
+			assessment.add(RiskSignalNewIpAddress, riskScoreNewIpAddress)
+		}
+	}
+
+	// recent failed attempts of the user and from the IP address, as counted for the login lockout
+	failedAttempts, err := re.countRecentFailedAttempts(settings, input)
+	if err != nil {
+		return nil, err
+	}
+	if failedAttempts > 0 {
+		assessment.add(RiskSignalFailedAttempts, min(failedAttempts*riskScorePerFailedAttempt, riskScoreMaxFailedAttempts))
+	}
+
+	assessment.Score = min(assessment.Score, riskScoreMax)
+	assessment.Block = settings.RiskScoreForBlock > 0 && assessment.Score >= settings.RiskScoreForBlock
+	assessment.StepUp = settings.RiskScoreForOTP > 0 && assessment.Score >= settings.RiskScoreForOTP
+	assessment.Notify = settings.RiskScoreForNotification > 0 && assessment.Score >= settings.RiskScoreForNotification
+
+	return assessment, nil
+}
+
+func (re *RiskEngine) countRecentFailedAttempts(settings *entities.Settings, input *LoginRiskInput) (int, error) {
+
+	since := time.Now().UTC().Add(-time.Duration(settings.LoginLockoutDurationInSeconds) * time.Second)
+	failedAttempts := 0
+
+	if input.User.LastFailedLoginAt.Valid && input.User.LastFailedLoginAt.Time.After(since) {
+		failedAttempts += input.User.FailedLoginAttempts
+	}
+
+	if len(input.IpAddress) > 0 {
+		failedLoginIp, err := re.database.GetFailedLoginIpByIpAddress(nil, input.IpAddress)
+		if err != nil {
+			return 0, err
+		}
+		if failedLoginIp != nil && failedLoginIp.LastFailedAt.After(since) {
+			failedAttempts += failedLoginIp.FailedAttempts
+		}
+	}
+	return failedAttempts, nil
+}
+
+func (a *LoginRiskAssessment) add(signal string, score int) {
+	a.Signals = append(a.Signals, signal)
+	a.Score += score
+}
+
+// ParseIpRanges parses a list of IP ranges in CIDR notation, or single IP addresses, separated by
+// commas, spaces or new lines.
+func ParseIpRanges(ipRanges string) ([]*net.IPNet, error) {
+	result := []*net.IPNet{}
+
+	fields := strings.FieldsFunc(ipRanges, func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\n' || r == '\r' || r == '\t'
+	})
+	for _, field := range fields {
+		if !strings.Contains(field, "/") {
+			ip := net.ParseIP(field)
+			if ip == nil {
+				return nil, customerrors.NewValidationError("", fmt.Sprintf("Invalid IP address or range: %v.", field))
+			}
+			if ip.To4() != nil {
+				field += "/32"
+			} else {
+				field += "/128"
+			}
+		}
+		_, ipNet, err := net.ParseCIDR(field)
+		if err != nil {
+			return nil, customerrors.NewValidationError("", fmt.Sprintf("Invalid IP address or range: %v.", field))
+		}
+		result = append(result, ipNet)
+	}
+	return result, nil
+}
+
+func ipInRanges(ip net.IP, ipRanges []*net.IPNet) bool {
+	if ip == nil {
+		return false
+	}
+	for _, ipRange := range ipRanges {
+		if ipRange.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,205 @@
+package core
+
+// The discovery endpoints (RFC 7644, section 4) describe what this server supports, so
+// provisioning clients can adapt their requests.
+
+func NewServiceProviderConfig(baseURL string) map[string]any {
+	return map[string]any{
+		"schemas":          []string{SchemaServiceProviderConfig},
+		"documentationUri": "https://goiabada.dev",
+		"patch":            map[string]any{"supported": true},
+		"bulk":             map[string]any{"supported": false, "maxOperations": 0, "maxPayloadSize": 0},
+		"filter":           map[string]any{"supported": true, "maxResults": MaxCount},
+		"changePassword":   map[string]any{"supported": true},
+		"sort":             map[string]any{"supported": false},
+		"etag":             map[string]any{"supported": true},
+		"authenticationSchemes": []map[string]any{
+			{
+				"type":        "oauthbearertoken",
+				"name":        "OAuth Bearer Token",
+				"description": "An access token obtained with the client credentials flow, with the authserver:scim scope.",
+				"specUri":     "https://www.rfc-editor.org/info/rfc6750",
+				"primary":     true,
+			},
+		},
+		"meta": map[string]any{
+			"resourceType": "ServiceProviderConfig",
+			"location":     baseURL + "/scim/v2/ServiceProviderConfig",
+		},
+	}
+}
+
+func NewResourceTypes(baseURL string) []map[string]any {
+	return []map[string]any{
+		{
+			"schemas":     []string{SchemaResourceType},
+			"id":          "User",
+			"name":        "User",
+			"endpoint":    "/Users",
+			"description": "User Account",
+			"schema":      SchemaUser,
+			"meta": map[string]any{
+				"resourceType": "ResourceType",
+				"location":     baseURL + "/scim/v2/ResourceTypes/User",
+			},
+		},
+		{
+			"schemas":     []string{SchemaResourceType},
+			"id":          "Group",
+			"name":        "Group",
+			"endpoint":    "/Groups",
+			"description": "Group",
+			"schema":      SchemaGroup,
+			"meta": map[string]any{
+				"resourceType": "ResourceType",
+				"location":     baseURL + "/scim/v2/ResourceTypes/Group",
+			},
+		},
+	}
+}
+
+type schemaAttribute struct {
+	name          string
+	typ           string
+	multiValued   bool
+	required      bool
+	caseExact     bool
+	mutability    string
+	returned      string
+	uniqueness    string
+	subAttributes []schemaAttribute
+}
+
+func (a schemaAttribute) toMap() map[string]any {
+	result := map[string]any{
+		"name":        a.name,
+		"type":        a.typ,
+		"multiValued": a.multiValued,
+		"required":    a.required,
+		"caseExact":   a.caseExact,
+		"mutability":  a.mutability,
+		"returned":    a.returned,
+		"uniqueness":  a.uniqueness,
+	}
+	if len(a.subAttributes) > 0 {
+		subAttributes := []map[string]any{}
+		for _, subAttribute := range a.subAttributes {
+			subAttributes = append(subAttributes, subAttribute.toMap())
+		}
+		result["subAttributes"] = subAttributes
+	}
+	return result
+}
+
+func attribute(name string, typ string, mutability string) schemaAttribute {
+	return schemaAttribute{
+		name:       name,
+		typ:        typ,
+		mutability: mutability,
+		returned:   "default",
+		uniqueness: "none",
+	}
+}
+
+func multiValuedAttribute(name string, mutability string, subAttributes ...schemaAttribute) schemaAttribute {
+	a := attribute(name, "complex", mutability)
+	a.multiValued = true
+	a.subAttributes = subAttributes
+	return a
+}
+
+func newSchema(id string, name string, description string, baseURL string, attributes ...schemaAttribute) map[string]any {
+	result := []map[string]any{}
+	for _, a := range attributes {
+		result = append(result, a.toMap())
+	}
+	return map[string]any{
+		"schemas":     []string{SchemaSchema},
+		"id":          id,
+		"name":        name,
+		"description": description,
+		"attributes":  result,
+		"meta": map[string]any{
+			"resourceType": "Schema",
+			"location":     baseURL + "/scim/v2/Schemas/" + id,
+		},
+	}
+}
+
+func NewSchemas(baseURL string) []map[string]any {
+
+	userName := attribute("userName", "string", "readWrite")
+	userName.required = true
+	userName.uniqueness = "server"
+
+	password := attribute("password", "string", "writeOnly")
+	password.returned = "never"
+
+	externalId := attribute("externalId", "string", "readWrite")
+	externalId.caseExact = true
+
+	displayName := attribute("displayName", "string", "readWrite")
+	displayName.required = true
+	displayName.uniqueness = "server"
+
+	return []map[string]any{
+		newSchema(SchemaUser, "User", "User Account", baseURL,
+			userName,
+			externalId,
+			schemaAttribute{
+				name:       "name",
+				typ:        "complex",
+				mutability: "readWrite",
+				returned:   "default",
+				uniqueness: "none",
+				subAttributes: []schemaAttribute{
+					attribute("formatted", "string", "readOnly"),
+					attribute("givenName", "string", "readWrite"),
+					attribute("middleName", "string", "readWrite"),
+					attribute("familyName", "string", "readWrite"),
+				},
+			},
+			attribute("displayName", "string", "readOnly"),
+			attribute("nickName", "string", "readWrite"),
+			attribute("profileUrl", "reference", "readWrite"),
+			attribute("locale", "string", "readWrite"),
+			attribute("timezone", "string", "readWrite"),
+			attribute("active", "boolean", "readWrite"),
+			password,
+			multiValuedAttribute("emails", "readWrite",
+				attribute("value", "string", "readWrite"),
+				attribute("type", "string", "readWrite"),
+				attribute("primary", "boolean", "readWrite"),
+			),
+			multiValuedAttribute("phoneNumbers", "readWrite",
+				attribute("value", "string", "readWrite"),
+				attribute("type", "string", "readWrite"),
+				attribute("primary", "boolean", "readWrite"),
+			),
+			multiValuedAttribute("addresses", "readWrite",
+				attribute("streetAddress", "string", "readWrite"),
+				attribute("locality", "string", "readWrite"),
+				attribute("region", "string", "readWrite"),
+				attribute("postalCode", "string", "readWrite"),
+				attribute("country", "string", "readWrite"),
+				attribute("type", "string", "readWrite"),
+				attribute("primary", "boolean", "readWrite"),
+			),
+			multiValuedAttribute("groups", "readOnly",
+				attribute("value", "string", "readOnly"),
+				attribute("$ref", "reference", "readOnly"),
+				attribute("display", "string", "readOnly"),
+			),
+		),
+		newSchema(SchemaGroup, "Group", "Group", baseURL,
+			displayName,
+			externalId,
+			multiValuedAttribute("members", "readWrite",
+				attribute("value", "string", "immutable"),
+				attribute("$ref", "reference", "immutable"),
+				attribute("display", "string", "readOnly"),
+				attribute("type", "string", "immutable"),
+			),
+		),
+	}
+}
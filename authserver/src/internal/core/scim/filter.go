@@ -0,0 +1,436 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Filter is a parsed SCIM filter expression (RFC 7644, section 3.4.2.2), evaluated against
+// the JSON representation of a resource.
+type Filter interface {
+	Matches(resource map[string]any) bool
+}
+
+type attributePath struct {
+	attribute    string
+	subAttribute string
+}
+
+// parseAttributePath splits an attribute path like "name.givenName" or
+// "urn:ietf:params:scim:schemas:core:2.0:User:name.givenName" in its components.
+func parseAttributePath(path string) attributePath {
+	if strings.HasPrefix(strings.ToLower(path), "urn:") {
+		// strip the schema URN
+		if idx := strings.LastIndex(path, ":"); idx >= 0 {
+			path = path[idx+1:]
+		}
+	}
+	attribute, subAttribute, _ := strings.Cut(path, ".")
+	return attributePath{
+		attribute:    attribute,
+		subAttribute: subAttribute,
+	}
+}
+
+type logicalExpression struct {
+	operator string
+	left     Filter
+	right    Filter
+}
+
+func (e *logicalExpression) Matches(resource map[string]any) bool {
+	if e.operator == "and" {
+		return e.left.Matches(resource) && e.right.Matches(resource)
+	}
+	return e.left.Matches(resource) || e.right.Matches(resource)
+}
+
+type notExpression struct {
+	filter Filter
+}
+
+func (e *notExpression) Matches(resource map[string]any) bool {
+	return !e.filter.Matches(resource)
+}
+
+// valuePathExpression filters the values of a multi-valued attribute, like emails[type eq "work"].
+type valuePathExpression struct {
+	attribute string
+	filter    Filter
+}
+
+func (e *valuePathExpression) Matches(resource map[string]any) bool {
+	key, ok := findKey(resource, e.attribute)
+	if !ok {
+		return false
+	}
+	for _, value := range asSlice(resource[key]) {
+		if m, ok := value.(map[string]any); ok && e.filter.Matches(m) {
+			return true
+		}
+	}
+	return false
+}
+
+type attributeExpression struct {
+	path     attributePath
+	operator string
+	value    any
+}
+
+func (e *attributeExpression) Matches(resource map[string]any) bool {
+	values := resolveValues(resource, e.path)
+
+	if e.operator == "pr" {
+		for _, value := range values {
+			if isPresent(value) {
+				return true
+			}
+		}
+		return false
+	}
+
+	if e.operator == "ne" {
+		// not equal to any of the values
+		return !(&attributeExpression{path: e.path, operator: "eq", value: e.value}).Matches(resource)
+	}
+
+	caseExact := strings.EqualFold(e.path.attribute, "id") || strings.EqualFold(e.path.attribute, "externalId")
+	for _, value := range values {
+		if compare(value, e.operator, e.value, caseExact) {
+			return true
+		}
+	}
+
+	if e.value == nil && e.operator == "eq" {
+		// "eq null" matches unassigned attributes
+		return len(values) == 0
+	}
+	return false
+}
+
+// resolveValues returns the values at the path. The values of multi-valued attributes are flattened,
+// and the "value" sub-attribute is used when a multi-valued complex attribute is compared directly.
+func resolveValues(resource map[string]any, path attributePath) []any {
+	key, ok := findKey(resource, path.attribute)
+	if !ok {
+		return nil
+	}
+
+	result := []any{}
+	for _, value := range asSlice(resource[key]) {
+		m, isComplex := value.(map[string]any)
+		if !isComplex {
+			if len(path.subAttribute) == 0 {
+				result = append(result, value)
+			}
+			continue
+		}
+		subAttribute := path.subAttribute
+		if len(subAttribute) == 0 {
+			subAttribute = "value"
+		}
+		if subKey, ok := findKey(m, subAttribute); ok {
+			result = append(result, m[subKey])
+		}
+	}
+	return result
+}
+
+func asSlice(value any) []any {
+	if value == nil {
+		return nil
+	}
+	if s, ok := value.([]any); ok {
+		return s
+	}
+	return []any{value}
+}
+
+func isPresent(value any) bool {
+	switch v := value.(type) {
+	case nil:
+		return false
+	case string:
+		return len(v) > 0
+	case []any:
+		return len(v) > 0
+	case map[string]any:
+		return len(v) > 0
+	}
+	return true
+}
+
+func compare(actual any, operator string, expected any, caseExact bool) bool {
+	switch a := actual.(type) {
+	case string:
+		e, ok := expected.(string)
+		if !ok {
+			return false
+		}
+		if !caseExact {
+			a = strings.ToLower(a)
+			e = strings.ToLower(e)
+		}
+		switch operator {
+		case "eq":
+			return a == e
+		case "co":
+			return strings.Contains(a, e)
+		case "sw":
+			return strings.HasPrefix(a, e)
+		case "ew":
+			return strings.HasSuffix(a, e)
+		case "gt":
+			return a > e
+		case "ge":
+			return a >= e
+		case "lt":
+			return a < e
+		case "le":
+			return a <= e
+		}
+	case float64:
+		e, ok := expected.(float64)
+		if !ok {
+			return false
+		}
+		switch operator {
+		case "eq":
+			return a == e
+		case "gt":
+			return a > e
+		case "ge":
+			return a >= e
+		case "lt":
+			return a < e
+		case "le":
+			return a <= e
+		}
+	case bool:
+		e, ok := expected.(bool)
+		return ok && operator == "eq" && a == e
+	}
+	return false
+}
+
+// ParseFilter parses a filter expression. The error is a SCIM error with the invalidFilter type.
+func ParseFilter(filter string) (Filter, error) {
+	tokens, err := tokenizeFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+	p := &filterParser{tokens: tokens}
+	result, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, invalidFilterError(fmt.Sprintf("unexpected %v", p.tokens[p.pos].text))
+	}
+	return result, nil
+}
+
+func invalidFilterError(detail string) *Error {
+	return NewError(http.StatusBadRequest, "invalidFilter", "The filter is invalid: "+detail+".")
+}
+
+type filterToken struct {
+	text     string
+	isString bool
+}
+
+func tokenizeFilter(filter string) ([]filterToken, error) {
+	tokens := []filterToken{}
+	i := 0
+	for i < len(filter) {
+		c := filter[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '(' || c == ')' || c == '[' || c == ']':
+			tokens = append(tokens, filterToken{text: string(c)})
+			i++
+		case c == '"':
+			// a JSON string, ends at the first unescaped quote
+			j := i + 1
+			for j < len(filter) && filter[j] != '"' {
+				if filter[j] == '\\' {
+					j++
+				}
+				j++
+			}
+			if j >= len(filter) {
+				return nil, invalidFilterError("unterminated string")
+			}
+			var value string
+			if err := json.Unmarshal([]byte(filter[i:j+1]), &value); err != nil {
+				return nil, invalidFilterError("invalid string " + filter[i:j+1])
+			}
+			tokens = append(tokens, filterToken{text: value, isString: true})
+			i = j + 1
+		default:
+			j := i
+			for j < len(filter) && !strings.ContainsRune(" \t\n\r()[]\"", rune(filter[j])) {
+				j++
+			}
+			tokens = append(tokens, filterToken{text: filter[i:j]})
+			i = j
+		}
+	}
+	if len(tokens) == 0 {
+		return nil, invalidFilterError("the expression is empty")
+	}
+	return tokens, nil
+}
+
+type filterParser struct {
+	tokens []filterToken
+	pos    int
+}
+
+func (p *filterParser) peekKeyword(keyword string) bool {
+	return p.pos < len(p.tokens) && !p.tokens[p.pos].isString && strings.EqualFold(p.tokens[p.pos].text, keyword)
+}
+
+func (p *filterParser) expect(text string) error {
+	if !p.peekKeyword(text) {
+		return invalidFilterError("expected " + text)
+	}
+	p.pos++
+	return nil
+}
+
+func (p *filterParser) parseOr() (Filter, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peekKeyword("or") {
+		p.pos++
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &logicalExpression{operator: "or", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *filterParser) parseAnd() (Filter, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.peekKeyword("and") {
+		p.pos++
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = &logicalExpression{operator: "and", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *filterParser) parseUnary() (Filter, error) {
+	if p.peekKeyword("not") {
+		p.pos++
+		if err := p.expect("("); err != nil {
+			return nil, err
+		}
+		filter, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(")"); err != nil {
+			return nil, err
+		}
+		return &notExpression{filter: filter}, nil
+	}
+
+	if p.peekKeyword("(") {
+		p.pos++
+		filter, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(")"); err != nil {
+			return nil, err
+		}
+		return filter, nil
+	}
+
+	if p.pos >= len(p.tokens) || p.tokens[p.pos].isString {
+		return nil, invalidFilterError("expected an attribute name")
+	}
+	attribute := p.tokens[p.pos].text
+	p.pos++
+
+	if p.peekKeyword("[") {
+		p.pos++
+		filter, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect("]"); err != nil {
+			return nil, err
+		}
+		return &valuePathExpression{attribute: parseAttributePath(attribute).attribute, filter: filter}, nil
+	}
+
+	if p.pos >= len(p.tokens) || p.tokens[p.pos].isString {
+		return nil, invalidFilterError("expected an operator after " + attribute)
+	}
+	operator := strings.ToLower(p.tokens[p.pos].text)
+	p.pos++
+
+	expression := &attributeExpression{path: parseAttributePath(attribute), operator: operator}
+	switch operator {
+	case "pr":
+		return expression, nil
+	case "eq", "ne", "co", "sw", "ew", "gt", "ge", "lt", "le":
+	default:
+		return nil, invalidFilterError("unsupported operator " + operator)
+	}
+
+	if p.pos >= len(p.tokens) {
+		return nil, invalidFilterError("expected a value after " + operator)
+	}
+	token := p.tokens[p.pos]
+	p.pos++
+	if token.isString {
+		expression.value = token.text
+		return expression, nil
+	}
+	// true, false, null or a number
+	var value any
+	if err := json.Unmarshal([]byte(strings.ToLower(token.text)), &value); err != nil {
+		return nil, invalidFilterError("invalid value " + token.text)
+	}
+	if _, isString := value.(string); isString {
+		return nil, invalidFilterError("invalid value " + token.text)
+	}
+	expression.value = value
+	return expression, nil
+}
+
+// GetEqualityValue returns the value when the filter is a single "attribute eq value" comparison
+// of the given attribute, which allows a direct lookup instead of a scan.
+func GetEqualityValue(filter Filter, attribute string) (string, bool) {
+	expression, ok := filter.(*attributeExpression)
+	if !ok || expression.operator != "eq" {
+		return "", false
+	}
+	path := expression.path.attribute
+	if len(expression.path.subAttribute) > 0 {
+		path += "." + expression.path.subAttribute
+	}
+	if !strings.EqualFold(path, attribute) {
+		return "", false
+	}
+	value, ok := expression.value.(string)
+	return value, ok
+}
@@ -0,0 +1,280 @@
+package core
+
+import (
+	"net/http"
+	"reflect"
+	"strings"
+)
+
+type PatchOperation struct {
+	Op    string `json:"op"`
+	Path  string `json:"path"`
+	Value any    `json:"value"`
+}
+
+type PatchRequest struct {
+	Schemas    []string         `json:"schemas"`
+	Operations []PatchOperation `json:"Operations"`
+}
+
+// patchPath is a PATCH target like "members", "name.givenName", `emails[type eq "work"]`
+// or `emails[type eq "work"].value` (RFC 7644, section 3.5.2).
+type patchPath struct {
+	attribute    string
+	filter       Filter
+	subAttribute string
+}
+
+func parsePatchPath(path string) (*patchPath, error) {
+	invalidPath := NewError(http.StatusBadRequest, "invalidPath", "The path "+path+" is invalid.")
+
+	open := strings.Index(path, "[")
+	if open < 0 {
+		attributePath := parseAttributePath(path)
+		if len(attributePath.attribute) == 0 {
+			return nil, invalidPath
+		}
+		return &patchPath{
+			attribute:    attributePath.attribute,
+			subAttribute: attributePath.subAttribute,
+		}, nil
+	}
+
+	close := strings.LastIndex(path, "]")
+	if close < open {
+		return nil, invalidPath
+	}
+	filter, err := ParseFilter(path[open+1 : close])
+	if err != nil {
+		return nil, err
+	}
+	result := &patchPath{
+		attribute: parseAttributePath(path[:open]).attribute,
+		filter:    filter,
+	}
+	rest := path[close+1:]
+	if len(rest) > 0 {
+		if !strings.HasPrefix(rest, ".") || len(rest) == 1 {
+			return nil, invalidPath
+		}
+		result.subAttribute = rest[1:]
+	}
+	if len(result.attribute) == 0 {
+		return nil, invalidPath
+	}
+	return result, nil
+}
+
+// ApplyPatch applies the operations of a PATCH request to the JSON representation of a resource.
+// Attributes in readOnlyAttributes can't be targeted by a path, and are ignored when they are part
+// of the value of an operation without a path.
+func ApplyPatch(resource map[string]any, operations []PatchOperation, readOnlyAttributes ...string) error {
+
+	isReadOnly := func(attribute string) bool {
+		for _, readOnly := range readOnlyAttributes {
+			if strings.EqualFold(attribute, readOnly) {
+				return true
+			}
+		}
+		return false
+	}
+
+	for _, operation := range operations {
+		op := strings.ToLower(operation.Op)
+		if op != "add" && op != "replace" && op != "remove" {
+			return NewInvalidValueError("The operation " + operation.Op + " is not supported.")
+		}
+
+		if len(operation.Path) == 0 {
+			if op == "remove" {
+				return NewError(http.StatusBadRequest, "noTarget", "A path is required for the remove operation.")
+			}
+			values, ok := operation.Value.(map[string]any)
+			if !ok {
+				return NewInvalidValueError("The value of an operation without a path must be an object.")
+			}
+			for key, value := range values {
+				path, err := parsePatchPath(key)
+				if err != nil {
+					return err
+				}
+				if isReadOnly(path.attribute) || strings.EqualFold(path.attribute, "schemas") {
+					continue
+				}
+				err = applyPatchOperation(resource, op, path, value)
+				if err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		path, err := parsePatchPath(operation.Path)
+		if err != nil {
+			return err
+		}
+		if isReadOnly(path.attribute) {
+			return NewError(http.StatusBadRequest, "mutability", "The attribute "+path.attribute+" is read-only.")
+		}
+		if op != "remove" && operation.Value == nil {
+			return NewInvalidValueError("A value is required for the " + op + " operation.")
+		}
+		err = applyPatchOperation(resource, op, path, operation.Value)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func applyPatchOperation(resource map[string]any, op string, path *patchPath, value any) error {
+
+	key, exists := findKey(resource, path.attribute)
+	if !exists {
+		key = path.attribute
+	}
+
+	if path.filter != nil {
+		elements, isSlice := resource[key].([]any)
+		if !isSlice {
+			if op == "remove" {
+				return nil
+			}
+			return NewError(http.StatusBadRequest, "noTarget", "No values of "+path.attribute+" match the filter.")
+		}
+
+		remaining := []any{}
+		matched := false
+		for _, element := range elements {
+			m, isComplex := element.(map[string]any)
+			if !isComplex || !path.filter.Matches(m) {
+				remaining = append(remaining, element)
+				continue
+			}
+			matched = true
+			switch {
+			case op == "remove" && len(path.subAttribute) == 0:
+				continue
+			case op == "remove":
+				if subKey, ok := findKey(m, path.subAttribute); ok {
+					delete(m, subKey)
+				}
+			case len(path.subAttribute) > 0:
+				setKey(m, path.subAttribute, value)
+			default:
+				newValue, ok := value.(map[string]any)
+				if !ok {
+					return NewInvalidValueError("The value for " + path.attribute + " must be an object.")
+				}
+				if op == "replace" {
+					m = map[string]any{}
+				}
+				for k, v := range newValue {
+					setKey(m, k, v)
+				}
+			}
+			remaining = append(remaining, m)
+		}
+		if !matched && op != "remove" {
+			return NewError(http.StatusBadRequest, "noTarget", "No values of "+path.attribute+" match the filter.")
+		}
+		resource[key] = remaining
+		return nil
+	}
+
+	if len(path.subAttribute) > 0 {
+		if op == "remove" {
+			if m, ok := resource[key].(map[string]any); ok {
+				if subKey, ok := findKey(m, path.subAttribute); ok {
+					delete(m, subKey)
+				}
+			}
+			return nil
+		}
+		m, ok := resource[key].(map[string]any)
+		if !ok {
+			if exists && resource[key] != nil {
+				return NewError(http.StatusBadRequest, "invalidPath", "The attribute "+path.attribute+" has no sub-attributes.")
+			}
+			m = map[string]any{}
+			resource[key] = m
+		}
+		setKey(m, path.subAttribute, value)
+		return nil
+	}
+
+	switch op {
+	case "remove":
+		if value == nil {
+			delete(resource, key)
+			return nil
+		}
+		// removes the given values of a multi-valued attribute, for example members
+		elements, _ := resource[key].([]any)
+		remaining := []any{}
+		for _, element := range elements {
+			if !containsValue(asSlice(value), element) {
+				remaining = append(remaining, element)
+			}
+		}
+		resource[key] = remaining
+	case "add":
+		switch existing := resource[key].(type) {
+		case []any:
+			for _, newValue := range asSlice(value) {
+				if !containsValue(existing, newValue) {
+					existing = append(existing, newValue)
+				}
+			}
+			resource[key] = existing
+		case map[string]any:
+			newValue, ok := value.(map[string]any)
+			if !ok {
+				return NewInvalidValueError("The value for " + path.attribute + " must be an object.")
+			}
+			for k, v := range newValue {
+				setKey(existing, k, v)
+			}
+		default:
+			resource[key] = value
+		}
+	case "replace":
+		if existing, ok := resource[key].(map[string]any); ok {
+			if newValue, ok := value.(map[string]any); ok {
+				for k, v := range newValue {
+					setKey(existing, k, v)
+				}
+				return nil
+			}
+		}
+		resource[key] = value
+	}
+	return nil
+}
+
+// containsValue reports whether the multi-valued attribute has the value. Complex values
+// are compared by their "value" sub-attribute when they have one.
+func containsValue(values []any, value any) bool {
+	identity := func(v any) any {
+		if m, ok := v.(map[string]any); ok {
+			if key, ok := findKey(m, "value"); ok {
+				return m[key]
+			}
+		}
+		return v
+	}
+	for _, v := range values {
+		if reflect.DeepEqual(identity(v), identity(value)) {
+			return true
+		}
+	}
+	return false
+}
+
+func setKey(m map[string]any, name string, value any) {
+	if key, ok := findKey(m, name); ok {
+		m[key] = value
+		return
+	}
+	m[name] = value
+}
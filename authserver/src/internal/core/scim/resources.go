@@ -0,0 +1,241 @@
+package core
+
+import (
+	"database/sql"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/biter777/countries"
+	"github.com/leodip/goiabada/internal/entities"
+)
+
+type Name struct {
+	Formatted  string `json:"formatted,omitempty"`
+	GivenName  string `json:"givenName,omitempty"`
+	MiddleName string `json:"middleName,omitempty"`
+	FamilyName string `json:"familyName,omitempty"`
+}
+
+type MultiValuedAttribute struct {
+	Value   string `json:"value"`
+	Display string `json:"display,omitempty"`
+	Type    string `json:"type,omitempty"`
+	Primary bool   `json:"primary,omitempty"`
+	Ref     string `json:"$ref,omitempty"`
+}
+
+type Address struct {
+	Formatted     string `json:"formatted,omitempty"`
+	StreetAddress string `json:"streetAddress,omitempty"`
+	Locality      string `json:"locality,omitempty"`
+	Region        string `json:"region,omitempty"`
+	PostalCode    string `json:"postalCode,omitempty"`
+	Country       string `json:"country,omitempty"`
+	Type          string `json:"type,omitempty"`
+	Primary       bool   `json:"primary,omitempty"`
+}
+
+// User is the SCIM representation of a user. The userName is the email address the user signs in with.
+type User struct {
+	Schemas      []string               `json:"schemas"`
+	Id           string                 `json:"id,omitempty"`
+	ExternalId   string                 `json:"externalId,omitempty"`
+	UserName     string                 `json:"userName"`
+	Name         *Name                  `json:"name,omitempty"`
+	DisplayName  string                 `json:"displayName,omitempty"`
+	NickName     string                 `json:"nickName,omitempty"`
+	ProfileUrl   string                 `json:"profileUrl,omitempty"`
+	Locale       string                 `json:"locale,omitempty"`
+	Timezone     string                 `json:"timezone,omitempty"`
+	Active       *bool                  `json:"active,omitempty"`
+	Password     string                 `json:"password,omitempty"`
+	Emails       []MultiValuedAttribute `json:"emails,omitempty"`
+	PhoneNumbers []MultiValuedAttribute `json:"phoneNumbers,omitempty"`
+	Addresses    []Address              `json:"addresses,omitempty"`
+	Groups       []MultiValuedAttribute `json:"groups,omitempty"`
+	Meta         *Meta                  `json:"meta,omitempty"`
+}
+
+// Group is the SCIM representation of a group. The displayName is the group identifier,
+// and the value of each member is the subject of the user.
+type Group struct {
+	Schemas     []string               `json:"schemas"`
+	Id          string                 `json:"id,omitempty"`
+	ExternalId  string                 `json:"externalId,omitempty"`
+	DisplayName string                 `json:"displayName"`
+	Members     []MultiValuedAttribute `json:"members,omitempty"`
+	Meta        *Meta                  `json:"meta,omitempty"`
+}
+
+func formatTime(t sql.NullTime) string {
+	if !t.Valid {
+		return ""
+	}
+	return t.Time.UTC().Format(time.RFC3339)
+}
+
+// NewUser returns the SCIM representation of the user. The groups of the user must be loaded.
+func NewUser(user *entities.User, baseURL string) *User {
+	active := user.Enabled
+	result := &User{
+		Schemas:    []string{SchemaUser},
+		Id:         user.Subject.String(),
+		ExternalId: user.ExternalId,
+		UserName:   user.Email,
+		Name: &Name{
+			Formatted:  user.GetFullName(),
+			GivenName:  user.GivenName,
+			MiddleName: user.MiddleName,
+			FamilyName: user.FamilyName,
+		},
+		DisplayName: user.GetFullName(),
+		NickName:    user.Nickname,
+		ProfileUrl:  user.Website,
+		Locale:      user.Locale,
+		Timezone:    user.ZoneInfo,
+		Active:      &active,
+		Meta: &Meta{
+			ResourceType: "User",
+			Created:      formatTime(user.CreatedAt),
+			LastModified: formatTime(user.UpdatedAt),
+			Location:     baseURL + "/scim/v2/Users/" + user.Subject.String(),
+		},
+	}
+
+	if len(user.Email) > 0 {
+		result.Emails = []MultiValuedAttribute{{Value: user.Email, Type: "work", Primary: true}}
+	}
+
+	if len(user.PhoneNumber) > 0 {
+		result.PhoneNumbers = []MultiValuedAttribute{{Value: user.PhoneNumber, Type: "work", Primary: true}}
+	}
+
+	if len(user.AddressLine1+user.AddressLine2+user.AddressLocality+user.AddressRegion+
+		user.AddressPostalCode+user.AddressCountry) > 0 {
+		address := Address{
+			StreetAddress: strings.TrimSpace(user.AddressLine1 + "\n" + user.AddressLine2),
+			Locality:      user.AddressLocality,
+			Region:        user.AddressRegion,
+			PostalCode:    user.AddressPostalCode,
+			Type:          "work",
+			Primary:       true,
+		}
+		if len(user.AddressCountry) > 0 {
+			address.Country = countries.ByName(user.AddressCountry).Alpha2()
+		}
+		result.Addresses = []Address{address}
+	}
+
+	for _, group := range user.Groups {
+		result.Groups = append(result.Groups, MultiValuedAttribute{
+			Value:   strconv.FormatInt(group.Id, 10),
+			Display: group.GroupIdentifier,
+			Ref:     baseURL + "/scim/v2/Groups/" + strconv.FormatInt(group.Id, 10),
+		})
+	}
+	return result
+}
+
+// NewGroup returns the SCIM representation of the group with the given members.
+func NewGroup(group *entities.Group, members []entities.User, baseURL string) *Group {
+	id := strconv.FormatInt(group.Id, 10)
+	result := &Group{
+		Schemas:     []string{SchemaGroup},
+		Id:          id,
+		ExternalId:  group.ExternalId,
+		DisplayName: group.GroupIdentifier,
+		Meta: &Meta{
+			ResourceType: "Group",
+			Created:      formatTime(group.CreatedAt),
+			LastModified: formatTime(group.UpdatedAt),
+			Location:     baseURL + "/scim/v2/Groups/" + id,
+		},
+	}
+	for _, member := range members {
+		result.Members = append(result.Members, MultiValuedAttribute{
+			Value:   member.Subject.String(),
+			Display: member.Email,
+			Type:    "User",
+			Ref:     baseURL + "/scim/v2/Users/" + member.Subject.String(),
+		})
+	}
+	return result
+}
+
+// GetPrimary returns the primary value of a multi-valued attribute, or the first value when
+// none is flagged as primary.
+func GetPrimary(values []MultiValuedAttribute) string {
+	for _, value := range values {
+		if value.Primary {
+			return strings.TrimSpace(value.Value)
+		}
+	}
+	if len(values) > 0 {
+		return strings.TrimSpace(values[0].Value)
+	}
+	return ""
+}
+
+// GetPrimaryAddress returns the primary address, or the first one when none is flagged as primary.
+func GetPrimaryAddress(addresses []Address) *Address {
+	for idx := range addresses {
+		if addresses[idx].Primary {
+			return &addresses[idx]
+		}
+	}
+	if len(addresses) > 0 {
+		return &addresses[0]
+	}
+	return nil
+}
+
+// SplitStreetAddress maps the street address to the two address lines of the user.
+func SplitStreetAddress(streetAddress string) (string, string) {
+	line1, line2, _ := strings.Cut(strings.TrimSpace(streetAddress), "\n")
+	return strings.TrimSpace(line1), strings.TrimSpace(line2)
+}
+
+// CountryToAlpha3 converts a country (ISO 3166-1 alpha-2, as used by SCIM) to the alpha-3 code
+// stored on the user. An empty string is returned when the country is unknown.
+func CountryToAlpha3(country string) string {
+	c := countries.ByName(country)
+	if c.Info().Code == 0 {
+		return ""
+	}
+	return c.Alpha3()
+}
+
+// DecodeUser converts the JSON representation of a user (for example after a PATCH) back
+// to a User. Boolean values sent as strings, like "False", are accepted for the active attribute.
+func DecodeUser(resource map[string]any) (*User, error) {
+	if key, ok := findKey(resource, "active"); ok {
+		if s, isString := resource[key].(string); isString {
+			value, err := strconv.ParseBool(s)
+			if err != nil {
+				return nil, NewInvalidValueError("The value of active must be a boolean.")
+			}
+			resource[key] = value
+		}
+	}
+	return decode[User](resource)
+}
+
+// DecodeGroup converts the JSON representation of a group back to a Group.
+func DecodeGroup(resource map[string]any) (*Group, error) {
+	return decode[Group](resource)
+}
+
+func decode[T any](resource map[string]any) (*T, error) {
+	data, err := json.Marshal(resource)
+	if err != nil {
+		return nil, err
+	}
+	var result T
+	err = json.Unmarshal(data, &result)
+	if err != nil {
+		return nil, NewInvalidValueError("The resource is invalid: " + err.Error())
+	}
+	return &result, nil
+}
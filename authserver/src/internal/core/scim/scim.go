@@ -0,0 +1,255 @@
+package core
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const ContentType = "application/scim+json"
+
+const (
+	SchemaUser                  = "urn:ietf:params:scim:schemas:core:2.0:User"
+	SchemaGroup                 = "urn:ietf:params:scim:schemas:core:2.0:Group"
+	SchemaServiceProviderConfig = "urn:ietf:params:scim:schemas:core:2.0:ServiceProviderConfig"
+	SchemaResourceType          = "urn:ietf:params:scim:schemas:core:2.0:ResourceType"
+	SchemaSchema                = "urn:ietf:params:scim:schemas:core:2.0:Schema"
+	SchemaListResponse          = "urn:ietf:params:scim:api:messages:2.0:ListResponse"
+	SchemaPatchOp               = "urn:ietf:params:scim:api:messages:2.0:PatchOp"
+	SchemaError                 = "urn:ietf:params:scim:api:messages:2.0:Error"
+)
+
+// DefaultCount is the page size used when the client does not specify one, and MaxCount
+// is the largest page size the server returns.
+const DefaultCount = 100
+const MaxCount = 1000
+
+// Error is a SCIM protocol error (RFC 7644, section 3.12).
+type Error struct {
+	Status   int
+	ScimType string
+	Detail   string
+}
+
+func NewError(status int, scimType string, detail string) *Error {
+	return &Error{
+		Status:   status,
+		ScimType: scimType,
+		Detail:   detail,
+	}
+}
+
+func NewInvalidValueError(detail string) *Error {
+	return NewError(http.StatusBadRequest, "invalidValue", detail)
+}
+
+func (e *Error) Error() string {
+	return e.Detail
+}
+
+func (e *Error) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Schemas  []string `json:"schemas"`
+		Status   string   `json:"status"`
+		ScimType string   `json:"scimType,omitempty"`
+		Detail   string   `json:"detail,omitempty"`
+	}{
+		Schemas:  []string{SchemaError},
+		Status:   strconv.Itoa(e.Status),
+		ScimType: e.ScimType,
+		Detail:   e.Detail,
+	})
+}
+
+type Meta struct {
+	ResourceType string `json:"resourceType"`
+	Created      string `json:"created,omitempty"`
+	LastModified string `json:"lastModified,omitempty"`
+	Location     string `json:"location"`
+	Version      string `json:"version,omitempty"`
+}
+
+type ListResponse struct {
+	Schemas      []string         `json:"schemas"`
+	TotalResults int              `json:"totalResults"`
+	StartIndex   int              `json:"startIndex"`
+	ItemsPerPage int              `json:"itemsPerPage"`
+	Resources    []map[string]any `json:"Resources"`
+}
+
+func NewListResponse(resources []map[string]any, totalResults int, startIndex int) *ListResponse {
+	if resources == nil {
+		resources = []map[string]any{}
+	}
+	return &ListResponse{
+		Schemas:      []string{SchemaListResponse},
+		TotalResults: totalResults,
+		StartIndex:   startIndex,
+		ItemsPerPage: len(resources),
+		Resources:    resources,
+	}
+}
+
+// ToMap converts a resource to its generic JSON representation, which is what filters,
+// PATCH operations and attribute projections work on.
+func ToMap(resource any) (map[string]any, error) {
+	data, err := json.Marshal(resource)
+	if err != nil {
+		return nil, err
+	}
+	var m map[string]any
+	err = json.Unmarshal(data, &m)
+	if err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// ComputeVersion returns a weak entity tag for the resource. It is derived from the content of the
+// resource (excluding its meta attribute), so it changes whenever any returned attribute changes,
+// including group memberships.
+func ComputeVersion(resource map[string]any) string {
+	content := make(map[string]any, len(resource))
+	for key, value := range resource {
+		if !strings.EqualFold(key, "meta") {
+			content[key] = value
+		}
+	}
+	// encoding/json sorts map keys, so the output is stable
+	data, _ := json.Marshal(content)
+	hash := sha256.Sum256(data)
+	return `W/"` + hex.EncodeToString(hash[:8]) + `"`
+}
+
+// SetVersion computes the entity tag of the resource and stores it in meta.version.
+func SetVersion(resource map[string]any) string {
+	version := ComputeVersion(resource)
+	if meta, ok := resource["meta"].(map[string]any); ok {
+		meta["version"] = version
+	}
+	return version
+}
+
+// VersionMatches reports whether an If-Match or If-None-Match header value matches the version.
+func VersionMatches(header string, version string) bool {
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" {
+			return true
+		}
+		// weak comparison (RFC 7232, section 2.3.2)
+		if strings.TrimPrefix(tag, "W/") == strings.TrimPrefix(version, "W/") {
+			return true
+		}
+	}
+	return false
+}
+
+// ProjectAttributes applies the attributes and excludedAttributes parameters of a request
+// (RFC 7644, section 3.9) to the resource. The id and schemas attributes are always returned.
+func ProjectAttributes(resource map[string]any, attributes []string, excludedAttributes []string) map[string]any {
+
+	isAlwaysReturned := func(key string) bool {
+		return strings.EqualFold(key, "id") || strings.EqualFold(key, "schemas")
+	}
+
+	if len(attributes) > 0 {
+		result := map[string]any{}
+		for key, value := range resource {
+			if isAlwaysReturned(key) {
+				result[key] = value
+			}
+		}
+		for _, attribute := range attributes {
+			path := parseAttributePath(attribute)
+			key, ok := findKey(resource, path.attribute)
+			if !ok {
+				continue
+			}
+			if len(path.subAttribute) == 0 {
+				result[key] = resource[key]
+				continue
+			}
+			complexValue, ok := resource[key].(map[string]any)
+			if !ok {
+				continue
+			}
+			subKey, ok := findKey(complexValue, path.subAttribute)
+			if !ok {
+				continue
+			}
+			projected, ok := result[key].(map[string]any)
+			if !ok {
+				projected = map[string]any{}
+				result[key] = projected
+			}
+			projected[subKey] = complexValue[subKey]
+		}
+		return result
+	}
+
+	for _, attribute := range excludedAttributes {
+		path := parseAttributePath(attribute)
+		key, ok := findKey(resource, path.attribute)
+		if !ok || isAlwaysReturned(key) {
+			continue
+		}
+		if len(path.subAttribute) == 0 {
+			delete(resource, key)
+			continue
+		}
+		if complexValue, ok := resource[key].(map[string]any); ok {
+			if subKey, ok := findKey(complexValue, path.subAttribute); ok {
+				delete(complexValue, subKey)
+			}
+		}
+	}
+	return resource
+}
+
+// IsAttributeRequested reports whether the attribute will be part of the response, given the
+// attributes and excludedAttributes parameters of the request.
+func IsAttributeRequested(name string, attributes []string, excludedAttributes []string) bool {
+	if len(attributes) > 0 {
+		for _, attribute := range attributes {
+			if strings.EqualFold(parseAttributePath(attribute).attribute, name) {
+				return true
+			}
+		}
+		return false
+	}
+	for _, attribute := range excludedAttributes {
+		path := parseAttributePath(attribute)
+		if strings.EqualFold(path.attribute, name) && len(path.subAttribute) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// SplitAttributeList parses a comma separated list of attribute names.
+func SplitAttributeList(value string) []string {
+	result := []string{}
+	for _, name := range strings.Split(value, ",") {
+		if name = strings.TrimSpace(name); len(name) > 0 {
+			result = append(result, name)
+		}
+	}
+	return result
+}
+
+// findKey looks up a key in the map ignoring case, as attribute names are case insensitive.
+func findKey(m map[string]any, name string) (string, bool) {
+	if _, ok := m[name]; ok {
+		return name, true
+	}
+	for key := range m {
+		if strings.EqualFold(key, name) {
+			return key, true
+		}
+	}
+	return "", false
+}
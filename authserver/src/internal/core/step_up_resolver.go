@@ -0,0 +1,94 @@
+package core
+
+import (
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/leodip/goiabada/internal/data"
+	"github.com/leodip/goiabada/internal/enums"
+)
+
+type StepUpResolver struct {
+	database data.Database
+}
+
+func NewStepUpResolver(database data.Database) *StepUpResolver {
+	return &StepUpResolver{
+		database: database,
+	}
+}
+
+// StepUpRequirements are the authentication requirements of the resources and permissions in a scope.
+// An empty MinAcrLevel and a zero MaxAuthAgeInSeconds mean there's no requirement.
+type StepUpRequirements struct {
+	MinAcrLevel         enums.AcrLevel
+	MaxAuthAgeInSeconds int
+}
+
+// GetRequirements returns the strongest ACR level and the shortest authentication age required by the
+// resources and permissions in the scope. OpenID Connect scopes and unknown scopes are ignored.
+func (sr *StepUpResolver) GetRequirements(scope string) (*StepUpRequirements, error) {
+
+	requirements := &StepUpRequirements{}
+
+	space := regexp.MustCompile(`\s+`)
+	scope = strings.TrimSpace(space.ReplaceAllString(scope, " "))
+	if len(scope) == 0 {
+		return requirements, nil
+	}
+
+	for _, scopeStr := range strings.Split(scope, " ") {
+
+		if IsIdTokenScope(scopeStr) {
+			continue
+		}
+
+		parts := strings.Split(scopeStr, ":")
+		if len(parts) != 2 {
+			continue
+		}
+
+		resource, err := sr.database.GetResourceByResourceIdentifier(nil, parts[0])
+		if err != nil {
+			return nil, err
+		}
+		if resource == nil {
+			continue
+		}
+		requirements.add(resource.MinAcrLevel, resource.MaxAuthAgeInSeconds)
+
+		permissions, err := sr.database.GetPermissionsByResourceId(nil, resource.Id)
+		if err != nil {
+			return nil, err
+		}
+		for _, permission := range permissions {
+			if permission.PermissionIdentifier == parts[1] {
+				requirements.add(permission.MinAcrLevel, permission.MaxAuthAgeInSeconds)
+				break
+			}
+		}
+	}
+
+	return requirements, nil
+}
+
+func (req *StepUpRequirements) add(minAcrLevel enums.AcrLevel, maxAuthAgeInSeconds int) {
+	if len(minAcrLevel) > 0 && !req.MinAcrLevel.Satisfies(minAcrLevel) {
+		req.MinAcrLevel = minAcrLevel
+	}
+	if maxAuthAgeInSeconds > 0 && (req.MaxAuthAgeInSeconds == 0 || maxAuthAgeInSeconds < req.MaxAuthAgeInSeconds) {
+		req.MaxAuthAgeInSeconds = maxAuthAgeInSeconds
+	}
+}
+
+// IsSatisfiedBy returns true when an authentication at the ACR level, completed at authTime, meets the requirements.
+func (req *StepUpRequirements) IsSatisfiedBy(acrLevel enums.AcrLevel, authTime time.Time) bool {
+	if len(req.MinAcrLevel) > 0 && !acrLevel.Satisfies(req.MinAcrLevel) {
+		return false
+	}
+	if req.MaxAuthAgeInSeconds > 0 && time.Now().UTC().After(authTime.Add(time.Duration(req.MaxAuthAgeInSeconds)*time.Second)) {
+		return false
+	}
+	return true
+}
@@ -143,6 +143,7 @@ func (t *TokenIssuer) generateAccessToken(settings *entities.Settings, code *ent
 	claims["acr"] = code.AcrLevel
 	claims["amr"] = code.AuthMethods
 	claims["sid"] = code.SessionIdentifier
+	t.addActClaim(claims, code)
 
 	scopes := strings.Split(scope, " ")
 
@@ -262,6 +263,7 @@ func (t *TokenIssuer) generateIdToken(settings *entities.Settings, code *entitie
 	claims["acr"] = code.AcrLevel
 	claims["amr"] = code.AuthMethods
 	claims["sid"] = code.SessionIdentifier
+	t.addActClaim(claims, code)
 
 	scopes := strings.Split(scope, " ")
 
@@ -336,7 +338,10 @@ func (t *TokenIssuer) generateRefreshToken(settings *entities.Settings, code *en
 
 	scopes := strings.Split(scope, " ")
 
-	if slices.Contains(scopes, "offline_access") {
+	// impersonated sessions are time-boxed, so they never get offline refresh tokens
+	isOffline := slices.Contains(scopes, "offline_access") && len(code.ImpersonatorSubject) == 0
+
+	if isOffline {
 		// offline refresh token (not related to user session)
 		claims["typ"] = "Offline"
 
@@ -399,7 +404,7 @@ func (t *TokenIssuer) generateRefreshToken(settings *entities.Settings, code *en
 		refreshTokenEntity.FirstRefreshTokenJti = jti
 	}
 
-	if !slices.Contains(scopes, "offline_access") {
+	if !isOffline {
 		refreshTokenEntity.SessionIdentifier = claims["sid"].(string)
 	} else {
 		t := time.Unix(claims["offline_access_max_lifetime"].(int64), 0)
@@ -454,6 +459,9 @@ func (t *TokenIssuer) getRefreshTokenMaxLifetime(refreshTokenType string, now ti
 		}
 		maxLifetime := userSession.Started.Add(
 			time.Duration(time.Second * time.Duration(settings.UserSessionMaxLifetimeInSeconds))).Unix()
+		if userSession.IsImpersonated() && userSession.ImpersonationExpiresAt.Valid {
+			maxLifetime = min(maxLifetime, userSession.ImpersonationExpiresAt.Time.Unix())
+		}
 		return maxLifetime, nil
 	}
 	return 0, errors.WithStack(fmt.Errorf("invalid refresh token type: %v", refreshTokenType))
@@ -611,6 +619,15 @@ func (t *TokenIssuer) GenerateTokenResponseForRefresh(ctx context.Context, input
 	return &tokenResponse, nil
 }
 
+// addActClaim names the admin in the tokens of impersonated sessions (RFC 8693).
+func (tm *TokenIssuer) addActClaim(claims jwt.MapClaims, code *entities.Code) {
+	if len(code.ImpersonatorSubject) > 0 {
+		claims["act"] = map[string]interface{}{
+			"sub": code.ImpersonatorSubject,
+		}
+	}
+}
+
 func (tm *TokenIssuer) addOpenIdConnectClaims(claims jwt.MapClaims, code *entities.Code) {
 
 	scopes := strings.Split(code.Scope, " ")
@@ -1,6 +1,9 @@
 package core
 
 import (
+	"database/sql"
+	"time"
+
 	"github.com/google/uuid"
 	"github.com/leodip/goiabada/internal/constants"
 	"github.com/leodip/goiabada/internal/data"
@@ -30,6 +33,28 @@ type CreateUserInput struct {
 
 func (uc *UserCreator) CreateUser(ctx context.Context, input *CreateUserInput) (*entities.User, error) {
 
+	tx, err := uc.database.BeginTransaction()
+	if err != nil {
+		return nil, err
+	}
+	defer uc.database.RollbackTransaction(tx)
+
+	user, err := uc.CreateUserInTransaction(ctx, tx, input)
+	if err != nil {
+		return nil, err
+	}
+
+	err = uc.database.CommitTransaction(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// CreateUserInTransaction creates the user within the transaction of the caller, which commits it.
+func (uc *UserCreator) CreateUserInTransaction(ctx context.Context, tx *sql.Tx, input *CreateUserInput) (*entities.User, error) {
+
 	user := &entities.User{
 		Subject:       uuid.New(),
 		Enabled:       true,
@@ -40,13 +65,16 @@ func (uc *UserCreator) CreateUser(ctx context.Context, input *CreateUserInput) (
 		FamilyName:    input.FamilyName,
 		PasswordHash:  input.PasswordHash,
 	}
+	if len(user.PasswordHash) > 0 {
+		user.PasswordChangedAt = sql.NullTime{Time: time.Now().UTC(), Valid: true}
+	}
 
-	authServerResource, err := uc.database.GetResourceByResourceIdentifier(nil, constants.AuthServerResourceIdentifier)
+	authServerResource, err := uc.database.GetResourceByResourceIdentifier(tx, constants.AuthServerResourceIdentifier)
 	if err != nil {
 		return nil, err
 	}
 
-	permissions, err := uc.database.GetPermissionsByResourceId(nil, authServerResource.Id)
+	permissions, err := uc.database.GetPermissionsByResourceId(tx, authServerResource.Id)
 	if err != nil {
 		return nil, err
 	}
@@ -65,12 +93,6 @@ func (uc *UserCreator) CreateUser(ctx context.Context, input *CreateUserInput) (
 
 	user.Permissions = []entities.Permission{*accountPermission}
 
-	tx, err := uc.database.BeginTransaction()
-	if err != nil {
-		return nil, err
-	}
-	defer uc.database.RollbackTransaction(tx)
-
 	err = uc.database.CreateUser(tx, user)
 	if err != nil {
 		return nil, err
@@ -86,10 +108,5 @@ func (uc *UserCreator) CreateUser(ctx context.Context, input *CreateUserInput) (
 		}
 	}
 
-	err = uc.database.CommitTransaction(tx)
-	if err != nil {
-		return nil, err
-	}
-
 	return user, nil
 }
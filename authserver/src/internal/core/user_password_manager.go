@@ -0,0 +1,90 @@
+package core
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/leodip/goiabada/internal/common"
+	"github.com/leodip/goiabada/internal/data"
+	"github.com/leodip/goiabada/internal/entities"
+	"github.com/leodip/goiabada/internal/lib"
+)
+
+type UserPasswordManager struct {
+	database data.Database
+}
+
+func NewUserPasswordManager(database data.Database) *UserPasswordManager {
+	return &UserPasswordManager{
+		database: database,
+	}
+}
+
+// SetPassword hashes the new password of the user and resets the password age. When the password history
+// is enabled, the previous password of an existing user is kept in the history, which is trimmed to the
+// size configured in the settings. The user itself is not saved.
+func (m *UserPasswordManager) SetPassword(ctx context.Context, user *entities.User, password string) error {
+
+	settings := ctx.Value(common.ContextKeySettings).(*entities.Settings)
+
+	passwordHash, err := lib.HashPassword(password)
+	if err != nil {
+		return err
+	}
+
+	if user.Id > 0 {
+		err = m.updatePasswordHistory(user, settings.PasswordHistoryCount)
+		if err != nil {
+			return err
+		}
+	}
+
+	user.PasswordHash = passwordHash
+	user.PasswordChangedAt = sql.NullTime{Time: time.Now().UTC(), Valid: true}
+	user.PasswordBreached = false
+	return nil
+}
+
+// the current password is also part of the history, so only historyCount-1 previous passwords are kept
+func (m *UserPasswordManager) updatePasswordHistory(user *entities.User, historyCount int) error {
+
+	if historyCount > 1 && len(user.PasswordHash) > 0 {
+		err := m.database.CreateUserPasswordHistory(nil, &entities.UserPasswordHistory{
+			UserId:       user.Id,
+			PasswordHash: user.PasswordHash,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	userPasswordHistory, err := m.database.GetUserPasswordHistoryByUserId(nil, user.Id)
+	if err != nil {
+		return err
+	}
+
+	for i := max(historyCount-1, 0); i < len(userPasswordHistory); i++ {
+		err = m.database.DeleteUserPasswordHistory(nil, userPasswordHistory[i].Id)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetPasswordChangeAllowedAt returns when the user is allowed to change the password again, according to
+// the minimum password age. The time is zero when the password can be changed now.
+func (m *UserPasswordManager) GetPasswordChangeAllowedAt(ctx context.Context, user *entities.User) time.Time {
+
+	settings := ctx.Value(common.ContextKeySettings).(*entities.Settings)
+	if settings.PasswordMinAgeInDays <= 0 || !user.PasswordChangedAt.Valid {
+		return time.Time{}
+	}
+
+	allowedAt := user.PasswordChangedAt.Time.AddDate(0, 0, settings.PasswordMinAgeInDays)
+	if time.Now().UTC().After(allowedAt) {
+		return time.Time{}
+	}
+	return allowedAt
+}
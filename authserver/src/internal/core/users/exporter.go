@@ -0,0 +1,109 @@
+package core
+
+import (
+	"strings"
+
+	"github.com/leodip/goiabada/internal/data"
+	"github.com/leodip/goiabada/internal/entities"
+)
+
+type Exporter struct {
+	database data.Database
+}
+
+func NewExporter(database data.Database) *Exporter {
+	return &Exporter{
+		database: database,
+	}
+}
+
+// Export returns the users with their groups and attributes. When groupId is greater than zero, only
+// the members of the group are exported. Password hashes are never exported.
+func (e *Exporter) Export(groupId int64) ([]UserRecord, error) {
+
+	const pageSize = 100
+	records := []UserRecord{}
+
+	for page := 1; ; page++ {
+		var users []entities.User
+		var total int
+		var err error
+		if groupId > 0 {
+			users, total, err = e.database.GetGroupMembersPaginated(nil, groupId, page, pageSize)
+		} else {
+			users, total, err = e.database.SearchUsersPaginated(nil, "", page, pageSize)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		err = e.database.UsersLoadGroups(nil, users)
+		if err != nil {
+			return nil, err
+		}
+		for idx := range users {
+			attributes, err := e.database.GetUserAttributesByUserId(nil, users[idx].Id)
+			if err != nil {
+				return nil, err
+			}
+			records = append(records, newUserRecord(&users[idx], attributes))
+		}
+
+		if len(users) == 0 || page*pageSize >= total {
+			break
+		}
+	}
+	return records, nil
+}
+
+func newUserRecord(user *entities.User, attributes []entities.UserAttribute) UserRecord {
+	enabled := user.Enabled
+	record := UserRecord{
+		Email:               user.Email,
+		EmailVerified:       user.EmailVerified,
+		Enabled:             &enabled,
+		Username:            user.Username,
+		GivenName:           user.GivenName,
+		MiddleName:          user.MiddleName,
+		FamilyName:          user.FamilyName,
+		Nickname:            user.Nickname,
+		Website:             user.Website,
+		Gender:              user.Gender,
+		ZoneInfo:            user.ZoneInfo,
+		Locale:              user.Locale,
+		PhoneNumberVerified: user.PhoneNumberVerified,
+		AddressLine1:        user.AddressLine1,
+		AddressLine2:        user.AddressLine2,
+		AddressLocality:     user.AddressLocality,
+		AddressRegion:       user.AddressRegion,
+		AddressPostalCode:   user.AddressPostalCode,
+		AddressCountry:      user.AddressCountry,
+		Groups:              []string{},
+		Attributes:          []Attribute{},
+	}
+	if user.BirthDate.Valid {
+		record.BirthDate = user.BirthDate.Time.Format("2006-01-02")
+	}
+	// the phone number is stored with the country code, separated by a space
+	if len(user.PhoneNumber) > 0 {
+		country, number, found := strings.Cut(user.PhoneNumber, " ")
+		if found {
+			record.PhoneNumberCountry = country
+			record.PhoneNumber = number
+		} else {
+			record.PhoneNumber = user.PhoneNumber
+		}
+	}
+	for _, group := range user.Groups {
+		record.Groups = append(record.Groups, group.GroupIdentifier)
+	}
+	for _, attribute := range attributes {
+		record.Attributes = append(record.Attributes, Attribute{
+			Key:                  attribute.Key,
+			Value:                attribute.Value,
+			IncludeInIdToken:     attribute.IncludeInIdToken,
+			IncludeInAccessToken: attribute.IncludeInAccessToken,
+		})
+	}
+	return record
+}
@@ -0,0 +1,376 @@
+package core
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/leodip/goiabada/internal/core"
+	core_validators "github.com/leodip/goiabada/internal/core/validators"
+	"github.com/leodip/goiabada/internal/customerrors"
+	"github.com/leodip/goiabada/internal/data"
+	"github.com/leodip/goiabada/internal/entities"
+	"github.com/leodip/goiabada/internal/enums"
+	"github.com/leodip/goiabada/internal/lib"
+)
+
+const (
+	RowStatusCreated = "created"
+	RowStatusValid   = "valid"
+	RowStatusSkipped = "skipped"
+	RowStatusFailed  = "failed"
+)
+
+// ImportRow is the outcome of the import of a user. The rows are numbered from 1, without the
+// header of CSV files.
+type ImportRow struct {
+	Row    int
+	Email  string
+	Status string
+	Error  string
+}
+
+type ImportResult struct {
+	Rows    []ImportRow
+	Created int
+	Valid   int
+	Skipped int
+	Failed  int
+	// the users that were created, with the information needed to invite them and their groups
+	CreatedUsers []*entities.User
+}
+
+type Importer struct {
+	database            data.Database
+	userCreator         *core.UserCreator
+	profileValidator    *core_validators.ProfileValidator
+	emailValidator      *core_validators.EmailValidator
+	phoneValidator      *core_validators.PhoneValidator
+	addressValidator    *core_validators.AddressValidator
+	identifierValidator *core_validators.IdentifierValidator
+	inputSanitizer      *core.InputSanitizer
+}
+
+func NewImporter(database data.Database, userCreator *core.UserCreator, profileValidator *core_validators.ProfileValidator,
+	emailValidator *core_validators.EmailValidator, phoneValidator *core_validators.PhoneValidator,
+	addressValidator *core_validators.AddressValidator, identifierValidator *core_validators.IdentifierValidator,
+	inputSanitizer *core.InputSanitizer) *Importer {
+	return &Importer{
+		database:            database,
+		userCreator:         userCreator,
+		profileValidator:    profileValidator,
+		emailValidator:      emailValidator,
+		phoneValidator:      phoneValidator,
+		addressValidator:    addressValidator,
+		identifierValidator: identifierValidator,
+		inputSanitizer:      inputSanitizer,
+	}
+}
+
+// Import creates the users of the records. Each user is validated and created on its own, in its own
+// transaction, so an invalid record or a database error doesn't prevent the others from being imported,
+// and is reported in the row of the record. Users whose email address is already registered are skipped.
+// With dryRun, the records are only validated.
+func (i *Importer) Import(ctx context.Context, records []UserRecord, dryRun bool) (*ImportResult, error) {
+
+	result := &ImportResult{
+		Rows:         []ImportRow{},
+		CreatedUsers: []*entities.User{},
+	}
+
+	groups, err := i.database.GetAllGroups(nil)
+	if err != nil {
+		return nil, err
+	}
+	groupsByIdentifier := map[string]*entities.Group{}
+	for _, group := range groups {
+		groupsByIdentifier[group.GroupIdentifier] = group
+	}
+
+	emails := map[string]int{}
+	usernames := map[string]int{}
+
+	for idx := range records {
+		record := &records[idx]
+		row := ImportRow{
+			Row:   idx + 1,
+			Email: strings.ToLower(strings.TrimSpace(record.Email)),
+		}
+
+		user, userGroups, err := i.newUser(ctx, record, groupsByIdentifier)
+		if err == nil {
+			if previous, ok := emails[user.Email]; ok {
+				err = customerrors.NewValidationError("", fmt.Sprintf("The email address is repeated in the row %v.", previous))
+			} else if previous, ok := usernames[strings.ToLower(user.Username)]; ok && len(user.Username) > 0 {
+				err = customerrors.NewValidationError("", fmt.Sprintf("The username is repeated in the row %v.", previous))
+			}
+		}
+		if err != nil {
+			result.addFailedRow(row, err)
+			continue
+		}
+
+		existingUser, err := i.database.GetUserByEmail(nil, user.Email)
+		if err != nil {
+			result.addFailedRow(row, err)
+			continue
+		}
+		if existingUser != nil {
+			row.Status = RowStatusSkipped
+			row.Error = "A user with this email address already exists."
+			result.Skipped++
+			result.Rows = append(result.Rows, row)
+			continue
+		}
+
+		emails[user.Email] = row.Row
+		if len(user.Username) > 0 {
+			usernames[strings.ToLower(user.Username)] = row.Row
+		}
+
+		if dryRun {
+			row.Status = RowStatusValid
+			result.Valid++
+			result.Rows = append(result.Rows, row)
+			continue
+		}
+
+		err = i.createUser(ctx, user, userGroups)
+		if err != nil {
+			result.addFailedRow(row, err)
+			continue
+		}
+		row.Status = RowStatusCreated
+		result.Created++
+		result.Rows = append(result.Rows, row)
+		result.CreatedUsers = append(result.CreatedUsers, user)
+	}
+
+	return result, nil
+}
+
+// addFailedRow records the row as failed. Validation errors are reported as they are, and other errors
+// are logged, as they may contain details of the database.
+func (r *ImportResult) addFailedRow(row ImportRow, err error) {
+	row.Status = RowStatusFailed
+	if valErr, ok := err.(*customerrors.ValidationError); ok {
+		row.Error = valErr.Description
+	} else {
+		slog.Error(fmt.Sprintf("unable to import the user of row %v: %+v", row.Row, err))
+		row.Error = "An unexpected error has occurred, the user was not created. Please check the logs."
+	}
+	r.Failed++
+	r.Rows = append(r.Rows, row)
+}
+
+// newUser validates the record and returns the user that it represents, with its groups. The
+// attributes are set in user.Attributes.
+func (i *Importer) newUser(ctx context.Context, record *UserRecord,
+	groupsByIdentifier map[string]*entities.Group) (*entities.User, []*entities.Group, error) {
+
+	email := strings.ToLower(strings.TrimSpace(record.Email))
+	if len(email) == 0 {
+		return nil, nil, customerrors.NewValidationError("", "Please enter an email address.")
+	}
+	err := i.emailValidator.ValidateEmailAddress(ctx, email)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(email) > 60 {
+		return nil, nil, customerrors.NewValidationError("", "The email address cannot exceed a maximum length of 60 characters.")
+	}
+
+	// the validator expects the index of the gender
+	gender := ""
+	if len(record.Gender) > 0 {
+		gender = record.Gender
+		for g := int(enums.GenderFemale); g <= int(enums.GenderOther); g++ {
+			if strings.EqualFold(enums.Gender(g).String(), record.Gender) {
+				gender = strconv.Itoa(g)
+				break
+			}
+		}
+	}
+
+	zoneInfoCountryName := ""
+	for _, tz := range lib.GetTimeZones() {
+		if tz.Zone == record.ZoneInfo {
+			zoneInfoCountryName = tz.CountryName
+			break
+		}
+	}
+
+	profileInput := &core_validators.ValidateProfileInput{
+		Username:            strings.TrimSpace(record.Username),
+		GivenName:           strings.TrimSpace(record.GivenName),
+		MiddleName:          strings.TrimSpace(record.MiddleName),
+		FamilyName:          strings.TrimSpace(record.FamilyName),
+		Nickname:            strings.TrimSpace(record.Nickname),
+		Website:             strings.TrimSpace(record.Website),
+		Gender:              gender,
+		DateOfBirth:         strings.TrimSpace(record.BirthDate),
+		ZoneInfoCountryName: zoneInfoCountryName,
+		ZoneInfo:            record.ZoneInfo,
+		Locale:              record.Locale,
+	}
+	err = i.profileValidator.ValidateProfile(ctx, profileInput)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	phoneInput := &core_validators.ValidatePhoneInput{
+		PhoneNumberCountry: strings.TrimSpace(record.PhoneNumberCountry),
+		PhoneNumber:        strings.TrimSpace(record.PhoneNumber),
+	}
+	err = i.phoneValidator.ValidatePhone(ctx, phoneInput)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	addressInput := &core_validators.ValidateAddressInput{
+		AddressLine1:      strings.TrimSpace(record.AddressLine1),
+		AddressLine2:      strings.TrimSpace(record.AddressLine2),
+		AddressLocality:   strings.TrimSpace(record.AddressLocality),
+		AddressRegion:     strings.TrimSpace(record.AddressRegion),
+		AddressPostalCode: strings.TrimSpace(record.AddressPostalCode),
+		AddressCountry:    strings.TrimSpace(record.AddressCountry),
+	}
+	err = i.addressValidator.ValidateAddress(ctx, addressInput)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	passwordHash := strings.TrimSpace(record.PasswordHash)
+	if len(passwordHash) > 0 && !lib.IsSupportedPasswordHash(passwordHash) {
+		return nil, nil, customerrors.NewValidationError("", "The password hash is not in a supported format.")
+	}
+
+	userGroups := []*entities.Group{}
+	for _, groupIdentifier := range record.Groups {
+		group, ok := groupsByIdentifier[strings.TrimSpace(groupIdentifier)]
+		if !ok {
+			return nil, nil, customerrors.NewValidationError("", fmt.Sprintf("Group not found: %v.", groupIdentifier))
+		}
+		if !slices.Contains(userGroups, group) {
+			userGroups = append(userGroups, group)
+		}
+	}
+
+	attributes := []entities.UserAttribute{}
+	for _, attribute := range record.Attributes {
+		key := strings.TrimSpace(attribute.Key)
+		value := strings.TrimSpace(attribute.Value)
+		if len(key) == 0 {
+			return nil, nil, customerrors.NewValidationError("", "Attribute key is required.")
+		}
+		err = i.identifierValidator.ValidateIdentifier(key, false)
+		if err != nil {
+			return nil, nil, err
+		}
+		const maxLengthAttrValue = 250
+		if len(value) > maxLengthAttrValue {
+			return nil, nil, customerrors.NewValidationError("", "The attribute value cannot exceed a maximum length of "+
+				strconv.Itoa(maxLengthAttrValue)+" characters. Please make the value shorter.")
+		}
+		attributes = append(attributes, entities.UserAttribute{
+			Key:                  key,
+			Value:                i.inputSanitizer.Sanitize(value),
+			IncludeInIdToken:     attribute.IncludeInIdToken,
+			IncludeInAccessToken: attribute.IncludeInAccessToken,
+		})
+	}
+
+	user := &entities.User{
+		Enabled:             record.Enabled == nil || *record.Enabled,
+		Email:               i.inputSanitizer.Sanitize(email),
+		EmailVerified:       record.EmailVerified,
+		PasswordHash:        passwordHash,
+		Username:            i.inputSanitizer.Sanitize(profileInput.Username),
+		GivenName:           i.inputSanitizer.Sanitize(profileInput.GivenName),
+		MiddleName:          i.inputSanitizer.Sanitize(profileInput.MiddleName),
+		FamilyName:          i.inputSanitizer.Sanitize(profileInput.FamilyName),
+		Nickname:            i.inputSanitizer.Sanitize(profileInput.Nickname),
+		Website:             profileInput.Website,
+		ZoneInfoCountryName: zoneInfoCountryName,
+		ZoneInfo:            record.ZoneInfo,
+		Locale:              record.Locale,
+		AddressLine1:        i.inputSanitizer.Sanitize(addressInput.AddressLine1),
+		AddressLine2:        i.inputSanitizer.Sanitize(addressInput.AddressLine2),
+		AddressLocality:     i.inputSanitizer.Sanitize(addressInput.AddressLocality),
+		AddressRegion:       i.inputSanitizer.Sanitize(addressInput.AddressRegion),
+		AddressPostalCode:   i.inputSanitizer.Sanitize(addressInput.AddressPostalCode),
+		AddressCountry:      i.inputSanitizer.Sanitize(addressInput.AddressCountry),
+		Attributes:          attributes,
+	}
+	if len(gender) > 0 {
+		idx, _ := strconv.Atoi(gender)
+		user.Gender = enums.Gender(idx).String()
+	}
+	if len(profileInput.DateOfBirth) > 0 {
+		parsedTime, _ := time.Parse("2006-01-02", profileInput.DateOfBirth)
+		user.BirthDate = sql.NullTime{Time: parsedTime, Valid: true}
+	}
+	if len(phoneInput.PhoneNumber) > 0 {
+		user.PhoneNumber = i.inputSanitizer.Sanitize(fmt.Sprintf("%v %v", phoneInput.PhoneNumberCountry, phoneInput.PhoneNumber))
+		user.PhoneNumberVerified = record.PhoneNumberVerified
+	}
+	return user, userGroups, nil
+}
+
+// createUser creates the user with its groups and attributes in a transaction, so a failure doesn't leave
+// a partially imported user behind.
+func (i *Importer) createUser(ctx context.Context, user *entities.User, groups []*entities.Group) error {
+
+	tx, err := i.database.BeginTransaction()
+	if err != nil {
+		return err
+	}
+	defer i.database.RollbackTransaction(tx)
+
+	createdUser, err := i.userCreator.CreateUserInTransaction(ctx, tx, &core.CreateUserInput{
+		Email:         user.Email,
+		EmailVerified: user.EmailVerified,
+		PasswordHash:  user.PasswordHash,
+		GivenName:     user.GivenName,
+		MiddleName:    user.MiddleName,
+		FamilyName:    user.FamilyName,
+	})
+	if err != nil {
+		return err
+	}
+
+	user.Id = createdUser.Id
+	user.Subject = createdUser.Subject
+	user.CreatedAt = createdUser.CreatedAt
+	user.PasswordChangedAt = createdUser.PasswordChangedAt
+	err = i.database.UpdateUser(tx, user)
+	if err != nil {
+		return err
+	}
+
+	for _, group := range groups {
+		err = i.database.CreateUserGroup(tx, &entities.UserGroup{
+			UserId:  user.Id,
+			GroupId: group.Id,
+		})
+		if err != nil {
+			return err
+		}
+		user.Groups = append(user.Groups, *group)
+	}
+
+	for idx := range user.Attributes {
+		user.Attributes[idx].UserId = user.Id
+		err = i.database.CreateUserAttribute(tx, &user.Attributes[idx])
+		if err != nil {
+			return err
+		}
+	}
+
+	return i.database.CommitTransaction(tx)
+}
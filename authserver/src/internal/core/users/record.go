@@ -0,0 +1,348 @@
+package core
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/leodip/goiabada/internal/customerrors"
+	"github.com/pkg/errors"
+)
+
+// UserRecord is a user in an import or export file. In CSV files, the groups are separated by
+// semicolons and the attributes are written as key=value, also separated by semicolons.
+type UserRecord struct {
+	Email               string `json:"email"`
+	EmailVerified       bool   `json:"emailVerified"`
+	Enabled             *bool  `json:"enabled,omitempty"`
+	Username            string `json:"username,omitempty"`
+	GivenName           string `json:"givenName,omitempty"`
+	MiddleName          string `json:"middleName,omitempty"`
+	FamilyName          string `json:"familyName,omitempty"`
+	Nickname            string `json:"nickname,omitempty"`
+	Website             string `json:"website,omitempty"`
+	Gender              string `json:"gender,omitempty"`
+	BirthDate           string `json:"birthDate,omitempty"`
+	ZoneInfo            string `json:"zoneInfo,omitempty"`
+	Locale              string `json:"locale,omitempty"`
+	PhoneNumberCountry  string `json:"phoneNumberCountry,omitempty"`
+	PhoneNumber         string `json:"phoneNumber,omitempty"`
+	PhoneNumberVerified bool   `json:"phoneNumberVerified,omitempty"`
+	AddressLine1        string `json:"addressLine1,omitempty"`
+	AddressLine2        string `json:"addressLine2,omitempty"`
+	AddressLocality     string `json:"addressLocality,omitempty"`
+	AddressRegion       string `json:"addressRegion,omitempty"`
+	AddressPostalCode   string `json:"addressPostalCode,omitempty"`
+	AddressCountry      string `json:"addressCountry,omitempty"`
+	// a hash in one of the formats supported by lib.VerifyPasswordHash. It's never exported.
+	PasswordHash string      `json:"passwordHash,omitempty"`
+	Groups       []string    `json:"groups"`
+	Attributes   []Attribute `json:"attributes"`
+}
+
+type Attribute struct {
+	Key                  string `json:"key"`
+	Value                string `json:"value"`
+	IncludeInIdToken     bool   `json:"includeInIdToken"`
+	IncludeInAccessToken bool   `json:"includeInAccessToken"`
+}
+
+// Fields are the columns of the CSV files, in the order they are exported.
+var Fields = []string{
+	"email", "emailVerified", "enabled", "username", "givenName", "middleName", "familyName", "nickname",
+	"website", "gender", "birthDate", "zoneInfo", "locale", "phoneNumberCountry", "phoneNumber",
+	"phoneNumberVerified", "addressLine1", "addressLine2", "addressLocality", "addressRegion",
+	"addressPostalCode", "addressCountry", "passwordHash", "groups", "attributes",
+}
+
+func (u *UserRecord) getField(field string) string {
+	switch field {
+	case "email":
+		return u.Email
+	case "emailVerified":
+		return strconv.FormatBool(u.EmailVerified)
+	case "enabled":
+		return strconv.FormatBool(u.Enabled == nil || *u.Enabled)
+	case "username":
+		return u.Username
+	case "givenName":
+		return u.GivenName
+	case "middleName":
+		return u.MiddleName
+	case "familyName":
+		return u.FamilyName
+	case "nickname":
+		return u.Nickname
+	case "website":
+		return u.Website
+	case "gender":
+		return u.Gender
+	case "birthDate":
+		return u.BirthDate
+	case "zoneInfo":
+		return u.ZoneInfo
+	case "locale":
+		return u.Locale
+	case "phoneNumberCountry":
+		return u.PhoneNumberCountry
+	case "phoneNumber":
+		return u.PhoneNumber
+	case "phoneNumberVerified":
+		return strconv.FormatBool(u.PhoneNumberVerified)
+	case "addressLine1":
+		return u.AddressLine1
+	case "addressLine2":
+		return u.AddressLine2
+	case "addressLocality":
+		return u.AddressLocality
+	case "addressRegion":
+		return u.AddressRegion
+	case "addressPostalCode":
+		return u.AddressPostalCode
+	case "addressCountry":
+		return u.AddressCountry
+	case "passwordHash":
+		return u.PasswordHash
+	case "groups":
+		return strings.Join(u.Groups, ";")
+	case "attributes":
+		attributes := []string{}
+		for _, attribute := range u.Attributes {
+			attributes = append(attributes, attribute.Key+"="+attribute.Value)
+		}
+		return strings.Join(attributes, ";")
+	}
+	return ""
+}
+
+func (u *UserRecord) setField(field string, value string) error {
+	value = strings.TrimSpace(value)
+	var err error
+	switch field {
+	case "email":
+		u.Email = value
+	case "emailVerified":
+		u.EmailVerified, err = parseBool(value, field)
+	case "enabled":
+		if len(value) > 0 {
+			enabled, parseErr := parseBool(value, field)
+			u.Enabled, err = &enabled, parseErr
+		}
+	case "username":
+		u.Username = value
+	case "givenName":
+		u.GivenName = value
+	case "middleName":
+		u.MiddleName = value
+	case "familyName":
+		u.FamilyName = value
+	case "nickname":
+		u.Nickname = value
+	case "website":
+		u.Website = value
+	case "gender":
+		u.Gender = value
+	case "birthDate":
+		u.BirthDate = value
+	case "zoneInfo":
+		u.ZoneInfo = value
+	case "locale":
+		u.Locale = value
+	case "phoneNumberCountry":
+		u.PhoneNumberCountry = value
+	case "phoneNumber":
+		u.PhoneNumber = value
+	case "phoneNumberVerified":
+		u.PhoneNumberVerified, err = parseBool(value, field)
+	case "addressLine1":
+		u.AddressLine1 = value
+	case "addressLine2":
+		u.AddressLine2 = value
+	case "addressLocality":
+		u.AddressLocality = value
+	case "addressRegion":
+		u.AddressRegion = value
+	case "addressPostalCode":
+		u.AddressPostalCode = value
+	case "addressCountry":
+		u.AddressCountry = value
+	case "passwordHash":
+		u.PasswordHash = value
+	case "groups":
+		for _, group := range strings.Split(value, ";") {
+			if group = strings.TrimSpace(group); len(group) > 0 {
+				u.Groups = append(u.Groups, group)
+			}
+		}
+	case "attributes":
+		for _, attribute := range strings.Split(value, ";") {
+			if len(strings.TrimSpace(attribute)) == 0 {
+				continue
+			}
+			key, attributeValue, found := strings.Cut(attribute, "=")
+			if !found {
+				return customerrors.NewValidationError("", fmt.Sprintf("Invalid attribute %v. The format is key=value.", strings.TrimSpace(attribute)))
+			}
+			// as in the admin area, new attributes are included in the tokens
+			u.Attributes = append(u.Attributes, Attribute{
+				Key:                  strings.TrimSpace(key),
+				Value:                strings.TrimSpace(attributeValue),
+				IncludeInIdToken:     true,
+				IncludeInAccessToken: true,
+			})
+		}
+	}
+	return err
+}
+
+func parseBool(value string, field string) (bool, error) {
+	switch strings.ToLower(value) {
+	case "", "false", "0", "no", "n":
+		return false, nil
+	case "true", "1", "yes", "y":
+		return true, nil
+	}
+	return false, customerrors.NewValidationError("", fmt.Sprintf("Invalid value for %v: %v. Use true or false.", field, value))
+}
+
+// ParseColumnMapping reads a column mapping with one "column=field" entry per line, or separated by
+// commas. The columns that are not mapped are matched with the fields by name.
+func ParseColumnMapping(mapping string) (map[string]string, error) {
+	result := map[string]string{}
+	entries := strings.FieldsFunc(mapping, func(r rune) bool {
+		return r == '\n' || r == ','
+	})
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if len(entry) == 0 {
+			continue
+		}
+		column, field, found := strings.Cut(entry, "=")
+		column = strings.TrimSpace(column)
+		field = getFieldName(strings.TrimSpace(field))
+		if !found || len(column) == 0 || len(field) == 0 {
+			return nil, customerrors.NewValidationError("", fmt.Sprintf("Invalid column mapping: %v. The format is column=field, and the fields are: %v.",
+				entry, strings.Join(Fields, ", ")))
+		}
+		result[strings.ToLower(column)] = field
+	}
+	return result, nil
+}
+
+// getFieldName returns the name of the field, ignoring the case, or an empty string.
+func getFieldName(name string) string {
+	for _, field := range Fields {
+		if strings.EqualFold(field, name) {
+			return field
+		}
+	}
+	return ""
+}
+
+// ReadRecords reads the users of a CSV or JSON file. A JSON file holds an array of users. The first
+// line of a CSV file has the names of the columns, which are mapped to the fields with the column
+// mapping, or by name. The columns that don't match a field are returned, and ignored.
+func ReadRecords(data []byte, columnMapping map[string]string) ([]UserRecord, []string, error) {
+
+	trimmed := bytes.TrimSpace(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf")))
+	if len(trimmed) == 0 {
+		return nil, nil, customerrors.NewValidationError("", "The file is empty.")
+	}
+
+	if trimmed[0] == '[' {
+		records := []UserRecord{}
+		decoder := json.NewDecoder(bytes.NewReader(trimmed))
+		decoder.DisallowUnknownFields()
+		err := decoder.Decode(&records)
+		if err != nil {
+			return nil, nil, customerrors.NewValidationError("", fmt.Sprintf("Unable to parse the JSON file: %v.", err))
+		}
+		return records, []string{}, nil
+	}
+
+	reader := csv.NewReader(bytes.NewReader(trimmed))
+	reader.FieldsPerRecord = -1
+	header, err := reader.Read()
+	if err != nil {
+		return nil, nil, customerrors.NewValidationError("", fmt.Sprintf("Unable to parse the CSV file: %v.", err))
+	}
+
+	fields := make([]string, len(header))
+	ignoredColumns := []string{}
+	emailFound := false
+	for idx, column := range header {
+		column = strings.TrimSpace(column)
+		field, ok := columnMapping[strings.ToLower(column)]
+		if !ok {
+			field = getFieldName(column)
+		}
+		if len(field) == 0 {
+			ignoredColumns = append(ignoredColumns, column)
+		}
+		if field == "email" {
+			emailFound = true
+		}
+		fields[idx] = field
+	}
+	if !emailFound {
+		return nil, nil, customerrors.NewValidationError("", "The CSV file must have a column with the email address of the users.")
+	}
+
+	records := []UserRecord{}
+	for {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, customerrors.NewValidationError("", fmt.Sprintf("Unable to parse the CSV file: %v.", err))
+		}
+		record := UserRecord{}
+		for idx, value := range row {
+			if idx >= len(fields) || len(fields[idx]) == 0 {
+				continue
+			}
+			err = record.setField(fields[idx], value)
+			if err != nil {
+				line, _ := reader.FieldPos(0)
+				return nil, nil, customerrors.NewValidationError("", fmt.Sprintf("Line %v: %v", line,
+					err.(*customerrors.ValidationError).Description))
+			}
+		}
+		records = append(records, record)
+	}
+	return records, ignoredColumns, nil
+}
+
+// WriteRecords writes the users in the CSV or JSON format. The files can be imported again.
+func WriteRecords(w io.Writer, records []UserRecord, format string) error {
+	switch format {
+	case "json":
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return errors.WithStack(encoder.Encode(records))
+	case "csv":
+		writer := csv.NewWriter(w)
+		err := writer.Write(Fields)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		for idx := range records {
+			row := make([]string, len(Fields))
+			for i, field := range Fields {
+				row[i] = records[idx].getField(field)
+			}
+			err = writer.Write(row)
+			if err != nil {
+				return errors.WithStack(err)
+			}
+		}
+		writer.Flush()
+		return errors.WithStack(writer.Error())
+	}
+	return customerrors.NewValidationError("", fmt.Sprintf("Invalid format %v. Use csv or json.", format))
+}
@@ -3,74 +3,125 @@ package core
 import (
 	"context"
 	"fmt"
+	"strings"
 	"unicode"
 
 	"github.com/leodip/goiabada/internal/common"
+	"github.com/leodip/goiabada/internal/core"
 	"github.com/leodip/goiabada/internal/customerrors"
+	"github.com/leodip/goiabada/internal/data"
 	"github.com/leodip/goiabada/internal/entities"
-	"github.com/leodip/goiabada/internal/enums"
+	"github.com/leodip/goiabada/internal/lib"
 )
 
 type PasswordValidator struct {
+	database                data.Database
+	breachedPasswordChecker *core.BreachedPasswordChecker
 }
 
-func NewPasswordValidator() *PasswordValidator {
-	return &PasswordValidator{}
+func NewPasswordValidator(database data.Database, breachedPasswordChecker *core.BreachedPasswordChecker) *PasswordValidator {
+	return &PasswordValidator{
+		database:                database,
+		breachedPasswordChecker: breachedPasswordChecker,
+	}
 }
 
-func (val *PasswordValidator) ValidatePassword(ctx context.Context, password string) error {
+// ValidatePassword checks the password against the password policy in the settings. The user is the one
+// that will have the password: it can be a new user, not yet saved, or nil when unknown. For an existing
+// user the password history is also checked.
+func (val *PasswordValidator) ValidatePassword(ctx context.Context, password string, user *entities.User) error {
 	settings := ctx.Value(common.ContextKeySettings).(*entities.Settings)
 
-	minLength := 1
-	maxLength := 64
-	mustIncludeLowerCase := false
-	mustIncludeUpperCase := false
-	mustIncludeANumber := false
-	mustIncludeASpecialChar := false
-
-	switch settings.PasswordPolicy {
-	case enums.PasswordPolicyLow:
-		minLength = 6
-	case enums.PasswordPolicyMedium:
-		minLength = 8
-		mustIncludeLowerCase = true
-		mustIncludeUpperCase = true
-		mustIncludeANumber = true
-	case enums.PasswordPolicyHigh:
-		minLength = 10
-		mustIncludeLowerCase = true
-		mustIncludeUpperCase = true
-		mustIncludeANumber = true
-		mustIncludeASpecialChar = true
-	}
-
-	if len(password) < minLength {
-		return customerrors.NewValidationError("", fmt.Sprintf("The minimum length for the password is %v characters", minLength))
+	if len(password) < settings.PasswordMinLength {
+		return customerrors.NewValidationError("", fmt.Sprintf("The minimum length for the password is %v characters", settings.PasswordMinLength))
 	}
 
-	if len(password) > maxLength {
-		return customerrors.NewValidationError("", fmt.Sprintf("The maximum length for the password is %v characters", maxLength))
+	if len(password) > settings.PasswordMaxLength {
+		return customerrors.NewValidationError("", fmt.Sprintf("The maximum length for the password is %v characters", settings.PasswordMaxLength))
 	}
 
-	if mustIncludeLowerCase && !val.containsLowerCase(password) {
+	if settings.PasswordRequiresLowercase && !val.containsLowerCase(password) {
 		return customerrors.NewValidationError("", "As per our policy, a lowercase character is required in the password.")
 	}
 
-	if mustIncludeUpperCase && !val.containsUpperCase(password) {
+	if settings.PasswordRequiresUppercase && !val.containsUpperCase(password) {
 		return customerrors.NewValidationError("", "As per our policy, an uppercase character is required in the password.")
 	}
 
-	if mustIncludeANumber && !val.containsNumber(password) {
+	if settings.PasswordRequiresNumber && !val.containsNumber(password) {
 		return customerrors.NewValidationError("", "As per our policy, your password must contain a numerical digit.")
 	}
 
-	if mustIncludeASpecialChar && !val.containsSpecialChar(password) {
+	if settings.PasswordRequiresSpecialChar && !val.containsSpecialChar(password) {
 		return customerrors.NewValidationError("", "As per our policy, a special character/symbol is required in the password.")
 	}
 
+	if settings.PasswordDisallowUsernameOrEmail && user != nil && val.containsUsernameOrEmail(password, user) {
+		return customerrors.NewValidationError("", "As per our policy, the password can't contain your username or email address.")
+	}
+
+	if settings.PasswordHistoryCount > 0 && user != nil && user.Id > 0 {
+		reused, err := val.isInPasswordHistory(password, user, settings.PasswordHistoryCount)
+		if err != nil {
+			return err
+		}
+		if reused {
+			if settings.PasswordHistoryCount == 1 {
+				return customerrors.NewValidationError("", "As per our policy, the new password must be different from the current password.")
+			}
+			return customerrors.NewValidationError("", fmt.Sprintf("As per our policy, you can't reuse any of your last %v passwords.", settings.PasswordHistoryCount))
+		}
+	}
+
+	if settings.RejectBreachedPasswords {
+		breached, err := val.breachedPasswordChecker.IsBreached(password)
+		if err != nil {
+			return err
+		}
+		if breached {
+			return customerrors.NewValidationError("", "This password has appeared in a data breach and can't be used. Please choose a different password.")
+		}
+	}
+
 	return nil
 }
 
+// the parts of the username and email address shorter than this are not checked
+const minUserInfoLengthToCheck = 3
+
+func (val *PasswordValidator) containsUsernameOrEmail(password string, user *entities.User) bool {
+	password = strings.ToLower(password)
+
+	email := strings.ToLower(user.Email)
+	localPart, _, _ := strings.Cut(email, "@")
+
+	for _, userInfo := range []string{strings.ToLower(user.Username), email, localPart} {
+		if len(userInfo) >= minUserInfoLengthToCheck && strings.Contains(password, userInfo) {
+			return true
+		}
+	}
+	return false
+}
+
+// isInPasswordHistory checks the current password and the previous ones, up to historyCount passwords.
+func (val *PasswordValidator) isInPasswordHistory(password string, user *entities.User, historyCount int) (bool, error) {
+	if len(user.PasswordHash) > 0 && lib.VerifyPasswordHash(user.PasswordHash, password) {
+		return true, nil
+	}
+
+	userPasswordHistory, err := val.database.GetUserPasswordHistoryByUserId(nil, user.Id)
+	if err != nil {
+		return false, err
+	}
+
+	for i := 0; i < len(userPasswordHistory) && i < historyCount-1; i++ {
+		if lib.VerifyPasswordHash(userPasswordHistory[i].PasswordHash, password) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
 func (val *PasswordValidator) containsLowerCase(s string) bool {
 	for _, char := range s {
 		if unicode.IsLower(char) {
@@ -65,7 +65,8 @@ func (val *ProfileValidator) ValidateProfile(ctx context.Context, input *Validat
 			return err
 		}
 
-		if userByUsername != nil && userByUsername.Subject != user.Subject {
+		// the user is nil when the username of a new user is validated
+		if userByUsername != nil && (user == nil || userByUsername.Subject != user.Subject) {
 			return customerrors.NewValidationError("", "Sorry, this username is already taken.")
 		}
 
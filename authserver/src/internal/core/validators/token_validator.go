@@ -17,6 +17,7 @@ import (
 	"github.com/leodip/goiabada/internal/data"
 	"github.com/leodip/goiabada/internal/dtos"
 	"github.com/leodip/goiabada/internal/entities"
+	"github.com/leodip/goiabada/internal/enums"
 	"github.com/leodip/goiabada/internal/lib"
 )
 
@@ -24,14 +25,16 @@ type TokenValidator struct {
 	database          data.Database
 	tokenParser       *core_token.TokenParser
 	permissionChecker *core.PermissionChecker
+	stepUpResolver    *core.StepUpResolver
 }
 
 func NewTokenValidator(database data.Database, tokenParser *core_token.TokenParser,
-	permissionChecker *core.PermissionChecker) *TokenValidator {
+	permissionChecker *core.PermissionChecker, stepUpResolver *core.StepUpResolver) *TokenValidator {
 	return &TokenValidator{
 		database:          database,
 		tokenParser:       tokenParser,
 		permissionChecker: permissionChecker,
+		stepUpResolver:    stepUpResolver,
 	}
 }
 
@@ -124,12 +127,16 @@ func (val *TokenValidator) ValidateTokenRequest(ctx context.Context, input *Vali
 		}
 
 		if !codeEntity.User.Enabled {
-			lib.LogAudit(constants.AuditUserDisabled, map[string]interface{}{
+			lib.LogAudit(ctx, constants.AuditUserDisabled, map[string]interface{}{
 				"userId": codeEntity.User.Id,
 			})
 			return nil, customerrors.NewValidationError("invalid_grant", "The user account is disabled.")
 		}
 
+		if len(codeEntity.ImpersonatorSubject) > 0 && !codeEntity.Client.AcceptImpersonatedTokens {
+			return nil, customerrors.NewValidationError("invalid_grant", "The client does not accept impersonated tokens.")
+		}
+
 		const authCodeExpirationInSeconds = 60
 		if time.Now().UTC().After(codeEntity.CreatedAt.Time.Add(time.Second * time.Duration(authCodeExpirationInSeconds))) {
 			// code has expired
@@ -276,6 +283,14 @@ func (val *TokenValidator) ValidateTokenRequest(ctx context.Context, input *Vali
 			return nil, customerrors.NewValidationError("invalid_grant", "The user account is disabled.")
 		}
 
+		if len(refreshToken.Code.ImpersonatorSubject) > 0 && !client.AcceptImpersonatedTokens {
+			return nil, customerrors.NewValidationError("invalid_grant", "The client does not accept impersonated tokens.")
+		}
+
+		// the authentication behind the refresh token, checked against the step-up requirements of the scopes
+		authAcrLevel := refreshToken.Code.AcrLevel
+		authTime := refreshToken.Code.AuthenticatedAt
+
 		refreshTokenType := refreshTokenInfo.GetStringClaim("typ")
 		switch refreshTokenType {
 		case "Refresh":
@@ -294,6 +309,8 @@ func (val *TokenValidator) ValidateTokenRequest(ctx context.Context, input *Vali
 			if !isSessionValid {
 				return nil, customerrors.NewValidationError("invalid_grant", invalidTokenMessage)
 			}
+			authAcrLevel = userSession.AcrLevel
+			authTime = userSession.AuthTime
 		case "Offline":
 			// this is an offline refresh token
 			// its lifetime is not linked to the user session
@@ -386,6 +403,18 @@ func (val *TokenValidator) ValidateTokenRequest(ctx context.Context, input *Vali
 						customerrors.NewValidationError("invalid_grant",
 							fmt.Sprintf("Scope '%v' is not recognized. The user does not have the '%v' permission.", inputScopeStr, inputScopeStr))
 				}
+
+				// check if the authentication still meets the requirements of the scope
+				stepUpRequirements, err := val.stepUpResolver.GetRequirements(inputScopeStr)
+				if err != nil {
+					return nil, err
+				}
+				acrLevel, _ := enums.AcrLevelFromString(authAcrLevel)
+				if !stepUpRequirements.IsSatisfiedBy(acrLevel, authTime) {
+					return nil,
+						customerrors.NewValidationError("invalid_grant",
+							fmt.Sprintf("Scope '%v' requires a stronger or more recent authentication than the one of the refresh token. Please authenticate the user again.", inputScopeStr))
+				}
 			}
 		}
 
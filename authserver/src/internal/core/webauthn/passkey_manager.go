@@ -0,0 +1,349 @@
+package core
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/webauthn"
+	"github.com/google/uuid"
+	"github.com/leodip/goiabada/internal/common"
+	"github.com/leodip/goiabada/internal/data"
+	"github.com/leodip/goiabada/internal/entities"
+	"github.com/leodip/goiabada/internal/lib"
+	"github.com/pkg/errors"
+)
+
+// ErrPasskeyVerificationFailed is returned when the response of the authenticator is not accepted,
+// for example because the signature is invalid or the ceremony has expired.
+var ErrPasskeyVerificationFailed = errors.New("passkey verification failed")
+
+const ceremonyTimeout = 5 * time.Minute
+
+type PasskeyManager struct {
+	database data.Database
+}
+
+func NewPasskeyManager(database data.Database) *PasskeyManager {
+	return &PasskeyManager{
+		database: database,
+	}
+}
+
+// passkeyUser adapts a user (with the passkeys loaded) to the user of the WebAuthn library.
+// The user handle is the subject of the user, which is random and never changes.
+type passkeyUser struct {
+	user *entities.User
+}
+
+func (u *passkeyUser) WebAuthnID() []byte {
+	return u.user.Subject[:]
+}
+
+func (u *passkeyUser) WebAuthnName() string {
+	return u.user.Email
+}
+
+func (u *passkeyUser) WebAuthnDisplayName() string {
+	if fullName := u.user.GetFullName(); len(fullName) > 0 {
+		return fullName
+	}
+	return u.user.Email
+}
+
+func (u *passkeyUser) WebAuthnIcon() string {
+	return ""
+}
+
+func (u *passkeyUser) WebAuthnCredentials() []webauthn.Credential {
+	credentials := make([]webauthn.Credential, 0, len(u.user.Passkeys))
+	for _, passkey := range u.user.Passkeys {
+		transports := []protocol.AuthenticatorTransport{}
+		for _, transport := range strings.Fields(passkey.Transports) {
+			transports = append(transports, protocol.AuthenticatorTransport(transport))
+		}
+		credentials = append(credentials, webauthn.Credential{
+			ID:              passkey.CredentialId,
+			PublicKey:       passkey.PublicKey,
+			AttestationType: passkey.AttestationType,
+			Transport:       transports,
+			Flags: webauthn.CredentialFlags{
+				BackupEligible: passkey.BackupEligible,
+				BackupState:    passkey.BackupState,
+			},
+			Authenticator: webauthn.Authenticator{
+				AAGUID:    passkey.AAGUID,
+				SignCount: uint32(passkey.SignCount),
+			},
+		})
+	}
+	return credentials
+}
+
+func (pm *PasskeyManager) newRelyingParty(ctx context.Context) (*webauthn.WebAuthn, error) {
+
+	settings := ctx.Value(common.ContextKeySettings).(*entities.Settings)
+
+	baseUrl, err := url.Parse(lib.GetBaseUrl())
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	// the relying party id is the host name, and the origin must match the base URL
+	relyingParty, err := webauthn.New(&webauthn.Config{
+		RPID:                  baseUrl.Hostname(),
+		RPDisplayName:         settings.AppName,
+		RPOrigins:             []string{baseUrl.Scheme + "://" + baseUrl.Host},
+		AttestationPreference: protocol.PreferNoAttestation,
+		Timeouts: webauthn.TimeoutsConfig{
+			Login: webauthn.TimeoutConfig{
+				Enforce: true,
+				Timeout: ceremonyTimeout,
+			},
+			Registration: webauthn.TimeoutConfig{
+				Enforce: true,
+				Timeout: ceremonyTimeout,
+			},
+		},
+	})
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return relyingParty, nil
+}
+
+func marshalCeremony(sessionData *webauthn.SessionData) (string, error) {
+	ceremony, err := json.Marshal(sessionData)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	return string(ceremony), nil
+}
+
+func unmarshalCeremony(ceremony string) (*webauthn.SessionData, error) {
+	var sessionData webauthn.SessionData
+	err := json.Unmarshal([]byte(ceremony), &sessionData)
+	if err != nil {
+		return nil, fmt.Errorf("%w: the ceremony state is invalid", ErrPasskeyVerificationFailed)
+	}
+	return &sessionData, nil
+}
+
+func verificationFailed(err error) error {
+	if protocolErr, ok := err.(*protocol.Error); ok {
+		return fmt.Errorf("%w: %v %v", ErrPasskeyVerificationFailed, protocolErr.Details, protocolErr.DevInfo)
+	}
+	return fmt.Errorf("%w: %v", ErrPasskeyVerificationFailed, err)
+}
+
+// BeginRegistration returns the options for navigator.credentials.create(), and the state of the
+// ceremony, which must be kept (in the session) until FinishRegistration. The passkeys of the user
+// must be loaded, so they are excluded from the registration.
+func (pm *PasskeyManager) BeginRegistration(ctx context.Context, user *entities.User) (*protocol.CredentialCreation, string, error) {
+
+	relyingParty, err := pm.newRelyingParty(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+
+	webAuthnUser := &passkeyUser{user: user}
+	exclusions := []protocol.CredentialDescriptor{}
+	for _, credential := range webAuthnUser.WebAuthnCredentials() {
+		exclusions = append(exclusions, credential.Descriptor())
+	}
+
+	// passkeys are discoverable credentials, so they can also be used without the email
+	options, sessionData, err := relyingParty.BeginRegistration(webAuthnUser,
+		webauthn.WithExclusions(exclusions),
+		webauthn.WithResidentKeyRequirement(protocol.ResidentKeyRequirementRequired))
+	if err != nil {
+		return nil, "", errors.WithStack(err)
+	}
+
+	ceremony, err := marshalCeremony(sessionData)
+	if err != nil {
+		return nil, "", err
+	}
+	return options, ceremony, nil
+}
+
+// FinishRegistration verifies the response of navigator.credentials.create() and stores the new passkey.
+func (pm *PasskeyManager) FinishRegistration(ctx context.Context, user *entities.User, ceremony string,
+	name string, r *http.Request) (*entities.UserPasskey, error) {
+
+	relyingParty, err := pm.newRelyingParty(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	sessionData, err := unmarshalCeremony(ceremony)
+	if err != nil {
+		return nil, err
+	}
+
+	credential, err := relyingParty.FinishRegistration(&passkeyUser{user: user}, *sessionData, r)
+	if err != nil {
+		return nil, verificationFailed(err)
+	}
+
+	transports := []string{}
+	for _, transport := range credential.Transport {
+		transports = append(transports, string(transport))
+	}
+
+	userPasskey := &entities.UserPasskey{
+		UserId:          user.Id,
+		Name:            name,
+		CredentialId:    credential.ID,
+		PublicKey:       credential.PublicKey,
+		AttestationType: credential.AttestationType,
+		Transports:      strings.Join(transports, " "),
+		AAGUID:          credential.Authenticator.AAGUID,
+		SignCount:       int64(credential.Authenticator.SignCount),
+		BackupEligible:  credential.Flags.BackupEligible,
+		BackupState:     credential.Flags.BackupState,
+	}
+	err = pm.database.CreateUserPasskey(nil, userPasskey)
+	if err != nil {
+		return nil, err
+	}
+	return userPasskey, nil
+}
+
+// BeginLogin starts an authentication ceremony with one of the passkeys of the user (second factor).
+// The passkeys of the user must be loaded.
+func (pm *PasskeyManager) BeginLogin(ctx context.Context, user *entities.User) (*protocol.CredentialAssertion, string, error) {
+
+	relyingParty, err := pm.newRelyingParty(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+
+	options, sessionData, err := relyingParty.BeginLogin(&passkeyUser{user: user})
+	if err != nil {
+		return nil, "", errors.WithStack(err)
+	}
+
+	ceremony, err := marshalCeremony(sessionData)
+	if err != nil {
+		return nil, "", err
+	}
+	return options, ceremony, nil
+}
+
+// FinishLogin verifies the response of navigator.credentials.get() for a ceremony started with BeginLogin.
+func (pm *PasskeyManager) FinishLogin(ctx context.Context, user *entities.User, ceremony string, r *http.Request) error {
+
+	relyingParty, err := pm.newRelyingParty(ctx)
+	if err != nil {
+		return err
+	}
+
+	sessionData, err := unmarshalCeremony(ceremony)
+	if err != nil {
+		return err
+	}
+
+	credential, err := relyingParty.FinishLogin(&passkeyUser{user: user}, *sessionData, r)
+	if err != nil {
+		return verificationFailed(err)
+	}
+
+	return pm.updateSignCount(user, credential)
+}
+
+// BeginDiscoverableLogin starts an authentication ceremony where the user is not known in advance
+// (passwordless). The authenticator must verify the user (PIN, biometrics).
+func (pm *PasskeyManager) BeginDiscoverableLogin(ctx context.Context) (*protocol.CredentialAssertion, string, error) {
+
+	relyingParty, err := pm.newRelyingParty(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+
+	options, sessionData, err := relyingParty.BeginDiscoverableLogin(
+		webauthn.WithUserVerification(protocol.VerificationRequired))
+	if err != nil {
+		return nil, "", errors.WithStack(err)
+	}
+
+	ceremony, err := marshalCeremony(sessionData)
+	if err != nil {
+		return nil, "", err
+	}
+	return options, ceremony, nil
+}
+
+// FinishDiscoverableLogin verifies the response of navigator.credentials.get() for a ceremony started
+// with BeginDiscoverableLogin, and returns the user that owns the passkey.
+func (pm *PasskeyManager) FinishDiscoverableLogin(ctx context.Context, ceremony string, r *http.Request) (*entities.User, error) {
+
+	relyingParty, err := pm.newRelyingParty(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	sessionData, err := unmarshalCeremony(ceremony)
+	if err != nil {
+		return nil, err
+	}
+
+	var user *entities.User
+	findUser := func(rawID, userHandle []byte) (webauthn.User, error) {
+		subject, err := uuid.FromBytes(userHandle)
+		if err != nil {
+			return nil, err
+		}
+		user, err = pm.database.GetUserBySubject(nil, subject.String())
+		if err != nil {
+			return nil, err
+		}
+		if user == nil {
+			return nil, errors.New("the passkey does not belong to any user")
+		}
+		err = pm.database.UserLoadPasskeys(nil, user)
+		if err != nil {
+			return nil, err
+		}
+		return &passkeyUser{user: user}, nil
+	}
+
+	credential, err := relyingParty.FinishDiscoverableLogin(findUser, *sessionData, r)
+	if err != nil {
+		return nil, verificationFailed(err)
+	}
+
+	err = pm.updateSignCount(user, credential)
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// updateSignCount stores the signature counter of the passkey that was used. When the counter
+// didn't increase the authenticator might have been cloned, and the authentication is rejected.
+func (pm *PasskeyManager) updateSignCount(user *entities.User, credential *webauthn.Credential) error {
+
+	if credential.Authenticator.CloneWarning {
+		return fmt.Errorf("%w: the signature counter of the passkey did not increase, the authenticator might have been cloned",
+			ErrPasskeyVerificationFailed)
+	}
+
+	for i := range user.Passkeys {
+		passkey := &user.Passkeys[i]
+		if string(passkey.CredentialId) != string(credential.ID) {
+			continue
+		}
+		passkey.SignCount = int64(credential.Authenticator.SignCount)
+		passkey.BackupState = credential.Flags.BackupState
+		passkey.LastUsedAt = sql.NullTime{Time: time.Now().UTC(), Valid: true}
+		return pm.database.UpdateUserPasskey(nil, passkey)
+	}
+	return errors.WithStack(errors.New("the passkey used was not found"))
+}
@@ -0,0 +1,271 @@
+package core
+
+import (
+	"bytes"
+	"database/sql"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/leodip/goiabada/internal/constants"
+	"github.com/leodip/goiabada/internal/data"
+	"github.com/leodip/goiabada/internal/entities"
+	"github.com/leodip/goiabada/internal/lib"
+	"github.com/pkg/errors"
+)
+
+const maxRetryInterval = time.Hour
+
+const maxLastErrorLength = 512
+
+type DispatcherConfig struct {
+	PollInterval    time.Duration
+	Timeout         time.Duration
+	MaxAttempts     int
+	RetryInterval   time.Duration
+	RetentionInDays int
+	BatchSize       int
+}
+
+// Dispatcher sends the pending deliveries of the outbox. Each delivery is posted to its webhook
+// signed with the secret of the webhook (see lib.SignWebhookPayload). A delivery is delivered when
+// the webhook responds with a 2xx status code, otherwise it's retried with exponential backoff
+// until the max attempts, when it's marked as failed. The deliveries are delivered at least once,
+// so the receivers may see the same event id more than once.
+type Dispatcher struct {
+	database   data.Database
+	config     DispatcherConfig
+	httpClient *http.Client
+	stop       chan struct{}
+	done       chan struct{}
+}
+
+func NewDispatcher(database data.Database, config DispatcherConfig) *Dispatcher {
+	if config.PollInterval <= 0 {
+		config.PollInterval = 5 * time.Second
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = 10 * time.Second
+	}
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = 8
+	}
+	if config.RetryInterval <= 0 {
+		config.RetryInterval = 30 * time.Second
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = 50
+	}
+
+	return &Dispatcher{
+		database:   database,
+		config:     config,
+		httpClient: &http.Client{Timeout: config.Timeout},
+		stop:       make(chan struct{}),
+		done:       make(chan struct{}),
+	}
+}
+
+// Start sends the due deliveries at the poll interval, in the background. The expired deliveries
+// are deleted every hour.
+func (d *Dispatcher) Start() {
+	go d.run()
+}
+
+// Close stops the dispatcher, waiting for the deliveries being sent.
+func (d *Dispatcher) Close() {
+	close(d.stop)
+	<-d.done
+}
+
+func (d *Dispatcher) run() {
+	defer close(d.done)
+
+	ticker := time.NewTicker(d.config.PollInterval)
+	defer ticker.Stop()
+
+	cleanupTicker := time.NewTicker(time.Hour)
+	defer cleanupTicker.Stop()
+
+	for {
+		select {
+		case <-d.stop:
+			return
+		case <-ticker.C:
+			err := d.DeliverDue()
+			if err != nil {
+				slog.Error(fmt.Sprintf("unable to send the webhook deliveries: %+v", err))
+			}
+		case <-cleanupTicker.C:
+			err := d.DeleteExpiredDeliveries()
+			if err != nil {
+				slog.Error(fmt.Sprintf("unable to delete the expired webhook deliveries: %+v", err))
+			}
+		}
+	}
+}
+
+// DeliverDue sends the deliveries whose next attempt is due, in batches, until there are no more.
+func (d *Dispatcher) DeliverDue() error {
+	var settings *entities.Settings
+	webhooks := map[int64]*entities.Webhook{}
+
+	for {
+		select {
+		case <-d.stop:
+			return nil
+		default:
+		}
+
+		deliveries, err := d.database.GetDueWebhookDeliveries(nil, time.Now().UTC(), d.config.BatchSize)
+		if err != nil {
+			return err
+		}
+		if len(deliveries) == 0 {
+			return nil
+		}
+
+		if settings == nil {
+			settings, err = d.database.GetSettingsById(nil, 1)
+			if err != nil {
+				return err
+			}
+		}
+
+		for i := range deliveries {
+			delivery := &deliveries[i]
+
+			webhook, ok := webhooks[delivery.WebhookId]
+			if !ok {
+				webhook, err = d.database.GetWebhookById(nil, delivery.WebhookId)
+				if err != nil {
+					return err
+				}
+				webhooks[delivery.WebhookId] = webhook
+			}
+
+			err = d.deliver(settings, webhook, delivery)
+			if err != nil {
+				return err
+			}
+		}
+
+		if len(deliveries) < d.config.BatchSize {
+			return nil
+		}
+	}
+}
+
+// deliver makes an attempt to send the delivery and records the result. It only returns an error
+// when the result can't be recorded.
+func (d *Dispatcher) deliver(settings *entities.Settings, webhook *entities.Webhook,
+	delivery *entities.WebhookDelivery) error {
+
+	now := time.Now().UTC()
+
+	if webhook == nil || !webhook.Enabled {
+		// the deliveries of disabled webhooks are not retried, they can be redelivered manually
+		delivery.Status = constants.WebhookDeliveryStatusFailed
+		delivery.NextAttemptAt = sql.NullTime{}
+		delivery.LastError = "the webhook is disabled"
+		return d.database.UpdateWebhookDelivery(nil, delivery)
+	}
+
+	delivery.Attempts++
+	delivery.LastAttemptAt = sql.NullTime{Time: now, Valid: true}
+
+	statusCode, err := d.post(settings, webhook, delivery)
+	delivery.LastResponseCode = statusCode
+
+	if err == nil {
+		delivery.Status = constants.WebhookDeliveryStatusDelivered
+		delivery.NextAttemptAt = sql.NullTime{}
+		delivery.LastError = ""
+		return d.database.UpdateWebhookDelivery(nil, delivery)
+	}
+
+	delivery.LastError = truncate(err.Error(), maxLastErrorLength)
+	if delivery.Attempts >= d.config.MaxAttempts {
+		slog.Warn(fmt.Sprintf("unable to deliver the webhook event %v to %v after %v attempts: %v",
+			delivery.EventId, webhook.Url, delivery.Attempts, err))
+		delivery.Status = constants.WebhookDeliveryStatusFailed
+		delivery.NextAttemptAt = sql.NullTime{}
+	} else {
+		delivery.NextAttemptAt = sql.NullTime{Time: now.Add(d.retryInterval(delivery.Attempts)), Valid: true}
+	}
+	return d.database.UpdateWebhookDelivery(nil, delivery)
+}
+
+// retryInterval returns the time to wait after the given number of attempts, doubling the retry
+// interval after each attempt.
+func (d *Dispatcher) retryInterval(attempts int) time.Duration {
+	interval := d.config.RetryInterval
+	for i := 1; i < attempts && interval < maxRetryInterval; i++ {
+		interval *= 2
+	}
+	return min(interval, maxRetryInterval)
+}
+
+// post sends the delivery to the webhook and returns the status code of the response, or zero
+// when there was no response.
+func (d *Dispatcher) post(settings *entities.Settings, webhook *entities.Webhook,
+	delivery *entities.WebhookDelivery) (int, error) {
+
+	secret, err := lib.DecryptText(webhook.SecretEncrypted, settings.AESEncryptionKey)
+	if err != nil {
+		return 0, errors.Wrap(err, "unable to decrypt the secret of the webhook")
+	}
+
+	body := []byte(delivery.Payload)
+	timestamp := time.Now().UTC().Unix()
+
+	req, err := http.NewRequest(http.MethodPost, webhook.Url, bytes.NewReader(body))
+	if err != nil {
+		return 0, errors.Wrap(err, "unable to create the webhook request")
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Goiabada-Webhooks")
+	req.Header.Set("X-Goiabada-Event", delivery.EventType)
+	req.Header.Set("X-Goiabada-Delivery", delivery.EventId)
+	req.Header.Set("X-Goiabada-Timestamp", fmt.Sprint(timestamp))
+	req.Header.Set("X-Goiabada-Signature", lib.SignWebhookPayload(secret, timestamp, body))
+
+	resp, err := d.httpClient.Do(req)
+	if err != nil {
+		return 0, errors.Wrap(err, "unable to post to the webhook")
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return resp.StatusCode, nil
+	}
+	return resp.StatusCode, errors.WithStack(fmt.Errorf("the webhook responded with status code %v", resp.StatusCode))
+}
+
+// DeleteExpiredDeliveries deletes the deliveries older than the retention period that are no longer
+// pending. With zero days the deliveries are kept forever.
+func (d *Dispatcher) DeleteExpiredDeliveries() error {
+	if d.config.RetentionInDays <= 0 {
+		return nil
+	}
+
+	before := time.Now().UTC().AddDate(0, 0, -d.config.RetentionInDays)
+	deleted, err := d.database.DeleteWebhookDeliveriesCreatedBefore(nil, before)
+	if err != nil {
+		return err
+	}
+	if deleted > 0 {
+		slog.Info(fmt.Sprintf("deleted %v expired webhook deliveries", deleted))
+	}
+	return nil
+}
+
+func truncate(s string, maxLength int) string {
+	if len(s) <= maxLength {
+		return s
+	}
+	return s[:maxLength]
+}
@@ -0,0 +1,116 @@
+package core
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/leodip/goiabada/internal/constants"
+	core_api "github.com/leodip/goiabada/internal/core/api"
+	"github.com/leodip/goiabada/internal/data"
+	"github.com/leodip/goiabada/internal/entities"
+	"github.com/pkg/errors"
+)
+
+// Event is the payload posted to the webhooks. The id is the same in all the deliveries of the
+// event, including the redeliveries, so the receivers can discard the duplicates.
+type Event struct {
+	Id        string    `json:"id"`
+	Type      string    `json:"type"`
+	CreatedAt time.Time `json:"createdAt"`
+	Data      EventData `json:"data"`
+}
+
+// EventData has the user of the event, with the same representation as in the API, and the group
+// in user.added_to_group.
+type EventData struct {
+	User  *core_api.User  `json:"user"`
+	Group *core_api.Group `json:"group,omitempty"`
+}
+
+// Publisher adds the events to the outbox of the webhooks subscribed to them. The deliveries are
+// sent later by the dispatcher, so publishing an event never waits for the receivers.
+type Publisher struct {
+	database data.Database
+}
+
+func NewPublisher(database data.Database) *Publisher {
+	return &Publisher{
+		database: database,
+	}
+}
+
+// PublishUserEvent creates a pending delivery of the event for each enabled webhook subscribed to
+// the event type. The group is only used in user.added_to_group.
+func (p *Publisher) PublishUserEvent(tx *sql.Tx, eventType string, user *entities.User, group *entities.Group) error {
+	if user == nil {
+		return errors.WithStack(errors.New("the user of the webhook event is required"))
+	}
+
+	webhooks, err := p.database.GetEnabledWebhooks(tx)
+	if err != nil {
+		return err
+	}
+
+	subscribed := make([]entities.Webhook, 0, len(webhooks))
+	for _, webhook := range webhooks {
+		if webhook.HasEventType(eventType) {
+			subscribed = append(subscribed, webhook)
+		}
+	}
+	if len(subscribed) == 0 {
+		return nil
+	}
+
+	event := Event{
+		Id:        uuid.New().String(),
+		Type:      eventType,
+		CreatedAt: time.Now().UTC(),
+		Data: EventData{
+			User: core_api.NewUser(user),
+		},
+	}
+	if group != nil && eventType == constants.WebhookEventUserAddedToGroup {
+		event.Data.Group = core_api.NewGroup(group)
+	}
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return errors.Wrap(err, "unable to marshal the webhook event")
+	}
+
+	for _, webhook := range subscribed {
+		err = p.database.CreateWebhookDelivery(tx, &entities.WebhookDelivery{
+			WebhookId:     webhook.Id,
+			EventId:       event.Id,
+			EventType:     event.Type,
+			Payload:       string(payload),
+			Status:        constants.WebhookDeliveryStatusPending,
+			NextAttemptAt: sql.NullTime{Time: event.CreatedAt, Valid: true},
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Redeliver creates a new pending delivery of the event of the delivery, to the same webhook. The
+// original delivery is kept in the log as it is.
+func (p *Publisher) Redeliver(tx *sql.Tx, webhookDelivery *entities.WebhookDelivery) (*entities.WebhookDelivery, error) {
+	redelivery := &entities.WebhookDelivery{
+		WebhookId:     webhookDelivery.WebhookId,
+		EventId:       webhookDelivery.EventId,
+		EventType:     webhookDelivery.EventType,
+		Payload:       webhookDelivery.Payload,
+		Status:        constants.WebhookDeliveryStatusPending,
+		NextAttemptAt: sql.NullTime{Time: time.Now().UTC(), Valid: true},
+	}
+	err := p.database.CreateWebhookDelivery(tx, redelivery)
+	if err != nil {
+		return nil, err
+	}
+	return redelivery, nil
+}
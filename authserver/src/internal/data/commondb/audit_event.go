@@ -0,0 +1,187 @@
+package commondb
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/huandu/go-sqlbuilder"
+	"github.com/leodip/goiabada/internal/entities"
+	"github.com/pkg/errors"
+)
+
+// CreateAuditEvent inserts an audit event. The creation time is the time of the event, when it's set.
+func (d *CommonDatabase) CreateAuditEvent(tx *sql.Tx, auditEvent *entities.AuditEvent) error {
+
+	if len(auditEvent.EventType) == 0 {
+		return errors.WithStack(errors.New("can't create audit event with an empty event type"))
+	}
+
+	originalCreatedAt := auditEvent.CreatedAt
+	if !auditEvent.CreatedAt.Valid {
+		auditEvent.CreatedAt = sql.NullTime{Time: time.Now().UTC(), Valid: true}
+	}
+
+	auditEventStruct := sqlbuilder.NewStruct(new(entities.AuditEvent)).
+		For(d.Flavor)
+
+	insertBuilder := auditEventStruct.WithoutTag("pk").InsertInto("audit_events", auditEvent)
+
+	sql, args := insertBuilder.Build()
+	id, err := d.ExecInsertSql(tx, sql, args...)
+	if err != nil {
+		auditEvent.CreatedAt = originalCreatedAt
+		return errors.Wrap(err, "unable to insert audit event")
+	}
+
+	auditEvent.Id = id
+	return nil
+}
+
+// auditEventFilterConditions returns the conditions of the filter, built with the arguments of the builder.
+func auditEventFilterConditions(cond *sqlbuilder.Cond, filter *entities.AuditEventFilter) []string {
+
+	conditions := []string{}
+	if len(filter.EventType) > 0 {
+		conditions = append(conditions, cond.Equal("event_type", filter.EventType))
+	}
+	if matches := auditEventMatches(cond, "user_id", filter.UserId, filter.UserSubject); len(matches) > 0 {
+		conditions = append(conditions, cond.Or(matches...))
+	}
+	if matches := auditEventMatches(cond, "client_id", filter.ClientId, filter.ClientIdentifier); len(matches) > 0 {
+		conditions = append(conditions, cond.Or(matches...))
+	}
+	if !filter.From.IsZero() {
+		conditions = append(conditions, cond.GreaterEqualThan("created_at", filter.From))
+	}
+	if !filter.To.IsZero() {
+		conditions = append(conditions, cond.LessThan("created_at", filter.To))
+	}
+	return conditions
+}
+
+// auditEventMatches returns the conditions that match the events about an entity, by its id, or
+// triggered by it, by its subject or identifier.
+func auditEventMatches(cond *sqlbuilder.Cond, idColumn string, id int64, actorSubject string) []string {
+	matches := []string{}
+	if id > 0 {
+		matches = append(matches, cond.Equal(idColumn, id))
+	}
+	if len(actorSubject) > 0 {
+		matches = append(matches, cond.Equal("actor_subject", actorSubject))
+	}
+	return matches
+}
+
+// SearchAuditEventsPaginated returns the audit events that match the filter, the most recent first,
+// and the total number of events that match the filter.
+func (d *CommonDatabase) SearchAuditEventsPaginated(tx *sql.Tx, filter *entities.AuditEventFilter, page int,
+	pageSize int) ([]entities.AuditEvent, int, error) {
+
+	if page < 1 {
+		page = 1
+	}
+
+	if pageSize < 1 {
+		pageSize = 10
+	}
+
+	auditEventStruct := sqlbuilder.NewStruct(new(entities.AuditEvent)).
+		For(d.Flavor)
+
+	selectBuilder := auditEventStruct.SelectFrom("audit_events")
+	if conditions := auditEventFilterConditions(&selectBuilder.Cond, filter); len(conditions) > 0 {
+		selectBuilder.Where(conditions...)
+	}
+	selectBuilder.OrderBy("created_at DESC", "id DESC")
+	selectBuilder.Offset((page - 1) * pageSize)
+	selectBuilder.Limit(pageSize)
+
+	sql, args := selectBuilder.Build()
+	rows, err := d.QuerySql(tx, sql, args...)
+	if err != nil {
+		return nil, 0, errors.Wrap(err, "unable to query database")
+	}
+	defer rows.Close()
+
+	auditEvents := make([]entities.AuditEvent, 0)
+	for rows.Next() {
+		var auditEvent entities.AuditEvent
+		addr := auditEventStruct.Addr(&auditEvent)
+		err = rows.Scan(addr...)
+		if err != nil {
+			return nil, 0, errors.Wrap(err, "unable to scan audit event")
+		}
+		auditEvents = append(auditEvents, auditEvent)
+	}
+
+	var count int
+	selectBuilder = d.Flavor.NewSelectBuilder()
+	selectBuilder.Select("count(*)").From("audit_events")
+	if conditions := auditEventFilterConditions(&selectBuilder.Cond, filter); len(conditions) > 0 {
+		selectBuilder.Where(conditions...)
+	}
+
+	sql, args = selectBuilder.Build()
+	rows2, err := d.QuerySql(tx, sql, args...)
+	if err != nil {
+		return nil, 0, errors.Wrap(err, "unable to query database")
+	}
+	defer rows2.Close()
+
+	if rows2.Next() {
+		err = rows2.Scan(&count)
+		if err != nil {
+			return nil, 0, errors.Wrap(err, "unable to scan count")
+		}
+	}
+
+	return auditEvents, count, nil
+}
+
+// GetAuditEventTypes returns the distinct event types in the audit events, sorted by name.
+func (d *CommonDatabase) GetAuditEventTypes(tx *sql.Tx) ([]string, error) {
+
+	selectBuilder := d.Flavor.NewSelectBuilder()
+	selectBuilder.Select("event_type").Distinct().From("audit_events")
+	selectBuilder.OrderBy("event_type").Asc()
+
+	sql, args := selectBuilder.Build()
+	rows, err := d.QuerySql(tx, sql, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to query database")
+	}
+	defer rows.Close()
+
+	eventTypes := make([]string, 0)
+	for rows.Next() {
+		var eventType string
+		err = rows.Scan(&eventType)
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to scan event type")
+		}
+		eventTypes = append(eventTypes, eventType)
+	}
+
+	return eventTypes, nil
+}
+
+// DeleteAuditEventsCreatedBefore deletes the audit events older than the given time, and returns how
+// many were deleted.
+func (d *CommonDatabase) DeleteAuditEventsCreatedBefore(tx *sql.Tx, before time.Time) (int64, error) {
+
+	deleteBuilder := d.Flavor.NewDeleteBuilder()
+	deleteBuilder.DeleteFrom("audit_events")
+	deleteBuilder.Where(deleteBuilder.LessThan("created_at", before))
+
+	sql, args := deleteBuilder.Build()
+	result, err := d.ExecSql(tx, sql, args...)
+	if err != nil {
+		return 0, errors.Wrap(err, "unable to delete audit events")
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, errors.Wrap(err, "unable to get rows affected")
+	}
+	return rowsAffected, nil
+}
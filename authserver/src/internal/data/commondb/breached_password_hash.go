@@ -0,0 +1,87 @@
+package commondb
+
+import (
+	"database/sql"
+
+	"github.com/pkg/errors"
+)
+
+// CreateBreachedPasswordHashes inserts the SHA-1 hashes that are not in the table yet, and returns
+// how many were inserted.
+func (d *CommonDatabase) CreateBreachedPasswordHashes(tx *sql.Tx, sha1Hashes []string) (int64, error) {
+
+	if len(sha1Hashes) == 0 {
+		return 0, nil
+	}
+
+	insertBuilder := d.Flavor.NewInsertBuilder()
+	insertBuilder.InsertIgnoreInto("breached_password_hashes").Cols("sha1_hash")
+	for _, sha1Hash := range sha1Hashes {
+		insertBuilder.Values(sha1Hash)
+	}
+
+	sql, args := insertBuilder.Build()
+	result, err := d.ExecSql(tx, sql, args...)
+	if err != nil {
+		return 0, errors.Wrap(err, "unable to insert breached password hashes")
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, errors.Wrap(err, "unable to get rows affected")
+	}
+	return rowsAffected, nil
+}
+
+func (d *CommonDatabase) BreachedPasswordHashExists(tx *sql.Tx, sha1Hash string) (bool, error) {
+
+	selectBuilder := d.Flavor.NewSelectBuilder()
+	selectBuilder.Select("id").From("breached_password_hashes")
+	selectBuilder.Where(selectBuilder.Equal("sha1_hash", sha1Hash))
+	selectBuilder.Limit(1)
+
+	sql, args := selectBuilder.Build()
+	rows, err := d.QuerySql(tx, sql, args...)
+	if err != nil {
+		return false, errors.Wrap(err, "unable to query database")
+	}
+	defer rows.Close()
+
+	return rows.Next(), nil
+}
+
+func (d *CommonDatabase) CountBreachedPasswordHashes(tx *sql.Tx) (int, error) {
+
+	selectBuilder := d.Flavor.NewSelectBuilder()
+	selectBuilder.Select("count(*)").From("breached_password_hashes")
+
+	sql, args := selectBuilder.Build()
+	rows, err := d.QuerySql(tx, sql, args...)
+	if err != nil {
+		return 0, errors.Wrap(err, "unable to query database")
+	}
+	defer rows.Close()
+
+	var count int
+	if rows.Next() {
+		err = rows.Scan(&count)
+		if err != nil {
+			return 0, errors.Wrap(err, "unable to scan count")
+		}
+	}
+	return count, nil
+}
+
+func (d *CommonDatabase) DeleteAllBreachedPasswordHashes(tx *sql.Tx) error {
+
+	deleteBuilder := d.Flavor.NewDeleteBuilder()
+	deleteBuilder.DeleteFrom("breached_password_hashes")
+
+	sql, args := deleteBuilder.Build()
+	_, err := d.ExecSql(tx, sql, args...)
+	if err != nil {
+		return errors.Wrap(err, "unable to delete breached password hashes")
+	}
+
+	return nil
+}
@@ -24,20 +24,13 @@ func (d *CommonDatabase) CreateClient(tx *sql.Tx, client *entities.Client) error
 	insertBuilder := clientStruct.WithoutTag("pk").InsertInto("clients", client)
 
 	sql, args := insertBuilder.Build()
-	result, err := d.ExecSql(tx, sql, args...)
+	id, err := d.ExecInsertSql(tx, sql, args...)
 	if err != nil {
 		client.CreatedAt = originalCreatedAt
 		client.UpdatedAt = originalUpdatedAt
 		return errors.Wrap(err, "unable to insert client")
 	}
 
-	id, err := result.LastInsertId()
-	if err != nil {
-		client.CreatedAt = originalCreatedAt
-		client.UpdatedAt = originalUpdatedAt
-		return errors.Wrap(err, "unable to get last insert id")
-	}
-
 	client.Id = id
 	return nil
 }
@@ -186,7 +179,7 @@ func (d *CommonDatabase) ClientLoadPermissions(tx *sql.Tx, client *entities.Clie
 		return nil
 	}
 
-	clientPermissions, err := d.GetClientPermissionsByClientId(nil, client.Id)
+	clientPermissions, err := d.GetClientPermissionsByClientId(tx, client.Id)
 	if err != nil {
 		return err
 	}
@@ -196,7 +189,7 @@ func (d *CommonDatabase) ClientLoadPermissions(tx *sql.Tx, client *entities.Clie
 		permissionIds = append(permissionIds, clientPermission.PermissionId)
 	}
 
-	client.Permissions, err = d.GetPermissionsByIds(nil, permissionIds)
+	client.Permissions, err = d.GetPermissionsByIds(tx, permissionIds)
 	if err != nil {
 		return err
 	}
@@ -32,20 +32,13 @@ func (d *CommonDatabase) CreateClientPermission(tx *sql.Tx, clientPermission *en
 	insertBuilder := clientPermissionStruct.WithoutTag("pk").InsertInto("clients_permissions", clientPermission)
 
 	sql, args := insertBuilder.Build()
-	result, err := d.ExecSql(tx, sql, args...)
+	id, err := d.ExecInsertSql(tx, sql, args...)
 	if err != nil {
 		clientPermission.CreatedAt = originalCreatedAt
 		clientPermission.UpdatedAt = originalUpdatedAt
 		return errors.Wrap(err, "unable to insert clientPermission")
 	}
 
-	id, err := result.LastInsertId()
-	if err != nil {
-		clientPermission.CreatedAt = originalCreatedAt
-		clientPermission.UpdatedAt = originalUpdatedAt
-		return errors.Wrap(err, "unable to get last insert id")
-	}
-
 	clientPermission.Id = id
 	return nil
 }
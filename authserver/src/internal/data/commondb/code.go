@@ -32,20 +32,13 @@ func (d *CommonDatabase) CreateCode(tx *sql.Tx, code *entities.Code) error {
 	insertBuilder := codeStruct.WithoutTag("pk").InsertInto("codes", code)
 
 	sql, args := insertBuilder.Build()
-	result, err := d.ExecSql(tx, sql, args...)
+	id, err := d.ExecInsertSql(tx, sql, args...)
 	if err != nil {
 		code.CreatedAt = originalCreatedAt
 		code.UpdatedAt = originalUpdatedAt
 		return errors.Wrap(err, "unable to insert code")
 	}
 
-	id, err := result.LastInsertId()
-	if err != nil {
-		code.CreatedAt = originalCreatedAt
-		code.UpdatedAt = originalUpdatedAt
-		return errors.Wrap(err, "unable to get last insert id")
-	}
-
 	code.Id = id
 	return nil
 }
@@ -88,6 +88,51 @@ func (d *CommonDatabase) ExecSql(tx *sql.Tx, sql string, args ...any) (sql.Resul
 	return result, nil
 }
 
+// ExecInsertSql executes an insert statement and returns the id of the new row. PostgreSQL doesn't
+// support LastInsertId, so in that case the id is returned by the statement itself.
+func (d *CommonDatabase) ExecInsertSql(tx *sql.Tx, sql string, args ...any) (int64, error) {
+
+	if d.Flavor != sqlbuilder.PostgreSQL {
+		result, err := d.ExecSql(tx, sql, args...)
+		if err != nil {
+			return 0, err
+		}
+
+		id, err := result.LastInsertId()
+		if err != nil {
+			return 0, errors.Wrap(err, "unable to get last insert id")
+		}
+		return id, nil
+	}
+
+	rows, err := d.QuerySql(tx, sql+" RETURNING id", args...)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	var id int64
+	if rows.Next() {
+		err = rows.Scan(&id)
+		if err != nil {
+			return 0, errors.Wrap(err, "unable to scan the id of the inserted row")
+		}
+	}
+	if err = rows.Err(); err != nil {
+		return 0, errors.Wrap(err, "unable to execute SQL")
+	}
+	return id, nil
+}
+
+// Like returns a case-insensitive LIKE condition. MySQL and SQLite compare case-insensitively by
+// default, but PostgreSQL needs ILIKE for that.
+func (d *CommonDatabase) Like(selectBuilder *sqlbuilder.SelectBuilder, field string, value interface{}) string {
+	if d.Flavor == sqlbuilder.PostgreSQL {
+		return field + " ILIKE " + selectBuilder.Var(value)
+	}
+	return selectBuilder.Like(field, value)
+}
+
 func (d *CommonDatabase) QuerySql(tx *sql.Tx, sql string, args ...any) (*sql.Rows, error) {
 	d.Log(sql, args...)
 
@@ -0,0 +1,167 @@
+package commondb
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/huandu/go-sqlbuilder"
+	"github.com/leodip/goiabada/internal/entities"
+	"github.com/pkg/errors"
+)
+
+func (d *CommonDatabase) CreateFailedLoginIp(tx *sql.Tx, failedLoginIp *entities.FailedLoginIp) error {
+
+	if len(failedLoginIp.IpAddress) == 0 {
+		return errors.WithStack(errors.New("can't create failed login ip with an empty ip address"))
+	}
+
+	now := time.Now().UTC()
+
+	originalCreatedAt := failedLoginIp.CreatedAt
+	originalUpdatedAt := failedLoginIp.UpdatedAt
+	failedLoginIp.CreatedAt = sql.NullTime{Time: now, Valid: true}
+	failedLoginIp.UpdatedAt = sql.NullTime{Time: now, Valid: true}
+
+	failedLoginIpStruct := sqlbuilder.NewStruct(new(entities.FailedLoginIp)).
+		For(d.Flavor)
+
+	insertBuilder := failedLoginIpStruct.WithoutTag("pk").InsertInto("failed_login_ips", failedLoginIp)
+
+	sql, args := insertBuilder.Build()
+	id, err := d.ExecInsertSql(tx, sql, args...)
+	if err != nil {
+		failedLoginIp.CreatedAt = originalCreatedAt
+		failedLoginIp.UpdatedAt = originalUpdatedAt
+		return errors.Wrap(err, "unable to insert failed login ip")
+	}
+
+	failedLoginIp.Id = id
+	return nil
+}
+
+func (d *CommonDatabase) UpdateFailedLoginIp(tx *sql.Tx, failedLoginIp *entities.FailedLoginIp) error {
+
+	if failedLoginIp.Id == 0 {
+		return errors.WithStack(errors.New("can't update failed login ip with id 0"))
+	}
+
+	originalUpdatedAt := failedLoginIp.UpdatedAt
+	failedLoginIp.UpdatedAt = sql.NullTime{Time: time.Now().UTC(), Valid: true}
+
+	failedLoginIpStruct := sqlbuilder.NewStruct(new(entities.FailedLoginIp)).
+		For(d.Flavor)
+
+	updateBuilder := failedLoginIpStruct.WithoutTag("pk").Update("failed_login_ips", failedLoginIp)
+	updateBuilder.Where(updateBuilder.Equal("id", failedLoginIp.Id))
+
+	sql, args := updateBuilder.Build()
+	_, err := d.ExecSql(tx, sql, args...)
+	if err != nil {
+		failedLoginIp.UpdatedAt = originalUpdatedAt
+		return errors.Wrap(err, "unable to update failed login ip")
+	}
+
+	return nil
+}
+
+func (d *CommonDatabase) GetFailedLoginIpById(tx *sql.Tx, failedLoginIpId int64) (*entities.FailedLoginIp, error) {
+
+	failedLoginIpStruct := sqlbuilder.NewStruct(new(entities.FailedLoginIp)).
+		For(d.Flavor)
+
+	selectBuilder := failedLoginIpStruct.SelectFrom("failed_login_ips")
+	selectBuilder.Where(selectBuilder.Equal("id", failedLoginIpId))
+
+	sql, args := selectBuilder.Build()
+	rows, err := d.QuerySql(tx, sql, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to query database")
+	}
+	defer rows.Close()
+
+	var failedLoginIp entities.FailedLoginIp
+	if rows.Next() {
+		addr := failedLoginIpStruct.Addr(&failedLoginIp)
+		err = rows.Scan(addr...)
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to scan failed login ip")
+		}
+		return &failedLoginIp, nil
+	}
+	return nil, nil
+}
+
+func (d *CommonDatabase) GetFailedLoginIpByIpAddress(tx *sql.Tx, ipAddress string) (*entities.FailedLoginIp, error) {
+
+	failedLoginIpStruct := sqlbuilder.NewStruct(new(entities.FailedLoginIp)).
+		For(d.Flavor)
+
+	selectBuilder := failedLoginIpStruct.SelectFrom("failed_login_ips")
+	selectBuilder.Where(selectBuilder.Equal("ip_address", ipAddress))
+
+	sql, args := selectBuilder.Build()
+	rows, err := d.QuerySql(tx, sql, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to query database")
+	}
+	defer rows.Close()
+
+	var failedLoginIp entities.FailedLoginIp
+	if rows.Next() {
+		addr := failedLoginIpStruct.Addr(&failedLoginIp)
+		err = rows.Scan(addr...)
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to scan failed login ip")
+		}
+		return &failedLoginIp, nil
+	}
+	return nil, nil
+}
+
+// GetLockedFailedLoginIps returns the IP addresses with a lockout time. The lockout may have already expired.
+func (d *CommonDatabase) GetLockedFailedLoginIps(tx *sql.Tx) ([]entities.FailedLoginIp, error) {
+
+	failedLoginIpStruct := sqlbuilder.NewStruct(new(entities.FailedLoginIp)).
+		For(d.Flavor)
+
+	selectBuilder := failedLoginIpStruct.SelectFrom("failed_login_ips")
+	selectBuilder.Where(selectBuilder.IsNotNull("locked_until"))
+	selectBuilder.OrderBy("locked_until").Desc()
+
+	sql, args := selectBuilder.Build()
+	rows, err := d.QuerySql(tx, sql, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to query database")
+	}
+	defer rows.Close()
+
+	failedLoginIps := make([]entities.FailedLoginIp, 0)
+	for rows.Next() {
+		var failedLoginIp entities.FailedLoginIp
+		addr := failedLoginIpStruct.Addr(&failedLoginIp)
+		err = rows.Scan(addr...)
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to scan failed login ip")
+		}
+		failedLoginIps = append(failedLoginIps, failedLoginIp)
+	}
+
+	return failedLoginIps, nil
+}
+
+func (d *CommonDatabase) DeleteFailedLoginIp(tx *sql.Tx, failedLoginIpId int64) error {
+
+	failedLoginIpStruct := sqlbuilder.NewStruct(new(entities.FailedLoginIp)).
+		For(d.Flavor)
+
+	deleteBuilder := failedLoginIpStruct.DeleteFrom("failed_login_ips")
+	deleteBuilder.Where(deleteBuilder.Equal("id", failedLoginIpId))
+
+	sql, args := deleteBuilder.Build()
+	_, err := d.ExecSql(tx, sql, args...)
+	if err != nil {
+		return errors.Wrap(err, "unable to delete failed login ip")
+	}
+
+	return nil
+}
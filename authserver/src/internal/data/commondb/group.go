@@ -21,23 +21,16 @@ func (d *CommonDatabase) CreateGroup(tx *sql.Tx, group *entities.Group) error {
 	groupStruct := sqlbuilder.NewStruct(new(entities.Group)).
 		For(d.Flavor)
 
-	insertBuilder := groupStruct.WithoutTag("pk").InsertInto("`groups`", group)
+	insertBuilder := groupStruct.WithoutTag("pk").InsertInto(d.groupsTable(), group)
 
 	sql, args := insertBuilder.Build()
-	result, err := d.ExecSql(tx, sql, args...)
+	id, err := d.ExecInsertSql(tx, sql, args...)
 	if err != nil {
 		group.CreatedAt = originalCreatedAt
 		group.UpdatedAt = originalUpdatedAt
 		return errors.Wrap(err, "unable to insert group")
 	}
 
-	id, err := result.LastInsertId()
-	if err != nil {
-		group.CreatedAt = originalCreatedAt
-		group.UpdatedAt = originalUpdatedAt
-		return errors.Wrap(err, "unable to get last insert id")
-	}
-
 	group.Id = id
 	return nil
 }
@@ -54,7 +47,7 @@ func (d *CommonDatabase) UpdateGroup(tx *sql.Tx, group *entities.Group) error {
 	groupStruct := sqlbuilder.NewStruct(new(entities.Group)).
 		For(d.Flavor)
 
-	updateBuilder := groupStruct.WithoutTag("pk").Update("`groups`", group)
+	updateBuilder := groupStruct.WithoutTag("pk").Update(d.groupsTable(), group)
 	updateBuilder.Where(updateBuilder.Equal("id", group.Id))
 
 	sql, args := updateBuilder.Build()
@@ -94,7 +87,7 @@ func (d *CommonDatabase) GetGroupById(tx *sql.Tx, groupId int64) (*entities.Grou
 	groupStruct := sqlbuilder.NewStruct(new(entities.Group)).
 		For(d.Flavor)
 
-	selectBuilder := groupStruct.SelectFrom("`groups`")
+	selectBuilder := groupStruct.SelectFrom(d.groupsTable())
 	selectBuilder.Where(selectBuilder.Equal("id", groupId))
 
 	group, err := d.getGroupCommon(tx, selectBuilder, groupStruct)
@@ -114,7 +107,7 @@ func (d *CommonDatabase) GetGroupsByIds(tx *sql.Tx, groupIds []int64) ([]entitie
 	groupStruct := sqlbuilder.NewStruct(new(entities.Group)).
 		For(d.Flavor)
 
-	selectBuilder := groupStruct.SelectFrom("`groups`")
+	selectBuilder := groupStruct.SelectFrom(d.groupsTable())
 	selectBuilder.Where(selectBuilder.In("id", sqlbuilder.Flatten(groupIds)...))
 
 	sql, args := selectBuilder.Build()
@@ -246,7 +239,7 @@ func (d *CommonDatabase) GetGroupByGroupIdentifier(tx *sql.Tx, groupIdentifier s
 	groupStruct := sqlbuilder.NewStruct(new(entities.Group)).
 		For(d.Flavor)
 
-	selectBuilder := groupStruct.SelectFrom("`groups`")
+	selectBuilder := groupStruct.SelectFrom(d.groupsTable())
 	selectBuilder.Where(selectBuilder.Equal("group_identifier", groupIdentifier))
 
 	group, err := d.getGroupCommon(tx, selectBuilder, groupStruct)
@@ -262,7 +255,7 @@ func (d *CommonDatabase) GetAllGroups(tx *sql.Tx) ([]*entities.Group, error) {
 	groupStruct := sqlbuilder.NewStruct(new(entities.Group)).
 		For(d.Flavor)
 
-	selectBuilder := groupStruct.SelectFrom("`groups`")
+	selectBuilder := groupStruct.SelectFrom(d.groupsTable())
 
 	sql, args := selectBuilder.Build()
 	rows, err := d.QuerySql(tx, sql, args...)
@@ -297,7 +290,7 @@ func (d *CommonDatabase) GetAllGroupsPaginated(tx *sql.Tx, page int, pageSize in
 	groupStruct := sqlbuilder.NewStruct(new(entities.Group)).
 		For(d.Flavor)
 
-	selectBuilder := groupStruct.SelectFrom("`groups`")
+	selectBuilder := groupStruct.SelectFrom(d.groupsTable())
 	selectBuilder.OrderBy("group_identifier").Asc()
 	selectBuilder.Offset((page - 1) * pageSize)
 	selectBuilder.Limit(pageSize)
@@ -321,7 +314,7 @@ func (d *CommonDatabase) GetAllGroupsPaginated(tx *sql.Tx, page int, pageSize in
 	}
 
 	selectBuilder = d.Flavor.NewSelectBuilder()
-	selectBuilder.Select("count(*)").From("`groups`")
+	selectBuilder.Select("count(*)").From(d.groupsTable())
 
 	sql, args = selectBuilder.Build()
 	rows2, err := d.QuerySql(tx, sql, args...)
@@ -362,7 +355,7 @@ func (d *CommonDatabase) GetGroupMembersPaginated(tx *sql.Tx, groupId int64, pag
 	selectBuilder.Limit(pageSize)
 
 	sql, args := selectBuilder.Build()
-	rows, err := d.QuerySql(nil, sql, args...)
+	rows, err := d.QuerySql(tx, sql, args...)
 	if err != nil {
 		return nil, 0, errors.Wrap(err, "unable to query database")
 	}
@@ -385,7 +378,7 @@ func (d *CommonDatabase) GetGroupMembersPaginated(tx *sql.Tx, groupId int64, pag
 	selectBuilder.Where(selectBuilder.Equal("users_groups.group_id", groupId))
 
 	sql, args = selectBuilder.Build()
-	rows2, err := d.QuerySql(nil, sql, args...)
+	rows2, err := d.QuerySql(tx, sql, args...)
 	if err != nil {
 		return nil, 0, errors.Wrap(err, "unable to query database")
 	}
@@ -431,7 +424,7 @@ func (d *CommonDatabase) DeleteGroup(tx *sql.Tx, groupId int64) error {
 	clientStruct := sqlbuilder.NewStruct(new(entities.Group)).
 		For(d.Flavor)
 
-	deleteBuilder := clientStruct.DeleteFrom("`groups`")
+	deleteBuilder := clientStruct.DeleteFrom(d.groupsTable())
 	deleteBuilder.Where(deleteBuilder.Equal("id", groupId))
 
 	sql, args := deleteBuilder.Build()
@@ -442,3 +435,9 @@ func (d *CommonDatabase) DeleteGroup(tx *sql.Tx, groupId int64) error {
 
 	return nil
 }
+
+// groupsTable returns the name of the groups table, quoted for the flavor of the database, as
+// groups is a reserved word in MySQL.
+func (d *CommonDatabase) groupsTable() string {
+	return d.Flavor.Quote("groups")
+}
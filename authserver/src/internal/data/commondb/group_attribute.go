@@ -28,20 +28,13 @@ func (d *CommonDatabase) CreateGroupAttribute(tx *sql.Tx, groupAttribute *entiti
 	insertBuilder := groupAttributeStruct.WithoutTag("pk").InsertInto("group_attributes", groupAttribute)
 
 	sql, args := insertBuilder.Build()
-	result, err := d.ExecSql(tx, sql, args...)
+	id, err := d.ExecInsertSql(tx, sql, args...)
 	if err != nil {
 		groupAttribute.CreatedAt = originalCreatedAt
 		groupAttribute.UpdatedAt = originalUpdatedAt
 		return errors.Wrap(err, "unable to insert groupAttribute")
 	}
 
-	id, err := result.LastInsertId()
-	if err != nil {
-		groupAttribute.CreatedAt = originalCreatedAt
-		groupAttribute.UpdatedAt = originalUpdatedAt
-		return errors.Wrap(err, "unable to get last insert id")
-	}
-
 	groupAttribute.Id = id
 	return nil
 }
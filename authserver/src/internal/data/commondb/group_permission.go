@@ -32,20 +32,13 @@ func (d *CommonDatabase) CreateGroupPermission(tx *sql.Tx, groupPermission *enti
 	insertBuilder := groupPermissionStruct.WithoutTag("pk").InsertInto("groups_permissions", groupPermission)
 
 	sql, args := insertBuilder.Build()
-	result, err := d.ExecSql(tx, sql, args...)
+	id, err := d.ExecInsertSql(tx, sql, args...)
 	if err != nil {
 		groupPermission.CreatedAt = originalCreatedAt
 		groupPermission.UpdatedAt = originalUpdatedAt
 		return errors.Wrap(err, "unable to insert groupPermission")
 	}
 
-	id, err := result.LastInsertId()
-	if err != nil {
-		groupPermission.CreatedAt = originalCreatedAt
-		groupPermission.UpdatedAt = originalUpdatedAt
-		return errors.Wrap(err, "unable to get last insert id")
-	}
-
 	groupPermission.Id = id
 	return nil
 }
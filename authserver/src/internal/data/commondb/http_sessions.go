@@ -24,20 +24,13 @@ func (d *CommonDatabase) CreateHttpSession(tx *sql.Tx, httpSession *entities.Htt
 	insertBuilder := httpSessionStruct.WithoutTag("pk").InsertInto("http_sessions", httpSession)
 
 	sql, args := insertBuilder.Build()
-	result, err := d.ExecSql(tx, sql, args...)
+	id, err := d.ExecInsertSql(tx, sql, args...)
 	if err != nil {
 		httpSession.CreatedAt = originalCreatedAt
 		httpSession.UpdatedAt = originalUpdatedAt
 		return errors.Wrap(err, "unable to insert httpSession")
 	}
 
-	id, err := result.LastInsertId()
-	if err != nil {
-		httpSession.CreatedAt = originalCreatedAt
-		httpSession.UpdatedAt = originalUpdatedAt
-		return errors.Wrap(err, "unable to get last insert id")
-	}
-
 	httpSession.Id = id
 	return nil
 }
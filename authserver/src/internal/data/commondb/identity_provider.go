@@ -0,0 +1,194 @@
+package commondb
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/huandu/go-sqlbuilder"
+	"github.com/leodip/goiabada/internal/entities"
+	"github.com/pkg/errors"
+)
+
+func (d *CommonDatabase) CreateIdentityProvider(tx *sql.Tx, identityProvider *entities.IdentityProvider) error {
+
+	now := time.Now().UTC()
+
+	originalCreatedAt := identityProvider.CreatedAt
+	originalUpdatedAt := identityProvider.UpdatedAt
+	identityProvider.CreatedAt = sql.NullTime{Time: now, Valid: true}
+	identityProvider.UpdatedAt = sql.NullTime{Time: now, Valid: true}
+
+	identityProviderStruct := sqlbuilder.NewStruct(new(entities.IdentityProvider)).
+		For(d.Flavor)
+
+	insertBuilder := identityProviderStruct.WithoutTag("pk").InsertInto("identity_providers", identityProvider)
+
+	sql, args := insertBuilder.Build()
+	id, err := d.ExecInsertSql(tx, sql, args...)
+	if err != nil {
+		identityProvider.CreatedAt = originalCreatedAt
+		identityProvider.UpdatedAt = originalUpdatedAt
+		return errors.Wrap(err, "unable to insert identity provider")
+	}
+
+	identityProvider.Id = id
+	return nil
+}
+
+func (d *CommonDatabase) UpdateIdentityProvider(tx *sql.Tx, identityProvider *entities.IdentityProvider) error {
+
+	if identityProvider.Id == 0 {
+		return errors.WithStack(errors.New("can't update identity provider with id 0"))
+	}
+
+	originalUpdatedAt := identityProvider.UpdatedAt
+	identityProvider.UpdatedAt = sql.NullTime{Time: time.Now().UTC(), Valid: true}
+
+	identityProviderStruct := sqlbuilder.NewStruct(new(entities.IdentityProvider)).
+		For(d.Flavor)
+
+	updateBuilder := identityProviderStruct.WithoutTag("pk").Update("identity_providers", identityProvider)
+	updateBuilder.Where(updateBuilder.Equal("id", identityProvider.Id))
+
+	sql, args := updateBuilder.Build()
+	_, err := d.ExecSql(tx, sql, args...)
+	if err != nil {
+		identityProvider.UpdatedAt = originalUpdatedAt
+		return errors.Wrap(err, "unable to update identity provider")
+	}
+
+	return nil
+}
+
+func (d *CommonDatabase) getIdentityProviderCommon(tx *sql.Tx, selectBuilder *sqlbuilder.SelectBuilder,
+	identityProviderStruct *sqlbuilder.Struct) (*entities.IdentityProvider, error) {
+
+	sql, args := selectBuilder.Build()
+	rows, err := d.QuerySql(tx, sql, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to query database")
+	}
+	defer rows.Close()
+
+	var identityProvider entities.IdentityProvider
+	if rows.Next() {
+		addr := identityProviderStruct.Addr(&identityProvider)
+		err = rows.Scan(addr...)
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to scan identity provider")
+		}
+		return &identityProvider, nil
+	}
+	return nil, nil
+}
+
+func (d *CommonDatabase) getIdentityProvidersCommon(tx *sql.Tx, selectBuilder *sqlbuilder.SelectBuilder,
+	identityProviderStruct *sqlbuilder.Struct) ([]entities.IdentityProvider, error) {
+
+	sql, args := selectBuilder.Build()
+	rows, err := d.QuerySql(tx, sql, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to query database")
+	}
+	defer rows.Close()
+
+	identityProviders := make([]entities.IdentityProvider, 0)
+	for rows.Next() {
+		var identityProvider entities.IdentityProvider
+		addr := identityProviderStruct.Addr(&identityProvider)
+		err = rows.Scan(addr...)
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to scan identity provider")
+		}
+		identityProviders = append(identityProviders, identityProvider)
+	}
+
+	return identityProviders, nil
+}
+
+func (d *CommonDatabase) GetIdentityProviderById(tx *sql.Tx, identityProviderId int64) (*entities.IdentityProvider, error) {
+
+	identityProviderStruct := sqlbuilder.NewStruct(new(entities.IdentityProvider)).
+		For(d.Flavor)
+
+	selectBuilder := identityProviderStruct.SelectFrom("identity_providers")
+	selectBuilder.Where(selectBuilder.Equal("id", identityProviderId))
+
+	identityProvider, err := d.getIdentityProviderCommon(tx, selectBuilder, identityProviderStruct)
+	if err != nil {
+		return nil, err
+	}
+
+	return identityProvider, nil
+}
+
+func (d *CommonDatabase) GetIdentityProviderByIdentifier(tx *sql.Tx, identityProviderIdentifier string) (*entities.IdentityProvider, error) {
+
+	identityProviderStruct := sqlbuilder.NewStruct(new(entities.IdentityProvider)).
+		For(d.Flavor)
+
+	selectBuilder := identityProviderStruct.SelectFrom("identity_providers")
+	selectBuilder.Where(selectBuilder.Equal("identity_provider_identifier", identityProviderIdentifier))
+
+	identityProvider, err := d.getIdentityProviderCommon(tx, selectBuilder, identityProviderStruct)
+	if err != nil {
+		return nil, err
+	}
+
+	return identityProvider, nil
+}
+
+func (d *CommonDatabase) GetIdentityProvidersByIds(tx *sql.Tx, identityProviderIds []int64) ([]entities.IdentityProvider, error) {
+
+	if len(identityProviderIds) == 0 {
+		return nil, nil
+	}
+
+	identityProviderStruct := sqlbuilder.NewStruct(new(entities.IdentityProvider)).
+		For(d.Flavor)
+
+	selectBuilder := identityProviderStruct.SelectFrom("identity_providers")
+	selectBuilder.Where(selectBuilder.In("id", sqlbuilder.Flatten(identityProviderIds)...))
+
+	return d.getIdentityProvidersCommon(tx, selectBuilder, identityProviderStruct)
+}
+
+func (d *CommonDatabase) GetAllIdentityProviders(tx *sql.Tx) ([]entities.IdentityProvider, error) {
+
+	identityProviderStruct := sqlbuilder.NewStruct(new(entities.IdentityProvider)).
+		For(d.Flavor)
+
+	selectBuilder := identityProviderStruct.SelectFrom("identity_providers")
+	selectBuilder.OrderBy("name").Asc()
+
+	return d.getIdentityProvidersCommon(tx, selectBuilder, identityProviderStruct)
+}
+
+func (d *CommonDatabase) GetEnabledIdentityProviders(tx *sql.Tx) ([]entities.IdentityProvider, error) {
+
+	identityProviderStruct := sqlbuilder.NewStruct(new(entities.IdentityProvider)).
+		For(d.Flavor)
+
+	selectBuilder := identityProviderStruct.SelectFrom("identity_providers")
+	selectBuilder.Where(selectBuilder.Equal("enabled", true))
+	selectBuilder.OrderBy("name").Asc()
+
+	return d.getIdentityProvidersCommon(tx, selectBuilder, identityProviderStruct)
+}
+
+func (d *CommonDatabase) DeleteIdentityProvider(tx *sql.Tx, identityProviderId int64) error {
+
+	identityProviderStruct := sqlbuilder.NewStruct(new(entities.IdentityProvider)).
+		For(d.Flavor)
+
+	deleteBuilder := identityProviderStruct.DeleteFrom("identity_providers")
+	deleteBuilder.Where(deleteBuilder.Equal("id", identityProviderId))
+
+	sql, args := deleteBuilder.Build()
+	_, err := d.ExecSql(tx, sql, args...)
+	if err != nil {
+		return errors.Wrap(err, "unable to delete identity provider")
+	}
+
+	return nil
+}
@@ -25,20 +25,13 @@ func (d *CommonDatabase) CreateKeyPair(tx *sql.Tx, keyPair *entities.KeyPair) er
 	insertBuilder := keyPairStruct.WithoutTag("pk").InsertInto("key_pairs", keyPair)
 
 	sql, args := insertBuilder.Build()
-	result, err := d.ExecSql(tx, sql, args...)
+	id, err := d.ExecInsertSql(tx, sql, args...)
 	if err != nil {
 		keyPair.CreatedAt = originalCreatedAt
 		keyPair.UpdatedAt = originalUpdatedAt
 		return errors.Wrap(err, "unable to insert keyPair")
 	}
 
-	id, err := result.LastInsertId()
-	if err != nil {
-		keyPair.CreatedAt = originalCreatedAt
-		keyPair.UpdatedAt = originalUpdatedAt
-		return errors.Wrap(err, "unable to get last insert id")
-	}
-
 	keyPair.Id = id
 	return nil
 }
@@ -28,20 +28,13 @@ func (d *CommonDatabase) CreatePermission(tx *sql.Tx, permission *entities.Permi
 	insertBuilder := permissionStruct.WithoutTag("pk").InsertInto("permissions", permission)
 
 	sql, args := insertBuilder.Build()
-	result, err := d.ExecSql(tx, sql, args...)
+	id, err := d.ExecInsertSql(tx, sql, args...)
 	if err != nil {
 		permission.CreatedAt = originalCreatedAt
 		permission.UpdatedAt = originalUpdatedAt
 		return errors.Wrap(err, "unable to insert permission")
 	}
 
-	id, err := result.LastInsertId()
-	if err != nil {
-		permission.CreatedAt = originalCreatedAt
-		permission.UpdatedAt = originalUpdatedAt
-		return errors.Wrap(err, "unable to get last insert id")
-	}
-
 	permission.Id = id
 	return nil
 }
@@ -24,20 +24,13 @@ func (d *CommonDatabase) CreatePreRegistration(tx *sql.Tx, preRegistration *enti
 	insertBuilder := preRegistrationStruct.WithoutTag("pk").InsertInto("pre_registrations", preRegistration)
 
 	sql, args := insertBuilder.Build()
-	result, err := d.ExecSql(tx, sql, args...)
+	id, err := d.ExecInsertSql(tx, sql, args...)
 	if err != nil {
 		preRegistration.CreatedAt = originalCreatedAt
 		preRegistration.UpdatedAt = originalUpdatedAt
 		return errors.Wrap(err, "unable to insert preRegistration")
 	}
 
-	id, err := result.LastInsertId()
-	if err != nil {
-		preRegistration.CreatedAt = originalCreatedAt
-		preRegistration.UpdatedAt = originalUpdatedAt
-		return errors.Wrap(err, "unable to get last insert id")
-	}
-
 	preRegistration.Id = id
 	return nil
 }
@@ -26,18 +26,12 @@ func (d *CommonDatabase) CreateRedirectURI(tx *sql.Tx, redirectURI *entities.Red
 	insertBuilder := redirectURIStruct.WithoutTag("pk").InsertInto("redirect_uris", redirectURI)
 
 	sql, args := insertBuilder.Build()
-	result, err := d.ExecSql(tx, sql, args...)
+	id, err := d.ExecInsertSql(tx, sql, args...)
 	if err != nil {
 		redirectURI.CreatedAt = originalCreatedAt
 		return errors.Wrap(err, "unable to insert redirectURI")
 	}
 
-	id, err := result.LastInsertId()
-	if err != nil {
-		redirectURI.CreatedAt = originalCreatedAt
-		return errors.Wrap(err, "unable to get last insert id")
-	}
-
 	redirectURI.Id = id
 	return nil
 }
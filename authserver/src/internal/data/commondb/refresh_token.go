@@ -24,20 +24,13 @@ func (d *CommonDatabase) CreateRefreshToken(tx *sql.Tx, refreshToken *entities.R
 	insertBuilder := refreshTokenStruct.WithoutTag("pk").InsertInto("refresh_tokens", refreshToken)
 
 	sql, args := insertBuilder.Build()
-	result, err := d.ExecSql(tx, sql, args...)
+	id, err := d.ExecInsertSql(tx, sql, args...)
 	if err != nil {
 		refreshToken.CreatedAt = originalCreatedAt
 		refreshToken.UpdatedAt = originalUpdatedAt
 		return errors.Wrap(err, "unable to insert refreshToken")
 	}
 
-	id, err := result.LastInsertId()
-	if err != nil {
-		refreshToken.CreatedAt = originalCreatedAt
-		refreshToken.UpdatedAt = originalUpdatedAt
-		return errors.Wrap(err, "unable to get last insert id")
-	}
-
 	refreshToken.Id = id
 	return nil
 }
@@ -24,20 +24,13 @@ func (d *CommonDatabase) CreateResource(tx *sql.Tx, resource *entities.Resource)
 	insertBuilder := resourceStruct.WithoutTag("pk").InsertInto("resources", resource)
 
 	sql, args := insertBuilder.Build()
-	result, err := d.ExecSql(tx, sql, args...)
+	id, err := d.ExecInsertSql(tx, sql, args...)
 	if err != nil {
 		resource.CreatedAt = originalCreatedAt
 		resource.UpdatedAt = originalUpdatedAt
 		return errors.Wrap(err, "unable to insert resource")
 	}
 
-	id, err := result.LastInsertId()
-	if err != nil {
-		resource.CreatedAt = originalCreatedAt
-		resource.UpdatedAt = originalUpdatedAt
-		return errors.Wrap(err, "unable to get last insert id")
-	}
-
 	resource.Id = id
 	return nil
 }
@@ -161,7 +154,7 @@ func (d *CommonDatabase) GetAllResources(tx *sql.Tx) ([]entities.Resource, error
 	selectBuilder := resourceStruct.SelectFrom("resources")
 
 	sql, args := selectBuilder.Build()
-	rows, err := d.QuerySql(nil, sql, args...)
+	rows, err := d.QuerySql(tx, sql, args...)
 	if err != nil {
 		return nil, errors.Wrap(err, "unable to query database")
 	}
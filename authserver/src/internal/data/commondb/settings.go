@@ -24,20 +24,13 @@ func (d *CommonDatabase) CreateSettings(tx *sql.Tx, settings *entities.Settings)
 	insertBuilder := settingsStruct.WithoutTag("pk").InsertInto("settings", settings)
 
 	sql, args := insertBuilder.Build()
-	result, err := d.ExecSql(tx, sql, args...)
+	id, err := d.ExecInsertSql(tx, sql, args...)
 	if err != nil {
 		settings.CreatedAt = originalCreatedAt
 		settings.UpdatedAt = originalUpdatedAt
 		return errors.Wrap(err, "unable to insert settings")
 	}
 
-	id, err := result.LastInsertId()
-	if err != nil {
-		settings.CreatedAt = originalCreatedAt
-		settings.UpdatedAt = originalUpdatedAt
-		return errors.Wrap(err, "unable to get last insert id")
-	}
-
 	settings.Id = id
 	return nil
 }
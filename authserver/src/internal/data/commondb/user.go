@@ -24,20 +24,13 @@ func (d *CommonDatabase) CreateUser(tx *sql.Tx, user *entities.User) error {
 	insertBuilder := userStruct.WithoutTag("pk").InsertInto("users", user)
 
 	sql, args := insertBuilder.Build()
-	result, err := d.ExecSql(tx, sql, args...)
+	id, err := d.ExecInsertSql(tx, sql, args...)
 	if err != nil {
 		user.CreatedAt = originalCreatedAt
 		user.UpdatedAt = originalUpdatedAt
 		return errors.Wrap(err, "unable to insert user")
 	}
 
-	id, err := result.LastInsertId()
-	if err != nil {
-		user.CreatedAt = originalCreatedAt
-		user.UpdatedAt = originalUpdatedAt
-		return errors.Wrap(err, "unable to get last insert id")
-	}
-
 	user.Id = id
 	return nil
 }
@@ -188,6 +181,22 @@ func (d *CommonDatabase) UserLoadAttributes(tx *sql.Tx, user *entities.User) err
 	return nil
 }
 
+func (d *CommonDatabase) UserLoadPasskeys(tx *sql.Tx, user *entities.User) error {
+
+	if user == nil {
+		return nil
+	}
+
+	userPasskeys, err := d.GetUserPasskeysByUserId(tx, user.Id)
+	if err != nil {
+		return err
+	}
+
+	user.Passkeys = userPasskeys
+
+	return nil
+}
+
 func (d *CommonDatabase) UserLoadPermissions(tx *sql.Tx, user *entities.User) error {
 
 	if user == nil {
@@ -356,6 +365,37 @@ func (d *CommonDatabase) GetLastUserWithOTPState(tx *sql.Tx, otpEnabledState boo
 	return user, nil
 }
 
+// GetLockedUsers returns the users with a lockout time. The lockout may have already expired.
+func (d *CommonDatabase) GetLockedUsers(tx *sql.Tx) ([]entities.User, error) {
+
+	userStruct := sqlbuilder.NewStruct(new(entities.User)).
+		For(d.Flavor)
+
+	selectBuilder := userStruct.SelectFrom("users")
+	selectBuilder.Where(selectBuilder.IsNotNull("locked_until"))
+	selectBuilder.OrderBy("locked_until").Desc()
+
+	sql, args := selectBuilder.Build()
+	rows, err := d.QuerySql(tx, sql, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to query database")
+	}
+	defer rows.Close()
+
+	users := []entities.User{}
+	for rows.Next() {
+		var user entities.User
+		addr := userStruct.Addr(&user)
+		err = rows.Scan(addr...)
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to scan user")
+		}
+		users = append(users, user)
+	}
+
+	return users, nil
+}
+
 func (d *CommonDatabase) SearchUsersPaginated(tx *sql.Tx, query string, page int, pageSize int) ([]entities.User, int, error) {
 
 	if page < 1 {
@@ -374,12 +414,12 @@ func (d *CommonDatabase) SearchUsersPaginated(tx *sql.Tx, query string, page int
 	if query != "" {
 		selectBuilder.Where(
 			selectBuilder.Or(
-				selectBuilder.Like("subject", "%"+query+"%"),
-				selectBuilder.Like("username", "%"+query+"%"),
-				selectBuilder.Like("given_name", "%"+query+"%"),
-				selectBuilder.Like("middle_name", "%"+query+"%"),
-				selectBuilder.Like("family_name", "%"+query+"%"),
-				selectBuilder.Like("email", "%"+query+"%"),
+				d.Like(selectBuilder, "subject", "%"+query+"%"),
+				d.Like(selectBuilder, "username", "%"+query+"%"),
+				d.Like(selectBuilder, "given_name", "%"+query+"%"),
+				d.Like(selectBuilder, "middle_name", "%"+query+"%"),
+				d.Like(selectBuilder, "family_name", "%"+query+"%"),
+				d.Like(selectBuilder, "email", "%"+query+"%"),
 			),
 		)
 	}
@@ -412,12 +452,12 @@ func (d *CommonDatabase) SearchUsersPaginated(tx *sql.Tx, query string, page int
 	if query != "" {
 		selectBuilder.Where(
 			selectBuilder.Or(
-				selectBuilder.Like("subject", "%"+query+"%"),
-				selectBuilder.Like("username", "%"+query+"%"),
-				selectBuilder.Like("given_name", "%"+query+"%"),
-				selectBuilder.Like("middle_name", "%"+query+"%"),
-				selectBuilder.Like("family_name", "%"+query+"%"),
-				selectBuilder.Like("email", "%"+query+"%"),
+				d.Like(selectBuilder, "subject", "%"+query+"%"),
+				d.Like(selectBuilder, "username", "%"+query+"%"),
+				d.Like(selectBuilder, "given_name", "%"+query+"%"),
+				d.Like(selectBuilder, "middle_name", "%"+query+"%"),
+				d.Like(selectBuilder, "family_name", "%"+query+"%"),
+				d.Like(selectBuilder, "email", "%"+query+"%"),
 			),
 		)
 	}
@@ -28,20 +28,13 @@ func (d *CommonDatabase) CreateUserAttribute(tx *sql.Tx, userAttribute *entities
 	insertBuilder := userAttributeStruct.WithoutTag("pk").InsertInto("user_attributes", userAttribute)
 
 	sql, args := insertBuilder.Build()
-	result, err := d.ExecSql(tx, sql, args...)
+	id, err := d.ExecInsertSql(tx, sql, args...)
 	if err != nil {
 		userAttribute.CreatedAt = originalCreatedAt
 		userAttribute.UpdatedAt = originalUpdatedAt
 		return errors.Wrap(err, "unable to insert userAttribute")
 	}
 
-	id, err := result.LastInsertId()
-	if err != nil {
-		userAttribute.CreatedAt = originalCreatedAt
-		userAttribute.UpdatedAt = originalUpdatedAt
-		return errors.Wrap(err, "unable to get last insert id")
-	}
-
 	userAttribute.Id = id
 	return nil
 }
@@ -32,20 +32,13 @@ func (d *CommonDatabase) CreateUserConsent(tx *sql.Tx, userConsent *entities.Use
 	insertBuilder := userConsentStruct.WithoutTag("pk").InsertInto("user_consents", userConsent)
 
 	sql, args := insertBuilder.Build()
-	result, err := d.ExecSql(tx, sql, args...)
+	id, err := d.ExecInsertSql(tx, sql, args...)
 	if err != nil {
 		userConsent.CreatedAt = originalCreatedAt
 		userConsent.UpdatedAt = originalUpdatedAt
 		return errors.Wrap(err, "unable to insert userConsent")
 	}
 
-	id, err := result.LastInsertId()
-	if err != nil {
-		userConsent.CreatedAt = originalCreatedAt
-		userConsent.UpdatedAt = originalUpdatedAt
-		return errors.Wrap(err, "unable to get last insert id")
-	}
-
 	userConsent.Id = id
 	return nil
 }
@@ -0,0 +1,192 @@
+package commondb
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/huandu/go-sqlbuilder"
+	"github.com/leodip/goiabada/internal/entities"
+	"github.com/pkg/errors"
+)
+
+func (d *CommonDatabase) CreateUserFederatedIdentity(tx *sql.Tx, userFederatedIdentity *entities.UserFederatedIdentity) error {
+
+	if userFederatedIdentity.UserId == 0 {
+		return errors.WithStack(errors.New("can't create user federated identity with user_id 0"))
+	}
+
+	if userFederatedIdentity.IdentityProviderId == 0 {
+		return errors.WithStack(errors.New("can't create user federated identity with identity_provider_id 0"))
+	}
+
+	now := time.Now().UTC()
+
+	originalCreatedAt := userFederatedIdentity.CreatedAt
+	originalUpdatedAt := userFederatedIdentity.UpdatedAt
+	userFederatedIdentity.CreatedAt = sql.NullTime{Time: now, Valid: true}
+	userFederatedIdentity.UpdatedAt = sql.NullTime{Time: now, Valid: true}
+
+	userFederatedIdentityStruct := sqlbuilder.NewStruct(new(entities.UserFederatedIdentity)).
+		For(d.Flavor)
+
+	insertBuilder := userFederatedIdentityStruct.WithoutTag("pk").InsertInto("user_federated_identities", userFederatedIdentity)
+
+	sql, args := insertBuilder.Build()
+	id, err := d.ExecInsertSql(tx, sql, args...)
+	if err != nil {
+		userFederatedIdentity.CreatedAt = originalCreatedAt
+		userFederatedIdentity.UpdatedAt = originalUpdatedAt
+		return errors.Wrap(err, "unable to insert user federated identity")
+	}
+
+	userFederatedIdentity.Id = id
+	return nil
+}
+
+func (d *CommonDatabase) UpdateUserFederatedIdentity(tx *sql.Tx, userFederatedIdentity *entities.UserFederatedIdentity) error {
+
+	if userFederatedIdentity.Id == 0 {
+		return errors.WithStack(errors.New("can't update user federated identity with id 0"))
+	}
+
+	originalUpdatedAt := userFederatedIdentity.UpdatedAt
+	userFederatedIdentity.UpdatedAt = sql.NullTime{Time: time.Now().UTC(), Valid: true}
+
+	userFederatedIdentityStruct := sqlbuilder.NewStruct(new(entities.UserFederatedIdentity)).
+		For(d.Flavor)
+
+	updateBuilder := userFederatedIdentityStruct.WithoutTag("pk").Update("user_federated_identities", userFederatedIdentity)
+	updateBuilder.Where(updateBuilder.Equal("id", userFederatedIdentity.Id))
+
+	sql, args := updateBuilder.Build()
+	_, err := d.ExecSql(tx, sql, args...)
+	if err != nil {
+		userFederatedIdentity.UpdatedAt = originalUpdatedAt
+		return errors.Wrap(err, "unable to update user federated identity")
+	}
+
+	return nil
+}
+
+func (d *CommonDatabase) getUserFederatedIdentityCommon(tx *sql.Tx, selectBuilder *sqlbuilder.SelectBuilder,
+	userFederatedIdentityStruct *sqlbuilder.Struct) (*entities.UserFederatedIdentity, error) {
+
+	sql, args := selectBuilder.Build()
+	rows, err := d.QuerySql(tx, sql, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to query database")
+	}
+	defer rows.Close()
+
+	var userFederatedIdentity entities.UserFederatedIdentity
+	if rows.Next() {
+		addr := userFederatedIdentityStruct.Addr(&userFederatedIdentity)
+		err = rows.Scan(addr...)
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to scan user federated identity")
+		}
+		return &userFederatedIdentity, nil
+	}
+	return nil, nil
+}
+
+func (d *CommonDatabase) GetUserFederatedIdentityById(tx *sql.Tx, userFederatedIdentityId int64) (*entities.UserFederatedIdentity, error) {
+
+	userFederatedIdentityStruct := sqlbuilder.NewStruct(new(entities.UserFederatedIdentity)).
+		For(d.Flavor)
+
+	selectBuilder := userFederatedIdentityStruct.SelectFrom("user_federated_identities")
+	selectBuilder.Where(selectBuilder.Equal("id", userFederatedIdentityId))
+
+	return d.getUserFederatedIdentityCommon(tx, selectBuilder, userFederatedIdentityStruct)
+}
+
+func (d *CommonDatabase) GetUserFederatedIdentityByIdentityProviderIdAndSubject(tx *sql.Tx, identityProviderId int64,
+	subject string) (*entities.UserFederatedIdentity, error) {
+
+	userFederatedIdentityStruct := sqlbuilder.NewStruct(new(entities.UserFederatedIdentity)).
+		For(d.Flavor)
+
+	selectBuilder := userFederatedIdentityStruct.SelectFrom("user_federated_identities")
+	selectBuilder.Where(
+		selectBuilder.Equal("identity_provider_id", identityProviderId),
+		selectBuilder.Equal("subject", subject),
+	)
+
+	return d.getUserFederatedIdentityCommon(tx, selectBuilder, userFederatedIdentityStruct)
+}
+
+func (d *CommonDatabase) GetUserFederatedIdentitiesByUserId(tx *sql.Tx, userId int64) ([]entities.UserFederatedIdentity, error) {
+
+	userFederatedIdentityStruct := sqlbuilder.NewStruct(new(entities.UserFederatedIdentity)).
+		For(d.Flavor)
+
+	selectBuilder := userFederatedIdentityStruct.SelectFrom("user_federated_identities")
+	selectBuilder.Where(selectBuilder.Equal("user_id", userId))
+
+	sql, args := selectBuilder.Build()
+	rows, err := d.QuerySql(tx, sql, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to query database")
+	}
+	defer rows.Close()
+
+	userFederatedIdentities := make([]entities.UserFederatedIdentity, 0)
+	for rows.Next() {
+		var userFederatedIdentity entities.UserFederatedIdentity
+		addr := userFederatedIdentityStruct.Addr(&userFederatedIdentity)
+		err = rows.Scan(addr...)
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to scan user federated identity")
+		}
+		userFederatedIdentities = append(userFederatedIdentities, userFederatedIdentity)
+	}
+
+	return userFederatedIdentities, nil
+}
+
+func (d *CommonDatabase) UserFederatedIdentitiesLoadIdentityProviders(tx *sql.Tx,
+	userFederatedIdentities []entities.UserFederatedIdentity) error {
+
+	if userFederatedIdentities == nil {
+		return nil
+	}
+
+	identityProviderIds := make([]int64, 0, len(userFederatedIdentities))
+	for _, userFederatedIdentity := range userFederatedIdentities {
+		identityProviderIds = append(identityProviderIds, userFederatedIdentity.IdentityProviderId)
+	}
+
+	identityProviders, err := d.GetIdentityProvidersByIds(tx, identityProviderIds)
+	if err != nil {
+		return err
+	}
+
+	identityProvidersById := make(map[int64]entities.IdentityProvider)
+	for _, identityProvider := range identityProviders {
+		identityProvidersById[identityProvider.Id] = identityProvider
+	}
+
+	for i, userFederatedIdentity := range userFederatedIdentities {
+		userFederatedIdentities[i].IdentityProvider = identityProvidersById[userFederatedIdentity.IdentityProviderId]
+	}
+
+	return nil
+}
+
+func (d *CommonDatabase) DeleteUserFederatedIdentity(tx *sql.Tx, userFederatedIdentityId int64) error {
+
+	userFederatedIdentityStruct := sqlbuilder.NewStruct(new(entities.UserFederatedIdentity)).
+		For(d.Flavor)
+
+	deleteBuilder := userFederatedIdentityStruct.DeleteFrom("user_federated_identities")
+	deleteBuilder.Where(deleteBuilder.Equal("id", userFederatedIdentityId))
+
+	sql, args := deleteBuilder.Build()
+	_, err := d.ExecSql(tx, sql, args...)
+	if err != nil {
+		return errors.Wrap(err, "unable to delete user federated identity")
+	}
+
+	return nil
+}
@@ -32,20 +32,13 @@ func (d *CommonDatabase) CreateUserGroup(tx *sql.Tx, userGroup *entities.UserGro
 	insertBuilder := userGroupStruct.WithoutTag("pk").InsertInto("users_groups", userGroup)
 
 	sql, args := insertBuilder.Build()
-	result, err := d.ExecSql(tx, sql, args...)
+	id, err := d.ExecInsertSql(tx, sql, args...)
 	if err != nil {
 		userGroup.CreatedAt = originalCreatedAt
 		userGroup.UpdatedAt = originalUpdatedAt
 		return errors.Wrap(err, "unable to insert userGroup")
 	}
 
-	id, err := result.LastInsertId()
-	if err != nil {
-		userGroup.CreatedAt = originalCreatedAt
-		userGroup.UpdatedAt = originalUpdatedAt
-		return errors.Wrap(err, "unable to get last insert id")
-	}
-
 	userGroup.Id = id
 	return nil
 }
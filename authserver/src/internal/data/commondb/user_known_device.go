@@ -0,0 +1,95 @@
+package commondb
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/huandu/go-sqlbuilder"
+	"github.com/leodip/goiabada/internal/entities"
+	"github.com/pkg/errors"
+)
+
+func (d *CommonDatabase) CreateUserKnownDevice(tx *sql.Tx, userKnownDevice *entities.UserKnownDevice) error {
+
+	if userKnownDevice.UserId == 0 {
+		return errors.WithStack(errors.New("can't create user known device with user_id 0"))
+	}
+
+	now := time.Now().UTC()
+
+	originalCreatedAt := userKnownDevice.CreatedAt
+	originalUpdatedAt := userKnownDevice.UpdatedAt
+	userKnownDevice.CreatedAt = sql.NullTime{Time: now, Valid: true}
+	userKnownDevice.UpdatedAt = sql.NullTime{Time: now, Valid: true}
+
+	userKnownDeviceStruct := sqlbuilder.NewStruct(new(entities.UserKnownDevice)).
+		For(d.Flavor)
+
+	insertBuilder := userKnownDeviceStruct.WithoutTag("pk").InsertInto("user_known_devices", userKnownDevice)
+
+	sql, args := insertBuilder.Build()
+	id, err := d.ExecInsertSql(tx, sql, args...)
+	if err != nil {
+		userKnownDevice.CreatedAt = originalCreatedAt
+		userKnownDevice.UpdatedAt = originalUpdatedAt
+		return errors.Wrap(err, "unable to insert user known device")
+	}
+
+	userKnownDevice.Id = id
+	return nil
+}
+
+func (d *CommonDatabase) UpdateUserKnownDevice(tx *sql.Tx, userKnownDevice *entities.UserKnownDevice) error {
+
+	if userKnownDevice.Id == 0 {
+		return errors.WithStack(errors.New("can't update user known device with id 0"))
+	}
+
+	originalUpdatedAt := userKnownDevice.UpdatedAt
+	userKnownDevice.UpdatedAt = sql.NullTime{Time: time.Now().UTC(), Valid: true}
+
+	userKnownDeviceStruct := sqlbuilder.NewStruct(new(entities.UserKnownDevice)).
+		For(d.Flavor)
+
+	updateBuilder := userKnownDeviceStruct.WithoutTag("pk").Update("user_known_devices", userKnownDevice)
+	updateBuilder.Where(updateBuilder.Equal("id", userKnownDevice.Id))
+
+	sql, args := updateBuilder.Build()
+	_, err := d.ExecSql(tx, sql, args...)
+	if err != nil {
+		userKnownDevice.UpdatedAt = originalUpdatedAt
+		return errors.Wrap(err, "unable to update user known device")
+	}
+
+	return nil
+}
+
+func (d *CommonDatabase) GetUserKnownDevicesByUserId(tx *sql.Tx, userId int64) ([]entities.UserKnownDevice, error) {
+
+	userKnownDeviceStruct := sqlbuilder.NewStruct(new(entities.UserKnownDevice)).
+		For(d.Flavor)
+
+	selectBuilder := userKnownDeviceStruct.SelectFrom("user_known_devices")
+	selectBuilder.Where(selectBuilder.Equal("user_id", userId))
+	selectBuilder.OrderBy("id")
+
+	sql, args := selectBuilder.Build()
+	rows, err := d.QuerySql(tx, sql, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to query database")
+	}
+	defer rows.Close()
+
+	userKnownDevices := make([]entities.UserKnownDevice, 0)
+	for rows.Next() {
+		var userKnownDevice entities.UserKnownDevice
+		addr := userKnownDeviceStruct.Addr(&userKnownDevice)
+		err = rows.Scan(addr...)
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to scan user known device")
+		}
+		userKnownDevices = append(userKnownDevices, userKnownDevice)
+	}
+
+	return userKnownDevices, nil
+}
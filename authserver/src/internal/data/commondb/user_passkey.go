@@ -0,0 +1,139 @@
+package commondb
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/huandu/go-sqlbuilder"
+	"github.com/leodip/goiabada/internal/entities"
+	"github.com/pkg/errors"
+)
+
+func (d *CommonDatabase) CreateUserPasskey(tx *sql.Tx, userPasskey *entities.UserPasskey) error {
+
+	if userPasskey.UserId == 0 {
+		return errors.WithStack(errors.New("can't create user passkey with user_id 0"))
+	}
+
+	now := time.Now().UTC()
+
+	originalCreatedAt := userPasskey.CreatedAt
+	originalUpdatedAt := userPasskey.UpdatedAt
+	userPasskey.CreatedAt = sql.NullTime{Time: now, Valid: true}
+	userPasskey.UpdatedAt = sql.NullTime{Time: now, Valid: true}
+
+	userPasskeyStruct := sqlbuilder.NewStruct(new(entities.UserPasskey)).
+		For(d.Flavor)
+
+	insertBuilder := userPasskeyStruct.WithoutTag("pk").InsertInto("user_passkeys", userPasskey)
+
+	sql, args := insertBuilder.Build()
+	id, err := d.ExecInsertSql(tx, sql, args...)
+	if err != nil {
+		userPasskey.CreatedAt = originalCreatedAt
+		userPasskey.UpdatedAt = originalUpdatedAt
+		return errors.Wrap(err, "unable to insert user passkey")
+	}
+
+	userPasskey.Id = id
+	return nil
+}
+
+func (d *CommonDatabase) UpdateUserPasskey(tx *sql.Tx, userPasskey *entities.UserPasskey) error {
+
+	if userPasskey.Id == 0 {
+		return errors.WithStack(errors.New("can't update user passkey with id 0"))
+	}
+
+	originalUpdatedAt := userPasskey.UpdatedAt
+	userPasskey.UpdatedAt = sql.NullTime{Time: time.Now().UTC(), Valid: true}
+
+	userPasskeyStruct := sqlbuilder.NewStruct(new(entities.UserPasskey)).
+		For(d.Flavor)
+
+	updateBuilder := userPasskeyStruct.WithoutTag("pk").Update("user_passkeys", userPasskey)
+	updateBuilder.Where(updateBuilder.Equal("id", userPasskey.Id))
+
+	sql, args := updateBuilder.Build()
+	_, err := d.ExecSql(tx, sql, args...)
+	if err != nil {
+		userPasskey.UpdatedAt = originalUpdatedAt
+		return errors.Wrap(err, "unable to update user passkey")
+	}
+
+	return nil
+}
+
+func (d *CommonDatabase) GetUserPasskeyById(tx *sql.Tx, userPasskeyId int64) (*entities.UserPasskey, error) {
+
+	userPasskeyStruct := sqlbuilder.NewStruct(new(entities.UserPasskey)).
+		For(d.Flavor)
+
+	selectBuilder := userPasskeyStruct.SelectFrom("user_passkeys")
+	selectBuilder.Where(selectBuilder.Equal("id", userPasskeyId))
+
+	sql, args := selectBuilder.Build()
+	rows, err := d.QuerySql(tx, sql, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to query database")
+	}
+	defer rows.Close()
+
+	var userPasskey entities.UserPasskey
+	if rows.Next() {
+		addr := userPasskeyStruct.Addr(&userPasskey)
+		err = rows.Scan(addr...)
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to scan user passkey")
+		}
+		return &userPasskey, nil
+	}
+	return nil, nil
+}
+
+func (d *CommonDatabase) GetUserPasskeysByUserId(tx *sql.Tx, userId int64) ([]entities.UserPasskey, error) {
+
+	userPasskeyStruct := sqlbuilder.NewStruct(new(entities.UserPasskey)).
+		For(d.Flavor)
+
+	selectBuilder := userPasskeyStruct.SelectFrom("user_passkeys")
+	selectBuilder.Where(selectBuilder.Equal("user_id", userId))
+	selectBuilder.OrderBy("id")
+
+	sql, args := selectBuilder.Build()
+	rows, err := d.QuerySql(tx, sql, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to query database")
+	}
+	defer rows.Close()
+
+	userPasskeys := make([]entities.UserPasskey, 0)
+	for rows.Next() {
+		var userPasskey entities.UserPasskey
+		addr := userPasskeyStruct.Addr(&userPasskey)
+		err = rows.Scan(addr...)
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to scan user passkey")
+		}
+		userPasskeys = append(userPasskeys, userPasskey)
+	}
+
+	return userPasskeys, nil
+}
+
+func (d *CommonDatabase) DeleteUserPasskey(tx *sql.Tx, userPasskeyId int64) error {
+
+	userPasskeyStruct := sqlbuilder.NewStruct(new(entities.UserPasskey)).
+		For(d.Flavor)
+
+	deleteBuilder := userPasskeyStruct.DeleteFrom("user_passkeys")
+	deleteBuilder.Where(deleteBuilder.Equal("id", userPasskeyId))
+
+	sql, args := deleteBuilder.Build()
+	_, err := d.ExecSql(tx, sql, args...)
+	if err != nil {
+		return errors.Wrap(err, "unable to delete user passkey")
+	}
+
+	return nil
+}
@@ -0,0 +1,83 @@
+package commondb
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/huandu/go-sqlbuilder"
+	"github.com/leodip/goiabada/internal/entities"
+	"github.com/pkg/errors"
+)
+
+func (d *CommonDatabase) CreateUserPasswordHistory(tx *sql.Tx, userPasswordHistory *entities.UserPasswordHistory) error {
+
+	if userPasswordHistory.UserId == 0 {
+		return errors.WithStack(errors.New("can't create user password history with user_id 0"))
+	}
+
+	originalCreatedAt := userPasswordHistory.CreatedAt
+	userPasswordHistory.CreatedAt = sql.NullTime{Time: time.Now().UTC(), Valid: true}
+
+	userPasswordHistoryStruct := sqlbuilder.NewStruct(new(entities.UserPasswordHistory)).
+		For(d.Flavor)
+
+	insertBuilder := userPasswordHistoryStruct.WithoutTag("pk").InsertInto("user_password_history", userPasswordHistory)
+
+	sql, args := insertBuilder.Build()
+	id, err := d.ExecInsertSql(tx, sql, args...)
+	if err != nil {
+		userPasswordHistory.CreatedAt = originalCreatedAt
+		return errors.Wrap(err, "unable to insert user password history")
+	}
+
+	userPasswordHistory.Id = id
+	return nil
+}
+
+// GetUserPasswordHistoryByUserId returns the previous passwords of the user, the most recent first.
+func (d *CommonDatabase) GetUserPasswordHistoryByUserId(tx *sql.Tx, userId int64) ([]entities.UserPasswordHistory, error) {
+
+	userPasswordHistoryStruct := sqlbuilder.NewStruct(new(entities.UserPasswordHistory)).
+		For(d.Flavor)
+
+	selectBuilder := userPasswordHistoryStruct.SelectFrom("user_password_history")
+	selectBuilder.Where(selectBuilder.Equal("user_id", userId))
+	selectBuilder.OrderBy("id").Desc()
+
+	sql, args := selectBuilder.Build()
+	rows, err := d.QuerySql(tx, sql, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to query database")
+	}
+	defer rows.Close()
+
+	userPasswordHistory := make([]entities.UserPasswordHistory, 0)
+	for rows.Next() {
+		var item entities.UserPasswordHistory
+		addr := userPasswordHistoryStruct.Addr(&item)
+		err = rows.Scan(addr...)
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to scan user password history")
+		}
+		userPasswordHistory = append(userPasswordHistory, item)
+	}
+
+	return userPasswordHistory, nil
+}
+
+func (d *CommonDatabase) DeleteUserPasswordHistory(tx *sql.Tx, userPasswordHistoryId int64) error {
+
+	userPasswordHistoryStruct := sqlbuilder.NewStruct(new(entities.UserPasswordHistory)).
+		For(d.Flavor)
+
+	deleteBuilder := userPasswordHistoryStruct.DeleteFrom("user_password_history")
+	deleteBuilder.Where(deleteBuilder.Equal("id", userPasswordHistoryId))
+
+	sql, args := deleteBuilder.Build()
+	_, err := d.ExecSql(tx, sql, args...)
+	if err != nil {
+		return errors.Wrap(err, "unable to delete user password history")
+	}
+
+	return nil
+}
@@ -32,20 +32,13 @@ func (d *CommonDatabase) CreateUserPermission(tx *sql.Tx, userPermission *entiti
 	insertBuilder := userPermissionStruct.WithoutTag("pk").InsertInto("users_permissions", userPermission)
 
 	sql, args := insertBuilder.Build()
-	result, err := d.ExecSql(tx, sql, args...)
+	id, err := d.ExecInsertSql(tx, sql, args...)
 	if err != nil {
 		userPermission.CreatedAt = originalCreatedAt
 		userPermission.UpdatedAt = originalUpdatedAt
 		return errors.Wrap(err, "unable to insert userPermission")
 	}
 
-	id, err := result.LastInsertId()
-	if err != nil {
-		userPermission.CreatedAt = originalCreatedAt
-		userPermission.UpdatedAt = originalUpdatedAt
-		return errors.Wrap(err, "unable to get last insert id")
-	}
-
 	userPermission.Id = id
 	return nil
 }
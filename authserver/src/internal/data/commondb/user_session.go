@@ -28,20 +28,13 @@ func (d *CommonDatabase) CreateUserSession(tx *sql.Tx, userSession *entities.Use
 	insertBuilder := userSessionStruct.WithoutTag("pk").InsertInto("user_sessions", userSession)
 
 	sql, args := insertBuilder.Build()
-	result, err := d.ExecSql(tx, sql, args...)
+	id, err := d.ExecInsertSql(tx, sql, args...)
 	if err != nil {
 		userSession.CreatedAt = originalCreatedAt
 		userSession.UpdatedAt = originalUpdatedAt
 		return errors.Wrap(err, "unable to insert userSession")
 	}
 
-	id, err := result.LastInsertId()
-	if err != nil {
-		userSession.CreatedAt = originalCreatedAt
-		userSession.UpdatedAt = originalUpdatedAt
-		return errors.Wrap(err, "unable to get last insert id")
-	}
-
 	userSession.Id = id
 	return nil
 }
@@ -24,20 +24,13 @@ func (d *CommonDatabase) CreateUserSessionClient(tx *sql.Tx, userSessionClient *
 	insertBuilder := userSessionClientStruct.WithoutTag("pk").InsertInto("user_session_clients", userSessionClient)
 
 	sql, args := insertBuilder.Build()
-	result, err := d.ExecSql(tx, sql, args...)
+	id, err := d.ExecInsertSql(tx, sql, args...)
 	if err != nil {
 		userSessionClient.CreatedAt = originalCreatedAt
 		userSessionClient.UpdatedAt = originalUpdatedAt
 		return errors.Wrap(err, "unable to insert userSessionClient")
 	}
 
-	id, err := result.LastInsertId()
-	if err != nil {
-		userSessionClient.CreatedAt = originalCreatedAt
-		userSessionClient.UpdatedAt = originalUpdatedAt
-		return errors.Wrap(err, "unable to get last insert id")
-	}
-
 	userSessionClient.Id = id
 	return nil
 }
@@ -0,0 +1,166 @@
+package commondb
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/huandu/go-sqlbuilder"
+	"github.com/leodip/goiabada/internal/entities"
+	"github.com/pkg/errors"
+)
+
+func (d *CommonDatabase) CreateUserTrustedDevice(tx *sql.Tx, userTrustedDevice *entities.UserTrustedDevice) error {
+
+	if userTrustedDevice.UserId == 0 {
+		return errors.WithStack(errors.New("can't create user trusted device with user_id 0"))
+	}
+
+	now := time.Now().UTC()
+
+	originalCreatedAt := userTrustedDevice.CreatedAt
+	originalUpdatedAt := userTrustedDevice.UpdatedAt
+	userTrustedDevice.CreatedAt = sql.NullTime{Time: now, Valid: true}
+	userTrustedDevice.UpdatedAt = sql.NullTime{Time: now, Valid: true}
+
+	userTrustedDeviceStruct := sqlbuilder.NewStruct(new(entities.UserTrustedDevice)).
+		For(d.Flavor)
+
+	insertBuilder := userTrustedDeviceStruct.WithoutTag("pk").InsertInto("user_trusted_devices", userTrustedDevice)
+
+	sql, args := insertBuilder.Build()
+	id, err := d.ExecInsertSql(tx, sql, args...)
+	if err != nil {
+		userTrustedDevice.CreatedAt = originalCreatedAt
+		userTrustedDevice.UpdatedAt = originalUpdatedAt
+		return errors.Wrap(err, "unable to insert user trusted device")
+	}
+
+	userTrustedDevice.Id = id
+	return nil
+}
+
+func (d *CommonDatabase) UpdateUserTrustedDevice(tx *sql.Tx, userTrustedDevice *entities.UserTrustedDevice) error {
+
+	if userTrustedDevice.Id == 0 {
+		return errors.WithStack(errors.New("can't update user trusted device with id 0"))
+	}
+
+	originalUpdatedAt := userTrustedDevice.UpdatedAt
+	userTrustedDevice.UpdatedAt = sql.NullTime{Time: time.Now().UTC(), Valid: true}
+
+	userTrustedDeviceStruct := sqlbuilder.NewStruct(new(entities.UserTrustedDevice)).
+		For(d.Flavor)
+
+	updateBuilder := userTrustedDeviceStruct.WithoutTag("pk").Update("user_trusted_devices", userTrustedDevice)
+	updateBuilder.Where(updateBuilder.Equal("id", userTrustedDevice.Id))
+
+	sql, args := updateBuilder.Build()
+	_, err := d.ExecSql(tx, sql, args...)
+	if err != nil {
+		userTrustedDevice.UpdatedAt = originalUpdatedAt
+		return errors.Wrap(err, "unable to update user trusted device")
+	}
+
+	return nil
+}
+
+func (d *CommonDatabase) GetUserTrustedDeviceById(tx *sql.Tx, userTrustedDeviceId int64) (*entities.UserTrustedDevice, error) {
+
+	userTrustedDeviceStruct := sqlbuilder.NewStruct(new(entities.UserTrustedDevice)).
+		For(d.Flavor)
+
+	selectBuilder := userTrustedDeviceStruct.SelectFrom("user_trusted_devices")
+	selectBuilder.Where(selectBuilder.Equal("id", userTrustedDeviceId))
+
+	sql, args := selectBuilder.Build()
+	rows, err := d.QuerySql(tx, sql, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to query database")
+	}
+	defer rows.Close()
+
+	var userTrustedDevice entities.UserTrustedDevice
+	if rows.Next() {
+		addr := userTrustedDeviceStruct.Addr(&userTrustedDevice)
+		err = rows.Scan(addr...)
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to scan user trusted device")
+		}
+		return &userTrustedDevice, nil
+	}
+	return nil, nil
+}
+
+func (d *CommonDatabase) GetUserTrustedDeviceByIdentifierHash(tx *sql.Tx, identifierHash string) (*entities.UserTrustedDevice, error) {
+
+	userTrustedDeviceStruct := sqlbuilder.NewStruct(new(entities.UserTrustedDevice)).
+		For(d.Flavor)
+
+	selectBuilder := userTrustedDeviceStruct.SelectFrom("user_trusted_devices")
+	selectBuilder.Where(selectBuilder.Equal("identifier_hash", identifierHash))
+
+	sql, args := selectBuilder.Build()
+	rows, err := d.QuerySql(tx, sql, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to query database")
+	}
+	defer rows.Close()
+
+	var userTrustedDevice entities.UserTrustedDevice
+	if rows.Next() {
+		addr := userTrustedDeviceStruct.Addr(&userTrustedDevice)
+		err = rows.Scan(addr...)
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to scan user trusted device")
+		}
+		return &userTrustedDevice, nil
+	}
+	return nil, nil
+}
+
+func (d *CommonDatabase) GetUserTrustedDevicesByUserId(tx *sql.Tx, userId int64) ([]entities.UserTrustedDevice, error) {
+
+	userTrustedDeviceStruct := sqlbuilder.NewStruct(new(entities.UserTrustedDevice)).
+		For(d.Flavor)
+
+	selectBuilder := userTrustedDeviceStruct.SelectFrom("user_trusted_devices")
+	selectBuilder.Where(selectBuilder.Equal("user_id", userId))
+	selectBuilder.OrderBy("id")
+
+	sql, args := selectBuilder.Build()
+	rows, err := d.QuerySql(tx, sql, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to query database")
+	}
+	defer rows.Close()
+
+	userTrustedDevices := make([]entities.UserTrustedDevice, 0)
+	for rows.Next() {
+		var userTrustedDevice entities.UserTrustedDevice
+		addr := userTrustedDeviceStruct.Addr(&userTrustedDevice)
+		err = rows.Scan(addr...)
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to scan user trusted device")
+		}
+		userTrustedDevices = append(userTrustedDevices, userTrustedDevice)
+	}
+
+	return userTrustedDevices, nil
+}
+
+func (d *CommonDatabase) DeleteUserTrustedDevice(tx *sql.Tx, userTrustedDeviceId int64) error {
+
+	userTrustedDeviceStruct := sqlbuilder.NewStruct(new(entities.UserTrustedDevice)).
+		For(d.Flavor)
+
+	deleteBuilder := userTrustedDeviceStruct.DeleteFrom("user_trusted_devices")
+	deleteBuilder.Where(deleteBuilder.Equal("id", userTrustedDeviceId))
+
+	sql, args := deleteBuilder.Build()
+	_, err := d.ExecSql(tx, sql, args...)
+	if err != nil {
+		return errors.Wrap(err, "unable to delete user trusted device")
+	}
+
+	return nil
+}
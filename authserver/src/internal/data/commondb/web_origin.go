@@ -26,18 +26,12 @@ func (d *CommonDatabase) CreateWebOrigin(tx *sql.Tx, webOrigin *entities.WebOrig
 	insertBuilder := webOriginStruct.WithoutTag("pk").InsertInto("web_origins", webOrigin)
 
 	sql, args := insertBuilder.Build()
-	result, err := d.ExecSql(tx, sql, args...)
+	id, err := d.ExecInsertSql(tx, sql, args...)
 	if err != nil {
 		webOrigin.CreatedAt = originalCreatedAt
 		return errors.Wrap(err, "unable to insert webOrigin")
 	}
 
-	id, err := result.LastInsertId()
-	if err != nil {
-		webOrigin.CreatedAt = originalCreatedAt
-		return errors.Wrap(err, "unable to get last insert id")
-	}
-
 	webOrigin.Id = id
 	return nil
 }
@@ -0,0 +1,155 @@
+package commondb
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/huandu/go-sqlbuilder"
+	"github.com/leodip/goiabada/internal/entities"
+	"github.com/pkg/errors"
+)
+
+func (d *CommonDatabase) CreateWebhook(tx *sql.Tx, webhook *entities.Webhook) error {
+
+	now := time.Now().UTC()
+
+	originalCreatedAt := webhook.CreatedAt
+	originalUpdatedAt := webhook.UpdatedAt
+	webhook.CreatedAt = sql.NullTime{Time: now, Valid: true}
+	webhook.UpdatedAt = sql.NullTime{Time: now, Valid: true}
+
+	webhookStruct := sqlbuilder.NewStruct(new(entities.Webhook)).
+		For(d.Flavor)
+
+	insertBuilder := webhookStruct.WithoutTag("pk").InsertInto("webhooks", webhook)
+
+	sql, args := insertBuilder.Build()
+	id, err := d.ExecInsertSql(tx, sql, args...)
+	if err != nil {
+		webhook.CreatedAt = originalCreatedAt
+		webhook.UpdatedAt = originalUpdatedAt
+		return errors.Wrap(err, "unable to insert webhook")
+	}
+
+	webhook.Id = id
+	return nil
+}
+
+func (d *CommonDatabase) UpdateWebhook(tx *sql.Tx, webhook *entities.Webhook) error {
+
+	if webhook.Id == 0 {
+		return errors.WithStack(errors.New("can't update webhook with id 0"))
+	}
+
+	originalUpdatedAt := webhook.UpdatedAt
+	webhook.UpdatedAt = sql.NullTime{Time: time.Now().UTC(), Valid: true}
+
+	webhookStruct := sqlbuilder.NewStruct(new(entities.Webhook)).
+		For(d.Flavor)
+
+	updateBuilder := webhookStruct.WithoutTag("pk").Update("webhooks", webhook)
+	updateBuilder.Where(updateBuilder.Equal("id", webhook.Id))
+
+	sql, args := updateBuilder.Build()
+	_, err := d.ExecSql(tx, sql, args...)
+	if err != nil {
+		webhook.UpdatedAt = originalUpdatedAt
+		return errors.Wrap(err, "unable to update webhook")
+	}
+
+	return nil
+}
+
+func (d *CommonDatabase) getWebhooksCommon(tx *sql.Tx, selectBuilder *sqlbuilder.SelectBuilder,
+	webhookStruct *sqlbuilder.Struct) ([]entities.Webhook, error) {
+
+	sql, args := selectBuilder.Build()
+	rows, err := d.QuerySql(tx, sql, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to query database")
+	}
+	defer rows.Close()
+
+	webhooks := make([]entities.Webhook, 0)
+	for rows.Next() {
+		var webhook entities.Webhook
+		addr := webhookStruct.Addr(&webhook)
+		err = rows.Scan(addr...)
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to scan webhook")
+		}
+		webhooks = append(webhooks, webhook)
+	}
+
+	return webhooks, nil
+}
+
+func (d *CommonDatabase) GetWebhookById(tx *sql.Tx, webhookId int64) (*entities.Webhook, error) {
+
+	webhookStruct := sqlbuilder.NewStruct(new(entities.Webhook)).
+		For(d.Flavor)
+
+	selectBuilder := webhookStruct.SelectFrom("webhooks")
+	selectBuilder.Where(selectBuilder.Equal("id", webhookId))
+
+	webhooks, err := d.getWebhooksCommon(tx, selectBuilder, webhookStruct)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(webhooks) == 0 {
+		return nil, nil
+	}
+	return &webhooks[0], nil
+}
+
+func (d *CommonDatabase) GetAllWebhooks(tx *sql.Tx) ([]entities.Webhook, error) {
+
+	webhookStruct := sqlbuilder.NewStruct(new(entities.Webhook)).
+		For(d.Flavor)
+
+	selectBuilder := webhookStruct.SelectFrom("webhooks")
+	selectBuilder.OrderBy("id").Asc()
+
+	return d.getWebhooksCommon(tx, selectBuilder, webhookStruct)
+}
+
+func (d *CommonDatabase) GetEnabledWebhooks(tx *sql.Tx) ([]entities.Webhook, error) {
+
+	webhookStruct := sqlbuilder.NewStruct(new(entities.Webhook)).
+		For(d.Flavor)
+
+	selectBuilder := webhookStruct.SelectFrom("webhooks")
+	selectBuilder.Where(selectBuilder.Equal("enabled", true))
+	selectBuilder.OrderBy("id").Asc()
+
+	return d.getWebhooksCommon(tx, selectBuilder, webhookStruct)
+}
+
+// DeleteWebhook deletes the webhook and its deliveries.
+func (d *CommonDatabase) DeleteWebhook(tx *sql.Tx, webhookId int64) error {
+
+	deleteBuilder := d.Flavor.NewDeleteBuilder()
+	deleteBuilder.DeleteFrom("webhook_deliveries")
+	deleteBuilder.Where(deleteBuilder.Equal("webhook_id", webhookId))
+
+	sql, args := deleteBuilder.Build()
+	_, err := d.ExecSql(tx, sql, args...)
+	if err != nil {
+		return errors.Wrap(err, "unable to delete webhook deliveries")
+	}
+
+	webhookStruct := sqlbuilder.NewStruct(new(entities.Webhook)).
+		For(d.Flavor)
+
+	deleteBuilder = webhookStruct.DeleteFrom("webhooks")
+	deleteBuilder.Where(deleteBuilder.Equal("id", webhookId))
+
+	sql, args = deleteBuilder.Build()
+	_, err = d.ExecSql(tx, sql, args...)
+	if err != nil {
+		return errors.Wrap(err, "unable to delete webhook")
+	}
+
+	return nil
+}
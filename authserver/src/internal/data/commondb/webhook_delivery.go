@@ -0,0 +1,199 @@
+package commondb
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/huandu/go-sqlbuilder"
+	"github.com/leodip/goiabada/internal/constants"
+	"github.com/leodip/goiabada/internal/entities"
+	"github.com/pkg/errors"
+)
+
+func (d *CommonDatabase) CreateWebhookDelivery(tx *sql.Tx, webhookDelivery *entities.WebhookDelivery) error {
+
+	if webhookDelivery.WebhookId == 0 {
+		return errors.WithStack(errors.New("can't create webhook delivery with webhook id 0"))
+	}
+
+	now := time.Now().UTC()
+
+	originalCreatedAt := webhookDelivery.CreatedAt
+	originalUpdatedAt := webhookDelivery.UpdatedAt
+	webhookDelivery.CreatedAt = sql.NullTime{Time: now, Valid: true}
+	webhookDelivery.UpdatedAt = sql.NullTime{Time: now, Valid: true}
+
+	webhookDeliveryStruct := sqlbuilder.NewStruct(new(entities.WebhookDelivery)).
+		For(d.Flavor)
+
+	insertBuilder := webhookDeliveryStruct.WithoutTag("pk").InsertInto("webhook_deliveries", webhookDelivery)
+
+	sql, args := insertBuilder.Build()
+	id, err := d.ExecInsertSql(tx, sql, args...)
+	if err != nil {
+		webhookDelivery.CreatedAt = originalCreatedAt
+		webhookDelivery.UpdatedAt = originalUpdatedAt
+		return errors.Wrap(err, "unable to insert webhook delivery")
+	}
+
+	webhookDelivery.Id = id
+	return nil
+}
+
+func (d *CommonDatabase) UpdateWebhookDelivery(tx *sql.Tx, webhookDelivery *entities.WebhookDelivery) error {
+
+	if webhookDelivery.Id == 0 {
+		return errors.WithStack(errors.New("can't update webhook delivery with id 0"))
+	}
+
+	originalUpdatedAt := webhookDelivery.UpdatedAt
+	webhookDelivery.UpdatedAt = sql.NullTime{Time: time.Now().UTC(), Valid: true}
+
+	webhookDeliveryStruct := sqlbuilder.NewStruct(new(entities.WebhookDelivery)).
+		For(d.Flavor)
+
+	updateBuilder := webhookDeliveryStruct.WithoutTag("pk").Update("webhook_deliveries", webhookDelivery)
+	updateBuilder.Where(updateBuilder.Equal("id", webhookDelivery.Id))
+
+	sql, args := updateBuilder.Build()
+	_, err := d.ExecSql(tx, sql, args...)
+	if err != nil {
+		webhookDelivery.UpdatedAt = originalUpdatedAt
+		return errors.Wrap(err, "unable to update webhook delivery")
+	}
+
+	return nil
+}
+
+func (d *CommonDatabase) getWebhookDeliveriesCommon(tx *sql.Tx, selectBuilder *sqlbuilder.SelectBuilder,
+	webhookDeliveryStruct *sqlbuilder.Struct) ([]entities.WebhookDelivery, error) {
+
+	sql, args := selectBuilder.Build()
+	rows, err := d.QuerySql(tx, sql, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to query database")
+	}
+	defer rows.Close()
+
+	webhookDeliveries := make([]entities.WebhookDelivery, 0)
+	for rows.Next() {
+		var webhookDelivery entities.WebhookDelivery
+		addr := webhookDeliveryStruct.Addr(&webhookDelivery)
+		err = rows.Scan(addr...)
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to scan webhook delivery")
+		}
+		webhookDeliveries = append(webhookDeliveries, webhookDelivery)
+	}
+
+	return webhookDeliveries, nil
+}
+
+func (d *CommonDatabase) GetWebhookDeliveryById(tx *sql.Tx, webhookDeliveryId int64) (*entities.WebhookDelivery, error) {
+
+	webhookDeliveryStruct := sqlbuilder.NewStruct(new(entities.WebhookDelivery)).
+		For(d.Flavor)
+
+	selectBuilder := webhookDeliveryStruct.SelectFrom("webhook_deliveries")
+	selectBuilder.Where(selectBuilder.Equal("id", webhookDeliveryId))
+
+	webhookDeliveries, err := d.getWebhookDeliveriesCommon(tx, selectBuilder, webhookDeliveryStruct)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(webhookDeliveries) == 0 {
+		return nil, nil
+	}
+	return &webhookDeliveries[0], nil
+}
+
+// GetDueWebhookDeliveries returns the pending deliveries whose next attempt is due, the oldest first.
+func (d *CommonDatabase) GetDueWebhookDeliveries(tx *sql.Tx, now time.Time, limit int) ([]entities.WebhookDelivery, error) {
+
+	webhookDeliveryStruct := sqlbuilder.NewStruct(new(entities.WebhookDelivery)).
+		For(d.Flavor)
+
+	selectBuilder := webhookDeliveryStruct.SelectFrom("webhook_deliveries")
+	selectBuilder.Where(
+		selectBuilder.Equal("status", constants.WebhookDeliveryStatusPending),
+		selectBuilder.LessEqualThan("next_attempt_at", now),
+	)
+	selectBuilder.OrderBy("next_attempt_at", "id").Asc()
+	selectBuilder.Limit(limit)
+
+	return d.getWebhookDeliveriesCommon(tx, selectBuilder, webhookDeliveryStruct)
+}
+
+// GetWebhookDeliveriesPaginated returns the deliveries of the webhook, the most recent first, and the
+// total number of deliveries of the webhook.
+func (d *CommonDatabase) GetWebhookDeliveriesPaginated(tx *sql.Tx, webhookId int64, page int,
+	pageSize int) ([]entities.WebhookDelivery, int, error) {
+
+	if page < 1 {
+		page = 1
+	}
+
+	if pageSize < 1 {
+		pageSize = 10
+	}
+
+	webhookDeliveryStruct := sqlbuilder.NewStruct(new(entities.WebhookDelivery)).
+		For(d.Flavor)
+
+	selectBuilder := webhookDeliveryStruct.SelectFrom("webhook_deliveries")
+	selectBuilder.Where(selectBuilder.Equal("webhook_id", webhookId))
+	selectBuilder.OrderBy("id").Desc()
+	selectBuilder.Offset((page - 1) * pageSize)
+	selectBuilder.Limit(pageSize)
+
+	webhookDeliveries, err := d.getWebhookDeliveriesCommon(tx, selectBuilder, webhookDeliveryStruct)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var count int
+	selectBuilder = d.Flavor.NewSelectBuilder()
+	selectBuilder.Select("count(*)").From("webhook_deliveries")
+	selectBuilder.Where(selectBuilder.Equal("webhook_id", webhookId))
+
+	sql, args := selectBuilder.Build()
+	rows, err := d.QuerySql(tx, sql, args...)
+	if err != nil {
+		return nil, 0, errors.Wrap(err, "unable to query database")
+	}
+	defer rows.Close()
+
+	if rows.Next() {
+		err = rows.Scan(&count)
+		if err != nil {
+			return nil, 0, errors.Wrap(err, "unable to scan count")
+		}
+	}
+
+	return webhookDeliveries, count, nil
+}
+
+// DeleteWebhookDeliveriesCreatedBefore deletes the deliveries older than the given time that are no
+// longer pending, and returns how many were deleted.
+func (d *CommonDatabase) DeleteWebhookDeliveriesCreatedBefore(tx *sql.Tx, before time.Time) (int64, error) {
+
+	deleteBuilder := d.Flavor.NewDeleteBuilder()
+	deleteBuilder.DeleteFrom("webhook_deliveries")
+	deleteBuilder.Where(
+		deleteBuilder.LessThan("created_at", before),
+		deleteBuilder.NotEqual("status", constants.WebhookDeliveryStatusPending),
+	)
+
+	sql, args := deleteBuilder.Build()
+	result, err := d.ExecSql(tx, sql, args...)
+	if err != nil {
+		return 0, errors.Wrap(err, "unable to delete webhook deliveries")
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, errors.Wrap(err, "unable to get rows affected")
+	}
+	return rowsAffected, nil
+}
@@ -3,10 +3,12 @@ package data
 import (
 	"database/sql"
 	"log/slog"
+	"time"
 
 	"github.com/pkg/errors"
 
 	"github.com/leodip/goiabada/internal/data/mysqldb"
+	"github.com/leodip/goiabada/internal/data/postgresdb"
 	"github.com/leodip/goiabada/internal/data/sqlitedb"
 	"github.com/leodip/goiabada/internal/entities"
 	"github.com/spf13/viper"
@@ -17,6 +19,9 @@ type Database interface {
 	CommitTransaction(tx *sql.Tx) error
 	RollbackTransaction(tx *sql.Tx) error
 	Migrate() error
+	MigrateDown(steps int) error
+	GetMigrationVersion() (uint, bool, error)
+	GetLatestMigrationVersion() (uint, error)
 
 	CreateClient(tx *sql.Tx, client *entities.Client) error
 	UpdateClient(tx *sql.Tx, client *entities.Client) error
@@ -37,6 +42,7 @@ type Database interface {
 	GetUserBySubject(tx *sql.Tx, subject string) (*entities.User, error)
 	GetUserByEmail(tx *sql.Tx, email string) (*entities.User, error)
 	GetLastUserWithOTPState(tx *sql.Tx, otpEnabledState bool) (*entities.User, error)
+	GetLockedUsers(tx *sql.Tx) ([]entities.User, error)
 	SearchUsersPaginated(tx *sql.Tx, query string, page int, pageSize int) ([]entities.User, int, error)
 	DeleteUser(tx *sql.Tx, userId int64) error
 	UserLoadGroups(tx *sql.Tx, user *entities.User) error
@@ -44,6 +50,7 @@ type Database interface {
 	UserLoadPermissions(tx *sql.Tx, user *entities.User) error
 	UsersLoadPermissions(tx *sql.Tx, users []entities.User) error
 	UserLoadAttributes(tx *sql.Tx, user *entities.User) error
+	UserLoadPasskeys(tx *sql.Tx, user *entities.User) error
 
 	CreateCode(tx *sql.Tx, code *entities.Code) error
 	UpdateCode(tx *sql.Tx, code *entities.Code) error
@@ -198,25 +205,83 @@ type Database interface {
 	GetHttpSessionById(tx *sql.Tx, httpSessionId int64) (*entities.HttpSession, error)
 	DeleteHttpSession(tx *sql.Tx, httpSessionId int64) error
 	DeleteHttpSessionExpired(tx *sql.Tx) error
+
+	CreateIdentityProvider(tx *sql.Tx, identityProvider *entities.IdentityProvider) error
+	UpdateIdentityProvider(tx *sql.Tx, identityProvider *entities.IdentityProvider) error
+	GetIdentityProviderById(tx *sql.Tx, identityProviderId int64) (*entities.IdentityProvider, error)
+	GetIdentityProviderByIdentifier(tx *sql.Tx, identityProviderIdentifier string) (*entities.IdentityProvider, error)
+	GetIdentityProvidersByIds(tx *sql.Tx, identityProviderIds []int64) ([]entities.IdentityProvider, error)
+	GetAllIdentityProviders(tx *sql.Tx) ([]entities.IdentityProvider, error)
+	GetEnabledIdentityProviders(tx *sql.Tx) ([]entities.IdentityProvider, error)
+	DeleteIdentityProvider(tx *sql.Tx, identityProviderId int64) error
+
+	CreateUserFederatedIdentity(tx *sql.Tx, userFederatedIdentity *entities.UserFederatedIdentity) error
+	UpdateUserFederatedIdentity(tx *sql.Tx, userFederatedIdentity *entities.UserFederatedIdentity) error
+	GetUserFederatedIdentityById(tx *sql.Tx, userFederatedIdentityId int64) (*entities.UserFederatedIdentity, error)
+	GetUserFederatedIdentityByIdentityProviderIdAndSubject(tx *sql.Tx, identityProviderId int64, subject string) (*entities.UserFederatedIdentity, error)
+	GetUserFederatedIdentitiesByUserId(tx *sql.Tx, userId int64) ([]entities.UserFederatedIdentity, error)
+	UserFederatedIdentitiesLoadIdentityProviders(tx *sql.Tx, userFederatedIdentities []entities.UserFederatedIdentity) error
+	DeleteUserFederatedIdentity(tx *sql.Tx, userFederatedIdentityId int64) error
+
+	CreateUserPasskey(tx *sql.Tx, userPasskey *entities.UserPasskey) error
+	UpdateUserPasskey(tx *sql.Tx, userPasskey *entities.UserPasskey) error
+	GetUserPasskeyById(tx *sql.Tx, userPasskeyId int64) (*entities.UserPasskey, error)
+	GetUserPasskeysByUserId(tx *sql.Tx, userId int64) ([]entities.UserPasskey, error)
+	DeleteUserPasskey(tx *sql.Tx, userPasskeyId int64) error
+
+	CreateUserTrustedDevice(tx *sql.Tx, userTrustedDevice *entities.UserTrustedDevice) error
+	UpdateUserTrustedDevice(tx *sql.Tx, userTrustedDevice *entities.UserTrustedDevice) error
+	GetUserTrustedDeviceById(tx *sql.Tx, userTrustedDeviceId int64) (*entities.UserTrustedDevice, error)
+	GetUserTrustedDeviceByIdentifierHash(tx *sql.Tx, identifierHash string) (*entities.UserTrustedDevice, error)
+	GetUserTrustedDevicesByUserId(tx *sql.Tx, userId int64) ([]entities.UserTrustedDevice, error)
+	DeleteUserTrustedDevice(tx *sql.Tx, userTrustedDeviceId int64) error
+
+	CreateUserKnownDevice(tx *sql.Tx, userKnownDevice *entities.UserKnownDevice) error
+	UpdateUserKnownDevice(tx *sql.Tx, userKnownDevice *entities.UserKnownDevice) error
+	GetUserKnownDevicesByUserId(tx *sql.Tx, userId int64) ([]entities.UserKnownDevice, error)
+
+	CreateUserPasswordHistory(tx *sql.Tx, userPasswordHistory *entities.UserPasswordHistory) error
+	GetUserPasswordHistoryByUserId(tx *sql.Tx, userId int64) ([]entities.UserPasswordHistory, error)
+	DeleteUserPasswordHistory(tx *sql.Tx, userPasswordHistoryId int64) error
+
+	CreateFailedLoginIp(tx *sql.Tx, failedLoginIp *entities.FailedLoginIp) error
+	UpdateFailedLoginIp(tx *sql.Tx, failedLoginIp *entities.FailedLoginIp) error
+	GetFailedLoginIpById(tx *sql.Tx, failedLoginIpId int64) (*entities.FailedLoginIp, error)
+	GetFailedLoginIpByIpAddress(tx *sql.Tx, ipAddress string) (*entities.FailedLoginIp, error)
+	GetLockedFailedLoginIps(tx *sql.Tx) ([]entities.FailedLoginIp, error)
+	DeleteFailedLoginIp(tx *sql.Tx, failedLoginIpId int64) error
+
+	CreateBreachedPasswordHashes(tx *sql.Tx, sha1Hashes []string) (int64, error)
+	BreachedPasswordHashExists(tx *sql.Tx, sha1Hash string) (bool, error)
+	CountBreachedPasswordHashes(tx *sql.Tx) (int, error)
+	DeleteAllBreachedPasswordHashes(tx *sql.Tx) error
+
+	CreateAuditEvent(tx *sql.Tx, auditEvent *entities.AuditEvent) error
+	SearchAuditEventsPaginated(tx *sql.Tx, filter *entities.AuditEventFilter, page int, pageSize int) ([]entities.AuditEvent, int, error)
+	GetAuditEventTypes(tx *sql.Tx) ([]string, error)
+	DeleteAuditEventsCreatedBefore(tx *sql.Tx, before time.Time) (int64, error)
+
+	CreateWebhook(tx *sql.Tx, webhook *entities.Webhook) error
+	UpdateWebhook(tx *sql.Tx, webhook *entities.Webhook) error
+	GetWebhookById(tx *sql.Tx, webhookId int64) (*entities.Webhook, error)
+	GetAllWebhooks(tx *sql.Tx) ([]entities.Webhook, error)
+	GetEnabledWebhooks(tx *sql.Tx) ([]entities.Webhook, error)
+	DeleteWebhook(tx *sql.Tx, webhookId int64) error
+	CreateWebhookDelivery(tx *sql.Tx, webhookDelivery *entities.WebhookDelivery) error
+	UpdateWebhookDelivery(tx *sql.Tx, webhookDelivery *entities.WebhookDelivery) error
+	GetWebhookDeliveryById(tx *sql.Tx, webhookDeliveryId int64) (*entities.WebhookDelivery, error)
+	GetDueWebhookDeliveries(tx *sql.Tx, now time.Time, limit int) ([]entities.WebhookDelivery, error)
+	GetWebhookDeliveriesPaginated(tx *sql.Tx, webhookId int64, page int, pageSize int) ([]entities.WebhookDelivery, int, error)
+	DeleteWebhookDeliveriesCreatedBefore(tx *sql.Tx, before time.Time) (int64, error)
 }
 
+// NewDatabase connects to the configured database, applies the pending migrations and seeds the
+// initial data when the database is empty.
 func NewDatabase() (Database, error) {
 
-	var database Database
-	var err error
-
-	if dbType := viper.GetString("DB.Type"); dbType == "mysql" {
-		database, err = mysqldb.NewMySQLDatabase()
-		if err != nil {
-			return nil, err
-		}
-	} else if dbType == "sqlite" {
-		database, err = sqlitedb.NewSQLiteDatabase()
-		if err != nil {
-			return nil, err
-		}
-	} else {
-		return nil, errors.WithStack(errors.New("unsupported database type: " + dbType))
+	database, err := OpenDatabase()
+	if err != nil {
+		return nil, err
 	}
 
 	err = database.Migrate()
@@ -243,6 +308,33 @@ func NewDatabase() (Database, error) {
 	return database, nil
 }
 
+// OpenDatabase connects to the configured database, without applying the migrations.
+func OpenDatabase() (Database, error) {
+
+	var database Database
+	var err error
+
+	if dbType := viper.GetString("DB.Type"); dbType == "mysql" {
+		database, err = mysqldb.NewMySQLDatabase()
+		if err != nil {
+			return nil, err
+		}
+	} else if dbType == "postgres" {
+		database, err = postgresdb.NewPostgresDatabase()
+		if err != nil {
+			return nil, err
+		}
+	} else if dbType == "sqlite" {
+		database, err = sqlitedb.NewSQLiteDatabase()
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		return nil, errors.WithStack(errors.New("unsupported database type: " + dbType))
+	}
+	return database, nil
+}
+
 func isDatabaseEmpty(database Database) (bool, error) {
 	settings, err := database.GetSettingsById(nil, 1)
 	if err != nil {
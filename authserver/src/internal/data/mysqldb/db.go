@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"embed"
 	"fmt"
+	"io/fs"
 	"log/slog"
 	"strings"
 
@@ -80,22 +81,31 @@ func (d *MySQLDatabase) RollbackTransaction(tx *sql.Tx) error {
 	return d.CommonDB.RollbackTransaction(tx)
 }
 
-func (d *MySQLDatabase) Migrate() error {
+func (d *MySQLDatabase) newMigrate() (*gomigrate.Migrate, error) {
 	driver, err := mysql.WithInstance(d.DB, &mysql.Config{
 		DatabaseName: viper.GetString("DB.DbName"),
 	})
 	if err != nil {
-		return errors.Wrap(err, "unable to create migration driver")
+		return nil, errors.Wrap(err, "unable to create migration driver")
+	}
+
+	sourceDriver, err := iofs.New(mysqlMigrationsFs, "migrations")
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to create migration filesystem")
 	}
 
-	iofs, err := iofs.New(mysqlMigrationsFs, "migrations")
+	migrate, err := gomigrate.NewWithInstance("iofs", sourceDriver, "mysql", driver)
 	if err != nil {
-		return errors.Wrap(err, "unable to create migration filesystem")
+		return nil, errors.Wrap(err, "unable to create migration instance")
 	}
 
-	migrate, err := gomigrate.NewWithInstance("iofs", iofs, "mysql", driver)
+	return migrate, nil
+}
+
+func (d *MySQLDatabase) Migrate() error {
+	migrate, err := d.newMigrate()
 	if err != nil {
-		return errors.Wrap(err, "unable to create migration instance")
+		return err
 	}
 
 	err = migrate.Up()
@@ -107,3 +117,58 @@ func (d *MySQLDatabase) Migrate() error {
 
 	return nil
 }
+
+// MigrateDown reverts the last migrations applied to the database.
+func (d *MySQLDatabase) MigrateDown(steps int) error {
+	migrate, err := d.newMigrate()
+	if err != nil {
+		return err
+	}
+
+	err = migrate.Steps(-steps)
+	if err != nil {
+		return errors.Wrap(err, "unable to revert the migrations")
+	}
+	return nil
+}
+
+// GetMigrationVersion returns the version of the last migration applied to the database, which is
+// zero when no migration was applied. The database is dirty when a migration failed halfway.
+func (d *MySQLDatabase) GetMigrationVersion() (uint, bool, error) {
+	migrate, err := d.newMigrate()
+	if err != nil {
+		return 0, false, err
+	}
+
+	version, dirty, err := migrate.Version()
+	if err == gomigrate.ErrNilVersion {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, errors.Wrap(err, "unable to get the migration version")
+	}
+	return version, dirty, nil
+}
+
+// GetLatestMigrationVersion returns the version of the last migration embedded in the binary.
+func (d *MySQLDatabase) GetLatestMigrationVersion() (uint, error) {
+	sourceDriver, err := iofs.New(mysqlMigrationsFs, "migrations")
+	if err != nil {
+		return 0, errors.Wrap(err, "unable to create migration filesystem")
+	}
+
+	version, err := sourceDriver.First()
+	if err != nil {
+		return 0, errors.Wrap(err, "unable to read the migrations")
+	}
+	for {
+		next, err := sourceDriver.Next(version)
+		if errors.Is(err, fs.ErrNotExist) {
+			return version, nil
+		}
+		if err != nil {
+			return 0, errors.Wrap(err, "unable to read the migrations")
+		}
+		version = next
+	}
+}
@@ -0,0 +1,31 @@
+package mysqldb
+
+import (
+	"database/sql"
+
+	"github.com/leodip/goiabada/internal/entities"
+)
+
+func (d *MySQLDatabase) CreateFailedLoginIp(tx *sql.Tx, failedLoginIp *entities.FailedLoginIp) error {
+	return d.CommonDB.CreateFailedLoginIp(tx, failedLoginIp)
+}
+
+func (d *MySQLDatabase) UpdateFailedLoginIp(tx *sql.Tx, failedLoginIp *entities.FailedLoginIp) error {
+	return d.CommonDB.UpdateFailedLoginIp(tx, failedLoginIp)
+}
+
+func (d *MySQLDatabase) GetFailedLoginIpById(tx *sql.Tx, failedLoginIpId int64) (*entities.FailedLoginIp, error) {
+	return d.CommonDB.GetFailedLoginIpById(tx, failedLoginIpId)
+}
+
+func (d *MySQLDatabase) GetFailedLoginIpByIpAddress(tx *sql.Tx, ipAddress string) (*entities.FailedLoginIp, error) {
+	return d.CommonDB.GetFailedLoginIpByIpAddress(tx, ipAddress)
+}
+
+func (d *MySQLDatabase) GetLockedFailedLoginIps(tx *sql.Tx) ([]entities.FailedLoginIp, error) {
+	return d.CommonDB.GetLockedFailedLoginIps(tx)
+}
+
+func (d *MySQLDatabase) DeleteFailedLoginIp(tx *sql.Tx, failedLoginIpId int64) error {
+	return d.CommonDB.DeleteFailedLoginIp(tx, failedLoginIpId)
+}
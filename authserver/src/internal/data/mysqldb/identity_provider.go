@@ -0,0 +1,39 @@
+package mysqldb
+
+import (
+	"database/sql"
+
+	"github.com/leodip/goiabada/internal/entities"
+)
+
+func (d *MySQLDatabase) CreateIdentityProvider(tx *sql.Tx, identityProvider *entities.IdentityProvider) error {
+	return d.CommonDB.CreateIdentityProvider(tx, identityProvider)
+}
+
+func (d *MySQLDatabase) UpdateIdentityProvider(tx *sql.Tx, identityProvider *entities.IdentityProvider) error {
+	return d.CommonDB.UpdateIdentityProvider(tx, identityProvider)
+}
+
+func (d *MySQLDatabase) GetIdentityProviderById(tx *sql.Tx, identityProviderId int64) (*entities.IdentityProvider, error) {
+	return d.CommonDB.GetIdentityProviderById(tx, identityProviderId)
+}
+
+func (d *MySQLDatabase) GetIdentityProviderByIdentifier(tx *sql.Tx, identityProviderIdentifier string) (*entities.IdentityProvider, error) {
+	return d.CommonDB.GetIdentityProviderByIdentifier(tx, identityProviderIdentifier)
+}
+
+func (d *MySQLDatabase) GetIdentityProvidersByIds(tx *sql.Tx, identityProviderIds []int64) ([]entities.IdentityProvider, error) {
+	return d.CommonDB.GetIdentityProvidersByIds(tx, identityProviderIds)
+}
+
+func (d *MySQLDatabase) GetAllIdentityProviders(tx *sql.Tx) ([]entities.IdentityProvider, error) {
+	return d.CommonDB.GetAllIdentityProviders(tx)
+}
+
+func (d *MySQLDatabase) GetEnabledIdentityProviders(tx *sql.Tx) ([]entities.IdentityProvider, error) {
+	return d.CommonDB.GetEnabledIdentityProviders(tx)
+}
+
+func (d *MySQLDatabase) DeleteIdentityProvider(tx *sql.Tx, identityProviderId int64) error {
+	return d.CommonDB.DeleteIdentityProvider(tx, identityProviderId)
+}
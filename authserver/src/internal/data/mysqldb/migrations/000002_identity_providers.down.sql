@@ -0,0 +1,6 @@
+-- BEGIN
+
+DROP TABLE IF EXISTS `user_federated_identities`;
+DROP TABLE IF EXISTS `identity_providers`;
+
+-- END
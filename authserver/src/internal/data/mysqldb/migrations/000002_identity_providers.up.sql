@@ -0,0 +1,40 @@
+-- BEGIN
+
+CREATE TABLE `identity_providers` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `created_at` datetime(6) DEFAULT NULL,
+  `updated_at` datetime(6) DEFAULT NULL,
+  `name` varchar(64) NOT NULL,
+  `identity_provider_identifier` varchar(40) NOT NULL,
+  `type` varchar(16) NOT NULL,
+  `enabled` tinyint(1) NOT NULL,
+  `issuer` varchar(256) NOT NULL,
+  `client_identifier` varchar(256) NOT NULL,
+  `client_secret_encrypted` longblob,
+  `scopes` varchar(512) NOT NULL,
+  `email_claim` varchar(64) NOT NULL,
+  `given_name_claim` varchar(64) NOT NULL,
+  `family_name_claim` varchar(64) NOT NULL,
+  `link_by_email` tinyint(1) NOT NULL,
+  `auto_provision` tinyint(1) NOT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `idx_identity_provider_identifier` (`identity_provider_identifier`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+
+CREATE TABLE `user_federated_identities` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `created_at` datetime(6) DEFAULT NULL,
+  `updated_at` datetime(6) DEFAULT NULL,
+  `user_id` bigint unsigned NOT NULL,
+  `identity_provider_id` bigint unsigned NOT NULL,
+  `subject` varchar(256) NOT NULL,
+  `last_login_at` datetime(6) DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `idx_identity_provider_subject` (`identity_provider_id`,`subject`),
+  KEY `fk_user_federated_identities_user` (`user_id`),
+  CONSTRAINT `fk_user_federated_identities_user` FOREIGN KEY (`user_id`) REFERENCES `users` (`id`) ON DELETE CASCADE,
+  CONSTRAINT `fk_user_federated_identities_identity_provider` FOREIGN KEY (`identity_provider_id`) REFERENCES `identity_providers` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+-- END
@@ -0,0 +1,10 @@
+-- BEGIN
+
+ALTER TABLE `identity_providers`
+  ADD COLUMN `saml_metadata_url` varchar(512) NOT NULL DEFAULT '',
+  ADD COLUMN `saml_metadata_xml` longblob,
+  ADD COLUMN `saml_name_id_format` varchar(128) NOT NULL DEFAULT '',
+  ADD COLUMN `groups_claim` varchar(128) NOT NULL DEFAULT '',
+  ADD COLUMN `user_attributes_mapping` varchar(2048) NOT NULL DEFAULT '';
+
+-- END
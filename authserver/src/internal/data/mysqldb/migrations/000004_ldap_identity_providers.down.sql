@@ -0,0 +1,13 @@
+-- BEGIN
+
+ALTER TABLE `identity_providers`
+  DROP COLUMN `ldap_url`,
+  DROP COLUMN `ldap_start_tls`,
+  DROP COLUMN `ldap_bind_dn`,
+  DROP COLUMN `ldap_bind_password_encrypted`,
+  DROP COLUMN `ldap_base_dn`,
+  DROP COLUMN `ldap_user_filter`,
+  DROP COLUMN `ldap_subject_attribute`,
+  DROP COLUMN `ldap_local_password_fallback`;
+
+-- END
@@ -0,0 +1,13 @@
+-- BEGIN
+
+ALTER TABLE `identity_providers`
+  ADD COLUMN `ldap_url` varchar(512) NOT NULL DEFAULT '',
+  ADD COLUMN `ldap_start_tls` tinyint(1) NOT NULL DEFAULT 0,
+  ADD COLUMN `ldap_bind_dn` varchar(512) NOT NULL DEFAULT '',
+  ADD COLUMN `ldap_bind_password_encrypted` blob,
+  ADD COLUMN `ldap_base_dn` varchar(512) NOT NULL DEFAULT '',
+  ADD COLUMN `ldap_user_filter` varchar(512) NOT NULL DEFAULT '',
+  ADD COLUMN `ldap_subject_attribute` varchar(128) NOT NULL DEFAULT '',
+  ADD COLUMN `ldap_local_password_fallback` tinyint(1) NOT NULL DEFAULT 0;
+
+-- END
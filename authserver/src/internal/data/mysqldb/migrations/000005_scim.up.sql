@@ -0,0 +1,13 @@
+-- BEGIN
+
+ALTER TABLE `users`
+  ADD COLUMN `external_id` varchar(256) NOT NULL DEFAULT '';
+
+ALTER TABLE `groups`
+  ADD COLUMN `external_id` varchar(256) NOT NULL DEFAULT '';
+
+INSERT INTO `permissions` (`created_at`, `updated_at`, `permission_identifier`, `description`, `resource_id`)
+  SELECT NOW(6), NOW(6), 'scim', 'Provision users and groups via the SCIM 2.0 API', `id`
+  FROM `resources` WHERE `resource_identifier` = 'authserver';
+
+-- END
@@ -0,0 +1,23 @@
+-- BEGIN
+
+CREATE TABLE `user_passkeys` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `created_at` datetime(6) DEFAULT NULL,
+  `updated_at` datetime(6) DEFAULT NULL,
+  `user_id` bigint unsigned NOT NULL,
+  `name` varchar(64) NOT NULL,
+  `credential_id` varbinary(1023) NOT NULL,
+  `public_key` blob NOT NULL,
+  `attestation_type` varchar(32) NOT NULL,
+  `transports` varchar(128) NOT NULL,
+  `aaguid` varbinary(16) DEFAULT NULL,
+  `sign_count` bigint NOT NULL,
+  `backup_eligible` tinyint(1) NOT NULL,
+  `backup_state` tinyint(1) NOT NULL,
+  `last_used_at` datetime(6) DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  KEY `fk_user_passkeys_user` (`user_id`),
+  CONSTRAINT `fk_user_passkeys_user` FOREIGN KEY (`user_id`) REFERENCES `users` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+-- END
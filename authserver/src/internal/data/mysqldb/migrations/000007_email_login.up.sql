@@ -0,0 +1,12 @@
+-- BEGIN
+
+ALTER TABLE `clients`
+  ADD COLUMN `email_login_enabled` tinyint(1) NOT NULL DEFAULT 0;
+
+ALTER TABLE `users`
+  ADD COLUMN `email_login_code_hash` varchar(64) NOT NULL DEFAULT '',
+  ADD COLUMN `email_login_token_hash` varchar(64) NOT NULL DEFAULT '',
+  ADD COLUMN `email_login_code_issued_at` datetime(6) DEFAULT NULL,
+  ADD COLUMN `email_login_code_attempts` int NOT NULL DEFAULT 0;
+
+-- END
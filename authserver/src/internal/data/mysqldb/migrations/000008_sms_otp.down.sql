@@ -0,0 +1,11 @@
+-- BEGIN
+
+ALTER TABLE `users`
+  DROP COLUMN `sms_otp_code_hash`,
+  DROP COLUMN `sms_otp_code_issued_at`,
+  DROP COLUMN `sms_otp_code_attempts`;
+
+ALTER TABLE `settings`
+  DROP COLUMN `sms_otp_allowed_for_mandatory_2fa`;
+
+-- END
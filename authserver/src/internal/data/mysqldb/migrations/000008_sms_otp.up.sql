@@ -0,0 +1,11 @@
+-- BEGIN
+
+ALTER TABLE `settings`
+  ADD COLUMN `sms_otp_allowed_for_mandatory_2fa` tinyint(1) NOT NULL DEFAULT 0;
+
+ALTER TABLE `users`
+  ADD COLUMN `sms_otp_code_hash` varchar(64) NOT NULL DEFAULT '',
+  ADD COLUMN `sms_otp_code_issued_at` datetime(6) DEFAULT NULL,
+  ADD COLUMN `sms_otp_code_attempts` int NOT NULL DEFAULT 0;
+
+-- END
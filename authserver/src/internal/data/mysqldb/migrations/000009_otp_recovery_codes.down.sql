@@ -0,0 +1,6 @@
+-- BEGIN
+
+ALTER TABLE `users`
+  DROP COLUMN `otp_recovery_codes_hashes`;
+
+-- END
@@ -0,0 +1,6 @@
+-- BEGIN
+
+ALTER TABLE `users`
+  ADD COLUMN `otp_recovery_codes_hashes` varchar(1024) NOT NULL DEFAULT '';
+
+-- END
@@ -0,0 +1,8 @@
+-- BEGIN
+
+DROP TABLE IF EXISTS `user_trusted_devices`;
+
+ALTER TABLE `settings`
+  DROP COLUMN `trusted_device_lifetime_in_days`;
+
+-- END
@@ -0,0 +1,24 @@
+-- BEGIN
+
+ALTER TABLE `settings`
+  ADD COLUMN `trusted_device_lifetime_in_days` int NOT NULL DEFAULT 30;
+
+CREATE TABLE `user_trusted_devices` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `created_at` datetime(6) DEFAULT NULL,
+  `updated_at` datetime(6) DEFAULT NULL,
+  `user_id` bigint unsigned NOT NULL,
+  `identifier_hash` varchar(64) NOT NULL,
+  `ip_address` varchar(512) NOT NULL,
+  `device_name` varchar(256) NOT NULL,
+  `device_type` varchar(32) NOT NULL,
+  `device_os` varchar(64) NOT NULL,
+  `expires_at` datetime(6) NOT NULL,
+  `last_used_at` datetime(6) DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `idx_identifier_hash` (`identifier_hash`),
+  KEY `fk_user_trusted_devices_user` (`user_id`),
+  CONSTRAINT `fk_user_trusted_devices_user` FOREIGN KEY (`user_id`) REFERENCES `users` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+-- END
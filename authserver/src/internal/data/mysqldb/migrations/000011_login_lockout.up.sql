@@ -0,0 +1,26 @@
+-- BEGIN
+
+ALTER TABLE `settings`
+  ADD COLUMN `login_delay_after_failed_attempts` int NOT NULL DEFAULT 3,
+  ADD COLUMN `login_lockout_after_failed_attempts` int NOT NULL DEFAULT 10,
+  ADD COLUMN `login_ip_lockout_after_failed_attempts` int NOT NULL DEFAULT 50,
+  ADD COLUMN `login_lockout_duration_in_seconds` int NOT NULL DEFAULT 900;
+
+ALTER TABLE `users`
+  ADD COLUMN `failed_login_attempts` int NOT NULL DEFAULT 0,
+  ADD COLUMN `last_failed_login_at` datetime(6) DEFAULT NULL,
+  ADD COLUMN `locked_until` datetime(6) DEFAULT NULL;
+
+CREATE TABLE `failed_login_ips` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `created_at` datetime(6) DEFAULT NULL,
+  `updated_at` datetime(6) DEFAULT NULL,
+  `ip_address` varchar(512) NOT NULL,
+  `failed_attempts` int NOT NULL,
+  `last_failed_at` datetime(6) NOT NULL,
+  `locked_until` datetime(6) DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `idx_ip_address` (`ip_address`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+-- END
@@ -0,0 +1,17 @@
+-- BEGIN
+
+ALTER TABLE `settings`
+  ADD COLUMN `reject_breached_passwords` tinyint(1) NOT NULL DEFAULT 1,
+  ADD COLUMN `force_change_of_breached_passwords` tinyint(1) NOT NULL DEFAULT 0;
+
+ALTER TABLE `users`
+  ADD COLUMN `password_breached` tinyint(1) NOT NULL DEFAULT 0;
+
+CREATE TABLE `breached_password_hashes` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `sha1_hash` char(40) NOT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `idx_sha1_hash` (`sha1_hash`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+-- END
@@ -0,0 +1,40 @@
+-- BEGIN
+
+ALTER TABLE `settings`
+  ADD COLUMN `password_min_length` int NOT NULL DEFAULT 6,
+  ADD COLUMN `password_max_length` int NOT NULL DEFAULT 64,
+  ADD COLUMN `password_requires_uppercase` tinyint(1) NOT NULL DEFAULT 0,
+  ADD COLUMN `password_requires_lowercase` tinyint(1) NOT NULL DEFAULT 0,
+  ADD COLUMN `password_requires_number` tinyint(1) NOT NULL DEFAULT 0,
+  ADD COLUMN `password_requires_special_char` tinyint(1) NOT NULL DEFAULT 0,
+  ADD COLUMN `password_disallow_username_or_email` tinyint(1) NOT NULL DEFAULT 1,
+  ADD COLUMN `password_history_count` int NOT NULL DEFAULT 0,
+  ADD COLUMN `password_max_age_in_days` int NOT NULL DEFAULT 0,
+  ADD COLUMN `password_min_age_in_days` int NOT NULL DEFAULT 0;
+
+UPDATE `settings` SET
+  `password_min_length` = CASE `password_policy` WHEN 0 THEN 1 WHEN 2 THEN 8 WHEN 3 THEN 10 ELSE 6 END,
+  `password_requires_uppercase` = CASE WHEN `password_policy` >= 2 THEN 1 ELSE 0 END,
+  `password_requires_lowercase` = CASE WHEN `password_policy` >= 2 THEN 1 ELSE 0 END,
+  `password_requires_number` = CASE WHEN `password_policy` >= 2 THEN 1 ELSE 0 END,
+  `password_requires_special_char` = CASE WHEN `password_policy` >= 3 THEN 1 ELSE 0 END;
+
+ALTER TABLE `settings`
+  DROP COLUMN `password_policy`;
+
+ALTER TABLE `users`
+  ADD COLUMN `password_changed_at` datetime(6) DEFAULT NULL;
+
+UPDATE `users` SET `password_changed_at` = COALESCE(`updated_at`, `created_at`) WHERE `password_hash` <> '';
+
+CREATE TABLE `user_password_history` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `created_at` datetime(6) DEFAULT NULL,
+  `user_id` bigint unsigned NOT NULL,
+  `password_hash` varchar(64) NOT NULL,
+  PRIMARY KEY (`id`),
+  KEY `fk_user_password_history_user` (`user_id`),
+  CONSTRAINT `fk_user_password_history_user` FOREIGN KEY (`user_id`) REFERENCES `users` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+-- END
@@ -0,0 +1,12 @@
+-- BEGIN
+
+ALTER TABLE `users`
+  MODIFY COLUMN `password_hash` varchar(255) NOT NULL;
+
+ALTER TABLE `pre_registrations`
+  MODIFY COLUMN `password_hash` varchar(255) NOT NULL;
+
+ALTER TABLE `user_password_history`
+  MODIFY COLUMN `password_hash` varchar(255) NOT NULL;
+
+-- END
@@ -0,0 +1,11 @@
+-- BEGIN
+
+ALTER TABLE `resources`
+  ADD COLUMN `min_acr_level` varchar(128) NOT NULL DEFAULT '',
+  ADD COLUMN `max_auth_age_in_seconds` int NOT NULL DEFAULT 0;
+
+ALTER TABLE `permissions`
+  ADD COLUMN `min_acr_level` varchar(128) NOT NULL DEFAULT '',
+  ADD COLUMN `max_auth_age_in_seconds` int NOT NULL DEFAULT 0;
+
+-- END
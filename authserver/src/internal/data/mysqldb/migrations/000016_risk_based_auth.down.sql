@@ -0,0 +1,11 @@
+-- BEGIN
+
+ALTER TABLE `settings`
+  DROP COLUMN `risk_based_auth_enabled`,
+  DROP COLUMN `risk_score_for_otp`,
+  DROP COLUMN `risk_score_for_block`,
+  DROP COLUMN `risk_score_for_notification`,
+  DROP COLUMN `risk_trusted_ip_ranges`,
+  DROP COLUMN `risk_suspicious_ip_ranges`;
+
+-- END
@@ -0,0 +1,11 @@
+-- BEGIN
+
+ALTER TABLE `settings`
+  ADD COLUMN `risk_based_auth_enabled` tinyint(1) NOT NULL DEFAULT 0,
+  ADD COLUMN `risk_score_for_otp` int NOT NULL DEFAULT 40,
+  ADD COLUMN `risk_score_for_block` int NOT NULL DEFAULT 90,
+  ADD COLUMN `risk_score_for_notification` int NOT NULL DEFAULT 30,
+  ADD COLUMN `risk_trusted_ip_ranges` varchar(2048) NOT NULL DEFAULT '',
+  ADD COLUMN `risk_suspicious_ip_ranges` varchar(2048) NOT NULL DEFAULT '';
+
+-- END
@@ -0,0 +1,24 @@
+-- BEGIN
+
+ALTER TABLE `users`
+  ADD COLUMN `disable_password_change_notification` tinyint(1) NOT NULL DEFAULT 0,
+  ADD COLUMN `disable_email_change_notification` tinyint(1) NOT NULL DEFAULT 0,
+  ADD COLUMN `disable_otp_change_notification` tinyint(1) NOT NULL DEFAULT 0,
+  ADD COLUMN `disable_new_device_notification` tinyint(1) NOT NULL DEFAULT 0;
+
+CREATE TABLE `user_known_devices` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `created_at` datetime(6) DEFAULT NULL,
+  `updated_at` datetime(6) DEFAULT NULL,
+  `user_id` bigint unsigned NOT NULL,
+  `fingerprint` varchar(64) NOT NULL,
+  `device_name` varchar(256) NOT NULL,
+  `device_type` varchar(32) NOT NULL,
+  `device_os` varchar(64) NOT NULL,
+  `last_seen_at` datetime(6) NOT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `idx_user_id_fingerprint` (`user_id`, `fingerprint`),
+  CONSTRAINT `fk_user_known_devices_user` FOREIGN KEY (`user_id`) REFERENCES `users` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+-- END
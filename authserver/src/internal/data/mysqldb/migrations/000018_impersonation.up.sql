@@ -0,0 +1,14 @@
+-- BEGIN
+
+ALTER TABLE `clients`
+  ADD COLUMN `accept_impersonated_tokens` tinyint(1) NOT NULL DEFAULT 0;
+
+ALTER TABLE `user_sessions`
+  ADD COLUMN `impersonator_user_id` bigint unsigned DEFAULT NULL,
+  ADD COLUMN `impersonation_expires_at` datetime(6) DEFAULT NULL,
+  ADD CONSTRAINT `fk_user_sessions_impersonator_user` FOREIGN KEY (`impersonator_user_id`) REFERENCES `users` (`id`) ON DELETE CASCADE;
+
+ALTER TABLE `codes`
+  ADD COLUMN `impersonator_subject` varchar(64) NOT NULL DEFAULT '';
+
+-- END
@@ -0,0 +1,21 @@
+-- BEGIN
+
+CREATE TABLE `audit_events` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `created_at` datetime(6) DEFAULT NULL,
+  `event_type` varchar(64) NOT NULL,
+  `actor_subject` varchar(256) NOT NULL,
+  `ip_address` varchar(64) NOT NULL,
+  `request_id` varchar(128) NOT NULL,
+  `user_id` bigint unsigned DEFAULT NULL,
+  `client_id` bigint unsigned DEFAULT NULL,
+  `details` longtext NOT NULL,
+  PRIMARY KEY (`id`),
+  KEY `idx_audit_events_created_at` (`created_at`),
+  KEY `idx_audit_events_event_type` (`event_type`),
+  KEY `idx_audit_events_actor_subject` (`actor_subject`),
+  KEY `idx_audit_events_user_id` (`user_id`),
+  KEY `idx_audit_events_client_id` (`client_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+-- END
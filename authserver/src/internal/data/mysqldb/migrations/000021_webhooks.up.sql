@@ -0,0 +1,35 @@
+-- BEGIN
+
+CREATE TABLE `webhooks` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `created_at` datetime(6) DEFAULT NULL,
+  `updated_at` datetime(6) DEFAULT NULL,
+  `url` varchar(512) NOT NULL,
+  `description` varchar(128) NOT NULL,
+  `secret_encrypted` longblob NOT NULL,
+  `event_types` varchar(512) NOT NULL,
+  `enabled` tinyint(1) NOT NULL,
+  PRIMARY KEY (`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+CREATE TABLE `webhook_deliveries` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `created_at` datetime(6) DEFAULT NULL,
+  `updated_at` datetime(6) DEFAULT NULL,
+  `webhook_id` bigint unsigned NOT NULL,
+  `event_id` varchar(64) NOT NULL,
+  `event_type` varchar(64) NOT NULL,
+  `payload` longtext NOT NULL,
+  `status` varchar(16) NOT NULL,
+  `attempts` int NOT NULL,
+  `next_attempt_at` datetime(6) DEFAULT NULL,
+  `last_attempt_at` datetime(6) DEFAULT NULL,
+  `last_response_code` int NOT NULL,
+  `last_error` varchar(512) NOT NULL,
+  PRIMARY KEY (`id`),
+  KEY `idx_webhook_deliveries_status_next_attempt_at` (`status`, `next_attempt_at`),
+  KEY `idx_webhook_deliveries_created_at` (`created_at`),
+  CONSTRAINT `fk_webhook_deliveries_webhook` FOREIGN KEY (`webhook_id`) REFERENCES `webhooks` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+-- END
@@ -0,0 +1,6 @@
+-- BEGIN
+
+ALTER TABLE `identity_providers`
+  ADD COLUMN `trust_email` tinyint(1) NOT NULL DEFAULT 0;
+
+-- END
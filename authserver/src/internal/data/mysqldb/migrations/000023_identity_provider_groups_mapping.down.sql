@@ -0,0 +1,6 @@
+-- BEGIN
+
+ALTER TABLE `identity_providers`
+  DROP COLUMN `groups_mapping`;
+
+-- END
@@ -0,0 +1,6 @@
+-- BEGIN
+
+ALTER TABLE `identity_providers`
+  ADD COLUMN `groups_mapping` varchar(2048) NOT NULL DEFAULT '';
+
+-- END
@@ -30,6 +30,10 @@ func (d *MySQLDatabase) UserLoadAttributes(tx *sql.Tx, user *entities.User) erro
 	return d.CommonDB.UserLoadAttributes(tx, user)
 }
 
+func (d *MySQLDatabase) UserLoadPasskeys(tx *sql.Tx, user *entities.User) error {
+	return d.CommonDB.UserLoadPasskeys(tx, user)
+}
+
 func (d *MySQLDatabase) UserLoadPermissions(tx *sql.Tx, user *entities.User) error {
 	return d.CommonDB.UserLoadPermissions(tx, user)
 }
@@ -58,6 +62,10 @@ func (d *MySQLDatabase) GetLastUserWithOTPState(tx *sql.Tx, otpEnabledState bool
 	return d.CommonDB.GetLastUserWithOTPState(tx, otpEnabledState)
 }
 
+func (d *MySQLDatabase) GetLockedUsers(tx *sql.Tx) ([]entities.User, error) {
+	return d.CommonDB.GetLockedUsers(tx)
+}
+
 func (d *MySQLDatabase) SearchUsersPaginated(tx *sql.Tx, query string, page int, pageSize int) ([]entities.User, int, error) {
 	return d.CommonDB.SearchUsersPaginated(tx, query, page, pageSize)
 }
@@ -0,0 +1,35 @@
+package mysqldb
+
+import (
+	"database/sql"
+
+	"github.com/leodip/goiabada/internal/entities"
+)
+
+func (d *MySQLDatabase) CreateUserFederatedIdentity(tx *sql.Tx, userFederatedIdentity *entities.UserFederatedIdentity) error {
+	return d.CommonDB.CreateUserFederatedIdentity(tx, userFederatedIdentity)
+}
+
+func (d *MySQLDatabase) UpdateUserFederatedIdentity(tx *sql.Tx, userFederatedIdentity *entities.UserFederatedIdentity) error {
+	return d.CommonDB.UpdateUserFederatedIdentity(tx, userFederatedIdentity)
+}
+
+func (d *MySQLDatabase) GetUserFederatedIdentityById(tx *sql.Tx, userFederatedIdentityId int64) (*entities.UserFederatedIdentity, error) {
+	return d.CommonDB.GetUserFederatedIdentityById(tx, userFederatedIdentityId)
+}
+
+func (d *MySQLDatabase) GetUserFederatedIdentityByIdentityProviderIdAndSubject(tx *sql.Tx, identityProviderId int64, subject string) (*entities.UserFederatedIdentity, error) {
+	return d.CommonDB.GetUserFederatedIdentityByIdentityProviderIdAndSubject(tx, identityProviderId, subject)
+}
+
+func (d *MySQLDatabase) GetUserFederatedIdentitiesByUserId(tx *sql.Tx, userId int64) ([]entities.UserFederatedIdentity, error) {
+	return d.CommonDB.GetUserFederatedIdentitiesByUserId(tx, userId)
+}
+
+func (d *MySQLDatabase) UserFederatedIdentitiesLoadIdentityProviders(tx *sql.Tx, userFederatedIdentities []entities.UserFederatedIdentity) error {
+	return d.CommonDB.UserFederatedIdentitiesLoadIdentityProviders(tx, userFederatedIdentities)
+}
+
+func (d *MySQLDatabase) DeleteUserFederatedIdentity(tx *sql.Tx, userFederatedIdentityId int64) error {
+	return d.CommonDB.DeleteUserFederatedIdentity(tx, userFederatedIdentityId)
+}
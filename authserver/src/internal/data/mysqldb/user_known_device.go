@@ -0,0 +1,19 @@
+package mysqldb
+
+import (
+	"database/sql"
+
+	"github.com/leodip/goiabada/internal/entities"
+)
+
+func (d *MySQLDatabase) CreateUserKnownDevice(tx *sql.Tx, userKnownDevice *entities.UserKnownDevice) error {
+	return d.CommonDB.CreateUserKnownDevice(tx, userKnownDevice)
+}
+
+func (d *MySQLDatabase) UpdateUserKnownDevice(tx *sql.Tx, userKnownDevice *entities.UserKnownDevice) error {
+	return d.CommonDB.UpdateUserKnownDevice(tx, userKnownDevice)
+}
+
+func (d *MySQLDatabase) GetUserKnownDevicesByUserId(tx *sql.Tx, userId int64) ([]entities.UserKnownDevice, error) {
+	return d.CommonDB.GetUserKnownDevicesByUserId(tx, userId)
+}
@@ -0,0 +1,27 @@
+package mysqldb
+
+import (
+	"database/sql"
+
+	"github.com/leodip/goiabada/internal/entities"
+)
+
+func (d *MySQLDatabase) CreateUserPasskey(tx *sql.Tx, userPasskey *entities.UserPasskey) error {
+	return d.CommonDB.CreateUserPasskey(tx, userPasskey)
+}
+
+func (d *MySQLDatabase) UpdateUserPasskey(tx *sql.Tx, userPasskey *entities.UserPasskey) error {
+	return d.CommonDB.UpdateUserPasskey(tx, userPasskey)
+}
+
+func (d *MySQLDatabase) GetUserPasskeyById(tx *sql.Tx, userPasskeyId int64) (*entities.UserPasskey, error) {
+	return d.CommonDB.GetUserPasskeyById(tx, userPasskeyId)
+}
+
+func (d *MySQLDatabase) GetUserPasskeysByUserId(tx *sql.Tx, userId int64) ([]entities.UserPasskey, error) {
+	return d.CommonDB.GetUserPasskeysByUserId(tx, userId)
+}
+
+func (d *MySQLDatabase) DeleteUserPasskey(tx *sql.Tx, userPasskeyId int64) error {
+	return d.CommonDB.DeleteUserPasskey(tx, userPasskeyId)
+}
@@ -0,0 +1,19 @@
+package mysqldb
+
+import (
+	"database/sql"
+
+	"github.com/leodip/goiabada/internal/entities"
+)
+
+func (d *MySQLDatabase) CreateUserPasswordHistory(tx *sql.Tx, userPasswordHistory *entities.UserPasswordHistory) error {
+	return d.CommonDB.CreateUserPasswordHistory(tx, userPasswordHistory)
+}
+
+func (d *MySQLDatabase) GetUserPasswordHistoryByUserId(tx *sql.Tx, userId int64) ([]entities.UserPasswordHistory, error) {
+	return d.CommonDB.GetUserPasswordHistoryByUserId(tx, userId)
+}
+
+func (d *MySQLDatabase) DeleteUserPasswordHistory(tx *sql.Tx, userPasswordHistoryId int64) error {
+	return d.CommonDB.DeleteUserPasswordHistory(tx, userPasswordHistoryId)
+}
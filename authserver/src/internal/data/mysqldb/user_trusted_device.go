@@ -0,0 +1,31 @@
+package mysqldb
+
+import (
+	"database/sql"
+
+	"github.com/leodip/goiabada/internal/entities"
+)
+
+func (d *MySQLDatabase) CreateUserTrustedDevice(tx *sql.Tx, userTrustedDevice *entities.UserTrustedDevice) error {
+	return d.CommonDB.CreateUserTrustedDevice(tx, userTrustedDevice)
+}
+
+func (d *MySQLDatabase) UpdateUserTrustedDevice(tx *sql.Tx, userTrustedDevice *entities.UserTrustedDevice) error {
+	return d.CommonDB.UpdateUserTrustedDevice(tx, userTrustedDevice)
+}
+
+func (d *MySQLDatabase) GetUserTrustedDeviceById(tx *sql.Tx, userTrustedDeviceId int64) (*entities.UserTrustedDevice, error) {
+	return d.CommonDB.GetUserTrustedDeviceById(tx, userTrustedDeviceId)
+}
+
+func (d *MySQLDatabase) GetUserTrustedDeviceByIdentifierHash(tx *sql.Tx, identifierHash string) (*entities.UserTrustedDevice, error) {
+	return d.CommonDB.GetUserTrustedDeviceByIdentifierHash(tx, identifierHash)
+}
+
+func (d *MySQLDatabase) GetUserTrustedDevicesByUserId(tx *sql.Tx, userId int64) ([]entities.UserTrustedDevice, error) {
+	return d.CommonDB.GetUserTrustedDevicesByUserId(tx, userId)
+}
+
+func (d *MySQLDatabase) DeleteUserTrustedDevice(tx *sql.Tx, userTrustedDeviceId int64) error {
+	return d.CommonDB.DeleteUserTrustedDevice(tx, userTrustedDeviceId)
+}
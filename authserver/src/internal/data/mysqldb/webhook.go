@@ -0,0 +1,31 @@
+package mysqldb
+
+import (
+	"database/sql"
+
+	"github.com/leodip/goiabada/internal/entities"
+)
+
+func (d *MySQLDatabase) CreateWebhook(tx *sql.Tx, webhook *entities.Webhook) error {
+	return d.CommonDB.CreateWebhook(tx, webhook)
+}
+
+func (d *MySQLDatabase) UpdateWebhook(tx *sql.Tx, webhook *entities.Webhook) error {
+	return d.CommonDB.UpdateWebhook(tx, webhook)
+}
+
+func (d *MySQLDatabase) GetWebhookById(tx *sql.Tx, webhookId int64) (*entities.Webhook, error) {
+	return d.CommonDB.GetWebhookById(tx, webhookId)
+}
+
+func (d *MySQLDatabase) GetAllWebhooks(tx *sql.Tx) ([]entities.Webhook, error) {
+	return d.CommonDB.GetAllWebhooks(tx)
+}
+
+func (d *MySQLDatabase) GetEnabledWebhooks(tx *sql.Tx) ([]entities.Webhook, error) {
+	return d.CommonDB.GetEnabledWebhooks(tx)
+}
+
+func (d *MySQLDatabase) DeleteWebhook(tx *sql.Tx, webhookId int64) error {
+	return d.CommonDB.DeleteWebhook(tx, webhookId)
+}
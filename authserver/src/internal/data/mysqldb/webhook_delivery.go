@@ -0,0 +1,32 @@
+package mysqldb
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/leodip/goiabada/internal/entities"
+)
+
+func (d *MySQLDatabase) CreateWebhookDelivery(tx *sql.Tx, webhookDelivery *entities.WebhookDelivery) error {
+	return d.CommonDB.CreateWebhookDelivery(tx, webhookDelivery)
+}
+
+func (d *MySQLDatabase) UpdateWebhookDelivery(tx *sql.Tx, webhookDelivery *entities.WebhookDelivery) error {
+	return d.CommonDB.UpdateWebhookDelivery(tx, webhookDelivery)
+}
+
+func (d *MySQLDatabase) GetWebhookDeliveryById(tx *sql.Tx, webhookDeliveryId int64) (*entities.WebhookDelivery, error) {
+	return d.CommonDB.GetWebhookDeliveryById(tx, webhookDeliveryId)
+}
+
+func (d *MySQLDatabase) GetDueWebhookDeliveries(tx *sql.Tx, now time.Time, limit int) ([]entities.WebhookDelivery, error) {
+	return d.CommonDB.GetDueWebhookDeliveries(tx, now, limit)
+}
+
+func (d *MySQLDatabase) GetWebhookDeliveriesPaginated(tx *sql.Tx, webhookId int64, page int, pageSize int) ([]entities.WebhookDelivery, int, error) {
+	return d.CommonDB.GetWebhookDeliveriesPaginated(tx, webhookId, page, pageSize)
+}
+
+func (d *MySQLDatabase) DeleteWebhookDeliveriesCreatedBefore(tx *sql.Tx, before time.Time) (int64, error) {
+	return d.CommonDB.DeleteWebhookDeliveriesCreatedBefore(tx, before)
+}
@@ -0,0 +1,47 @@
+package postgresdb
+
+import (
+	"database/sql"
+
+	"github.com/leodip/goiabada/internal/entities"
+)
+
+func (d *PostgresDatabase) CreateClient(tx *sql.Tx, client *entities.Client) error {
+	return d.CommonDB.CreateClient(tx, client)
+}
+
+func (d *PostgresDatabase) UpdateClient(tx *sql.Tx, client *entities.Client) error {
+	return d.CommonDB.UpdateClient(tx, client)
+}
+
+func (d *PostgresDatabase) GetClientById(tx *sql.Tx, clientId int64) (*entities.Client, error) {
+	return d.CommonDB.GetClientById(tx, clientId)
+}
+
+func (d *PostgresDatabase) GetClientByClientIdentifier(tx *sql.Tx, clientIdentifier string) (*entities.Client, error) {
+	return d.CommonDB.GetClientByClientIdentifier(tx, clientIdentifier)
+}
+
+func (d *PostgresDatabase) ClientLoadRedirectURIs(tx *sql.Tx, client *entities.Client) error {
+	return d.CommonDB.ClientLoadRedirectURIs(tx, client)
+}
+
+func (d *PostgresDatabase) ClientLoadWebOrigins(tx *sql.Tx, client *entities.Client) error {
+	return d.CommonDB.ClientLoadWebOrigins(tx, client)
+}
+
+func (d *PostgresDatabase) GetClientsByIds(tx *sql.Tx, clientIds []int64) ([]entities.Client, error) {
+	return d.CommonDB.GetClientsByIds(tx, clientIds)
+}
+
+func (d *PostgresDatabase) ClientLoadPermissions(tx *sql.Tx, client *entities.Client) error {
+	return d.CommonDB.ClientLoadPermissions(tx, client)
+}
+
+func (d *PostgresDatabase) GetAllClients(tx *sql.Tx) ([]*entities.Client, error) {
+	return d.CommonDB.GetAllClients(tx)
+}
+
+func (d *PostgresDatabase) DeleteClient(tx *sql.Tx, clientId int64) error {
+	return d.CommonDB.DeleteClient(tx, clientId)
+}
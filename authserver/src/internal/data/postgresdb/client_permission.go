@@ -0,0 +1,31 @@
+package postgresdb
+
+import (
+	"database/sql"
+
+	"github.com/leodip/goiabada/internal/entities"
+)
+
+func (d *PostgresDatabase) CreateClientPermission(tx *sql.Tx, clientPermission *entities.ClientPermission) error {
+	return d.CommonDB.CreateClientPermission(tx, clientPermission)
+}
+
+func (d *PostgresDatabase) UpdateClientPermission(tx *sql.Tx, clientPermission *entities.ClientPermission) error {
+	return d.CommonDB.UpdateClientPermission(tx, clientPermission)
+}
+
+func (d *PostgresDatabase) GetClientPermissionById(tx *sql.Tx, clientPermissionId int64) (*entities.ClientPermission, error) {
+	return d.CommonDB.GetClientPermissionById(tx, clientPermissionId)
+}
+
+func (d *PostgresDatabase) GetClientPermissionByClientIdAndPermissionId(tx *sql.Tx, clientId, permissionId int64) (*entities.ClientPermission, error) {
+	return d.CommonDB.GetClientPermissionByClientIdAndPermissionId(tx, clientId, permissionId)
+}
+
+func (d *PostgresDatabase) GetClientPermissionsByClientId(tx *sql.Tx, clientId int64) ([]entities.ClientPermission, error) {
+	return d.CommonDB.GetClientPermissionsByClientId(tx, clientId)
+}
+
+func (d *PostgresDatabase) DeleteClientPermission(tx *sql.Tx, clientPermissionId int64) error {
+	return d.CommonDB.DeleteClientPermission(tx, clientPermissionId)
+}
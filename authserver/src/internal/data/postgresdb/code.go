@@ -0,0 +1,35 @@
+package postgresdb
+
+import (
+	"database/sql"
+
+	"github.com/leodip/goiabada/internal/entities"
+)
+
+func (d *PostgresDatabase) CreateCode(tx *sql.Tx, code *entities.Code) error {
+	return d.CommonDB.CreateCode(tx, code)
+}
+
+func (d *PostgresDatabase) UpdateCode(tx *sql.Tx, code *entities.Code) error {
+	return d.CommonDB.UpdateCode(tx, code)
+}
+
+func (d *PostgresDatabase) GetCodeById(tx *sql.Tx, codeId int64) (*entities.Code, error) {
+	return d.CommonDB.GetCodeById(tx, codeId)
+}
+
+func (d *PostgresDatabase) CodeLoadClient(tx *sql.Tx, code *entities.Code) error {
+	return d.CommonDB.CodeLoadClient(tx, code)
+}
+
+func (d *PostgresDatabase) CodeLoadUser(tx *sql.Tx, code *entities.Code) error {
+	return d.CommonDB.CodeLoadUser(tx, code)
+}
+
+func (d *PostgresDatabase) GetCodeByCodeHash(tx *sql.Tx, codeHash string, used bool) (*entities.Code, error) {
+	return d.CommonDB.GetCodeByCodeHash(tx, codeHash, used)
+}
+
+func (d *PostgresDatabase) DeleteCode(tx *sql.Tx, codeId int64) error {
+	return d.CommonDB.DeleteCode(tx, codeId)
+}
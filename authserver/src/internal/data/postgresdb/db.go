@@ -0,0 +1,189 @@
+package postgresdb
+
+import (
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"net"
+	"net/url"
+
+	gomigrate "github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database/postgres"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+	"github.com/huandu/go-sqlbuilder"
+	"github.com/leodip/goiabada/internal/data/commondb"
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+)
+
+//go:embed migrations/*.sql
+var postgresMigrationsFs embed.FS
+
+type PostgresDatabase struct {
+	DB       *sql.DB
+	CommonDB *commondb.CommonDatabase
+}
+
+func NewPostgresDatabase() (*PostgresDatabase, error) {
+	dsnWithoutDBname := postgresDsn("postgres")
+	dsnWithDBname := postgresDsn(viper.GetString("DB.DbName"))
+
+	slog.Info(fmt.Sprintf("using database: %v", dsnWithDBname.Redacted()))
+
+	db, err := sql.Open("postgres", dsnWithoutDBname.String())
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to open database")
+	}
+
+	// create the database if it does not exist
+	var exists bool
+	err = db.QueryRow("SELECT EXISTS (SELECT 1 FROM pg_database WHERE datname = $1)",
+		viper.GetString("DB.DbName")).Scan(&exists)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to check if the database exists")
+	}
+	if !exists {
+		createDatabaseCommand := fmt.Sprintf("CREATE DATABASE %v ENCODING 'UTF8';", pq.QuoteIdentifier(viper.GetString("DB.DbName")))
+		_, err = db.Exec(createDatabaseCommand)
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to create database")
+		}
+	}
+	db.Close()
+
+	db, err = sql.Open("postgres", dsnWithDBname.String())
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to open database")
+	}
+
+	commonDb := commondb.NewCommonDatabase(db, sqlbuilder.PostgreSQL)
+
+	postgresDb := PostgresDatabase{
+		DB:       db,
+		CommonDB: commonDb,
+	}
+	return &postgresDb, nil
+}
+
+// postgresDsn returns the connection URL of the database. The session time zone is UTC, like in
+// the other databases.
+func postgresDsn(dbName string) *url.URL {
+	query := url.Values{}
+	query.Set("sslmode", viper.GetString("DB.SSLMode"))
+	query.Set("timezone", "UTC")
+
+	return &url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(viper.GetString("DB.Username"), viper.GetString("DB.Password")),
+		Host:     net.JoinHostPort(viper.GetString("DB.Host"), viper.GetString("DB.Port")),
+		Path:     "/" + dbName,
+		RawQuery: query.Encode(),
+	}
+}
+
+func (d *PostgresDatabase) BeginTransaction() (*sql.Tx, error) {
+	return d.CommonDB.BeginTransaction()
+}
+
+func (d *PostgresDatabase) CommitTransaction(tx *sql.Tx) error {
+	return d.CommonDB.CommitTransaction(tx)
+}
+
+func (d *PostgresDatabase) RollbackTransaction(tx *sql.Tx) error {
+	return d.CommonDB.RollbackTransaction(tx)
+}
+
+func (d *PostgresDatabase) newMigrate() (*gomigrate.Migrate, error) {
+	driver, err := postgres.WithInstance(d.DB, &postgres.Config{
+		DatabaseName: viper.GetString("DB.DbName"),
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to create migration driver")
+	}
+
+	sourceDriver, err := iofs.New(postgresMigrationsFs, "migrations")
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to create migration filesystem")
+	}
+
+	migrate, err := gomigrate.NewWithInstance("iofs", sourceDriver, "postgres", driver)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to create migration instance")
+	}
+
+	return migrate, nil
+}
+
+func (d *PostgresDatabase) Migrate() error {
+	migrate, err := d.newMigrate()
+	if err != nil {
+		return err
+	}
+
+	err = migrate.Up()
+	if err != nil && err != gomigrate.ErrNoChange {
+		return errors.Wrap(err, "unable to migrate the database")
+	} else if err != nil && err == gomigrate.ErrNoChange {
+		slog.Info("no need to migrate the database")
+	}
+
+	return nil
+}
+
+// MigrateDown reverts the last migrations applied to the database.
+func (d *PostgresDatabase) MigrateDown(steps int) error {
+	migrate, err := d.newMigrate()
+	if err != nil {
+		return err
+	}
+
+	err = migrate.Steps(-steps)
+	if err != nil {
+		return errors.Wrap(err, "unable to revert the migrations")
+	}
+	return nil
+}
+
+// GetMigrationVersion returns the version of the last migration applied to the database, which is
+// zero when no migration was applied. The database is dirty when a migration failed halfway.
+func (d *PostgresDatabase) GetMigrationVersion() (uint, bool, error) {
+	migrate, err := d.newMigrate()
+	if err != nil {
+		return 0, false, err
+	}
+
+	version, dirty, err := migrate.Version()
+	if err == gomigrate.ErrNilVersion {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, errors.Wrap(err, "unable to get the migration version")
+	}
+	return version, dirty, nil
+}
+
+// GetLatestMigrationVersion returns the version of the last migration embedded in the binary.
+func (d *PostgresDatabase) GetLatestMigrationVersion() (uint, error) {
+	sourceDriver, err := iofs.New(postgresMigrationsFs, "migrations")
+	if err != nil {
+		return 0, errors.Wrap(err, "unable to create migration filesystem")
+	}
+
+	version, err := sourceDriver.First()
+	if err != nil {
+		return 0, errors.Wrap(err, "unable to read the migrations")
+	}
+	for {
+		next, err := sourceDriver.Next(version)
+		if errors.Is(err, fs.ErrNotExist) {
+			return version, nil
+		}
+		if err != nil {
+			return 0, errors.Wrap(err, "unable to read the migrations")
+		}
+		version = next
+	}
+}
@@ -0,0 +1,31 @@
+package postgresdb
+
+import (
+	"database/sql"
+
+	"github.com/leodip/goiabada/internal/entities"
+)
+
+func (d *PostgresDatabase) CreateFailedLoginIp(tx *sql.Tx, failedLoginIp *entities.FailedLoginIp) error {
+	return d.CommonDB.CreateFailedLoginIp(tx, failedLoginIp)
+}
+
+func (d *PostgresDatabase) UpdateFailedLoginIp(tx *sql.Tx, failedLoginIp *entities.FailedLoginIp) error {
+	return d.CommonDB.UpdateFailedLoginIp(tx, failedLoginIp)
+}
+
+func (d *PostgresDatabase) GetFailedLoginIpById(tx *sql.Tx, failedLoginIpId int64) (*entities.FailedLoginIp, error) {
+	return d.CommonDB.GetFailedLoginIpById(tx, failedLoginIpId)
+}
+
+func (d *PostgresDatabase) GetFailedLoginIpByIpAddress(tx *sql.Tx, ipAddress string) (*entities.FailedLoginIp, error) {
+	return d.CommonDB.GetFailedLoginIpByIpAddress(tx, ipAddress)
+}
+
+func (d *PostgresDatabase) GetLockedFailedLoginIps(tx *sql.Tx) ([]entities.FailedLoginIp, error) {
+	return d.CommonDB.GetLockedFailedLoginIps(tx)
+}
+
+func (d *PostgresDatabase) DeleteFailedLoginIp(tx *sql.Tx, failedLoginIpId int64) error {
+	return d.CommonDB.DeleteFailedLoginIp(tx, failedLoginIpId)
+}
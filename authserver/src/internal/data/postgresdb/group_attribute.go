@@ -0,0 +1,31 @@
+package postgresdb
+
+import (
+	"database/sql"
+
+	"github.com/leodip/goiabada/internal/entities"
+)
+
+func (d *PostgresDatabase) CreateGroupAttribute(tx *sql.Tx, groupAttribute *entities.GroupAttribute) error {
+	return d.CommonDB.CreateGroupAttribute(tx, groupAttribute)
+}
+
+func (d *PostgresDatabase) UpdateGroupAttribute(tx *sql.Tx, groupAttribute *entities.GroupAttribute) error {
+	return d.CommonDB.UpdateGroupAttribute(tx, groupAttribute)
+}
+
+func (d *PostgresDatabase) GetGroupAttributeById(tx *sql.Tx, groupAttributeId int64) (*entities.GroupAttribute, error) {
+	return d.CommonDB.GetGroupAttributeById(tx, groupAttributeId)
+}
+
+func (d *PostgresDatabase) GetGroupAttributesByGroupIds(tx *sql.Tx, groupIds []int64) ([]entities.GroupAttribute, error) {
+	return d.CommonDB.GetGroupAttributesByGroupIds(tx, groupIds)
+}
+
+func (d *PostgresDatabase) GetGroupAttributesByGroupId(tx *sql.Tx, groupId int64) ([]entities.GroupAttribute, error) {
+	return d.CommonDB.GetGroupAttributesByGroupId(tx, groupId)
+}
+
+func (d *PostgresDatabase) DeleteGroupAttribute(tx *sql.Tx, groupAttributeId int64) error {
+	return d.CommonDB.DeleteGroupAttribute(tx, groupAttributeId)
+}
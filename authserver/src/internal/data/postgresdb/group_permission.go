@@ -0,0 +1,35 @@
+package postgresdb
+
+import (
+	"database/sql"
+
+	"github.com/leodip/goiabada/internal/entities"
+)
+
+func (d *PostgresDatabase) CreateGroupPermission(tx *sql.Tx, groupPermission *entities.GroupPermission) error {
+	return d.CommonDB.CreateGroupPermission(tx, groupPermission)
+}
+
+func (d *PostgresDatabase) UpdateGroupPermission(tx *sql.Tx, groupPermission *entities.GroupPermission) error {
+	return d.CommonDB.UpdateGroupPermission(tx, groupPermission)
+}
+
+func (d *PostgresDatabase) GetGroupPermissionsByGroupId(tx *sql.Tx, groupId int64) ([]entities.GroupPermission, error) {
+	return d.CommonDB.GetGroupPermissionsByGroupId(tx, groupId)
+}
+
+func (d *PostgresDatabase) GetGroupPermissionsByGroupIds(tx *sql.Tx, groupIds []int64) ([]entities.GroupPermission, error) {
+	return d.CommonDB.GetGroupPermissionsByGroupIds(tx, groupIds)
+}
+
+func (d *PostgresDatabase) GetGroupPermissionById(tx *sql.Tx, groupPermissionId int64) (*entities.GroupPermission, error) {
+	return d.CommonDB.GetGroupPermissionById(tx, groupPermissionId)
+}
+
+func (d *PostgresDatabase) GetGroupPermissionByGroupIdAndPermissionId(tx *sql.Tx, groupId, permissionId int64) (*entities.GroupPermission, error) {
+	return d.CommonDB.GetGroupPermissionByGroupIdAndPermissionId(tx, groupId, permissionId)
+}
+
+func (d *PostgresDatabase) DeleteGroupPermission(tx *sql.Tx, groupPermissionId int64) error {
+	return d.CommonDB.DeleteGroupPermission(tx, groupPermissionId)
+}
@@ -0,0 +1,27 @@
+package postgresdb
+
+import (
+	"database/sql"
+
+	"github.com/leodip/goiabada/internal/entities"
+)
+
+func (d *PostgresDatabase) CreateHttpSession(tx *sql.Tx, httpSession *entities.HttpSession) error {
+	return d.CommonDB.CreateHttpSession(tx, httpSession)
+}
+
+func (d *PostgresDatabase) UpdateHttpSession(tx *sql.Tx, httpSession *entities.HttpSession) error {
+	return d.CommonDB.UpdateHttpSession(tx, httpSession)
+}
+
+func (d *PostgresDatabase) GetHttpSessionById(tx *sql.Tx, httpSessionId int64) (*entities.HttpSession, error) {
+	return d.CommonDB.GetHttpSessionById(tx, httpSessionId)
+}
+
+func (d *PostgresDatabase) DeleteHttpSession(tx *sql.Tx, httpSessionId int64) error {
+	return d.CommonDB.DeleteHttpSession(tx, httpSessionId)
+}
+
+func (d *PostgresDatabase) DeleteHttpSessionExpired(tx *sql.Tx) error {
+	return d.CommonDB.DeleteHttpSessionExpired(tx)
+}
@@ -0,0 +1,39 @@
+package postgresdb
+
+import (
+	"database/sql"
+
+	"github.com/leodip/goiabada/internal/entities"
+)
+
+func (d *PostgresDatabase) CreateIdentityProvider(tx *sql.Tx, identityProvider *entities.IdentityProvider) error {
+	return d.CommonDB.CreateIdentityProvider(tx, identityProvider)
+}
+
+func (d *PostgresDatabase) UpdateIdentityProvider(tx *sql.Tx, identityProvider *entities.IdentityProvider) error {
+	return d.CommonDB.UpdateIdentityProvider(tx, identityProvider)
+}
+
+func (d *PostgresDatabase) GetIdentityProviderById(tx *sql.Tx, identityProviderId int64) (*entities.IdentityProvider, error) {
+	return d.CommonDB.GetIdentityProviderById(tx, identityProviderId)
+}
+
+func (d *PostgresDatabase) GetIdentityProviderByIdentifier(tx *sql.Tx, identityProviderIdentifier string) (*entities.IdentityProvider, error) {
+	return d.CommonDB.GetIdentityProviderByIdentifier(tx, identityProviderIdentifier)
+}
+
+func (d *PostgresDatabase) GetIdentityProvidersByIds(tx *sql.Tx, identityProviderIds []int64) ([]entities.IdentityProvider, error) {
+	return d.CommonDB.GetIdentityProvidersByIds(tx, identityProviderIds)
+}
+
+func (d *PostgresDatabase) GetAllIdentityProviders(tx *sql.Tx) ([]entities.IdentityProvider, error) {
+	return d.CommonDB.GetAllIdentityProviders(tx)
+}
+
+func (d *PostgresDatabase) GetEnabledIdentityProviders(tx *sql.Tx) ([]entities.IdentityProvider, error) {
+	return d.CommonDB.GetEnabledIdentityProviders(tx)
+}
+
+func (d *PostgresDatabase) DeleteIdentityProvider(tx *sql.Tx, identityProviderId int64) error {
+	return d.CommonDB.DeleteIdentityProvider(tx, identityProviderId)
+}
@@ -0,0 +1,31 @@
+package postgresdb
+
+import (
+	"database/sql"
+
+	"github.com/leodip/goiabada/internal/entities"
+)
+
+func (d *PostgresDatabase) CreateKeyPair(tx *sql.Tx, keyPair *entities.KeyPair) error {
+	return d.CommonDB.CreateKeyPair(tx, keyPair)
+}
+
+func (d *PostgresDatabase) UpdateKeyPair(tx *sql.Tx, keyPair *entities.KeyPair) error {
+	return d.CommonDB.UpdateKeyPair(tx, keyPair)
+}
+
+func (d *PostgresDatabase) GetKeyPairById(tx *sql.Tx, keyPairId int64) (*entities.KeyPair, error) {
+	return d.CommonDB.GetKeyPairById(tx, keyPairId)
+}
+
+func (d *PostgresDatabase) GetAllSigningKeys(tx *sql.Tx) ([]entities.KeyPair, error) {
+	return d.CommonDB.GetAllSigningKeys(tx)
+}
+
+func (d *PostgresDatabase) GetCurrentSigningKey(tx *sql.Tx) (*entities.KeyPair, error) {
+	return d.CommonDB.GetCurrentSigningKey(tx)
+}
+
+func (d *PostgresDatabase) DeleteKeyPair(tx *sql.Tx, keyPairId int64) error {
+	return d.CommonDB.DeleteKeyPair(tx, keyPairId)
+}
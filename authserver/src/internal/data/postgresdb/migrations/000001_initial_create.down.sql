@@ -0,0 +1,26 @@
+-- BEGIN
+
+DROP TABLE IF EXISTS clients_permissions;
+DROP TABLE IF EXISTS group_attributes;
+DROP TABLE IF EXISTS groups_permissions;
+DROP TABLE IF EXISTS http_sessions;
+DROP TABLE IF EXISTS key_pairs;
+DROP TABLE IF EXISTS pre_registrations;
+DROP TABLE IF EXISTS redirect_uris;
+DROP TABLE IF EXISTS refresh_tokens;
+DROP TABLE IF EXISTS settings;
+DROP TABLE IF EXISTS user_attributes;
+DROP TABLE IF EXISTS user_consents;
+DROP TABLE IF EXISTS user_session_clients;
+DROP TABLE IF EXISTS users_groups;
+DROP TABLE IF EXISTS users_permissions;
+DROP TABLE IF EXISTS web_origins;
+DROP TABLE IF EXISTS user_sessions;
+DROP TABLE IF EXISTS codes;
+DROP TABLE IF EXISTS permissions;
+DROP TABLE IF EXISTS groups;
+DROP TABLE IF EXISTS users;
+DROP TABLE IF EXISTS resources;
+DROP TABLE IF EXISTS clients;
+
+-- END
\ No newline at end of file
@@ -0,0 +1,426 @@
+-- BEGIN
+
+CREATE TABLE clients (
+  id BIGSERIAL NOT NULL,
+  created_at timestamptz(6) DEFAULT NULL,
+  updated_at timestamptz(6) DEFAULT NULL,
+  client_identifier varchar(40) NOT NULL,
+  client_secret_encrypted bytea,
+  description varchar(128) DEFAULT NULL,
+  enabled boolean NOT NULL,
+  consent_required boolean NOT NULL,
+  is_public boolean NOT NULL,
+  authorization_code_enabled boolean NOT NULL,
+  client_credentials_enabled boolean NOT NULL,
+  token_expiration_in_seconds int NOT NULL,
+  refresh_token_offline_idle_timeout_in_seconds int NOT NULL,
+  refresh_token_offline_max_lifetime_in_seconds int NOT NULL,
+  include_open_id_connect_claims_in_access_token varchar(16) NOT NULL,
+  default_acr_level varchar(128) NOT NULL,
+  PRIMARY KEY (id),
+  CONSTRAINT idx_client_identifier UNIQUE (client_identifier)
+);
+
+
+CREATE TABLE resources (
+  id BIGSERIAL NOT NULL,
+  created_at timestamptz(6) DEFAULT NULL,
+  updated_at timestamptz(6) DEFAULT NULL,
+  resource_identifier varchar(40) NOT NULL,
+  description varchar(128) DEFAULT NULL,
+  PRIMARY KEY (id),
+  CONSTRAINT idx_resource_identifier UNIQUE (resource_identifier)
+);
+
+
+CREATE TABLE permissions (
+  id BIGSERIAL NOT NULL,
+  created_at timestamptz(6) DEFAULT NULL,
+  updated_at timestamptz(6) DEFAULT NULL,
+  permission_identifier varchar(40) NOT NULL,
+  description varchar(128) DEFAULT NULL,
+  resource_id bigint NOT NULL,
+  PRIMARY KEY (id),
+  CONSTRAINT idx_permission_identifier UNIQUE (permission_identifier),
+  CONSTRAINT fk_permissions_resource FOREIGN KEY (resource_id) REFERENCES resources (id) ON DELETE CASCADE
+);
+
+CREATE INDEX fk_permissions_resource ON permissions (resource_id);
+
+
+CREATE TABLE clients_permissions (
+  id BIGSERIAL NOT NULL,
+  created_at timestamptz(6) DEFAULT NULL,
+  updated_at timestamptz(6) DEFAULT NULL,
+  client_id bigint NOT NULL,
+  permission_id bigint NOT NULL,
+  PRIMARY KEY (id),
+  CONSTRAINT fk_clients_permissions_client FOREIGN KEY (client_id) REFERENCES clients (id) ON DELETE CASCADE,
+  CONSTRAINT fk_clients_permissions_permission FOREIGN KEY (permission_id) REFERENCES permissions (id) ON DELETE CASCADE
+);
+
+CREATE INDEX fk_clients_permissions_client ON clients_permissions (client_id);
+
+CREATE INDEX fk_clients_permissions_permission ON clients_permissions (permission_id);
+
+
+CREATE TABLE users (
+  id BIGSERIAL NOT NULL,
+  created_at timestamptz(6) DEFAULT NULL,
+  updated_at timestamptz(6) DEFAULT NULL,
+  enabled boolean NOT NULL,
+  subject varchar(64) NOT NULL,
+  username varchar(32) NOT NULL,
+  given_name varchar(64) DEFAULT NULL,
+  middle_name varchar(64) DEFAULT NULL,
+  family_name varchar(64) DEFAULT NULL,
+  nickname varchar(64) DEFAULT NULL,
+  website varchar(128) DEFAULT NULL,
+  gender varchar(16) DEFAULT NULL,
+  email varchar(64) DEFAULT NULL,
+  email_verified boolean NOT NULL,
+  email_verification_code_encrypted bytea,
+  email_verification_code_issued_at timestamptz(6) DEFAULT NULL,
+  zone_info_country_name varchar(128) DEFAULT NULL,
+  zone_info varchar(128) DEFAULT NULL,
+  locale varchar(32) DEFAULT NULL,
+  birth_date timestamptz(6) DEFAULT NULL,
+  phone_number varchar(32) DEFAULT NULL,
+  phone_number_verified boolean NOT NULL,
+  phone_number_verification_code_encrypted bytea,
+  phone_number_verification_code_issued_at timestamptz(6) DEFAULT NULL,
+  address_line1 varchar(64) DEFAULT NULL,
+  address_line2 varchar(64) DEFAULT NULL,
+  address_locality varchar(64) DEFAULT NULL,
+  address_region varchar(64) DEFAULT NULL,
+  address_postal_code varchar(32) DEFAULT NULL,
+  address_country varchar(32) DEFAULT NULL,
+  password_hash varchar(64) NOT NULL,
+  otp_secret varchar(64) DEFAULT NULL,
+  otp_enabled boolean NOT NULL,
+  forgot_password_code_encrypted bytea,
+  forgot_password_code_issued_at timestamptz(6) DEFAULT NULL,
+  PRIMARY KEY (id),
+  CONSTRAINT idx_subject UNIQUE (subject),
+  CONSTRAINT idx_email UNIQUE (email)
+);
+
+CREATE INDEX idx_username ON users (username);
+
+CREATE INDEX idx_given_name ON users (given_name);
+
+CREATE INDEX idx_middle_name ON users (middle_name);
+
+CREATE INDEX idx_family_name ON users (family_name);
+
+
+CREATE TABLE codes (
+  id BIGSERIAL NOT NULL,
+  created_at timestamptz(6) DEFAULT NULL,
+  updated_at timestamptz(6) DEFAULT NULL,
+  code_hash varchar(64) NOT NULL,
+  client_id bigint NOT NULL,
+  code_challenge varchar(256) NOT NULL,
+  code_challenge_method varchar(10) NOT NULL,
+  scope varchar(512) NOT NULL,
+  state varchar(512) NOT NULL,
+  nonce varchar(512) NOT NULL,
+  redirect_uri varchar(256) NOT NULL,
+  user_id bigint NOT NULL,
+  ip_address varchar(64) NOT NULL,
+  user_agent varchar(512) NOT NULL,
+  response_mode varchar(16) NOT NULL,
+  authenticated_at timestamptz(6) NOT NULL,
+  session_identifier varchar(64) NOT NULL,
+  acr_level varchar(128) NOT NULL,
+  auth_methods varchar(64) NOT NULL,
+  used boolean NOT NULL,
+  PRIMARY KEY (id),
+  CONSTRAINT idx_code_hash UNIQUE (code_hash),
+  CONSTRAINT fk_codes_client FOREIGN KEY (client_id) REFERENCES clients (id) ON DELETE CASCADE,
+  CONSTRAINT fk_codes_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+CREATE INDEX fk_codes_client ON codes (client_id);
+
+CREATE INDEX fk_codes_user ON codes (user_id);
+
+
+CREATE TABLE groups (
+  id BIGSERIAL NOT NULL,
+  created_at timestamptz(6) DEFAULT NULL,
+  updated_at timestamptz(6) DEFAULT NULL,
+  group_identifier varchar(40) NOT NULL,
+  description varchar(128) DEFAULT NULL,
+  include_in_id_token boolean NOT NULL,
+  include_in_access_token boolean NOT NULL,
+  PRIMARY KEY (id),
+  CONSTRAINT idx_group_identifier UNIQUE (group_identifier)
+);
+
+
+CREATE TABLE group_attributes (
+  id BIGSERIAL NOT NULL,
+  created_at timestamptz(6) DEFAULT NULL,
+  updated_at timestamptz(6) DEFAULT NULL,
+  "key" varchar(32) NOT NULL,
+  "value" varchar(256) NOT NULL,
+  include_in_id_token boolean NOT NULL,
+  include_in_access_token boolean NOT NULL,
+  group_id bigint NOT NULL,
+  PRIMARY KEY (id),
+  CONSTRAINT fk_groups_attributes FOREIGN KEY (group_id) REFERENCES groups (id) ON DELETE CASCADE
+);
+
+CREATE INDEX fk_groups_attributes ON group_attributes (group_id);
+
+
+CREATE TABLE groups_permissions (
+  id BIGSERIAL NOT NULL,
+  created_at timestamptz(6) DEFAULT NULL,
+  updated_at timestamptz(6) DEFAULT NULL,
+  group_id bigint NOT NULL,
+  permission_id bigint NOT NULL,
+  PRIMARY KEY (id),
+  CONSTRAINT fk_groups_permissions_group FOREIGN KEY (group_id) REFERENCES groups (id) ON DELETE CASCADE,
+  CONSTRAINT fk_groups_permissions_permission FOREIGN KEY (permission_id) REFERENCES permissions (id) ON DELETE CASCADE
+);
+
+CREATE INDEX fk_groups_permissions_group ON groups_permissions (group_id);
+
+CREATE INDEX fk_groups_permissions_permission ON groups_permissions (permission_id);
+
+
+CREATE TABLE http_sessions (
+  id BIGSERIAL NOT NULL,
+  created_at timestamptz(6) DEFAULT NULL,
+  updated_at timestamptz(6) DEFAULT NULL,
+  data text,
+  expires_on timestamptz(6) DEFAULT NULL,
+  PRIMARY KEY (id)
+);
+
+CREATE INDEX idx_httpsess_expires ON http_sessions (expires_on);
+
+
+CREATE TABLE key_pairs (
+  id BIGSERIAL NOT NULL,
+  created_at timestamptz(6) DEFAULT NULL,
+  updated_at timestamptz(6) DEFAULT NULL,
+  state varchar(191) NOT NULL,
+  key_identifier varchar(64) NOT NULL,
+  "type" varchar(16) NOT NULL,
+  "algorithm" varchar(16) NOT NULL,
+  private_key_pem bytea,
+  public_key_pem bytea,
+  public_key_asn1_der bytea,
+  public_key_jwk bytea,
+  PRIMARY KEY (id)
+);
+
+CREATE INDEX idx_state ON key_pairs (state);
+
+
+CREATE TABLE pre_registrations (
+  id BIGSERIAL NOT NULL,
+  created_at timestamptz(6) DEFAULT NULL,
+  updated_at timestamptz(6) DEFAULT NULL,
+  email varchar(64) DEFAULT NULL,
+  password_hash varchar(64) NOT NULL,
+  verification_code_encrypted bytea,
+  verification_code_issued_at timestamptz(6) DEFAULT NULL,
+  PRIMARY KEY (id)
+);
+
+CREATE INDEX idx_pre_reg_email ON pre_registrations (email);
+
+
+CREATE TABLE redirect_uris (
+  id BIGSERIAL NOT NULL,
+  created_at timestamptz(6) DEFAULT NULL,
+  uri varchar(256) NOT NULL,
+  client_id bigint NOT NULL,
+  PRIMARY KEY (id),
+  CONSTRAINT fk_clients_redirect_uris FOREIGN KEY (client_id) REFERENCES clients (id) ON DELETE CASCADE
+);
+
+CREATE INDEX fk_clients_redirect_uris ON redirect_uris (client_id);
+
+
+CREATE TABLE refresh_tokens (
+  id BIGSERIAL NOT NULL,
+  created_at timestamptz(6) DEFAULT NULL,
+  updated_at timestamptz(6) DEFAULT NULL,
+  code_id bigint NOT NULL,
+  refresh_token_jti varchar(64) NOT NULL,
+  previous_refresh_token_jti varchar(64) NOT NULL,
+  first_refresh_token_jti varchar(64) NOT NULL,
+  session_identifier varchar(64) NOT NULL,
+  refresh_token_type varchar(16) NOT NULL,
+  scope varchar(512) NOT NULL,
+  issued_at timestamptz(6) DEFAULT NULL,
+  expires_at timestamptz(6) DEFAULT NULL,
+  max_lifetime timestamptz(6) DEFAULT NULL,
+  revoked boolean NOT NULL,
+  PRIMARY KEY (id),
+  CONSTRAINT idx_refresh_token_jti UNIQUE (refresh_token_jti),
+  CONSTRAINT fk_refresh_tokens_code FOREIGN KEY (code_id) REFERENCES codes (id) ON DELETE CASCADE
+);
+
+CREATE INDEX fk_refresh_tokens_code ON refresh_tokens (code_id);
+
+
+CREATE TABLE settings (
+  id BIGSERIAL NOT NULL,
+  created_at timestamptz(6) DEFAULT NULL,
+  updated_at timestamptz(6) DEFAULT NULL,
+  app_name varchar(32) NOT NULL,
+  issuer varchar(64) NOT NULL,
+  ui_theme varchar(32) NOT NULL,
+  password_policy int DEFAULT NULL,
+  self_registration_enabled boolean NOT NULL,
+  self_registration_requires_email_verification boolean NOT NULL,
+  token_expiration_in_seconds int NOT NULL,
+  refresh_token_offline_idle_timeout_in_seconds int NOT NULL,
+  refresh_token_offline_max_lifetime_in_seconds int NOT NULL,
+  user_session_idle_timeout_in_seconds int NOT NULL,
+  user_session_max_lifetime_in_seconds int NOT NULL,
+  include_open_id_connect_claims_in_access_token boolean NOT NULL,
+  session_authentication_key bytea NOT NULL,
+  session_encryption_key bytea NOT NULL,
+  aes_encryption_key bytea NOT NULL,
+  smtp_host varchar(128) DEFAULT NULL,
+  smtp_port int DEFAULT NULL,
+  smtp_username varchar(64) DEFAULT NULL,
+  smtp_password_encrypted bytea,
+  smtp_from_name varchar(64) DEFAULT NULL,
+  smtp_from_email varchar(64) DEFAULT NULL,
+  smtp_encryption varchar(16) DEFAULT NULL,
+  smtp_enabled boolean NOT NULL,
+  sms_provider varchar(32) DEFAULT NULL,
+  sms_config_encrypted bytea,
+  PRIMARY KEY (id)
+);
+
+
+
+CREATE TABLE user_attributes (
+  id BIGSERIAL NOT NULL,
+  created_at timestamptz(6) DEFAULT NULL,
+  updated_at timestamptz(6) DEFAULT NULL,
+  "key" varchar(32) NOT NULL,
+  "value" varchar(256) NOT NULL,
+  include_in_id_token boolean NOT NULL,
+  include_in_access_token boolean NOT NULL,
+  user_id bigint NOT NULL,
+  PRIMARY KEY (id),
+  CONSTRAINT fk_users_attributes FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+CREATE INDEX fk_users_attributes ON user_attributes (user_id);
+
+
+CREATE TABLE user_consents (
+  id BIGSERIAL NOT NULL,
+  created_at timestamptz(6) DEFAULT NULL,
+  updated_at timestamptz(6) DEFAULT NULL,
+  user_id bigint NOT NULL,
+  client_id bigint NOT NULL,
+  scope varchar(512) NOT NULL,
+  granted_at timestamptz(6) DEFAULT NULL,
+  PRIMARY KEY (id),
+  CONSTRAINT fk_user_consents_client FOREIGN KEY (client_id) REFERENCES clients (id) ON DELETE CASCADE,
+  CONSTRAINT fk_user_consents_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+CREATE INDEX fk_user_consents_user ON user_consents (user_id);
+
+CREATE INDEX fk_user_consents_client ON user_consents (client_id);
+
+
+CREATE TABLE user_sessions (
+  id BIGSERIAL NOT NULL,
+  created_at timestamptz(6) DEFAULT NULL,
+  updated_at timestamptz(6) DEFAULT NULL,
+  session_identifier varchar(64) NOT NULL,
+  started timestamptz(6) NOT NULL,
+  last_accessed timestamptz(6) NOT NULL,
+  auth_methods varchar(64) NOT NULL,
+  acr_level varchar(128) NOT NULL,
+  auth_time timestamptz(6) NOT NULL,
+  ip_address varchar(512) NOT NULL,
+  device_name varchar(256) NOT NULL,
+  device_type varchar(32) NOT NULL,
+  device_os varchar(64) NOT NULL,
+  user_id bigint NOT NULL,
+  PRIMARY KEY (id),
+  CONSTRAINT idx_session_identifier UNIQUE (session_identifier),
+  CONSTRAINT fk_user_sessions_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+CREATE INDEX fk_user_sessions_user ON user_sessions (user_id);
+
+
+CREATE TABLE user_session_clients (
+  id BIGSERIAL NOT NULL,
+  created_at timestamptz(6) DEFAULT NULL,
+  updated_at timestamptz(6) DEFAULT NULL,
+  user_session_id bigint NOT NULL,
+  client_id bigint NOT NULL,
+  started timestamptz(6) NOT NULL,
+  last_accessed timestamptz(6) NOT NULL,
+  PRIMARY KEY (id),
+  CONSTRAINT fk_user_sessions_clients FOREIGN KEY (user_session_id) REFERENCES user_sessions (id) ON DELETE CASCADE,
+  CONSTRAINT fk_user_session_clients_client FOREIGN KEY (client_id) REFERENCES clients (id) ON DELETE CASCADE
+);
+
+CREATE INDEX fk_user_sessions_clients ON user_session_clients (user_session_id);
+
+CREATE INDEX fk_user_session_clients_client ON user_session_clients (client_id);
+
+
+CREATE TABLE users_groups (
+  id BIGSERIAL NOT NULL,
+  created_at timestamptz(6) DEFAULT NULL,
+  updated_at timestamptz(6) DEFAULT NULL,
+  group_id bigint NOT NULL,
+  user_id bigint NOT NULL,
+  PRIMARY KEY (id),
+  CONSTRAINT fk_users_groups_group FOREIGN KEY (group_id) REFERENCES groups (id) ON DELETE CASCADE,
+  CONSTRAINT fk_users_groups_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+CREATE INDEX fk_users_groups_group ON users_groups (group_id);
+
+CREATE INDEX fk_users_groups_user ON users_groups (user_id);
+
+
+
+CREATE TABLE users_permissions (
+  id BIGSERIAL NOT NULL,
+  created_at timestamptz(6) DEFAULT NULL,
+  updated_at timestamptz(6) DEFAULT NULL,
+  user_id bigint NOT NULL,
+  permission_id bigint NOT NULL,
+  PRIMARY KEY (id),
+  CONSTRAINT fk_users_permissions_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE,
+  CONSTRAINT fk_users_permissions_permission FOREIGN KEY (permission_id) REFERENCES permissions (id) ON DELETE CASCADE
+);
+
+CREATE INDEX fk_users_permissions_user ON users_permissions (user_id);
+
+CREATE INDEX fk_users_permissions_permission ON users_permissions (permission_id);
+
+
+CREATE TABLE web_origins (
+  id BIGSERIAL NOT NULL,
+  created_at timestamptz(6) DEFAULT NULL,
+  origin varchar(256) NOT NULL,
+  client_id bigint NOT NULL,
+  PRIMARY KEY (id),
+  CONSTRAINT fk_clients_web_origins FOREIGN KEY (client_id) REFERENCES clients (id) ON DELETE CASCADE
+);
+
+CREATE INDEX fk_clients_web_origins ON web_origins (client_id);
+
+-- END
\ No newline at end of file
@@ -0,0 +1,41 @@
+-- BEGIN
+
+CREATE TABLE identity_providers (
+  id BIGSERIAL NOT NULL,
+  created_at timestamptz(6) DEFAULT NULL,
+  updated_at timestamptz(6) DEFAULT NULL,
+  name varchar(64) NOT NULL,
+  identity_provider_identifier varchar(40) NOT NULL,
+  "type" varchar(16) NOT NULL,
+  enabled boolean NOT NULL,
+  issuer varchar(256) NOT NULL,
+  client_identifier varchar(256) NOT NULL,
+  client_secret_encrypted bytea,
+  scopes varchar(512) NOT NULL,
+  email_claim varchar(64) NOT NULL,
+  given_name_claim varchar(64) NOT NULL,
+  family_name_claim varchar(64) NOT NULL,
+  link_by_email boolean NOT NULL,
+  auto_provision boolean NOT NULL,
+  PRIMARY KEY (id),
+  CONSTRAINT idx_identity_provider_identifier UNIQUE (identity_provider_identifier)
+);
+
+
+CREATE TABLE user_federated_identities (
+  id BIGSERIAL NOT NULL,
+  created_at timestamptz(6) DEFAULT NULL,
+  updated_at timestamptz(6) DEFAULT NULL,
+  user_id bigint NOT NULL,
+  identity_provider_id bigint NOT NULL,
+  subject varchar(256) NOT NULL,
+  last_login_at timestamptz(6) DEFAULT NULL,
+  PRIMARY KEY (id),
+  CONSTRAINT idx_identity_provider_subject UNIQUE (identity_provider_id, subject),
+  CONSTRAINT fk_user_federated_identities_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE,
+  CONSTRAINT fk_user_federated_identities_identity_provider FOREIGN KEY (identity_provider_id) REFERENCES identity_providers (id) ON DELETE CASCADE
+);
+
+CREATE INDEX fk_user_federated_identities_user ON user_federated_identities (user_id);
+
+-- END
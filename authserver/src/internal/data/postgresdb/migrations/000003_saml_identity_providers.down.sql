@@ -0,0 +1,10 @@
+-- BEGIN
+
+ALTER TABLE identity_providers
+  DROP COLUMN saml_metadata_url,
+  DROP COLUMN saml_metadata_xml,
+  DROP COLUMN saml_name_id_format,
+  DROP COLUMN groups_claim,
+  DROP COLUMN user_attributes_mapping;
+
+-- END
@@ -0,0 +1,13 @@
+-- BEGIN
+
+ALTER TABLE identity_providers
+  ADD COLUMN ldap_url varchar(512) NOT NULL DEFAULT '',
+  ADD COLUMN ldap_start_tls boolean NOT NULL DEFAULT false,
+  ADD COLUMN ldap_bind_dn varchar(512) NOT NULL DEFAULT '',
+  ADD COLUMN ldap_bind_password_encrypted bytea,
+  ADD COLUMN ldap_base_dn varchar(512) NOT NULL DEFAULT '',
+  ADD COLUMN ldap_user_filter varchar(512) NOT NULL DEFAULT '',
+  ADD COLUMN ldap_subject_attribute varchar(128) NOT NULL DEFAULT '',
+  ADD COLUMN ldap_local_password_fallback boolean NOT NULL DEFAULT false;
+
+-- END
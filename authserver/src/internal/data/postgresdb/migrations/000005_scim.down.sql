@@ -0,0 +1,11 @@
+-- BEGIN
+
+DELETE FROM permissions WHERE permission_identifier = 'scim';
+
+ALTER TABLE groups
+  DROP COLUMN external_id;
+
+ALTER TABLE users
+  DROP COLUMN external_id;
+
+-- END
@@ -0,0 +1,24 @@
+-- BEGIN
+
+CREATE TABLE user_passkeys (
+  id BIGSERIAL NOT NULL,
+  created_at timestamptz(6) DEFAULT NULL,
+  updated_at timestamptz(6) DEFAULT NULL,
+  user_id bigint NOT NULL,
+  name varchar(64) NOT NULL,
+  credential_id bytea NOT NULL,
+  public_key bytea NOT NULL,
+  attestation_type varchar(32) NOT NULL,
+  transports varchar(128) NOT NULL,
+  aaguid bytea DEFAULT NULL,
+  sign_count bigint NOT NULL,
+  backup_eligible boolean NOT NULL,
+  backup_state boolean NOT NULL,
+  last_used_at timestamptz(6) DEFAULT NULL,
+  PRIMARY KEY (id),
+  CONSTRAINT fk_user_passkeys_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+CREATE INDEX fk_user_passkeys_user ON user_passkeys (user_id);
+
+-- END
@@ -0,0 +1,12 @@
+-- BEGIN
+
+ALTER TABLE users
+  DROP COLUMN email_login_code_hash,
+  DROP COLUMN email_login_token_hash,
+  DROP COLUMN email_login_code_issued_at,
+  DROP COLUMN email_login_code_attempts;
+
+ALTER TABLE clients
+  DROP COLUMN email_login_enabled;
+
+-- END
@@ -0,0 +1,25 @@
+-- BEGIN
+
+ALTER TABLE settings
+  ADD COLUMN trusted_device_lifetime_in_days int NOT NULL DEFAULT 30;
+
+CREATE TABLE user_trusted_devices (
+  id BIGSERIAL NOT NULL,
+  created_at timestamptz(6) DEFAULT NULL,
+  updated_at timestamptz(6) DEFAULT NULL,
+  user_id bigint NOT NULL,
+  identifier_hash varchar(64) NOT NULL,
+  ip_address varchar(512) NOT NULL,
+  device_name varchar(256) NOT NULL,
+  device_type varchar(32) NOT NULL,
+  device_os varchar(64) NOT NULL,
+  expires_at timestamptz(6) NOT NULL,
+  last_used_at timestamptz(6) DEFAULT NULL,
+  PRIMARY KEY (id),
+  CONSTRAINT idx_identifier_hash UNIQUE (identifier_hash),
+  CONSTRAINT fk_user_trusted_devices_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+CREATE INDEX fk_user_trusted_devices_user ON user_trusted_devices (user_id);
+
+-- END
@@ -0,0 +1,16 @@
+-- BEGIN
+
+DROP TABLE IF EXISTS failed_login_ips;
+
+ALTER TABLE users
+  DROP COLUMN failed_login_attempts,
+  DROP COLUMN last_failed_login_at,
+  DROP COLUMN locked_until;
+
+ALTER TABLE settings
+  DROP COLUMN login_delay_after_failed_attempts,
+  DROP COLUMN login_lockout_after_failed_attempts,
+  DROP COLUMN login_ip_lockout_after_failed_attempts,
+  DROP COLUMN login_lockout_duration_in_seconds;
+
+-- END
@@ -0,0 +1,26 @@
+-- BEGIN
+
+ALTER TABLE settings
+  ADD COLUMN login_delay_after_failed_attempts int NOT NULL DEFAULT 3,
+  ADD COLUMN login_lockout_after_failed_attempts int NOT NULL DEFAULT 10,
+  ADD COLUMN login_ip_lockout_after_failed_attempts int NOT NULL DEFAULT 50,
+  ADD COLUMN login_lockout_duration_in_seconds int NOT NULL DEFAULT 900;
+
+ALTER TABLE users
+  ADD COLUMN failed_login_attempts int NOT NULL DEFAULT 0,
+  ADD COLUMN last_failed_login_at timestamptz(6) DEFAULT NULL,
+  ADD COLUMN locked_until timestamptz(6) DEFAULT NULL;
+
+CREATE TABLE failed_login_ips (
+  id BIGSERIAL NOT NULL,
+  created_at timestamptz(6) DEFAULT NULL,
+  updated_at timestamptz(6) DEFAULT NULL,
+  ip_address varchar(512) NOT NULL,
+  failed_attempts int NOT NULL,
+  last_failed_at timestamptz(6) NOT NULL,
+  locked_until timestamptz(6) DEFAULT NULL,
+  PRIMARY KEY (id),
+  CONSTRAINT idx_ip_address UNIQUE (ip_address)
+);
+
+-- END
@@ -0,0 +1,12 @@
+-- BEGIN
+
+DROP TABLE IF EXISTS breached_password_hashes;
+
+ALTER TABLE users
+  DROP COLUMN password_breached;
+
+ALTER TABLE settings
+  DROP COLUMN reject_breached_passwords,
+  DROP COLUMN force_change_of_breached_passwords;
+
+-- END
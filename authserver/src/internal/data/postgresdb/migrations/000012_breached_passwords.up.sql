@@ -0,0 +1,17 @@
+-- BEGIN
+
+ALTER TABLE settings
+  ADD COLUMN reject_breached_passwords boolean NOT NULL DEFAULT true,
+  ADD COLUMN force_change_of_breached_passwords boolean NOT NULL DEFAULT false;
+
+ALTER TABLE users
+  ADD COLUMN password_breached boolean NOT NULL DEFAULT false;
+
+CREATE TABLE breached_password_hashes (
+  id BIGSERIAL NOT NULL,
+  sha1_hash char(40) NOT NULL,
+  PRIMARY KEY (id),
+  CONSTRAINT idx_sha1_hash UNIQUE (sha1_hash)
+);
+
+-- END
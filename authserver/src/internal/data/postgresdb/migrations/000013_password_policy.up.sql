@@ -0,0 +1,41 @@
+-- BEGIN
+
+ALTER TABLE settings
+  ADD COLUMN password_min_length int NOT NULL DEFAULT 6,
+  ADD COLUMN password_max_length int NOT NULL DEFAULT 64,
+  ADD COLUMN password_requires_uppercase boolean NOT NULL DEFAULT false,
+  ADD COLUMN password_requires_lowercase boolean NOT NULL DEFAULT false,
+  ADD COLUMN password_requires_number boolean NOT NULL DEFAULT false,
+  ADD COLUMN password_requires_special_char boolean NOT NULL DEFAULT false,
+  ADD COLUMN password_disallow_username_or_email boolean NOT NULL DEFAULT true,
+  ADD COLUMN password_history_count int NOT NULL DEFAULT 0,
+  ADD COLUMN password_max_age_in_days int NOT NULL DEFAULT 0,
+  ADD COLUMN password_min_age_in_days int NOT NULL DEFAULT 0;
+
+UPDATE settings SET
+  password_min_length = CASE password_policy WHEN 0 THEN 1 WHEN 2 THEN 8 WHEN 3 THEN 10 ELSE 6 END,
+  password_requires_uppercase = CASE WHEN password_policy >= 2 THEN true ELSE false END,
+  password_requires_lowercase = CASE WHEN password_policy >= 2 THEN true ELSE false END,
+  password_requires_number = CASE WHEN password_policy >= 2 THEN true ELSE false END,
+  password_requires_special_char = CASE WHEN password_policy >= 3 THEN true ELSE false END;
+
+ALTER TABLE settings
+  DROP COLUMN password_policy;
+
+ALTER TABLE users
+  ADD COLUMN password_changed_at timestamptz(6) DEFAULT NULL;
+
+UPDATE users SET password_changed_at = COALESCE(updated_at, created_at) WHERE password_hash <> '';
+
+CREATE TABLE user_password_history (
+  id BIGSERIAL NOT NULL,
+  created_at timestamptz(6) DEFAULT NULL,
+  user_id bigint NOT NULL,
+  password_hash varchar(64) NOT NULL,
+  PRIMARY KEY (id),
+  CONSTRAINT fk_user_password_history_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+CREATE INDEX fk_user_password_history_user ON user_password_history (user_id);
+
+-- END
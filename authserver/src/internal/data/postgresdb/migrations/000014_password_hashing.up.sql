@@ -0,0 +1,12 @@
+-- BEGIN
+
+ALTER TABLE users
+  ALTER COLUMN password_hash TYPE varchar(255);
+
+ALTER TABLE pre_registrations
+  ALTER COLUMN password_hash TYPE varchar(255);
+
+ALTER TABLE user_password_history
+  ALTER COLUMN password_hash TYPE varchar(255);
+
+-- END
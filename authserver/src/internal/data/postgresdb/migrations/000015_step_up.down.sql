@@ -0,0 +1,11 @@
+-- BEGIN
+
+ALTER TABLE permissions
+  DROP COLUMN min_acr_level,
+  DROP COLUMN max_auth_age_in_seconds;
+
+ALTER TABLE resources
+  DROP COLUMN min_acr_level,
+  DROP COLUMN max_auth_age_in_seconds;
+
+-- END
@@ -0,0 +1,11 @@
+-- BEGIN
+
+DROP TABLE IF EXISTS user_known_devices;
+
+ALTER TABLE users
+  DROP COLUMN disable_password_change_notification,
+  DROP COLUMN disable_email_change_notification,
+  DROP COLUMN disable_otp_change_notification,
+  DROP COLUMN disable_new_device_notification;
+
+-- END
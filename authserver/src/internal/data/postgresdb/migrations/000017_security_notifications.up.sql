@@ -0,0 +1,24 @@
+-- BEGIN
+
+ALTER TABLE users
+  ADD COLUMN disable_password_change_notification boolean NOT NULL DEFAULT false,
+  ADD COLUMN disable_email_change_notification boolean NOT NULL DEFAULT false,
+  ADD COLUMN disable_otp_change_notification boolean NOT NULL DEFAULT false,
+  ADD COLUMN disable_new_device_notification boolean NOT NULL DEFAULT false;
+
+CREATE TABLE user_known_devices (
+  id BIGSERIAL NOT NULL,
+  created_at timestamptz(6) DEFAULT NULL,
+  updated_at timestamptz(6) DEFAULT NULL,
+  user_id bigint NOT NULL,
+  fingerprint varchar(64) NOT NULL,
+  device_name varchar(256) NOT NULL,
+  device_type varchar(32) NOT NULL,
+  device_os varchar(64) NOT NULL,
+  last_seen_at timestamptz(6) NOT NULL,
+  PRIMARY KEY (id),
+  CONSTRAINT idx_user_id_fingerprint UNIQUE (user_id, fingerprint),
+  CONSTRAINT fk_user_known_devices_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+-- END
@@ -0,0 +1,14 @@
+-- BEGIN
+
+ALTER TABLE codes
+  DROP COLUMN impersonator_subject;
+
+ALTER TABLE user_sessions
+  DROP CONSTRAINT fk_user_sessions_impersonator_user,
+  DROP COLUMN impersonator_user_id,
+  DROP COLUMN impersonation_expires_at;
+
+ALTER TABLE clients
+  DROP COLUMN accept_impersonated_tokens;
+
+-- END
@@ -0,0 +1,5 @@
+-- BEGIN
+
+DELETE FROM permissions WHERE permission_identifier IN ('admin-api-users-read', 'admin-api-users-write', 'admin-api-groups-read', 'admin-api-groups-write', 'admin-api-clients-read', 'admin-api-clients-write', 'admin-api-resources-read', 'admin-api-resources-write', 'admin-api-settings-read', 'admin-api-settings-write');
+
+-- END
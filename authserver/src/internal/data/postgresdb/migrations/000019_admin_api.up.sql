@@ -0,0 +1,43 @@
+-- BEGIN
+
+INSERT INTO permissions (created_at, updated_at, permission_identifier, description, resource_id)
+  SELECT NOW(), NOW(), 'admin-api-users-read', 'Read users via the admin API', id
+  FROM resources WHERE resource_identifier = 'authserver';
+
+INSERT INTO permissions (created_at, updated_at, permission_identifier, description, resource_id)
+  SELECT NOW(), NOW(), 'admin-api-users-write', 'Manage users via the admin API', id
+  FROM resources WHERE resource_identifier = 'authserver';
+
+INSERT INTO permissions (created_at, updated_at, permission_identifier, description, resource_id)
+  SELECT NOW(), NOW(), 'admin-api-groups-read', 'Read groups via the admin API', id
+  FROM resources WHERE resource_identifier = 'authserver';
+
+INSERT INTO permissions (created_at, updated_at, permission_identifier, description, resource_id)
+  SELECT NOW(), NOW(), 'admin-api-groups-write', 'Manage groups via the admin API', id
+  FROM resources WHERE resource_identifier = 'authserver';
+
+INSERT INTO permissions (created_at, updated_at, permission_identifier, description, resource_id)
+  SELECT NOW(), NOW(), 'admin-api-clients-read', 'Read clients via the admin API', id
+  FROM resources WHERE resource_identifier = 'authserver';
+
+INSERT INTO permissions (created_at, updated_at, permission_identifier, description, resource_id)
+  SELECT NOW(), NOW(), 'admin-api-clients-write', 'Manage clients via the admin API', id
+  FROM resources WHERE resource_identifier = 'authserver';
+
+INSERT INTO permissions (created_at, updated_at, permission_identifier, description, resource_id)
+  SELECT NOW(), NOW(), 'admin-api-resources-read', 'Read resources and permissions via the admin API', id
+  FROM resources WHERE resource_identifier = 'authserver';
+
+INSERT INTO permissions (created_at, updated_at, permission_identifier, description, resource_id)
+  SELECT NOW(), NOW(), 'admin-api-resources-write', 'Manage resources and permissions via the admin API', id
+  FROM resources WHERE resource_identifier = 'authserver';
+
+INSERT INTO permissions (created_at, updated_at, permission_identifier, description, resource_id)
+  SELECT NOW(), NOW(), 'admin-api-settings-read', 'Read the settings via the admin API', id
+  FROM resources WHERE resource_identifier = 'authserver';
+
+INSERT INTO permissions (created_at, updated_at, permission_identifier, description, resource_id)
+  SELECT NOW(), NOW(), 'admin-api-settings-write', 'Update the settings via the admin API', id
+  FROM resources WHERE resource_identifier = 'authserver';
+
+-- END
@@ -0,0 +1,26 @@
+-- BEGIN
+
+CREATE TABLE audit_events (
+  id BIGSERIAL NOT NULL,
+  created_at timestamptz(6) DEFAULT NULL,
+  event_type varchar(64) NOT NULL,
+  actor_subject varchar(256) NOT NULL,
+  ip_address varchar(64) NOT NULL,
+  request_id varchar(128) NOT NULL,
+  user_id bigint DEFAULT NULL,
+  client_id bigint DEFAULT NULL,
+  details text NOT NULL,
+  PRIMARY KEY (id)
+);
+
+CREATE INDEX idx_audit_events_created_at ON audit_events (created_at);
+
+CREATE INDEX idx_audit_events_event_type ON audit_events (event_type);
+
+CREATE INDEX idx_audit_events_actor_subject ON audit_events (actor_subject);
+
+CREATE INDEX idx_audit_events_user_id ON audit_events (user_id);
+
+CREATE INDEX idx_audit_events_client_id ON audit_events (client_id);
+
+-- END
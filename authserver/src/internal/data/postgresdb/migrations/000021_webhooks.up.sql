@@ -0,0 +1,37 @@
+-- BEGIN
+
+CREATE TABLE webhooks (
+  id BIGSERIAL NOT NULL,
+  created_at timestamptz(6) DEFAULT NULL,
+  updated_at timestamptz(6) DEFAULT NULL,
+  url varchar(512) NOT NULL,
+  description varchar(128) NOT NULL,
+  secret_encrypted bytea NOT NULL,
+  event_types varchar(512) NOT NULL,
+  enabled boolean NOT NULL,
+  PRIMARY KEY (id)
+);
+
+CREATE TABLE webhook_deliveries (
+  id BIGSERIAL NOT NULL,
+  created_at timestamptz(6) DEFAULT NULL,
+  updated_at timestamptz(6) DEFAULT NULL,
+  webhook_id bigint NOT NULL,
+  event_id varchar(64) NOT NULL,
+  event_type varchar(64) NOT NULL,
+  payload text NOT NULL,
+  status varchar(16) NOT NULL,
+  attempts integer NOT NULL,
+  next_attempt_at timestamptz(6) DEFAULT NULL,
+  last_attempt_at timestamptz(6) DEFAULT NULL,
+  last_response_code integer NOT NULL,
+  last_error varchar(512) NOT NULL,
+  PRIMARY KEY (id),
+  CONSTRAINT fk_webhook_deliveries_webhook FOREIGN KEY (webhook_id) REFERENCES webhooks (id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_webhook_deliveries_webhook_id ON webhook_deliveries (webhook_id);
+CREATE INDEX idx_webhook_deliveries_status_next_attempt_at ON webhook_deliveries (status, next_attempt_at);
+CREATE INDEX idx_webhook_deliveries_created_at ON webhook_deliveries (created_at);
+
+-- END
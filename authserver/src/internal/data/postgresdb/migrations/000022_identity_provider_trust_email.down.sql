@@ -0,0 +1,6 @@
+-- BEGIN
+
+ALTER TABLE identity_providers
+  DROP COLUMN trust_email;
+
+-- END
@@ -0,0 +1,6 @@
+-- BEGIN
+
+ALTER TABLE identity_providers
+  ADD COLUMN trust_email boolean NOT NULL DEFAULT false;
+
+-- END
@@ -0,0 +1,35 @@
+package postgresdb
+
+import (
+	"database/sql"
+
+	"github.com/leodip/goiabada/internal/entities"
+)
+
+func (d *PostgresDatabase) CreatePermission(tx *sql.Tx, permission *entities.Permission) error {
+	return d.CommonDB.CreatePermission(tx, permission)
+}
+
+func (d *PostgresDatabase) UpdatePermission(tx *sql.Tx, permission *entities.Permission) error {
+	return d.CommonDB.UpdatePermission(tx, permission)
+}
+
+func (d *PostgresDatabase) GetPermissionById(tx *sql.Tx, permissionId int64) (*entities.Permission, error) {
+	return d.CommonDB.GetPermissionById(tx, permissionId)
+}
+
+func (d *PostgresDatabase) GetPermissionsByResourceId(tx *sql.Tx, resourceId int64) ([]entities.Permission, error) {
+	return d.CommonDB.GetPermissionsByResourceId(tx, resourceId)
+}
+
+func (d *PostgresDatabase) PermissionsLoadResources(tx *sql.Tx, permissions []entities.Permission) error {
+	return d.CommonDB.PermissionsLoadResources(tx, permissions)
+}
+
+func (d *PostgresDatabase) GetPermissionsByIds(tx *sql.Tx, permissionIds []int64) ([]entities.Permission, error) {
+	return d.CommonDB.GetPermissionsByIds(tx, permissionIds)
+}
+
+func (d *PostgresDatabase) DeletePermission(tx *sql.Tx, permissionId int64) error {
+	return d.CommonDB.DeletePermission(tx, permissionId)
+}
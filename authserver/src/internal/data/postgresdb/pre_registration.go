@@ -0,0 +1,27 @@
+package postgresdb
+
+import (
+	"database/sql"
+
+	"github.com/leodip/goiabada/internal/entities"
+)
+
+func (d *PostgresDatabase) CreatePreRegistration(tx *sql.Tx, preRegistration *entities.PreRegistration) error {
+	return d.CommonDB.CreatePreRegistration(tx, preRegistration)
+}
+
+func (d *PostgresDatabase) UpdatePreRegistration(tx *sql.Tx, preRegistration *entities.PreRegistration) error {
+	return d.CommonDB.UpdatePreRegistration(tx, preRegistration)
+}
+
+func (d *PostgresDatabase) GetPreRegistrationById(tx *sql.Tx, preRegistrationId int64) (*entities.PreRegistration, error) {
+	return d.CommonDB.GetPreRegistrationById(tx, preRegistrationId)
+}
+
+func (d *PostgresDatabase) DeletePreRegistration(tx *sql.Tx, preRegistrationId int64) error {
+	return d.CommonDB.DeletePreRegistration(tx, preRegistrationId)
+}
+
+func (d *PostgresDatabase) GetPreRegistrationByEmail(tx *sql.Tx, email string) (*entities.PreRegistration, error) {
+	return d.CommonDB.GetPreRegistrationByEmail(tx, email)
+}
@@ -0,0 +1,23 @@
+package postgresdb
+
+import (
+	"database/sql"
+
+	"github.com/leodip/goiabada/internal/entities"
+)
+
+func (d *PostgresDatabase) CreateRedirectURI(tx *sql.Tx, redirectURI *entities.RedirectURI) error {
+	return d.CommonDB.CreateRedirectURI(tx, redirectURI)
+}
+
+func (d *PostgresDatabase) GetRedirectURIById(tx *sql.Tx, redirectURIId int64) (*entities.RedirectURI, error) {
+	return d.CommonDB.GetRedirectURIById(tx, redirectURIId)
+}
+
+func (d *PostgresDatabase) GetRedirectURIsByClientId(tx *sql.Tx, clientId int64) ([]entities.RedirectURI, error) {
+	return d.CommonDB.GetRedirectURIsByClientId(tx, clientId)
+}
+
+func (d *PostgresDatabase) DeleteRedirectURI(tx *sql.Tx, redirectURIId int64) error {
+	return d.CommonDB.DeleteRedirectURI(tx, redirectURIId)
+}
@@ -0,0 +1,31 @@
+package postgresdb
+
+import (
+	"database/sql"
+
+	"github.com/leodip/goiabada/internal/entities"
+)
+
+func (d *PostgresDatabase) CreateRefreshToken(tx *sql.Tx, refreshToken *entities.RefreshToken) error {
+	return d.CommonDB.CreateRefreshToken(tx, refreshToken)
+}
+
+func (d *PostgresDatabase) UpdateRefreshToken(tx *sql.Tx, refreshToken *entities.RefreshToken) error {
+	return d.CommonDB.UpdateRefreshToken(tx, refreshToken)
+}
+
+func (d *PostgresDatabase) GetRefreshTokenById(tx *sql.Tx, refreshTokenId int64) (*entities.RefreshToken, error) {
+	return d.CommonDB.GetRefreshTokenById(tx, refreshTokenId)
+}
+
+func (d *PostgresDatabase) RefreshTokenLoadCode(tx *sql.Tx, refreshToken *entities.RefreshToken) error {
+	return d.CommonDB.RefreshTokenLoadCode(tx, refreshToken)
+}
+
+func (d *PostgresDatabase) GetRefreshTokenByJti(tx *sql.Tx, jti string) (*entities.RefreshToken, error) {
+	return d.CommonDB.GetRefreshTokenByJti(tx, jti)
+}
+
+func (d *PostgresDatabase) DeleteRefreshToken(tx *sql.Tx, refreshTokenId int64) error {
+	return d.CommonDB.DeleteRefreshToken(tx, refreshTokenId)
+}
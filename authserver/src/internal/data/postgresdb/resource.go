@@ -0,0 +1,35 @@
+package postgresdb
+
+import (
+	"database/sql"
+
+	"github.com/leodip/goiabada/internal/entities"
+)
+
+func (d *PostgresDatabase) CreateResource(tx *sql.Tx, resource *entities.Resource) error {
+	return d.CommonDB.CreateResource(tx, resource)
+}
+
+func (d *PostgresDatabase) UpdateResource(tx *sql.Tx, resource *entities.Resource) error {
+	return d.CommonDB.UpdateResource(tx, resource)
+}
+
+func (d *PostgresDatabase) GetResourceById(tx *sql.Tx, resourceId int64) (*entities.Resource, error) {
+	return d.CommonDB.GetResourceById(tx, resourceId)
+}
+
+func (d *PostgresDatabase) GetResourceByResourceIdentifier(tx *sql.Tx, resourceIdentifier string) (*entities.Resource, error) {
+	return d.CommonDB.GetResourceByResourceIdentifier(tx, resourceIdentifier)
+}
+
+func (d *PostgresDatabase) GetResourcesByIds(tx *sql.Tx, resourceIds []int64) ([]entities.Resource, error) {
+	return d.CommonDB.GetResourcesByIds(tx, resourceIds)
+}
+
+func (d *PostgresDatabase) GetAllResources(tx *sql.Tx) ([]entities.Resource, error) {
+	return d.CommonDB.GetAllResources(tx)
+}
+
+func (d *PostgresDatabase) DeleteResource(tx *sql.Tx, resourceId int64) error {
+	return d.CommonDB.DeleteResource(tx, resourceId)
+}
@@ -0,0 +1,19 @@
+package postgresdb
+
+import (
+	"database/sql"
+
+	"github.com/leodip/goiabada/internal/entities"
+)
+
+func (d *PostgresDatabase) CreateSettings(tx *sql.Tx, settings *entities.Settings) error {
+	return d.CommonDB.CreateSettings(tx, settings)
+}
+
+func (d *PostgresDatabase) UpdateSettings(tx *sql.Tx, settings *entities.Settings) error {
+	return d.CommonDB.UpdateSettings(tx, settings)
+}
+
+func (d *PostgresDatabase) GetSettingsById(tx *sql.Tx, settingsId int64) (*entities.Settings, error) {
+	return d.CommonDB.GetSettingsById(tx, settingsId)
+}
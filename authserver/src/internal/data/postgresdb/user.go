@@ -0,0 +1,75 @@
+package postgresdb
+
+import (
+	"database/sql"
+
+	"github.com/leodip/goiabada/internal/entities"
+)
+
+func (d *PostgresDatabase) CreateUser(tx *sql.Tx, user *entities.User) error {
+	return d.CommonDB.CreateUser(tx, user)
+}
+
+func (d *PostgresDatabase) UpdateUser(tx *sql.Tx, user *entities.User) error {
+	return d.CommonDB.UpdateUser(tx, user)
+}
+
+func (d *PostgresDatabase) GetUsersByIds(tx *sql.Tx, userIds []int64) (map[int64]entities.User, error) {
+	return d.CommonDB.GetUsersByIds(tx, userIds)
+}
+
+func (d *PostgresDatabase) GetUserById(tx *sql.Tx, userId int64) (*entities.User, error) {
+	return d.CommonDB.GetUserById(tx, userId)
+}
+
+func (d *PostgresDatabase) UsersLoadPermissions(tx *sql.Tx, users []entities.User) error {
+	return d.CommonDB.UsersLoadPermissions(tx, users)
+}
+
+func (d *PostgresDatabase) UserLoadAttributes(tx *sql.Tx, user *entities.User) error {
+	return d.CommonDB.UserLoadAttributes(tx, user)
+}
+
+func (d *PostgresDatabase) UserLoadPasskeys(tx *sql.Tx, user *entities.User) error {
+	return d.CommonDB.UserLoadPasskeys(tx, user)
+}
+
+func (d *PostgresDatabase) UserLoadPermissions(tx *sql.Tx, user *entities.User) error {
+	return d.CommonDB.UserLoadPermissions(tx, user)
+}
+
+func (d *PostgresDatabase) UsersLoadGroups(tx *sql.Tx, users []entities.User) error {
+	return d.CommonDB.UsersLoadGroups(tx, users)
+}
+
+func (d *PostgresDatabase) UserLoadGroups(tx *sql.Tx, user *entities.User) error {
+	return d.CommonDB.UserLoadGroups(tx, user)
+}
+
+func (d *PostgresDatabase) GetUserByUsername(tx *sql.Tx, username string) (*entities.User, error) {
+	return d.CommonDB.GetUserByUsername(tx, username)
+}
+
+func (d *PostgresDatabase) GetUserBySubject(tx *sql.Tx, subject string) (*entities.User, error) {
+	return d.CommonDB.GetUserBySubject(tx, subject)
+}
+
+func (d *PostgresDatabase) GetUserByEmail(tx *sql.Tx, email string) (*entities.User, error) {
+	return d.CommonDB.GetUserByEmail(tx, email)
+}
+
+func (d *PostgresDatabase) GetLastUserWithOTPState(tx *sql.Tx, otpEnabledState bool) (*entities.User, error) {
+	return d.CommonDB.GetLastUserWithOTPState(tx, otpEnabledState)
+}
+
+func (d *PostgresDatabase) GetLockedUsers(tx *sql.Tx) ([]entities.User, error) {
+	return d.CommonDB.GetLockedUsers(tx)
+}
+
+func (d *PostgresDatabase) SearchUsersPaginated(tx *sql.Tx, query string, page int, pageSize int) ([]entities.User, int, error) {
+	return d.CommonDB.SearchUsersPaginated(tx, query, page, pageSize)
+}
+
+func (d *PostgresDatabase) DeleteUser(tx *sql.Tx, userId int64) error {
+	return d.CommonDB.DeleteUser(tx, userId)
+}
@@ -0,0 +1,27 @@
+package postgresdb
+
+import (
+	"database/sql"
+
+	"github.com/leodip/goiabada/internal/entities"
+)
+
+func (d *PostgresDatabase) CreateUserAttribute(tx *sql.Tx, userAttribute *entities.UserAttribute) error {
+	return d.CommonDB.CreateUserAttribute(tx, userAttribute)
+}
+
+func (d *PostgresDatabase) UpdateUserAttribute(tx *sql.Tx, userAttribute *entities.UserAttribute) error {
+	return d.CommonDB.UpdateUserAttribute(tx, userAttribute)
+}
+
+func (d *PostgresDatabase) GetUserAttributeById(tx *sql.Tx, userAttributeId int64) (*entities.UserAttribute, error) {
+	return d.CommonDB.GetUserAttributeById(tx, userAttributeId)
+}
+
+func (d *PostgresDatabase) GetUserAttributesByUserId(tx *sql.Tx, userId int64) ([]entities.UserAttribute, error) {
+	return d.CommonDB.GetUserAttributesByUserId(tx, userId)
+}
+
+func (d *PostgresDatabase) DeleteUserAttribute(tx *sql.Tx, userAttributeId int64) error {
+	return d.CommonDB.DeleteUserAttribute(tx, userAttributeId)
+}
@@ -0,0 +1,39 @@
+package postgresdb
+
+import (
+	"database/sql"
+
+	"github.com/leodip/goiabada/internal/entities"
+)
+
+func (d *PostgresDatabase) CreateUserConsent(tx *sql.Tx, userConsent *entities.UserConsent) error {
+	return d.CommonDB.CreateUserConsent(tx, userConsent)
+}
+
+func (d *PostgresDatabase) UpdateUserConsent(tx *sql.Tx, userConsent *entities.UserConsent) error {
+	return d.CommonDB.UpdateUserConsent(tx, userConsent)
+}
+
+func (d *PostgresDatabase) GetUserConsentById(tx *sql.Tx, userConsentId int64) (*entities.UserConsent, error) {
+	return d.CommonDB.GetUserConsentById(tx, userConsentId)
+}
+
+func (d *PostgresDatabase) GetConsentByUserIdAndClientId(tx *sql.Tx, userId int64, clientId int64) (*entities.UserConsent, error) {
+	return d.CommonDB.GetConsentByUserIdAndClientId(tx, userId, clientId)
+}
+
+func (d *PostgresDatabase) UserConsentsLoadClients(tx *sql.Tx, userConsents []entities.UserConsent) error {
+	return d.CommonDB.UserConsentsLoadClients(tx, userConsents)
+}
+
+func (d *PostgresDatabase) GetConsentsByUserId(tx *sql.Tx, userId int64) ([]entities.UserConsent, error) {
+	return d.CommonDB.GetConsentsByUserId(tx, userId)
+}
+
+func (d *PostgresDatabase) DeleteUserConsent(tx *sql.Tx, userConsentId int64) error {
+	return d.CommonDB.DeleteUserConsent(tx, userConsentId)
+}
+
+func (d *PostgresDatabase) DeleteAllUserConsent(tx *sql.Tx) error {
+	return d.CommonDB.DeleteAllUserConsent(tx)
+}
@@ -0,0 +1,35 @@
+package postgresdb
+
+import (
+	"database/sql"
+
+	"github.com/leodip/goiabada/internal/entities"
+)
+
+func (d *PostgresDatabase) CreateUserFederatedIdentity(tx *sql.Tx, userFederatedIdentity *entities.UserFederatedIdentity) error {
+	return d.CommonDB.CreateUserFederatedIdentity(tx, userFederatedIdentity)
+}
+
+func (d *PostgresDatabase) UpdateUserFederatedIdentity(tx *sql.Tx, userFederatedIdentity *entities.UserFederatedIdentity) error {
+	return d.CommonDB.UpdateUserFederatedIdentity(tx, userFederatedIdentity)
+}
+
+func (d *PostgresDatabase) GetUserFederatedIdentityById(tx *sql.Tx, userFederatedIdentityId int64) (*entities.UserFederatedIdentity, error) {
+	return d.CommonDB.GetUserFederatedIdentityById(tx, userFederatedIdentityId)
+}
+
+func (d *PostgresDatabase) GetUserFederatedIdentityByIdentityProviderIdAndSubject(tx *sql.Tx, identityProviderId int64, subject string) (*entities.UserFederatedIdentity, error) {
+	return d.CommonDB.GetUserFederatedIdentityByIdentityProviderIdAndSubject(tx, identityProviderId, subject)
+}
+
+func (d *PostgresDatabase) GetUserFederatedIdentitiesByUserId(tx *sql.Tx, userId int64) ([]entities.UserFederatedIdentity, error) {
+	return d.CommonDB.GetUserFederatedIdentitiesByUserId(tx, userId)
+}
+
+func (d *PostgresDatabase) UserFederatedIdentitiesLoadIdentityProviders(tx *sql.Tx, userFederatedIdentities []entities.UserFederatedIdentity) error {
+	return d.CommonDB.UserFederatedIdentitiesLoadIdentityProviders(tx, userFederatedIdentities)
+}
+
+func (d *PostgresDatabase) DeleteUserFederatedIdentity(tx *sql.Tx, userFederatedIdentityId int64) error {
+	return d.CommonDB.DeleteUserFederatedIdentity(tx, userFederatedIdentityId)
+}
@@ -0,0 +1,35 @@
+package postgresdb
+
+import (
+	"database/sql"
+
+	"github.com/leodip/goiabada/internal/entities"
+)
+
+func (d *PostgresDatabase) CreateUserGroup(tx *sql.Tx, userGroup *entities.UserGroup) error {
+	return d.CommonDB.CreateUserGroup(tx, userGroup)
+}
+
+func (d *PostgresDatabase) UpdateUserGroup(tx *sql.Tx, userGroup *entities.UserGroup) error {
+	return d.CommonDB.UpdateUserGroup(tx, userGroup)
+}
+
+func (d *PostgresDatabase) GetUserGroupById(tx *sql.Tx, userGroupId int64) (*entities.UserGroup, error) {
+	return d.CommonDB.GetUserGroupById(tx, userGroupId)
+}
+
+func (d *PostgresDatabase) GetUserGroupsByUserIds(tx *sql.Tx, userIds []int64) ([]entities.UserGroup, error) {
+	return d.CommonDB.GetUserGroupsByUserIds(tx, userIds)
+}
+
+func (d *PostgresDatabase) GetUserGroupsByUserId(tx *sql.Tx, userId int64) ([]entities.UserGroup, error) {
+	return d.CommonDB.GetUserGroupsByUserId(tx, userId)
+}
+
+func (d *PostgresDatabase) GetUserGroupByUserIdAndGroupId(tx *sql.Tx, userId, groupId int64) (*entities.UserGroup, error) {
+	return d.CommonDB.GetUserGroupByUserIdAndGroupId(tx, userId, groupId)
+}
+
+func (d *PostgresDatabase) DeleteUserGroup(tx *sql.Tx, userGroupId int64) error {
+	return d.CommonDB.DeleteUserGroup(tx, userGroupId)
+}
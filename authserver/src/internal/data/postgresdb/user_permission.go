@@ -0,0 +1,39 @@
+package postgresdb
+
+import (
+	"database/sql"
+
+	"github.com/leodip/goiabada/internal/entities"
+)
+
+func (d *PostgresDatabase) CreateUserPermission(tx *sql.Tx, userPermission *entities.UserPermission) error {
+	return d.CommonDB.CreateUserPermission(tx, userPermission)
+}
+
+func (d *PostgresDatabase) UpdateUserPermission(tx *sql.Tx, userPermission *entities.UserPermission) error {
+	return d.CommonDB.UpdateUserPermission(tx, userPermission)
+}
+
+func (d *PostgresDatabase) GetUserPermissionById(tx *sql.Tx, userPermissionId int64) (*entities.UserPermission, error) {
+	return d.CommonDB.GetUserPermissionById(tx, userPermissionId)
+}
+
+func (d *PostgresDatabase) GetUserPermissionsByUserIds(tx *sql.Tx, userIds []int64) ([]entities.UserPermission, error) {
+	return d.CommonDB.GetUserPermissionsByUserIds(tx, userIds)
+}
+
+func (d *PostgresDatabase) GetUserPermissionsByUserId(tx *sql.Tx, userId int64) ([]entities.UserPermission, error) {
+	return d.CommonDB.GetUserPermissionsByUserId(tx, userId)
+}
+
+func (d *PostgresDatabase) GetUserPermissionByUserIdAndPermissionId(tx *sql.Tx, userId, permissionId int64) (*entities.UserPermission, error) {
+	return d.CommonDB.GetUserPermissionByUserIdAndPermissionId(tx, userId, permissionId)
+}
+
+func (d *PostgresDatabase) GetUsersByPermissionIdPaginated(tx *sql.Tx, permissionId int64, page int, pageSize int) ([]entities.User, int, error) {
+	return d.CommonDB.GetUsersByPermissionIdPaginated(tx, permissionId, page, pageSize)
+}
+
+func (d *PostgresDatabase) DeleteUserPermission(tx *sql.Tx, userPermissionId int64) error {
+	return d.CommonDB.DeleteUserPermission(tx, userPermissionId)
+}
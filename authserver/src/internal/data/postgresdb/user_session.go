@@ -0,0 +1,51 @@
+package postgresdb
+
+import (
+	"database/sql"
+
+	"github.com/leodip/goiabada/internal/entities"
+)
+
+func (d *PostgresDatabase) CreateUserSession(tx *sql.Tx, userSession *entities.UserSession) error {
+	return d.CommonDB.CreateUserSession(tx, userSession)
+}
+
+func (d *PostgresDatabase) UpdateUserSession(tx *sql.Tx, userSession *entities.UserSession) error {
+	return d.CommonDB.UpdateUserSession(tx, userSession)
+}
+
+func (d *PostgresDatabase) GetUserSessionById(tx *sql.Tx, userSessionId int64) (*entities.UserSession, error) {
+	return d.CommonDB.GetUserSessionById(tx, userSessionId)
+}
+
+func (d *PostgresDatabase) GetUserSessionBySessionIdentifier(tx *sql.Tx, sessionIdentifier string) (*entities.UserSession, error) {
+	return d.CommonDB.GetUserSessionBySessionIdentifier(tx, sessionIdentifier)
+}
+
+func (d *PostgresDatabase) GetUserSessionsByClientIdPaginated(tx *sql.Tx, clientId int64, page int, pageSize int) ([]entities.UserSession, int, error) {
+	return d.CommonDB.GetUserSessionsByClientIdPaginated(tx, clientId, page, pageSize)
+}
+
+func (d *PostgresDatabase) UserSessionsLoadUsers(tx *sql.Tx, userSessions []entities.UserSession) error {
+	return d.CommonDB.UserSessionsLoadUsers(tx, userSessions)
+}
+
+func (d *PostgresDatabase) UserSessionsLoadClients(tx *sql.Tx, userSessions []entities.UserSession) error {
+	return d.CommonDB.UserSessionsLoadClients(tx, userSessions)
+}
+
+func (d *PostgresDatabase) UserSessionLoadClients(tx *sql.Tx, userSession *entities.UserSession) error {
+	return d.CommonDB.UserSessionLoadClients(tx, userSession)
+}
+
+func (d *PostgresDatabase) UserSessionLoadUser(tx *sql.Tx, userSession *entities.UserSession) error {
+	return d.CommonDB.UserSessionLoadUser(tx, userSession)
+}
+
+func (d *PostgresDatabase) GetUserSessionsByUserId(tx *sql.Tx, userId int64) ([]entities.UserSession, error) {
+	return d.CommonDB.GetUserSessionsByUserId(tx, userId)
+}
+
+func (d *PostgresDatabase) DeleteUserSession(tx *sql.Tx, userSessionId int64) error {
+	return d.CommonDB.DeleteUserSession(tx, userSessionId)
+}
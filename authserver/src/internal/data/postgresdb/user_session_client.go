@@ -0,0 +1,39 @@
+package postgresdb
+
+import (
+	"database/sql"
+
+	"github.com/leodip/goiabada/internal/entities"
+)
+
+func (d *PostgresDatabase) CreateUserSessionClient(tx *sql.Tx, userSessionClient *entities.UserSessionClient) error {
+	return d.CommonDB.CreateUserSessionClient(tx, userSessionClient)
+}
+
+func (d *PostgresDatabase) UpdateUserSessionClient(tx *sql.Tx, userSessionClient *entities.UserSessionClient) error {
+	return d.CommonDB.UpdateUserSessionClient(tx, userSessionClient)
+}
+
+func (d *PostgresDatabase) UserSessionClientsLoadClients(tx *sql.Tx, userSessionClients []entities.UserSessionClient) error {
+	return d.CommonDB.UserSessionClientsLoadClients(tx, userSessionClients)
+}
+
+func (d *PostgresDatabase) GetUserSessionClientsByUserSessionIds(tx *sql.Tx, userSessionIds []int64) ([]entities.UserSessionClient, error) {
+	return d.CommonDB.GetUserSessionClientsByUserSessionIds(tx, userSessionIds)
+}
+
+func (d *PostgresDatabase) GetUserSessionClientsByUserSessionId(tx *sql.Tx, userSessionId int64) ([]entities.UserSessionClient, error) {
+	return d.CommonDB.GetUserSessionClientsByUserSessionId(tx, userSessionId)
+}
+
+func (d *PostgresDatabase) GetUserSessionsClientByIds(tx *sql.Tx, userSessionClientIds []int64) ([]entities.UserSessionClient, error) {
+	return d.CommonDB.GetUserSessionsClientByIds(tx, userSessionClientIds)
+}
+
+func (d *PostgresDatabase) GetUserSessionClientById(tx *sql.Tx, userSessionClientId int64) (*entities.UserSessionClient, error) {
+	return d.CommonDB.GetUserSessionClientById(tx, userSessionClientId)
+}
+
+func (d *PostgresDatabase) DeleteUserSessionClient(tx *sql.Tx, userSessionClientId int64) error {
+	return d.CommonDB.DeleteUserSessionClient(tx, userSessionClientId)
+}
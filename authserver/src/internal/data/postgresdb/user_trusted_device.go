@@ -0,0 +1,31 @@
+package postgresdb
+
+import (
+	"database/sql"
+
+	"github.com/leodip/goiabada/internal/entities"
+)
+
+func (d *PostgresDatabase) CreateUserTrustedDevice(tx *sql.Tx, userTrustedDevice *entities.UserTrustedDevice) error {
+	return d.CommonDB.CreateUserTrustedDevice(tx, userTrustedDevice)
+}
+
+func (d *PostgresDatabase) UpdateUserTrustedDevice(tx *sql.Tx, userTrustedDevice *entities.UserTrustedDevice) error {
+	return d.CommonDB.UpdateUserTrustedDevice(tx, userTrustedDevice)
+}
+
+func (d *PostgresDatabase) GetUserTrustedDeviceById(tx *sql.Tx, userTrustedDeviceId int64) (*entities.UserTrustedDevice, error) {
+	return d.CommonDB.GetUserTrustedDeviceById(tx, userTrustedDeviceId)
+}
+
+func (d *PostgresDatabase) GetUserTrustedDeviceByIdentifierHash(tx *sql.Tx, identifierHash string) (*entities.UserTrustedDevice, error) {
+	return d.CommonDB.GetUserTrustedDeviceByIdentifierHash(tx, identifierHash)
+}
+
+func (d *PostgresDatabase) GetUserTrustedDevicesByUserId(tx *sql.Tx, userId int64) ([]entities.UserTrustedDevice, error) {
+	return d.CommonDB.GetUserTrustedDevicesByUserId(tx, userId)
+}
+
+func (d *PostgresDatabase) DeleteUserTrustedDevice(tx *sql.Tx, userTrustedDeviceId int64) error {
+	return d.CommonDB.DeleteUserTrustedDevice(tx, userTrustedDeviceId)
+}
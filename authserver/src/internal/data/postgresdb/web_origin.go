@@ -0,0 +1,27 @@
+package postgresdb
+
+import (
+	"database/sql"
+
+	"github.com/leodip/goiabada/internal/entities"
+)
+
+func (d *PostgresDatabase) CreateWebOrigin(tx *sql.Tx, webOrigin *entities.WebOrigin) error {
+	return d.CommonDB.CreateWebOrigin(tx, webOrigin)
+}
+
+func (d *PostgresDatabase) GetWebOriginById(tx *sql.Tx, webOriginId int64) (*entities.WebOrigin, error) {
+	return d.CommonDB.GetWebOriginById(tx, webOriginId)
+}
+
+func (d *PostgresDatabase) GetWebOriginsByClientId(tx *sql.Tx, clientId int64) ([]entities.WebOrigin, error) {
+	return d.CommonDB.GetWebOriginsByClientId(tx, clientId)
+}
+
+func (d *PostgresDatabase) GetAllWebOrigins(tx *sql.Tx) ([]*entities.WebOrigin, error) {
+	return d.CommonDB.GetAllWebOrigins(tx)
+}
+
+func (d *PostgresDatabase) DeleteWebOrigin(tx *sql.Tx, webOriginId int64) error {
+	return d.CommonDB.DeleteWebOrigin(tx, webOriginId)
+}
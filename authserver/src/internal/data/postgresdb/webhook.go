@@ -0,0 +1,31 @@
+package postgresdb
+
+import (
+	"database/sql"
+
+	"github.com/leodip/goiabada/internal/entities"
+)
+
+func (d *PostgresDatabase) CreateWebhook(tx *sql.Tx, webhook *entities.Webhook) error {
+	return d.CommonDB.CreateWebhook(tx, webhook)
+}
+
+func (d *PostgresDatabase) UpdateWebhook(tx *sql.Tx, webhook *entities.Webhook) error {
+	return d.CommonDB.UpdateWebhook(tx, webhook)
+}
+
+func (d *PostgresDatabase) GetWebhookById(tx *sql.Tx, webhookId int64) (*entities.Webhook, error) {
+	return d.CommonDB.GetWebhookById(tx, webhookId)
+}
+
+func (d *PostgresDatabase) GetAllWebhooks(tx *sql.Tx) ([]entities.Webhook, error) {
+	return d.CommonDB.GetAllWebhooks(tx)
+}
+
+func (d *PostgresDatabase) GetEnabledWebhooks(tx *sql.Tx) ([]entities.Webhook, error) {
+	return d.CommonDB.GetEnabledWebhooks(tx)
+}
+
+func (d *PostgresDatabase) DeleteWebhook(tx *sql.Tx, webhookId int64) error {
+	return d.CommonDB.DeleteWebhook(tx, webhookId)
+}
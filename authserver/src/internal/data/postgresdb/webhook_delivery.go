@@ -0,0 +1,32 @@
+package postgresdb
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/leodip/goiabada/internal/entities"
+)
+
+func (d *PostgresDatabase) CreateWebhookDelivery(tx *sql.Tx, webhookDelivery *entities.WebhookDelivery) error {
+	return d.CommonDB.CreateWebhookDelivery(tx, webhookDelivery)
+}
+
+func (d *PostgresDatabase) UpdateWebhookDelivery(tx *sql.Tx, webhookDelivery *entities.WebhookDelivery) error {
+	return d.CommonDB.UpdateWebhookDelivery(tx, webhookDelivery)
+}
+
+func (d *PostgresDatabase) GetWebhookDeliveryById(tx *sql.Tx, webhookDeliveryId int64) (*entities.WebhookDelivery, error) {
+	return d.CommonDB.GetWebhookDeliveryById(tx, webhookDeliveryId)
+}
+
+func (d *PostgresDatabase) GetDueWebhookDeliveries(tx *sql.Tx, now time.Time, limit int) ([]entities.WebhookDelivery, error) {
+	return d.CommonDB.GetDueWebhookDeliveries(tx, now, limit)
+}
+
+func (d *PostgresDatabase) GetWebhookDeliveriesPaginated(tx *sql.Tx, webhookId int64, page int, pageSize int) ([]entities.WebhookDelivery, int, error) {
+	return d.CommonDB.GetWebhookDeliveriesPaginated(tx, webhookId, page, pageSize)
+}
+
+func (d *PostgresDatabase) DeleteWebhookDeliveriesCreatedBefore(tx *sql.Tx, before time.Time) (int64, error) {
+	return d.CommonDB.DeleteWebhookDeliveriesCreatedBefore(tx, before)
+}
@@ -117,6 +117,36 @@ func seed(database Database) error {
 		return err
 	}
 
+	permission4 := &entities.Permission{
+		PermissionIdentifier: constants.ScimPermissionIdentifier,
+		Description:          "Provision users and groups via the SCIM 2.0 API",
+		ResourceId:           resource.Id,
+	}
+	err = database.CreatePermission(nil, permission4)
+	if err != nil {
+		return err
+	}
+
+	adminApiPermissions := []*entities.Permission{
+		{PermissionIdentifier: constants.AdminApiUsersReadPermissionIdentifier, Description: "Read users via the admin API"},
+		{PermissionIdentifier: constants.AdminApiUsersWritePermissionIdentifier, Description: "Manage users via the admin API"},
+		{PermissionIdentifier: constants.AdminApiGroupsReadPermissionIdentifier, Description: "Read groups via the admin API"},
+		{PermissionIdentifier: constants.AdminApiGroupsWritePermissionIdentifier, Description: "Manage groups via the admin API"},
+		{PermissionIdentifier: constants.AdminApiClientsReadPermissionIdentifier, Description: "Read clients via the admin API"},
+		{PermissionIdentifier: constants.AdminApiClientsWritePermissionIdentifier, Description: "Manage clients via the admin API"},
+		{PermissionIdentifier: constants.AdminApiResourcesReadPermissionIdentifier, Description: "Read resources and permissions via the admin API"},
+		{PermissionIdentifier: constants.AdminApiResourcesWritePermissionIdentifier, Description: "Manage resources and permissions via the admin API"},
+		{PermissionIdentifier: constants.AdminApiSettingsReadPermissionIdentifier, Description: "Read the settings via the admin API"},
+		{PermissionIdentifier: constants.AdminApiSettingsWritePermissionIdentifier, Description: "Update the settings via the admin API"},
+	}
+	for _, permission := range adminApiPermissions {
+		permission.ResourceId = resource.Id
+		err = database.CreatePermission(nil, permission)
+		if err != nil {
+			return err
+		}
+	}
+
 	err = database.CreateUserPermission(nil, &entities.UserPermission{
 		UserId:       user.Id,
 		PermissionId: permission2.Id,
@@ -237,16 +267,29 @@ func seed(database Database) error {
 		UITheme:                 "",
 		SelfRegistrationEnabled: true,
 		SelfRegistrationRequiresEmailVerification: false,
-		PasswordPolicy:                          enums.PasswordPolicyLow,
-		SessionAuthenticationKey:                securecookie.GenerateRandomKey(64),
-		SessionEncryptionKey:                    securecookie.GenerateRandomKey(32),
-		AESEncryptionKey:                        encryptionKey,
-		TokenExpirationInSeconds:                300,      // 5 minutes
-		RefreshTokenOfflineIdleTimeoutInSeconds: 2592000,  // 30 days
-		RefreshTokenOfflineMaxLifetimeInSeconds: 31536000, // 1 year
-		UserSessionIdleTimeoutInSeconds:         7200,     // 2 hours
-		UserSessionMaxLifetimeInSeconds:         86400,    // 24 hours
-		IncludeOpenIDConnectClaimsInAccessToken: false,
+		SessionAuthenticationKey:                  securecookie.GenerateRandomKey(64),
+		SessionEncryptionKey:                      securecookie.GenerateRandomKey(32),
+		AESEncryptionKey:                          encryptionKey,
+		TokenExpirationInSeconds:                  300,      // 5 minutes
+		RefreshTokenOfflineIdleTimeoutInSeconds:   2592000,  // 30 days
+		RefreshTokenOfflineMaxLifetimeInSeconds:   31536000, // 1 year
+		UserSessionIdleTimeoutInSeconds:           7200,     // 2 hours
+		UserSessionMaxLifetimeInSeconds:           86400,    // 24 hours
+		TrustedDeviceLifetimeInDays:               30,
+		LoginDelayAfterFailedAttempts:             3,
+		LoginLockoutAfterFailedAttempts:           10,
+		LoginIpLockoutAfterFailedAttempts:         50,
+		LoginLockoutDurationInSeconds:             900, // 15 minutes
+		RiskBasedAuthEnabled:                      false,
+		RiskScoreForOTP:                           40,
+		RiskScoreForBlock:                         90,
+		RiskScoreForNotification:                  30,
+		RejectBreachedPasswords:                   true,
+		ForceChangeOfBreachedPasswords:            false,
+		PasswordMinLength:                         6,
+		PasswordMaxLength:                         64,
+		PasswordDisallowUsernameOrEmail:           true,
+		IncludeOpenIDConnectClaimsInAccessToken:   false,
 	}
 	err = database.CreateSettings(nil, settings)
 	if err != nil {
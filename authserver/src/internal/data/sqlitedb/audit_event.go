@@ -0,0 +1,24 @@
+package sqlitedb
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/leodip/goiabada/internal/entities"
+)
+
+func (d *SQLiteDatabase) CreateAuditEvent(tx *sql.Tx, auditEvent *entities.AuditEvent) error {
+	return d.CommonDB.CreateAuditEvent(tx, auditEvent)
+}
+
+func (d *SQLiteDatabase) SearchAuditEventsPaginated(tx *sql.Tx, filter *entities.AuditEventFilter, page int, pageSize int) ([]entities.AuditEvent, int, error) {
+	return d.CommonDB.SearchAuditEventsPaginated(tx, filter, page, pageSize)
+}
+
+func (d *SQLiteDatabase) GetAuditEventTypes(tx *sql.Tx) ([]string, error) {
+	return d.CommonDB.GetAuditEventTypes(tx)
+}
+
+func (d *SQLiteDatabase) DeleteAuditEventsCreatedBefore(tx *sql.Tx, before time.Time) (int64, error) {
+	return d.CommonDB.DeleteAuditEventsCreatedBefore(tx, before)
+}
@@ -0,0 +1,21 @@
+package sqlitedb
+
+import (
+	"database/sql"
+)
+
+func (d *SQLiteDatabase) CreateBreachedPasswordHashes(tx *sql.Tx, sha1Hashes []string) (int64, error) {
+	return d.CommonDB.CreateBreachedPasswordHashes(tx, sha1Hashes)
+}
+
+func (d *SQLiteDatabase) BreachedPasswordHashExists(tx *sql.Tx, sha1Hash string) (bool, error) {
+	return d.CommonDB.BreachedPasswordHashExists(tx, sha1Hash)
+}
+
+func (d *SQLiteDatabase) CountBreachedPasswordHashes(tx *sql.Tx) (int, error) {
+	return d.CommonDB.CountBreachedPasswordHashes(tx)
+}
+
+func (d *SQLiteDatabase) DeleteAllBreachedPasswordHashes(tx *sql.Tx) error {
+	return d.CommonDB.DeleteAllBreachedPasswordHashes(tx)
+}
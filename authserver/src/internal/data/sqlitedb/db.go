@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"embed"
 	"fmt"
+	"io/fs"
 	"log/slog"
 
 	gomigrate "github.com/golang-migrate/migrate/v4"
@@ -70,20 +71,29 @@ func (d *SQLiteDatabase) RollbackTransaction(tx *sql.Tx) error {
 	return d.CommonDB.RollbackTransaction(tx)
 }
 
-func (d *SQLiteDatabase) Migrate() error {
+func (d *SQLiteDatabase) newMigrate() (*gomigrate.Migrate, error) {
 	driver, err := sqlite.WithInstance(d.DB, &sqlite.Config{})
 	if err != nil {
-		return errors.Wrap(err, "unable to create migration driver")
+		return nil, errors.Wrap(err, "unable to create migration driver")
 	}
 
-	iofs, err := iofs.New(sqliteMigrationsFs, "migrations")
+	sourceDriver, err := iofs.New(sqliteMigrationsFs, "migrations")
 	if err != nil {
-		return errors.Wrap(err, "unable to create migration filesystem")
+		return nil, errors.Wrap(err, "unable to create migration filesystem")
 	}
 
-	migrate, err := gomigrate.NewWithInstance("iofs", iofs, "sqlite", driver)
+	migrate, err := gomigrate.NewWithInstance("iofs", sourceDriver, "sqlite", driver)
 	if err != nil {
-		return errors.Wrap(err, "unable to create migration instance")
+		return nil, errors.Wrap(err, "unable to create migration instance")
+	}
+
+	return migrate, nil
+}
+
+func (d *SQLiteDatabase) Migrate() error {
+	migrate, err := d.newMigrate()
+	if err != nil {
+		return err
 	}
 
 	err = migrate.Up()
@@ -95,3 +105,58 @@ func (d *SQLiteDatabase) Migrate() error {
 
 	return nil
 }
+
+// MigrateDown reverts the last migrations applied to the database.
+func (d *SQLiteDatabase) MigrateDown(steps int) error {
+	migrate, err := d.newMigrate()
+	if err != nil {
+		return err
+	}
+
+	err = migrate.Steps(-steps)
+	if err != nil {
+		return errors.Wrap(err, "unable to revert the migrations")
+	}
+	return nil
+}
+
+// GetMigrationVersion returns the version of the last migration applied to the database, which is
+// zero when no migration was applied. The database is dirty when a migration failed halfway.
+func (d *SQLiteDatabase) GetMigrationVersion() (uint, bool, error) {
+	migrate, err := d.newMigrate()
+	if err != nil {
+		return 0, false, err
+	}
+
+	version, dirty, err := migrate.Version()
+	if err == gomigrate.ErrNilVersion {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, errors.Wrap(err, "unable to get the migration version")
+	}
+	return version, dirty, nil
+}
+
+// GetLatestMigrationVersion returns the version of the last migration embedded in the binary.
+func (d *SQLiteDatabase) GetLatestMigrationVersion() (uint, error) {
+	sourceDriver, err := iofs.New(sqliteMigrationsFs, "migrations")
+	if err != nil {
+		return 0, errors.Wrap(err, "unable to create migration filesystem")
+	}
+
+	version, err := sourceDriver.First()
+	if err != nil {
+		return 0, errors.Wrap(err, "unable to read the migrations")
+	}
+	for {
+		next, err := sourceDriver.Next(version)
+		if errors.Is(err, fs.ErrNotExist) {
+			return version, nil
+		}
+		if err != nil {
+			return 0, errors.Wrap(err, "unable to read the migrations")
+		}
+		version = next
+	}
+}
@@ -0,0 +1,31 @@
+package sqlitedb
+
+import (
+	"database/sql"
+
+	"github.com/leodip/goiabada/internal/entities"
+)
+
+func (d *SQLiteDatabase) CreateFailedLoginIp(tx *sql.Tx, failedLoginIp *entities.FailedLoginIp) error {
+	return d.CommonDB.CreateFailedLoginIp(tx, failedLoginIp)
+}
+
+func (d *SQLiteDatabase) UpdateFailedLoginIp(tx *sql.Tx, failedLoginIp *entities.FailedLoginIp) error {
+	return d.CommonDB.UpdateFailedLoginIp(tx, failedLoginIp)
+}
+
+func (d *SQLiteDatabase) GetFailedLoginIpById(tx *sql.Tx, failedLoginIpId int64) (*entities.FailedLoginIp, error) {
+	return d.CommonDB.GetFailedLoginIpById(tx, failedLoginIpId)
+}
+
+func (d *SQLiteDatabase) GetFailedLoginIpByIpAddress(tx *sql.Tx, ipAddress string) (*entities.FailedLoginIp, error) {
+	return d.CommonDB.GetFailedLoginIpByIpAddress(tx, ipAddress)
+}
+
+func (d *SQLiteDatabase) GetLockedFailedLoginIps(tx *sql.Tx) ([]entities.FailedLoginIp, error) {
+	return d.CommonDB.GetLockedFailedLoginIps(tx)
+}
+
+func (d *SQLiteDatabase) DeleteFailedLoginIp(tx *sql.Tx, failedLoginIpId int64) error {
+	return d.CommonDB.DeleteFailedLoginIp(tx, failedLoginIpId)
+}
@@ -0,0 +1,39 @@
+package sqlitedb
+
+import (
+	"database/sql"
+
+	"github.com/leodip/goiabada/internal/entities"
+)
+
+func (d *SQLiteDatabase) CreateIdentityProvider(tx *sql.Tx, identityProvider *entities.IdentityProvider) error {
+	return d.CommonDB.CreateIdentityProvider(tx, identityProvider)
+}
+
+func (d *SQLiteDatabase) UpdateIdentityProvider(tx *sql.Tx, identityProvider *entities.IdentityProvider) error {
+	return d.CommonDB.UpdateIdentityProvider(tx, identityProvider)
+}
+
+func (d *SQLiteDatabase) GetIdentityProviderById(tx *sql.Tx, identityProviderId int64) (*entities.IdentityProvider, error) {
+	return d.CommonDB.GetIdentityProviderById(tx, identityProviderId)
+}
+
+func (d *SQLiteDatabase) GetIdentityProviderByIdentifier(tx *sql.Tx, identityProviderIdentifier string) (*entities.IdentityProvider, error) {
+	return d.CommonDB.GetIdentityProviderByIdentifier(tx, identityProviderIdentifier)
+}
+
+func (d *SQLiteDatabase) GetIdentityProvidersByIds(tx *sql.Tx, identityProviderIds []int64) ([]entities.IdentityProvider, error) {
+	return d.CommonDB.GetIdentityProvidersByIds(tx, identityProviderIds)
+}
+
+func (d *SQLiteDatabase) GetAllIdentityProviders(tx *sql.Tx) ([]entities.IdentityProvider, error) {
+	return d.CommonDB.GetAllIdentityProviders(tx)
+}
+
+func (d *SQLiteDatabase) GetEnabledIdentityProviders(tx *sql.Tx) ([]entities.IdentityProvider, error) {
+	return d.CommonDB.GetEnabledIdentityProviders(tx)
+}
+
+func (d *SQLiteDatabase) DeleteIdentityProvider(tx *sql.Tx, identityProviderId int64) error {
+	return d.CommonDB.DeleteIdentityProvider(tx, identityProviderId)
+}
@@ -0,0 +1,38 @@
+-- BEGIN
+
+CREATE TABLE identity_providers (
+  `id` integer PRIMARY KEY AUTOINCREMENT,
+  created_at DATETIME,
+  updated_at DATETIME,
+  `name` TEXT NOT NULL,
+  identity_provider_identifier TEXT NOT NULL,
+  `type` TEXT NOT NULL,
+  `enabled` numeric NOT NULL,
+  issuer TEXT NOT NULL,
+  client_identifier TEXT NOT NULL,
+  client_secret_encrypted BLOB,
+  scopes TEXT NOT NULL,
+  email_claim TEXT NOT NULL,
+  given_name_claim TEXT NOT NULL,
+  family_name_claim TEXT NOT NULL,
+  link_by_email numeric NOT NULL,
+  auto_provision numeric NOT NULL
+);
+
+
+CREATE TABLE user_federated_identities (
+  `id` integer PRIMARY KEY AUTOINCREMENT,
+  created_at DATETIME,
+  updated_at DATETIME,
+  user_id INTEGER NOT NULL,
+  identity_provider_id INTEGER NOT NULL,
+  `subject` TEXT NOT NULL,
+  last_login_at DATETIME,
+  CONSTRAINT fk_user_federated_identities_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE,
+  CONSTRAINT fk_user_federated_identities_identity_provider FOREIGN KEY (identity_provider_id) REFERENCES identity_providers (id) ON DELETE CASCADE
+);
+
+CREATE UNIQUE INDEX `idx_identity_provider_identifier` ON `identity_providers`(`identity_provider_identifier`);
+CREATE UNIQUE INDEX `idx_identity_provider_subject` ON `user_federated_identities`(`identity_provider_id`, `subject`);
+
+-- END
@@ -0,0 +1,9 @@
+-- BEGIN
+
+ALTER TABLE identity_providers DROP COLUMN saml_metadata_url;
+ALTER TABLE identity_providers DROP COLUMN saml_metadata_xml;
+ALTER TABLE identity_providers DROP COLUMN saml_name_id_format;
+ALTER TABLE identity_providers DROP COLUMN groups_claim;
+ALTER TABLE identity_providers DROP COLUMN user_attributes_mapping;
+
+-- END
@@ -0,0 +1,9 @@
+-- BEGIN
+
+ALTER TABLE identity_providers ADD COLUMN saml_metadata_url TEXT NOT NULL DEFAULT '';
+ALTER TABLE identity_providers ADD COLUMN saml_metadata_xml BLOB;
+ALTER TABLE identity_providers ADD COLUMN saml_name_id_format TEXT NOT NULL DEFAULT '';
+ALTER TABLE identity_providers ADD COLUMN groups_claim TEXT NOT NULL DEFAULT '';
+ALTER TABLE identity_providers ADD COLUMN user_attributes_mapping TEXT NOT NULL DEFAULT '';
+
+-- END
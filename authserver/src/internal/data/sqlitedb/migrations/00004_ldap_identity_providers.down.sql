@@ -0,0 +1,12 @@
+-- BEGIN
+
+ALTER TABLE identity_providers DROP COLUMN ldap_url;
+ALTER TABLE identity_providers DROP COLUMN ldap_start_tls;
+ALTER TABLE identity_providers DROP COLUMN ldap_bind_dn;
+ALTER TABLE identity_providers DROP COLUMN ldap_bind_password_encrypted;
+ALTER TABLE identity_providers DROP COLUMN ldap_base_dn;
+ALTER TABLE identity_providers DROP COLUMN ldap_user_filter;
+ALTER TABLE identity_providers DROP COLUMN ldap_subject_attribute;
+ALTER TABLE identity_providers DROP COLUMN ldap_local_password_fallback;
+
+-- END
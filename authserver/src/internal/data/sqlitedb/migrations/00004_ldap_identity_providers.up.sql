@@ -0,0 +1,12 @@
+-- BEGIN
+
+ALTER TABLE identity_providers ADD COLUMN ldap_url TEXT NOT NULL DEFAULT '';
+ALTER TABLE identity_providers ADD COLUMN ldap_start_tls numeric NOT NULL DEFAULT 0;
+ALTER TABLE identity_providers ADD COLUMN ldap_bind_dn TEXT NOT NULL DEFAULT '';
+ALTER TABLE identity_providers ADD COLUMN ldap_bind_password_encrypted BLOB;
+ALTER TABLE identity_providers ADD COLUMN ldap_base_dn TEXT NOT NULL DEFAULT '';
+ALTER TABLE identity_providers ADD COLUMN ldap_user_filter TEXT NOT NULL DEFAULT '';
+ALTER TABLE identity_providers ADD COLUMN ldap_subject_attribute TEXT NOT NULL DEFAULT '';
+ALTER TABLE identity_providers ADD COLUMN ldap_local_password_fallback numeric NOT NULL DEFAULT 0;
+
+-- END
@@ -0,0 +1,10 @@
+-- BEGIN
+
+ALTER TABLE users ADD COLUMN external_id TEXT NOT NULL DEFAULT '';
+ALTER TABLE groups ADD COLUMN external_id TEXT NOT NULL DEFAULT '';
+
+INSERT INTO permissions (created_at, updated_at, permission_identifier, `description`, resource_id)
+  SELECT datetime('now'), datetime('now'), 'scim', 'Provision users and groups via the SCIM 2.0 API', id
+  FROM resources WHERE resource_identifier = 'authserver';
+
+-- END
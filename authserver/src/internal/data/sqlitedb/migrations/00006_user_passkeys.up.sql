@@ -0,0 +1,23 @@
+-- BEGIN
+
+CREATE TABLE user_passkeys (
+  `id` integer PRIMARY KEY AUTOINCREMENT,
+  created_at DATETIME,
+  updated_at DATETIME,
+  user_id INTEGER NOT NULL,
+  `name` TEXT NOT NULL,
+  credential_id BLOB NOT NULL,
+  public_key BLOB NOT NULL,
+  attestation_type TEXT NOT NULL,
+  transports TEXT NOT NULL,
+  aaguid BLOB,
+  sign_count INTEGER NOT NULL,
+  backup_eligible numeric NOT NULL,
+  backup_state numeric NOT NULL,
+  last_used_at DATETIME,
+  CONSTRAINT fk_user_passkeys_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+CREATE INDEX `idx_user_passkeys_user_id` ON `user_passkeys`(`user_id`);
+
+-- END
@@ -0,0 +1,10 @@
+-- BEGIN
+
+ALTER TABLE users DROP COLUMN email_login_code_attempts;
+ALTER TABLE users DROP COLUMN email_login_code_issued_at;
+ALTER TABLE users DROP COLUMN email_login_token_hash;
+ALTER TABLE users DROP COLUMN email_login_code_hash;
+
+ALTER TABLE clients DROP COLUMN email_login_enabled;
+
+-- END
@@ -0,0 +1,10 @@
+-- BEGIN
+
+ALTER TABLE clients ADD COLUMN email_login_enabled numeric NOT NULL DEFAULT 0;
+
+ALTER TABLE users ADD COLUMN email_login_code_hash TEXT NOT NULL DEFAULT '';
+ALTER TABLE users ADD COLUMN email_login_token_hash TEXT NOT NULL DEFAULT '';
+ALTER TABLE users ADD COLUMN email_login_code_issued_at DATETIME;
+ALTER TABLE users ADD COLUMN email_login_code_attempts INTEGER NOT NULL DEFAULT 0;
+
+-- END
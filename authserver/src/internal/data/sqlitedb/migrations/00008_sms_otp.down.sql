@@ -0,0 +1,9 @@
+-- BEGIN
+
+ALTER TABLE users DROP COLUMN sms_otp_code_attempts;
+ALTER TABLE users DROP COLUMN sms_otp_code_issued_at;
+ALTER TABLE users DROP COLUMN sms_otp_code_hash;
+
+ALTER TABLE settings DROP COLUMN sms_otp_allowed_for_mandatory_2fa;
+
+-- END
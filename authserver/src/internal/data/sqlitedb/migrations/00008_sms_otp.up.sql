@@ -0,0 +1,9 @@
+-- BEGIN
+
+ALTER TABLE settings ADD COLUMN sms_otp_allowed_for_mandatory_2fa numeric NOT NULL DEFAULT 0;
+
+ALTER TABLE users ADD COLUMN sms_otp_code_hash TEXT NOT NULL DEFAULT '';
+ALTER TABLE users ADD COLUMN sms_otp_code_issued_at DATETIME;
+ALTER TABLE users ADD COLUMN sms_otp_code_attempts INTEGER NOT NULL DEFAULT 0;
+
+-- END
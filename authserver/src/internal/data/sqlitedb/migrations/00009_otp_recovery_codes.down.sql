@@ -0,0 +1,5 @@
+-- BEGIN
+
+ALTER TABLE users DROP COLUMN otp_recovery_codes_hashes;
+
+-- END
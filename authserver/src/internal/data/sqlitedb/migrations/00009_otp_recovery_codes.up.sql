@@ -0,0 +1,5 @@
+-- BEGIN
+
+ALTER TABLE users ADD COLUMN otp_recovery_codes_hashes TEXT NOT NULL DEFAULT '';
+
+-- END
@@ -0,0 +1,23 @@
+-- BEGIN
+
+ALTER TABLE settings ADD COLUMN trusted_device_lifetime_in_days INTEGER NOT NULL DEFAULT 30;
+
+CREATE TABLE user_trusted_devices (
+  `id` integer PRIMARY KEY AUTOINCREMENT,
+  created_at DATETIME,
+  updated_at DATETIME,
+  user_id INTEGER NOT NULL,
+  identifier_hash TEXT NOT NULL,
+  ip_address TEXT NOT NULL,
+  device_name TEXT NOT NULL,
+  device_type TEXT NOT NULL,
+  device_os TEXT NOT NULL,
+  expires_at DATETIME NOT NULL,
+  last_used_at DATETIME,
+  CONSTRAINT fk_user_trusted_devices_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+CREATE UNIQUE INDEX `idx_user_trusted_devices_identifier_hash` ON `user_trusted_devices`(`identifier_hash`);
+CREATE INDEX `idx_user_trusted_devices_user_id` ON `user_trusted_devices`(`user_id`);
+
+-- END
@@ -0,0 +1,24 @@
+-- BEGIN
+
+ALTER TABLE settings ADD COLUMN login_delay_after_failed_attempts INTEGER NOT NULL DEFAULT 3;
+ALTER TABLE settings ADD COLUMN login_lockout_after_failed_attempts INTEGER NOT NULL DEFAULT 10;
+ALTER TABLE settings ADD COLUMN login_ip_lockout_after_failed_attempts INTEGER NOT NULL DEFAULT 50;
+ALTER TABLE settings ADD COLUMN login_lockout_duration_in_seconds INTEGER NOT NULL DEFAULT 900;
+
+ALTER TABLE users ADD COLUMN failed_login_attempts INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE users ADD COLUMN last_failed_login_at DATETIME;
+ALTER TABLE users ADD COLUMN locked_until DATETIME;
+
+CREATE TABLE failed_login_ips (
+  `id` integer PRIMARY KEY AUTOINCREMENT,
+  created_at DATETIME,
+  updated_at DATETIME,
+  ip_address TEXT NOT NULL,
+  failed_attempts INTEGER NOT NULL,
+  last_failed_at DATETIME NOT NULL,
+  locked_until DATETIME
+);
+
+CREATE UNIQUE INDEX `idx_failed_login_ips_ip_address` ON `failed_login_ips`(`ip_address`);
+
+-- END
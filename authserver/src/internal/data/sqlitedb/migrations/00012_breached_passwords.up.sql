@@ -0,0 +1,15 @@
+-- BEGIN
+
+ALTER TABLE settings ADD COLUMN reject_breached_passwords numeric NOT NULL DEFAULT 1;
+ALTER TABLE settings ADD COLUMN force_change_of_breached_passwords numeric NOT NULL DEFAULT 0;
+
+ALTER TABLE users ADD COLUMN password_breached numeric NOT NULL DEFAULT 0;
+
+CREATE TABLE breached_password_hashes (
+  `id` integer PRIMARY KEY AUTOINCREMENT,
+  sha1_hash TEXT NOT NULL
+);
+
+CREATE UNIQUE INDEX `idx_breached_password_hashes_sha1_hash` ON `breached_password_hashes`(`sha1_hash`);
+
+-- END
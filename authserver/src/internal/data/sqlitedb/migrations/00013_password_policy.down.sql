@@ -0,0 +1,26 @@
+-- BEGIN
+
+DROP TABLE IF EXISTS `user_password_history`;
+
+ALTER TABLE users DROP COLUMN password_changed_at;
+
+ALTER TABLE settings ADD COLUMN password_policy INTEGER;
+
+UPDATE settings SET password_policy = CASE
+  WHEN password_requires_special_char = 1 THEN 3
+  WHEN password_requires_uppercase = 1 OR password_requires_lowercase = 1 OR password_requires_number = 1 THEN 2
+  WHEN password_min_length > 1 THEN 1
+  ELSE 0 END;
+
+ALTER TABLE settings DROP COLUMN password_min_length;
+ALTER TABLE settings DROP COLUMN password_max_length;
+ALTER TABLE settings DROP COLUMN password_requires_uppercase;
+ALTER TABLE settings DROP COLUMN password_requires_lowercase;
+ALTER TABLE settings DROP COLUMN password_requires_number;
+ALTER TABLE settings DROP COLUMN password_requires_special_char;
+ALTER TABLE settings DROP COLUMN password_disallow_username_or_email;
+ALTER TABLE settings DROP COLUMN password_history_count;
+ALTER TABLE settings DROP COLUMN password_max_age_in_days;
+ALTER TABLE settings DROP COLUMN password_min_age_in_days;
+
+-- END
@@ -0,0 +1,37 @@
+-- BEGIN
+
+ALTER TABLE settings ADD COLUMN password_min_length INTEGER NOT NULL DEFAULT 6;
+ALTER TABLE settings ADD COLUMN password_max_length INTEGER NOT NULL DEFAULT 64;
+ALTER TABLE settings ADD COLUMN password_requires_uppercase numeric NOT NULL DEFAULT 0;
+ALTER TABLE settings ADD COLUMN password_requires_lowercase numeric NOT NULL DEFAULT 0;
+ALTER TABLE settings ADD COLUMN password_requires_number numeric NOT NULL DEFAULT 0;
+ALTER TABLE settings ADD COLUMN password_requires_special_char numeric NOT NULL DEFAULT 0;
+ALTER TABLE settings ADD COLUMN password_disallow_username_or_email numeric NOT NULL DEFAULT 1;
+ALTER TABLE settings ADD COLUMN password_history_count INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE settings ADD COLUMN password_max_age_in_days INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE settings ADD COLUMN password_min_age_in_days INTEGER NOT NULL DEFAULT 0;
+
+UPDATE settings SET
+  password_min_length = CASE password_policy WHEN 0 THEN 1 WHEN 2 THEN 8 WHEN 3 THEN 10 ELSE 6 END,
+  password_requires_uppercase = CASE WHEN password_policy >= 2 THEN 1 ELSE 0 END,
+  password_requires_lowercase = CASE WHEN password_policy >= 2 THEN 1 ELSE 0 END,
+  password_requires_number = CASE WHEN password_policy >= 2 THEN 1 ELSE 0 END,
+  password_requires_special_char = CASE WHEN password_policy >= 3 THEN 1 ELSE 0 END;
+
+ALTER TABLE settings DROP COLUMN password_policy;
+
+ALTER TABLE users ADD COLUMN password_changed_at DATETIME;
+
+UPDATE users SET password_changed_at = COALESCE(updated_at, created_at) WHERE password_hash <> '';
+
+CREATE TABLE user_password_history (
+  `id` integer PRIMARY KEY AUTOINCREMENT,
+  created_at DATETIME,
+  user_id INTEGER NOT NULL,
+  password_hash TEXT NOT NULL,
+  CONSTRAINT fk_user_password_history_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+CREATE INDEX `idx_user_password_history_user_id` ON `user_password_history`(`user_id`);
+
+-- END
@@ -0,0 +1,5 @@
+-- BEGIN
+
+-- the password_hash columns are TEXT in SQLite, so the longer Argon2id and imported hashes fit as they are
+
+-- END
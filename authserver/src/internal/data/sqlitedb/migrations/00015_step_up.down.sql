@@ -0,0 +1,9 @@
+-- BEGIN
+
+ALTER TABLE permissions DROP COLUMN max_auth_age_in_seconds;
+ALTER TABLE permissions DROP COLUMN min_acr_level;
+
+ALTER TABLE resources DROP COLUMN max_auth_age_in_seconds;
+ALTER TABLE resources DROP COLUMN min_acr_level;
+
+-- END
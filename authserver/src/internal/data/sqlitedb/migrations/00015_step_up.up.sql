@@ -0,0 +1,9 @@
+-- BEGIN
+
+ALTER TABLE resources ADD COLUMN min_acr_level TEXT NOT NULL DEFAULT '';
+ALTER TABLE resources ADD COLUMN max_auth_age_in_seconds INTEGER NOT NULL DEFAULT 0;
+
+ALTER TABLE permissions ADD COLUMN min_acr_level TEXT NOT NULL DEFAULT '';
+ALTER TABLE permissions ADD COLUMN max_auth_age_in_seconds INTEGER NOT NULL DEFAULT 0;
+
+-- END
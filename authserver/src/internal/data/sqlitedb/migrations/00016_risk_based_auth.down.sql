@@ -0,0 +1,10 @@
+-- BEGIN
+
+ALTER TABLE settings DROP COLUMN risk_based_auth_enabled;
+ALTER TABLE settings DROP COLUMN risk_score_for_otp;
+ALTER TABLE settings DROP COLUMN risk_score_for_block;
+ALTER TABLE settings DROP COLUMN risk_score_for_notification;
+ALTER TABLE settings DROP COLUMN risk_trusted_ip_ranges;
+ALTER TABLE settings DROP COLUMN risk_suspicious_ip_ranges;
+
+-- END
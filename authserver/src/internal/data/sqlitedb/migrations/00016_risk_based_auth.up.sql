@@ -0,0 +1,10 @@
+-- BEGIN
+
+ALTER TABLE settings ADD COLUMN risk_based_auth_enabled numeric NOT NULL DEFAULT 0;
+ALTER TABLE settings ADD COLUMN risk_score_for_otp INTEGER NOT NULL DEFAULT 40;
+ALTER TABLE settings ADD COLUMN risk_score_for_block INTEGER NOT NULL DEFAULT 90;
+ALTER TABLE settings ADD COLUMN risk_score_for_notification INTEGER NOT NULL DEFAULT 30;
+ALTER TABLE settings ADD COLUMN risk_trusted_ip_ranges TEXT NOT NULL DEFAULT '';
+ALTER TABLE settings ADD COLUMN risk_suspicious_ip_ranges TEXT NOT NULL DEFAULT '';
+
+-- END
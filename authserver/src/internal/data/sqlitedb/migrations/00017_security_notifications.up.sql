@@ -0,0 +1,23 @@
+-- BEGIN
+
+ALTER TABLE users ADD COLUMN disable_password_change_notification numeric NOT NULL DEFAULT 0;
+ALTER TABLE users ADD COLUMN disable_email_change_notification numeric NOT NULL DEFAULT 0;
+ALTER TABLE users ADD COLUMN disable_otp_change_notification numeric NOT NULL DEFAULT 0;
+ALTER TABLE users ADD COLUMN disable_new_device_notification numeric NOT NULL DEFAULT 0;
+
+CREATE TABLE user_known_devices (
+  `id` integer PRIMARY KEY AUTOINCREMENT,
+  created_at DATETIME,
+  updated_at DATETIME,
+  user_id INTEGER NOT NULL,
+  fingerprint TEXT NOT NULL,
+  device_name TEXT NOT NULL,
+  device_type TEXT NOT NULL,
+  device_os TEXT NOT NULL,
+  last_seen_at DATETIME NOT NULL,
+  CONSTRAINT fk_user_known_devices_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+CREATE UNIQUE INDEX `idx_user_known_devices_user_id_fingerprint` ON `user_known_devices`(`user_id`, `fingerprint`);
+
+-- END
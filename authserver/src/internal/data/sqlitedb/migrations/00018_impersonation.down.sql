@@ -0,0 +1,10 @@
+-- BEGIN
+
+ALTER TABLE codes DROP COLUMN impersonator_subject;
+
+ALTER TABLE user_sessions DROP COLUMN impersonator_user_id;
+ALTER TABLE user_sessions DROP COLUMN impersonation_expires_at;
+
+ALTER TABLE clients DROP COLUMN accept_impersonated_tokens;
+
+-- END
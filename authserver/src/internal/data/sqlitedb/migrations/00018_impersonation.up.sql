@@ -0,0 +1,10 @@
+-- BEGIN
+
+ALTER TABLE clients ADD COLUMN accept_impersonated_tokens numeric NOT NULL DEFAULT 0;
+
+ALTER TABLE user_sessions ADD COLUMN impersonator_user_id INTEGER REFERENCES users (id) ON DELETE CASCADE;
+ALTER TABLE user_sessions ADD COLUMN impersonation_expires_at DATETIME;
+
+ALTER TABLE codes ADD COLUMN impersonator_subject TEXT NOT NULL DEFAULT '';
+
+-- END
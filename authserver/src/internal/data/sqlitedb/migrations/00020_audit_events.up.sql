@@ -0,0 +1,21 @@
+-- BEGIN
+
+CREATE TABLE audit_events (
+  `id` integer PRIMARY KEY AUTOINCREMENT,
+  created_at DATETIME,
+  event_type TEXT NOT NULL,
+  actor_subject TEXT NOT NULL,
+  ip_address TEXT NOT NULL,
+  request_id TEXT NOT NULL,
+  user_id INTEGER,
+  client_id INTEGER,
+  details TEXT NOT NULL
+);
+
+CREATE INDEX `idx_audit_events_created_at` ON `audit_events`(`created_at`);
+CREATE INDEX `idx_audit_events_event_type` ON `audit_events`(`event_type`);
+CREATE INDEX `idx_audit_events_actor_subject` ON `audit_events`(`actor_subject`);
+CREATE INDEX `idx_audit_events_user_id` ON `audit_events`(`user_id`);
+CREATE INDEX `idx_audit_events_client_id` ON `audit_events`(`client_id`);
+
+-- END
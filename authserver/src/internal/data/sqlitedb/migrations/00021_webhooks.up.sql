@@ -0,0 +1,35 @@
+-- BEGIN
+
+CREATE TABLE webhooks (
+  `id` integer PRIMARY KEY AUTOINCREMENT,
+  created_at DATETIME,
+  updated_at DATETIME,
+  url TEXT NOT NULL,
+  `description` TEXT NOT NULL,
+  secret_encrypted BLOB NOT NULL,
+  event_types TEXT NOT NULL,
+  enabled numeric NOT NULL
+);
+
+CREATE TABLE webhook_deliveries (
+  `id` integer PRIMARY KEY AUTOINCREMENT,
+  created_at DATETIME,
+  updated_at DATETIME,
+  webhook_id INTEGER NOT NULL,
+  event_id TEXT NOT NULL,
+  event_type TEXT NOT NULL,
+  payload TEXT NOT NULL,
+  status TEXT NOT NULL,
+  attempts INTEGER NOT NULL,
+  next_attempt_at DATETIME,
+  last_attempt_at DATETIME,
+  last_response_code INTEGER NOT NULL,
+  last_error TEXT NOT NULL,
+  CONSTRAINT fk_webhook_deliveries_webhook FOREIGN KEY (webhook_id) REFERENCES webhooks (id) ON DELETE CASCADE
+);
+
+CREATE INDEX `idx_webhook_deliveries_webhook_id` ON `webhook_deliveries`(`webhook_id`);
+CREATE INDEX `idx_webhook_deliveries_status_next_attempt_at` ON `webhook_deliveries`(`status`, `next_attempt_at`);
+CREATE INDEX `idx_webhook_deliveries_created_at` ON `webhook_deliveries`(`created_at`);
+
+-- END
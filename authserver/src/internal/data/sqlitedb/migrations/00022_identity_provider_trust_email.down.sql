@@ -0,0 +1,5 @@
+-- BEGIN
+
+ALTER TABLE identity_providers DROP COLUMN trust_email;
+
+-- END
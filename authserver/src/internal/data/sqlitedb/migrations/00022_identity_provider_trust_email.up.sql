@@ -0,0 +1,5 @@
+-- BEGIN
+
+ALTER TABLE identity_providers ADD COLUMN trust_email numeric NOT NULL DEFAULT 0;
+
+-- END
@@ -0,0 +1,5 @@
+-- BEGIN
+
+ALTER TABLE identity_providers ADD COLUMN groups_mapping TEXT NOT NULL DEFAULT '';
+
+-- END
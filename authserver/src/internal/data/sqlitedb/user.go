@@ -30,6 +30,10 @@ func (d *SQLiteDatabase) UserLoadAttributes(tx *sql.Tx, user *entities.User) err
 	return d.CommonDB.UserLoadAttributes(tx, user)
 }
 
+func (d *SQLiteDatabase) UserLoadPasskeys(tx *sql.Tx, user *entities.User) error {
+	return d.CommonDB.UserLoadPasskeys(tx, user)
+}
+
 func (d *SQLiteDatabase) UserLoadPermissions(tx *sql.Tx, user *entities.User) error {
 	return d.CommonDB.UserLoadPermissions(tx, user)
 }
@@ -58,6 +62,10 @@ func (d *SQLiteDatabase) GetLastUserWithOTPState(tx *sql.Tx, otpEnabledState boo
 	return d.CommonDB.GetLastUserWithOTPState(tx, otpEnabledState)
 }
 
+func (d *SQLiteDatabase) GetLockedUsers(tx *sql.Tx) ([]entities.User, error) {
+	return d.CommonDB.GetLockedUsers(tx)
+}
+
 func (d *SQLiteDatabase) SearchUsersPaginated(tx *sql.Tx, query string, page int, pageSize int) ([]entities.User, int, error) {
 	return d.CommonDB.SearchUsersPaginated(tx, query, page, pageSize)
 }
@@ -0,0 +1,27 @@
+package sqlitedb
+
+import (
+	"database/sql"
+
+	"github.com/leodip/goiabada/internal/entities"
+)
+
+func (d *SQLiteDatabase) CreateUserPasskey(tx *sql.Tx, userPasskey *entities.UserPasskey) error {
+	return d.CommonDB.CreateUserPasskey(tx, userPasskey)
+}
+
+func (d *SQLiteDatabase) UpdateUserPasskey(tx *sql.Tx, userPasskey *entities.UserPasskey) error {
+	return d.CommonDB.UpdateUserPasskey(tx, userPasskey)
+}
+
+func (d *SQLiteDatabase) GetUserPasskeyById(tx *sql.Tx, userPasskeyId int64) (*entities.UserPasskey, error) {
+	return d.CommonDB.GetUserPasskeyById(tx, userPasskeyId)
+}
+
+func (d *SQLiteDatabase) GetUserPasskeysByUserId(tx *sql.Tx, userId int64) ([]entities.UserPasskey, error) {
+	return d.CommonDB.GetUserPasskeysByUserId(tx, userId)
+}
+
+func (d *SQLiteDatabase) DeleteUserPasskey(tx *sql.Tx, userPasskeyId int64) error {
+	return d.CommonDB.DeleteUserPasskey(tx, userPasskeyId)
+}
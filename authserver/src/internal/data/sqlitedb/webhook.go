@@ -0,0 +1,31 @@
+package sqlitedb
+
+import (
+	"database/sql"
+
+	"github.com/leodip/goiabada/internal/entities"
+)
+
+func (d *SQLiteDatabase) CreateWebhook(tx *sql.Tx, webhook *entities.Webhook) error {
+	return d.CommonDB.CreateWebhook(tx, webhook)
+}
+
+func (d *SQLiteDatabase) UpdateWebhook(tx *sql.Tx, webhook *entities.Webhook) error {
+	return d.CommonDB.UpdateWebhook(tx, webhook)
+}
+
+func (d *SQLiteDatabase) GetWebhookById(tx *sql.Tx, webhookId int64) (*entities.Webhook, error) {
+	return d.CommonDB.GetWebhookById(tx, webhookId)
+}
+
+func (d *SQLiteDatabase) GetAllWebhooks(tx *sql.Tx) ([]entities.Webhook, error) {
+	return d.CommonDB.GetAllWebhooks(tx)
+}
+
+func (d *SQLiteDatabase) GetEnabledWebhooks(tx *sql.Tx) ([]entities.Webhook, error) {
+	return d.CommonDB.GetEnabledWebhooks(tx)
+}
+
+func (d *SQLiteDatabase) DeleteWebhook(tx *sql.Tx, webhookId int64) error {
+	return d.CommonDB.DeleteWebhook(tx, webhookId)
+}
@@ -31,6 +31,17 @@ type AuthContext struct {
 	AuthTime            time.Time
 	UserId              int64
 	AuthCompleted       bool
+	EmailLoginAddress   string
+	// the failed email login codes of this login, which are not reset when a new code is sent
+	EmailLoginAttempts int
+	// the user must change a breached password before the authentication completes
+	PasswordChangeRequired bool
+	// the strongest ACR level and the shortest authentication age required by the resources and
+	// permissions in the scope (see core.StepUpResolver)
+	ScopeMinAcrLevel         string
+	ScopeMaxAuthAgeInSeconds int
+	// the login looked unusual, so the second factor of the user is required (see core.RiskEngine)
+	RiskStepUp bool
 }
 
 func (ac *AuthContext) SetScope(scope string) {
@@ -68,6 +79,32 @@ func (ac *AuthContext) ParseRequestedMaxAge() *int {
 	return requestedMaxAge
 }
 
+// GetMaxAgeInSeconds returns the shortest of the requested max age and the authentication age required
+// by the scope, or nil when there's neither.
+func (ac *AuthContext) GetMaxAgeInSeconds() *int {
+	maxAge := ac.ParseRequestedMaxAge()
+	if ac.ScopeMaxAuthAgeInSeconds > 0 && (maxAge == nil || ac.ScopeMaxAuthAgeInSeconds < *maxAge) {
+		maxAge = &ac.ScopeMaxAuthAgeInSeconds
+	}
+	return maxAge
+}
+
+// GetTargetAcrLevel returns the first requested ACR level, or the default ACR level of the client,
+// raised to the ACR level required by the scope when it's stronger.
+func (ac *AuthContext) GetTargetAcrLevel(defaultAcrLevel enums.AcrLevel) enums.AcrLevel {
+	targetAcrLevel := defaultAcrLevel
+	requestedAcrValues := ac.ParseRequestedAcrValues()
+	if len(requestedAcrValues) > 0 {
+		targetAcrLevel = requestedAcrValues[0]
+	}
+
+	scopeMinAcrLevel, err := enums.AcrLevelFromString(ac.ScopeMinAcrLevel)
+	if err == nil && !targetAcrLevel.Satisfies(scopeMinAcrLevel) {
+		targetAcrLevel = scopeMinAcrLevel
+	}
+	return targetAcrLevel
+}
+
 func (ac *AuthContext) SetAcrLevel(targetAcrLevel enums.AcrLevel, userSession *entities.UserSession) error {
 
 	if userSession == nil {
@@ -80,6 +117,12 @@ func (ac *AuthContext) SetAcrLevel(targetAcrLevel enums.AcrLevel, userSession *e
 		return err
 	}
 
+	// a session authenticated with a passkey satisfies any ACR level
+	if userSessionAcrLevel == enums.AcrLevel4 {
+		ac.AcrLevel = userSessionAcrLevel.String()
+		return nil
+	}
+
 	switch targetAcrLevel {
 	case enums.AcrLevel1:
 		if userSessionAcrLevel == enums.AcrLevel2 || userSessionAcrLevel == enums.AcrLevel3 {
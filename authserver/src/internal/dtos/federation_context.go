@@ -0,0 +1,9 @@
+package dtos
+
+type FederationContext struct {
+	IdentityProviderId int64
+	State              string
+	Nonce              string
+	CodeVerifier       string
+	SAMLRequestId      string
+}
@@ -29,6 +29,8 @@ type Client struct {
 	RefreshTokenOfflineMaxLifetimeInSeconds int            `db:"refresh_token_offline_max_lifetime_in_seconds"`
 	IncludeOpenIDConnectClaimsInAccessToken string         `db:"include_open_id_connect_claims_in_access_token"`
 	DefaultAcrLevel                         enums.AcrLevel `db:"default_acr_level"`
+	EmailLoginEnabled                       bool           `db:"email_login_enabled"`
+	AcceptImpersonatedTokens                bool           `db:"accept_impersonated_tokens"`
 	Permissions                             []Permission   `db:"-"`
 	RedirectURIs                            []RedirectURI  `db:"-"`
 	WebOrigins                              []WebOrigin    `db:"-"`
@@ -54,11 +56,13 @@ type WebOrigin struct {
 }
 
 type Resource struct {
-	Id                 int64        `db:"id" fieldtag:"pk"`
-	CreatedAt          sql.NullTime `db:"created_at"`
-	UpdatedAt          sql.NullTime `db:"updated_at"`
-	ResourceIdentifier string       `db:"resource_identifier"`
-	Description        string       `db:"description"`
+	Id                  int64          `db:"id" fieldtag:"pk"`
+	CreatedAt           sql.NullTime   `db:"created_at"`
+	UpdatedAt           sql.NullTime   `db:"updated_at"`
+	ResourceIdentifier  string         `db:"resource_identifier"`
+	Description         string         `db:"description"`
+	MinAcrLevel         enums.AcrLevel `db:"min_acr_level"`
+	MaxAuthAgeInSeconds int            `db:"max_auth_age_in_seconds"`
 }
 
 func (r *Resource) IsSystemLevelResource() bool {
@@ -74,13 +78,15 @@ func (r *Resource) IsSystemLevelResource() bool {
 }
 
 type Permission struct {
-	Id                   int64        `db:"id" fieldtag:"pk"`
-	CreatedAt            sql.NullTime `db:"created_at"`
-	UpdatedAt            sql.NullTime `db:"updated_at"`
-	PermissionIdentifier string       `db:"permission_identifier"`
-	Description          string       `db:"description"`
-	ResourceId           int64        `db:"resource_id"`
-	Resource             Resource     `db:"-"`
+	Id                   int64          `db:"id" fieldtag:"pk"`
+	CreatedAt            sql.NullTime   `db:"created_at"`
+	UpdatedAt            sql.NullTime   `db:"updated_at"`
+	PermissionIdentifier string         `db:"permission_identifier"`
+	Description          string         `db:"description"`
+	ResourceId           int64          `db:"resource_id"`
+	Resource             Resource       `db:"-"`
+	MinAcrLevel          enums.AcrLevel `db:"min_acr_level"`
+	MaxAuthAgeInSeconds  int            `db:"max_auth_age_in_seconds"`
 }
 
 type RedirectURI struct {
@@ -96,6 +102,7 @@ type User struct {
 	UpdatedAt                            sql.NullTime    `db:"updated_at"`
 	Enabled                              bool            `db:"enabled"`
 	Subject                              uuid.UUID       `db:"subject"`
+	ExternalId                           string          `db:"external_id"`
 	Username                             string          `db:"username"`
 	GivenName                            string          `db:"given_name"`
 	MiddleName                           string          `db:"middle_name"`
@@ -124,11 +131,44 @@ type User struct {
 	PasswordHash                         string          `db:"password_hash"`
 	OTPSecret                            string          `db:"otp_secret"`
 	OTPEnabled                           bool            `db:"otp_enabled"`
+	OTPRecoveryCodesHashes               string          `db:"otp_recovery_codes_hashes"`
 	ForgotPasswordCodeEncrypted          []byte          `db:"forgot_password_code_encrypted"`
 	ForgotPasswordCodeIssuedAt           sql.NullTime    `db:"forgot_password_code_issued_at"`
+	EmailLoginCodeHash                   string          `db:"email_login_code_hash"`
+	EmailLoginTokenHash                  string          `db:"email_login_token_hash"`
+	EmailLoginCodeIssuedAt               sql.NullTime    `db:"email_login_code_issued_at"`
+	EmailLoginCodeAttempts               int             `db:"email_login_code_attempts"`
+	SMSOTPCodeHash                       string          `db:"sms_otp_code_hash"`
+	SMSOTPCodeIssuedAt                   sql.NullTime    `db:"sms_otp_code_issued_at"`
+	SMSOTPCodeAttempts                   int             `db:"sms_otp_code_attempts"`
+	FailedLoginAttempts                  int             `db:"failed_login_attempts"`
+	LastFailedLoginAt                    sql.NullTime    `db:"last_failed_login_at"`
+	LockedUntil                          sql.NullTime    `db:"locked_until"`
+	PasswordBreached                     bool            `db:"password_breached"`
+	PasswordChangedAt                    sql.NullTime    `db:"password_changed_at"`
+	DisablePasswordChangeNotification    bool            `db:"disable_password_change_notification"`
+	DisableEmailChangeNotification       bool            `db:"disable_email_change_notification"`
+	DisableOTPChangeNotification         bool            `db:"disable_otp_change_notification"`
+	DisableNewDeviceNotification         bool            `db:"disable_new_device_notification"`
 	Groups                               []Group         `db:"-"`
 	Permissions                          []Permission    `db:"-"`
 	Attributes                           []UserAttribute `db:"-"`
+	Passkeys                             []UserPasskey   `db:"-"`
+}
+
+func (u *User) GetOTPRecoveryCodesRemaining() int {
+	return len(strings.Fields(u.OTPRecoveryCodesHashes))
+}
+
+// IsLocked returns true while the user is temporarily locked out after too many failed logins.
+func (u *User) IsLocked() bool {
+	return u.LockedUntil.Valid && time.Now().UTC().Before(u.LockedUntil.Time)
+}
+
+// IsPasswordExpired returns true when the password is older than the maximum age (0 means no maximum age).
+func (u *User) IsPasswordExpired(maxAgeInDays int) bool {
+	return maxAgeInDays > 0 && u.PasswordChangedAt.Valid &&
+		time.Now().UTC().After(u.PasswordChangedAt.Time.AddDate(0, 0, maxAgeInDays))
 }
 
 func (u *User) HasAddress() bool {
@@ -228,22 +268,33 @@ func (uc *UserConsent) HasScope(scope string) bool {
 }
 
 type UserSession struct {
-	Id                int64               `db:"id" fieldtag:"pk"`
-	CreatedAt         sql.NullTime        `db:"created_at"`
-	UpdatedAt         sql.NullTime        `db:"updated_at"`
-	SessionIdentifier string              `db:"session_identifier"`
-	Started           time.Time           `db:"started"`
-	LastAccessed      time.Time           `db:"last_accessed"`
-	AuthMethods       string              `db:"auth_methods"`
-	AcrLevel          string              `db:"acr_level"`
-	AuthTime          time.Time           `db:"auth_time"`
-	IpAddress         string              `db:"ip_address"`
-	DeviceName        string              `db:"device_name"`
-	DeviceType        string              `db:"device_type"`
-	DeviceOS          string              `db:"device_os"`
-	UserId            int64               `db:"user_id"`
-	User              User                `db:"-"`
-	Clients           []UserSessionClient `db:"-"`
+	Id                     int64               `db:"id" fieldtag:"pk"`
+	CreatedAt              sql.NullTime        `db:"created_at"`
+	UpdatedAt              sql.NullTime        `db:"updated_at"`
+	SessionIdentifier      string              `db:"session_identifier"`
+	Started                time.Time           `db:"started"`
+	LastAccessed           time.Time           `db:"last_accessed"`
+	AuthMethods            string              `db:"auth_methods"`
+	AcrLevel               string              `db:"acr_level"`
+	AuthTime               time.Time           `db:"auth_time"`
+	IpAddress              string              `db:"ip_address"`
+	DeviceName             string              `db:"device_name"`
+	DeviceType             string              `db:"device_type"`
+	DeviceOS               string              `db:"device_os"`
+	UserId                 int64               `db:"user_id"`
+	ImpersonatorUserId     sql.NullInt64       `db:"impersonator_user_id"`
+	ImpersonationExpiresAt sql.NullTime        `db:"impersonation_expires_at"`
+	User                   User                `db:"-"`
+	Clients                []UserSessionClient `db:"-"`
+}
+
+// IsImpersonated returns true when the session was started by an admin impersonating the user.
+func (us *UserSession) IsImpersonated() bool {
+	return us.ImpersonatorUserId.Valid
+}
+
+func (us *UserSession) IsImpersonationExpired() bool {
+	return us.IsImpersonated() && us.ImpersonationExpiresAt.Valid && time.Now().UTC().After(us.ImpersonationExpiresAt.Time)
 }
 
 func (us *UserSession) isValidSinceStarted(userSessionMaxLifetimeInSeconds int) bool {
@@ -268,6 +319,9 @@ func (us *UserSession) IsValid(userSessionIdleTimeoutInSeconds int, userSessionM
 		isValid = isValid && us.isValidSinceStarted(*requestedMaxAgeInSeconds)
 	}
 
+	// impersonated sessions are time-boxed
+	isValid = isValid && !us.IsImpersonationExpired()
+
 	return isValid
 }
 
@@ -305,6 +359,7 @@ type Code struct {
 	SessionIdentifier   string       `db:"session_identifier"`
 	AcrLevel            string       `db:"acr_level"`
 	AuthMethods         string       `db:"auth_methods"`
+	ImpersonatorSubject string       `db:"impersonator_subject"`
 	Used                bool         `db:"used"`
 }
 
@@ -341,34 +396,57 @@ type KeyPair struct {
 }
 
 type Settings struct {
-	Id                                        int64                `db:"id" fieldtag:"pk"`
-	CreatedAt                                 sql.NullTime         `db:"created_at"`
-	UpdatedAt                                 sql.NullTime         `db:"updated_at"`
-	AppName                                   string               `db:"app_name"`
-	Issuer                                    string               `db:"issuer"`
-	UITheme                                   string               `db:"ui_theme"`
-	PasswordPolicy                            enums.PasswordPolicy `db:"password_policy"`
-	SelfRegistrationEnabled                   bool                 `db:"self_registration_enabled"`
-	SelfRegistrationRequiresEmailVerification bool                 `db:"self_registration_requires_email_verification"`
-	TokenExpirationInSeconds                  int                  `db:"token_expiration_in_seconds"`
-	RefreshTokenOfflineIdleTimeoutInSeconds   int                  `db:"refresh_token_offline_idle_timeout_in_seconds"`
-	RefreshTokenOfflineMaxLifetimeInSeconds   int                  `db:"refresh_token_offline_max_lifetime_in_seconds"`
-	UserSessionIdleTimeoutInSeconds           int                  `db:"user_session_idle_timeout_in_seconds"`
-	UserSessionMaxLifetimeInSeconds           int                  `db:"user_session_max_lifetime_in_seconds"`
-	IncludeOpenIDConnectClaimsInAccessToken   bool                 `db:"include_open_id_connect_claims_in_access_token"`
-	SessionAuthenticationKey                  []byte               `db:"session_authentication_key"`
-	SessionEncryptionKey                      []byte               `db:"session_encryption_key"`
-	AESEncryptionKey                          []byte               `db:"aes_encryption_key"`
-	SMTPHost                                  string               `db:"smtp_host"`
-	SMTPPort                                  int                  `db:"smtp_port"`
-	SMTPUsername                              string               `db:"smtp_username"`
-	SMTPPasswordEncrypted                     []byte               `db:"smtp_password_encrypted"`
-	SMTPFromName                              string               `db:"smtp_from_name"`
-	SMTPFromEmail                             string               `db:"smtp_from_email"`
-	SMTPEncryption                            string               `db:"smtp_encryption"`
-	SMTPEnabled                               bool                 `db:"smtp_enabled"`
-	SMSProvider                               string               `db:"sms_provider"`
-	SMSConfigEncrypted                        []byte               `db:"sms_config_encrypted"`
+	Id                                        int64        `db:"id" fieldtag:"pk"`
+	CreatedAt                                 sql.NullTime `db:"created_at"`
+	UpdatedAt                                 sql.NullTime `db:"updated_at"`
+	AppName                                   string       `db:"app_name"`
+	Issuer                                    string       `db:"issuer"`
+	UITheme                                   string       `db:"ui_theme"`
+	SelfRegistrationEnabled                   bool         `db:"self_registration_enabled"`
+	SelfRegistrationRequiresEmailVerification bool         `db:"self_registration_requires_email_verification"`
+	TokenExpirationInSeconds                  int          `db:"token_expiration_in_seconds"`
+	RefreshTokenOfflineIdleTimeoutInSeconds   int          `db:"refresh_token_offline_idle_timeout_in_seconds"`
+	RefreshTokenOfflineMaxLifetimeInSeconds   int          `db:"refresh_token_offline_max_lifetime_in_seconds"`
+	UserSessionIdleTimeoutInSeconds           int          `db:"user_session_idle_timeout_in_seconds"`
+	UserSessionMaxLifetimeInSeconds           int          `db:"user_session_max_lifetime_in_seconds"`
+	IncludeOpenIDConnectClaimsInAccessToken   bool         `db:"include_open_id_connect_claims_in_access_token"`
+	SessionAuthenticationKey                  []byte       `db:"session_authentication_key"`
+	SessionEncryptionKey                      []byte       `db:"session_encryption_key"`
+	AESEncryptionKey                          []byte       `db:"aes_encryption_key"`
+	SMTPHost                                  string       `db:"smtp_host"`
+	SMTPPort                                  int          `db:"smtp_port"`
+	SMTPUsername                              string       `db:"smtp_username"`
+	SMTPPasswordEncrypted                     []byte       `db:"smtp_password_encrypted"`
+	SMTPFromName                              string       `db:"smtp_from_name"`
+	SMTPFromEmail                             string       `db:"smtp_from_email"`
+	SMTPEncryption                            string       `db:"smtp_encryption"`
+	SMTPEnabled                               bool         `db:"smtp_enabled"`
+	SMSProvider                               string       `db:"sms_provider"`
+	SMSConfigEncrypted                        []byte       `db:"sms_config_encrypted"`
+	SMSOTPAllowedForMandatory2FA              bool         `db:"sms_otp_allowed_for_mandatory_2fa"`
+	TrustedDeviceLifetimeInDays               int          `db:"trusted_device_lifetime_in_days"`
+	LoginDelayAfterFailedAttempts             int          `db:"login_delay_after_failed_attempts"`
+	LoginLockoutAfterFailedAttempts           int          `db:"login_lockout_after_failed_attempts"`
+	LoginIpLockoutAfterFailedAttempts         int          `db:"login_ip_lockout_after_failed_attempts"`
+	LoginLockoutDurationInSeconds             int          `db:"login_lockout_duration_in_seconds"`
+	RiskBasedAuthEnabled                      bool         `db:"risk_based_auth_enabled"`
+	RiskScoreForOTP                           int          `db:"risk_score_for_otp"`
+	RiskScoreForBlock                         int          `db:"risk_score_for_block"`
+	RiskScoreForNotification                  int          `db:"risk_score_for_notification"`
+	RiskTrustedIpRanges                       string       `db:"risk_trusted_ip_ranges"`
+	RiskSuspiciousIpRanges                    string       `db:"risk_suspicious_ip_ranges"`
+	RejectBreachedPasswords                   bool         `db:"reject_breached_passwords"`
+	ForceChangeOfBreachedPasswords            bool         `db:"force_change_of_breached_passwords"`
+	PasswordMinLength                         int          `db:"password_min_length"`
+	PasswordMaxLength                         int          `db:"password_max_length"`
+	PasswordRequiresUppercase                 bool         `db:"password_requires_uppercase"`
+	PasswordRequiresLowercase                 bool         `db:"password_requires_lowercase"`
+	PasswordRequiresNumber                    bool         `db:"password_requires_number"`
+	PasswordRequiresSpecialChar               bool         `db:"password_requires_special_char"`
+	PasswordDisallowUsernameOrEmail           bool         `db:"password_disallow_username_or_email"`
+	PasswordHistoryCount                      int          `db:"password_history_count"`
+	PasswordMaxAgeInDays                      int          `db:"password_max_age_in_days"`
+	PasswordMinAgeInDays                      int          `db:"password_min_age_in_days"`
 }
 
 type PreRegistration struct {
@@ -386,6 +464,7 @@ type Group struct {
 	CreatedAt            sql.NullTime     `db:"created_at"`
 	UpdatedAt            sql.NullTime     `db:"updated_at"`
 	GroupIdentifier      string           `db:"group_identifier"`
+	ExternalId           string           `db:"external_id"`
 	Description          string           `db:"description"`
 	Attributes           []GroupAttribute `db:"-"`
 	Permissions          []Permission     `db:"-"`
@@ -454,3 +533,180 @@ type GroupPermission struct {
 	GroupId      int64        `db:"group_id"`
 	PermissionId int64        `db:"permission_id"`
 }
+
+type IdentityProvider struct {
+	Id                         int64        `db:"id" fieldtag:"pk"`
+	CreatedAt                  sql.NullTime `db:"created_at"`
+	UpdatedAt                  sql.NullTime `db:"updated_at"`
+	Name                       string       `db:"name"`
+	IdentityProviderIdentifier string       `db:"identity_provider_identifier"`
+	Type                       string       `db:"type" fieldopt:"withquote"`
+	Enabled                    bool         `db:"enabled"`
+	Issuer                     string       `db:"issuer"`
+	ClientIdentifier           string       `db:"client_identifier"`
+	ClientSecretEncrypted      []byte       `db:"client_secret_encrypted"`
+	Scopes                     string       `db:"scopes"`
+	EmailClaim                 string       `db:"email_claim"`
+	GivenNameClaim             string       `db:"given_name_claim"`
+	FamilyNameClaim            string       `db:"family_name_claim"`
+	LinkByEmail                bool         `db:"link_by_email"`
+	TrustEmail                 bool         `db:"trust_email"`
+	AutoProvision              bool         `db:"auto_provision"`
+	SAMLMetadataURL            string       `db:"saml_metadata_url"`
+	SAMLMetadataXML            []byte       `db:"saml_metadata_xml"`
+	SAMLNameIdFormat           string       `db:"saml_name_id_format"`
+	GroupsClaim                string       `db:"groups_claim"`
+	GroupsMapping              string       `db:"groups_mapping"`
+	UserAttributesMapping      string       `db:"user_attributes_mapping"`
+	LDAPURL                    string       `db:"ldap_url"`
+	LDAPStartTLS               bool         `db:"ldap_start_tls"`
+	LDAPBindDN                 string       `db:"ldap_bind_dn"`
+	LDAPBindPasswordEncrypted  []byte       `db:"ldap_bind_password_encrypted"`
+	LDAPBaseDN                 string       `db:"ldap_base_dn"`
+	LDAPUserFilter             string       `db:"ldap_user_filter"`
+	LDAPSubjectAttribute       string       `db:"ldap_subject_attribute"`
+	LDAPLocalPasswordFallback  bool         `db:"ldap_local_password_fallback"`
+}
+
+type UserFederatedIdentity struct {
+	Id                 int64            `db:"id" fieldtag:"pk"`
+	CreatedAt          sql.NullTime     `db:"created_at"`
+	UpdatedAt          sql.NullTime     `db:"updated_at"`
+	UserId             int64            `db:"user_id"`
+	IdentityProviderId int64            `db:"identity_provider_id"`
+	IdentityProvider   IdentityProvider `db:"-"`
+	Subject            string           `db:"subject"`
+	LastLoginAt        sql.NullTime     `db:"last_login_at"`
+}
+
+type UserPasskey struct {
+	Id              int64        `db:"id" fieldtag:"pk"`
+	CreatedAt       sql.NullTime `db:"created_at"`
+	UpdatedAt       sql.NullTime `db:"updated_at"`
+	UserId          int64        `db:"user_id"`
+	Name            string       `db:"name"`
+	CredentialId    []byte       `db:"credential_id"`
+	PublicKey       []byte       `db:"public_key"`
+	AttestationType string       `db:"attestation_type"`
+	Transports      string       `db:"transports"`
+	AAGUID          []byte       `db:"aaguid"`
+	SignCount       int64        `db:"sign_count"`
+	BackupEligible  bool         `db:"backup_eligible"`
+	BackupState     bool         `db:"backup_state"`
+	LastUsedAt      sql.NullTime `db:"last_used_at"`
+}
+
+type FailedLoginIp struct {
+	Id             int64        `db:"id" fieldtag:"pk"`
+	CreatedAt      sql.NullTime `db:"created_at"`
+	UpdatedAt      sql.NullTime `db:"updated_at"`
+	IpAddress      string       `db:"ip_address"`
+	FailedAttempts int          `db:"failed_attempts"`
+	LastFailedAt   time.Time    `db:"last_failed_at"`
+	LockedUntil    sql.NullTime `db:"locked_until"`
+}
+
+func (f *FailedLoginIp) IsLocked() bool {
+	return f.LockedUntil.Valid && time.Now().UTC().Before(f.LockedUntil.Time)
+}
+
+type UserTrustedDevice struct {
+	Id             int64        `db:"id" fieldtag:"pk"`
+	CreatedAt      sql.NullTime `db:"created_at"`
+	UpdatedAt      sql.NullTime `db:"updated_at"`
+	UserId         int64        `db:"user_id"`
+	IdentifierHash string       `db:"identifier_hash"`
+	IpAddress      string       `db:"ip_address"`
+	DeviceName     string       `db:"device_name"`
+	DeviceType     string       `db:"device_type"`
+	DeviceOS       string       `db:"device_os"`
+	ExpiresAt      time.Time    `db:"expires_at"`
+	LastUsedAt     sql.NullTime `db:"last_used_at"`
+}
+
+func (td *UserTrustedDevice) IsExpired() bool {
+	return time.Now().UTC().After(td.ExpiresAt)
+}
+
+type UserKnownDevice struct {
+	Id          int64        `db:"id" fieldtag:"pk"`
+	CreatedAt   sql.NullTime `db:"created_at"`
+	UpdatedAt   sql.NullTime `db:"updated_at"`
+	UserId      int64        `db:"user_id"`
+	Fingerprint string       `db:"fingerprint"`
+	DeviceName  string       `db:"device_name"`
+	DeviceType  string       `db:"device_type"`
+	DeviceOS    string       `db:"device_os"`
+	LastSeenAt  time.Time    `db:"last_seen_at"`
+}
+
+type UserPasswordHistory struct {
+	Id           int64        `db:"id" fieldtag:"pk"`
+	CreatedAt    sql.NullTime `db:"created_at"`
+	UserId       int64        `db:"user_id"`
+	PasswordHash string       `db:"password_hash"`
+}
+
+type AuditEvent struct {
+	Id           int64         `db:"id" fieldtag:"pk"`
+	CreatedAt    sql.NullTime  `db:"created_at"`
+	EventType    string        `db:"event_type"`
+	ActorSubject string        `db:"actor_subject"`
+	IpAddress    string        `db:"ip_address"`
+	RequestId    string        `db:"request_id"`
+	UserId       sql.NullInt64 `db:"user_id"`
+	ClientId     sql.NullInt64 `db:"client_id"`
+	Details      string        `db:"details"`
+}
+
+// AuditEventFilter selects the audit events to search. The empty fields don't filter the events.
+// The user matches the events about the user and the events triggered by the user, and the same
+// goes for the client.
+type AuditEventFilter struct {
+	EventType        string
+	UserId           int64
+	UserSubject      string
+	ClientId         int64
+	ClientIdentifier string
+	From             time.Time
+	To               time.Time
+}
+
+type Webhook struct {
+	Id              int64        `db:"id" fieldtag:"pk"`
+	CreatedAt       sql.NullTime `db:"created_at"`
+	UpdatedAt       sql.NullTime `db:"updated_at"`
+	Url             string       `db:"url"`
+	Description     string       `db:"description"`
+	SecretEncrypted []byte       `db:"secret_encrypted"`
+	EventTypes      string       `db:"event_types"`
+	Enabled         bool         `db:"enabled"`
+}
+
+// GetEventTypes returns the event types the webhook is subscribed to, which are stored separated by spaces.
+func (w *Webhook) GetEventTypes() []string {
+	return strings.Fields(w.EventTypes)
+}
+
+func (w *Webhook) HasEventType(eventType string) bool {
+	return slices.Contains(w.GetEventTypes(), eventType)
+}
+
+// WebhookDelivery is an event to be delivered to a webhook. The deliveries are the outbox of the
+// webhooks: they are created when the event happens and sent in the background, and are kept as
+// the delivery log.
+type WebhookDelivery struct {
+	Id               int64        `db:"id" fieldtag:"pk"`
+	CreatedAt        sql.NullTime `db:"created_at"`
+	UpdatedAt        sql.NullTime `db:"updated_at"`
+	WebhookId        int64        `db:"webhook_id"`
+	EventId          string       `db:"event_id"`
+	EventType        string       `db:"event_type"`
+	Payload          string       `db:"payload"`
+	Status           string       `db:"status"`
+	Attempts         int          `db:"attempts"`
+	NextAttemptAt    sql.NullTime `db:"next_attempt_at"`
+	LastAttemptAt    sql.NullTime `db:"last_attempt_at"`
+	LastResponseCode int          `db:"last_response_code"`
+	LastError        string       `db:"last_error"`
+}
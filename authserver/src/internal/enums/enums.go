@@ -26,6 +26,7 @@ const (
 	AcrLevel1 AcrLevel = "urn:goiabada:pwd"                // password
 	AcrLevel2 AcrLevel = "urn:goiabada:pwd:otp_ifpossible" // password + otp if enabled
 	AcrLevel3 AcrLevel = "urn:goiabada:pwd:otp_mandatory"  // password + mandatory otp
+	AcrLevel4 AcrLevel = "urn:goiabada:passkey"            // passkey (as the first or the second factor)
 )
 
 func (acrl AcrLevel) String() string {
@@ -40,19 +41,44 @@ func AcrLevelFromString(s string) (AcrLevel, error) {
 		return AcrLevel2, nil
 	case AcrLevel3.String():
 		return AcrLevel3, nil
+	case AcrLevel4.String():
+		return AcrLevel4, nil
 	}
 	return "", errors.WithStack(errors.New("invalid ACR level " + s))
 }
 
+// Satisfies returns true when an authentication at this ACR level meets the required ACR level.
+// The levels are ordered from the password only (level 1) to the passkey (level 4).
+func (acrl AcrLevel) Satisfies(requiredAcrLevel AcrLevel) bool {
+	rank := func(acrLevel AcrLevel) int {
+		switch acrLevel {
+		case AcrLevel1:
+			return 1
+		case AcrLevel2:
+			return 2
+		case AcrLevel3:
+			return 3
+		case AcrLevel4:
+			return 4
+		}
+		return 0
+	}
+	return rank(acrl) >= rank(requiredAcrLevel)
+}
+
 type AuthMethod int
 
 const (
 	AuthMethodPassword AuthMethod = iota
 	AuthMethodOTP
+	AuthMethodFederated
+	AuthMethodPasskey
+	AuthMethodEmail
+	AuthMethodSMS
 )
 
 func (am AuthMethod) String() string {
-	return []string{"pwd", "otp"}[am]
+	return []string{"pwd", "otp", "fed", "pop", "email", "sms"}[am]
 }
 
 type Gender int
@@ -71,33 +97,6 @@ func IsGenderValid(i int) bool {
 	return i >= 0 && i <= int(GenderOther)
 }
 
-type PasswordPolicy int
-
-const (
-	PasswordPolicyNone   PasswordPolicy = iota // at least 1 char
-	PasswordPolicyLow                          // at least 6 chars
-	PasswordPolicyMedium                       // at least 8 chars. Must contain: 1 uppercase, 1 lowercase and 1 number
-	PasswordPolicyHigh                         // at least 10 chars. Must contain: 1 uppercase, 1 lowercase, 1 number and 1 special character/symbol
-)
-
-func (p PasswordPolicy) String() string {
-	return []string{"none", "low", "medium", "high"}[p]
-}
-
-func PasswordPolicyFromString(s string) (PasswordPolicy, error) {
-	switch s {
-	case PasswordPolicyNone.String():
-		return PasswordPolicyNone, nil
-	case PasswordPolicyLow.String():
-		return PasswordPolicyLow, nil
-	case PasswordPolicyMedium.String():
-		return PasswordPolicyMedium, nil
-	case PasswordPolicyHigh.String():
-		return PasswordPolicyHigh, nil
-	}
-	return PasswordPolicyNone, errors.WithStack(errors.New("invalid password policy " + s))
-}
-
 type KeyState int
 
 const (
@@ -169,3 +168,27 @@ func ThreeStateSettingFromString(s string) (ThreeStateSetting, error) {
 	}
 	return ThreeStateSettingOn, errors.WithStack(errors.New("invalid three state setting " + s))
 }
+
+type IdentityProviderType int
+
+const (
+	IdentityProviderTypeOIDC IdentityProviderType = iota
+	IdentityProviderTypeSAML
+	IdentityProviderTypeLDAP
+)
+
+func (ipt IdentityProviderType) String() string {
+	return []string{"oidc", "saml", "ldap"}[ipt]
+}
+
+func IdentityProviderTypeFromString(s string) (IdentityProviderType, error) {
+	switch s {
+	case IdentityProviderTypeOIDC.String():
+		return IdentityProviderTypeOIDC, nil
+	case IdentityProviderTypeSAML.String():
+		return IdentityProviderTypeSAML, nil
+	case IdentityProviderTypeLDAP.String():
+		return IdentityProviderTypeLDAP, nil
+	}
+	return IdentityProviderTypeOIDC, errors.WithStack(errors.New("invalid identity provider type " + s))
+}
@@ -43,15 +43,50 @@ func InitViper() {
 	} else {
 		viper.SetDefault("DB.Type", "mysql")
 		viper.SetDefault("DB.Host", "localhost")
-		viper.SetDefault("DB.Port", "3306")
 		viper.SetDefault("DB.Name", "goiabada")
-		viper.SetDefault("DB.Username", "root")
+		if viper.GetString("DB.Type") == "postgres" {
+			viper.SetDefault("DB.Port", "5432")
+			viper.SetDefault("DB.Username", "postgres")
+			viper.SetDefault("DB.SSLMode", "disable")
+		} else {
+			viper.SetDefault("DB.Port", "3306")
+			viper.SetDefault("DB.Username", "root")
+		}
 	}
 
+	viper.SetDefault("Auditing.Database.Enabled", true)
+	viper.SetDefault("Auditing.Database.RetentionInDays", 365)
+	viper.SetDefault("Auditing.File.Enabled", false)
+	viper.SetDefault("Auditing.File.Path", "audit/goiabada-audit.log")
+	viper.SetDefault("Auditing.File.MaxSizeInMB", 100)
+	viper.SetDefault("Auditing.File.MaxBackups", 5)
+	viper.SetDefault("Auditing.Syslog.Enabled", false)
+	viper.SetDefault("Auditing.Syslog.Network", "udp")
+	viper.SetDefault("Auditing.Syslog.Address", "localhost:514")
+	viper.SetDefault("Auditing.Syslog.Facility", "authpriv")
+	viper.SetDefault("Auditing.Syslog.AppName", "goiabada")
+	viper.SetDefault("Auditing.Webhook.Enabled", false)
+	viper.SetDefault("Auditing.Webhook.BatchSize", 100)
+	viper.SetDefault("Auditing.Webhook.FlushIntervalInSeconds", 5)
+	viper.SetDefault("Auditing.Webhook.TimeoutInSeconds", 10)
+	viper.SetDefault("Auditing.Webhook.MaxRetries", 5)
+
+	viper.SetDefault("Webhooks.PollIntervalInSeconds", 5)
+	viper.SetDefault("Webhooks.TimeoutInSeconds", 10)
+	viper.SetDefault("Webhooks.MaxAttempts", 8)
+	viper.SetDefault("Webhooks.RetryIntervalInSeconds", 30)
+	viper.SetDefault("Webhooks.DeliveryRetentionInDays", 30)
+
 	viper.SetDefault("RateLimiter.Enabled", true)
 	viper.SetDefault("RateLimiter.MaxRequests", 50)
 	viper.SetDefault("RateLimiter.WindowSizeInSeconds", 10)
 
+	viper.SetDefault("PasswordHashing.Algorithm", "argon2id")
+	viper.SetDefault("PasswordHashing.Argon2id.MemoryInKiB", 65536)
+	viper.SetDefault("PasswordHashing.Argon2id.Iterations", 3)
+	viper.SetDefault("PasswordHashing.Argon2id.Parallelism", 2)
+	viper.SetDefault("PasswordHashing.Bcrypt.Cost", 10)
+
 	slog.Info("viper configuration initialized")
 }
 
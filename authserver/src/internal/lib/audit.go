@@ -1,22 +1,71 @@
 package lib
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"log/slog"
+	"time"
 
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/leodip/goiabada/internal/common"
 	"github.com/spf13/viper"
 )
 
 type AuditEvent struct {
-	Event   string                 `json:"event"`
-	Details map[string]interface{} `json:"details"`
+	Timestamp    time.Time              `json:"timestamp"`
+	Event        string                 `json:"event"`
+	ActorSubject string                 `json:"actorSubject,omitempty"`
+	IpAddress    string                 `json:"ipAddress,omitempty"`
+	RequestId    string                 `json:"requestId,omitempty"`
+	Details      map[string]interface{} `json:"details"`
 }
 
-func LogAudit(event string, details map[string]interface{}) {
+// AuditSink delivers the audit events to a destination, like the database or a SIEM. WriteAuditEvent
+// must not block the caller, as audit events are logged while handling requests, sometimes with a
+// transaction open.
+type AuditSink interface {
+	WriteAuditEvent(auditEvent *AuditEvent)
+	Close()
+}
+
+var auditSinks []AuditSink
+
+// AddAuditSink adds a sink that receives the audit events.
+func AddAuditSink(sink AuditSink) {
+	auditSinks = append(auditSinks, sink)
+}
+
+// CloseAuditSinks waits for the pending audit events to be delivered and removes the sinks.
+func CloseAuditSinks() {
+	for _, sink := range auditSinks {
+		sink.Close()
+	}
+	auditSinks = nil
+}
+
+// the keys of the details that identify who triggered the event, in order of preference
+var auditActorKeys = []string{"loggedInUser", "apiClient", "scimClient", "cliUser"}
+
+func LogAudit(ctx context.Context, event string, details map[string]interface{}) {
 	auditEvent := AuditEvent{
-		Event:   event,
-		Details: details,
+		Timestamp: time.Now().UTC(),
+		Event:     event,
+		Details:   details,
+	}
+
+	for _, key := range auditActorKeys {
+		if actor, ok := details[key].(string); ok && len(actor) > 0 {
+			auditEvent.ActorSubject = actor
+			break
+		}
+	}
+
+	if ctx != nil {
+		auditEvent.RequestId = middleware.GetReqID(ctx)
+		if ipAddress, ok := ctx.Value(common.ContextKeyIpAddress).(string); ok {
+			auditEvent.IpAddress = ipAddress
+		}
 	}
 
 	detailsJson, err := json.Marshal(auditEvent.Details)
@@ -30,4 +79,8 @@ func LogAudit(event string, details map[string]interface{}) {
 	if consoleLogEnabled {
 		slog.Info(fmt.Sprintf("audit: %v; details: %v", auditEvent.Event, string(detailsJson)))
 	}
+
+	for _, sink := range auditSinks {
+		sink.WriteAuditEvent(&auditEvent)
+	}
 }
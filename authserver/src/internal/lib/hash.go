@@ -1,11 +1,12 @@
 package lib
 
 import (
+	"crypto/hmac"
 	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 
 	"github.com/pkg/errors"
-	"golang.org/x/crypto/bcrypt"
 )
 
 // This can hash strings of any length
@@ -28,16 +29,12 @@ func VerifyStringHash(hashedString string, s string) bool {
 	return hash == hashedString
 }
 
-// The maximum length for password is 72 bytes
-func HashPassword(password string) (string, error) {
-	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
-	if err != nil {
-		return "", errors.Wrap(err, "unable to hash")
-	}
-	return string(hash), nil
-}
-
-func VerifyPasswordHash(hashedPassword string, password string) bool {
-	err := bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password))
-	return err == nil
+// SignWebhookPayload returns the signature of a webhook payload, sent in the X-Goiabada-Signature
+// header. The receiver computes the HMAC-SHA256, with the shared secret, of the timestamp of the
+// X-Goiabada-Timestamp header, a dot and the body, and compares it with the header.
+func SignWebhookPayload(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(fmt.Sprintf("%v.", timestamp)))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
 }
@@ -0,0 +1,338 @@
+package lib
+
+import (
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"hash"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+	"golang.org/x/crypto/pbkdf2"
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	passwordHashingArgon2id = "argon2id"
+	passwordHashingBcrypt   = "bcrypt"
+)
+
+const (
+	defaultArgon2idMemoryInKiB  = 64 * 1024
+	defaultArgon2idIterations   = 3
+	defaultArgon2idParallelism  = 2
+	argon2idSaltLength          = 16
+	argon2idKeyLength           = 32
+	maxArgon2idMemoryInKiB      = 4 * 1024 * 1024
+	maxImportedHashIterations   = 10_000_000
+	maxImportedScryptCostFactor = 20
+)
+
+type argon2idParams struct {
+	memory      uint32
+	iterations  uint32
+	parallelism uint8
+}
+
+func getPasswordHashingAlgorithm() string {
+	algorithm := strings.ToLower(strings.TrimSpace(viper.GetString("PasswordHashing.Algorithm")))
+	if algorithm == passwordHashingBcrypt {
+		return passwordHashingBcrypt
+	}
+	return passwordHashingArgon2id
+}
+
+func getArgon2idParams() argon2idParams {
+	params := argon2idParams{
+		memory:      defaultArgon2idMemoryInKiB,
+		iterations:  defaultArgon2idIterations,
+		parallelism: defaultArgon2idParallelism,
+	}
+	if memory := viper.GetInt("PasswordHashing.Argon2id.MemoryInKiB"); memory > 0 && memory <= maxArgon2idMemoryInKiB {
+		params.memory = uint32(memory)
+	}
+	if iterations := viper.GetInt("PasswordHashing.Argon2id.Iterations"); iterations > 0 && iterations <= 100 {
+		params.iterations = uint32(iterations)
+	}
+	if parallelism := viper.GetInt("PasswordHashing.Argon2id.Parallelism"); parallelism > 0 && parallelism <= 255 {
+		params.parallelism = uint8(parallelism)
+	}
+	return params
+}
+
+func getBcryptCost() int {
+	cost := viper.GetInt("PasswordHashing.Bcrypt.Cost")
+	if cost < bcrypt.MinCost || cost > bcrypt.MaxCost {
+		return bcrypt.DefaultCost
+	}
+	return cost
+}
+
+// HashPassword hashes the password with the configured algorithm (Argon2id by default).
+// With bcrypt, only the first 72 bytes of the password are used.
+func HashPassword(password string) (string, error) {
+	if getPasswordHashingAlgorithm() == passwordHashingBcrypt {
+		hash, err := bcrypt.GenerateFromPassword([]byte(password), getBcryptCost())
+		if err != nil {
+			return "", errors.Wrap(err, "unable to hash")
+		}
+		return string(hash), nil
+	}
+
+	params := getArgon2idParams()
+	salt := make([]byte, argon2idSaltLength)
+	_, err := rand.Read(salt)
+	if err != nil {
+		return "", errors.Wrap(err, "unable to generate salt")
+	}
+	key := argon2.IDKey([]byte(password), salt, params.iterations, params.memory, params.parallelism, argon2idKeyLength)
+
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s", argon2.Version, params.memory, params.iterations,
+		params.parallelism, base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+// VerifyPasswordHash checks the password against a hash created by HashPassword, or against a hash imported
+// from another system. Besides Argon2id and bcrypt, these formats are supported:
+//
+//	pbkdf2_sha256$<iterations>$<salt>$<base64 hash>           (Django, also pbkdf2_sha1)
+//	$pbkdf2-sha256$<iterations>$<salt>$<hash>                 (passlib, also $pbkdf2$ and $pbkdf2-sha512$)
+//	$scrypt$ln=<log2 N>,r=<r>,p=<p>$<salt>$<hash>             (passlib)
+//	{SSHA}<base64 of hash and salt>                           (LDAP, also {SSHA256}, {SSHA512}, {SHA}, {SHA256} and {SHA512})
+func VerifyPasswordHash(hashedPassword string, password string) bool {
+	switch {
+	case strings.HasPrefix(hashedPassword, "$argon2id$"):
+		return verifyArgon2idHash(hashedPassword, password)
+	case strings.HasPrefix(hashedPassword, "$2a$"), strings.HasPrefix(hashedPassword, "$2b$"),
+		strings.HasPrefix(hashedPassword, "$2y$"):
+		return bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password)) == nil
+	case strings.HasPrefix(hashedPassword, "pbkdf2_"):
+		return verifyDjangoPbkdf2Hash(hashedPassword, password)
+	case strings.HasPrefix(hashedPassword, "$pbkdf2"):
+		return verifyPasslibPbkdf2Hash(hashedPassword, password)
+	case strings.HasPrefix(hashedPassword, "$scrypt$"):
+		return verifyScryptHash(hashedPassword, password)
+	case strings.HasPrefix(hashedPassword, "{"):
+		return verifyLdapHash(hashedPassword, password)
+	}
+	return false
+}
+
+// IsSupportedPasswordHash returns true when the hash is in one of the formats accepted by VerifyPasswordHash.
+// Only the prefix is checked, so a malformed hash is still rejected when the password is verified.
+func IsSupportedPasswordHash(hashedPassword string) bool {
+	for _, prefix := range []string{"$argon2id$", "$2a$", "$2b$", "$2y$", "pbkdf2_", "$pbkdf2", "$scrypt$", "{"} {
+		if strings.HasPrefix(hashedPassword, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// PasswordHashNeedsRehash returns true when the hash wasn't created with the configured algorithm and
+// parameters, so it should be replaced the next time the password is available.
+func PasswordHashNeedsRehash(hashedPassword string) bool {
+	if len(hashedPassword) == 0 {
+		return false
+	}
+
+	if getPasswordHashingAlgorithm() == passwordHashingBcrypt {
+		cost, err := bcrypt.Cost([]byte(hashedPassword))
+		return err != nil || cost != getBcryptCost()
+	}
+
+	params, _, key, err := parseArgon2idHash(hashedPassword)
+	return err != nil || params != getArgon2idParams() || len(key) != argon2idKeyLength
+}
+
+func parseArgon2idHash(hashedPassword string) (params argon2idParams, salt []byte, key []byte, err error) {
+	// $argon2id$v=19$m=65536,t=3,p=2$<salt>$<key>
+	parts := strings.Split(hashedPassword, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return params, nil, nil, errors.WithStack(errors.New("invalid argon2id hash"))
+	}
+
+	var version int
+	_, err = fmt.Sscanf(parts[2], "v=%d", &version)
+	if err != nil || version != argon2.Version {
+		return params, nil, nil, errors.WithStack(errors.New("unsupported argon2id version"))
+	}
+
+	_, err = fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.memory, &params.iterations, &params.parallelism)
+	if err != nil || params.memory > maxArgon2idMemoryInKiB || params.iterations == 0 || params.parallelism == 0 {
+		return params, nil, nil, errors.WithStack(errors.New("invalid argon2id parameters"))
+	}
+
+	salt, err = decodeHashBase64(parts[4])
+	if err != nil {
+		return params, nil, nil, err
+	}
+	key, err = decodeHashBase64(parts[5])
+	if err != nil || len(key) == 0 {
+		return params, nil, nil, errors.WithStack(errors.New("invalid argon2id key"))
+	}
+	return params, salt, key, nil
+}
+
+func verifyArgon2idHash(hashedPassword string, password string) bool {
+	params, salt, key, err := parseArgon2idHash(hashedPassword)
+	if err != nil {
+		return false
+	}
+	otherKey := argon2.IDKey([]byte(password), salt, params.iterations, params.memory, params.parallelism, uint32(len(key)))
+	return subtle.ConstantTimeCompare(key, otherKey) == 1
+}
+
+func verifyDjangoPbkdf2Hash(hashedPassword string, password string) bool {
+	// pbkdf2_sha256$<iterations>$<salt>$<base64 hash>
+	parts := strings.Split(hashedPassword, "$")
+	if len(parts) != 4 {
+		return false
+	}
+
+	var hashFunc func() hash.Hash
+	switch parts[0] {
+	case "pbkdf2_sha1":
+		hashFunc = sha1.New
+	case "pbkdf2_sha256":
+		hashFunc = sha256.New
+	default:
+		return false
+	}
+
+	iterations, err := strconv.Atoi(parts[1])
+	if err != nil || iterations <= 0 || iterations > maxImportedHashIterations {
+		return false
+	}
+	key, err := base64.StdEncoding.DecodeString(parts[3])
+	if err != nil || len(key) == 0 {
+		return false
+	}
+
+	otherKey := pbkdf2.Key([]byte(password), []byte(parts[2]), iterations, len(key), hashFunc)
+	return subtle.ConstantTimeCompare(key, otherKey) == 1
+}
+
+func verifyPasslibPbkdf2Hash(hashedPassword string, password string) bool {
+	// $pbkdf2-sha256$<iterations>$<salt>$<hash>
+	parts := strings.Split(hashedPassword, "$")
+	if len(parts) != 5 {
+		return false
+	}
+
+	var hashFunc func() hash.Hash
+	switch parts[1] {
+	case "pbkdf2":
+		hashFunc = sha1.New
+	case "pbkdf2-sha256":
+		hashFunc = sha256.New
+	case "pbkdf2-sha512":
+		hashFunc = sha512.New
+	default:
+		return false
+	}
+
+	iterations, err := strconv.Atoi(parts[2])
+	if err != nil || iterations <= 0 || iterations > maxImportedHashIterations {
+		return false
+	}
+	salt, err := decodeHashBase64(parts[3])
+	if err != nil {
+		return false
+	}
+	key, err := decodeHashBase64(parts[4])
+	if err != nil || len(key) == 0 {
+		return false
+	}
+
+	otherKey := pbkdf2.Key([]byte(password), salt, iterations, len(key), hashFunc)
+	return subtle.ConstantTimeCompare(key, otherKey) == 1
+}
+
+func verifyScryptHash(hashedPassword string, password string) bool {
+	// $scrypt$ln=16,r=8,p=1$<salt>$<hash>
+	parts := strings.Split(hashedPassword, "$")
+	if len(parts) != 5 {
+		return false
+	}
+
+	var ln, r, p int
+	_, err := fmt.Sscanf(parts[2], "ln=%d,r=%d,p=%d", &ln, &r, &p)
+	if err != nil || ln <= 0 || ln > maxImportedScryptCostFactor || r <= 0 || p <= 0 || r*p > 64 {
+		return false
+	}
+	salt, err := decodeHashBase64(parts[3])
+	if err != nil {
+		return false
+	}
+	key, err := decodeHashBase64(parts[4])
+	if err != nil || len(key) == 0 {
+		return false
+	}
+
+	otherKey, err := scrypt.Key([]byte(password), salt, 1<<ln, r, p, len(key))
+	if err != nil {
+		return false
+	}
+	return subtle.ConstantTimeCompare(key, otherKey) == 1
+}
+
+func verifyLdapHash(hashedPassword string, password string) bool {
+	// {SSHA}<base64 of the digest of password+salt, followed by the salt>
+	end := strings.Index(hashedPassword, "}")
+	if end < 0 {
+		return false
+	}
+	scheme := strings.ToUpper(hashedPassword[1:end])
+
+	salted := strings.HasPrefix(scheme, "SSHA")
+	if salted {
+		scheme = scheme[1:]
+	}
+
+	var hashFunc func() hash.Hash
+	switch scheme {
+	case "SHA":
+		hashFunc = sha1.New
+	case "SHA256":
+		hashFunc = sha256.New
+	case "SHA512":
+		hashFunc = sha512.New
+	default:
+		return false
+	}
+
+	decoded, err := base64.StdEncoding.DecodeString(hashedPassword[end+1:])
+	if err != nil {
+		return false
+	}
+
+	h := hashFunc()
+	size := h.Size()
+	if len(decoded) < size || (!salted && len(decoded) != size) {
+		return false
+	}
+	digest, salt := decoded[:size], decoded[size:]
+
+	h.Write([]byte(password))
+	h.Write(salt)
+	return subtle.ConstantTimeCompare(digest, h.Sum(nil)) == 1
+}
+
+// decodeHashBase64 decodes base64 with or without padding, including the "adapted" alphabet
+// of passlib, that uses '.' instead of '+'
+func decodeHashBase64(s string) ([]byte, error) {
+	s = strings.TrimRight(strings.ReplaceAll(s, ".", "+"), "=")
+	decoded, err := base64.RawStdEncoding.DecodeString(s)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to decode base64")
+	}
+	return decoded, nil
+}
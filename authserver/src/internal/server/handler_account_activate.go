@@ -84,18 +84,20 @@ func (s *Server) handleAccountActivateGet(userCreator userCreator) http.HandlerF
 			return
 		}
 
-		lib.LogAudit(constants.AuditCreatedUser, map[string]interface{}{
+		lib.LogAudit(r.Context(), constants.AuditCreatedUser, map[string]interface{}{
 			"email": createdUser.Email,
 		})
+		s.publishUserEvent(constants.WebhookEventUserCreated, createdUser, nil)
 
 		err = s.database.DeletePreRegistration(nil, preRegistration.Id)
 		if err != nil {
 			s.internalServerError(w, r, err)
 		}
 
-		lib.LogAudit(constants.AuditActivatedAccount, map[string]interface{}{
+		lib.LogAudit(r.Context(), constants.AuditActivatedAccount, map[string]interface{}{
 			"email": createdUser.Email,
 		})
+		s.publishUserEvent(constants.WebhookEventUserActivated, createdUser, nil)
 
 		bind := map[string]interface{}{}
 
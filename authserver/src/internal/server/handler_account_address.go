@@ -171,10 +171,11 @@ func (s *Server) handleAccountAddressPost(addressValidator addressValidator,
 			return
 		}
 
-		lib.LogAudit(constants.AuditUpdatedUserAddress, map[string]interface{}{
+		lib.LogAudit(r.Context(), constants.AuditUpdatedUserAddress, map[string]interface{}{
 			"userId":       user.Id,
 			"loggedInUser": s.getLoggedInSubject(r),
 		})
+		s.publishUserEvent(constants.WebhookEventUserUpdated, user, nil)
 
 		http.Redirect(w, r, lib.GetBaseUrl()+"/account/address", http.StatusFound)
 	}
@@ -2,8 +2,10 @@ package server
 
 import (
 	"database/sql"
+	"fmt"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/gorilla/csrf"
 	"github.com/leodip/goiabada/internal/common"
@@ -29,7 +31,7 @@ func (s *Server) handleAccountChangePasswordGet() http.HandlerFunc {
 	}
 }
 
-func (s *Server) handleAccountChangePasswordPost(passwordValidator passwordValidator) http.HandlerFunc {
+func (s *Server) handleAccountChangePasswordPost(passwordValidator passwordValidator, userPasswordManager userPasswordManager) http.HandlerFunc {
 
 	return func(w http.ResponseWriter, r *http.Request) {
 
@@ -75,6 +77,13 @@ func (s *Server) handleAccountChangePasswordPost(passwordValidator passwordValid
 			return
 		}
 
+		passwordChangeAllowedAt := userPasswordManager.GetPasswordChangeAllowedAt(r.Context(), user)
+		if !passwordChangeAllowedAt.IsZero() {
+			renderError(fmt.Sprintf("Your password was changed recently. As per our policy, you can change it again after %v.",
+				passwordChangeAllowedAt.Format(time.RFC1123)))
+			return
+		}
+
 		if len(strings.TrimSpace(newPassword)) == 0 {
 			renderError("New password is required.")
 			return
@@ -85,18 +94,17 @@ func (s *Server) handleAccountChangePasswordPost(passwordValidator passwordValid
 			return
 		}
 
-		err = passwordValidator.ValidatePassword(r.Context(), newPassword)
+		err = passwordValidator.ValidatePassword(r.Context(), newPassword, user)
 		if err != nil {
 			renderError(err.Error())
 			return
 		}
 
-		passwordHash, err := lib.HashPassword(newPassword)
+		err = userPasswordManager.SetPassword(r.Context(), user, newPassword)
 		if err != nil {
 			s.internalServerError(w, r, err)
 			return
 		}
-		user.PasswordHash = passwordHash
 		user.ForgotPasswordCodeEncrypted = nil
 		user.ForgotPasswordCodeIssuedAt = sql.NullTime{Valid: false}
 		err = s.database.UpdateUser(nil, user)
@@ -105,10 +113,11 @@ func (s *Server) handleAccountChangePasswordPost(passwordValidator passwordValid
 			return
 		}
 
-		lib.LogAudit(constants.AuditChangedPassword, map[string]interface{}{
+		lib.LogAudit(r.Context(), constants.AuditChangedPassword, map[string]interface{}{
 			"userId":       user.Id,
 			"loggedInUser": s.getLoggedInSubject(r),
 		})
+		s.sendSecurityNotification(r, user, constants.AuditChangedPassword)
 
 		bind := map[string]interface{}{
 			"savedSuccessfully": true,
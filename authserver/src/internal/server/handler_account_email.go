@@ -225,7 +225,7 @@ func (s *Server) handleAccountEmailVerifyGet() http.HandlerFunc {
 			return
 		}
 
-		lib.LogAudit(constants.AuditVerifiedEmail, map[string]interface{}{
+		lib.LogAudit(r.Context(), constants.AuditVerifiedEmail, map[string]interface{}{
 			"userId":       user.Id,
 			"loggedInUser": s.getLoggedInSubject(r),
 		})
@@ -286,6 +286,7 @@ func (s *Server) handleAccountEmailPost(emailValidator emailValidator, inputSani
 		}
 
 		if input.Email != user.Email {
+			oldEmail := user.Email
 			user.Email = inputSanitizer.Sanitize(input.Email)
 			user.EmailVerified = false
 			user.EmailVerificationCodeEncrypted = nil
@@ -310,10 +311,13 @@ func (s *Server) handleAccountEmailPost(emailValidator emailValidator, inputSani
 				return
 			}
 
-			lib.LogAudit(constants.AuditUpdatedUserEmail, map[string]interface{}{
+			lib.LogAudit(r.Context(), constants.AuditUpdatedUserEmail, map[string]interface{}{
 				"userId":       user.Id,
 				"loggedInUser": s.getLoggedInSubject(r),
 			})
+			s.publishUserEvent(constants.WebhookEventUserUpdated, user, nil)
+
+			s.notifyEmailChange(r, user, oldEmail)
 		}
 
 		http.Redirect(w, r, lib.GetBaseUrl()+"/account/email", http.StatusFound)
@@ -221,7 +221,7 @@ func (s *Server) handleAccountLogoutGet() http.HandlerFunc {
 						return
 					}
 
-					lib.LogAudit(constants.AuditDeletedUserSessionClient, map[string]interface{}{
+					lib.LogAudit(r.Context(), constants.AuditDeletedUserSessionClient, map[string]interface{}{
 						"userId":        userSession.UserId,
 						"userSessionId": userSession.Id,
 						"clientId":      userSessionClient.Client.Id,
@@ -236,7 +236,7 @@ func (s *Server) handleAccountLogoutGet() http.HandlerFunc {
 							return
 						}
 
-						lib.LogAudit(constants.AuditLogout, map[string]interface{}{
+						lib.LogAudit(r.Context(), constants.AuditLogout, map[string]interface{}{
 							"userId":            userSession.UserId,
 							"sessionIdentifier": sessionIdentifier,
 							"loggedInUser":      s.getLoggedInSubject(r),
@@ -302,7 +302,7 @@ func (s *Server) handleAccountLogoutPost() http.HandlerFunc {
 			return
 		}
 
-		lib.LogAudit(constants.AuditLogout, map[string]interface{}{
+		lib.LogAudit(r.Context(), constants.AuditLogout, map[string]interface{}{
 			"userId":            userId,
 			"sessionIdentifier": sessionIdentifier,
 			"loggedInUser":      s.getLoggedInSubject(r),
@@ -138,7 +138,7 @@ func (s *Server) handleAccountManageConsentsRevokePost() http.HandlerFunc {
 				return
 			}
 
-			lib.LogAudit(constants.AuditDeletedUserConsent, map[string]interface{}{
+			lib.LogAudit(r.Context(), constants.AuditDeletedUserConsent, map[string]interface{}{
 				"userId":       user.Id,
 				"consentId":    int64(consentId),
 				"loggedInUser": s.getLoggedInSubject(r),
@@ -0,0 +1,124 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/gorilla/csrf"
+	"github.com/leodip/goiabada/internal/common"
+	"github.com/leodip/goiabada/internal/constants"
+	"github.com/leodip/goiabada/internal/dtos"
+	"github.com/leodip/goiabada/internal/lib"
+)
+
+func (s *Server) handleAccountNotificationsGet() http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		var jwtInfo dtos.JwtInfo
+		if r.Context().Value(common.ContextKeyJwtInfo) != nil {
+			jwtInfo = r.Context().Value(common.ContextKeyJwtInfo).(dtos.JwtInfo)
+		}
+
+		sub, err := jwtInfo.IdToken.Claims.GetSubject()
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+		user, err := s.database.GetUserBySubject(nil, sub)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		sess, err := s.sessionStore.Get(r, common.SessionName)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		savedSuccessfully := sess.Flashes("savedSuccessfully")
+		if savedSuccessfully != nil {
+			err = sess.Save(r, w)
+			if err != nil {
+				s.internalServerError(w, r, err)
+				return
+			}
+		}
+
+		notifications := struct {
+			PasswordChanged bool
+			EmailChanged    bool
+			OTPChanged      bool
+			NewDeviceLogin  bool
+		}{
+			PasswordChanged: !user.DisablePasswordChangeNotification,
+			EmailChanged:    !user.DisableEmailChangeNotification,
+			OTPChanged:      !user.DisableOTPChangeNotification,
+			NewDeviceLogin:  !user.DisableNewDeviceNotification,
+		}
+
+		bind := map[string]interface{}{
+			"notifications":     notifications,
+			"savedSuccessfully": len(savedSuccessfully) > 0,
+			"csrfField":         csrf.TemplateField(r),
+		}
+
+		err = s.renderTemplate(w, r, "/layouts/menu_layout.html", "/account_notifications.html", bind)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+	}
+}
+
+func (s *Server) handleAccountNotificationsPost() http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		var jwtInfo dtos.JwtInfo
+		if r.Context().Value(common.ContextKeyJwtInfo) != nil {
+			jwtInfo = r.Context().Value(common.ContextKeyJwtInfo).(dtos.JwtInfo)
+		}
+
+		sub, err := jwtInfo.IdToken.Claims.GetSubject()
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+		user, err := s.database.GetUserBySubject(nil, sub)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		user.DisablePasswordChangeNotification = r.FormValue("passwordChanged") != "on"
+		user.DisableEmailChangeNotification = r.FormValue("emailChanged") != "on"
+		user.DisableOTPChangeNotification = r.FormValue("otpChanged") != "on"
+		user.DisableNewDeviceNotification = r.FormValue("newDeviceLogin") != "on"
+
+		err = s.database.UpdateUser(nil, user)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		sess, err := s.sessionStore.Get(r, common.SessionName)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+		sess.AddFlash("true", "savedSuccessfully")
+		err = sess.Save(r, w)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		lib.LogAudit(r.Context(), constants.AuditUpdatedUserNotifications, map[string]interface{}{
+			"userId":       user.Id,
+			"loggedInUser": s.getLoggedInSubject(r),
+		})
+
+		http.Redirect(w, r, lib.GetBaseUrl()+"/account/notifications", http.StatusFound)
+	}
+}
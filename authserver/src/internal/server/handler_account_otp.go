@@ -33,8 +33,9 @@ func (s *Server) handleAccountOtpGet(otpSecretGenerator otpSecretGenerator) http
 		}
 
 		bind := map[string]interface{}{
-			"otpEnabled": user.OTPEnabled,
-			"csrfField":  csrf.TemplateField(r),
+			"otpEnabled":             user.OTPEnabled,
+			"recoveryCodesRemaining": user.GetOTPRecoveryCodesRemaining(),
+			"csrfField":              csrf.TemplateField(r),
 		}
 
 		if !user.OTPEnabled {
@@ -72,7 +73,7 @@ func (s *Server) handleAccountOtpGet(otpSecretGenerator otpSecretGenerator) http
 	}
 }
 
-func (s *Server) handleAccountOtpPost() http.HandlerFunc {
+func (s *Server) handleAccountOtpPost(otpRecoveryCodeManager otpRecoveryCodeManager) http.HandlerFunc {
 
 	return func(w http.ResponseWriter, r *http.Request) {
 
@@ -96,9 +97,10 @@ func (s *Server) handleAccountOtpPost() http.HandlerFunc {
 
 		renderError := func(message string, base64Image string, secretKey string) {
 			bind := map[string]interface{}{
-				"error":      message,
-				"otpEnabled": user.OTPEnabled,
-				"csrfField":  csrf.TemplateField(r),
+				"error":                  message,
+				"otpEnabled":             user.OTPEnabled,
+				"recoveryCodesRemaining": user.GetOTPRecoveryCodesRemaining(),
+				"csrfField":              csrf.TemplateField(r),
 			}
 
 			if len(base64Image) > 0 {
@@ -124,11 +126,18 @@ func (s *Server) handleAccountOtpPost() http.HandlerFunc {
 			// disable OTP
 			user.OTPSecret = ""
 			user.OTPEnabled = false
+			user.OTPRecoveryCodesHashes = ""
 			err = s.database.UpdateUser(nil, user)
 			if err != nil {
 				s.internalServerError(w, r, err)
 				return
 			}
+
+			lib.LogAudit(r.Context(), constants.AuditDisabledOTP, map[string]interface{}{
+				"userId":       user.Id,
+				"loggedInUser": s.getLoggedInSubject(r),
+			})
+			s.sendSecurityNotification(r, user, constants.AuditDisabledOTP)
 		} else {
 			// enable OTP
 
@@ -166,18 +175,111 @@ func (s *Server) handleAccountOtpPost() http.HandlerFunc {
 			// save OTP secret
 			user.OTPSecret = secretKey
 			user.OTPEnabled = true
+			recoveryCodes, err := otpRecoveryCodeManager.GenerateRecoveryCodes(user)
+			if err != nil {
+				s.internalServerError(w, r, err)
+				return
+			}
 			err = s.database.UpdateUser(nil, user)
 			if err != nil {
 				s.internalServerError(w, r, err)
 				return
 			}
 
-			lib.LogAudit(constants.AuditEnrolledOTP, map[string]interface{}{
+			lib.LogAudit(r.Context(), constants.AuditEnrolledOTP, map[string]interface{}{
 				"userId":       user.Id,
 				"loggedInUser": s.getLoggedInSubject(r),
 			})
+			s.sendSecurityNotification(r, user, constants.AuditEnrolledOTP)
+
+			lib.LogAudit(r.Context(), constants.AuditGeneratedOTPRecoveryCodes, map[string]interface{}{
+				"userId":       user.Id,
+				"loggedInUser": s.getLoggedInSubject(r),
+			})
+
+			// the recovery codes are only displayed once
+			s.renderAccountOtpRecoveryCodes(w, r, user, recoveryCodes)
+			return
 		}
 
 		http.Redirect(w, r, lib.GetBaseUrl()+"/account/otp", http.StatusFound)
 	}
 }
+
+func (s *Server) handleAccountOtpRecoveryCodesPost(otpRecoveryCodeManager otpRecoveryCodeManager) http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		var jwtInfo dtos.JwtInfo
+		if r.Context().Value(common.ContextKeyJwtInfo) != nil {
+			jwtInfo = r.Context().Value(common.ContextKeyJwtInfo).(dtos.JwtInfo)
+		}
+
+		sub, err := jwtInfo.IdToken.Claims.GetSubject()
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+		user, err := s.database.GetUserBySubject(nil, sub)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		if !user.OTPEnabled {
+			http.Redirect(w, r, lib.GetBaseUrl()+"/account/otp", http.StatusFound)
+			return
+		}
+
+		// the code from the authenticator app is required instead of the password, which federated and
+		// passwordless users don't have
+		otpCode := r.FormValue("otp")
+		if len(otpCode) == 0 || !totp.Validate(otpCode, user.OTPSecret) {
+			bind := map[string]interface{}{
+				"error":                  "Authentication failed. Check the code from your authenticator app and try again.",
+				"otpEnabled":             user.OTPEnabled,
+				"recoveryCodesRemaining": user.GetOTPRecoveryCodesRemaining(),
+				"csrfField":              csrf.TemplateField(r),
+			}
+
+			err := s.renderTemplate(w, r, "/layouts/menu_layout.html", "/account_otp.html", bind)
+			if err != nil {
+				s.internalServerError(w, r, err)
+			}
+			return
+		}
+
+		recoveryCodes, err := otpRecoveryCodeManager.GenerateRecoveryCodes(user)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+		err = s.database.UpdateUser(nil, user)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		lib.LogAudit(r.Context(), constants.AuditGeneratedOTPRecoveryCodes, map[string]interface{}{
+			"userId":       user.Id,
+			"loggedInUser": s.getLoggedInSubject(r),
+		})
+
+		s.renderAccountOtpRecoveryCodes(w, r, user, recoveryCodes)
+	}
+}
+
+func (s *Server) renderAccountOtpRecoveryCodes(w http.ResponseWriter, r *http.Request, user *entities.User, recoveryCodes []string) {
+
+	bind := map[string]interface{}{
+		"otpEnabled":             user.OTPEnabled,
+		"recoveryCodes":          recoveryCodes,
+		"recoveryCodesRemaining": user.GetOTPRecoveryCodesRemaining(),
+		"csrfField":              csrf.TemplateField(r),
+	}
+
+	err := s.renderTemplate(w, r, "/layouts/menu_layout.html", "/account_otp.html", bind)
+	if err != nil {
+		s.internalServerError(w, r, err)
+	}
+}
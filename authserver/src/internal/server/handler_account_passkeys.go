@@ -0,0 +1,256 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/csrf"
+	"github.com/leodip/goiabada/internal/common"
+	"github.com/leodip/goiabada/internal/constants"
+	core_webauthn "github.com/leodip/goiabada/internal/core/webauthn"
+	"github.com/leodip/goiabada/internal/customerrors"
+	"github.com/leodip/goiabada/internal/dtos"
+	"github.com/leodip/goiabada/internal/entities"
+	"github.com/leodip/goiabada/internal/lib"
+	"github.com/pkg/errors"
+)
+
+const maxLengthPasskeyName = 64
+
+func (s *Server) handleAccountPasskeysGet() http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		user, err := s.getAccountUserWithPasskeys(r)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		type passkeyInfo struct {
+			Id         int64
+			Name       string
+			CreatedAt  string
+			LastUsedAt string
+			Synced     bool
+		}
+
+		passkeys := make([]passkeyInfo, 0, len(user.Passkeys))
+		for _, passkey := range user.Passkeys {
+			info := passkeyInfo{
+				Id:        passkey.Id,
+				Name:      passkey.Name,
+				CreatedAt: passkey.CreatedAt.Time.Format("02 Jan 2006 15:04:05 MST"),
+				Synced:    passkey.BackupState,
+			}
+			if passkey.LastUsedAt.Valid {
+				info.LastUsedAt = passkey.LastUsedAt.Time.Format("02 Jan 2006 15:04:05 MST")
+			}
+			passkeys = append(passkeys, info)
+		}
+
+		bind := map[string]interface{}{
+			"passkeys":  passkeys,
+			"csrfField": csrf.TemplateField(r),
+		}
+
+		err = s.renderTemplate(w, r, "/layouts/menu_layout.html", "/account_passkeys.html", bind)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+	}
+}
+
+func (s *Server) handleAccountPasskeysRegisterBeginPost(passkeyManager passkeyManager) http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		user, err := s.getAccountUserWithPasskeys(r)
+		if err != nil {
+			s.jsonError(w, r, err)
+			return
+		}
+
+		var data struct {
+			Name     string `json:"name"`
+			Password string `json:"password"`
+		}
+		err = json.NewDecoder(r.Body).Decode(&data)
+		if err != nil {
+			s.jsonError(w, r, errors.Wrap(err, "could not decode request body"))
+			return
+		}
+
+		name := strings.TrimSpace(data.Name)
+		if len(name) == 0 {
+			s.jsonError(w, r, customerrors.NewValidationError("", "Please enter a name for the passkey."))
+			return
+		}
+		if len(name) > maxLengthPasskeyName {
+			s.jsonError(w, r, customerrors.NewValidationError("", "The name cannot exceed a maximum length of "+strconv.Itoa(maxLengthPasskeyName)+" characters."))
+			return
+		}
+
+		if !lib.VerifyPasswordHash(user.PasswordHash, data.Password) {
+			s.jsonError(w, r, customerrors.NewValidationError("", "Authentication failed. Check your password and try again."))
+			return
+		}
+
+		options, ceremony, err := passkeyManager.BeginRegistration(r.Context(), user)
+		if err != nil {
+			s.jsonError(w, r, err)
+			return
+		}
+
+		sess, err := s.sessionStore.Get(r, common.SessionName)
+		if err != nil {
+			s.jsonError(w, r, err)
+			return
+		}
+		sess.Values[common.SessionKeyPasskeyCeremony] = ceremony
+		sess.Values[common.SessionKeyPasskeyName] = name
+		err = sess.Save(r, w)
+		if err != nil {
+			s.jsonError(w, r, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(options)
+	}
+}
+
+func (s *Server) handleAccountPasskeysRegisterFinishPost(passkeyManager passkeyManager) http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		user, err := s.getAccountUserWithPasskeys(r)
+		if err != nil {
+			s.jsonError(w, r, err)
+			return
+		}
+
+		ceremony, err := s.popPasskeyCeremony(w, r)
+		if err != nil {
+			s.jsonError(w, r, err)
+			return
+		}
+
+		sess, err := s.sessionStore.Get(r, common.SessionName)
+		if err != nil {
+			s.jsonError(w, r, err)
+			return
+		}
+		name, _ := sess.Values[common.SessionKeyPasskeyName].(string)
+		delete(sess.Values, common.SessionKeyPasskeyName)
+		err = sess.Save(r, w)
+		if err != nil {
+			s.jsonError(w, r, err)
+			return
+		}
+
+		passkey, err := passkeyManager.FinishRegistration(r.Context(), user, ceremony, name, r)
+		if err != nil {
+			if errors.Is(err, core_webauthn.ErrPasskeyVerificationFailed) {
+				s.jsonError(w, r, customerrors.NewValidationError("", "The passkey could not be registered. Please try again."))
+				return
+			}
+			s.jsonError(w, r, err)
+			return
+		}
+
+		lib.LogAudit(r.Context(), constants.AuditRegisteredPasskey, map[string]interface{}{
+			"userId":       user.Id,
+			"passkeyId":    passkey.Id,
+			"loggedInUser": s.getLoggedInSubject(r),
+		})
+
+		result := struct {
+			Success bool
+		}{
+			Success: true,
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(result)
+	}
+}
+
+func (s *Server) handleAccountPasskeysDeletePost() http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		user, err := s.getAccountUserWithPasskeys(r)
+		if err != nil {
+			s.jsonError(w, r, err)
+			return
+		}
+
+		var data map[string]interface{}
+		decoder := json.NewDecoder(r.Body)
+		if err := decoder.Decode(&data); err != nil {
+			s.jsonError(w, r, errors.Wrap(err, "could not decode request body"))
+			return
+		}
+
+		passkeyId, ok := data["passkeyId"].(float64)
+		if !ok || passkeyId == 0 {
+			s.jsonError(w, r, errors.WithStack(errors.New("could not find passkey id to delete")))
+			return
+		}
+
+		for _, passkey := range user.Passkeys {
+			if passkey.Id == int64(passkeyId) {
+				err := s.database.DeleteUserPasskey(nil, passkey.Id)
+				if err != nil {
+					s.jsonError(w, r, err)
+					return
+				}
+
+				lib.LogAudit(r.Context(), constants.AuditDeletedPasskey, map[string]interface{}{
+					"userId":       user.Id,
+					"passkeyId":    passkey.Id,
+					"loggedInUser": s.getLoggedInSubject(r),
+				})
+
+				result := struct {
+					Success bool
+				}{
+					Success: true,
+				}
+				w.Header().Set("Content-Type", "application/json")
+				json.NewEncoder(w).Encode(result)
+				return
+			}
+		}
+
+		s.jsonError(w, r, errors.WithStack(errors.New("the passkey does not belong to the user")))
+	}
+}
+
+func (s *Server) getAccountUserWithPasskeys(r *http.Request) (*entities.User, error) {
+	var jwtInfo dtos.JwtInfo
+	if r.Context().Value(common.ContextKeyJwtInfo) != nil {
+		jwtInfo = r.Context().Value(common.ContextKeyJwtInfo).(dtos.JwtInfo)
+	}
+
+	sub, err := jwtInfo.IdToken.Claims.GetSubject()
+	if err != nil {
+		return nil, err
+	}
+	user, err := s.database.GetUserBySubject(nil, sub)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, errors.WithStack(errors.New("user not found"))
+	}
+
+	err = s.database.UserLoadPasskeys(nil, user)
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
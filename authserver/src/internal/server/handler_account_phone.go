@@ -195,7 +195,7 @@ func (s *Server) handleAccountPhoneVerifyPost() http.HandlerFunc {
 			return
 		}
 
-		lib.LogAudit(constants.AuditVerifiedPhone, map[string]interface{}{
+		lib.LogAudit(r.Context(), constants.AuditVerifiedPhone, map[string]interface{}{
 			"userId":       user.Id,
 			"loggedInUser": s.getLoggedInSubject(r),
 		})
@@ -281,7 +281,7 @@ func (s *Server) handleAccountPhoneSendVerificationPost(smsSender smsSender) htt
 			return
 		}
 
-		lib.LogAudit(constants.AuditSentPhoneVerificationMessage, map[string]interface{}{
+		lib.LogAudit(r.Context(), constants.AuditSentPhoneVerificationMessage, map[string]interface{}{
 			"userId":       user.Id,
 			"loggedInUser": s.getLoggedInSubject(r),
 		})
@@ -368,10 +368,11 @@ func (s *Server) handleAccountPhonePost(phoneValidator phoneValidator) http.Hand
 			return
 		}
 
-		lib.LogAudit(constants.AuditUpdatedUserPhone, map[string]interface{}{
+		lib.LogAudit(r.Context(), constants.AuditUpdatedUserPhone, map[string]interface{}{
 			"userId":       user.Id,
 			"loggedInUser": s.getLoggedInSubject(r),
 		})
+		s.publishUserEvent(constants.WebhookEventUserUpdated, user, nil)
 
 		http.Redirect(w, r, lib.GetBaseUrl()+"/account/phone", http.StatusFound)
 	}
@@ -204,10 +204,11 @@ func (s *Server) handleAccountProfilePost(profileValidator profileValidator, inp
 			return
 		}
 
-		lib.LogAudit(constants.AuditUpdatedUserProfile, map[string]interface{}{
+		lib.LogAudit(r.Context(), constants.AuditUpdatedUserProfile, map[string]interface{}{
 			"userId":       user.Id,
 			"loggedInUser": s.getLoggedInSubject(r),
 		})
+		s.publishUserEvent(constants.WebhookEventUserUpdated, user, nil)
 
 		http.Redirect(w, r, lib.GetBaseUrl()+"/account/profile", http.StatusFound)
 	}
@@ -115,7 +115,7 @@ func (s *Server) handleAccountRegisterPost(userCreator userCreator, emailValidat
 			return
 		}
 
-		err = passwordValidator.ValidatePassword(r.Context(), password)
+		err = passwordValidator.ValidatePassword(r.Context(), password, &entities.User{Email: email})
 		if err != nil {
 			renderError(err.Error())
 			return
@@ -155,7 +155,7 @@ func (s *Server) handleAccountRegisterPost(userCreator userCreator, emailValidat
 				return
 			}
 
-			lib.LogAudit(constants.AuditCreatedPreRegistration, map[string]interface{}{
+			lib.LogAudit(r.Context(), constants.AuditCreatedPreRegistration, map[string]interface{}{
 				"email": preRegistration.Email,
 			})
 
@@ -195,7 +195,7 @@ func (s *Server) handleAccountRegisterPost(userCreator userCreator, emailValidat
 				return
 			}
 
-			_, err = userCreator.CreateUser(r.Context(), &core.CreateUserInput{
+			createdUser, err := userCreator.CreateUser(r.Context(), &core.CreateUserInput{
 				Email:         email,
 				EmailVerified: false,
 				PasswordHash:  passwordHash,
@@ -205,9 +205,10 @@ func (s *Server) handleAccountRegisterPost(userCreator userCreator, emailValidat
 				return
 			}
 
-			lib.LogAudit(constants.AuditCreatedUser, map[string]interface{}{
+			lib.LogAudit(r.Context(), constants.AuditCreatedUser, map[string]interface{}{
 				"email": email,
 			})
+			s.publishUserEvent(constants.WebhookEventUserCreated, createdUser, nil)
 
 			if settings.SMTPEnabled {
 				bind := map[string]interface{}{
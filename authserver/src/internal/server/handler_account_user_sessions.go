@@ -32,6 +32,18 @@ func (s *Server) handleAccountSessionsGet() http.HandlerFunc {
 		Clients                   []string
 	}
 
+	type trustedDeviceInfo struct {
+		TrustedDeviceId int64
+		IsCurrent       bool
+		TrustedAt       string
+		ExpiresAt       string
+		LastUsedAt      string
+		IpAddress       string
+		DeviceName      string
+		DeviceType      string
+		DeviceOS        string
+	}
+
 	return func(w http.ResponseWriter, r *http.Request) {
 
 		settings := r.Context().Value(common.ContextKeySettings).(*entities.Settings)
@@ -107,9 +119,47 @@ func (s *Server) handleAccountSessionsGet() http.HandlerFunc {
 			return sessionInfoArr[i].UserSessionId > sessionInfoArr[j].UserSessionId
 		})
 
+		trustedDevices, err := s.database.GetUserTrustedDevicesByUserId(nil, user.Id)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		currentTrustedDevice, err := s.getTrustedDevice(r, user.Id)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		trustedDeviceInfoArr := []trustedDeviceInfo{}
+		for _, td := range trustedDevices {
+			if td.IsExpired() {
+				continue
+			}
+			tdi := trustedDeviceInfo{
+				TrustedDeviceId: td.Id,
+				IsCurrent:       currentTrustedDevice != nil && currentTrustedDevice.Id == td.Id,
+				TrustedAt:       td.CreatedAt.Time.Format(time.RFC1123),
+				ExpiresAt:       td.ExpiresAt.Format(time.RFC1123),
+				IpAddress:       td.IpAddress,
+				DeviceName:      td.DeviceName,
+				DeviceType:      td.DeviceType,
+				DeviceOS:        td.DeviceOS,
+			}
+			if td.LastUsedAt.Valid {
+				tdi.LastUsedAt = td.LastUsedAt.Time.Format(time.RFC1123)
+			}
+			trustedDeviceInfoArr = append(trustedDeviceInfoArr, tdi)
+		}
+
+		sort.Slice(trustedDeviceInfoArr, func(i, j int) bool {
+			return trustedDeviceInfoArr[i].TrustedDeviceId > trustedDeviceInfoArr[j].TrustedDeviceId
+		})
+
 		bind := map[string]interface{}{
-			"sessions":  sessionInfoArr,
-			"csrfField": csrf.TemplateField(r),
+			"sessions":       sessionInfoArr,
+			"trustedDevices": trustedDeviceInfoArr,
+			"csrfField":      csrf.TemplateField(r),
 		}
 
 		err = s.renderTemplate(w, r, "/layouts/menu_layout.html", "/account_user_sessions.html", bind)
@@ -167,7 +217,7 @@ func (s *Server) handleAccountSessionsEndSesssionPost() http.HandlerFunc {
 					return
 				}
 
-				lib.LogAudit(constants.AuditDeletedUserSession, map[string]interface{}{
+				lib.LogAudit(r.Context(), constants.AuditDeletedUserSession, map[string]interface{}{
 					"userSessionId": us.Id,
 					"loggedInUser":  s.getLoggedInSubject(r),
 				})
@@ -184,3 +234,52 @@ func (s *Server) handleAccountSessionsEndSesssionPost() http.HandlerFunc {
 		}
 	}
 }
+
+func (s *Server) handleAccountTrustedDevicesRevokePost() http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		var jwtInfo dtos.JwtInfo
+		if r.Context().Value(common.ContextKeyJwtInfo) != nil {
+			jwtInfo = r.Context().Value(common.ContextKeyJwtInfo).(dtos.JwtInfo)
+		}
+
+		sub, err := jwtInfo.IdToken.Claims.GetSubject()
+		if err != nil {
+			s.jsonError(w, r, err)
+			return
+		}
+		user, err := s.database.GetUserBySubject(nil, sub)
+		if err != nil {
+			s.jsonError(w, r, err)
+			return
+		}
+
+		var data map[string]interface{}
+		decoder := json.NewDecoder(r.Body)
+		if err := decoder.Decode(&data); err != nil {
+			s.jsonError(w, r, errors.Wrap(err, "could not decode request body"))
+			return
+		}
+
+		trustedDeviceId, ok := data["trustedDeviceId"].(float64)
+		if !ok || trustedDeviceId == 0 {
+			s.jsonError(w, r, errors.WithStack(errors.New("could not find trusted device id to revoke")))
+			return
+		}
+
+		err = s.revokeTrustedDevice(r, user, int64(trustedDeviceId))
+		if err != nil {
+			s.jsonError(w, r, err)
+			return
+		}
+
+		result := struct {
+			Success bool
+		}{
+			Success: true,
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(result)
+	}
+}
@@ -0,0 +1,284 @@
+package server
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/leodip/goiabada/internal/constants"
+	"github.com/leodip/goiabada/internal/entities"
+	"github.com/leodip/goiabada/internal/lib"
+	"github.com/unknwon/paginater"
+)
+
+const auditDateLayout = "2006-01-02"
+
+type auditFilterInput struct {
+	EventType string
+	User      string
+	Client    string
+	From      string
+	To        string
+}
+
+// queryString returns the filter as a query string, to keep it in the links of the page.
+func (f auditFilterInput) queryString() string {
+	query := url.Values{}
+	for key, value := range map[string]string{
+		"eventType": f.EventType,
+		"user":      f.User,
+		"client":    f.Client,
+		"from":      f.From,
+		"to":        f.To,
+	} {
+		if len(value) > 0 {
+			query.Set(key, value)
+		}
+	}
+	return query.Encode()
+}
+
+func (s *Server) getAuditEventFilter(r *http.Request) (auditFilterInput, *entities.AuditEventFilter, string, error) {
+
+	input := auditFilterInput{
+		EventType: strings.TrimSpace(r.URL.Query().Get("eventType")),
+		User:      strings.TrimSpace(r.URL.Query().Get("user")),
+		Client:    strings.TrimSpace(r.URL.Query().Get("client")),
+		From:      strings.TrimSpace(r.URL.Query().Get("from")),
+		To:        strings.TrimSpace(r.URL.Query().Get("to")),
+	}
+
+	filter := &entities.AuditEventFilter{
+		EventType: input.EventType,
+	}
+
+	if len(input.User) > 0 {
+		// the user can be searched by email, username or subject
+		user, err := s.database.GetUserByEmail(nil, input.User)
+		if err != nil {
+			return input, nil, "", err
+		}
+		if user == nil {
+			user, err = s.database.GetUserByUsername(nil, input.User)
+			if err != nil {
+				return input, nil, "", err
+			}
+		}
+		if user == nil {
+			user, err = s.database.GetUserBySubject(nil, input.User)
+			if err != nil {
+				return input, nil, "", err
+			}
+		}
+		if user != nil {
+			filter.UserId = user.Id
+			filter.UserSubject = user.Subject.String()
+		} else {
+			// the user may have been deleted, their events are found by subject
+			filter.UserSubject = input.User
+		}
+	}
+
+	if len(input.Client) > 0 {
+		client, err := s.database.GetClientByClientIdentifier(nil, input.Client)
+		if err != nil {
+			return input, nil, "", err
+		}
+		if client != nil {
+			filter.ClientId = client.Id
+		}
+		filter.ClientIdentifier = input.Client
+	}
+
+	if len(input.From) > 0 {
+		from, err := time.Parse(auditDateLayout, input.From)
+		if err != nil {
+			return input, nil, "Invalid start date. Please use the format YYYY-MM-DD.", nil
+		}
+		filter.From = from
+	}
+
+	if len(input.To) > 0 {
+		to, err := time.Parse(auditDateLayout, input.To)
+		if err != nil {
+			return input, nil, "Invalid end date. Please use the format YYYY-MM-DD.", nil
+		}
+		// the end date is inclusive
+		filter.To = to.AddDate(0, 0, 1)
+	}
+
+	if !filter.From.IsZero() && !filter.To.IsZero() && !filter.From.Before(filter.To) {
+		return input, nil, "The start date must be before or equal to the end date.", nil
+	}
+
+	return input, filter, "", nil
+}
+
+func (s *Server) handleAdminAuditGet() http.HandlerFunc {
+
+	type auditEventResult struct {
+		CreatedAt    string
+		EventType    string
+		ActorSubject string
+		IpAddress    string
+		RequestId    string
+		Details      string
+	}
+
+	type pageResult struct {
+		AuditEvents []auditEventResult
+		Total       int
+		Page        int
+		PageSize    int
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		pageInt, err := strconv.Atoi(r.URL.Query().Get("page"))
+		if err != nil {
+			pageInt = 1
+		}
+		if pageInt < 1 {
+			pageInt = 1
+		}
+
+		eventTypes, err := s.database.GetAuditEventTypes(nil)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		input, filter, errorMessage, err := s.getAuditEventFilter(r)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		const pageSize = 20
+		result := pageResult{
+			AuditEvents: make([]auditEventResult, 0),
+			Page:        pageInt,
+			PageSize:    pageSize,
+		}
+
+		if filter != nil {
+			auditEvents, total, err := s.database.SearchAuditEventsPaginated(nil, filter, pageInt, pageSize)
+			if err != nil {
+				s.internalServerError(w, r, err)
+				return
+			}
+
+			for _, auditEvent := range auditEvents {
+				result.AuditEvents = append(result.AuditEvents, auditEventResult{
+					CreatedAt:    auditEvent.CreatedAt.Time.UTC().Format("2006-01-02 15:04:05"),
+					EventType:    auditEvent.EventType,
+					ActorSubject: auditEvent.ActorSubject,
+					IpAddress:    auditEvent.IpAddress,
+					RequestId:    auditEvent.RequestId,
+					Details:      auditEvent.Details,
+				})
+			}
+			result.Total = total
+		}
+
+		queryString := input.queryString()
+		paginatorLink := "/admin/audit"
+		exportLink := "/admin/audit/export"
+		if len(queryString) > 0 {
+			paginatorLink += "?" + queryString
+			exportLink += "?" + queryString
+		}
+
+		bind := map[string]interface{}{
+			"pageResult":    result,
+			"paginator":     paginater.New(result.Total, pageSize, pageInt, 5),
+			"paginatorLink": paginatorLink,
+			"exportLink":    exportLink,
+			"filter":        input,
+			"eventTypes":    eventTypes,
+			"error":         errorMessage,
+		}
+
+		err = s.renderTemplate(w, r, "/layouts/menu_layout.html", "/admin_audit.html", bind)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+	}
+}
+
+func (s *Server) handleAdminAuditExportGet() http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		_, filter, errorMessage, err := s.getAuditEventFilter(r)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+		if len(errorMessage) > 0 {
+			http.Error(w, errorMessage, http.StatusBadRequest)
+			return
+		}
+
+		var buf bytes.Buffer
+		csvWriter := csv.NewWriter(&buf)
+		err = csvWriter.Write([]string{"timestamp", "event_type", "actor_subject", "ip_address", "request_id", "details"})
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		const pageSize = 500
+		exported := 0
+		for page := 1; ; page++ {
+			auditEvents, total, err := s.database.SearchAuditEventsPaginated(nil, filter, page, pageSize)
+			if err != nil {
+				s.internalServerError(w, r, err)
+				return
+			}
+
+			for _, auditEvent := range auditEvents {
+				err = csvWriter.Write([]string{
+					auditEvent.CreatedAt.Time.UTC().Format(time.RFC3339),
+					auditEvent.EventType,
+					auditEvent.ActorSubject,
+					auditEvent.IpAddress,
+					auditEvent.RequestId,
+					auditEvent.Details,
+				})
+				if err != nil {
+					s.internalServerError(w, r, err)
+					return
+				}
+			}
+			exported += len(auditEvents)
+
+			if len(auditEvents) < pageSize || page*pageSize >= total {
+				break
+			}
+		}
+
+		csvWriter.Flush()
+		err = csvWriter.Error()
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		lib.LogAudit(r.Context(), constants.AuditExportedAuditEvents, map[string]interface{}{
+			"eventCount":   exported,
+			"loggedInUser": s.getLoggedInSubject(r),
+		})
+
+		fileName := fmt.Sprintf("audit-%v.csv", time.Now().UTC().Format("20060102"))
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", fileName))
+		w.Write(buf.Bytes())
+	}
+}
@@ -206,7 +206,7 @@ func (s *Server) handleAdminClientAuthenticationPost() http.HandlerFunc {
 			return
 		}
 
-		lib.LogAudit(constants.AuditUpdatedClientAuthentication, map[string]interface{}{
+		lib.LogAudit(r.Context(), constants.AuditUpdatedClientAuthentication, map[string]interface{}{
 			"clientId":     client.Id,
 			"loggedInUser": s.getLoggedInSubject(r),
 		})
@@ -111,7 +111,7 @@ func (s *Server) handleAdminClientDeletePost() http.HandlerFunc {
 			return
 		}
 
-		lib.LogAudit(constants.AuditDeletedClient, map[string]interface{}{
+		lib.LogAudit(r.Context(), constants.AuditDeletedClient, map[string]interface{}{
 			"clientId":         client.Id,
 			"clientIdentifier": client.ClientIdentifier,
 			"loggedInUser":     s.getLoggedInSubject(r),
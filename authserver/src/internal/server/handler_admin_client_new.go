@@ -114,7 +114,7 @@ func (s *Server) handleAdminClientNewPost(identifierValidator identifierValidato
 			return
 		}
 
-		lib.LogAudit(constants.AuditCreatedClient, map[string]interface{}{
+		lib.LogAudit(r.Context(), constants.AuditCreatedClient, map[string]interface{}{
 			"clientId":         client.Id,
 			"clientIdentifier": client.ClientIdentifier,
 			"loggedInUser":     s.getLoggedInSubject(r),
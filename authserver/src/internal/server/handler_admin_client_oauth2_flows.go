@@ -150,7 +150,7 @@ func (s *Server) handleAdminClientOAuth2Post() http.HandlerFunc {
 			return
 		}
 
-		lib.LogAudit(constants.AuditUpdatedClientOAuth2Flows, map[string]interface{}{
+		lib.LogAudit(r.Context(), constants.AuditUpdatedClientOAuth2Flows, map[string]interface{}{
 			"clientId":     client.Id,
 			"loggedInUser": s.getLoggedInSubject(r),
 		})
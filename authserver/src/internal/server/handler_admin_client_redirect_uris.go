@@ -216,7 +216,7 @@ func (s *Server) handleAdminClientRedirectURIsPost() http.HandlerFunc {
 			return
 		}
 
-		lib.LogAudit(constants.AuditUpdatedRedirectURIs, map[string]interface{}{
+		lib.LogAudit(r.Context(), constants.AuditUpdatedRedirectURIs, map[string]interface{}{
 			"clientId":     client.Id,
 			"loggedInUser": s.getLoggedInSubject(r),
 		})
@@ -209,7 +209,7 @@ func (s *Server) handleAdminClientUserSessionsPost() http.HandlerFunc {
 			return
 		}
 
-		lib.LogAudit(constants.AuditDeletedUserSession, map[string]interface{}{
+		lib.LogAudit(r.Context(), constants.AuditDeletedUserSession, map[string]interface{}{
 			"userSessionId": userSessionId,
 			"loggedInUser":  s.getLoggedInSubject(r),
 		})
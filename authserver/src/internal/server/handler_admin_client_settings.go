@@ -50,6 +50,8 @@ func (s *Server) handleAdminClientSettingsGet() http.HandlerFunc {
 			ConsentRequired          bool
 			AuthorizationCodeEnabled bool
 			DefaultAcrLevel          string
+			EmailLoginEnabled        bool
+			AcceptImpersonatedTokens bool
 			IsSystemLevelClient      bool
 		}{
 			ClientId:                 client.Id,
@@ -59,6 +61,8 @@ func (s *Server) handleAdminClientSettingsGet() http.HandlerFunc {
 			ConsentRequired:          client.ConsentRequired,
 			AuthorizationCodeEnabled: client.AuthorizationCodeEnabled,
 			DefaultAcrLevel:          client.DefaultAcrLevel.String(),
+			EmailLoginEnabled:        client.EmailLoginEnabled,
+			AcceptImpersonatedTokens: client.AcceptImpersonatedTokens,
 			IsSystemLevelClient:      client.IsSystemLevelClient(),
 		}
 
@@ -140,6 +144,8 @@ func (s *Server) handleAdminClientSettingsPost(identifierValidator identifierVal
 			ConsentRequired          bool
 			AuthorizationCodeEnabled bool
 			DefaultAcrLevel          string
+			EmailLoginEnabled        bool
+			AcceptImpersonatedTokens bool
 			IsSystemLevelClient      bool
 		}{
 			ClientId:                 id,
@@ -149,6 +155,8 @@ func (s *Server) handleAdminClientSettingsPost(identifierValidator identifierVal
 			ConsentRequired:          consentRequired,
 			AuthorizationCodeEnabled: client.AuthorizationCodeEnabled,
 			DefaultAcrLevel:          r.FormValue("defaultAcrLevel"),
+			EmailLoginEnabled:        r.FormValue("emailLoginEnabled") == "on",
+			AcceptImpersonatedTokens: r.FormValue("acceptImpersonatedTokens") == "on",
 			IsSystemLevelClient:      isSystemLevelClient,
 		}
 
@@ -205,6 +213,8 @@ func (s *Server) handleAdminClientSettingsPost(identifierValidator identifierVal
 				return
 			}
 			client.DefaultAcrLevel = acrLevel
+			client.EmailLoginEnabled = adminClientSettings.EmailLoginEnabled
+			client.AcceptImpersonatedTokens = adminClientSettings.AcceptImpersonatedTokens
 		}
 
 		err = s.database.UpdateClient(nil, client)
@@ -226,7 +236,7 @@ func (s *Server) handleAdminClientSettingsPost(identifierValidator identifierVal
 			return
 		}
 
-		lib.LogAudit(constants.AuditUpdatedClientSettings, map[string]interface{}{
+		lib.LogAudit(r.Context(), constants.AuditUpdatedClientSettings, map[string]interface{}{
 			"clientId":     client.Id,
 			"loggedInUser": s.getLoggedInSubject(r),
 		})
@@ -211,7 +211,7 @@ func (s *Server) handleAdminClientTokensPost() http.HandlerFunc {
 			return
 		}
 
-		lib.LogAudit(constants.AuditUpdatedClientTokens, map[string]interface{}{
+		lib.LogAudit(r.Context(), constants.AuditUpdatedClientTokens, map[string]interface{}{
 			"clientId":     client.Id,
 			"loggedInUser": s.getLoggedInSubject(r),
 		})
@@ -136,7 +136,7 @@ func (s *Server) handleAdminGroupAttributesAddPost(identifierValidator identifie
 			return
 		}
 
-		lib.LogAudit(constants.AuditAddedGroupAttribute, map[string]interface{}{
+		lib.LogAudit(r.Context(), constants.AuditAddedGroupAttribute, map[string]interface{}{
 			"groupAttributeId": groupAttribute.Id,
 			"groupId":          group.Id,
 			"groupIdentifier":  group.GroupIdentifier,
@@ -200,11 +200,12 @@ func (s *Server) handleAdminGroupMembersAddPost() http.HandlerFunc {
 			return
 		}
 
-		lib.LogAudit(constants.AuditUserAddedToGroup, map[string]interface{}{
+		lib.LogAudit(r.Context(), constants.AuditUserAddedToGroup, map[string]interface{}{
 			"userId":       user.Id,
 			"groupId":      group.Id,
 			"loggedInUser": s.getLoggedInSubject(r),
 		})
+		s.publishUserEvent(constants.WebhookEventUserAddedToGroup, user, group)
 
 		result := struct {
 			Success bool
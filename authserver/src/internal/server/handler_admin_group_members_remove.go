@@ -77,7 +77,7 @@ func (s *Server) handleAdminGroupMembersRemoveUserPost() http.HandlerFunc {
 			return
 		}
 
-		lib.LogAudit(constants.AuditUserRemovedFromGroup, map[string]interface{}{
+		lib.LogAudit(r.Context(), constants.AuditUserRemovedFromGroup, map[string]interface{}{
 			"userId":       user.Id,
 			"groupId":      group.Id,
 			"loggedInUser": s.getLoggedInSubject(r),
@@ -92,7 +92,7 @@ func (s *Server) handleAdminGroupNewPost(identifierValidator identifierValidator
 			return
 		}
 
-		lib.LogAudit(constants.AuditCreatedGroup, map[string]interface{}{
+		lib.LogAudit(r.Context(), constants.AuditCreatedGroup, map[string]interface{}{
 			"groupId":         group.Id,
 			"groupIdentifier": group.GroupIdentifier,
 			"loggedInUser":    s.getLoggedInSubject(r),
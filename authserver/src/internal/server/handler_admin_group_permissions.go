@@ -178,7 +178,7 @@ func (s *Server) handleAdminGroupPermissionsPost() http.HandlerFunc {
 					return
 				}
 
-				lib.LogAudit(constants.AuditAddedGroupPermission, map[string]interface{}{
+				lib.LogAudit(r.Context(), constants.AuditAddedGroupPermission, map[string]interface{}{
 					"groupId":      group.Id,
 					"permissionId": permission.Id,
 					"loggedInUser": s.getLoggedInSubject(r),
@@ -215,7 +215,7 @@ func (s *Server) handleAdminGroupPermissionsPost() http.HandlerFunc {
 				return
 			}
 
-			lib.LogAudit(constants.AuditDeletedGroupPermission, map[string]interface{}{
+			lib.LogAudit(r.Context(), constants.AuditDeletedGroupPermission, map[string]interface{}{
 				"groupId":      group.Id,
 				"permissionId": permissionId,
 				"loggedInUser": s.getLoggedInSubject(r),
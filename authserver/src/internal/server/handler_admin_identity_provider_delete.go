@@ -0,0 +1,83 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/csrf"
+	"github.com/leodip/goiabada/internal/constants"
+	"github.com/leodip/goiabada/internal/lib"
+)
+
+func (s *Server) handleAdminIdentityProviderDeleteGet() http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		identityProvider, err := s.getIdentityProviderFromURL(r)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		bind := map[string]interface{}{
+			"identityProvider": identityProvider,
+			"csrfField":        csrf.TemplateField(r),
+		}
+
+		err = s.renderTemplate(w, r, "/layouts/menu_layout.html", "/admin_identity_providers_delete.html", bind)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+	}
+}
+
+func (s *Server) handleAdminIdentityProviderDeletePost() http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		identityProvider, err := s.getIdentityProviderFromURL(r)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		renderError := func(message string) {
+			bind := map[string]interface{}{
+				"identityProvider": identityProvider,
+				"error":            message,
+				"csrfField":        csrf.TemplateField(r),
+			}
+
+			err := s.renderTemplate(w, r, "/layouts/menu_layout.html", "/admin_identity_providers_delete.html", bind)
+			if err != nil {
+				s.internalServerError(w, r, err)
+			}
+		}
+
+		identityProviderIdentifier := r.FormValue("identityProviderIdentifier")
+		if len(identityProviderIdentifier) == 0 {
+			renderError("Identity provider identifier is required.")
+			return
+		}
+
+		if identityProvider.IdentityProviderIdentifier != identityProviderIdentifier {
+			renderError("Identity provider identifier does not match the identity provider being deleted.")
+			return
+		}
+
+		err = s.database.DeleteIdentityProvider(nil, identityProvider.Id)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		lib.LogAudit(r.Context(), constants.AuditDeletedIdentityProvider, map[string]interface{}{
+			"identityProviderId":         identityProvider.Id,
+			"identityProviderIdentifier": identityProvider.IdentityProviderIdentifier,
+			"loggedInUser":               s.getLoggedInSubject(r),
+		})
+
+		http.Redirect(w, r, fmt.Sprintf("%v/admin/identity-providers", lib.GetBaseUrl()), http.StatusFound)
+	}
+}
@@ -0,0 +1,201 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/csrf"
+	"github.com/leodip/goiabada/internal/common"
+	"github.com/leodip/goiabada/internal/constants"
+	"github.com/leodip/goiabada/internal/customerrors"
+	"github.com/leodip/goiabada/internal/entities"
+	"github.com/leodip/goiabada/internal/enums"
+	"github.com/leodip/goiabada/internal/lib"
+)
+
+func (s *Server) handleAdminIdentityProviderNewGet() http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		bind := map[string]interface{}{
+			"type":      enums.IdentityProviderTypeOIDC.String(),
+			"csrfField": csrf.TemplateField(r),
+		}
+
+		err := s.renderTemplate(w, r, "/layouts/menu_layout.html", "/admin_identity_providers_new.html", bind)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+	}
+}
+
+func (s *Server) handleAdminIdentityProviderNewPost(identifierValidator identifierValidator,
+	inputSanitizer inputSanitizer, samlMetadataLoader samlMetadataLoader) http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		renderError := func(message string) {
+			bind := map[string]interface{}{
+				"error":                      message,
+				"type":                       r.FormValue("type"),
+				"name":                       r.FormValue("name"),
+				"identityProviderIdentifier": r.FormValue("identityProviderIdentifier"),
+				"issuer":                     r.FormValue("issuer"),
+				"clientIdentifier":           r.FormValue("clientIdentifier"),
+				"samlMetadataURL":            r.FormValue("samlMetadataURL"),
+				"samlMetadataXML":            r.FormValue("samlMetadataXML"),
+				"ldapURL":                    r.FormValue("ldapURL"),
+				"ldapBaseDN":                 r.FormValue("ldapBaseDN"),
+				"ldapBindDN":                 r.FormValue("ldapBindDN"),
+				"csrfField":                  csrf.TemplateField(r),
+			}
+
+			err := s.renderTemplate(w, r, "/layouts/menu_layout.html", "/admin_identity_providers_new.html", bind)
+			if err != nil {
+				s.internalServerError(w, r, err)
+			}
+		}
+
+		handleValidationError := func(err error) {
+			if valError, ok := err.(*customerrors.ValidationError); ok {
+				renderError(valError.Description)
+			} else {
+				s.internalServerError(w, r, err)
+			}
+		}
+
+		idpType, err := enums.IdentityProviderTypeFromString(r.FormValue("type"))
+		if err != nil {
+			renderError("Invalid identity provider type.")
+			return
+		}
+
+		name := strings.TrimSpace(r.FormValue("name"))
+		identityProviderIdentifier := strings.TrimSpace(r.FormValue("identityProviderIdentifier"))
+
+		err = validateIdentityProviderName(name)
+		if err != nil {
+			handleValidationError(err)
+			return
+		}
+
+		if len(identityProviderIdentifier) == 0 {
+			renderError("Identity provider identifier is required.")
+			return
+		}
+
+		err = identifierValidator.ValidateIdentifier(identityProviderIdentifier, true)
+		if err != nil {
+			handleValidationError(err)
+			return
+		}
+
+		existingIdentityProvider, err := s.database.GetIdentityProviderByIdentifier(nil, identityProviderIdentifier)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+		if existingIdentityProvider != nil {
+			renderError("The identity provider identifier is already in use.")
+			return
+		}
+
+		identityProvider := &entities.IdentityProvider{
+			Name:                       inputSanitizer.Sanitize(name),
+			IdentityProviderIdentifier: identityProviderIdentifier,
+			Type:                       idpType.String(),
+			Enabled:                    false,
+			LinkByEmail:                false,
+			AutoProvision:              false,
+		}
+
+		if idpType == enums.IdentityProviderTypeSAML {
+			metadataURL := strings.TrimSpace(r.FormValue("samlMetadataURL"))
+			metadata, entityID, err := loadSAMLMetadata(r.Context(), samlMetadataLoader, metadataURL, r.FormValue("samlMetadataXML"))
+			if err != nil {
+				handleValidationError(err)
+				return
+			}
+
+			identityProvider.Issuer = entityID
+			identityProvider.SAMLMetadataURL = metadataURL
+			identityProvider.SAMLMetadataXML = metadata
+			identityProvider.SAMLNameIdFormat = samlNameIdFormats[0]
+			identityProvider.EmailClaim = "mail"
+			identityProvider.GivenNameClaim = "givenName"
+			identityProvider.FamilyNameClaim = "sn"
+		} else if idpType == enums.IdentityProviderTypeLDAP {
+			ldapURL := strings.TrimSpace(r.FormValue("ldapURL"))
+			ldapBaseDN := strings.TrimSpace(r.FormValue("ldapBaseDN"))
+			ldapUserFilter := defaultLDAPUserFilter
+
+			err = validateLDAPIdentityProviderSettings(ldapURL, ldapBaseDN, ldapUserFilter)
+			if err != nil {
+				handleValidationError(err)
+				return
+			}
+
+			ldapBindPassword := r.FormValue("ldapBindPassword")
+			if len(ldapBindPassword) > 0 {
+				settings := r.Context().Value(common.ContextKeySettings).(*entities.Settings)
+				identityProvider.LDAPBindPasswordEncrypted, err = lib.EncryptText(ldapBindPassword, settings.AESEncryptionKey)
+				if err != nil {
+					s.internalServerError(w, r, err)
+					return
+				}
+			}
+
+			identityProvider.LDAPURL = ldapURL
+			identityProvider.LDAPBaseDN = ldapBaseDN
+			identityProvider.LDAPBindDN = strings.TrimSpace(r.FormValue("ldapBindDN"))
+			identityProvider.LDAPUserFilter = ldapUserFilter
+			identityProvider.LDAPSubjectAttribute = "entryUUID"
+			identityProvider.EmailClaim = "mail"
+			identityProvider.GivenNameClaim = "givenName"
+			identityProvider.FamilyNameClaim = "sn"
+			identityProvider.GroupsClaim = "memberOf"
+		} else {
+			issuer := strings.TrimSpace(r.FormValue("issuer"))
+			clientIdentifier := strings.TrimSpace(r.FormValue("clientIdentifier"))
+			clientSecret := strings.TrimSpace(r.FormValue("clientSecret"))
+
+			err = validateOIDCIdentityProviderSettings(issuer, clientIdentifier)
+			if err != nil {
+				handleValidationError(err)
+				return
+			}
+
+			if len(clientSecret) > 0 {
+				settings := r.Context().Value(common.ContextKeySettings).(*entities.Settings)
+				identityProvider.ClientSecretEncrypted, err = lib.EncryptText(clientSecret, settings.AESEncryptionKey)
+				if err != nil {
+					s.internalServerError(w, r, err)
+					return
+				}
+			}
+
+			identityProvider.Issuer = issuer
+			identityProvider.ClientIdentifier = clientIdentifier
+			identityProvider.Scopes = "openid profile email"
+			identityProvider.EmailClaim = "email"
+			identityProvider.GivenNameClaim = "given_name"
+			identityProvider.FamilyNameClaim = "family_name"
+		}
+
+		err = s.database.CreateIdentityProvider(nil, identityProvider)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		lib.LogAudit(r.Context(), constants.AuditCreatedIdentityProvider, map[string]interface{}{
+			"identityProviderId":         identityProvider.Id,
+			"identityProviderIdentifier": identityProvider.IdentityProviderIdentifier,
+			"loggedInUser":               s.getLoggedInSubject(r),
+		})
+
+		http.Redirect(w, r, fmt.Sprintf("%v/admin/identity-providers/%v/settings", lib.GetBaseUrl(), identityProvider.Id), http.StatusFound)
+	}
+}
@@ -0,0 +1,466 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/crewjam/saml"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-ldap/ldap/v3"
+	"github.com/gorilla/csrf"
+	"github.com/leodip/goiabada/internal/common"
+	"github.com/leodip/goiabada/internal/constants"
+	"github.com/leodip/goiabada/internal/core"
+	core_federation "github.com/leodip/goiabada/internal/core/federation"
+	"github.com/leodip/goiabada/internal/customerrors"
+	"github.com/leodip/goiabada/internal/entities"
+	"github.com/leodip/goiabada/internal/enums"
+	"github.com/leodip/goiabada/internal/lib"
+	"github.com/pkg/errors"
+)
+
+func validateIdentityProviderName(name string) error {
+
+	if len(name) == 0 {
+		return customerrors.NewValidationError("", "Name is required.")
+	}
+
+	const maxLengthName = 64
+	if len(name) > maxLengthName {
+		return customerrors.NewValidationError("", "The name cannot exceed a maximum length of "+strconv.Itoa(maxLengthName)+" characters.")
+	}
+
+	return nil
+}
+
+func validateOIDCIdentityProviderSettings(issuer string, clientIdentifier string) error {
+
+	if len(issuer) == 0 {
+		return customerrors.NewValidationError("", "Issuer is required.")
+	}
+
+	issuerURL, err := url.ParseRequestURI(issuer)
+	if err != nil || (issuerURL.Scheme != "https" && issuerURL.Scheme != "http") || len(issuerURL.Host) == 0 {
+		return customerrors.NewValidationError("", "The issuer must be a valid URL.")
+	}
+
+	if len(clientIdentifier) == 0 {
+		return customerrors.NewValidationError("", "Client identifier is required.")
+	}
+
+	return nil
+}
+
+const defaultLDAPUserFilter = "(&(objectClass=person)(mail={email}))"
+
+func validateLDAPIdentityProviderSettings(ldapURL string, baseDN string, userFilter string) error {
+
+	if len(ldapURL) == 0 {
+		return customerrors.NewValidationError("", "The LDAP URL is required.")
+	}
+
+	parsedURL, err := url.Parse(ldapURL)
+	if err != nil || (parsedURL.Scheme != "ldap" && parsedURL.Scheme != "ldaps") || len(parsedURL.Host) == 0 {
+		return customerrors.NewValidationError("", "The LDAP URL must be a valid URL, starting with ldap:// or ldaps://.")
+	}
+
+	if len(baseDN) == 0 {
+		return customerrors.NewValidationError("", "The base DN is required.")
+	}
+
+	if !strings.Contains(userFilter, "{email}") {
+		return customerrors.NewValidationError("", "The user filter must contain the {email} placeholder.")
+	}
+
+	if _, err := ldap.CompileFilter(strings.ReplaceAll(userFilter, "{email}", "user@example.com")); err != nil {
+		return customerrors.NewValidationError("", "The user filter is invalid.")
+	}
+
+	return nil
+}
+
+// validateIdentityProviderGroupsMapping validates the groups mapping. The mapped groups must exist, and can't
+// grant authserver permissions, as whoever controls the assertions of the identity provider could join them.
+func (s *Server) validateIdentityProviderGroupsMapping(permissionChecker *core.PermissionChecker, groupsMapping string) error {
+
+	mapping, err := core_federation.ParseGroupsMapping(groupsMapping)
+	if err != nil {
+		return customerrors.NewValidationError("", strings.ToUpper(err.Error()[:1])+err.Error()[1:]+".")
+	}
+
+	for _, groupIdentifiers := range mapping {
+		for _, groupIdentifier := range groupIdentifiers {
+			group, err := s.database.GetGroupByGroupIdentifier(nil, groupIdentifier)
+			if err != nil {
+				return err
+			}
+			if group == nil {
+				return customerrors.NewValidationError("", "The group "+groupIdentifier+" of the groups mapping does not exist.")
+			}
+			privileged, err := permissionChecker.GroupGrantsAuthServerPermissions(group)
+			if err != nil {
+				return err
+			}
+			if privileged {
+				return customerrors.NewValidationError("", "The group "+groupIdentifier+
+					" grants permissions of the authserver resource, so it can't be mapped to the groups of an identity provider.")
+			}
+		}
+	}
+
+	return nil
+}
+
+// loadSAMLMetadata returns the metadata of a SAML identity provider, either fetched from the metadata URL
+// or as provided by the admin, and the entity ID of the identity provider.
+func loadSAMLMetadata(ctx context.Context, samlMetadataLoader samlMetadataLoader, metadataURL string,
+	metadataXML string) ([]byte, string, error) {
+
+	var metadata []byte
+	if len(metadataURL) > 0 {
+		parsedURL, err := url.ParseRequestURI(metadataURL)
+		if err != nil || (parsedURL.Scheme != "https" && parsedURL.Scheme != "http") || len(parsedURL.Host) == 0 {
+			return nil, "", customerrors.NewValidationError("", "The metadata URL must be a valid URL.")
+		}
+
+		metadata, err = samlMetadataLoader.FetchMetadata(ctx, metadataURL)
+		if err != nil {
+			slog.Warn(fmt.Sprintf("unable to fetch SAML metadata from %v: %+v", metadataURL, err))
+			return nil, "", customerrors.NewValidationError("", "Unable to load the metadata from the metadata URL.")
+		}
+	} else {
+		metadata = []byte(strings.TrimSpace(metadataXML))
+	}
+
+	if len(metadata) == 0 {
+		return nil, "", customerrors.NewValidationError("", "The metadata URL or the metadata XML is required.")
+	}
+
+	entityDescriptor, err := samlMetadataLoader.ParseMetadata(metadata)
+	if err != nil {
+		return nil, "", customerrors.NewValidationError("", "The metadata is invalid: "+errors.Cause(err).Error()+".")
+	}
+
+	return metadata, entityDescriptor.EntityID, nil
+}
+
+// validateIdentityProviderMappings validates the claims (or SAML attributes) mapped to groups and user attributes.
+func validateIdentityProviderMappings(identifierValidator identifierValidator, userAttributesMapping string) error {
+
+	mapping, err := core_federation.ParseUserAttributesMapping(userAttributesMapping)
+	if err != nil {
+		return customerrors.NewValidationError("", strings.ToUpper(err.Error()[:1])+err.Error()[1:]+".")
+	}
+
+	const maxLengthKey = 32
+	for _, key := range mapping {
+		if len(key) > maxLengthKey {
+			return customerrors.NewValidationError("", "The user attribute key "+key+" cannot exceed a maximum length of "+
+				strconv.Itoa(maxLengthKey)+" characters.")
+		}
+		err = identifierValidator.ValidateIdentifier(key, false)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func getIdentityProviderBind(identityProvider *entities.IdentityProvider) map[string]interface{} {
+	isSAML := identityProvider.Type == enums.IdentityProviderTypeSAML.String()
+	isLDAP := identityProvider.Type == enums.IdentityProviderTypeLDAP.String()
+
+	claimLabel := "claim"
+	if isSAML || isLDAP {
+		claimLabel = "attribute"
+	}
+
+	return map[string]interface{}{
+		"identityProvider":    identityProvider,
+		"isSAML":              isSAML,
+		"isLDAP":              isLDAP,
+		"claimLabel":          claimLabel,
+		"samlMetadataXML":     string(identityProvider.SAMLMetadataXML),
+		"samlEntityID":        core_federation.GetSAMLMetadataURL(identityProvider.IdentityProviderIdentifier),
+		"samlAcsURL":          core_federation.GetSAMLAcsURL(identityProvider.IdentityProviderIdentifier),
+		"samlNameIdFormats":   samlNameIdFormats,
+		"hasClientSecret":     len(identityProvider.ClientSecretEncrypted) > 0,
+		"hasLDAPBindPassword": len(identityProvider.LDAPBindPasswordEncrypted) > 0,
+		"callbackURI":         getFederatedCallbackURI(identityProvider.IdentityProviderIdentifier),
+	}
+}
+
+var samlNameIdFormats = []string{
+	string(saml.PersistentNameIDFormat),
+	string(saml.EmailAddressNameIDFormat),
+	string(saml.UnspecifiedNameIDFormat),
+	string(saml.TransientNameIDFormat),
+}
+
+func (s *Server) getIdentityProviderFromURL(r *http.Request) (*entities.IdentityProvider, error) {
+
+	idStr := chi.URLParam(r, "identityProviderId")
+	if len(idStr) == 0 {
+		return nil, errors.WithStack(errors.New("identityProviderId is required"))
+	}
+
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	identityProvider, err := s.database.GetIdentityProviderById(nil, id)
+	if err != nil {
+		return nil, err
+	}
+	if identityProvider == nil {
+		return nil, errors.WithStack(errors.New(fmt.Sprintf("identity provider %v not found", id)))
+	}
+	return identityProvider, nil
+}
+
+func (s *Server) handleAdminIdentityProviderSettingsGet() http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		identityProvider, err := s.getIdentityProviderFromURL(r)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		sess, err := s.sessionStore.Get(r, common.SessionName)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		savedSuccessfully := sess.Flashes("savedSuccessfully")
+		if savedSuccessfully != nil {
+			err = sess.Save(r, w)
+			if err != nil {
+				s.internalServerError(w, r, err)
+				return
+			}
+		}
+
+		bind := getIdentityProviderBind(identityProvider)
+		bind["savedSuccessfully"] = len(savedSuccessfully) > 0
+		bind["csrfField"] = csrf.TemplateField(r)
+
+		err = s.renderTemplate(w, r, "/layouts/menu_layout.html", "/admin_identity_providers_settings.html", bind)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+	}
+}
+
+func (s *Server) handleAdminIdentityProviderSettingsPost(identifierValidator identifierValidator,
+	inputSanitizer inputSanitizer, samlMetadataLoader samlMetadataLoader, permissionChecker *core.PermissionChecker) http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		identityProvider, err := s.getIdentityProviderFromURL(r)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		isSAML := identityProvider.Type == enums.IdentityProviderTypeSAML.String()
+		isLDAP := identityProvider.Type == enums.IdentityProviderTypeLDAP.String()
+
+		identityProvider.Name = strings.TrimSpace(r.FormValue("name"))
+		identityProvider.IdentityProviderIdentifier = strings.TrimSpace(r.FormValue("identityProviderIdentifier"))
+		identityProvider.Enabled = r.FormValue("enabled") == "on"
+		identityProvider.EmailClaim = strings.TrimSpace(r.FormValue("emailClaim"))
+		identityProvider.GivenNameClaim = strings.TrimSpace(r.FormValue("givenNameClaim"))
+		identityProvider.FamilyNameClaim = strings.TrimSpace(r.FormValue("familyNameClaim"))
+		identityProvider.GroupsClaim = strings.TrimSpace(r.FormValue("groupsClaim"))
+		identityProvider.GroupsMapping = strings.TrimSpace(strings.ReplaceAll(r.FormValue("groupsMapping"), "\r\n", "\n"))
+		identityProvider.UserAttributesMapping = strings.TrimSpace(strings.ReplaceAll(r.FormValue("userAttributesMapping"), "\r\n", "\n"))
+		identityProvider.LinkByEmail = r.FormValue("linkByEmail") == "on"
+		identityProvider.TrustEmail = (isSAML || isLDAP) && r.FormValue("trustEmail") == "on"
+		identityProvider.AutoProvision = r.FormValue("autoProvision") == "on"
+
+		samlMetadataXML := string(identityProvider.SAMLMetadataXML)
+		if isSAML {
+			identityProvider.SAMLMetadataURL = strings.TrimSpace(r.FormValue("samlMetadataURL"))
+			identityProvider.SAMLNameIdFormat = r.FormValue("samlNameIdFormat")
+			samlMetadataXML = r.FormValue("samlMetadataXML")
+		} else if isLDAP {
+			identityProvider.LDAPURL = strings.TrimSpace(r.FormValue("ldapURL"))
+			identityProvider.LDAPStartTLS = r.FormValue("ldapStartTLS") == "on"
+			identityProvider.LDAPBindDN = strings.TrimSpace(r.FormValue("ldapBindDN"))
+			identityProvider.LDAPBaseDN = strings.TrimSpace(r.FormValue("ldapBaseDN"))
+			identityProvider.LDAPUserFilter = strings.TrimSpace(r.FormValue("ldapUserFilter"))
+			identityProvider.LDAPSubjectAttribute = strings.TrimSpace(r.FormValue("ldapSubjectAttribute"))
+			identityProvider.LDAPLocalPasswordFallback = r.FormValue("ldapLocalPasswordFallback") == "on"
+		} else {
+			identityProvider.Issuer = strings.TrimSpace(r.FormValue("issuer"))
+			identityProvider.ClientIdentifier = strings.TrimSpace(r.FormValue("clientIdentifier"))
+			identityProvider.Scopes = strings.Join(strings.Fields(r.FormValue("scopes")), " ")
+		}
+
+		renderError := func(message string) {
+			bind := getIdentityProviderBind(identityProvider)
+			bind["samlMetadataXML"] = samlMetadataXML
+			bind["error"] = message
+			bind["csrfField"] = csrf.TemplateField(r)
+
+			err := s.renderTemplate(w, r, "/layouts/menu_layout.html", "/admin_identity_providers_settings.html", bind)
+			if err != nil {
+				s.internalServerError(w, r, err)
+			}
+		}
+
+		handleValidationError := func(err error) {
+			if valError, ok := err.(*customerrors.ValidationError); ok {
+				renderError(valError.Description)
+			} else {
+				s.internalServerError(w, r, err)
+			}
+		}
+
+		err = validateIdentityProviderName(identityProvider.Name)
+		if err != nil {
+			handleValidationError(err)
+			return
+		}
+
+		err = identifierValidator.ValidateIdentifier(identityProvider.IdentityProviderIdentifier, true)
+		if err != nil {
+			handleValidationError(err)
+			return
+		}
+
+		existingIdentityProvider, err := s.database.GetIdentityProviderByIdentifier(nil, identityProvider.IdentityProviderIdentifier)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+		if existingIdentityProvider != nil && existingIdentityProvider.Id != identityProvider.Id {
+			renderError("The identity provider identifier is already in use.")
+			return
+		}
+
+		if isSAML {
+			// the metadata is reloaded from the metadata URL only when requested
+			metadataURL := ""
+			if r.FormValue("reloadSAMLMetadata") == "on" {
+				metadataURL = identityProvider.SAMLMetadataURL
+				if len(metadataURL) == 0 {
+					renderError("The metadata URL is required to reload the metadata.")
+					return
+				}
+			}
+			metadata, entityID, err := loadSAMLMetadata(r.Context(), samlMetadataLoader, metadataURL, samlMetadataXML)
+			if err != nil {
+				handleValidationError(err)
+				return
+			}
+			identityProvider.SAMLMetadataXML = metadata
+			identityProvider.Issuer = entityID
+
+			if !slices.Contains(samlNameIdFormats, identityProvider.SAMLNameIdFormat) {
+				renderError("Invalid NameID format.")
+				return
+			}
+		} else if isLDAP {
+			err = validateLDAPIdentityProviderSettings(identityProvider.LDAPURL, identityProvider.LDAPBaseDN,
+				identityProvider.LDAPUserFilter)
+			if err != nil {
+				handleValidationError(err)
+				return
+			}
+		} else {
+			err = validateOIDCIdentityProviderSettings(identityProvider.Issuer, identityProvider.ClientIdentifier)
+			if err != nil {
+				handleValidationError(err)
+				return
+			}
+
+			if !slices.Contains(strings.Split(identityProvider.Scopes, " "), "openid") {
+				renderError("The scopes must include openid.")
+				return
+			}
+
+			if len(identityProvider.EmailClaim) == 0 {
+				renderError("The email claim is required.")
+				return
+			}
+		}
+
+		err = validateIdentityProviderMappings(identifierValidator, identityProvider.UserAttributesMapping)
+		if err != nil {
+			handleValidationError(err)
+			return
+		}
+
+		err = s.validateIdentityProviderGroupsMapping(permissionChecker, identityProvider.GroupsMapping)
+		if err != nil {
+			handleValidationError(err)
+			return
+		}
+
+		identityProvider.Name = inputSanitizer.Sanitize(identityProvider.Name)
+
+		if isLDAP {
+			ldapBindPassword := r.FormValue("ldapBindPassword")
+			if len(ldapBindPassword) > 0 {
+				settings := r.Context().Value(common.ContextKeySettings).(*entities.Settings)
+				identityProvider.LDAPBindPasswordEncrypted, err = lib.EncryptText(ldapBindPassword, settings.AESEncryptionKey)
+				if err != nil {
+					s.internalServerError(w, r, err)
+					return
+				}
+			} else if r.FormValue("removeLDAPBindPassword") == "on" {
+				identityProvider.LDAPBindPasswordEncrypted = nil
+			}
+		} else if !isSAML {
+			clientSecret := strings.TrimSpace(r.FormValue("clientSecret"))
+			if len(clientSecret) > 0 {
+				settings := r.Context().Value(common.ContextKeySettings).(*entities.Settings)
+				identityProvider.ClientSecretEncrypted, err = lib.EncryptText(clientSecret, settings.AESEncryptionKey)
+				if err != nil {
+					s.internalServerError(w, r, err)
+					return
+				}
+			} else if r.FormValue("removeClientSecret") == "on" {
+				identityProvider.ClientSecretEncrypted = nil
+			}
+		}
+
+		err = s.database.UpdateIdentityProvider(nil, identityProvider)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		sess, err := s.sessionStore.Get(r, common.SessionName)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		sess.AddFlash("true", "savedSuccessfully")
+		err = sess.Save(r, w)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		lib.LogAudit(r.Context(), constants.AuditUpdatedIdentityProvider, map[string]interface{}{
+			"identityProviderId": identityProvider.Id,
+			"loggedInUser":       s.getLoggedInSubject(r),
+		})
+
+		http.Redirect(w, r, fmt.Sprintf("%v/admin/identity-providers/%v/settings", lib.GetBaseUrl(), identityProvider.Id), http.StatusFound)
+	}
+}
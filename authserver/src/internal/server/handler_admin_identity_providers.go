@@ -0,0 +1,27 @@
+package server
+
+import (
+	"net/http"
+)
+
+func (s *Server) handleAdminIdentityProvidersGet() http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		identityProviders, err := s.database.GetAllIdentityProviders(nil)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		bind := map[string]interface{}{
+			"identityProviders": identityProviders,
+		}
+
+		err = s.renderTemplate(w, r, "/layouts/menu_layout.html", "/admin_identity_providers.html", bind)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+	}
+}
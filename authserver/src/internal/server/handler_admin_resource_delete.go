@@ -124,7 +124,7 @@ func (s *Server) handleAdminResourceDeletePost() http.HandlerFunc {
 			return
 		}
 
-		lib.LogAudit(constants.AuditDeletedResource, map[string]interface{}{
+		lib.LogAudit(r.Context(), constants.AuditDeletedResource, map[string]interface{}{
 			"resourceId":         resource.Id,
 			"resourceIdentifier": resource.ResourceIdentifier,
 			"loggedInUser":       s.getLoggedInSubject(r),
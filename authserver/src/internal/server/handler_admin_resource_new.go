@@ -86,7 +86,7 @@ func (s *Server) handleAdminResourceNewPost(identifierValidator identifierValida
 			return
 		}
 
-		lib.LogAudit(constants.AuditCreatedResource, map[string]interface{}{
+		lib.LogAudit(r.Context(), constants.AuditCreatedResource, map[string]interface{}{
 			"resourceId":         resource.Id,
 			"resourceIdentifier": resource.ResourceIdentifier,
 			"loggedInUser":       s.getLoggedInSubject(r),
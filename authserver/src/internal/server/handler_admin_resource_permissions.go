@@ -88,9 +88,11 @@ func (s *Server) handleAdminResourcePermissionsPost(identifierValidator identifi
 	inputSanitizer inputSanitizer) http.HandlerFunc {
 
 	type permission struct {
-		Id          int64  `json:"id"`
-		Identifier  string `json:"permissionIdentifier"`
-		Description string `json:"description"`
+		Id                  int64  `json:"id"`
+		Identifier          string `json:"permissionIdentifier"`
+		Description         string `json:"description"`
+		MinAcrLevel         string `json:"minAcrLevel"`
+		MaxAuthAgeInSeconds string `json:"maxAuthAgeInSeconds"`
 	}
 
 	type savePermissionsInput struct {
@@ -190,12 +192,27 @@ func (s *Server) handleAdminResourcePermissionsPost(identifierValidator identifi
 				return
 			}
 
+			minAcrLevel, maxAuthAgeInSeconds, err := parseStepUpRequirements(perm.MinAcrLevel, perm.MaxAuthAgeInSeconds)
+			if err != nil {
+				if valError, ok := err.(*customerrors.ValidationError); ok {
+					result.Error = valError.Description
+					w.Header().Set("Content-Type", "application/json")
+					json.NewEncoder(w).Encode(result)
+					return
+				} else {
+					s.jsonError(w, r, err)
+					return
+				}
+			}
+
 			if perm.Id < 0 {
 				// create new permission
 				permissionToAdd := &entities.Permission{
 					ResourceId:           resource.Id,
 					Description:          perm.Description,
 					PermissionIdentifier: perm.Identifier,
+					MinAcrLevel:          minAcrLevel,
+					MaxAuthAgeInSeconds:  maxAuthAgeInSeconds,
 				}
 				err := s.database.CreatePermission(nil, permissionToAdd)
 				if err != nil {
@@ -215,6 +232,8 @@ func (s *Server) handleAdminResourcePermissionsPost(identifierValidator identifi
 				}
 				existingPermission.PermissionIdentifier = perm.Identifier
 				existingPermission.Description = perm.Description
+				existingPermission.MinAcrLevel = minAcrLevel
+				existingPermission.MaxAuthAgeInSeconds = maxAuthAgeInSeconds
 				err = s.database.UpdatePermission(nil, existingPermission)
 				if err != nil {
 					s.jsonError(w, r, err)
@@ -264,7 +283,7 @@ func (s *Server) handleAdminResourcePermissionsPost(identifierValidator identifi
 			return
 		}
 
-		lib.LogAudit(constants.AuditUpdatedResourcePermissions, map[string]interface{}{
+		lib.LogAudit(r.Context(), constants.AuditUpdatedResourcePermissions, map[string]interface{}{
 			"resourceId":   resource.Id,
 			"loggedInUser": s.getLoggedInSubject(r),
 		})
@@ -334,6 +353,19 @@ func (s *Server) handleAdminResourceValidatePermissionPost(identifierValidator i
 			return
 		}
 
+		_, _, err = parseStepUpRequirements(data["minAcrLevel"], data["maxAuthAgeInSeconds"])
+		if err != nil {
+			if valError, ok := err.(*customerrors.ValidationError); ok {
+				result.Error = valError.Description
+				w.Header().Set("Content-Type", "application/json")
+				json.NewEncoder(w).Encode(result)
+				return
+			} else {
+				s.jsonError(w, r, err)
+				return
+			}
+		}
+
 		result.Valid = true
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(result)
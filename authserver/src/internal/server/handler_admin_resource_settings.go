@@ -60,6 +60,8 @@ func (s *Server) handleAdminResourceSettingsGet() http.HandlerFunc {
 			"resourceId":            resource.Id,
 			"resourceIdentifier":    resource.ResourceIdentifier,
 			"description":           resource.Description,
+			"minAcrLevel":           resource.MinAcrLevel.String(),
+			"maxAuthAgeInSeconds":   resource.MaxAuthAgeInSeconds,
 			"isSystemLevelResource": resource.IsSystemLevelResource(),
 			"savedSuccessfully":     len(savedSuccessfully) > 0,
 			"csrfField":             csrf.TemplateField(r),
@@ -106,14 +108,18 @@ func (s *Server) handleAdminResourceSettingsPost(identifierValidator identifierV
 
 		resourceIdentifier := r.FormValue("resourceIdentifier")
 		description := r.FormValue("description")
+		minAcrLevel := r.FormValue("minAcrLevel")
+		maxAuthAgeInSeconds := r.FormValue("maxAuthAgeInSeconds")
 
 		renderError := func(message string) {
 			bind := map[string]interface{}{
-				"resourceId":         resource.Id,
-				"resourceIdentifier": resourceIdentifier,
-				"description":        description,
-				"error":              message,
-				"csrfField":          csrf.TemplateField(r),
+				"resourceId":          resource.Id,
+				"resourceIdentifier":  resourceIdentifier,
+				"description":         description,
+				"minAcrLevel":         minAcrLevel,
+				"maxAuthAgeInSeconds": maxAuthAgeInSeconds,
+				"error":               message,
+				"csrfField":           csrf.TemplateField(r),
 			}
 
 			err := s.renderTemplate(w, r, "/layouts/menu_layout.html", "/admin_resources_settings.html", bind)
@@ -149,8 +155,21 @@ func (s *Server) handleAdminResourceSettingsPost(identifierValidator identifierV
 			return
 		}
 
+		minAcrLevelEnum, maxAuthAgeInSecondsInt, err := parseStepUpRequirements(minAcrLevel, maxAuthAgeInSeconds)
+		if err != nil {
+			if valError, ok := err.(*customerrors.ValidationError); ok {
+				renderError(valError.Description)
+				return
+			} else {
+				s.internalServerError(w, r, err)
+				return
+			}
+		}
+
 		resource.ResourceIdentifier = strings.TrimSpace(inputSanitizer.Sanitize(resourceIdentifier))
 		resource.Description = strings.TrimSpace(inputSanitizer.Sanitize(description))
+		resource.MinAcrLevel = minAcrLevelEnum
+		resource.MaxAuthAgeInSeconds = maxAuthAgeInSecondsInt
 
 		err = s.database.UpdateResource(nil, resource)
 		if err != nil {
@@ -171,7 +190,7 @@ func (s *Server) handleAdminResourceSettingsPost(identifierValidator identifierV
 			return
 		}
 
-		lib.LogAudit(constants.AuditUpdatedResource, map[string]interface{}{
+		lib.LogAudit(r.Context(), constants.AuditUpdatedResource, map[string]interface{}{
 			"resourceId":         resource.Id,
 			"resourceIdentifier": resource.ResourceIdentifier,
 			"loggedInUser":       s.getLoggedInSubject(r),
@@ -309,7 +309,7 @@ func (s *Server) handleAdminResourceUsersWithPermissionRemovePermissionPost() ht
 			return
 		}
 
-		lib.LogAudit(constants.AuditDeletedUserPermission, map[string]interface{}{
+		lib.LogAudit(r.Context(), constants.AuditDeletedUserPermission, map[string]interface{}{
 			"userId":       user.Id,
 			"permissionId": permissionId,
 			"loggedInUser": s.getLoggedInSubject(r),
@@ -703,7 +703,7 @@ func (s *Server) handleAdminResourceUsersWithPermissionAddPermissionPost() http.
 				return
 			}
 
-			lib.LogAudit(constants.AuditAddedUserPermission, map[string]interface{}{
+			lib.LogAudit(r.Context(), constants.AuditAddedUserPermission, map[string]interface{}{
 				"userId":       user.Id,
 				"permissionId": permissionId,
 				"loggedInUser": s.getLoggedInSubject(r),
@@ -0,0 +1,230 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/csrf"
+	"github.com/leodip/goiabada/internal/common"
+	"github.com/leodip/goiabada/internal/constants"
+	"github.com/leodip/goiabada/internal/customerrors"
+	"github.com/leodip/goiabada/internal/entities"
+	"github.com/leodip/goiabada/internal/lib"
+	"github.com/pkg/errors"
+)
+
+// the corpus can be uploaded in several files, to stay under this limit
+const maxBreachedPasswordsFileSize = 200 * 1024 * 1024
+
+func (s *Server) handleAdminSettingsBreachedPasswordsGet(
+	breachedPasswordChecker breachedPasswordChecker,
+) http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		settings := r.Context().Value(common.ContextKeySettings).(*entities.Settings)
+
+		settingsInfo := struct {
+			RejectBreachedPasswords        bool
+			ForceChangeOfBreachedPasswords bool
+		}{
+			RejectBreachedPasswords:        settings.RejectBreachedPasswords,
+			ForceChangeOfBreachedPasswords: settings.ForceChangeOfBreachedPasswords,
+		}
+
+		hashCount, err := breachedPasswordChecker.CountHashes()
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		sess, err := s.sessionStore.Get(r, common.SessionName)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		savedSuccessfully := sess.Flashes("savedSuccessfully")
+		importedCount := sess.Flashes("importedCount")
+		cleared := sess.Flashes("cleared")
+		if savedSuccessfully != nil || importedCount != nil || cleared != nil {
+			err = sess.Save(r, w)
+			if err != nil {
+				s.internalServerError(w, r, err)
+				return
+			}
+		}
+
+		bind := map[string]interface{}{
+			"settings":          settingsInfo,
+			"hashCount":         hashCount,
+			"savedSuccessfully": len(savedSuccessfully) > 0,
+			"cleared":           len(cleared) > 0,
+			"csrfField":         csrf.TemplateField(r),
+		}
+		if len(importedCount) > 0 {
+			bind["importedCount"] = importedCount[0]
+		}
+
+		err = s.renderTemplate(w, r, "/layouts/menu_layout.html", "/admin_settings_breached_passwords.html", bind)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+	}
+}
+
+func (s *Server) handleAdminSettingsBreachedPasswordsPost() http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		settings := r.Context().Value(common.ContextKeySettings).(*entities.Settings)
+
+		settings.RejectBreachedPasswords = r.FormValue("rejectBreachedPasswords") == "on"
+		settings.ForceChangeOfBreachedPasswords = r.FormValue("forceChangeOfBreachedPasswords") == "on"
+
+		err := s.database.UpdateSettings(nil, settings)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		lib.LogAudit(r.Context(), constants.AuditUpdatedBreachedPasswordsSettings, map[string]interface{}{
+			"loggedInUser": s.getLoggedInSubject(r),
+		})
+
+		sess, err := s.sessionStore.Get(r, common.SessionName)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		sess.AddFlash("true", "savedSuccessfully")
+		err = sess.Save(r, w)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		http.Redirect(w, r, fmt.Sprintf("%v/admin/settings/breached-passwords", lib.GetBaseUrl()), http.StatusFound)
+	}
+}
+
+func (s *Server) handleAdminSettingsBreachedPasswordsImportPost(
+	breachedPasswordChecker breachedPasswordChecker,
+) http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		settings := r.Context().Value(common.ContextKeySettings).(*entities.Settings)
+
+		renderError := func(message string) {
+
+			hashCount, err := breachedPasswordChecker.CountHashes()
+			if err != nil {
+				s.internalServerError(w, r, err)
+				return
+			}
+
+			bind := map[string]interface{}{
+				"settings": struct {
+					RejectBreachedPasswords        bool
+					ForceChangeOfBreachedPasswords bool
+				}{
+					RejectBreachedPasswords:        settings.RejectBreachedPasswords,
+					ForceChangeOfBreachedPasswords: settings.ForceChangeOfBreachedPasswords,
+				},
+				"hashCount": hashCount,
+				"csrfField": csrf.TemplateField(r),
+				"error":     message,
+			}
+
+			err = s.renderTemplate(w, r, "/layouts/menu_layout.html", "/admin_settings_breached_passwords.html", bind)
+			if err != nil {
+				s.internalServerError(w, r, err)
+			}
+		}
+
+		file, fileHeader, err := r.FormFile("breachedPasswordsFile")
+		if err != nil {
+			if errors.Is(err, http.ErrMissingFile) {
+				renderError("Please select a file to import.")
+				return
+			}
+			s.internalServerError(w, r, err)
+			return
+		}
+		defer file.Close()
+
+		if fileHeader.Size > maxBreachedPasswordsFileSize {
+			renderError(fmt.Sprintf("The file is too large. Please split it in files of up to %v MB, or import it with the command line (goiabada breached-passwords import).",
+				maxBreachedPasswordsFileSize/1024/1024))
+			return
+		}
+
+		importedCount, err := breachedPasswordChecker.ImportCorpus(file, fileHeader.Filename)
+		if err != nil {
+			if valError, ok := err.(*customerrors.ValidationError); ok {
+				renderError(valError.Description)
+			} else {
+				s.internalServerError(w, r, err)
+			}
+			return
+		}
+
+		lib.LogAudit(r.Context(), constants.AuditImportedBreachedPasswords, map[string]interface{}{
+			"fileName":      fileHeader.Filename,
+			"importedCount": importedCount,
+			"loggedInUser":  s.getLoggedInSubject(r),
+		})
+
+		sess, err := s.sessionStore.Get(r, common.SessionName)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		sess.AddFlash(strconv.FormatInt(importedCount, 10), "importedCount")
+		err = sess.Save(r, w)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		http.Redirect(w, r, fmt.Sprintf("%v/admin/settings/breached-passwords", lib.GetBaseUrl()), http.StatusFound)
+	}
+}
+
+func (s *Server) handleAdminSettingsBreachedPasswordsClearPost(
+	breachedPasswordChecker breachedPasswordChecker,
+) http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		err := breachedPasswordChecker.ClearCorpus()
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		lib.LogAudit(r.Context(), constants.AuditClearedBreachedPasswords, map[string]interface{}{
+			"loggedInUser": s.getLoggedInSubject(r),
+		})
+
+		sess, err := s.sessionStore.Get(r, common.SessionName)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		sess.AddFlash("true", "cleared")
+		err = sess.Save(r, w)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		http.Redirect(w, r, fmt.Sprintf("%v/admin/settings/breached-passwords", lib.GetBaseUrl()), http.StatusFound)
+	}
+}
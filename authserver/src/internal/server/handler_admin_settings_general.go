@@ -11,7 +11,6 @@ import (
 	"github.com/leodip/goiabada/internal/common"
 	"github.com/leodip/goiabada/internal/constants"
 	"github.com/leodip/goiabada/internal/entities"
-	"github.com/leodip/goiabada/internal/enums"
 	"github.com/leodip/goiabada/internal/lib"
 )
 
@@ -26,13 +25,11 @@ func (s *Server) handleAdminSettingsGeneralGet() http.HandlerFunc {
 			Issuer                                    string
 			SelfRegistrationEnabled                   bool
 			SelfRegistrationRequiresEmailVerification bool
-			PasswordPolicy                            string
 		}{
 			AppName:                 settings.AppName,
 			Issuer:                  settings.Issuer,
 			SelfRegistrationEnabled: settings.SelfRegistrationEnabled,
 			SelfRegistrationRequiresEmailVerification: settings.SelfRegistrationRequiresEmailVerification,
-			PasswordPolicy: settings.PasswordPolicy.String(),
 		}
 
 		sess, err := s.sessionStore.Get(r, common.SessionName)
@@ -73,13 +70,11 @@ func (s *Server) handleAdminSettingsGeneralPost(inputSanitizer inputSanitizer) h
 			Issuer                                    string
 			SelfRegistrationEnabled                   bool
 			SelfRegistrationRequiresEmailVerification bool
-			PasswordPolicy                            string
 		}{
 			AppName:                 strings.TrimSpace(r.FormValue("appName")),
 			Issuer:                  strings.TrimSpace(r.FormValue("issuer")),
 			SelfRegistrationEnabled: r.FormValue("selfRegistrationEnabled") == "on",
 			SelfRegistrationRequiresEmailVerification: r.FormValue("selfRegistrationRequiresEmailVerification") == "on",
-			PasswordPolicy: r.FormValue("passwordPolicy"),
 		}
 
 		renderError := func(message string) {
@@ -136,12 +131,6 @@ func (s *Server) handleAdminSettingsGeneralPost(inputSanitizer inputSanitizer) h
 			return
 		}
 
-		passwordPolicy, err := enums.PasswordPolicyFromString(settingsInfo.PasswordPolicy)
-		if err != nil {
-			s.internalServerError(w, r, err)
-			return
-		}
-
 		settings := r.Context().Value(common.ContextKeySettings).(*entities.Settings)
 		settings.AppName = inputSanitizer.Sanitize(settingsInfo.AppName)
 		settings.Issuer = inputSanitizer.Sanitize(settingsInfo.Issuer)
@@ -151,15 +140,14 @@ func (s *Server) handleAdminSettingsGeneralPost(inputSanitizer inputSanitizer) h
 		} else {
 			settings.SelfRegistrationRequiresEmailVerification = false
 		}
-		settings.PasswordPolicy = passwordPolicy
 
-		err = s.database.UpdateSettings(nil, settings)
+		err := s.database.UpdateSettings(nil, settings)
 		if err != nil {
 			s.internalServerError(w, r, err)
 			return
 		}
 
-		lib.LogAudit(constants.AuditUpdatedGeneralSettings, map[string]interface{}{
+		lib.LogAudit(r.Context(), constants.AuditUpdatedGeneralSettings, map[string]interface{}{
 			"loggedInUser": s.getLoggedInSubject(r),
 		})
 
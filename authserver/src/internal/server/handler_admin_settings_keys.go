@@ -1,14 +1,11 @@
 package server
 
 import (
-	"crypto/x509"
 	"encoding/base64"
 	"encoding/json"
-	"encoding/pem"
 	"fmt"
 	"net/http"
 
-	"github.com/google/uuid"
 	"github.com/gorilla/csrf"
 	"github.com/leodip/goiabada/internal/constants"
 	"github.com/leodip/goiabada/internal/entities"
@@ -96,114 +93,17 @@ func (s *Server) handleAdminSettingsKeysGet() http.HandlerFunc {
 	}
 }
 
-func (s *Server) handleAdminSettingsKeysRotatePost() http.HandlerFunc {
+func (s *Server) handleAdminSettingsKeysRotatePost(keyRotator keyRotator) http.HandlerFunc {
 
 	return func(w http.ResponseWriter, r *http.Request) {
 
-		allSigningKeys, err := s.database.GetAllSigningKeys(nil)
-		if err != nil {
-			s.jsonError(w, r, err)
-			return
-		}
-
-		var currentKey *entities.KeyPair
-		var nextKey *entities.KeyPair
-		var previousKey *entities.KeyPair
-		for i, signingKey := range allSigningKeys {
-			keyState, err := enums.KeyStateFromString(signingKey.State)
-			if err != nil {
-				s.jsonError(w, r, err)
-				return
-			}
-			switch keyState {
-			case enums.KeyStateCurrent:
-				currentKey = &allSigningKeys[i]
-			case enums.KeyStateNext:
-				nextKey = &allSigningKeys[i]
-			case enums.KeyStatePrevious:
-				previousKey = &allSigningKeys[i]
-			}
-		}
-
-		if previousKey != nil {
-			err = s.database.DeleteKeyPair(nil, previousKey.Id)
-			if err != nil {
-				s.jsonError(w, r, err)
-				return
-			}
-		}
-
-		if currentKey == nil {
-			s.jsonError(w, r, errors.WithStack(fmt.Errorf("no current key found")))
-			return
-		}
-
-		if nextKey == nil {
-			s.jsonError(w, r, errors.WithStack(fmt.Errorf("no next key found")))
-			return
-		}
-
-		// current key becomes previous
-		currentKey.State = enums.KeyStatePrevious.String()
-		err = s.database.UpdateKeyPair(nil, currentKey)
-		if err != nil {
-			s.jsonError(w, r, err)
-			return
-		}
-
-		// next key becomes current
-		nextKey.State = enums.KeyStateCurrent.String()
-		err = s.database.UpdateKeyPair(nil, nextKey)
-		if err != nil {
-			s.jsonError(w, r, err)
-			return
-		}
-
-		// create a new next key
-		privateKey, err := lib.GeneratePrivateKey(4096)
-		if err != nil {
-			s.jsonError(w, r, errors.Wrap(err, "unable to generate a private key"))
-			return
-		}
-		privateKeyPEM := lib.EncodePrivateKeyToPEM(privateKey)
-
-		publicKeyASN1_DER, err := x509.MarshalPKIXPublicKey(&privateKey.PublicKey)
-		if err != nil {
-			s.jsonError(w, r, errors.Wrap(err, "unable to marshal public key to PKIX"))
-			return
-		}
-
-		publicKeyPEM := pem.EncodeToMemory(
-			&pem.Block{
-				Type:  "RSA PUBLIC KEY",
-				Bytes: publicKeyASN1_DER,
-			},
-		)
-
-		kid := uuid.New().String()
-		publicKeyJWK, err := lib.MarshalRSAPublicKeyToJWK(&privateKey.PublicKey, kid)
-		if err != nil {
-			s.jsonError(w, r, err)
-			return
-		}
-
-		keyPair := &entities.KeyPair{
-			State:             enums.KeyStateNext.String(),
-			KeyIdentifier:     kid,
-			Type:              "RSA",
-			Algorithm:         "RS256",
-			PrivateKeyPEM:     privateKeyPEM,
-			PublicKeyPEM:      publicKeyPEM,
-			PublicKeyASN1_DER: publicKeyASN1_DER,
-			PublicKeyJWK:      publicKeyJWK,
-		}
-		err = s.database.CreateKeyPair(nil, keyPair)
+		err := keyRotator.RotateKeys()
 		if err != nil {
 			s.jsonError(w, r, err)
 			return
 		}
 
-		lib.LogAudit(constants.AuditRotatedKeys, map[string]interface{}{
+		lib.LogAudit(r.Context(), constants.AuditRotatedKeys, map[string]interface{}{
 			"loggedInUser": s.getLoggedInSubject(r),
 		})
 
@@ -263,7 +163,7 @@ func (s *Server) handleAdminSettingsKeysRevokePost() http.HandlerFunc {
 			return
 		}
 
-		lib.LogAudit(constants.AuditRevokedKey, map[string]interface{}{
+		lib.LogAudit(r.Context(), constants.AuditRevokedKey, map[string]interface{}{
 			"loggedInUser": s.getLoggedInSubject(r),
 			"keyId":        previousKey.KeyIdentifier,
 		})
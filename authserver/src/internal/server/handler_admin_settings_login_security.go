@@ -0,0 +1,255 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/csrf"
+	"github.com/leodip/goiabada/internal/common"
+	"github.com/leodip/goiabada/internal/constants"
+	"github.com/leodip/goiabada/internal/core"
+	"github.com/leodip/goiabada/internal/customerrors"
+	"github.com/leodip/goiabada/internal/entities"
+	"github.com/leodip/goiabada/internal/lib"
+)
+
+func (s *Server) handleAdminSettingsLoginSecurityGet() http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		settings := r.Context().Value(common.ContextKeySettings).(*entities.Settings)
+
+		settingsInfo := struct {
+			LoginDelayAfterFailedAttempts     int
+			LoginLockoutAfterFailedAttempts   int
+			LoginIpLockoutAfterFailedAttempts int
+			LoginLockoutDurationInSeconds     int
+			RiskBasedAuthEnabled              bool
+			RiskScoreForOTP                   int
+			RiskScoreForBlock                 int
+			RiskScoreForNotification          int
+			RiskTrustedIpRanges               string
+			RiskSuspiciousIpRanges            string
+		}{
+			LoginDelayAfterFailedAttempts:     settings.LoginDelayAfterFailedAttempts,
+			LoginLockoutAfterFailedAttempts:   settings.LoginLockoutAfterFailedAttempts,
+			LoginIpLockoutAfterFailedAttempts: settings.LoginIpLockoutAfterFailedAttempts,
+			LoginLockoutDurationInSeconds:     settings.LoginLockoutDurationInSeconds,
+			RiskBasedAuthEnabled:              settings.RiskBasedAuthEnabled,
+			RiskScoreForOTP:                   settings.RiskScoreForOTP,
+			RiskScoreForBlock:                 settings.RiskScoreForBlock,
+			RiskScoreForNotification:          settings.RiskScoreForNotification,
+			RiskTrustedIpRanges:               settings.RiskTrustedIpRanges,
+			RiskSuspiciousIpRanges:            settings.RiskSuspiciousIpRanges,
+		}
+
+		sess, err := s.sessionStore.Get(r, common.SessionName)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		savedSuccessfully := sess.Flashes("savedSuccessfully")
+		if savedSuccessfully != nil {
+			err = sess.Save(r, w)
+			if err != nil {
+				s.internalServerError(w, r, err)
+				return
+			}
+		}
+
+		bind := map[string]interface{}{
+			"settings":          settingsInfo,
+			"savedSuccessfully": len(savedSuccessfully) > 0,
+			"csrfField":         csrf.TemplateField(r),
+		}
+
+		err = s.renderTemplate(w, r, "/layouts/menu_layout.html", "/admin_settings_login_security.html", bind)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+	}
+}
+
+func (s *Server) handleAdminSettingsLoginSecurityPost() http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		settings := r.Context().Value(common.ContextKeySettings).(*entities.Settings)
+
+		settingsInfo := struct {
+			LoginDelayAfterFailedAttempts     string
+			LoginLockoutAfterFailedAttempts   string
+			LoginIpLockoutAfterFailedAttempts string
+			LoginLockoutDurationInSeconds     string
+			RiskBasedAuthEnabled              bool
+			RiskScoreForOTP                   string
+			RiskScoreForBlock                 string
+			RiskScoreForNotification          string
+			RiskTrustedIpRanges               string
+			RiskSuspiciousIpRanges            string
+		}{
+			LoginDelayAfterFailedAttempts:     r.FormValue("loginDelayAfterFailedAttempts"),
+			LoginLockoutAfterFailedAttempts:   r.FormValue("loginLockoutAfterFailedAttempts"),
+			LoginIpLockoutAfterFailedAttempts: r.FormValue("loginIpLockoutAfterFailedAttempts"),
+			LoginLockoutDurationInSeconds:     r.FormValue("loginLockoutDurationInSeconds"),
+			RiskBasedAuthEnabled:              r.FormValue("riskBasedAuthEnabled") == "on",
+			RiskScoreForOTP:                   r.FormValue("riskScoreForOTP"),
+			RiskScoreForBlock:                 r.FormValue("riskScoreForBlock"),
+			RiskScoreForNotification:          r.FormValue("riskScoreForNotification"),
+			RiskTrustedIpRanges:               strings.TrimSpace(r.FormValue("riskTrustedIpRanges")),
+			RiskSuspiciousIpRanges:            strings.TrimSpace(r.FormValue("riskSuspiciousIpRanges")),
+		}
+
+		renderError := func(message string) {
+
+			bind := map[string]interface{}{
+				"settings":  settingsInfo,
+				"csrfField": csrf.TemplateField(r),
+				"error":     message,
+			}
+
+			err := s.renderTemplate(w, r, "/layouts/menu_layout.html", "/admin_settings_login_security.html", bind)
+			if err != nil {
+				s.internalServerError(w, r, err)
+			}
+		}
+
+		loginDelayAfterFailedAttemptsInt, err := strconv.Atoi(settingsInfo.LoginDelayAfterFailedAttempts)
+		if err != nil {
+			settingsInfo.LoginDelayAfterFailedAttempts = strconv.Itoa(settings.LoginDelayAfterFailedAttempts)
+			renderError("Invalid value for progressive delay - after failed attempts.")
+			return
+		}
+
+		loginLockoutAfterFailedAttemptsInt, err := strconv.Atoi(settingsInfo.LoginLockoutAfterFailedAttempts)
+		if err != nil {
+			settingsInfo.LoginLockoutAfterFailedAttempts = strconv.Itoa(settings.LoginLockoutAfterFailedAttempts)
+			renderError("Invalid value for account lockout - after failed attempts.")
+			return
+		}
+
+		loginIpLockoutAfterFailedAttemptsInt, err := strconv.Atoi(settingsInfo.LoginIpLockoutAfterFailedAttempts)
+		if err != nil {
+			settingsInfo.LoginIpLockoutAfterFailedAttempts = strconv.Itoa(settings.LoginIpLockoutAfterFailedAttempts)
+			renderError("Invalid value for IP address lockout - after failed attempts.")
+			return
+		}
+
+		loginLockoutDurationInSecondsInt, err := strconv.Atoi(settingsInfo.LoginLockoutDurationInSeconds)
+		if err != nil {
+			settingsInfo.LoginLockoutDurationInSeconds = strconv.Itoa(settings.LoginLockoutDurationInSeconds)
+			renderError("Invalid value for lockout duration in seconds.")
+			return
+		}
+
+		const maxAttempts = 1000
+		if loginDelayAfterFailedAttemptsInt < 0 || loginDelayAfterFailedAttemptsInt > maxAttempts {
+			renderError(fmt.Sprintf("Progressive delay - after failed attempts must be between 0 and %v.", maxAttempts))
+			return
+		}
+
+		if loginLockoutAfterFailedAttemptsInt < 0 || loginLockoutAfterFailedAttemptsInt > maxAttempts {
+			renderError(fmt.Sprintf("Account lockout - after failed attempts must be between 0 and %v.", maxAttempts))
+			return
+		}
+
+		const maxIpAttempts = 100000
+		if loginIpLockoutAfterFailedAttemptsInt < 0 || loginIpLockoutAfterFailedAttemptsInt > maxIpAttempts {
+			renderError(fmt.Sprintf("IP address lockout - after failed attempts must be between 0 and %v.", maxIpAttempts))
+			return
+		}
+
+		if loginLockoutDurationInSecondsInt <= 0 {
+			renderError("Lockout duration in seconds must be greater than zero.")
+			return
+		}
+
+		const maxLockoutDurationInSeconds = 86400
+		if loginLockoutDurationInSecondsInt > maxLockoutDurationInSeconds {
+			renderError(fmt.Sprintf("Lockout duration in seconds cannot be greater than %v.", maxLockoutDurationInSeconds))
+			return
+		}
+
+		riskScores := []struct {
+			value string
+			name  string
+		}{
+			{settingsInfo.RiskScoreForOTP, "Risk score to require the OTP"},
+			{settingsInfo.RiskScoreForBlock, "Risk score to block the login"},
+			{settingsInfo.RiskScoreForNotification, "Risk score to notify the user"},
+		}
+		riskScoresInt := []int{}
+		for _, riskScore := range riskScores {
+			riskScoreInt, err := strconv.Atoi(riskScore.value)
+			if err != nil || riskScoreInt < 0 || riskScoreInt > 100 {
+				renderError(fmt.Sprintf("%v must be between 0 and 100.", riskScore.name))
+				return
+			}
+			riskScoresInt = append(riskScoresInt, riskScoreInt)
+		}
+
+		const maxLengthIpRanges = 2048
+		ipRanges := []struct {
+			value string
+			name  string
+		}{
+			{settingsInfo.RiskTrustedIpRanges, "trusted IP ranges"},
+			{settingsInfo.RiskSuspiciousIpRanges, "suspicious IP ranges"},
+		}
+		for _, ipRange := range ipRanges {
+			if len(ipRange.value) > maxLengthIpRanges {
+				renderError(fmt.Sprintf("The %v cannot exceed a maximum length of %v characters.", ipRange.name, maxLengthIpRanges))
+				return
+			}
+			_, err = core.ParseIpRanges(ipRange.value)
+			if err != nil {
+				if valError, ok := err.(*customerrors.ValidationError); ok {
+					renderError(valError.Description)
+					return
+				}
+				s.internalServerError(w, r, err)
+				return
+			}
+		}
+
+		settings.LoginDelayAfterFailedAttempts = loginDelayAfterFailedAttemptsInt
+		settings.LoginLockoutAfterFailedAttempts = loginLockoutAfterFailedAttemptsInt
+		settings.LoginIpLockoutAfterFailedAttempts = loginIpLockoutAfterFailedAttemptsInt
+		settings.LoginLockoutDurationInSeconds = loginLockoutDurationInSecondsInt
+		settings.RiskBasedAuthEnabled = settingsInfo.RiskBasedAuthEnabled
+		settings.RiskScoreForOTP = riskScoresInt[0]
+		settings.RiskScoreForBlock = riskScoresInt[1]
+		settings.RiskScoreForNotification = riskScoresInt[2]
+		settings.RiskTrustedIpRanges = settingsInfo.RiskTrustedIpRanges
+		settings.RiskSuspiciousIpRanges = settingsInfo.RiskSuspiciousIpRanges
+
+		err = s.database.UpdateSettings(nil, settings)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		lib.LogAudit(r.Context(), constants.AuditUpdatedLoginSecuritySettings, map[string]interface{}{
+			"loggedInUser": s.getLoggedInSubject(r),
+		})
+
+		sess, err := s.sessionStore.Get(r, common.SessionName)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		sess.AddFlash("true", "savedSuccessfully")
+		err = sess.Save(r, w)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		http.Redirect(w, r, fmt.Sprintf("%v/admin/settings/login-security", lib.GetBaseUrl()), http.StatusFound)
+	}
+}
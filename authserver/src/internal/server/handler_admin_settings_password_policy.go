@@ -0,0 +1,223 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/csrf"
+	"github.com/leodip/goiabada/internal/common"
+	"github.com/leodip/goiabada/internal/constants"
+	"github.com/leodip/goiabada/internal/entities"
+	"github.com/leodip/goiabada/internal/lib"
+)
+
+func (s *Server) handleAdminSettingsPasswordPolicyGet() http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		settings := r.Context().Value(common.ContextKeySettings).(*entities.Settings)
+
+		settingsInfo := struct {
+			PasswordMinLength               int
+			PasswordMaxLength               int
+			PasswordRequiresUppercase       bool
+			PasswordRequiresLowercase       bool
+			PasswordRequiresNumber          bool
+			PasswordRequiresSpecialChar     bool
+			PasswordDisallowUsernameOrEmail bool
+			PasswordHistoryCount            int
+			PasswordMaxAgeInDays            int
+			PasswordMinAgeInDays            int
+		}{
+			PasswordMinLength:               settings.PasswordMinLength,
+			PasswordMaxLength:               settings.PasswordMaxLength,
+			PasswordRequiresUppercase:       settings.PasswordRequiresUppercase,
+			PasswordRequiresLowercase:       settings.PasswordRequiresLowercase,
+			PasswordRequiresNumber:          settings.PasswordRequiresNumber,
+			PasswordRequiresSpecialChar:     settings.PasswordRequiresSpecialChar,
+			PasswordDisallowUsernameOrEmail: settings.PasswordDisallowUsernameOrEmail,
+			PasswordHistoryCount:            settings.PasswordHistoryCount,
+			PasswordMaxAgeInDays:            settings.PasswordMaxAgeInDays,
+			PasswordMinAgeInDays:            settings.PasswordMinAgeInDays,
+		}
+
+		sess, err := s.sessionStore.Get(r, common.SessionName)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		savedSuccessfully := sess.Flashes("savedSuccessfully")
+		if savedSuccessfully != nil {
+			err = sess.Save(r, w)
+			if err != nil {
+				s.internalServerError(w, r, err)
+				return
+			}
+		}
+
+		bind := map[string]interface{}{
+			"settings":          settingsInfo,
+			"savedSuccessfully": len(savedSuccessfully) > 0,
+			"csrfField":         csrf.TemplateField(r),
+		}
+
+		err = s.renderTemplate(w, r, "/layouts/menu_layout.html", "/admin_settings_password_policy.html", bind)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+	}
+}
+
+func (s *Server) handleAdminSettingsPasswordPolicyPost() http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		settings := r.Context().Value(common.ContextKeySettings).(*entities.Settings)
+
+		settingsInfo := struct {
+			PasswordMinLength               string
+			PasswordMaxLength               string
+			PasswordRequiresUppercase       bool
+			PasswordRequiresLowercase       bool
+			PasswordRequiresNumber          bool
+			PasswordRequiresSpecialChar     bool
+			PasswordDisallowUsernameOrEmail bool
+			PasswordHistoryCount            string
+			PasswordMaxAgeInDays            string
+			PasswordMinAgeInDays            string
+		}{
+			PasswordMinLength:               r.FormValue("passwordMinLength"),
+			PasswordMaxLength:               r.FormValue("passwordMaxLength"),
+			PasswordRequiresUppercase:       r.FormValue("passwordRequiresUppercase") == "on",
+			PasswordRequiresLowercase:       r.FormValue("passwordRequiresLowercase") == "on",
+			PasswordRequiresNumber:          r.FormValue("passwordRequiresNumber") == "on",
+			PasswordRequiresSpecialChar:     r.FormValue("passwordRequiresSpecialChar") == "on",
+			PasswordDisallowUsernameOrEmail: r.FormValue("passwordDisallowUsernameOrEmail") == "on",
+			PasswordHistoryCount:            r.FormValue("passwordHistoryCount"),
+			PasswordMaxAgeInDays:            r.FormValue("passwordMaxAgeInDays"),
+			PasswordMinAgeInDays:            r.FormValue("passwordMinAgeInDays"),
+		}
+
+		renderError := func(message string) {
+
+			bind := map[string]interface{}{
+				"settings":  settingsInfo,
+				"csrfField": csrf.TemplateField(r),
+				"error":     message,
+			}
+
+			err := s.renderTemplate(w, r, "/layouts/menu_layout.html", "/admin_settings_password_policy.html", bind)
+			if err != nil {
+				s.internalServerError(w, r, err)
+			}
+		}
+
+		passwordMinLengthInt, err := strconv.Atoi(settingsInfo.PasswordMinLength)
+		if err != nil {
+			settingsInfo.PasswordMinLength = strconv.Itoa(settings.PasswordMinLength)
+			renderError("Invalid value for minimum length.")
+			return
+		}
+
+		passwordMaxLengthInt, err := strconv.Atoi(settingsInfo.PasswordMaxLength)
+		if err != nil {
+			settingsInfo.PasswordMaxLength = strconv.Itoa(settings.PasswordMaxLength)
+			renderError("Invalid value for maximum length.")
+			return
+		}
+
+		passwordHistoryCountInt, err := strconv.Atoi(settingsInfo.PasswordHistoryCount)
+		if err != nil {
+			settingsInfo.PasswordHistoryCount = strconv.Itoa(settings.PasswordHistoryCount)
+			renderError("Invalid value for password history.")
+			return
+		}
+
+		passwordMaxAgeInDaysInt, err := strconv.Atoi(settingsInfo.PasswordMaxAgeInDays)
+		if err != nil {
+			settingsInfo.PasswordMaxAgeInDays = strconv.Itoa(settings.PasswordMaxAgeInDays)
+			renderError("Invalid value for maximum password age in days.")
+			return
+		}
+
+		passwordMinAgeInDaysInt, err := strconv.Atoi(settingsInfo.PasswordMinAgeInDays)
+		if err != nil {
+			settingsInfo.PasswordMinAgeInDays = strconv.Itoa(settings.PasswordMinAgeInDays)
+			renderError("Invalid value for minimum password age in days.")
+			return
+		}
+
+		// when bcrypt is configured, only the first 72 bytes of the password are used
+		const maxPasswordLength = 64
+		if passwordMinLengthInt < 1 || passwordMinLengthInt > maxPasswordLength {
+			renderError(fmt.Sprintf("Minimum length must be between 1 and %v.", maxPasswordLength))
+			return
+		}
+
+		if passwordMaxLengthInt < passwordMinLengthInt || passwordMaxLengthInt > maxPasswordLength {
+			renderError(fmt.Sprintf("Maximum length must be between the minimum length and %v.", maxPasswordLength))
+			return
+		}
+
+		const maxPasswordHistoryCount = 24
+		if passwordHistoryCountInt < 0 || passwordHistoryCountInt > maxPasswordHistoryCount {
+			renderError(fmt.Sprintf("Password history must be between 0 and %v.", maxPasswordHistoryCount))
+			return
+		}
+
+		const maxPasswordAgeInDays = 3650
+		if passwordMaxAgeInDaysInt < 0 || passwordMaxAgeInDaysInt > maxPasswordAgeInDays {
+			renderError(fmt.Sprintf("Maximum password age in days must be between 0 and %v.", maxPasswordAgeInDays))
+			return
+		}
+
+		if passwordMinAgeInDaysInt < 0 || passwordMinAgeInDaysInt > maxPasswordAgeInDays {
+			renderError(fmt.Sprintf("Minimum password age in days must be between 0 and %v.", maxPasswordAgeInDays))
+			return
+		}
+
+		if passwordMaxAgeInDaysInt > 0 && passwordMinAgeInDaysInt >= passwordMaxAgeInDaysInt {
+			renderError("Minimum password age in days must be lower than the maximum password age.")
+			return
+		}
+
+		settings.PasswordMinLength = passwordMinLengthInt
+		settings.PasswordMaxLength = passwordMaxLengthInt
+		settings.PasswordRequiresUppercase = settingsInfo.PasswordRequiresUppercase
+		settings.PasswordRequiresLowercase = settingsInfo.PasswordRequiresLowercase
+		settings.PasswordRequiresNumber = settingsInfo.PasswordRequiresNumber
+		settings.PasswordRequiresSpecialChar = settingsInfo.PasswordRequiresSpecialChar
+		settings.PasswordDisallowUsernameOrEmail = settingsInfo.PasswordDisallowUsernameOrEmail
+		settings.PasswordHistoryCount = passwordHistoryCountInt
+		settings.PasswordMaxAgeInDays = passwordMaxAgeInDaysInt
+		settings.PasswordMinAgeInDays = passwordMinAgeInDaysInt
+
+		err = s.database.UpdateSettings(nil, settings)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		lib.LogAudit(r.Context(), constants.AuditUpdatedPasswordPolicySettings, map[string]interface{}{
+			"loggedInUser": s.getLoggedInSubject(r),
+		})
+
+		sess, err := s.sessionStore.Get(r, common.SessionName)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		sess.AddFlash("true", "savedSuccessfully")
+		err = sess.Save(r, w)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		http.Redirect(w, r, fmt.Sprintf("%v/admin/settings/password-policy", lib.GetBaseUrl()), http.StatusFound)
+	}
+}
@@ -21,9 +21,11 @@ func (s *Server) handleAdminSettingsSessionsGet() http.HandlerFunc {
 		settingsInfo := struct {
 			UserSessionIdleTimeoutInSeconds int
 			UserSessionMaxLifetimeInSeconds int
+			TrustedDeviceLifetimeInDays     int
 		}{
 			UserSessionIdleTimeoutInSeconds: settings.UserSessionIdleTimeoutInSeconds,
 			UserSessionMaxLifetimeInSeconds: settings.UserSessionMaxLifetimeInSeconds,
+			TrustedDeviceLifetimeInDays:     settings.TrustedDeviceLifetimeInDays,
 		}
 
 		sess, err := s.sessionStore.Get(r, common.SessionName)
@@ -64,9 +66,11 @@ func (s *Server) handleAdminSettingsSessionsPost() http.HandlerFunc {
 		settingsInfo := struct {
 			UserSessionIdleTimeoutInSeconds string
 			UserSessionMaxLifetimeInSeconds string
+			TrustedDeviceLifetimeInDays     string
 		}{
 			UserSessionIdleTimeoutInSeconds: r.FormValue("userSessionIdleTimeoutInSeconds"),
 			UserSessionMaxLifetimeInSeconds: r.FormValue("userSessionMaxLifetimeInSeconds"),
+			TrustedDeviceLifetimeInDays:     r.FormValue("trustedDeviceLifetimeInDays"),
 		}
 
 		renderError := func(message string) {
@@ -97,6 +101,13 @@ func (s *Server) handleAdminSettingsSessionsPost() http.HandlerFunc {
 			return
 		}
 
+		trustedDeviceLifetimeInDaysInt, err := strconv.Atoi(settingsInfo.TrustedDeviceLifetimeInDays)
+		if err != nil {
+			settingsInfo.TrustedDeviceLifetimeInDays = strconv.Itoa(settings.TrustedDeviceLifetimeInDays)
+			renderError("Invalid value for trusted devices - lifetime in days.")
+			return
+		}
+
 		if userSessionIdleTimeoutInSecondsInt <= 0 {
 			renderError("User session - idle timeout in seconds must be greater than zero.")
 			return
@@ -123,8 +134,20 @@ func (s *Server) handleAdminSettingsSessionsPost() http.HandlerFunc {
 			return
 		}
 
+		if trustedDeviceLifetimeInDaysInt < 0 {
+			renderError("Trusted devices - lifetime in days cannot be negative.")
+			return
+		}
+
+		const maxTrustedDeviceLifetimeInDays = 365
+		if trustedDeviceLifetimeInDaysInt > maxTrustedDeviceLifetimeInDays {
+			renderError(fmt.Sprintf("Trusted devices - lifetime in days cannot be greater than %v.", maxTrustedDeviceLifetimeInDays))
+			return
+		}
+
 		settings.UserSessionIdleTimeoutInSeconds = userSessionIdleTimeoutInSecondsInt
 		settings.UserSessionMaxLifetimeInSeconds = userSessionMaxLifetimeInSecondsInt
+		settings.TrustedDeviceLifetimeInDays = trustedDeviceLifetimeInDaysInt
 
 		err = s.database.UpdateSettings(nil, settings)
 		if err != nil {
@@ -132,7 +155,7 @@ func (s *Server) handleAdminSettingsSessionsPost() http.HandlerFunc {
 			return
 		}
 
-		lib.LogAudit(constants.AuditUpdatedSessionsSettings, map[string]interface{}{
+		lib.LogAudit(r.Context(), constants.AuditUpdatedSessionsSettings, map[string]interface{}{
 			"loggedInUser": s.getLoggedInSubject(r),
 		})
 
@@ -37,11 +37,13 @@ func (s *Server) handleAdminSettingsSMSGet() http.HandlerFunc {
 		}
 
 		settingsInfo := struct {
-			SMSProvider  string
-			TwilioConfig dtos.SMSTwilioConfig
+			SMSProvider                  string
+			TwilioConfig                 dtos.SMSTwilioConfig
+			SMSOTPAllowedForMandatory2FA bool
 		}{
-			SMSProvider:  settings.SMSProvider,
-			TwilioConfig: smsTwilioConfig,
+			SMSProvider:                  settings.SMSProvider,
+			TwilioConfig:                 smsTwilioConfig,
+			SMSOTPAllowedForMandatory2FA: settings.SMSOTPAllowedForMandatory2FA,
 		}
 
 		sess, err := s.sessionStore.Get(r, common.SessionName)
@@ -80,6 +82,7 @@ func (s *Server) handleAdminSettingsSMSPost() http.HandlerFunc {
 		settings := r.Context().Value(common.ContextKeySettings).(*entities.Settings)
 
 		provider := r.FormValue("provider")
+		smsOTPAllowedForMandatory2FA := r.FormValue("smsOTPAllowedForMandatory2FA") == "on"
 
 		if provider == "twilio" {
 			twilioAccountSID := r.FormValue("twilioAccountSID")
@@ -87,8 +90,9 @@ func (s *Server) handleAdminSettingsSMSPost() http.HandlerFunc {
 			twilioFrom := r.FormValue("twilioFrom")
 
 			settingsInfo := struct {
-				SMSProvider  string
-				TwilioConfig dtos.SMSTwilioConfig
+				SMSProvider                  string
+				TwilioConfig                 dtos.SMSTwilioConfig
+				SMSOTPAllowedForMandatory2FA bool
 			}{
 				SMSProvider: provider,
 				TwilioConfig: dtos.SMSTwilioConfig{
@@ -96,6 +100,7 @@ func (s *Server) handleAdminSettingsSMSPost() http.HandlerFunc {
 					AuthToken:  twilioAuthToken,
 					From:       twilioFrom,
 				},
+				SMSOTPAllowedForMandatory2FA: smsOTPAllowedForMandatory2FA,
 			}
 
 			renderError := func(message string) {
@@ -161,13 +166,15 @@ func (s *Server) handleAdminSettingsSMSPost() http.HandlerFunc {
 			return
 		}
 
+		settings.SMSOTPAllowedForMandatory2FA = smsOTPAllowedForMandatory2FA
+
 		err := s.database.UpdateSettings(nil, settings)
 		if err != nil {
 			s.internalServerError(w, r, err)
 			return
 		}
 
-		lib.LogAudit(constants.AuditUpdatedSMSSettings, map[string]interface{}{
+		lib.LogAudit(r.Context(), constants.AuditUpdatedSMSSettings, map[string]interface{}{
 			"loggedInUser": s.getLoggedInSubject(r),
 		})
 
@@ -159,7 +159,7 @@ func (s *Server) handleAdminSettingsTokensPost() http.HandlerFunc {
 			return
 		}
 
-		lib.LogAudit(constants.AuditUpdatedTokensSettings, map[string]interface{}{
+		lib.LogAudit(r.Context(), constants.AuditUpdatedTokensSettings, map[string]interface{}{
 			"loggedInUser": s.getLoggedInSubject(r),
 		})
 
@@ -189,10 +189,11 @@ func (s *Server) handleAdminUserAddressPost(addressValidator addressValidator,
 			return
 		}
 
-		lib.LogAudit(constants.AuditUpdatedUserAddress, map[string]interface{}{
+		lib.LogAudit(r.Context(), constants.AuditUpdatedUserAddress, map[string]interface{}{
 			"userId":       user.Id,
 			"loggedInUser": s.getLoggedInSubject(r),
 		})
+		s.publishUserEvent(constants.WebhookEventUserUpdated, user, nil)
 
 		http.Redirect(w, r, fmt.Sprintf("%v/admin/users/%v/address?page=%v&query=%v", lib.GetBaseUrl(), user.Id,
 			r.URL.Query().Get("page"), r.URL.Query().Get("query")), http.StatusFound)
@@ -122,7 +122,7 @@ func (s *Server) handleAdminUserAttributesRemovePost() http.HandlerFunc {
 			return
 		}
 
-		lib.LogAudit(constants.AuditDeleteUserAttribute, map[string]interface{}{
+		lib.LogAudit(r.Context(), constants.AuditDeleteUserAttribute, map[string]interface{}{
 			"userId":          user.Id,
 			"userAttributeId": attributeId,
 			"loggedInUser":    s.getLoggedInSubject(r),
@@ -138,7 +138,7 @@ func (s *Server) handleAdminUserAttributesAddPost(identifierValidator identifier
 			return
 		}
 
-		lib.LogAudit(constants.AuditAddedUserAttribute, map[string]interface{}{
+		lib.LogAudit(r.Context(), constants.AuditAddedUserAttribute, map[string]interface{}{
 			"userId":          user.Id,
 			"userAttributeId": userAttribute.Id,
 			"loggedInUser":    s.getLoggedInSubject(r),
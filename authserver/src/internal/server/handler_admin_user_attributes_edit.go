@@ -167,7 +167,7 @@ func (s *Server) handleAdminUserAttributesEditPost(identifierValidator identifie
 			return
 		}
 
-		lib.LogAudit(constants.AuditUpdatedUserAttribute, map[string]interface{}{
+		lib.LogAudit(r.Context(), constants.AuditUpdatedUserAttribute, map[string]interface{}{
 			"userId":          user.Id,
 			"userAttributeId": attribute.Id,
 			"loggedInUser":    s.getLoggedInSubject(r),
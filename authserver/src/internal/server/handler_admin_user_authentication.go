@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"fmt"
 	"net/http"
+	"slices"
 	"strconv"
 
 	"github.com/pkg/errors"
@@ -40,6 +41,24 @@ func (s *Server) handleAdminUserAuthenticationGet() http.HandlerFunc {
 			return
 		}
 
+		federatedIdentities, err := s.database.GetUserFederatedIdentitiesByUserId(nil, user.Id)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		err = s.database.UserFederatedIdentitiesLoadIdentityProviders(nil, federatedIdentities)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		err = s.database.UserLoadPasskeys(nil, user)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
 		sess, err := s.sessionStore.Get(r, common.SessionName)
 		if err != nil {
 			s.internalServerError(w, r, err)
@@ -56,12 +75,14 @@ func (s *Server) handleAdminUserAuthenticationGet() http.HandlerFunc {
 		}
 
 		bind := map[string]interface{}{
-			"user":              user,
-			"otpEnabled":        user.OTPEnabled,
-			"page":              r.URL.Query().Get("page"),
-			"query":             r.URL.Query().Get("query"),
-			"savedSuccessfully": len(savedSuccessfully) > 0,
-			"csrfField":         csrf.TemplateField(r),
+			"user":                user,
+			"otpEnabled":          user.OTPEnabled,
+			"federatedIdentities": federatedIdentities,
+			"passkeys":            user.Passkeys,
+			"page":                r.URL.Query().Get("page"),
+			"query":               r.URL.Query().Get("query"),
+			"savedSuccessfully":   len(savedSuccessfully) > 0,
+			"csrfField":           csrf.TemplateField(r),
 		}
 
 		err = s.renderTemplate(w, r, "/layouts/menu_layout.html", "/admin_users_authentication.html", bind)
@@ -72,7 +93,7 @@ func (s *Server) handleAdminUserAuthenticationGet() http.HandlerFunc {
 	}
 }
 
-func (s *Server) handleAdminUserAuthenticationPost(passwordValidator passwordValidator) http.HandlerFunc {
+func (s *Server) handleAdminUserAuthenticationPost(passwordValidator passwordValidator, userPasswordManager userPasswordManager) http.HandlerFunc {
 
 	return func(w http.ResponseWriter, r *http.Request) {
 
@@ -97,14 +118,34 @@ func (s *Server) handleAdminUserAuthenticationPost(passwordValidator passwordVal
 			return
 		}
 
+		federatedIdentities, err := s.database.GetUserFederatedIdentitiesByUserId(nil, user.Id)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		err = s.database.UserFederatedIdentitiesLoadIdentityProviders(nil, federatedIdentities)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		err = s.database.UserLoadPasskeys(nil, user)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
 		renderError := func(message string) {
 			bind := map[string]interface{}{
-				"user":       user,
-				"otpEnabled": r.FormValue("otpEnabled") == "on",
-				"page":       r.URL.Query().Get("page"),
-				"query":      r.URL.Query().Get("query"),
-				"csrfField":  csrf.TemplateField(r),
-				"error":      message,
+				"user":                user,
+				"otpEnabled":          r.FormValue("otpEnabled") == "on",
+				"federatedIdentities": federatedIdentities,
+				"passkeys":            user.Passkeys,
+				"page":                r.URL.Query().Get("page"),
+				"query":               r.URL.Query().Get("query"),
+				"csrfField":           csrf.TemplateField(r),
+				"error":               message,
 			}
 
 			err := s.renderTemplate(w, r, "/layouts/menu_layout.html", "/admin_users_authentication.html", bind)
@@ -115,27 +156,29 @@ func (s *Server) handleAdminUserAuthenticationPost(passwordValidator passwordVal
 
 		newPassword := r.FormValue("newPassword")
 		if len(newPassword) > 0 {
-			err = passwordValidator.ValidatePassword(r.Context(), newPassword)
+			err = passwordValidator.ValidatePassword(r.Context(), newPassword, user)
 			if err != nil {
 				renderError(err.Error())
 				return
 			}
 
-			passwordHash, err := lib.HashPassword(newPassword)
+			err = userPasswordManager.SetPassword(r.Context(), user, newPassword)
 			if err != nil {
 				s.internalServerError(w, r, err)
 				return
 			}
-			user.PasswordHash = passwordHash
 			user.ForgotPasswordCodeEncrypted = nil
 			user.ForgotPasswordCodeIssuedAt = sql.NullTime{Valid: false}
 		}
 
+		otpDisabled := false
 		if user.OTPEnabled {
 			otpEnabled := r.FormValue("otpEnabled") == "on"
 			if !otpEnabled {
+				otpDisabled = true
 				user.OTPEnabled = false
 				user.OTPSecret = ""
+				user.OTPRecoveryCodesHashes = ""
 			}
 		}
 
@@ -145,6 +188,57 @@ func (s *Server) handleAdminUserAuthenticationPost(passwordValidator passwordVal
 			return
 		}
 
+		if len(newPassword) > 0 {
+			lib.LogAudit(r.Context(), constants.AuditChangedPassword, map[string]interface{}{
+				"userId":       user.Id,
+				"loggedInUser": s.getLoggedInSubject(r),
+			})
+			s.sendSecurityNotification(r, user, constants.AuditChangedPassword)
+		}
+		if otpDisabled {
+			lib.LogAudit(r.Context(), constants.AuditDisabledOTP, map[string]interface{}{
+				"userId":       user.Id,
+				"loggedInUser": s.getLoggedInSubject(r),
+			})
+			s.sendSecurityNotification(r, user, constants.AuditDisabledOTP)
+		}
+
+		// linked identities that were switched off are removed
+		keepFederatedIdentities := r.Form["federatedIdentity"]
+		for _, federatedIdentity := range federatedIdentities {
+			if slices.Contains(keepFederatedIdentities, strconv.FormatInt(federatedIdentity.Id, 10)) {
+				continue
+			}
+			err = s.database.DeleteUserFederatedIdentity(nil, federatedIdentity.Id)
+			if err != nil {
+				s.internalServerError(w, r, err)
+				return
+			}
+			lib.LogAudit(r.Context(), constants.AuditUnlinkedFederatedIdentity, map[string]interface{}{
+				"userId":           user.Id,
+				"identityProvider": federatedIdentity.IdentityProvider.IdentityProviderIdentifier,
+				"loggedInUser":     s.getLoggedInSubject(r),
+			})
+		}
+
+		// passkeys that were switched off are removed
+		keepPasskeys := r.Form["passkey"]
+		for _, passkey := range user.Passkeys {
+			if slices.Contains(keepPasskeys, strconv.FormatInt(passkey.Id, 10)) {
+				continue
+			}
+			err = s.database.DeleteUserPasskey(nil, passkey.Id)
+			if err != nil {
+				s.internalServerError(w, r, err)
+				return
+			}
+			lib.LogAudit(r.Context(), constants.AuditDeletedPasskey, map[string]interface{}{
+				"userId":       user.Id,
+				"passkeyId":    passkey.Id,
+				"loggedInUser": s.getLoggedInSubject(r),
+			})
+		}
+
 		sess, err := s.sessionStore.Get(r, common.SessionName)
 		if err != nil {
 			s.internalServerError(w, r, err)
@@ -158,7 +252,7 @@ func (s *Server) handleAdminUserAuthenticationPost(passwordValidator passwordVal
 			return
 		}
 
-		lib.LogAudit(constants.AuditUpdatedUserAuthentication, map[string]interface{}{
+		lib.LogAudit(r.Context(), constants.AuditUpdatedUserAuthentication, map[string]interface{}{
 			"userId":       user.Id,
 			"loggedInUser": s.getLoggedInSubject(r),
 		})
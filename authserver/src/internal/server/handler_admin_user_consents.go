@@ -168,7 +168,7 @@ func (s *Server) handleAdminUserConsentsPost() http.HandlerFunc {
 				return
 			}
 
-			lib.LogAudit(constants.AuditDeletedUserConsent, map[string]interface{}{
+			lib.LogAudit(r.Context(), constants.AuditDeletedUserConsent, map[string]interface{}{
 				"userId":       user.Id,
 				"consentId":    consentId,
 				"loggedInUser": s.getLoggedInSubject(r),
@@ -84,10 +84,11 @@ func (s *Server) handleAdminUserDeletePost() http.HandlerFunc {
 			return
 		}
 
-		lib.LogAudit(constants.AuditDeletedUser, map[string]interface{}{
+		lib.LogAudit(r.Context(), constants.AuditDeletedUser, map[string]interface{}{
 			"userId":       user.Id,
 			"loggedInUser": s.getLoggedInSubject(r),
 		})
+		s.publishUserEvent(constants.WebhookEventUserDeleted, user, nil)
 
 		http.Redirect(w, r, fmt.Sprintf("%v/admin/users/?page=%v&query=%v", lib.GetBaseUrl(),
 			r.URL.Query().Get("page"), r.URL.Query().Get("query")), http.StatusFound)
@@ -97,6 +97,7 @@ func (s *Server) handleAdminUserDetailsPost() http.HandlerFunc {
 			return
 		}
 
+		wasEnabled := user.Enabled
 		user.Enabled = r.FormValue("enabled") == "on"
 		err = s.database.UpdateUser(nil, user)
 		if err != nil {
@@ -117,10 +118,11 @@ func (s *Server) handleAdminUserDetailsPost() http.HandlerFunc {
 			return
 		}
 
-		lib.LogAudit(constants.AuditUpdatedUserDetails, map[string]interface{}{
+		lib.LogAudit(r.Context(), constants.AuditUpdatedUserDetails, map[string]interface{}{
 			"userId":       user.Id,
 			"loggedInUser": s.getLoggedInSubject(r),
 		})
+		s.publishUserUpdatedEvent(wasEnabled, user)
 
 		http.Redirect(w, r, fmt.Sprintf("%v/admin/users/%v/details?page=%v&query=%v", lib.GetBaseUrl(), user.Id,
 			r.URL.Query().Get("page"), r.URL.Query().Get("query")), http.StatusFound)
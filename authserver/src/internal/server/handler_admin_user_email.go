@@ -133,6 +133,7 @@ func (s *Server) handleAdminUserEmailPost(emailValidator emailValidator,
 			}
 		}
 
+		oldEmail := user.Email
 		user.Email = inputSanitizer.Sanitize(input.Email)
 		user.EmailVerified = r.FormValue("emailVerified") == "on"
 		user.EmailVerificationCodeEncrypted = nil
@@ -157,10 +158,15 @@ func (s *Server) handleAdminUserEmailPost(emailValidator emailValidator,
 			return
 		}
 
-		lib.LogAudit(constants.AuditUpdatedUserEmail, map[string]interface{}{
+		lib.LogAudit(r.Context(), constants.AuditUpdatedUserEmail, map[string]interface{}{
 			"userId":       user.Id,
 			"loggedInUser": s.getLoggedInSubject(r),
 		})
+		s.publishUserEvent(constants.WebhookEventUserUpdated, user, nil)
+
+		if oldEmail != user.Email {
+			s.notifyEmailChange(r, user, oldEmail)
+		}
 
 		http.Redirect(w, r, fmt.Sprintf("%v/admin/users/%v/email?page=%v&query=%v", lib.GetBaseUrl(), user.Id,
 			r.URL.Query().Get("page"), r.URL.Query().Get("query")), http.StatusFound)
@@ -169,11 +169,12 @@ func (s *Server) handleAdminUserGroupsPost() http.HandlerFunc {
 					return
 				}
 
-				lib.LogAudit(constants.AuditUserAddedToGroup, map[string]interface{}{
+				lib.LogAudit(r.Context(), constants.AuditUserAddedToGroup, map[string]interface{}{
 					"userId":       user.Id,
 					"groupId":      group.Id,
 					"loggedInUser": s.getLoggedInSubject(r),
 				})
+				s.publishUserEvent(constants.WebhookEventUserAddedToGroup, user, group)
 			}
 		}
 
@@ -212,7 +213,7 @@ func (s *Server) handleAdminUserGroupsPost() http.HandlerFunc {
 				return
 			}
 
-			lib.LogAudit(constants.AuditUserRemovedFromGroup, map[string]interface{}{
+			lib.LogAudit(r.Context(), constants.AuditUserRemovedFromGroup, map[string]interface{}{
 				"userId":       user.Id,
 				"groupId":      group.Id,
 				"loggedInUser": s.getLoggedInSubject(r),
@@ -0,0 +1,195 @@
+package server
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/gorilla/csrf"
+	"github.com/leodip/goiabada/internal/common"
+	"github.com/leodip/goiabada/internal/constants"
+	"github.com/leodip/goiabada/internal/core"
+	"github.com/leodip/goiabada/internal/entities"
+	"github.com/leodip/goiabada/internal/enums"
+	"github.com/leodip/goiabada/internal/lib"
+	"github.com/pkg/errors"
+)
+
+func (s *Server) handleAdminUserImpersonatePost(permissionChecker *core.PermissionChecker) http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		idStr := chi.URLParam(r, "userId")
+		if len(idStr) == 0 {
+			s.internalServerError(w, r, errors.WithStack(errors.New("userId is required")))
+			return
+		}
+
+		id, err := strconv.ParseInt(idStr, 10, 64)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+		user, err := s.database.GetUserById(nil, id)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+		if user == nil {
+			s.internalServerError(w, r, errors.WithStack(errors.New("user not found")))
+			return
+		}
+
+		renderError := func(message string) {
+			bind := map[string]interface{}{
+				"user":               user,
+				"page":               r.URL.Query().Get("page"),
+				"query":              r.URL.Query().Get("query"),
+				"durationInMinutes":  r.FormValue("durationInMinutes"),
+				"impersonationError": message,
+				"csrfField":          csrf.TemplateField(r),
+			}
+
+			err := s.renderTemplate(w, r, "/layouts/menu_layout.html", "/admin_users_details.html", bind)
+			if err != nil {
+				s.internalServerError(w, r, err)
+			}
+		}
+
+		const maxDurationInMinutes = 480
+		durationInMinutes, err := strconv.Atoi(r.FormValue("durationInMinutes"))
+		if err != nil || durationInMinutes < 1 || durationInMinutes > maxDurationInMinutes {
+			renderError(fmt.Sprintf("The duration of the impersonation must be between 1 and %v minutes.", maxDurationInMinutes))
+			return
+		}
+
+		if !user.Enabled {
+			renderError("A disabled user can't be impersonated.")
+			return
+		}
+
+		admin, err := s.database.GetUserBySubject(nil, s.getLoggedInSubject(r))
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+		if admin == nil {
+			s.internalServerError(w, r, errors.WithStack(errors.New("logged in user not found")))
+			return
+		}
+		if admin.Id == user.Id {
+			renderError("You can't impersonate yourself.")
+			return
+		}
+
+		// impersonated sessions never give access to the admin area
+		isAdmin, err := permissionChecker.UserHasScopePermission(user.Id,
+			constants.AuthServerResourceIdentifier+":"+constants.AdminWebsitePermissionIdentifier)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+		if isAdmin {
+			renderError("Users with access to the admin area can't be impersonated.")
+			return
+		}
+
+		// the impersonated session keeps the authentication of the admin
+		adminSessionIdentifier := ""
+		if r.Context().Value(common.ContextKeySessionIdentifier) != nil {
+			adminSessionIdentifier = r.Context().Value(common.ContextKeySessionIdentifier).(string)
+		}
+		adminSession, err := s.database.GetUserSessionBySessionIdentifier(nil, adminSessionIdentifier)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+		if adminSession == nil {
+			s.internalServerError(w, r, errors.WithStack(errors.New("the session of the admin was not found")))
+			return
+		}
+
+		utcNow := time.Now().UTC()
+		userSession := &entities.UserSession{
+			SessionIdentifier:      uuid.New().String(),
+			Started:                utcNow,
+			LastAccessed:           utcNow,
+			IpAddress:              getClientIpAddress(r),
+			AuthMethods:            adminSession.AuthMethods,
+			AcrLevel:               adminSession.AcrLevel,
+			AuthTime:               utcNow,
+			UserId:                 user.Id,
+			DeviceName:             lib.GetDeviceName(r),
+			DeviceType:             lib.GetDeviceType(r),
+			DeviceOS:               lib.GetDeviceOS(r),
+			ImpersonatorUserId:     sql.NullInt64{Int64: admin.Id, Valid: true},
+			ImpersonationExpiresAt: sql.NullTime{Time: utcNow.Add(time.Duration(durationInMinutes) * time.Minute), Valid: true},
+		}
+		if len(userSession.AcrLevel) == 0 {
+			userSession.AcrLevel = enums.AcrLevel1.String()
+		}
+		err = s.database.CreateUserSession(nil, userSession)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		// the session of the admin is restored when the impersonation ends
+		sess, err := s.sessionStore.Get(r, common.SessionName)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+		sess.Values[common.SessionKeyImpersonatorSessionIdentifier] = adminSessionIdentifier
+		sess.Values[common.SessionKeySessionIdentifier] = userSession.SessionIdentifier
+		delete(sess.Values, common.SessionKeyJwt)
+		err = sess.Save(r, w)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		lib.LogAudit(r.Context(), constants.AuditStartedImpersonation, map[string]interface{}{
+			"userId":             user.Id,
+			"impersonatorUserId": admin.Id,
+			"durationInMinutes":  durationInMinutes,
+			"loggedInUser":       s.getLoggedInSubject(r),
+		})
+
+		http.Redirect(w, r, lib.GetBaseUrl()+"/", http.StatusFound)
+	}
+}
+
+func (s *Server) handleImpersonationStopPost() http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		userSession, ok := r.Context().Value(common.ContextKeyImpersonatedUserSession).(*entities.UserSession)
+		if !ok || userSession == nil {
+			http.Redirect(w, r, lib.GetBaseUrl()+"/", http.StatusFound)
+			return
+		}
+
+		sess, err := s.sessionStore.Get(r, common.SessionName)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+		err = endImpersonation(r.Context(), s.database, sess, userSession)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+		err = sess.Save(r, w)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		http.Redirect(w, r, fmt.Sprintf("%v/admin/users/%v/details", lib.GetBaseUrl(), userSession.UserId), http.StatusFound)
+	}
+}
@@ -118,7 +118,7 @@ func (s *Server) handleAdminUserNewPost(userCreator userCreator, profileValidato
 		passwordHash := ""
 		if (settings.SMTPEnabled && setPasswordType == "now") || !settings.SMTPEnabled {
 			formPassword := r.FormValue("password")
-			err := passwordValidator.ValidatePassword(r.Context(), formPassword)
+			err := passwordValidator.ValidatePassword(r.Context(), formPassword, &entities.User{Email: email})
 			if err != nil {
 				renderError(err.Error())
 				return
@@ -147,49 +147,14 @@ func (s *Server) handleAdminUserNewPost(userCreator userCreator, profileValidato
 			return
 		}
 
-		lib.LogAudit(constants.AuditCreatedUser, map[string]interface{}{
+		lib.LogAudit(r.Context(), constants.AuditCreatedUser, map[string]interface{}{
 			"email":        user.Email,
 			"loggedInUser": s.getLoggedInSubject(r),
 		})
+		s.publishUserEvent(constants.WebhookEventUserCreated, user, nil)
 
 		if settings.SMTPEnabled && setPasswordType == "email" {
-			verificationCode := lib.GenerateSecureRandomString(32)
-			verificationCodeEncrypted, err := lib.EncryptText(verificationCode, settings.AESEncryptionKey)
-			if err != nil {
-				s.internalServerError(w, r, err)
-				return
-			}
-
-			user.ForgotPasswordCodeEncrypted = verificationCodeEncrypted
-			utcNow := time.Now().UTC()
-			user.ForgotPasswordCodeIssuedAt = sql.NullTime{Time: utcNow, Valid: true}
-			err = s.database.UpdateUser(nil, user)
-			if err != nil {
-				s.internalServerError(w, r, err)
-				return
-			}
-
-			name := user.GetFullName()
-			if len(name) == 0 {
-				name = user.Email
-			}
-
-			bind := map[string]interface{}{
-				"name": name,
-				"link": lib.GetBaseUrl() + "/reset-password?email=" + user.Email + "&code=" + verificationCode,
-			}
-			buf, err := s.renderTemplateToBuffer(r, "/layouts/email_layout.html", "/emails/email_newuser_set_password.html", bind)
-			if err != nil {
-				s.internalServerError(w, r, err)
-				return
-			}
-
-			input := &core_senders.SendEmailInput{
-				To:       user.Email,
-				Subject:  settings.AppName + " - create a password for your new account",
-				HtmlBody: buf.String(),
-			}
-			err = emailSender.SendEmail(r.Context(), input)
+			err = s.sendSetPasswordEmail(r, user, emailSender)
 			if err != nil {
 				s.internalServerError(w, r, err)
 				return
@@ -212,3 +177,45 @@ func (s *Server) handleAdminUserNewPost(userCreator userCreator, profileValidato
 			r.URL.Query().Get("page"), r.URL.Query().Get("query")), http.StatusFound)
 	}
 }
+
+// sendSetPasswordEmail sends an email to the new user with a link to create a password. The link
+// holds a new forgot password code of the user.
+func (s *Server) sendSetPasswordEmail(r *http.Request, user *entities.User, emailSender emailSender) error {
+
+	settings := r.Context().Value(common.ContextKeySettings).(*entities.Settings)
+
+	verificationCode := lib.GenerateSecureRandomString(32)
+	verificationCodeEncrypted, err := lib.EncryptText(verificationCode, settings.AESEncryptionKey)
+	if err != nil {
+		return err
+	}
+
+	user.ForgotPasswordCodeEncrypted = verificationCodeEncrypted
+	utcNow := time.Now().UTC()
+	user.ForgotPasswordCodeIssuedAt = sql.NullTime{Time: utcNow, Valid: true}
+	err = s.database.UpdateUser(nil, user)
+	if err != nil {
+		return err
+	}
+
+	name := user.GetFullName()
+	if len(name) == 0 {
+		name = user.Email
+	}
+
+	bind := map[string]interface{}{
+		"name": name,
+		"link": lib.GetBaseUrl() + "/reset-password?email=" + user.Email + "&code=" + verificationCode,
+	}
+	buf, err := s.renderTemplateToBuffer(r, "/layouts/email_layout.html", "/emails/email_newuser_set_password.html", bind)
+	if err != nil {
+		return err
+	}
+
+	input := &core_senders.SendEmailInput{
+		To:       user.Email,
+		Subject:  settings.AppName + " - create a password for your new account",
+		HtmlBody: buf.String(),
+	}
+	return emailSender.SendEmail(r.Context(), input)
+}
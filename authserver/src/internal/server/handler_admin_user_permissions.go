@@ -182,7 +182,7 @@ func (s *Server) handleAdminUserPermissionsPost() http.HandlerFunc {
 					return
 				}
 
-				lib.LogAudit(constants.AuditAddedUserPermission, map[string]interface{}{
+				lib.LogAudit(r.Context(), constants.AuditAddedUserPermission, map[string]interface{}{
 					"userId":       user.Id,
 					"permissionId": permission.Id,
 					"loggedInUser": s.getLoggedInSubject(r),
@@ -219,7 +219,7 @@ func (s *Server) handleAdminUserPermissionsPost() http.HandlerFunc {
 				return
 			}
 
-			lib.LogAudit(constants.AuditDeletedUserPermission, map[string]interface{}{
+			lib.LogAudit(r.Context(), constants.AuditDeletedUserPermission, map[string]interface{}{
 				"userId":       user.Id,
 				"permissionId": permissionId,
 				"loggedInUser": s.getLoggedInSubject(r),
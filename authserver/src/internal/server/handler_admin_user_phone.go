@@ -174,10 +174,11 @@ func (s *Server) handleAdminUserPhonePost(phoneValidator phoneValidator,
 			return
 		}
 
-		lib.LogAudit(constants.AuditUpdatedUserPhone, map[string]interface{}{
+		lib.LogAudit(r.Context(), constants.AuditUpdatedUserPhone, map[string]interface{}{
 			"userId":       user.Id,
 			"loggedInUser": s.getLoggedInSubject(r),
 		})
+		s.publishUserEvent(constants.WebhookEventUserUpdated, user, nil)
 
 		http.Redirect(w, r, fmt.Sprintf("%v/admin/users/%v/phone?page=%v&query=%v", lib.GetBaseUrl(), user.Id,
 			r.URL.Query().Get("page"), r.URL.Query().Get("query")), http.StatusFound)
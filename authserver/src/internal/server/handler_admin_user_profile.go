@@ -220,10 +220,11 @@ func (s *Server) handleAdminUserProfilePost(profileValidator profileValidator,
 			return
 		}
 
-		lib.LogAudit(constants.AuditUpdatedUserProfile, map[string]interface{}{
+		lib.LogAudit(r.Context(), constants.AuditUpdatedUserProfile, map[string]interface{}{
 			"userId":       user.Id,
 			"loggedInUser": s.getLoggedInSubject(r),
 		})
+		s.publishUserEvent(constants.WebhookEventUserUpdated, user, nil)
 
 		http.Redirect(w, r, fmt.Sprintf("%v/admin/users/%v/profile?page=%v&query=%v", lib.GetBaseUrl(), user.Id,
 			r.URL.Query().Get("page"), r.URL.Query().Get("query")), http.StatusFound)
@@ -33,6 +33,17 @@ func (s *Server) handleAdminUserSessionsGet() http.HandlerFunc {
 		Clients                   []string
 	}
 
+	type trustedDeviceInfo struct {
+		TrustedDeviceId int64
+		TrustedAt       string
+		ExpiresAt       string
+		LastUsedAt      string
+		IpAddress       string
+		DeviceName      string
+		DeviceType      string
+		DeviceOS        string
+	}
+
 	return func(w http.ResponseWriter, r *http.Request) {
 
 		settings := r.Context().Value(common.ContextKeySettings).(*entities.Settings)
@@ -112,12 +123,43 @@ func (s *Server) handleAdminUserSessionsGet() http.HandlerFunc {
 			return sessionInfoArr[i].UserSessionId > sessionInfoArr[j].UserSessionId
 		})
 
+		trustedDevices, err := s.database.GetUserTrustedDevicesByUserId(nil, user.Id)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		trustedDeviceInfoArr := []trustedDeviceInfo{}
+		for _, td := range trustedDevices {
+			if td.IsExpired() {
+				continue
+			}
+			tdi := trustedDeviceInfo{
+				TrustedDeviceId: td.Id,
+				TrustedAt:       td.CreatedAt.Time.Format(time.RFC1123),
+				ExpiresAt:       td.ExpiresAt.Format(time.RFC1123),
+				IpAddress:       td.IpAddress,
+				DeviceName:      td.DeviceName,
+				DeviceType:      td.DeviceType,
+				DeviceOS:        td.DeviceOS,
+			}
+			if td.LastUsedAt.Valid {
+				tdi.LastUsedAt = td.LastUsedAt.Time.Format(time.RFC1123)
+			}
+			trustedDeviceInfoArr = append(trustedDeviceInfoArr, tdi)
+		}
+
+		sort.Slice(trustedDeviceInfoArr, func(i, j int) bool {
+			return trustedDeviceInfoArr[i].TrustedDeviceId > trustedDeviceInfoArr[j].TrustedDeviceId
+		})
+
 		bind := map[string]interface{}{
-			"user":      user,
-			"sessions":  sessionInfoArr,
-			"page":      r.URL.Query().Get("page"),
-			"query":     r.URL.Query().Get("query"),
-			"csrfField": csrf.TemplateField(r),
+			"user":           user,
+			"sessions":       sessionInfoArr,
+			"trustedDevices": trustedDeviceInfoArr,
+			"page":           r.URL.Query().Get("page"),
+			"query":          r.URL.Query().Get("query"),
+			"csrfField":      csrf.TemplateField(r),
 		}
 
 		err = s.renderTemplate(w, r, "/layouts/menu_layout.html", "/admin_users_sessions.html", bind)
@@ -180,7 +222,7 @@ func (s *Server) handleAdminUserSessionsPost() http.HandlerFunc {
 					return
 				}
 
-				lib.LogAudit(constants.AuditDeletedUserSession, map[string]interface{}{
+				lib.LogAudit(r.Context(), constants.AuditDeletedUserSession, map[string]interface{}{
 					"userSessionId": us.Id,
 					"loggedInUser":  s.getLoggedInSubject(r),
 				})
@@ -197,3 +239,57 @@ func (s *Server) handleAdminUserSessionsPost() http.HandlerFunc {
 		}
 	}
 }
+
+func (s *Server) handleAdminUserTrustedDevicesRevokePost() http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		idStr := chi.URLParam(r, "userId")
+		if len(idStr) == 0 {
+			s.jsonError(w, r, errors.WithStack(errors.New("userId is required")))
+			return
+		}
+
+		id, err := strconv.ParseInt(idStr, 10, 64)
+		if err != nil {
+			s.jsonError(w, r, err)
+			return
+		}
+		user, err := s.database.GetUserById(nil, id)
+		if err != nil {
+			s.jsonError(w, r, err)
+			return
+		}
+		if user == nil {
+			s.jsonError(w, r, errors.WithStack(errors.New("user not found")))
+			return
+		}
+
+		var data map[string]interface{}
+		decoder := json.NewDecoder(r.Body)
+		if err := decoder.Decode(&data); err != nil {
+			s.jsonError(w, r, err)
+			return
+		}
+
+		trustedDeviceId, ok := data["trustedDeviceId"].(float64)
+		if !ok || trustedDeviceId == 0 {
+			s.jsonError(w, r, errors.WithStack(errors.New("could not find trusted device id to revoke")))
+			return
+		}
+
+		err = s.revokeTrustedDevice(r, user, int64(trustedDeviceId))
+		if err != nil {
+			s.jsonError(w, r, err)
+			return
+		}
+
+		result := struct {
+			Success bool
+		}{
+			Success: true,
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(result)
+	}
+}
@@ -0,0 +1,135 @@
+package server
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/csrf"
+	"github.com/leodip/goiabada/internal/constants"
+	core_users "github.com/leodip/goiabada/internal/core/users"
+	"github.com/leodip/goiabada/internal/customerrors"
+	"github.com/leodip/goiabada/internal/entities"
+	"github.com/leodip/goiabada/internal/lib"
+)
+
+func (s *Server) handleAdminUsersExportGet() http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		groups, err := s.database.GetAllGroups(nil)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		bind := map[string]interface{}{
+			"groups":    groups,
+			"groupId":   "",
+			"format":    "csv",
+			"csrfField": csrf.TemplateField(r),
+		}
+
+		err = s.renderTemplate(w, r, "/layouts/menu_layout.html", "/admin_users_export.html", bind)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+	}
+}
+
+func (s *Server) handleAdminUsersExportPost(
+	userExporter userExporter,
+) http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		groups, err := s.database.GetAllGroups(nil)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		renderError := func(message string) {
+			bind := map[string]interface{}{
+				"groups":    groups,
+				"groupId":   r.FormValue("groupId"),
+				"format":    r.FormValue("format"),
+				"error":     message,
+				"csrfField": csrf.TemplateField(r),
+			}
+
+			err := s.renderTemplate(w, r, "/layouts/menu_layout.html", "/admin_users_export.html", bind)
+			if err != nil {
+				s.internalServerError(w, r, err)
+			}
+		}
+
+		format := r.FormValue("format")
+		if format != "csv" && format != "json" {
+			renderError("Please select the format of the file.")
+			return
+		}
+
+		var group *entities.Group
+		if len(r.FormValue("groupId")) > 0 {
+			groupId, err := strconv.ParseInt(r.FormValue("groupId"), 10, 64)
+			if err != nil {
+				renderError("Invalid group.")
+				return
+			}
+			for _, g := range groups {
+				if g.Id == groupId {
+					group = g
+					break
+				}
+			}
+			if group == nil {
+				renderError("Group not found.")
+				return
+			}
+		}
+
+		groupId := int64(0)
+		if group != nil {
+			groupId = group.Id
+		}
+		records, err := userExporter.Export(groupId)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		var buf bytes.Buffer
+		err = core_users.WriteRecords(&buf, records, format)
+		if err != nil {
+			if valError, ok := err.(*customerrors.ValidationError); ok {
+				renderError(valError.Description)
+			} else {
+				s.internalServerError(w, r, err)
+			}
+			return
+		}
+
+		auditDetails := map[string]interface{}{
+			"format":       format,
+			"userCount":    len(records),
+			"loggedInUser": s.getLoggedInSubject(r),
+		}
+		if group != nil {
+			auditDetails["groupIdentifier"] = group.GroupIdentifier
+		}
+		lib.LogAudit(r.Context(), constants.AuditExportedUsers, auditDetails)
+
+		contentType := "text/csv; charset=utf-8"
+		if format == "json" {
+			contentType = "application/json"
+		}
+		fileName := fmt.Sprintf("users-%v.%v", time.Now().UTC().Format("20060102"), format)
+		w.Header().Set("Content-Type", contentType)
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", fileName))
+		w.Write(buf.Bytes())
+	}
+}
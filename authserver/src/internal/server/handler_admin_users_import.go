@@ -0,0 +1,158 @@
+package server
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+
+	"github.com/gorilla/csrf"
+	"github.com/leodip/goiabada/internal/common"
+	"github.com/leodip/goiabada/internal/constants"
+	core_users "github.com/leodip/goiabada/internal/core/users"
+	"github.com/leodip/goiabada/internal/customerrors"
+	"github.com/leodip/goiabada/internal/entities"
+	"github.com/leodip/goiabada/internal/lib"
+	"github.com/pkg/errors"
+)
+
+const maxUsersImportFileSize = 10 * 1024 * 1024
+
+func (s *Server) handleAdminUsersImportGet() http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		settings := r.Context().Value(common.ContextKeySettings).(*entities.Settings)
+
+		bind := map[string]interface{}{
+			"smtpEnabled": settings.SMTPEnabled,
+			"dryRun":      true,
+			"fields":      core_users.Fields,
+			"csrfField":   csrf.TemplateField(r),
+		}
+
+		err := s.renderTemplate(w, r, "/layouts/menu_layout.html", "/admin_users_import.html", bind)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+	}
+}
+
+func (s *Server) handleAdminUsersImportPost(
+	userImporter userImporter,
+	emailSender emailSender,
+) http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		settings := r.Context().Value(common.ContextKeySettings).(*entities.Settings)
+
+		dryRun := r.FormValue("dryRun") == "on"
+		sendInvitations := r.FormValue("sendInvitations") == "on" && settings.SMTPEnabled
+
+		bind := map[string]interface{}{
+			"smtpEnabled":     settings.SMTPEnabled,
+			"dryRun":          dryRun,
+			"sendInvitations": sendInvitations,
+			"columnMapping":   r.FormValue("columnMapping"),
+			"fields":          core_users.Fields,
+			"csrfField":       csrf.TemplateField(r),
+		}
+
+		render := func() {
+			err := s.renderTemplate(w, r, "/layouts/menu_layout.html", "/admin_users_import.html", bind)
+			if err != nil {
+				s.internalServerError(w, r, err)
+			}
+		}
+
+		renderError := func(err error) {
+			if valError, ok := err.(*customerrors.ValidationError); ok {
+				bind["error"] = valError.Description
+				render()
+			} else {
+				s.internalServerError(w, r, err)
+			}
+		}
+
+		columnMapping, err := core_users.ParseColumnMapping(r.FormValue("columnMapping"))
+		if err != nil {
+			renderError(err)
+			return
+		}
+
+		file, fileHeader, err := r.FormFile("usersFile")
+		if err != nil {
+			if errors.Is(err, http.ErrMissingFile) {
+				renderError(customerrors.NewValidationError("", "Please select a file to import."))
+				return
+			}
+			s.internalServerError(w, r, err)
+			return
+		}
+		defer file.Close()
+
+		if fileHeader.Size > maxUsersImportFileSize {
+			renderError(customerrors.NewValidationError("", fmt.Sprintf("The file is too large. Please split it in files of up to %v MB.",
+				maxUsersImportFileSize/1024/1024)))
+			return
+		}
+
+		data, err := io.ReadAll(file)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		records, ignoredColumns, err := core_users.ReadRecords(data, columnMapping)
+		if err != nil {
+			renderError(err)
+			return
+		}
+
+		result, err := userImporter.Import(r.Context(), records, dryRun)
+		if err != nil {
+			renderError(err)
+			return
+		}
+
+		invitationsSent := 0
+		for _, user := range result.CreatedUsers {
+			lib.LogAudit(r.Context(), constants.AuditCreatedUser, map[string]interface{}{
+				"email":        user.Email,
+				"loggedInUser": s.getLoggedInSubject(r),
+			})
+			s.publishUserEvent(constants.WebhookEventUserCreated, user, nil)
+			for idx := range user.Groups {
+				s.publishUserEvent(constants.WebhookEventUserAddedToGroup, user, &user.Groups[idx])
+			}
+
+			// users with an imported password hash don't need to create a password
+			if sendInvitations && len(user.PasswordHash) == 0 {
+				err = s.sendSetPasswordEmail(r, user, emailSender)
+				if err != nil {
+					slog.Error(fmt.Sprintf("unable to send the invitation to %v: %+v", user.Email, err))
+					continue
+				}
+				invitationsSent++
+			}
+		}
+
+		if !dryRun {
+			lib.LogAudit(r.Context(), constants.AuditImportedUsers, map[string]interface{}{
+				"fileName":        fileHeader.Filename,
+				"createdCount":    result.Created,
+				"skippedCount":    result.Skipped,
+				"failedCount":     result.Failed,
+				"invitationsSent": invitationsSent,
+				"loggedInUser":    s.getLoggedInSubject(r),
+			})
+		}
+
+		bind["result"] = result
+		bind["ignoredColumns"] = ignoredColumns
+		bind["invitationsSent"] = invitationsSent
+		render()
+	}
+}
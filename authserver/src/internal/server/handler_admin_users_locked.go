@@ -0,0 +1,181 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/csrf"
+	"github.com/leodip/goiabada/internal/common"
+	"github.com/leodip/goiabada/internal/constants"
+	"github.com/leodip/goiabada/internal/lib"
+	"github.com/pkg/errors"
+)
+
+func (s *Server) handleAdminUsersLockedGet() http.HandlerFunc {
+
+	type lockedUserInfo struct {
+		UserId      int64
+		Subject     string
+		Email       string
+		Name        string
+		LockedUntil string
+	}
+
+	type lockedIpAddressInfo struct {
+		FailedLoginIpId int64
+		IpAddress       string
+		LockedUntil     string
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		users, err := s.database.GetLockedUsers(nil)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		// expired lockouts are kept until the next failed or successful login, so they are filtered out here
+		lockedUsers := []lockedUserInfo{}
+		for _, user := range users {
+			if !user.IsLocked() {
+				continue
+			}
+			lockedUsers = append(lockedUsers, lockedUserInfo{
+				UserId:      user.Id,
+				Subject:     user.Subject.String(),
+				Email:       user.Email,
+				Name:        user.GetFullName(),
+				LockedUntil: user.LockedUntil.Time.Format(time.RFC1123),
+			})
+		}
+
+		failedLoginIps, err := s.database.GetLockedFailedLoginIps(nil)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		lockedIpAddresses := []lockedIpAddressInfo{}
+		for _, failedLoginIp := range failedLoginIps {
+			if !failedLoginIp.IsLocked() {
+				continue
+			}
+			lockedIpAddresses = append(lockedIpAddresses, lockedIpAddressInfo{
+				FailedLoginIpId: failedLoginIp.Id,
+				IpAddress:       failedLoginIp.IpAddress,
+				LockedUntil:     failedLoginIp.LockedUntil.Time.Format(time.RFC1123),
+			})
+		}
+
+		sess, err := s.sessionStore.Get(r, common.SessionName)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		unlockedSuccessfully := sess.Flashes("unlockedSuccessfully")
+		if unlockedSuccessfully != nil {
+			err = sess.Save(r, w)
+			if err != nil {
+				s.internalServerError(w, r, err)
+				return
+			}
+		}
+
+		bind := map[string]interface{}{
+			"lockedUsers":          lockedUsers,
+			"lockedIpAddresses":    lockedIpAddresses,
+			"unlockedSuccessfully": len(unlockedSuccessfully) > 0,
+			"csrfField":            csrf.TemplateField(r),
+		}
+
+		err = s.renderTemplate(w, r, "/layouts/menu_layout.html", "/admin_users_locked.html", bind)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+	}
+}
+
+func (s *Server) handleAdminUsersLockedPost(loginLockoutManager loginLockoutManager) http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		if userIdStr := r.FormValue("unlockUserId"); len(userIdStr) > 0 {
+			userId, err := strconv.ParseInt(userIdStr, 10, 64)
+			if err != nil {
+				s.internalServerError(w, r, err)
+				return
+			}
+
+			user, err := s.database.GetUserById(nil, userId)
+			if err != nil {
+				s.internalServerError(w, r, err)
+				return
+			}
+			if user == nil {
+				s.internalServerError(w, r, errors.WithStack(errors.New("user not found")))
+				return
+			}
+
+			err = loginLockoutManager.UnlockUser(user)
+			if err != nil {
+				s.internalServerError(w, r, err)
+				return
+			}
+
+			lib.LogAudit(r.Context(), constants.AuditUnlockedUser, map[string]interface{}{
+				"userId":       user.Id,
+				"loggedInUser": s.getLoggedInSubject(r),
+			})
+		} else if failedLoginIpIdStr := r.FormValue("unlockFailedLoginIpId"); len(failedLoginIpIdStr) > 0 {
+			failedLoginIpId, err := strconv.ParseInt(failedLoginIpIdStr, 10, 64)
+			if err != nil {
+				s.internalServerError(w, r, err)
+				return
+			}
+
+			failedLoginIp, err := s.database.GetFailedLoginIpById(nil, failedLoginIpId)
+			if err != nil {
+				s.internalServerError(w, r, err)
+				return
+			}
+			if failedLoginIp == nil {
+				s.internalServerError(w, r, errors.WithStack(errors.New("IP address not found")))
+				return
+			}
+
+			err = loginLockoutManager.UnlockIpAddress(failedLoginIp.Id)
+			if err != nil {
+				s.internalServerError(w, r, err)
+				return
+			}
+
+			lib.LogAudit(r.Context(), constants.AuditUnlockedIpAddress, map[string]interface{}{
+				"ipAddress":    failedLoginIp.IpAddress,
+				"loggedInUser": s.getLoggedInSubject(r),
+			})
+		} else {
+			s.internalServerError(w, r, errors.WithStack(errors.New("nothing to unlock")))
+			return
+		}
+
+		sess, err := s.sessionStore.Get(r, common.SessionName)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		sess.AddFlash("true", "unlockedSuccessfully")
+		err = sess.Save(r, w)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		http.Redirect(w, r, fmt.Sprintf("%v/admin/users/locked", lib.GetBaseUrl()), http.StatusFound)
+	}
+}
@@ -0,0 +1,179 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/gorilla/csrf"
+	"github.com/leodip/goiabada/internal/common"
+	"github.com/leodip/goiabada/internal/constants"
+	"github.com/leodip/goiabada/internal/lib"
+	"github.com/pkg/errors"
+	"github.com/unknwon/paginater"
+)
+
+func (s *Server) handleAdminWebhookDeliveriesGet() http.HandlerFunc {
+
+	type deliveryResult struct {
+		Id               int64
+		CreatedAt        string
+		EventId          string
+		EventType        string
+		Payload          string
+		Status           string
+		Attempts         int
+		NextAttemptAt    string
+		LastAttemptAt    string
+		LastResponseCode int
+		LastError        string
+	}
+
+	type pageResult struct {
+		Deliveries []deliveryResult
+		Total      int
+		Page       int
+		PageSize   int
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		webhook, err := s.getWebhookFromURL(r)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		pageInt, err := strconv.Atoi(r.URL.Query().Get("page"))
+		if err != nil {
+			pageInt = 1
+		}
+		if pageInt < 1 {
+			pageInt = 1
+		}
+
+		const pageSize = 20
+		deliveries, total, err := s.database.GetWebhookDeliveriesPaginated(nil, webhook.Id, pageInt, pageSize)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		result := pageResult{
+			Deliveries: make([]deliveryResult, 0),
+			Total:      total,
+			Page:       pageInt,
+			PageSize:   pageSize,
+		}
+
+		const dateTimeFormat = "2006-01-02 15:04:05"
+		for _, delivery := range deliveries {
+			item := deliveryResult{
+				Id:               delivery.Id,
+				CreatedAt:        delivery.CreatedAt.Time.UTC().Format(dateTimeFormat),
+				EventId:          delivery.EventId,
+				EventType:        delivery.EventType,
+				Payload:          delivery.Payload,
+				Status:           delivery.Status,
+				Attempts:         delivery.Attempts,
+				LastResponseCode: delivery.LastResponseCode,
+				LastError:        delivery.LastError,
+			}
+			if delivery.NextAttemptAt.Valid && delivery.Status == constants.WebhookDeliveryStatusPending {
+				item.NextAttemptAt = delivery.NextAttemptAt.Time.UTC().Format(dateTimeFormat)
+			}
+			if delivery.LastAttemptAt.Valid {
+				item.LastAttemptAt = delivery.LastAttemptAt.Time.UTC().Format(dateTimeFormat)
+			}
+			result.Deliveries = append(result.Deliveries, item)
+		}
+
+		sess, err := s.sessionStore.Get(r, common.SessionName)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		redelivered := sess.Flashes("redelivered")
+		if redelivered != nil {
+			err = sess.Save(r, w)
+			if err != nil {
+				s.internalServerError(w, r, err)
+				return
+			}
+		}
+
+		bind := map[string]interface{}{
+			"webhook":       webhook,
+			"pageResult":    result,
+			"paginator":     paginater.New(result.Total, pageSize, pageInt, 5),
+			"paginatorLink": fmt.Sprintf("/admin/webhooks/%v/deliveries", webhook.Id),
+			"redelivered":   len(redelivered) > 0,
+			"csrfField":     csrf.TemplateField(r),
+		}
+
+		err = s.renderTemplate(w, r, "/layouts/menu_layout.html", "/admin_webhooks_deliveries.html", bind)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+	}
+}
+
+// handleAdminWebhookDeliveryRedeliverPost sends the event of the delivery again, as a new delivery.
+func (s *Server) handleAdminWebhookDeliveryRedeliverPost(webhookPublisher webhookPublisher) http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		webhook, err := s.getWebhookFromURL(r)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		deliveryId, err := strconv.ParseInt(chi.URLParam(r, "deliveryId"), 10, 64)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		delivery, err := s.database.GetWebhookDeliveryById(nil, deliveryId)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+		if delivery == nil || delivery.WebhookId != webhook.Id {
+			s.internalServerError(w, r, errors.WithStack(errors.New(fmt.Sprintf("webhook delivery %v not found", deliveryId))))
+			return
+		}
+
+		redelivery, err := webhookPublisher.Redeliver(nil, delivery)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		sess, err := s.sessionStore.Get(r, common.SessionName)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		sess.AddFlash("true", "redelivered")
+		err = sess.Save(r, w)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		lib.LogAudit(r.Context(), constants.AuditRedeliveredWebhookEvent, map[string]interface{}{
+			"webhookId":         webhook.Id,
+			"eventId":           delivery.EventId,
+			"webhookDeliveryId": redelivery.Id,
+			"loggedInUser":      s.getLoggedInSubject(r),
+		})
+
+		http.Redirect(w, r, fmt.Sprintf("%v/admin/webhooks/%v/deliveries", lib.GetBaseUrl(), webhook.Id), http.StatusFound)
+	}
+}
@@ -0,0 +1,95 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/csrf"
+	"github.com/leodip/goiabada/internal/common"
+	"github.com/leodip/goiabada/internal/constants"
+	"github.com/leodip/goiabada/internal/customerrors"
+	"github.com/leodip/goiabada/internal/entities"
+	"github.com/leodip/goiabada/internal/lib"
+)
+
+func (s *Server) handleAdminWebhookNewGet() http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		bind := map[string]interface{}{
+			"events":    getWebhookEventOptions(nil),
+			"enabled":   true,
+			"csrfField": csrf.TemplateField(r),
+		}
+
+		err := s.renderTemplate(w, r, "/layouts/menu_layout.html", "/admin_webhooks_new.html", bind)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+	}
+}
+
+func (s *Server) handleAdminWebhookNewPost(inputSanitizer inputSanitizer) http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		err := r.ParseForm()
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		webhookUrl, description, eventTypes, err := getWebhookInput(r, inputSanitizer)
+		if err != nil {
+			if valError, ok := err.(*customerrors.ValidationError); ok {
+				bind := map[string]interface{}{
+					"url":         r.FormValue("url"),
+					"description": r.FormValue("description"),
+					"events":      getWebhookEventOptions(r.Form["eventTypes"]),
+					"enabled":     r.FormValue("enabled") == "on",
+					"error":       valError.Description,
+					"csrfField":   csrf.TemplateField(r),
+				}
+
+				err = s.renderTemplate(w, r, "/layouts/menu_layout.html", "/admin_webhooks_new.html", bind)
+				if err != nil {
+					s.internalServerError(w, r, err)
+				}
+			} else {
+				s.internalServerError(w, r, err)
+			}
+			return
+		}
+
+		// the secret is generated, and shown in the settings of the webhook
+		settings := r.Context().Value(common.ContextKeySettings).(*entities.Settings)
+		secretEncrypted, err := lib.EncryptText(lib.GenerateSecureRandomString(60), settings.AESEncryptionKey)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		webhook := &entities.Webhook{
+			Url:             webhookUrl,
+			Description:     description,
+			SecretEncrypted: secretEncrypted,
+			EventTypes:      eventTypes,
+			Enabled:         r.FormValue("enabled") == "on",
+		}
+
+		err = s.database.CreateWebhook(nil, webhook)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		lib.LogAudit(r.Context(), constants.AuditCreatedWebhook, map[string]interface{}{
+			"webhookId":    webhook.Id,
+			"url":          webhook.Url,
+			"loggedInUser": s.getLoggedInSubject(r),
+		})
+
+		http.Redirect(w, r, fmt.Sprintf("%v/admin/webhooks/%v/settings", lib.GetBaseUrl(), webhook.Id), http.StatusFound)
+	}
+}
@@ -0,0 +1,243 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/gorilla/csrf"
+	"github.com/leodip/goiabada/internal/common"
+	"github.com/leodip/goiabada/internal/constants"
+	"github.com/leodip/goiabada/internal/customerrors"
+	"github.com/leodip/goiabada/internal/entities"
+	"github.com/leodip/goiabada/internal/lib"
+	"github.com/pkg/errors"
+)
+
+// getWebhookInput reads the url, description and event types of the webhook from the form, and
+// validates them. The event types are returned separated by spaces, as they are stored.
+func getWebhookInput(r *http.Request, inputSanitizer inputSanitizer) (string, string, string, error) {
+
+	webhookUrl := strings.TrimSpace(r.FormValue("url"))
+	description := strings.TrimSpace(r.FormValue("description"))
+
+	if len(webhookUrl) == 0 {
+		return "", "", "", customerrors.NewValidationError("", "The URL is required.")
+	}
+
+	const maxLengthUrl = 512
+	if len(webhookUrl) > maxLengthUrl {
+		return "", "", "", customerrors.NewValidationError("", "The URL cannot exceed a maximum length of "+
+			strconv.Itoa(maxLengthUrl)+" characters.")
+	}
+
+	parsedUrl, err := url.ParseRequestURI(webhookUrl)
+	if err != nil || (parsedUrl.Scheme != "https" && parsedUrl.Scheme != "http") || len(parsedUrl.Host) == 0 {
+		return "", "", "", customerrors.NewValidationError("", "The URL must be a valid http or https URL.")
+	}
+
+	const maxLengthDescription = 128
+	if len(description) > maxLengthDescription {
+		return "", "", "", customerrors.NewValidationError("", "The description cannot exceed a maximum length of "+
+			strconv.Itoa(maxLengthDescription)+" characters.")
+	}
+
+	eventTypes := []string{}
+	for _, eventType := range r.Form["eventTypes"] {
+		if !slices.Contains(constants.WebhookEventTypes, eventType) {
+			return "", "", "", customerrors.NewValidationError("", "Invalid event type "+eventType+".")
+		}
+		if !slices.Contains(eventTypes, eventType) {
+			eventTypes = append(eventTypes, eventType)
+		}
+	}
+
+	if len(eventTypes) == 0 {
+		return "", "", "", customerrors.NewValidationError("", "Please select at least one event.")
+	}
+
+	return webhookUrl, inputSanitizer.Sanitize(description), strings.Join(eventTypes, " "), nil
+}
+
+type webhookEventOption struct {
+	EventType string
+	Selected  bool
+}
+
+// getWebhookEventOptions returns the events the webhooks can subscribe to, as the checkboxes of the form.
+func getWebhookEventOptions(selectedEventTypes []string) []webhookEventOption {
+	options := []webhookEventOption{}
+	for _, eventType := range constants.WebhookEventTypes {
+		options = append(options, webhookEventOption{
+			EventType: eventType,
+			Selected:  slices.Contains(selectedEventTypes, eventType),
+		})
+	}
+	return options
+}
+
+func (s *Server) getWebhookFromURL(r *http.Request) (*entities.Webhook, error) {
+
+	idStr := chi.URLParam(r, "webhookId")
+	if len(idStr) == 0 {
+		return nil, errors.WithStack(errors.New("webhookId is required"))
+	}
+
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	webhook, err := s.database.GetWebhookById(nil, id)
+	if err != nil {
+		return nil, err
+	}
+	if webhook == nil {
+		return nil, errors.WithStack(errors.New(fmt.Sprintf("webhook %v not found", id)))
+	}
+	return webhook, nil
+}
+
+func (s *Server) handleAdminWebhookSettingsGet() http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		webhook, err := s.getWebhookFromURL(r)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		settings := r.Context().Value(common.ContextKeySettings).(*entities.Settings)
+		secret, err := lib.DecryptText(webhook.SecretEncrypted, settings.AESEncryptionKey)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		sess, err := s.sessionStore.Get(r, common.SessionName)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		savedSuccessfully := sess.Flashes("savedSuccessfully")
+		if savedSuccessfully != nil {
+			err = sess.Save(r, w)
+			if err != nil {
+				s.internalServerError(w, r, err)
+				return
+			}
+		}
+
+		bind := map[string]interface{}{
+			"webhook":           webhook,
+			"url":               webhook.Url,
+			"description":       webhook.Description,
+			"events":            getWebhookEventOptions(webhook.GetEventTypes()),
+			"enabled":           webhook.Enabled,
+			"secret":            secret,
+			"savedSuccessfully": len(savedSuccessfully) > 0,
+			"csrfField":         csrf.TemplateField(r),
+		}
+
+		err = s.renderTemplate(w, r, "/layouts/menu_layout.html", "/admin_webhooks_settings.html", bind)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+	}
+}
+
+func (s *Server) handleAdminWebhookSettingsPost(inputSanitizer inputSanitizer) http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		webhook, err := s.getWebhookFromURL(r)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		settings := r.Context().Value(common.ContextKeySettings).(*entities.Settings)
+		secret, err := lib.DecryptText(webhook.SecretEncrypted, settings.AESEncryptionKey)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		err = r.ParseForm()
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		webhookUrl, description, eventTypes, err := getWebhookInput(r, inputSanitizer)
+		if err != nil {
+			if valError, ok := err.(*customerrors.ValidationError); ok {
+				bind := map[string]interface{}{
+					"webhook":     webhook,
+					"url":         r.FormValue("url"),
+					"description": r.FormValue("description"),
+					"events":      getWebhookEventOptions(r.Form["eventTypes"]),
+					"enabled":     r.FormValue("enabled") == "on",
+					"secret":      secret,
+					"error":       valError.Description,
+					"csrfField":   csrf.TemplateField(r),
+				}
+
+				err = s.renderTemplate(w, r, "/layouts/menu_layout.html", "/admin_webhooks_settings.html", bind)
+				if err != nil {
+					s.internalServerError(w, r, err)
+				}
+			} else {
+				s.internalServerError(w, r, err)
+			}
+			return
+		}
+
+		webhook.Url = webhookUrl
+		webhook.Description = description
+		webhook.EventTypes = eventTypes
+		webhook.Enabled = r.FormValue("enabled") == "on"
+
+		regenerateSecret := r.FormValue("regenerateSecret") == "on"
+		if regenerateSecret {
+			webhook.SecretEncrypted, err = lib.EncryptText(lib.GenerateSecureRandomString(60), settings.AESEncryptionKey)
+			if err != nil {
+				s.internalServerError(w, r, err)
+				return
+			}
+		}
+
+		err = s.database.UpdateWebhook(nil, webhook)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		sess, err := s.sessionStore.Get(r, common.SessionName)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		sess.AddFlash("true", "savedSuccessfully")
+		err = sess.Save(r, w)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		lib.LogAudit(r.Context(), constants.AuditUpdatedWebhook, map[string]interface{}{
+			"webhookId":         webhook.Id,
+			"regeneratedSecret": regenerateSecret,
+			"loggedInUser":      s.getLoggedInSubject(r),
+		})
+
+		http.Redirect(w, r, fmt.Sprintf("%v/admin/webhooks/%v/settings", lib.GetBaseUrl(), webhook.Id), http.StatusFound)
+	}
+}
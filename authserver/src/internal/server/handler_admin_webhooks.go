@@ -0,0 +1,27 @@
+package server
+
+import (
+	"net/http"
+)
+
+func (s *Server) handleAdminWebhooksGet() http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		webhooks, err := s.database.GetAllWebhooks(nil)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		bind := map[string]interface{}{
+			"webhooks": webhooks,
+		}
+
+		err = s.renderTemplate(w, r, "/layouts/menu_layout.html", "/admin_webhooks.html", bind)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+	}
+}
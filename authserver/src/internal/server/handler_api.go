@@ -0,0 +1,134 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/leodip/goiabada/internal/common"
+	core_api "github.com/leodip/goiabada/internal/core/api"
+	"github.com/leodip/goiabada/internal/customerrors"
+	"github.com/leodip/goiabada/internal/dtos"
+)
+
+func writeApiResponse(w http.ResponseWriter, statusCode int, body any) {
+	w.Header().Set("Content-Type", core_api.ContentType)
+	w.WriteHeader(statusCode)
+	if body != nil {
+		json.NewEncoder(w).Encode(body)
+	}
+}
+
+func (s *Server) apiError(w http.ResponseWriter, r *http.Request, err error) {
+
+	if apiErr, ok := err.(*core_api.Error); ok {
+		writeApiResponse(w, apiErr.Status, core_api.ErrorResponse{
+			Error:            apiErr.Code,
+			ErrorDescription: apiErr.Description,
+		})
+		return
+	}
+
+	if valError, ok := err.(*customerrors.ValidationError); ok {
+		code := valError.Code
+		if len(code) == 0 {
+			code = "invalid_request"
+		}
+		writeApiResponse(w, http.StatusBadRequest, core_api.ErrorResponse{
+			Error:            code,
+			ErrorDescription: valError.Description,
+		})
+		return
+	}
+
+	requestId := middleware.GetReqID(r.Context())
+	slog.Error(fmt.Sprintf("%+v\nrequest-id: %v", err, requestId))
+
+	writeApiResponse(w, http.StatusInternalServerError, core_api.ErrorResponse{
+		Error:            "server_error",
+		ErrorDescription: fmt.Sprintf("An unexpected server error has occurred. For additional information, refer to the server logs. Request Id: %v", requestId),
+	})
+}
+
+// getApiClient returns the identifier of the client making the request, for the audit logs.
+func getApiClient(r *http.Request) string {
+	if jwtToken, ok := r.Context().Value(common.ContextKeyJwtInfo).(dtos.JwtToken); ok {
+		return jwtToken.GetStringClaim("sub")
+	}
+	return ""
+}
+
+func readApiRequest(w http.ResponseWriter, r *http.Request, v any) error {
+	err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1024*1024)).Decode(v)
+	if err != nil {
+		return customerrors.NewValidationError("invalid_request", "The request body is not a valid JSON object.")
+	}
+	return nil
+}
+
+// getApiId returns the id in the URL parameter passed as parameter. Ids that are not numbers
+// can't identify anything, so they result in a not found error.
+func getApiId(r *http.Request, param string, description string) (int64, error) {
+	id, err := strconv.ParseInt(chi.URLParam(r, param), 10, 64)
+	if err != nil || id <= 0 {
+		return 0, core_api.NewNotFoundError(description)
+	}
+	return id, nil
+}
+
+// getApiPagination returns the page and the page size in the query string of the request.
+func getApiPagination(r *http.Request) (int, int, error) {
+	page := 1
+	if len(r.URL.Query().Get("page")) > 0 {
+		i, err := strconv.Atoi(r.URL.Query().Get("page"))
+		if err != nil || i < 1 {
+			return 0, 0, customerrors.NewValidationError("invalid_request", "The page must be a positive number.")
+		}
+		page = i
+	}
+	pageSize := core_api.DefaultPageSize
+	if len(r.URL.Query().Get("pageSize")) > 0 {
+		i, err := strconv.Atoi(r.URL.Query().Get("pageSize"))
+		if err != nil || i < 1 || i > core_api.MaxPageSize {
+			return 0, 0, customerrors.NewValidationError("invalid_request",
+				fmt.Sprintf("The page size must be between 1 and %v.", core_api.MaxPageSize))
+		}
+		pageSize = i
+	}
+	return page, pageSize, nil
+}
+
+// validateApiAttribute validates and sanitizes the attribute of a user or a group.
+func validateApiAttribute(input *core_api.AttributeInput, identifierValidator identifierValidator,
+	inputSanitizer inputSanitizer) error {
+
+	input.Key = strings.TrimSpace(input.Key)
+	input.Value = strings.TrimSpace(input.Value)
+	if len(input.Key) == 0 {
+		return customerrors.NewValidationError("", "Attribute key is required.")
+	}
+	err := identifierValidator.ValidateIdentifier(input.Key, false)
+	if err != nil {
+		return err
+	}
+	const maxLengthAttrValue = 250
+	if len(input.Value) > maxLengthAttrValue {
+		return customerrors.NewValidationError("", "The attribute value cannot exceed a maximum length of "+
+			strconv.Itoa(maxLengthAttrValue)+" characters. Please make the value shorter.")
+	}
+	input.Value = inputSanitizer.Sanitize(input.Value)
+	return nil
+}
+
+func (s *Server) handleApiOpenAPIGet() http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", core_api.ContentType)
+		w.Write(core_api.OpenAPIDocument)
+	}
+}
@@ -0,0 +1,598 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/leodip/goiabada/internal/common"
+	"github.com/leodip/goiabada/internal/constants"
+	core_api "github.com/leodip/goiabada/internal/core/api"
+	"github.com/leodip/goiabada/internal/customerrors"
+	"github.com/leodip/goiabada/internal/entities"
+	"github.com/leodip/goiabada/internal/enums"
+	"github.com/leodip/goiabada/internal/lib"
+)
+
+// getApiClientById returns the client identified by the clientId in the URL, or nil after writing
+// the error response. When the client will be modified, system-level clients are refused, as well
+// as clients with authserver permissions the token doesn't hold (a new secret of such a client
+// would give access to them).
+func (s *Server) getApiClientById(w http.ResponseWriter, r *http.Request, modify bool) *entities.Client {
+	id, err := getApiId(r, "clientId", "Client not found.")
+	if err != nil {
+		s.apiError(w, r, err)
+		return nil
+	}
+	client, err := s.database.GetClientById(nil, id)
+	if err != nil {
+		s.apiError(w, r, err)
+		return nil
+	}
+	if client == nil {
+		s.apiError(w, r, core_api.NewNotFoundError("Client not found."))
+		return nil
+	}
+	if modify && client.IsSystemLevelClient() {
+		s.apiError(w, r, core_api.NewForbiddenError("System-level clients can't be modified."))
+		return nil
+	}
+	if modify {
+		err = s.database.ClientLoadPermissions(nil, client)
+		if err != nil {
+			s.apiError(w, r, err)
+			return nil
+		}
+		err = s.checkApiPermissionScopes(r, client.Permissions)
+		if err != nil {
+			s.apiError(w, r, err)
+			return nil
+		}
+	}
+	return client
+}
+
+func (s *Server) newApiClient(client *entities.Client) (*core_api.Client, error) {
+	err := s.database.ClientLoadRedirectURIs(nil, client)
+	if err != nil {
+		return nil, err
+	}
+	err = s.database.ClientLoadWebOrigins(nil, client)
+	if err != nil {
+		return nil, err
+	}
+	return core_api.NewClient(client), nil
+}
+
+// applyApiClient validates the representation of a client and copies its settings to the client.
+// The redirect URIs and the web origins are validated, but only saved by saveApiClientURIs.
+func (s *Server) applyApiClient(client *entities.Client, input *core_api.ClientInput,
+	identifierValidator identifierValidator, inputSanitizer inputSanitizer) error {
+
+	clientIdentifier := strings.TrimSpace(input.ClientIdentifier)
+	description := strings.TrimSpace(input.Description)
+
+	if len(clientIdentifier) == 0 {
+		return customerrors.NewValidationError("", "Client identifier is required.")
+	}
+
+	const maxLengthDescription = 100
+	if len(description) > maxLengthDescription {
+		return customerrors.NewValidationError("", "The description cannot exceed a maximum length of "+strconv.Itoa(maxLengthDescription)+" characters.")
+	}
+
+	err := identifierValidator.ValidateIdentifier(clientIdentifier, true)
+	if err != nil {
+		return err
+	}
+
+	existingClient, err := s.database.GetClientByClientIdentifier(nil, clientIdentifier)
+	if err != nil {
+		return err
+	}
+	if existingClient != nil && existingClient.Id != client.Id {
+		return core_api.NewConflictError("The client identifier is already in use.")
+	}
+
+	if input.IsPublic && input.ClientCredentialsEnabled {
+		return customerrors.NewValidationError("", "Public clients can't use the client credentials flow.")
+	}
+
+	defaultAcrLevel := enums.AcrLevel2
+	if len(input.DefaultAcrLevel) > 0 {
+		defaultAcrLevel, err = enums.AcrLevelFromString(input.DefaultAcrLevel)
+		if err != nil {
+			return customerrors.NewValidationError("", "Invalid default ACR level.")
+		}
+	}
+
+	const maxValue = 160000000
+	if input.TokenExpirationInSeconds < 0 || input.TokenExpirationInSeconds > maxValue {
+		return customerrors.NewValidationError("", fmt.Sprintf("Token expiration in seconds must be between 0 and %v.", maxValue))
+	}
+	if input.RefreshTokenOfflineIdleTimeoutInSeconds < 0 || input.RefreshTokenOfflineIdleTimeoutInSeconds > maxValue {
+		return customerrors.NewValidationError("", fmt.Sprintf("Refresh token offline - idle timeout in seconds must be between 0 and %v.", maxValue))
+	}
+	if input.RefreshTokenOfflineMaxLifetimeInSeconds < 0 || input.RefreshTokenOfflineMaxLifetimeInSeconds > maxValue {
+		return customerrors.NewValidationError("", fmt.Sprintf("Refresh token offline - max lifetime in seconds must be between 0 and %v.", maxValue))
+	}
+	if input.RefreshTokenOfflineIdleTimeoutInSeconds > input.RefreshTokenOfflineMaxLifetimeInSeconds {
+		return customerrors.NewValidationError("", "Refresh token offline - idle timeout cannot be greater than max lifetime.")
+	}
+
+	includeOpenIDConnectClaimsInAccessToken := enums.ThreeStateSettingDefault
+	if len(input.IncludeOpenIDConnectClaimsInAccessToken) > 0 {
+		includeOpenIDConnectClaimsInAccessToken, err = enums.ThreeStateSettingFromString(input.IncludeOpenIDConnectClaimsInAccessToken)
+		if err != nil {
+			return customerrors.NewValidationError("", "The value of includeOpenIDConnectClaimsInAccessToken must be on, off or default.")
+		}
+	}
+
+	for idx, redirectURI := range input.RedirectURIs {
+		input.RedirectURIs[idx] = strings.TrimSpace(redirectURI)
+		_, err := url.ParseRequestURI(input.RedirectURIs[idx])
+		if err != nil {
+			return customerrors.NewValidationError("", fmt.Sprintf("Invalid redirect URI: %v.", redirectURI))
+		}
+	}
+	for idx, webOrigin := range input.WebOrigins {
+		input.WebOrigins[idx] = strings.ToLower(strings.TrimSpace(webOrigin))
+		_, err := url.ParseRequestURI(input.WebOrigins[idx])
+		if err != nil {
+			return customerrors.NewValidationError("", fmt.Sprintf("Invalid web origin: %v.", webOrigin))
+		}
+	}
+
+	client.ClientIdentifier = inputSanitizer.Sanitize(clientIdentifier)
+	client.Description = inputSanitizer.Sanitize(description)
+	client.Enabled = input.Enabled
+	client.ConsentRequired = input.ConsentRequired
+	client.IsPublic = input.IsPublic
+	client.AuthorizationCodeEnabled = input.AuthorizationCodeEnabled
+	client.ClientCredentialsEnabled = input.ClientCredentialsEnabled
+	client.DefaultAcrLevel = defaultAcrLevel
+	client.EmailLoginEnabled = input.EmailLoginEnabled
+	client.AcceptImpersonatedTokens = input.AcceptImpersonatedTokens
+	client.TokenExpirationInSeconds = input.TokenExpirationInSeconds
+	client.RefreshTokenOfflineIdleTimeoutInSeconds = input.RefreshTokenOfflineIdleTimeoutInSeconds
+	client.RefreshTokenOfflineMaxLifetimeInSeconds = input.RefreshTokenOfflineMaxLifetimeInSeconds
+	client.IncludeOpenIDConnectClaimsInAccessToken = includeOpenIDConnectClaimsInAccessToken.String()
+	return nil
+}
+
+// saveApiClientURIs replaces the redirect URIs and the web origins of the client.
+func (s *Server) saveApiClientURIs(r *http.Request, client *entities.Client, input *core_api.ClientInput) error {
+
+	err := s.database.ClientLoadRedirectURIs(nil, client)
+	if err != nil {
+		return err
+	}
+	err = s.database.ClientLoadWebOrigins(nil, client)
+	if err != nil {
+		return err
+	}
+
+	redirectURIsChanged := false
+	for _, redirectURI := range client.RedirectURIs {
+		if !slices.Contains(input.RedirectURIs, redirectURI.URI) {
+			err = s.database.DeleteRedirectURI(nil, redirectURI.Id)
+			if err != nil {
+				return err
+			}
+			redirectURIsChanged = true
+		}
+	}
+	for _, uri := range input.RedirectURIs {
+		found := false
+		for _, redirectURI := range client.RedirectURIs {
+			if redirectURI.URI == uri {
+				found = true
+				break
+			}
+		}
+		if !found {
+			err = s.database.CreateRedirectURI(nil, &entities.RedirectURI{
+				ClientId: client.Id,
+				URI:      uri,
+			})
+			if err != nil {
+				return err
+			}
+			// the same URI is only added once
+			client.RedirectURIs = append(client.RedirectURIs, entities.RedirectURI{URI: uri})
+			redirectURIsChanged = true
+		}
+	}
+
+	webOriginsChanged := false
+	for _, webOrigin := range client.WebOrigins {
+		if !slices.Contains(input.WebOrigins, webOrigin.Origin) {
+			err = s.database.DeleteWebOrigin(nil, webOrigin.Id)
+			if err != nil {
+				return err
+			}
+			webOriginsChanged = true
+		}
+	}
+	for _, origin := range input.WebOrigins {
+		found := false
+		for _, webOrigin := range client.WebOrigins {
+			if webOrigin.Origin == origin {
+				found = true
+				break
+			}
+		}
+		if !found {
+			err = s.database.CreateWebOrigin(nil, &entities.WebOrigin{
+				ClientId: client.Id,
+				Origin:   origin,
+			})
+			if err != nil {
+				return err
+			}
+			client.WebOrigins = append(client.WebOrigins, entities.WebOrigin{Origin: origin})
+			webOriginsChanged = true
+		}
+	}
+
+	if redirectURIsChanged {
+		lib.LogAudit(r.Context(), constants.AuditUpdatedRedirectURIs, map[string]interface{}{
+			"clientId":  client.Id,
+			"apiClient": getApiClient(r),
+		})
+	}
+	if webOriginsChanged {
+		lib.LogAudit(r.Context(), constants.AuditUpdatedWebOrigins, map[string]interface{}{
+			"clientId":  client.Id,
+			"apiClient": getApiClient(r),
+		})
+	}
+	return nil
+}
+
+// setApiClientSecret generates a new secret for the client, and returns it.
+func setApiClientSecret(r *http.Request, client *entities.Client) (string, error) {
+	settings := r.Context().Value(common.ContextKeySettings).(*entities.Settings)
+	clientSecret := lib.GenerateSecureRandomString(60)
+	clientSecretEncrypted, err := lib.EncryptText(clientSecret, settings.AESEncryptionKey)
+	if err != nil {
+		return "", err
+	}
+	client.ClientSecretEncrypted = clientSecretEncrypted
+	return clientSecret, nil
+}
+
+func (s *Server) handleApiClientsGet() http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		clients, err := s.database.GetAllClients(nil)
+		if err != nil {
+			s.apiError(w, r, err)
+			return
+		}
+
+		result := core_api.ListResponse[*core_api.Client]{Items: []*core_api.Client{}, Total: len(clients)}
+		for _, client := range clients {
+			apiClient, err := s.newApiClient(client)
+			if err != nil {
+				s.apiError(w, r, err)
+				return
+			}
+			result.Items = append(result.Items, apiClient)
+		}
+		writeApiResponse(w, http.StatusOK, result)
+	}
+}
+
+func (s *Server) handleApiClientsPost(identifierValidator identifierValidator,
+	inputSanitizer inputSanitizer) http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		var input core_api.ClientInput
+		err := readApiRequest(w, r, &input)
+		if err != nil {
+			s.apiError(w, r, err)
+			return
+		}
+
+		client := &entities.Client{}
+		err = s.applyApiClient(client, &input, identifierValidator, inputSanitizer)
+		if err != nil {
+			s.apiError(w, r, err)
+			return
+		}
+
+		clientSecret := ""
+		if !client.IsPublic {
+			clientSecret, err = setApiClientSecret(r, client)
+			if err != nil {
+				s.apiError(w, r, err)
+				return
+			}
+		}
+
+		err = s.database.CreateClient(nil, client)
+		if err != nil {
+			s.apiError(w, r, err)
+			return
+		}
+
+		lib.LogAudit(r.Context(), constants.AuditCreatedClient, map[string]interface{}{
+			"clientId":         client.Id,
+			"clientIdentifier": client.ClientIdentifier,
+			"apiClient":        getApiClient(r),
+		})
+
+		err = s.saveApiClientURIs(r, client, &input)
+		if err != nil {
+			s.apiError(w, r, err)
+			return
+		}
+
+		client, err = s.database.GetClientById(nil, client.Id)
+		if err != nil {
+			s.apiError(w, r, err)
+			return
+		}
+		apiClient, err := s.newApiClient(client)
+		if err != nil {
+			s.apiError(w, r, err)
+			return
+		}
+		apiClient.ClientSecret = clientSecret
+		writeApiResponse(w, http.StatusCreated, apiClient)
+	}
+}
+
+func (s *Server) handleApiClientGet() http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		client := s.getApiClientById(w, r, false)
+		if client == nil {
+			return
+		}
+		apiClient, err := s.newApiClient(client)
+		if err != nil {
+			s.apiError(w, r, err)
+			return
+		}
+		writeApiResponse(w, http.StatusOK, apiClient)
+	}
+}
+
+// handleApiClientPut replaces the settings of the client. A secret is generated (and returned) when
+// a public client becomes confidential.
+func (s *Server) handleApiClientPut(identifierValidator identifierValidator,
+	inputSanitizer inputSanitizer) http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		client := s.getApiClientById(w, r, true)
+		if client == nil {
+			return
+		}
+
+		var input core_api.ClientInput
+		err := readApiRequest(w, r, &input)
+		if err != nil {
+			s.apiError(w, r, err)
+			return
+		}
+
+		wasPublic := client.IsPublic
+		err = s.applyApiClient(client, &input, identifierValidator, inputSanitizer)
+		if err != nil {
+			s.apiError(w, r, err)
+			return
+		}
+
+		clientSecret := ""
+		if client.IsPublic {
+			client.ClientSecretEncrypted = nil
+		} else if wasPublic || len(client.ClientSecretEncrypted) == 0 {
+			clientSecret, err = setApiClientSecret(r, client)
+			if err != nil {
+				s.apiError(w, r, err)
+				return
+			}
+		}
+
+		err = s.database.UpdateClient(nil, client)
+		if err != nil {
+			s.apiError(w, r, err)
+			return
+		}
+
+		lib.LogAudit(r.Context(), constants.AuditUpdatedClientSettings, map[string]interface{}{
+			"clientId":  client.Id,
+			"apiClient": getApiClient(r),
+		})
+
+		err = s.saveApiClientURIs(r, client, &input)
+		if err != nil {
+			s.apiError(w, r, err)
+			return
+		}
+
+		client, err = s.database.GetClientById(nil, client.Id)
+		if err != nil {
+			s.apiError(w, r, err)
+			return
+		}
+		apiClient, err := s.newApiClient(client)
+		if err != nil {
+			s.apiError(w, r, err)
+			return
+		}
+		apiClient.ClientSecret = clientSecret
+		writeApiResponse(w, http.StatusOK, apiClient)
+	}
+}
+
+func (s *Server) handleApiClientDelete() http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		client := s.getApiClientById(w, r, true)
+		if client == nil {
+			return
+		}
+
+		err := s.database.DeleteClient(nil, client.Id)
+		if err != nil {
+			s.apiError(w, r, err)
+			return
+		}
+
+		lib.LogAudit(r.Context(), constants.AuditDeletedClient, map[string]interface{}{
+			"clientId":         client.Id,
+			"clientIdentifier": client.ClientIdentifier,
+			"apiClient":        getApiClient(r),
+		})
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// handleApiClientSecretPost generates a new secret for a confidential client. The previous secret
+// stops working immediately.
+func (s *Server) handleApiClientSecretPost() http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		client := s.getApiClientById(w, r, true)
+		if client == nil {
+			return
+		}
+		if client.IsPublic {
+			s.apiError(w, r, customerrors.NewValidationError("", "Public clients don't have a secret."))
+			return
+		}
+
+		clientSecret, err := setApiClientSecret(r, client)
+		if err != nil {
+			s.apiError(w, r, err)
+			return
+		}
+		err = s.database.UpdateClient(nil, client)
+		if err != nil {
+			s.apiError(w, r, err)
+			return
+		}
+
+		lib.LogAudit(r.Context(), constants.AuditUpdatedClientAuthentication, map[string]interface{}{
+			"clientId":  client.Id,
+			"apiClient": getApiClient(r),
+		})
+
+		apiClient, err := s.newApiClient(client)
+		if err != nil {
+			s.apiError(w, r, err)
+			return
+		}
+		apiClient.ClientSecret = clientSecret
+		writeApiResponse(w, http.StatusOK, apiClient)
+	}
+}
+
+func (s *Server) handleApiClientPermissionsGet() http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		client := s.getApiClientById(w, r, false)
+		if client == nil {
+			return
+		}
+		err := s.database.ClientLoadPermissions(nil, client)
+		if err != nil {
+			s.apiError(w, r, err)
+			return
+		}
+		s.writeApiPermissions(w, r, client.Permissions)
+	}
+}
+
+// handleApiClientPermissionsPut replaces the permissions of the client (used in the client
+// credentials flow) with the permissions in the request.
+func (s *Server) handleApiClientPermissionsPut() http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		client := s.getApiClientById(w, r, true)
+		if client == nil {
+			return
+		}
+
+		var input core_api.IdList
+		err := readApiRequest(w, r, &input)
+		if err != nil {
+			s.apiError(w, r, err)
+			return
+		}
+
+		err = s.database.ClientLoadPermissions(nil, client)
+		if err != nil {
+			s.apiError(w, r, err)
+			return
+		}
+
+		err = s.validateApiPermissionIds(r, input.Ids, client.Permissions)
+		if err != nil {
+			s.apiError(w, r, err)
+			return
+		}
+
+		for _, permissionId := range input.Ids {
+			found := false
+			for _, permission := range client.Permissions {
+				if permission.Id == permissionId {
+					found = true
+					break
+				}
+			}
+			if !found {
+				err = s.database.CreateClientPermission(nil, &entities.ClientPermission{
+					ClientId:     client.Id,
+					PermissionId: permissionId,
+				})
+				if err != nil {
+					s.apiError(w, r, err)
+					return
+				}
+			}
+		}
+
+		for _, permission := range client.Permissions {
+			if !slices.Contains(input.Ids, permission.Id) {
+				clientPermission, err := s.database.GetClientPermissionByClientIdAndPermissionId(nil, client.Id, permission.Id)
+				if err != nil {
+					s.apiError(w, r, err)
+					return
+				}
+				err = s.database.DeleteClientPermission(nil, clientPermission.Id)
+				if err != nil {
+					s.apiError(w, r, err)
+					return
+				}
+			}
+		}
+
+		lib.LogAudit(r.Context(), constants.AuditUpdatedClientPermissions, map[string]interface{}{
+			"clientId":  client.Id,
+			"apiClient": getApiClient(r),
+		})
+
+		err = s.database.ClientLoadPermissions(nil, client)
+		if err != nil {
+			s.apiError(w, r, err)
+			return
+		}
+		s.writeApiPermissions(w, r, client.Permissions)
+	}
+}
@@ -0,0 +1,621 @@
+package server
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/leodip/goiabada/internal/constants"
+	core_api "github.com/leodip/goiabada/internal/core/api"
+	"github.com/leodip/goiabada/internal/customerrors"
+	"github.com/leodip/goiabada/internal/entities"
+	"github.com/leodip/goiabada/internal/lib"
+)
+
+// getApiGroup returns the group identified by the groupId in the URL, or nil after writing
+// the error response.
+func (s *Server) getApiGroup(w http.ResponseWriter, r *http.Request) *entities.Group {
+	id, err := getApiId(r, "groupId", "Group not found.")
+	if err != nil {
+		s.apiError(w, r, err)
+		return nil
+	}
+	group, err := s.database.GetGroupById(nil, id)
+	if err != nil {
+		s.apiError(w, r, err)
+		return nil
+	}
+	if group == nil {
+		s.apiError(w, r, core_api.NewNotFoundError("Group not found."))
+		return nil
+	}
+	return group
+}
+
+// applyApiGroup validates the representation of a group and copies it to the group.
+func (s *Server) applyApiGroup(group *entities.Group, input *core_api.GroupInput,
+	identifierValidator identifierValidator, inputSanitizer inputSanitizer) error {
+
+	groupIdentifier := strings.TrimSpace(input.GroupIdentifier)
+	description := strings.TrimSpace(input.Description)
+
+	if len(groupIdentifier) == 0 {
+		return customerrors.NewValidationError("", "Group identifier is required.")
+	}
+
+	const maxLengthDescription = 100
+	if len(description) > maxLengthDescription {
+		return customerrors.NewValidationError("", "The description cannot exceed a maximum length of "+strconv.Itoa(maxLengthDescription)+" characters.")
+	}
+
+	err := identifierValidator.ValidateIdentifier(groupIdentifier, true)
+	if err != nil {
+		return err
+	}
+
+	existingGroup, err := s.database.GetGroupByGroupIdentifier(nil, groupIdentifier)
+	if err != nil {
+		return err
+	}
+	if existingGroup != nil && existingGroup.Id != group.Id {
+		return core_api.NewConflictError("The group identifier is already in use.")
+	}
+
+	group.GroupIdentifier = inputSanitizer.Sanitize(groupIdentifier)
+	group.Description = inputSanitizer.Sanitize(description)
+	group.IncludeInIdToken = input.IncludeInIdToken
+	group.IncludeInAccessToken = input.IncludeInAccessToken
+	return nil
+}
+
+func (s *Server) handleApiGroupsGet() http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		groups, err := s.database.GetAllGroups(nil)
+		if err != nil {
+			s.apiError(w, r, err)
+			return
+		}
+
+		result := core_api.ListResponse[*core_api.Group]{Items: []*core_api.Group{}, Total: len(groups)}
+		for _, group := range groups {
+			result.Items = append(result.Items, core_api.NewGroup(group))
+		}
+		writeApiResponse(w, http.StatusOK, result)
+	}
+}
+
+func (s *Server) handleApiGroupsPost(identifierValidator identifierValidator,
+	inputSanitizer inputSanitizer) http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		var input core_api.GroupInput
+		err := readApiRequest(w, r, &input)
+		if err != nil {
+			s.apiError(w, r, err)
+			return
+		}
+
+		group := &entities.Group{}
+		err = s.applyApiGroup(group, &input, identifierValidator, inputSanitizer)
+		if err != nil {
+			s.apiError(w, r, err)
+			return
+		}
+
+		err = s.database.CreateGroup(nil, group)
+		if err != nil {
+			s.apiError(w, r, err)
+			return
+		}
+
+		lib.LogAudit(r.Context(), constants.AuditCreatedGroup, map[string]interface{}{
+			"groupId":         group.Id,
+			"groupIdentifier": group.GroupIdentifier,
+			"apiClient":       getApiClient(r),
+		})
+
+		group, err = s.database.GetGroupById(nil, group.Id)
+		if err != nil {
+			s.apiError(w, r, err)
+			return
+		}
+		writeApiResponse(w, http.StatusCreated, core_api.NewGroup(group))
+	}
+}
+
+func (s *Server) handleApiGroupGet() http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		group := s.getApiGroup(w, r)
+		if group == nil {
+			return
+		}
+		writeApiResponse(w, http.StatusOK, core_api.NewGroup(group))
+	}
+}
+
+func (s *Server) handleApiGroupPut(identifierValidator identifierValidator,
+	inputSanitizer inputSanitizer) http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		group := s.getApiGroup(w, r)
+		if group == nil {
+			return
+		}
+
+		var input core_api.GroupInput
+		err := readApiRequest(w, r, &input)
+		if err != nil {
+			s.apiError(w, r, err)
+			return
+		}
+
+		err = s.applyApiGroup(group, &input, identifierValidator, inputSanitizer)
+		if err != nil {
+			s.apiError(w, r, err)
+			return
+		}
+
+		err = s.database.UpdateGroup(nil, group)
+		if err != nil {
+			s.apiError(w, r, err)
+			return
+		}
+
+		lib.LogAudit(r.Context(), constants.AuditUpdatedGroup, map[string]interface{}{
+			"groupId":         group.Id,
+			"groupIdentifier": group.GroupIdentifier,
+			"apiClient":       getApiClient(r),
+		})
+
+		writeApiResponse(w, http.StatusOK, core_api.NewGroup(group))
+	}
+}
+
+func (s *Server) handleApiGroupDelete() http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		group := s.getApiGroup(w, r)
+		if group == nil {
+			return
+		}
+
+		err := s.database.DeleteGroup(nil, group.Id)
+		if err != nil {
+			s.apiError(w, r, err)
+			return
+		}
+
+		lib.LogAudit(r.Context(), constants.AuditDeletedGroup, map[string]interface{}{
+			"groupId":         group.Id,
+			"groupIdentifier": group.GroupIdentifier,
+			"apiClient":       getApiClient(r),
+		})
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func (s *Server) handleApiGroupMembersGet() http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		group := s.getApiGroup(w, r)
+		if group == nil {
+			return
+		}
+
+		page, pageSize, err := getApiPagination(r)
+		if err != nil {
+			s.apiError(w, r, err)
+			return
+		}
+
+		users, total, err := s.database.GetGroupMembersPaginated(nil, group.Id, page, pageSize)
+		if err != nil {
+			s.apiError(w, r, err)
+			return
+		}
+
+		result := core_api.ListResponse[*core_api.User]{
+			Items:    []*core_api.User{},
+			Total:    total,
+			Page:     page,
+			PageSize: pageSize,
+		}
+		for idx := range users {
+			result.Items = append(result.Items, core_api.NewUser(&users[idx]))
+		}
+		writeApiResponse(w, http.StatusOK, result)
+	}
+}
+
+func (s *Server) handleApiGroupMembersPost() http.HandlerFunc {
+
+	type addMemberInput struct {
+		UserId int64 `json:"userId"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		group := s.getApiGroup(w, r)
+		if group == nil {
+			return
+		}
+
+		var input addMemberInput
+		err := readApiRequest(w, r, &input)
+		if err != nil {
+			s.apiError(w, r, err)
+			return
+		}
+
+		user, err := s.database.GetUserById(nil, input.UserId)
+		if err != nil {
+			s.apiError(w, r, err)
+			return
+		}
+		if user == nil {
+			s.apiError(w, r, customerrors.NewValidationError("", "User not found."))
+			return
+		}
+
+		userGroup, err := s.database.GetUserGroupByUserIdAndGroupId(nil, user.Id, group.Id)
+		if err != nil {
+			s.apiError(w, r, err)
+			return
+		}
+		if userGroup != nil {
+			s.apiError(w, r, core_api.NewConflictError("The user is already a member of the group."))
+			return
+		}
+
+		err = s.checkApiGroupPermissionScopes(r, group)
+		if err != nil {
+			s.apiError(w, r, err)
+			return
+		}
+
+		err = s.database.CreateUserGroup(nil, &entities.UserGroup{
+			UserId:  user.Id,
+			GroupId: group.Id,
+		})
+		if err != nil {
+			s.apiError(w, r, err)
+			return
+		}
+
+		lib.LogAudit(r.Context(), constants.AuditUserAddedToGroup, map[string]interface{}{
+			"userId":    user.Id,
+			"groupId":   group.Id,
+			"apiClient": getApiClient(r),
+		})
+		s.publishUserEvent(constants.WebhookEventUserAddedToGroup, user, group)
+
+		writeApiResponse(w, http.StatusCreated, core_api.NewUser(user))
+	}
+}
+
+func (s *Server) handleApiGroupMemberDelete() http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		group := s.getApiGroup(w, r)
+		if group == nil {
+			return
+		}
+
+		userId, err := getApiId(r, "userId", "The user is not a member of the group.")
+		if err != nil {
+			s.apiError(w, r, err)
+			return
+		}
+		userGroup, err := s.database.GetUserGroupByUserIdAndGroupId(nil, userId, group.Id)
+		if err != nil {
+			s.apiError(w, r, err)
+			return
+		}
+		if userGroup == nil {
+			s.apiError(w, r, core_api.NewNotFoundError("The user is not a member of the group."))
+			return
+		}
+
+		err = s.checkApiGroupPermissionScopes(r, group)
+		if err != nil {
+			s.apiError(w, r, err)
+			return
+		}
+
+		err = s.database.DeleteUserGroup(nil, userGroup.Id)
+		if err != nil {
+			s.apiError(w, r, err)
+			return
+		}
+
+		lib.LogAudit(r.Context(), constants.AuditUserRemovedFromGroup, map[string]interface{}{
+			"userId":    userId,
+			"groupId":   group.Id,
+			"apiClient": getApiClient(r),
+		})
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func (s *Server) handleApiGroupPermissionsGet() http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		group := s.getApiGroup(w, r)
+		if group == nil {
+			return
+		}
+		err := s.database.GroupLoadPermissions(nil, group)
+		if err != nil {
+			s.apiError(w, r, err)
+			return
+		}
+		s.writeApiPermissions(w, r, group.Permissions)
+	}
+}
+
+// handleApiGroupPermissionsPut replaces the permissions of the group with the permissions in the request.
+func (s *Server) handleApiGroupPermissionsPut() http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		group := s.getApiGroup(w, r)
+		if group == nil {
+			return
+		}
+
+		var input core_api.IdList
+		err := readApiRequest(w, r, &input)
+		if err != nil {
+			s.apiError(w, r, err)
+			return
+		}
+
+		err = s.database.GroupLoadPermissions(nil, group)
+		if err != nil {
+			s.apiError(w, r, err)
+			return
+		}
+
+		err = s.validateApiPermissionIds(r, input.Ids, group.Permissions)
+		if err != nil {
+			s.apiError(w, r, err)
+			return
+		}
+
+		for _, permissionId := range input.Ids {
+			found := false
+			for _, permission := range group.Permissions {
+				if permission.Id == permissionId {
+					found = true
+					break
+				}
+			}
+			if !found {
+				err = s.database.CreateGroupPermission(nil, &entities.GroupPermission{
+					GroupId:      group.Id,
+					PermissionId: permissionId,
+				})
+				if err != nil {
+					s.apiError(w, r, err)
+					return
+				}
+				lib.LogAudit(r.Context(), constants.AuditAddedGroupPermission, map[string]interface{}{
+					"groupId":      group.Id,
+					"permissionId": permissionId,
+					"apiClient":    getApiClient(r),
+				})
+			}
+		}
+
+		for _, permission := range group.Permissions {
+			found := false
+			for _, permissionId := range input.Ids {
+				if permission.Id == permissionId {
+					found = true
+					break
+				}
+			}
+			if !found {
+				groupPermission, err := s.database.GetGroupPermissionByGroupIdAndPermissionId(nil, group.Id, permission.Id)
+				if err != nil {
+					s.apiError(w, r, err)
+					return
+				}
+				err = s.database.DeleteGroupPermission(nil, groupPermission.Id)
+				if err != nil {
+					s.apiError(w, r, err)
+					return
+				}
+				lib.LogAudit(r.Context(), constants.AuditDeletedGroupPermission, map[string]interface{}{
+					"groupId":      group.Id,
+					"permissionId": permission.Id,
+					"apiClient":    getApiClient(r),
+				})
+			}
+		}
+
+		err = s.database.GroupLoadPermissions(nil, group)
+		if err != nil {
+			s.apiError(w, r, err)
+			return
+		}
+		s.writeApiPermissions(w, r, group.Permissions)
+	}
+}
+
+func (s *Server) handleApiGroupAttributesGet() http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		group := s.getApiGroup(w, r)
+		if group == nil {
+			return
+		}
+
+		attributes, err := s.database.GetGroupAttributesByGroupId(nil, group.Id)
+		if err != nil {
+			s.apiError(w, r, err)
+			return
+		}
+
+		result := core_api.ListResponse[*core_api.Attribute]{Items: []*core_api.Attribute{}, Total: len(attributes)}
+		for idx := range attributes {
+			result.Items = append(result.Items, core_api.NewGroupAttribute(&attributes[idx]))
+		}
+		writeApiResponse(w, http.StatusOK, result)
+	}
+}
+
+func (s *Server) handleApiGroupAttributesPost(identifierValidator identifierValidator,
+	inputSanitizer inputSanitizer) http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		group := s.getApiGroup(w, r)
+		if group == nil {
+			return
+		}
+
+		var input core_api.AttributeInput
+		err := readApiRequest(w, r, &input)
+		if err != nil {
+			s.apiError(w, r, err)
+			return
+		}
+
+		err = validateApiAttribute(&input, identifierValidator, inputSanitizer)
+		if err != nil {
+			s.apiError(w, r, err)
+			return
+		}
+
+		groupAttribute := &entities.GroupAttribute{
+			Key:                  input.Key,
+			Value:                input.Value,
+			IncludeInAccessToken: input.IncludeInAccessToken,
+			IncludeInIdToken:     input.IncludeInIdToken,
+			GroupId:              group.Id,
+		}
+		err = s.database.CreateGroupAttribute(nil, groupAttribute)
+		if err != nil {
+			s.apiError(w, r, err)
+			return
+		}
+
+		lib.LogAudit(r.Context(), constants.AuditAddedGroupAttribute, map[string]interface{}{
+			"groupId":          group.Id,
+			"groupAttributeId": groupAttribute.Id,
+			"apiClient":        getApiClient(r),
+		})
+
+		writeApiResponse(w, http.StatusCreated, core_api.NewGroupAttribute(groupAttribute))
+	}
+}
+
+// getApiGroupAttribute returns the attribute identified by the attributeId in the URL, which must
+// belong to the group, or nil after writing the error response.
+func (s *Server) getApiGroupAttribute(w http.ResponseWriter, r *http.Request, group *entities.Group) *entities.GroupAttribute {
+	id, err := getApiId(r, "attributeId", "Attribute not found.")
+	if err != nil {
+		s.apiError(w, r, err)
+		return nil
+	}
+	groupAttribute, err := s.database.GetGroupAttributeById(nil, id)
+	if err != nil {
+		s.apiError(w, r, err)
+		return nil
+	}
+	if groupAttribute == nil || groupAttribute.GroupId != group.Id {
+		s.apiError(w, r, core_api.NewNotFoundError("Attribute not found."))
+		return nil
+	}
+	return groupAttribute
+}
+
+func (s *Server) handleApiGroupAttributePut(identifierValidator identifierValidator,
+	inputSanitizer inputSanitizer) http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		group := s.getApiGroup(w, r)
+		if group == nil {
+			return
+		}
+		groupAttribute := s.getApiGroupAttribute(w, r, group)
+		if groupAttribute == nil {
+			return
+		}
+
+		var input core_api.AttributeInput
+		err := readApiRequest(w, r, &input)
+		if err != nil {
+			s.apiError(w, r, err)
+			return
+		}
+
+		err = validateApiAttribute(&input, identifierValidator, inputSanitizer)
+		if err != nil {
+			s.apiError(w, r, err)
+			return
+		}
+
+		groupAttribute.Key = input.Key
+		groupAttribute.Value = input.Value
+		groupAttribute.IncludeInAccessToken = input.IncludeInAccessToken
+		groupAttribute.IncludeInIdToken = input.IncludeInIdToken
+		err = s.database.UpdateGroupAttribute(nil, groupAttribute)
+		if err != nil {
+			s.apiError(w, r, err)
+			return
+		}
+
+		lib.LogAudit(r.Context(), constants.AuditUpdatedGroupAttribute, map[string]interface{}{
+			"groupId":          group.Id,
+			"groupAttributeId": groupAttribute.Id,
+			"apiClient":        getApiClient(r),
+		})
+
+		writeApiResponse(w, http.StatusOK, core_api.NewGroupAttribute(groupAttribute))
+	}
+}
+
+func (s *Server) handleApiGroupAttributeDelete() http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		group := s.getApiGroup(w, r)
+		if group == nil {
+			return
+		}
+		groupAttribute := s.getApiGroupAttribute(w, r, group)
+		if groupAttribute == nil {
+			return
+		}
+
+		err := s.database.DeleteGroupAttribute(nil, groupAttribute.Id)
+		if err != nil {
+			s.apiError(w, r, err)
+			return
+		}
+
+		lib.LogAudit(r.Context(), constants.AuditDeleteGroupAttribute, map[string]interface{}{
+			"groupId":          group.Id,
+			"groupAttributeId": groupAttribute.Id,
+			"apiClient":        getApiClient(r),
+		})
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,408 @@
+package server
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/leodip/goiabada/internal/constants"
+	core_api "github.com/leodip/goiabada/internal/core/api"
+	"github.com/leodip/goiabada/internal/customerrors"
+	"github.com/leodip/goiabada/internal/entities"
+	"github.com/leodip/goiabada/internal/lib"
+)
+
+// getApiResource returns the resource identified by the resourceId in the URL, or nil after
+// writing the error response. When the resource will be modified, system-level resources are refused.
+func (s *Server) getApiResource(w http.ResponseWriter, r *http.Request, modify bool) *entities.Resource {
+	id, err := getApiId(r, "resourceId", "Resource not found.")
+	if err != nil {
+		s.apiError(w, r, err)
+		return nil
+	}
+	resource, err := s.database.GetResourceById(nil, id)
+	if err != nil {
+		s.apiError(w, r, err)
+		return nil
+	}
+	if resource == nil {
+		s.apiError(w, r, core_api.NewNotFoundError("Resource not found."))
+		return nil
+	}
+	if modify && resource.IsSystemLevelResource() {
+		s.apiError(w, r, core_api.NewForbiddenError("System-level resources can't be modified."))
+		return nil
+	}
+	return resource
+}
+
+// applyApiResource validates the representation of a resource and copies it to the resource.
+func (s *Server) applyApiResource(resource *entities.Resource, input *core_api.ResourceInput,
+	identifierValidator identifierValidator, inputSanitizer inputSanitizer) error {
+
+	resourceIdentifier := strings.TrimSpace(input.ResourceIdentifier)
+	description := strings.TrimSpace(input.Description)
+
+	if len(resourceIdentifier) == 0 {
+		return customerrors.NewValidationError("", "Resource identifier is required.")
+	}
+
+	const maxLengthDescription = 100
+	if len(description) > maxLengthDescription {
+		return customerrors.NewValidationError("", "The description cannot exceed a maximum length of "+strconv.Itoa(maxLengthDescription)+" characters.")
+	}
+
+	err := identifierValidator.ValidateIdentifier(resourceIdentifier, true)
+	if err != nil {
+		return err
+	}
+
+	existingResource, err := s.database.GetResourceByResourceIdentifier(nil, resourceIdentifier)
+	if err != nil {
+		return err
+	}
+	if existingResource != nil && existingResource.Id != resource.Id {
+		return core_api.NewConflictError("The resource identifier is already in use.")
+	}
+
+	minAcrLevel, maxAuthAgeInSeconds, err := parseStepUpRequirements(input.MinAcrLevel, strconv.Itoa(input.MaxAuthAgeInSeconds))
+	if err != nil {
+		return err
+	}
+
+	resource.ResourceIdentifier = inputSanitizer.Sanitize(resourceIdentifier)
+	resource.Description = inputSanitizer.Sanitize(description)
+	resource.MinAcrLevel = minAcrLevel
+	resource.MaxAuthAgeInSeconds = maxAuthAgeInSeconds
+	return nil
+}
+
+func (s *Server) handleApiResourcesGet() http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		resources, err := s.database.GetAllResources(nil)
+		if err != nil {
+			s.apiError(w, r, err)
+			return
+		}
+
+		result := core_api.ListResponse[*core_api.Resource]{Items: []*core_api.Resource{}, Total: len(resources)}
+		for idx := range resources {
+			result.Items = append(result.Items, core_api.NewResource(&resources[idx]))
+		}
+		writeApiResponse(w, http.StatusOK, result)
+	}
+}
+
+func (s *Server) handleApiResourcesPost(identifierValidator identifierValidator,
+	inputSanitizer inputSanitizer) http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		var input core_api.ResourceInput
+		err := readApiRequest(w, r, &input)
+		if err != nil {
+			s.apiError(w, r, err)
+			return
+		}
+
+		resource := &entities.Resource{}
+		err = s.applyApiResource(resource, &input, identifierValidator, inputSanitizer)
+		if err != nil {
+			s.apiError(w, r, err)
+			return
+		}
+
+		err = s.database.CreateResource(nil, resource)
+		if err != nil {
+			s.apiError(w, r, err)
+			return
+		}
+
+		lib.LogAudit(r.Context(), constants.AuditCreatedResource, map[string]interface{}{
+			"resourceId":         resource.Id,
+			"resourceIdentifier": resource.ResourceIdentifier,
+			"apiClient":          getApiClient(r),
+		})
+
+		resource, err = s.database.GetResourceById(nil, resource.Id)
+		if err != nil {
+			s.apiError(w, r, err)
+			return
+		}
+		writeApiResponse(w, http.StatusCreated, core_api.NewResource(resource))
+	}
+}
+
+func (s *Server) handleApiResourceGet() http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		resource := s.getApiResource(w, r, false)
+		if resource == nil {
+			return
+		}
+		writeApiResponse(w, http.StatusOK, core_api.NewResource(resource))
+	}
+}
+
+func (s *Server) handleApiResourcePut(identifierValidator identifierValidator,
+	inputSanitizer inputSanitizer) http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		resource := s.getApiResource(w, r, true)
+		if resource == nil {
+			return
+		}
+
+		var input core_api.ResourceInput
+		err := readApiRequest(w, r, &input)
+		if err != nil {
+			s.apiError(w, r, err)
+			return
+		}
+
+		err = s.applyApiResource(resource, &input, identifierValidator, inputSanitizer)
+		if err != nil {
+			s.apiError(w, r, err)
+			return
+		}
+
+		err = s.database.UpdateResource(nil, resource)
+		if err != nil {
+			s.apiError(w, r, err)
+			return
+		}
+
+		lib.LogAudit(r.Context(), constants.AuditUpdatedResource, map[string]interface{}{
+			"resourceId":         resource.Id,
+			"resourceIdentifier": resource.ResourceIdentifier,
+			"apiClient":          getApiClient(r),
+		})
+
+		writeApiResponse(w, http.StatusOK, core_api.NewResource(resource))
+	}
+}
+
+func (s *Server) handleApiResourceDelete() http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		resource := s.getApiResource(w, r, true)
+		if resource == nil {
+			return
+		}
+
+		err := s.database.DeleteResource(nil, resource.Id)
+		if err != nil {
+			s.apiError(w, r, err)
+			return
+		}
+
+		lib.LogAudit(r.Context(), constants.AuditDeletedResource, map[string]interface{}{
+			"resourceId":         resource.Id,
+			"resourceIdentifier": resource.ResourceIdentifier,
+			"apiClient":          getApiClient(r),
+		})
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func (s *Server) handleApiResourcePermissionsGet() http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		resource := s.getApiResource(w, r, false)
+		if resource == nil {
+			return
+		}
+
+		permissions, err := s.database.GetPermissionsByResourceId(nil, resource.Id)
+		if err != nil {
+			s.apiError(w, r, err)
+			return
+		}
+		s.writeApiPermissions(w, r, permissions)
+	}
+}
+
+// applyApiPermission validates the representation of a permission and copies it to the permission,
+// which must belong to the resource.
+func (s *Server) applyApiPermission(resource *entities.Resource, permission *entities.Permission,
+	input *core_api.PermissionInput, identifierValidator identifierValidator, inputSanitizer inputSanitizer) error {
+
+	permissionIdentifier := strings.TrimSpace(input.PermissionIdentifier)
+	description := strings.TrimSpace(input.Description)
+
+	if len(permissionIdentifier) == 0 {
+		return customerrors.NewValidationError("", "Permission identifier is required.")
+	}
+
+	err := identifierValidator.ValidateIdentifier(permissionIdentifier, true)
+	if err != nil {
+		return err
+	}
+
+	const maxLengthDescription = 100
+	if len(description) > maxLengthDescription {
+		return customerrors.NewValidationError("", "The description cannot exceed a maximum length of "+strconv.Itoa(maxLengthDescription)+" characters.")
+	}
+
+	minAcrLevel, maxAuthAgeInSeconds, err := parseStepUpRequirements(input.MinAcrLevel, strconv.Itoa(input.MaxAuthAgeInSeconds))
+	if err != nil {
+		return err
+	}
+
+	permissions, err := s.database.GetPermissionsByResourceId(nil, resource.Id)
+	if err != nil {
+		return err
+	}
+	for _, existingPermission := range permissions {
+		if existingPermission.PermissionIdentifier == permissionIdentifier && existingPermission.Id != permission.Id {
+			return core_api.NewConflictError("The permission identifier is already in use.")
+		}
+	}
+
+	permission.ResourceId = resource.Id
+	permission.Resource = *resource
+	permission.PermissionIdentifier = inputSanitizer.Sanitize(permissionIdentifier)
+	permission.Description = inputSanitizer.Sanitize(description)
+	permission.MinAcrLevel = minAcrLevel
+	permission.MaxAuthAgeInSeconds = maxAuthAgeInSeconds
+	return nil
+}
+
+func (s *Server) handleApiResourcePermissionsPost(identifierValidator identifierValidator,
+	inputSanitizer inputSanitizer) http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		resource := s.getApiResource(w, r, true)
+		if resource == nil {
+			return
+		}
+
+		var input core_api.PermissionInput
+		err := readApiRequest(w, r, &input)
+		if err != nil {
+			s.apiError(w, r, err)
+			return
+		}
+
+		permission := &entities.Permission{}
+		err = s.applyApiPermission(resource, permission, &input, identifierValidator, inputSanitizer)
+		if err != nil {
+			s.apiError(w, r, err)
+			return
+		}
+
+		err = s.database.CreatePermission(nil, permission)
+		if err != nil {
+			s.apiError(w, r, err)
+			return
+		}
+
+		lib.LogAudit(r.Context(), constants.AuditUpdatedResourcePermissions, map[string]interface{}{
+			"resourceId":   resource.Id,
+			"permissionId": permission.Id,
+			"apiClient":    getApiClient(r),
+		})
+
+		writeApiResponse(w, http.StatusCreated, core_api.NewPermission(permission))
+	}
+}
+
+// getApiPermission returns the permission identified by the permissionId in the URL, which must
+// belong to the resource, or nil after writing the error response.
+func (s *Server) getApiPermission(w http.ResponseWriter, r *http.Request, resource *entities.Resource) *entities.Permission {
+	id, err := getApiId(r, "permissionId", "Permission not found.")
+	if err != nil {
+		s.apiError(w, r, err)
+		return nil
+	}
+	permission, err := s.database.GetPermissionById(nil, id)
+	if err != nil {
+		s.apiError(w, r, err)
+		return nil
+	}
+	if permission == nil || permission.ResourceId != resource.Id {
+		s.apiError(w, r, core_api.NewNotFoundError("Permission not found."))
+		return nil
+	}
+	return permission
+}
+
+func (s *Server) handleApiResourcePermissionPut(identifierValidator identifierValidator,
+	inputSanitizer inputSanitizer) http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		resource := s.getApiResource(w, r, true)
+		if resource == nil {
+			return
+		}
+		permission := s.getApiPermission(w, r, resource)
+		if permission == nil {
+			return
+		}
+
+		var input core_api.PermissionInput
+		err := readApiRequest(w, r, &input)
+		if err != nil {
+			s.apiError(w, r, err)
+			return
+		}
+
+		err = s.applyApiPermission(resource, permission, &input, identifierValidator, inputSanitizer)
+		if err != nil {
+			s.apiError(w, r, err)
+			return
+		}
+
+		err = s.database.UpdatePermission(nil, permission)
+		if err != nil {
+			s.apiError(w, r, err)
+			return
+		}
+
+		lib.LogAudit(r.Context(), constants.AuditUpdatedResourcePermissions, map[string]interface{}{
+			"resourceId":   resource.Id,
+			"permissionId": permission.Id,
+			"apiClient":    getApiClient(r),
+		})
+
+		writeApiResponse(w, http.StatusOK, core_api.NewPermission(permission))
+	}
+}
+
+func (s *Server) handleApiResourcePermissionDelete() http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		resource := s.getApiResource(w, r, true)
+		if resource == nil {
+			return
+		}
+		permission := s.getApiPermission(w, r, resource)
+		if permission == nil {
+			return
+		}
+
+		err := s.database.DeletePermission(nil, permission.Id)
+		if err != nil {
+			s.apiError(w, r, err)
+			return
+		}
+
+		lib.LogAudit(r.Context(), constants.AuditUpdatedResourcePermissions, map[string]interface{}{
+			"resourceId":   resource.Id,
+			"permissionId": permission.Id,
+			"apiClient":    getApiClient(r),
+		})
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
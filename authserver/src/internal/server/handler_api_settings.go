@@ -0,0 +1,136 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/leodip/goiabada/internal/common"
+	"github.com/leodip/goiabada/internal/constants"
+	core_api "github.com/leodip/goiabada/internal/core/api"
+	"github.com/leodip/goiabada/internal/customerrors"
+	"github.com/leodip/goiabada/internal/entities"
+	"github.com/leodip/goiabada/internal/lib"
+)
+
+// validateApiSettings applies the same rules as the general, tokens and sessions pages of the admin area.
+func validateApiSettings(input *core_api.Settings) error {
+
+	input.AppName = strings.TrimSpace(input.AppName)
+	input.Issuer = strings.TrimSpace(input.Issuer)
+
+	maxLength := 30
+	if len(input.AppName) > maxLength {
+		return customerrors.NewValidationError("", fmt.Sprintf("App name is too long. The maximum length is %v characters.", maxLength))
+	}
+
+	// any value containing a ":" character MUST be a URI
+	if strings.Contains(input.Issuer, ":") {
+		_, err := url.ParseRequestURI(input.Issuer)
+		if err != nil {
+			return customerrors.NewValidationError("", "Invalid issuer. Please enter a valid URI.")
+		}
+	} else {
+		errorMsg := "Invalid issuer. It must start with a letter, can include letters, numbers, dashes, and underscores, but cannot end with a dash or underscore, or have two consecutive dashes or underscores."
+		match, _ := regexp.MatchString("^[a-zA-Z]([a-zA-Z0-9_-]*[a-zA-Z0-9])?$", input.Issuer)
+		if !match || strings.Contains(input.Issuer, "--") || strings.Contains(input.Issuer, "__") {
+			return customerrors.NewValidationError("", errorMsg)
+		}
+		minLength := 3
+		if len(input.Issuer) < minLength {
+			return customerrors.NewValidationError("", fmt.Sprintf("Issuer is too short. The minimum length is %v characters.", minLength))
+		}
+	}
+	maxLength = 60
+	if len(input.Issuer) > maxLength {
+		return customerrors.NewValidationError("", fmt.Sprintf("Issuer is too long. The maximum length is %v characters.", maxLength))
+	}
+
+	const maxValue = 160000000
+	if input.TokenExpirationInSeconds <= 0 || input.TokenExpirationInSeconds > maxValue {
+		return customerrors.NewValidationError("", fmt.Sprintf("Token expiration in seconds must be between 1 and %v.", maxValue))
+	}
+	if input.RefreshTokenOfflineIdleTimeoutInSeconds <= 0 || input.RefreshTokenOfflineIdleTimeoutInSeconds > maxValue {
+		return customerrors.NewValidationError("", fmt.Sprintf("Refresh token offline - idle timeout in seconds must be between 1 and %v.", maxValue))
+	}
+	if input.RefreshTokenOfflineMaxLifetimeInSeconds <= 0 || input.RefreshTokenOfflineMaxLifetimeInSeconds > maxValue {
+		return customerrors.NewValidationError("", fmt.Sprintf("Refresh token offline - max lifetime in seconds must be between 1 and %v.", maxValue))
+	}
+	if input.RefreshTokenOfflineIdleTimeoutInSeconds > input.RefreshTokenOfflineMaxLifetimeInSeconds {
+		return customerrors.NewValidationError("", "Refresh token offline - idle timeout cannot be greater than max lifetime.")
+	}
+
+	if input.UserSessionIdleTimeoutInSeconds <= 0 || input.UserSessionIdleTimeoutInSeconds > maxValue {
+		return customerrors.NewValidationError("", fmt.Sprintf("User session - idle timeout in seconds must be between 1 and %v.", maxValue))
+	}
+	if input.UserSessionMaxLifetimeInSeconds <= 0 || input.UserSessionMaxLifetimeInSeconds > maxValue {
+		return customerrors.NewValidationError("", fmt.Sprintf("User session - max lifetime in seconds must be between 1 and %v.", maxValue))
+	}
+	if input.UserSessionIdleTimeoutInSeconds > input.UserSessionMaxLifetimeInSeconds {
+		return customerrors.NewValidationError("", "User session - the idle timeout cannot be greater than the max lifetime.")
+	}
+
+	const maxTrustedDeviceLifetimeInDays = 365
+	if input.TrustedDeviceLifetimeInDays < 0 || input.TrustedDeviceLifetimeInDays > maxTrustedDeviceLifetimeInDays {
+		return customerrors.NewValidationError("", fmt.Sprintf("Trusted devices - lifetime in days must be between 0 and %v.", maxTrustedDeviceLifetimeInDays))
+	}
+	return nil
+}
+
+func (s *Server) handleApiSettingsGet() http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		settings := r.Context().Value(common.ContextKeySettings).(*entities.Settings)
+		writeApiResponse(w, http.StatusOK, core_api.NewSettings(settings))
+	}
+}
+
+func (s *Server) handleApiSettingsPut(inputSanitizer inputSanitizer) http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		var input core_api.Settings
+		err := readApiRequest(w, r, &input)
+		if err != nil {
+			s.apiError(w, r, err)
+			return
+		}
+
+		err = validateApiSettings(&input)
+		if err != nil {
+			s.apiError(w, r, err)
+			return
+		}
+
+		settings := r.Context().Value(common.ContextKeySettings).(*entities.Settings)
+		settings.AppName = inputSanitizer.Sanitize(input.AppName)
+		settings.Issuer = inputSanitizer.Sanitize(input.Issuer)
+		settings.SelfRegistrationEnabled = input.SelfRegistrationEnabled
+		settings.SelfRegistrationRequiresEmailVerification = input.SelfRegistrationEnabled && input.SelfRegistrationRequiresEmailVerification
+		settings.TokenExpirationInSeconds = input.TokenExpirationInSeconds
+		settings.RefreshTokenOfflineIdleTimeoutInSeconds = input.RefreshTokenOfflineIdleTimeoutInSeconds
+		settings.RefreshTokenOfflineMaxLifetimeInSeconds = input.RefreshTokenOfflineMaxLifetimeInSeconds
+		settings.IncludeOpenIDConnectClaimsInAccessToken = input.IncludeOpenIDConnectClaimsInAccessToken
+		settings.UserSessionIdleTimeoutInSeconds = input.UserSessionIdleTimeoutInSeconds
+		settings.UserSessionMaxLifetimeInSeconds = input.UserSessionMaxLifetimeInSeconds
+		settings.TrustedDeviceLifetimeInDays = input.TrustedDeviceLifetimeInDays
+
+		err = s.database.UpdateSettings(nil, settings)
+		if err != nil {
+			s.apiError(w, r, err)
+			return
+		}
+
+		for _, auditEvent := range []string{constants.AuditUpdatedGeneralSettings, constants.AuditUpdatedTokensSettings,
+			constants.AuditUpdatedSessionsSettings} {
+			lib.LogAudit(r.Context(), auditEvent, map[string]interface{}{
+				"apiClient": getApiClient(r),
+			})
+		}
+
+		writeApiResponse(w, http.StatusOK, core_api.NewSettings(settings))
+	}
+}
@@ -0,0 +1,1049 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/leodip/goiabada/internal/common"
+	"github.com/leodip/goiabada/internal/constants"
+	"github.com/leodip/goiabada/internal/core"
+	core_api "github.com/leodip/goiabada/internal/core/api"
+	core_validators "github.com/leodip/goiabada/internal/core/validators"
+	"github.com/leodip/goiabada/internal/customerrors"
+	"github.com/leodip/goiabada/internal/dtos"
+	"github.com/leodip/goiabada/internal/entities"
+	"github.com/leodip/goiabada/internal/enums"
+	"github.com/leodip/goiabada/internal/lib"
+)
+
+// getApiUser returns the user identified by the userId in the URL, or nil after writing
+// the error response.
+func (s *Server) getApiUser(w http.ResponseWriter, r *http.Request) *entities.User {
+	id, err := getApiId(r, "userId", "User not found.")
+	if err != nil {
+		s.apiError(w, r, err)
+		return nil
+	}
+	user, err := s.database.GetUserById(nil, id)
+	if err != nil {
+		s.apiError(w, r, err)
+		return nil
+	}
+	if user == nil {
+		s.apiError(w, r, core_api.NewNotFoundError("User not found."))
+		return nil
+	}
+	return user
+}
+
+// applyApiUser validates the representation of a user and copies it to the user. As in a PUT
+// request, the attributes that are not present are cleared (except enabled and password).
+func (s *Server) applyApiUser(ctx context.Context, user *entities.User, input *core_api.UserInput,
+	profileValidator profileValidator, emailValidator emailValidator, phoneValidator phoneValidator,
+	addressValidator addressValidator, passwordValidator passwordValidator, userPasswordManager userPasswordManager,
+	inputSanitizer inputSanitizer) error {
+
+	email := strings.ToLower(strings.TrimSpace(input.Email))
+	if len(email) == 0 {
+		return customerrors.NewValidationError("", "Please enter an email address.")
+	}
+	err := emailValidator.ValidateEmailAddress(ctx, email)
+	if err != nil {
+		return err
+	}
+	if len(email) > 60 {
+		return customerrors.NewValidationError("", "The email address cannot exceed a maximum length of 60 characters.")
+	}
+	existingUser, err := s.database.GetUserByEmail(nil, email)
+	if err != nil {
+		return err
+	}
+	if existingUser != nil && existingUser.Id != user.Id {
+		return core_api.NewConflictError("The email address is already in use.")
+	}
+
+	// the validator expects the index of the gender
+	gender := ""
+	if len(input.Gender) > 0 {
+		gender = input.Gender
+		for i := int(enums.GenderFemale); i <= int(enums.GenderOther); i++ {
+			if enums.Gender(i).String() == input.Gender {
+				gender = strconv.Itoa(i)
+				break
+			}
+		}
+	}
+
+	zoneInfoCountryName := ""
+	for _, tz := range lib.GetTimeZones() {
+		if tz.Zone == input.ZoneInfo {
+			zoneInfoCountryName = tz.CountryName
+			break
+		}
+	}
+
+	profileInput := &core_validators.ValidateProfileInput{
+		Username:            strings.TrimSpace(input.Username),
+		GivenName:           strings.TrimSpace(input.GivenName),
+		MiddleName:          strings.TrimSpace(input.MiddleName),
+		FamilyName:          strings.TrimSpace(input.FamilyName),
+		Nickname:            strings.TrimSpace(input.Nickname),
+		Website:             strings.TrimSpace(input.Website),
+		Gender:              gender,
+		DateOfBirth:         strings.TrimSpace(input.BirthDate),
+		ZoneInfoCountryName: zoneInfoCountryName,
+		ZoneInfo:            input.ZoneInfo,
+		Locale:              input.Locale,
+		Subject:             user.Subject.String(),
+	}
+	err = profileValidator.ValidateProfile(ctx, profileInput)
+	if err != nil {
+		return err
+	}
+
+	phoneInput := &core_validators.ValidatePhoneInput{
+		PhoneNumberCountry: input.PhoneNumberCountry,
+		PhoneNumber:        strings.TrimSpace(input.PhoneNumber),
+	}
+	err = phoneValidator.ValidatePhone(ctx, phoneInput)
+	if err != nil {
+		return err
+	}
+
+	addressInput := &core_validators.ValidateAddressInput{
+		AddressLine1:      strings.TrimSpace(input.Address.Line1),
+		AddressLine2:      strings.TrimSpace(input.Address.Line2),
+		AddressLocality:   strings.TrimSpace(input.Address.Locality),
+		AddressRegion:     strings.TrimSpace(input.Address.Region),
+		AddressPostalCode: strings.TrimSpace(input.Address.PostalCode),
+		AddressCountry:    strings.TrimSpace(input.Address.Country),
+	}
+	err = addressValidator.ValidateAddress(ctx, addressInput)
+	if err != nil {
+		return err
+	}
+
+	if len(input.Password) > 0 {
+		passwordUser := *user
+		passwordUser.Email = email
+		err = passwordValidator.ValidatePassword(ctx, input.Password, &passwordUser)
+		if err != nil {
+			return err
+		}
+		err = userPasswordManager.SetPassword(ctx, user, input.Password)
+		if err != nil {
+			return err
+		}
+	}
+
+	if input.Enabled != nil {
+		user.Enabled = *input.Enabled
+	}
+	if email != user.Email {
+		user.EmailVerificationCodeEncrypted = nil
+		user.EmailVerificationCodeIssuedAt = sql.NullTime{Valid: false}
+	}
+	user.Email = inputSanitizer.Sanitize(email)
+	user.EmailVerified = input.EmailVerified
+	user.Username = inputSanitizer.Sanitize(profileInput.Username)
+	user.GivenName = inputSanitizer.Sanitize(profileInput.GivenName)
+	user.MiddleName = inputSanitizer.Sanitize(profileInput.MiddleName)
+	user.FamilyName = inputSanitizer.Sanitize(profileInput.FamilyName)
+	user.Nickname = inputSanitizer.Sanitize(profileInput.Nickname)
+	user.Website = profileInput.Website
+	user.Gender = ""
+	if len(gender) > 0 {
+		i, _ := strconv.Atoi(gender)
+		user.Gender = enums.Gender(i).String()
+	}
+	user.BirthDate = sql.NullTime{Valid: false}
+	if len(profileInput.DateOfBirth) > 0 {
+		parsedTime, _ := time.Parse("2006-01-02", profileInput.DateOfBirth)
+		user.BirthDate = sql.NullTime{Time: parsedTime, Valid: true}
+	}
+	user.ZoneInfoCountryName = zoneInfoCountryName
+	user.ZoneInfo = input.ZoneInfo
+	user.Locale = input.Locale
+	user.PhoneNumber = ""
+	if len(phoneInput.PhoneNumber) > 0 {
+		user.PhoneNumber = inputSanitizer.Sanitize(fmt.Sprintf("%v %v", phoneInput.PhoneNumberCountry, phoneInput.PhoneNumber))
+	}
+	user.PhoneNumberVerified = input.PhoneNumberVerified && len(user.PhoneNumber) > 0
+	user.AddressLine1 = inputSanitizer.Sanitize(addressInput.AddressLine1)
+	user.AddressLine2 = inputSanitizer.Sanitize(addressInput.AddressLine2)
+	user.AddressLocality = inputSanitizer.Sanitize(addressInput.AddressLocality)
+	user.AddressRegion = inputSanitizer.Sanitize(addressInput.AddressRegion)
+	user.AddressPostalCode = inputSanitizer.Sanitize(addressInput.AddressPostalCode)
+	user.AddressCountry = inputSanitizer.Sanitize(addressInput.AddressCountry)
+	return nil
+}
+
+func (s *Server) handleApiUsersGet() http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		page, pageSize, err := getApiPagination(r)
+		if err != nil {
+			s.apiError(w, r, err)
+			return
+		}
+
+		users, total, err := s.database.SearchUsersPaginated(nil, strings.TrimSpace(r.URL.Query().Get("query")), page, pageSize)
+		if err != nil {
+			s.apiError(w, r, err)
+			return
+		}
+
+		result := core_api.ListResponse[*core_api.User]{
+			Items:    []*core_api.User{},
+			Total:    total,
+			Page:     page,
+			PageSize: pageSize,
+		}
+		for idx := range users {
+			result.Items = append(result.Items, core_api.NewUser(&users[idx]))
+		}
+		writeApiResponse(w, http.StatusOK, result)
+	}
+}
+
+func (s *Server) handleApiUsersPost(
+	userCreator userCreator,
+	profileValidator profileValidator,
+	emailValidator emailValidator,
+	phoneValidator phoneValidator,
+	addressValidator addressValidator,
+	passwordValidator passwordValidator,
+	userPasswordManager userPasswordManager,
+	inputSanitizer inputSanitizer,
+) http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		var input core_api.UserInput
+		err := readApiRequest(w, r, &input)
+		if err != nil {
+			s.apiError(w, r, err)
+			return
+		}
+
+		// the attributes are validated before the user is created
+		newUser := &entities.User{Enabled: true}
+		err = s.applyApiUser(r.Context(), newUser, &input, profileValidator, emailValidator, phoneValidator,
+			addressValidator, passwordValidator, userPasswordManager, inputSanitizer)
+		if err != nil {
+			s.apiError(w, r, err)
+			return
+		}
+
+		user, err := userCreator.CreateUser(r.Context(), &core.CreateUserInput{
+			Email:         newUser.Email,
+			EmailVerified: newUser.EmailVerified,
+			PasswordHash:  newUser.PasswordHash,
+			GivenName:     newUser.GivenName,
+			MiddleName:    newUser.MiddleName,
+			FamilyName:    newUser.FamilyName,
+		})
+		if err != nil {
+			s.apiError(w, r, err)
+			return
+		}
+
+		newUser.Id = user.Id
+		newUser.Subject = user.Subject
+		newUser.CreatedAt = user.CreatedAt
+		err = s.database.UpdateUser(nil, newUser)
+		if err != nil {
+			s.apiError(w, r, err)
+			return
+		}
+
+		lib.LogAudit(r.Context(), constants.AuditCreatedUser, map[string]interface{}{
+			"email":     newUser.Email,
+			"apiClient": getApiClient(r),
+		})
+
+		user, err = s.database.GetUserById(nil, newUser.Id)
+		if err != nil {
+			s.apiError(w, r, err)
+			return
+		}
+		s.publishUserEvent(constants.WebhookEventUserCreated, user, nil)
+		writeApiResponse(w, http.StatusCreated, core_api.NewUser(user))
+	}
+}
+
+func (s *Server) handleApiUserGet() http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		user := s.getApiUser(w, r)
+		if user == nil {
+			return
+		}
+		writeApiResponse(w, http.StatusOK, core_api.NewUser(user))
+	}
+}
+
+func (s *Server) handleApiUserPut(
+	profileValidator profileValidator,
+	emailValidator emailValidator,
+	phoneValidator phoneValidator,
+	addressValidator addressValidator,
+	passwordValidator passwordValidator,
+	userPasswordManager userPasswordManager,
+	inputSanitizer inputSanitizer,
+) http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		user := s.getApiUser(w, r)
+		if user == nil {
+			return
+		}
+
+		err := s.checkApiUserPermissionScopes(r, user)
+		if err != nil {
+			s.apiError(w, r, err)
+			return
+		}
+
+		var input core_api.UserInput
+		err = readApiRequest(w, r, &input)
+		if err != nil {
+			s.apiError(w, r, err)
+			return
+		}
+
+		oldEmail := user.Email
+		oldPasswordHash := user.PasswordHash
+		wasEnabled := user.Enabled
+		err = s.applyApiUser(r.Context(), user, &input, profileValidator, emailValidator, phoneValidator,
+			addressValidator, passwordValidator, userPasswordManager, inputSanitizer)
+		if err != nil {
+			s.apiError(w, r, err)
+			return
+		}
+
+		err = s.database.UpdateUser(nil, user)
+		if err != nil {
+			s.apiError(w, r, err)
+			return
+		}
+
+		lib.LogAudit(r.Context(), constants.AuditUpdatedUserDetails, map[string]interface{}{
+			"userId":    user.Id,
+			"apiClient": getApiClient(r),
+		})
+
+		if oldPasswordHash != user.PasswordHash {
+			lib.LogAudit(r.Context(), constants.AuditChangedPassword, map[string]interface{}{
+				"userId":    user.Id,
+				"apiClient": getApiClient(r),
+			})
+			s.sendSecurityNotification(r, user, constants.AuditChangedPassword)
+		}
+		if oldEmail != user.Email {
+			s.notifyEmailChange(r, user, oldEmail)
+		}
+
+		user, err = s.database.GetUserById(nil, user.Id)
+		if err != nil {
+			s.apiError(w, r, err)
+			return
+		}
+		s.publishUserUpdatedEvent(wasEnabled, user)
+		writeApiResponse(w, http.StatusOK, core_api.NewUser(user))
+	}
+}
+
+func (s *Server) handleApiUserDelete() http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		user := s.getApiUser(w, r)
+		if user == nil {
+			return
+		}
+
+		err := s.checkApiUserPermissionScopes(r, user)
+		if err != nil {
+			s.apiError(w, r, err)
+			return
+		}
+
+		err = s.database.DeleteUser(nil, user.Id)
+		if err != nil {
+			s.apiError(w, r, err)
+			return
+		}
+
+		lib.LogAudit(r.Context(), constants.AuditDeletedUser, map[string]interface{}{
+			"userId":    user.Id,
+			"apiClient": getApiClient(r),
+		})
+		s.publishUserEvent(constants.WebhookEventUserDeleted, user, nil)
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func (s *Server) writeApiUserGroups(w http.ResponseWriter, r *http.Request, user *entities.User) {
+	err := s.database.UserLoadGroups(nil, user)
+	if err != nil {
+		s.apiError(w, r, err)
+		return
+	}
+	result := core_api.ListResponse[*core_api.Group]{Items: []*core_api.Group{}, Total: len(user.Groups)}
+	for idx := range user.Groups {
+		result.Items = append(result.Items, core_api.NewGroup(&user.Groups[idx]))
+	}
+	writeApiResponse(w, http.StatusOK, result)
+}
+
+func (s *Server) handleApiUserGroupsGet() http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		user := s.getApiUser(w, r)
+		if user == nil {
+			return
+		}
+		s.writeApiUserGroups(w, r, user)
+	}
+}
+
+// handleApiUserGroupsPut replaces the groups of the user with the groups in the request.
+func (s *Server) handleApiUserGroupsPut() http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		user := s.getApiUser(w, r)
+		if user == nil {
+			return
+		}
+
+		var input core_api.IdList
+		err := readApiRequest(w, r, &input)
+		if err != nil {
+			s.apiError(w, r, err)
+			return
+		}
+
+		groups := map[int64]*entities.Group{}
+		for _, groupId := range input.Ids {
+			group, err := s.database.GetGroupById(nil, groupId)
+			if err != nil {
+				s.apiError(w, r, err)
+				return
+			}
+			if group == nil {
+				s.apiError(w, r, customerrors.NewValidationError("", fmt.Sprintf("Group %v not found.", groupId)))
+				return
+			}
+			groups[groupId] = group
+		}
+
+		err = s.database.UserLoadGroups(nil, user)
+		if err != nil {
+			s.apiError(w, r, err)
+			return
+		}
+
+		for idx := range user.Groups {
+			if _, ok := groups[user.Groups[idx].Id]; !ok {
+				groups[user.Groups[idx].Id] = &user.Groups[idx]
+			}
+		}
+		for groupId, group := range groups {
+			isMember := slices.ContainsFunc(user.Groups, func(g entities.Group) bool { return g.Id == groupId })
+			if isMember != slices.Contains(input.Ids, groupId) {
+				err = s.checkApiGroupPermissionScopes(r, group)
+				if err != nil {
+					s.apiError(w, r, err)
+					return
+				}
+			}
+		}
+
+		for _, groupId := range input.Ids {
+			found := false
+			for _, group := range user.Groups {
+				if group.Id == groupId {
+					found = true
+					break
+				}
+			}
+			if !found {
+				err = s.database.CreateUserGroup(nil, &entities.UserGroup{
+					UserId:  user.Id,
+					GroupId: groupId,
+				})
+				if err != nil {
+					s.apiError(w, r, err)
+					return
+				}
+				lib.LogAudit(r.Context(), constants.AuditUserAddedToGroup, map[string]interface{}{
+					"userId":    user.Id,
+					"groupId":   groupId,
+					"apiClient": getApiClient(r),
+				})
+				s.publishUserEvent(constants.WebhookEventUserAddedToGroup, user, groups[groupId])
+			}
+		}
+
+		for _, group := range user.Groups {
+			found := false
+			for _, groupId := range input.Ids {
+				if group.Id == groupId {
+					found = true
+					break
+				}
+			}
+			if !found {
+				userGroup, err := s.database.GetUserGroupByUserIdAndGroupId(nil, user.Id, group.Id)
+				if err != nil {
+					s.apiError(w, r, err)
+					return
+				}
+				err = s.database.DeleteUserGroup(nil, userGroup.Id)
+				if err != nil {
+					s.apiError(w, r, err)
+					return
+				}
+				lib.LogAudit(r.Context(), constants.AuditUserRemovedFromGroup, map[string]interface{}{
+					"userId":    user.Id,
+					"groupId":   group.Id,
+					"apiClient": getApiClient(r),
+				})
+			}
+		}
+
+		s.writeApiUserGroups(w, r, user)
+	}
+}
+
+func (s *Server) writeApiPermissions(w http.ResponseWriter, r *http.Request, permissions []entities.Permission) {
+	err := s.database.PermissionsLoadResources(nil, permissions)
+	if err != nil {
+		s.apiError(w, r, err)
+		return
+	}
+	result := core_api.ListResponse[*core_api.Permission]{Items: []*core_api.Permission{}, Total: len(permissions)}
+	for idx := range permissions {
+		result.Items = append(result.Items, core_api.NewPermission(&permissions[idx]))
+	}
+	writeApiResponse(w, http.StatusOK, result)
+}
+
+// checkApiPermissionScopes checks that the token holds all the permissions of the authserver
+// resource in the list. They can only be assigned or removed (directly or through a group) by a
+// token that holds them, otherwise a token with a narrow scope could grant access to the admin area
+// or to the rest of the admin API.
+func (s *Server) checkApiPermissionScopes(r *http.Request, permissions []entities.Permission) error {
+
+	authServerResource, err := s.database.GetResourceByResourceIdentifier(nil, constants.AuthServerResourceIdentifier)
+	if err != nil {
+		return err
+	}
+
+	jwtToken, _ := r.Context().Value(common.ContextKeyJwtInfo).(dtos.JwtToken)
+	for _, permission := range permissions {
+		// manage-account only gives access to the account of the user itself
+		if permission.ResourceId != authServerResource.Id ||
+			permission.PermissionIdentifier == constants.ManageAccountPermissionIdentifier {
+			continue
+		}
+		scope := constants.AuthServerResourceIdentifier + ":" + permission.PermissionIdentifier
+		if !jwtToken.HasScope(scope) {
+			return core_api.NewForbiddenError(fmt.Sprintf("This change involves the permission %v, and requires "+
+				"an access token carrying the %v scope.", permission.Id, scope))
+		}
+	}
+	return nil
+}
+
+// checkApiGroupPermissionScopes checks that the token holds all the permissions of the authserver
+// resource granted by the group, before a user joins or leaves it.
+func (s *Server) checkApiGroupPermissionScopes(r *http.Request, group *entities.Group) error {
+	err := s.database.GroupLoadPermissions(nil, group)
+	if err != nil {
+		return err
+	}
+	return s.checkApiPermissionScopes(r, group.Permissions)
+}
+
+// checkApiUserPermissionScopes checks that the token holds all the permissions of the authserver
+// resource the user has (directly or through a group), before the user is modified or deleted.
+// Otherwise a token with a narrow scope could set the password or email of an admin, and sign in
+// as the admin.
+func (s *Server) checkApiUserPermissionScopes(r *http.Request, user *entities.User) error {
+	err := s.database.UserLoadPermissions(nil, user)
+	if err != nil {
+		return err
+	}
+	err = s.database.UserLoadGroups(nil, user)
+	if err != nil {
+		return err
+	}
+	err = s.database.GroupsLoadPermissions(nil, user.Groups)
+	if err != nil {
+		return err
+	}
+
+	permissions := slices.Clone(user.Permissions)
+	for _, group := range user.Groups {
+		permissions = append(permissions, group.Permissions...)
+	}
+	return s.checkApiPermissionScopes(r, permissions)
+}
+
+// validateApiPermissionIds checks that all the permissions in the list exist, and that the token is
+// allowed to make the changes to the current permissions.
+func (s *Server) validateApiPermissionIds(r *http.Request, ids []int64, current []entities.Permission) error {
+
+	changed := []entities.Permission{}
+	for _, permissionId := range ids {
+		permission, err := s.database.GetPermissionById(nil, permissionId)
+		if err != nil {
+			return err
+		}
+		if permission == nil {
+			return customerrors.NewValidationError("", fmt.Sprintf("Permission %v not found.", permissionId))
+		}
+		if !slices.ContainsFunc(current, func(p entities.Permission) bool { return p.Id == permissionId }) {
+			changed = append(changed, *permission)
+		}
+	}
+
+	for _, permission := range current {
+		if !slices.Contains(ids, permission.Id) {
+			changed = append(changed, permission)
+		}
+	}
+	return s.checkApiPermissionScopes(r, changed)
+}
+
+func (s *Server) handleApiUserPermissionsGet() http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		user := s.getApiUser(w, r)
+		if user == nil {
+			return
+		}
+		err := s.database.UserLoadPermissions(nil, user)
+		if err != nil {
+			s.apiError(w, r, err)
+			return
+		}
+		s.writeApiPermissions(w, r, user.Permissions)
+	}
+}
+
+// handleApiUserPermissionsPut replaces the permissions of the user with the permissions in the request.
+func (s *Server) handleApiUserPermissionsPut() http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		user := s.getApiUser(w, r)
+		if user == nil {
+			return
+		}
+
+		var input core_api.IdList
+		err := readApiRequest(w, r, &input)
+		if err != nil {
+			s.apiError(w, r, err)
+			return
+		}
+
+		err = s.database.UserLoadPermissions(nil, user)
+		if err != nil {
+			s.apiError(w, r, err)
+			return
+		}
+
+		err = s.validateApiPermissionIds(r, input.Ids, user.Permissions)
+		if err != nil {
+			s.apiError(w, r, err)
+			return
+		}
+
+		for _, permissionId := range input.Ids {
+			found := false
+			for _, permission := range user.Permissions {
+				if permission.Id == permissionId {
+					found = true
+					break
+				}
+			}
+			if !found {
+				err = s.database.CreateUserPermission(nil, &entities.UserPermission{
+					UserId:       user.Id,
+					PermissionId: permissionId,
+				})
+				if err != nil {
+					s.apiError(w, r, err)
+					return
+				}
+				lib.LogAudit(r.Context(), constants.AuditAddedUserPermission, map[string]interface{}{
+					"userId":       user.Id,
+					"permissionId": permissionId,
+					"apiClient":    getApiClient(r),
+				})
+			}
+		}
+
+		for _, permission := range user.Permissions {
+			found := false
+			for _, permissionId := range input.Ids {
+				if permission.Id == permissionId {
+					found = true
+					break
+				}
+			}
+			if !found {
+				userPermission, err := s.database.GetUserPermissionByUserIdAndPermissionId(nil, user.Id, permission.Id)
+				if err != nil {
+					s.apiError(w, r, err)
+					return
+				}
+				err = s.database.DeleteUserPermission(nil, userPermission.Id)
+				if err != nil {
+					s.apiError(w, r, err)
+					return
+				}
+				lib.LogAudit(r.Context(), constants.AuditDeletedUserPermission, map[string]interface{}{
+					"userId":       user.Id,
+					"permissionId": permission.Id,
+					"apiClient":    getApiClient(r),
+				})
+			}
+		}
+
+		err = s.database.UserLoadPermissions(nil, user)
+		if err != nil {
+			s.apiError(w, r, err)
+			return
+		}
+		s.writeApiPermissions(w, r, user.Permissions)
+	}
+}
+
+func (s *Server) handleApiUserAttributesGet() http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		user := s.getApiUser(w, r)
+		if user == nil {
+			return
+		}
+
+		attributes, err := s.database.GetUserAttributesByUserId(nil, user.Id)
+		if err != nil {
+			s.apiError(w, r, err)
+			return
+		}
+
+		result := core_api.ListResponse[*core_api.Attribute]{Items: []*core_api.Attribute{}, Total: len(attributes)}
+		for idx := range attributes {
+			result.Items = append(result.Items, core_api.NewUserAttribute(&attributes[idx]))
+		}
+		writeApiResponse(w, http.StatusOK, result)
+	}
+}
+
+func (s *Server) handleApiUserAttributesPost(identifierValidator identifierValidator,
+	inputSanitizer inputSanitizer) http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		user := s.getApiUser(w, r)
+		if user == nil {
+			return
+		}
+
+		var input core_api.AttributeInput
+		err := readApiRequest(w, r, &input)
+		if err != nil {
+			s.apiError(w, r, err)
+			return
+		}
+
+		err = validateApiAttribute(&input, identifierValidator, inputSanitizer)
+		if err != nil {
+			s.apiError(w, r, err)
+			return
+		}
+
+		userAttribute := &entities.UserAttribute{
+			Key:                  input.Key,
+			Value:                input.Value,
+			IncludeInAccessToken: input.IncludeInAccessToken,
+			IncludeInIdToken:     input.IncludeInIdToken,
+			UserId:               user.Id,
+		}
+		err = s.database.CreateUserAttribute(nil, userAttribute)
+		if err != nil {
+			s.apiError(w, r, err)
+			return
+		}
+
+		lib.LogAudit(r.Context(), constants.AuditAddedUserAttribute, map[string]interface{}{
+			"userId":          user.Id,
+			"userAttributeId": userAttribute.Id,
+			"apiClient":       getApiClient(r),
+		})
+
+		writeApiResponse(w, http.StatusCreated, core_api.NewUserAttribute(userAttribute))
+	}
+}
+
+// getApiUserAttribute returns the attribute identified by the attributeId in the URL, which must
+// belong to the user, or nil after writing the error response.
+func (s *Server) getApiUserAttribute(w http.ResponseWriter, r *http.Request, user *entities.User) *entities.UserAttribute {
+	id, err := getApiId(r, "attributeId", "Attribute not found.")
+	if err != nil {
+		s.apiError(w, r, err)
+		return nil
+	}
+	userAttribute, err := s.database.GetUserAttributeById(nil, id)
+	if err != nil {
+		s.apiError(w, r, err)
+		return nil
+	}
+	if userAttribute == nil || userAttribute.UserId != user.Id {
+		s.apiError(w, r, core_api.NewNotFoundError("Attribute not found."))
+		return nil
+	}
+	return userAttribute
+}
+
+func (s *Server) handleApiUserAttributePut(identifierValidator identifierValidator,
+	inputSanitizer inputSanitizer) http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		user := s.getApiUser(w, r)
+		if user == nil {
+			return
+		}
+		userAttribute := s.getApiUserAttribute(w, r, user)
+		if userAttribute == nil {
+			return
+		}
+
+		var input core_api.AttributeInput
+		err := readApiRequest(w, r, &input)
+		if err != nil {
+			s.apiError(w, r, err)
+			return
+		}
+
+		err = validateApiAttribute(&input, identifierValidator, inputSanitizer)
+		if err != nil {
+			s.apiError(w, r, err)
+			return
+		}
+
+		userAttribute.Key = input.Key
+		userAttribute.Value = input.Value
+		userAttribute.IncludeInAccessToken = input.IncludeInAccessToken
+		userAttribute.IncludeInIdToken = input.IncludeInIdToken
+		err = s.database.UpdateUserAttribute(nil, userAttribute)
+		if err != nil {
+			s.apiError(w, r, err)
+			return
+		}
+
+		lib.LogAudit(r.Context(), constants.AuditUpdatedUserAttribute, map[string]interface{}{
+			"userId":          user.Id,
+			"userAttributeId": userAttribute.Id,
+			"apiClient":       getApiClient(r),
+		})
+
+		writeApiResponse(w, http.StatusOK, core_api.NewUserAttribute(userAttribute))
+	}
+}
+
+func (s *Server) handleApiUserAttributeDelete() http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		user := s.getApiUser(w, r)
+		if user == nil {
+			return
+		}
+		userAttribute := s.getApiUserAttribute(w, r, user)
+		if userAttribute == nil {
+			return
+		}
+
+		err := s.database.DeleteUserAttribute(nil, userAttribute.Id)
+		if err != nil {
+			s.apiError(w, r, err)
+			return
+		}
+
+		lib.LogAudit(r.Context(), constants.AuditDeleteUserAttribute, map[string]interface{}{
+			"userId":          user.Id,
+			"userAttributeId": userAttribute.Id,
+			"apiClient":       getApiClient(r),
+		})
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// handleApiUserSessionsGet returns the valid sessions of the user.
+func (s *Server) handleApiUserSessionsGet() http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		user := s.getApiUser(w, r)
+		if user == nil {
+			return
+		}
+
+		userSessions, err := s.database.GetUserSessionsByUserId(nil, user.Id)
+		if err != nil {
+			s.apiError(w, r, err)
+			return
+		}
+		err = s.database.UserSessionsLoadClients(nil, userSessions)
+		if err != nil {
+			s.apiError(w, r, err)
+			return
+		}
+
+		settings := r.Context().Value(common.ContextKeySettings).(*entities.Settings)
+		result := core_api.ListResponse[*core_api.UserSession]{Items: []*core_api.UserSession{}}
+		for idx := range userSessions {
+			if !userSessions[idx].IsValid(settings.UserSessionIdleTimeoutInSeconds, settings.UserSessionMaxLifetimeInSeconds, nil) {
+				continue
+			}
+			err = s.database.UserSessionClientsLoadClients(nil, userSessions[idx].Clients)
+			if err != nil {
+				s.apiError(w, r, err)
+				return
+			}
+			result.Items = append(result.Items, core_api.NewUserSession(&userSessions[idx]))
+		}
+		result.Total = len(result.Items)
+		writeApiResponse(w, http.StatusOK, result)
+	}
+}
+
+func (s *Server) handleApiUserSessionDelete() http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		user := s.getApiUser(w, r)
+		if user == nil {
+			return
+		}
+
+		id, err := getApiId(r, "sessionId", "Session not found.")
+		if err != nil {
+			s.apiError(w, r, err)
+			return
+		}
+		userSession, err := s.database.GetUserSessionById(nil, id)
+		if err != nil {
+			s.apiError(w, r, err)
+			return
+		}
+		if userSession == nil || userSession.UserId != user.Id {
+			s.apiError(w, r, core_api.NewNotFoundError("Session not found."))
+			return
+		}
+
+		err = s.database.DeleteUserSession(nil, userSession.Id)
+		if err != nil {
+			s.apiError(w, r, err)
+			return
+		}
+
+		lib.LogAudit(r.Context(), constants.AuditDeletedUserSession, map[string]interface{}{
+			"userSessionId": userSession.Id,
+			"apiClient":     getApiClient(r),
+		})
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func (s *Server) handleApiUserConsentsGet() http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		user := s.getApiUser(w, r)
+		if user == nil {
+			return
+		}
+
+		userConsents, err := s.database.GetConsentsByUserId(nil, user.Id)
+		if err != nil {
+			s.apiError(w, r, err)
+			return
+		}
+		err = s.database.UserConsentsLoadClients(nil, userConsents)
+		if err != nil {
+			s.apiError(w, r, err)
+			return
+		}
+
+		result := core_api.ListResponse[*core_api.UserConsent]{Items: []*core_api.UserConsent{}, Total: len(userConsents)}
+		for idx := range userConsents {
+			result.Items = append(result.Items, core_api.NewUserConsent(&userConsents[idx]))
+		}
+		writeApiResponse(w, http.StatusOK, result)
+	}
+}
+
+func (s *Server) handleApiUserConsentDelete() http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		user := s.getApiUser(w, r)
+		if user == nil {
+			return
+		}
+
+		id, err := getApiId(r, "consentId", "Consent not found.")
+		if err != nil {
+			s.apiError(w, r, err)
+			return
+		}
+		userConsent, err := s.database.GetUserConsentById(nil, id)
+		if err != nil {
+			s.apiError(w, r, err)
+			return
+		}
+		if userConsent == nil || userConsent.UserId != user.Id {
+			s.apiError(w, r, core_api.NewNotFoundError("Consent not found."))
+			return
+		}
+
+		err = s.database.DeleteUserConsent(nil, userConsent.Id)
+		if err != nil {
+			s.apiError(w, r, err)
+			return
+		}
+
+		lib.LogAudit(r.Context(), constants.AuditDeletedUserConsent, map[string]interface{}{
+			"userId":    user.Id,
+			"consentId": userConsent.Id,
+			"apiClient": getApiClient(r),
+		})
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
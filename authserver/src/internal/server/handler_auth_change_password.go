@@ -0,0 +1,162 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/csrf"
+	"github.com/leodip/goiabada/internal/common"
+	"github.com/leodip/goiabada/internal/constants"
+	"github.com/leodip/goiabada/internal/dtos"
+	"github.com/leodip/goiabada/internal/entities"
+	"github.com/leodip/goiabada/internal/lib"
+	"github.com/pkg/errors"
+)
+
+// getPasswordChangeUser returns the user that must change a breached password to complete the authentication.
+func (s *Server) getPasswordChangeUser(r *http.Request) (*dtos.AuthContext, *entities.User, error) {
+
+	authContext, err := s.getAuthContext(r)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !authContext.PasswordChangeRequired || authContext.UserId == 0 {
+		return nil, nil, errors.WithStack(errors.New("a password change is not required in the auth context"))
+	}
+
+	user, err := s.database.GetUserById(nil, authContext.UserId)
+	if err != nil {
+		return nil, nil, err
+	}
+	if user == nil {
+		return nil, nil, errors.WithStack(errors.New(fmt.Sprintf("user %v not found", authContext.UserId)))
+	}
+	return authContext, user, nil
+}
+
+// isPasswordChangeForBreach tells if the password must be changed because it was breached (otherwise it expired).
+func isPasswordChangeForBreach(r *http.Request, user *entities.User) bool {
+	settings := r.Context().Value(common.ContextKeySettings).(*entities.Settings)
+	return settings.ForceChangeOfBreachedPasswords && user.PasswordBreached
+}
+
+func (s *Server) handleAuthChangePasswordGet() http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		_, user, err := s.getPasswordChangeUser(r)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		bind := map[string]interface{}{
+			"passwordBreached": isPasswordChangeForBreach(r, user),
+			"error":            nil,
+			"csrfField":        csrf.TemplateField(r),
+		}
+
+		err = s.renderTemplate(w, r, "/layouts/auth_layout.html", "/auth_change_password.html", bind)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+	}
+}
+
+func (s *Server) handleAuthChangePasswordPost(passwordValidator passwordValidator, userPasswordManager userPasswordManager) http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		authContext, user, err := s.getPasswordChangeUser(r)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		renderError := func(message string) {
+			bind := map[string]interface{}{
+				"passwordBreached": isPasswordChangeForBreach(r, user),
+				"error":            message,
+				"csrfField":        csrf.TemplateField(r),
+			}
+
+			err := s.renderTemplate(w, r, "/layouts/auth_layout.html", "/auth_change_password.html", bind)
+			if err != nil {
+				s.internalServerError(w, r, err)
+			}
+		}
+
+		newPassword := r.FormValue("newPassword")
+		newPasswordConfirmation := r.FormValue("newPasswordConfirmation")
+
+		if len(strings.TrimSpace(newPassword)) == 0 {
+			renderError("New password is required.")
+			return
+		}
+
+		if newPassword != newPasswordConfirmation {
+			renderError("The new password confirmation does not match the password.")
+			return
+		}
+
+		if lib.VerifyPasswordHash(user.PasswordHash, newPassword) {
+			renderError("The new password must be different from the current password.")
+			return
+		}
+
+		err = passwordValidator.ValidatePassword(r.Context(), newPassword, user)
+		if err != nil {
+			renderError(err.Error())
+			return
+		}
+
+		err = userPasswordManager.SetPassword(r.Context(), user, newPassword)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+		err = s.database.UpdateUser(nil, user)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		lib.LogAudit(r.Context(), constants.AuditChangedPassword, map[string]interface{}{
+			"userId":       user.Id,
+			"loggedInUser": user.Subject.String(),
+		})
+		s.sendSecurityNotification(r, user, constants.AuditChangedPassword)
+
+		client, err := s.database.GetClientByClientIdentifier(nil, authContext.ClientId)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+		if client == nil {
+			s.internalServerError(w, r, errors.WithStack(errors.New(fmt.Sprintf("client %v not found", authContext.ClientId))))
+			return
+		}
+
+		// the authentication was completed before the password change, so the session can start now
+		_, err = s.startNewUserSession(w, r, user.Id, client.Id, authContext.AuthMethods, authContext.AcrLevel)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		authContext.PasswordChangeRequired = false
+		authContext.AuthTime = time.Now().UTC()
+		authContext.AuthCompleted = true
+		err = s.saveAuthContext(w, r, authContext)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		http.Redirect(w, r, lib.GetBaseUrl()+"/auth/consent", http.StatusFound)
+	}
+}
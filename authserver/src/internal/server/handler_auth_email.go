@@ -0,0 +1,414 @@
+package server
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gorilla/csrf"
+	"github.com/leodip/goiabada/internal/common"
+	"github.com/leodip/goiabada/internal/constants"
+	core_senders "github.com/leodip/goiabada/internal/core/senders"
+	"github.com/leodip/goiabada/internal/dtos"
+	"github.com/leodip/goiabada/internal/entities"
+	"github.com/leodip/goiabada/internal/enums"
+	"github.com/leodip/goiabada/internal/lib"
+	"github.com/pkg/errors"
+)
+
+const emailLoginCodeLifetime = 10 * time.Minute
+const emailLoginCodeResendWait = 60 * time.Second
+const emailLoginCodeMaxAttempts = 5
+const emailLoginFailedError = "The code is invalid or has expired."
+
+func (s *Server) handleAuthEmailGet() http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		authContext, err := s.getAuthContext(r)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		emailLoginEnabled, err := s.isEmailLoginEnabled(r, authContext)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+		if !emailLoginEnabled {
+			http.Redirect(w, r, lib.GetBaseUrl()+"/auth/pwd", http.StatusFound)
+			return
+		}
+
+		bind := map[string]interface{}{
+			"error":     nil,
+			"csrfField": csrf.TemplateField(r),
+		}
+
+		err = s.renderTemplate(w, r, "/layouts/auth_layout.html", "/auth_email.html", bind)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+	}
+}
+
+func (s *Server) handleAuthEmailPost(emailSender emailSender) http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		authContext, err := s.getAuthContext(r)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		emailLoginEnabled, err := s.isEmailLoginEnabled(r, authContext)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+		if !emailLoginEnabled {
+			s.internalServerError(w, r, errors.WithStack(errors.New("email login is not enabled for client "+authContext.ClientId)))
+			return
+		}
+
+		email := strings.ToLower(strings.TrimSpace(r.FormValue("email")))
+
+		if len(email) == 0 || strings.Count(email, "@") != 1 {
+			bind := map[string]interface{}{
+				"error":     "Please enter a valid email address.",
+				"email":     email,
+				"csrfField": csrf.TemplateField(r),
+			}
+
+			err = s.renderTemplate(w, r, "/layouts/auth_layout.html", "/auth_email.html", bind)
+			if err != nil {
+				s.internalServerError(w, r, err)
+			}
+			return
+		}
+
+		user, err := s.database.GetUserByEmail(nil, email)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		if user != nil && user.Enabled && user.EmailVerified {
+
+			localPasswordAllowed, err := s.isLocalPasswordAllowed(user)
+			if err != nil {
+				s.internalServerError(w, r, err)
+				return
+			}
+
+			// users that must authenticate with their LDAP directory can't bypass it with an email code
+			if localPasswordAllowed && s.canIssueEmailLoginCode(user) {
+				err = s.sendEmailLoginCode(r, emailSender, user)
+				if err != nil {
+					s.internalServerError(w, r, err)
+					return
+				}
+			}
+		}
+
+		// the code is checked against the email in the auth context, so the link
+		// must be opened in the same browser
+		authContext.EmailLoginAddress = email
+		err = s.saveAuthContext(w, r, authContext)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		// the response is the same whether the user exists or not
+		bind := map[string]interface{}{
+			"codeSent":  true,
+			"email":     email,
+			"csrfField": csrf.TemplateField(r),
+		}
+
+		err = s.renderTemplate(w, r, "/layouts/auth_layout.html", "/auth_email.html", bind)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+	}
+}
+
+func (s *Server) handleAuthEmailCodePost(loginManager loginManager, loginLockoutManager loginLockoutManager,
+	emailSender emailSender) http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		authContext, err := s.getAuthContext(r)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		code := strings.TrimSpace(r.FormValue("code"))
+
+		renderError := func(message string) {
+			bind := map[string]interface{}{
+				"codeSent":  true,
+				"email":     authContext.EmailLoginAddress,
+				"error":     message,
+				"csrfField": csrf.TemplateField(r),
+			}
+
+			err := s.renderTemplate(w, r, "/layouts/auth_layout.html", "/auth_email.html", bind)
+			if err != nil {
+				s.internalServerError(w, r, err)
+			}
+		}
+
+		if len(code) == 0 {
+			renderError("Please enter the code you received by email.")
+			return
+		}
+
+		s.completeEmailLogin(w, r, loginManager, loginLockoutManager, emailSender, authContext, code, false, renderError)
+	}
+}
+
+func (s *Server) handleAuthEmailVerifyGet(loginManager loginManager, loginLockoutManager loginLockoutManager,
+	emailSender emailSender) http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		authContext, err := s.getAuthContext(r)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		renderError := func(message string) {
+			bind := map[string]interface{}{
+				"error":     message,
+				"email":     authContext.EmailLoginAddress,
+				"csrfField": csrf.TemplateField(r),
+			}
+
+			err := s.renderTemplate(w, r, "/layouts/auth_layout.html", "/auth_email.html", bind)
+			if err != nil {
+				s.internalServerError(w, r, err)
+			}
+		}
+
+		token := r.URL.Query().Get("token")
+		if len(token) == 0 {
+			renderError(emailLoginFailedError)
+			return
+		}
+
+		s.completeEmailLogin(w, r, loginManager, loginLockoutManager, emailSender, authContext, token, true, renderError)
+	}
+}
+
+// completeEmailLogin verifies the code (or the token from the link) sent to the email address in
+// the auth context. Codes are single use, expire and can only be attempted a few times during a login,
+// even if new codes are sent. Failed attempts count towards the login lockout, like failed passwords.
+func (s *Server) completeEmailLogin(w http.ResponseWriter, r *http.Request, loginManager loginManager,
+	loginLockoutManager loginLockoutManager, emailSender emailSender, authContext *dtos.AuthContext,
+	code string, isToken bool, renderError func(message string)) {
+
+	emailLoginEnabled, err := s.isEmailLoginEnabled(r, authContext)
+	if err != nil {
+		s.internalServerError(w, r, err)
+		return
+	}
+	if !emailLoginEnabled || len(authContext.EmailLoginAddress) == 0 {
+		renderError(emailLoginFailedError)
+		return
+	}
+
+	user, err := s.database.GetUserByEmail(nil, authContext.EmailLoginAddress)
+	if err != nil {
+		s.internalServerError(w, r, err)
+		return
+	}
+
+	lockoutMessage, err := s.checkLoginLockout(r, loginLockoutManager, user)
+	if err != nil {
+		s.internalServerError(w, r, err)
+		return
+	}
+	if len(lockoutMessage) > 0 {
+		renderError(lockoutMessage)
+		return
+	}
+
+	failLogin := func(auditDetails map[string]interface{}) {
+		authContext.EmailLoginAttempts++
+		err := s.saveAuthContext(w, r, authContext)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		lib.LogAudit(r.Context(), constants.AuditAuthFailedEmail, auditDetails)
+		err = s.registerFailedLogin(r, loginLockoutManager, emailSender, user)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+		renderError(emailLoginFailedError)
+	}
+
+	if user == nil || len(user.EmailLoginCodeHash) == 0 || !user.EmailLoginCodeIssuedAt.Valid {
+		failLogin(map[string]interface{}{
+			"email": authContext.EmailLoginAddress,
+		})
+		return
+	}
+
+	expired := time.Now().UTC().After(user.EmailLoginCodeIssuedAt.Time.Add(emailLoginCodeLifetime))
+	tooManyAttempts := user.EmailLoginCodeAttempts >= emailLoginCodeMaxAttempts ||
+		authContext.EmailLoginAttempts >= emailLoginCodeMaxAttempts
+
+	storedHash := user.EmailLoginCodeHash
+	if isToken {
+		storedHash = user.EmailLoginTokenHash
+	}
+
+	if expired || tooManyAttempts || !lib.VerifyStringHash(storedHash, code) {
+		if expired || tooManyAttempts {
+			clearEmailLoginCode(user)
+		} else {
+			user.EmailLoginCodeAttempts++
+		}
+		err = s.database.UpdateUser(nil, user)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		failLogin(map[string]interface{}{
+			"userId": user.Id,
+		})
+		return
+	}
+
+	// the code can't be used again
+	clearEmailLoginCode(user)
+	err = s.database.UpdateUser(nil, user)
+	if err != nil {
+		s.internalServerError(w, r, err)
+		return
+	}
+
+	// from this point the user is considered authenticated with email
+
+	lib.LogAudit(r.Context(), constants.AuditAuthSuccessEmail, map[string]interface{}{
+		"userId": user.Id,
+	})
+
+	if !user.Enabled {
+		lib.LogAudit(r.Context(), constants.AuditUserDisabled, map[string]interface{}{
+			"userId": user.Id,
+		})
+		renderError("Your account is disabled.")
+		return
+	}
+
+	authContext.EmailLoginAddress = ""
+	err = s.completeFirstFactorAuth(w, r, loginManager, authContext, user, enums.AuthMethodEmail)
+	if err != nil {
+		s.internalServerError(w, r, err)
+		return
+	}
+}
+
+// canIssueEmailLoginCode returns false while the last code is recent, so that a user's inbox can't be flooded.
+func (s *Server) canIssueEmailLoginCode(user *entities.User) bool {
+	if !user.EmailLoginCodeIssuedAt.Valid {
+		return true
+	}
+	return time.Now().UTC().After(user.EmailLoginCodeIssuedAt.Time.Add(emailLoginCodeResendWait))
+}
+
+func (s *Server) sendEmailLoginCode(r *http.Request, emailSender emailSender, user *entities.User) error {
+
+	code := lib.GenerateRandomNumbers(6)
+	token := lib.GenerateSecureRandomString(32)
+
+	codeHash, err := lib.HashString(code)
+	if err != nil {
+		return err
+	}
+	tokenHash, err := lib.HashString(token)
+	if err != nil {
+		return err
+	}
+
+	user.EmailLoginCodeHash = codeHash
+	user.EmailLoginTokenHash = tokenHash
+	// the failed attempts are kept when a code is sent again before the previous one expired,
+	// otherwise resending the code would reset the attempts
+	if !user.EmailLoginCodeIssuedAt.Valid ||
+		time.Now().UTC().After(user.EmailLoginCodeIssuedAt.Time.Add(emailLoginCodeLifetime)) {
+		user.EmailLoginCodeAttempts = 0
+	}
+	user.EmailLoginCodeIssuedAt = sql.NullTime{Time: time.Now().UTC(), Valid: true}
+	err = s.database.UpdateUser(nil, user)
+	if err != nil {
+		return err
+	}
+
+	bind := map[string]interface{}{
+		"name":    user.GetFullName(),
+		"code":    code,
+		"link":    lib.GetBaseUrl() + "/auth/email/verify?token=" + url.QueryEscape(token),
+		"minutes": int(emailLoginCodeLifetime.Minutes()),
+	}
+	buf, err := s.renderTemplateToBuffer(r, "/layouts/email_layout.html", "/emails/email_login_code.html", bind)
+	if err != nil {
+		return err
+	}
+
+	input := &core_senders.SendEmailInput{
+		To:       user.Email,
+		Subject:  "Your sign-in code",
+		HtmlBody: buf.String(),
+	}
+	err = emailSender.SendEmail(r.Context(), input)
+	if err != nil {
+		return err
+	}
+
+	lib.LogAudit(r.Context(), constants.AuditSentEmailLoginCode, map[string]interface{}{
+		"userId": user.Id,
+	})
+	return nil
+}
+
+// isEmailLoginEnabled returns true when the client opted in to email login and SMTP is configured.
+func (s *Server) isEmailLoginEnabled(r *http.Request, authContext *dtos.AuthContext) (bool, error) {
+
+	settings := r.Context().Value(common.ContextKeySettings).(*entities.Settings)
+	if !settings.SMTPEnabled {
+		return false, nil
+	}
+
+	client, err := s.database.GetClientByClientIdentifier(nil, authContext.ClientId)
+	if err != nil {
+		return false, err
+	}
+	if client == nil {
+		return false, errors.WithStack(errors.New(fmt.Sprintf("client %v not found", authContext.ClientId)))
+	}
+	return client.EmailLoginEnabled, nil
+}
+
+func clearEmailLoginCode(user *entities.User) {
+	user.EmailLoginCodeHash = ""
+	user.EmailLoginTokenHash = ""
+	user.EmailLoginCodeIssuedAt = sql.NullTime{Valid: false}
+	user.EmailLoginCodeAttempts = 0
+}
@@ -0,0 +1,411 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/gorilla/csrf"
+	"github.com/leodip/goiabada/internal/common"
+	"github.com/leodip/goiabada/internal/constants"
+	core_federation "github.com/leodip/goiabada/internal/core/federation"
+	"github.com/leodip/goiabada/internal/customerrors"
+	"github.com/leodip/goiabada/internal/dtos"
+	"github.com/leodip/goiabada/internal/entities"
+	"github.com/leodip/goiabada/internal/enums"
+	"github.com/leodip/goiabada/internal/lib"
+	"github.com/pkg/errors"
+)
+
+func getFederatedCallbackURI(identityProviderIdentifier string) string {
+	return fmt.Sprintf("%v/auth/federated/%v/callback", lib.GetBaseUrl(), identityProviderIdentifier)
+}
+
+// getSignInIdentityProviders returns the enabled identity providers that are offered as a "Sign in with" option.
+// LDAP identity providers are not, as they are consulted when the user authenticates with email and password.
+func (s *Server) getSignInIdentityProviders() ([]entities.IdentityProvider, error) {
+
+	identityProviders, err := s.database.GetEnabledIdentityProviders(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	result := []entities.IdentityProvider{}
+	for _, idp := range identityProviders {
+		if idp.Type != enums.IdentityProviderTypeLDAP.String() {
+			result = append(result, idp)
+		}
+	}
+	return result, nil
+}
+
+func (s *Server) renderAuthPwdError(w http.ResponseWriter, r *http.Request, message string) {
+
+	settings := r.Context().Value(common.ContextKeySettings).(*entities.Settings)
+
+	identityProviders, err := s.getSignInIdentityProviders()
+	if err != nil {
+		s.internalServerError(w, r, err)
+		return
+	}
+
+	authContext, err := s.getAuthContext(r)
+	if err != nil {
+		s.internalServerError(w, r, err)
+		return
+	}
+
+	emailLoginEnabled, err := s.isEmailLoginEnabled(r, authContext)
+	if err != nil {
+		s.internalServerError(w, r, err)
+		return
+	}
+
+	bind := map[string]interface{}{
+		"error":             message,
+		"smtpEnabled":       settings.SMTPEnabled,
+		"emailLoginEnabled": emailLoginEnabled,
+		"identityProviders": identityProviders,
+		"csrfField":         csrf.TemplateField(r),
+	}
+
+	err = s.renderTemplate(w, r, "/layouts/auth_layout.html", "/auth_pwd.html", bind)
+	if err != nil {
+		s.internalServerError(w, r, err)
+	}
+}
+
+func (s *Server) handleAuthFederatedGet(oidcClient oidcClient, samlServiceProvider samlServiceProvider) http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		_, err := s.getAuthContext(r)
+		if err != nil {
+			if errors.Is(err, customerrors.ErrNoAuthContext) {
+				slog.Warn("no auth context, redirecting to " + lib.GetBaseUrl() + "/account/profile")
+				http.Redirect(w, r, lib.GetBaseUrl()+"/account/profile", http.StatusFound)
+			} else {
+				s.internalServerError(w, r, err)
+			}
+			return
+		}
+
+		identityProviderIdentifier := chi.URLParam(r, "identityProviderIdentifier")
+		idp, err := s.database.GetIdentityProviderByIdentifier(nil, identityProviderIdentifier)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+		if idp == nil || !idp.Enabled || idp.Type == enums.IdentityProviderTypeLDAP.String() {
+			s.internalServerError(w, r, errors.WithStack(fmt.Errorf("identity provider %v not found or disabled", identityProviderIdentifier)))
+			return
+		}
+
+		federationContext := dtos.FederationContext{
+			IdentityProviderId: idp.Id,
+			State:              lib.GenerateSecureRandomString(32),
+		}
+
+		var authenticationURL string
+		if idp.Type == enums.IdentityProviderTypeSAML.String() {
+			authenticationURL, federationContext.SAMLRequestId, err = samlServiceProvider.BuildAuthnRequestURL(idp, federationContext.State)
+		} else {
+			federationContext.Nonce = lib.GenerateSecureRandomString(32)
+			federationContext.CodeVerifier = lib.GenerateSecureRandomString(96)
+			authenticationURL, err = oidcClient.BuildAuthorizationURL(r.Context(), &core_federation.BuildAuthorizationURLInput{
+				IdentityProvider: idp,
+				RedirectURI:      getFederatedCallbackURI(idp.IdentityProviderIdentifier),
+				State:            federationContext.State,
+				Nonce:            federationContext.Nonce,
+				CodeChallenge:    lib.GeneratePKCECodeChallenge(federationContext.CodeVerifier),
+			})
+		}
+		if err != nil {
+			slog.Error(fmt.Sprintf("unable to start the authentication with identity provider %v: %+v", idp.IdentityProviderIdentifier, err))
+			s.renderAuthPwdError(w, r, fmt.Sprintf("Unable to connect to %v. Please try again later.", idp.Name))
+			return
+		}
+
+		sess, err := s.sessionStore.Get(r, common.SessionName)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		jsonData, err := json.Marshal(federationContext)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+		sess.Values[common.SessionKeyFederationContext] = string(jsonData)
+		err = sess.Save(r, w)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		http.Redirect(w, r, authenticationURL, http.StatusFound)
+	}
+}
+
+// takeFederationContext returns the federation context stored in the session, and the identity provider it refers to.
+// The federation context is single use, so it's removed from the session.
+func (s *Server) takeFederationContext(w http.ResponseWriter, r *http.Request,
+	idpType enums.IdentityProviderType) (*dtos.FederationContext, *entities.IdentityProvider, error) {
+
+	sess, err := s.sessionStore.Get(r, common.SessionName)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	jsonData, ok := sess.Values[common.SessionKeyFederationContext].(string)
+	if !ok {
+		return nil, nil, errors.WithStack(errors.New("unable to find the federation context in the session"))
+	}
+
+	var federationContext dtos.FederationContext
+	err = json.Unmarshal([]byte(jsonData), &federationContext)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	delete(sess.Values, common.SessionKeyFederationContext)
+	err = sess.Save(r, w)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	identityProviderIdentifier := chi.URLParam(r, "identityProviderIdentifier")
+	idp, err := s.database.GetIdentityProviderById(nil, federationContext.IdentityProviderId)
+	if err != nil {
+		return nil, nil, err
+	}
+	if idp == nil || !idp.Enabled || idp.IdentityProviderIdentifier != identityProviderIdentifier ||
+		idp.Type != idpType.String() {
+		return nil, nil, errors.WithStack(fmt.Errorf("identity provider %v not found or disabled", identityProviderIdentifier))
+	}
+
+	return &federationContext, idp, nil
+}
+
+func (s *Server) handleAuthFederatedCallbackGet(oidcClient oidcClient, federatedUserResolver federatedUserResolver,
+	loginManager loginManager) http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		authContext, err := s.getAuthContext(r)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		federationContext, idp, err := s.takeFederationContext(w, r, enums.IdentityProviderTypeOIDC)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		if r.URL.Query().Get("state") != federationContext.State {
+			s.internalServerError(w, r, errors.WithStack(errors.New("the state returned by the identity provider does not match")))
+			return
+		}
+
+		authFailedMessage := fmt.Sprintf("Authentication with %v failed.", idp.Name)
+
+		if upstreamError := r.URL.Query().Get("error"); len(upstreamError) > 0 {
+			lib.LogAudit(r.Context(), constants.AuditAuthFailedFederated, map[string]interface{}{
+				"identityProvider": idp.IdentityProviderIdentifier,
+				"error":            upstreamError,
+				"errorDescription": r.URL.Query().Get("error_description"),
+			})
+			s.renderAuthPwdError(w, r, authFailedMessage)
+			return
+		}
+
+		claims, err := oidcClient.ExchangeCode(r.Context(), &core_federation.ExchangeCodeInput{
+			IdentityProvider: idp,
+			Code:             r.URL.Query().Get("code"),
+			RedirectURI:      getFederatedCallbackURI(idp.IdentityProviderIdentifier),
+			CodeVerifier:     federationContext.CodeVerifier,
+			Nonce:            federationContext.Nonce,
+		})
+		if err != nil {
+			slog.Error(fmt.Sprintf("unable to complete the authentication with identity provider %v: %+v", idp.IdentityProviderIdentifier, err))
+			lib.LogAudit(r.Context(), constants.AuditAuthFailedFederated, map[string]interface{}{
+				"identityProvider": idp.IdentityProviderIdentifier,
+				"error":            err.Error(),
+			})
+			s.renderAuthPwdError(w, r, authFailedMessage)
+			return
+		}
+
+		identity := core_federation.NewFederatedIdentityFromClaims(idp, claims)
+		s.completeFederatedAuth(w, r, federatedUserResolver, loginManager, authContext, idp, identity, enums.AuthMethodFederated)
+	}
+}
+
+func (s *Server) handleAuthFederatedSAMLMetadataGet(samlServiceProvider samlServiceProvider) http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		identityProviderIdentifier := chi.URLParam(r, "identityProviderIdentifier")
+		idp, err := s.database.GetIdentityProviderByIdentifier(nil, identityProviderIdentifier)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+		if idp == nil || idp.Type != enums.IdentityProviderTypeSAML.String() {
+			http.NotFound(w, r)
+			return
+		}
+
+		metadataXML, err := samlServiceProvider.GetServiceProviderMetadata(idp)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/samlmetadata+xml")
+		_, err = w.Write(metadataXML)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+	}
+}
+
+// handleAuthFederatedSAMLAcsPost receives the SAML response from the identity provider.
+// This is a cross-site POST, so the session cookie (SameSite=Lax) is not available here.
+// The response is posted again, from our own origin, to the callback endpoint, where the session is available.
+func (s *Server) handleAuthFederatedSAMLAcsPost() http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		identityProviderIdentifier := chi.URLParam(r, "identityProviderIdentifier")
+
+		bind := map[string]interface{}{
+			"action":       fmt.Sprintf("%v/auth/federated/%v/callback", lib.GetBaseUrl(), identityProviderIdentifier),
+			"samlResponse": r.FormValue("SAMLResponse"),
+			"relayState":   r.FormValue("RelayState"),
+		}
+
+		err := s.renderTemplate(w, r, "/layouts/auth_layout.html", "/auth_federated_saml_post.html", bind)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+	}
+}
+
+func (s *Server) handleAuthFederatedCallbackPost(samlServiceProvider samlServiceProvider, federatedUserResolver federatedUserResolver,
+	loginManager loginManager) http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		authContext, err := s.getAuthContext(r)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		federationContext, idp, err := s.takeFederationContext(w, r, enums.IdentityProviderTypeSAML)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		if r.FormValue("RelayState") != federationContext.State {
+			s.internalServerError(w, r, errors.WithStack(errors.New("the relay state returned by the identity provider does not match")))
+			return
+		}
+
+		identity, err := samlServiceProvider.ParseResponse(r, idp, federationContext.SAMLRequestId)
+		if err != nil {
+			slog.Error(fmt.Sprintf("unable to complete the authentication with identity provider %v: %+v", idp.IdentityProviderIdentifier, err))
+			lib.LogAudit(r.Context(), constants.AuditAuthFailedFederated, map[string]interface{}{
+				"identityProvider": idp.IdentityProviderIdentifier,
+				"error":            err.Error(),
+			})
+			s.renderAuthPwdError(w, r, fmt.Sprintf("Authentication with %v failed.", idp.Name))
+			return
+		}
+
+		s.completeFederatedAuth(w, r, federatedUserResolver, loginManager, authContext, idp, identity, enums.AuthMethodFederated)
+	}
+}
+
+// completeFederatedAuth links or provisions the local user for the identity asserted by the
+// identity provider, and continues with the regular authentication flow.
+func (s *Server) completeFederatedAuth(w http.ResponseWriter, r *http.Request, federatedUserResolver federatedUserResolver,
+	loginManager loginManager, authContext *dtos.AuthContext, idp *entities.IdentityProvider, identity *core_federation.FederatedIdentity,
+	authMethod enums.AuthMethod) {
+
+	user := s.resolveFederatedUser(w, r, federatedUserResolver, idp, identity)
+	if user == nil {
+		return
+	}
+
+	err := s.completeFirstFactorAuth(w, r, loginManager, authContext, user, authMethod)
+	if err != nil {
+		s.internalServerError(w, r, err)
+		return
+	}
+}
+
+// resolveFederatedUser links or provisions the local user for the identity asserted by the identity
+// provider. It returns nil, after writing the response, when the user can't sign in.
+func (s *Server) resolveFederatedUser(w http.ResponseWriter, r *http.Request, federatedUserResolver federatedUserResolver,
+	idp *entities.IdentityProvider, identity *core_federation.FederatedIdentity) *entities.User {
+
+	result, err := federatedUserResolver.ResolveUser(r.Context(), idp, identity)
+	if err != nil {
+		if valError, ok := err.(*customerrors.ValidationError); ok {
+			lib.LogAudit(r.Context(), constants.AuditAuthFailedFederated, map[string]interface{}{
+				"identityProvider": idp.IdentityProviderIdentifier,
+				"subject":          identity.Subject,
+				"error":            valError.Description,
+			})
+			s.renderAuthPwdError(w, r, valError.Description)
+		} else {
+			s.internalServerError(w, r, err)
+		}
+		return nil
+	}
+
+	user := result.User
+
+	if result.Provisioned {
+		lib.LogAudit(r.Context(), constants.AuditCreatedUser, map[string]interface{}{
+			"userId":           user.Id,
+			"email":            user.Email,
+			"identityProvider": idp.IdentityProviderIdentifier,
+		})
+	}
+
+	if result.Linked || result.Provisioned {
+		lib.LogAudit(r.Context(), constants.AuditLinkedFederatedIdentity, map[string]interface{}{
+			"userId":           user.Id,
+			"identityProvider": idp.IdentityProviderIdentifier,
+			"subject":          result.Subject,
+		})
+	}
+
+	// from this point the user is considered authenticated by the upstream identity provider
+
+	lib.LogAudit(r.Context(), constants.AuditAuthSuccessFederated, map[string]interface{}{
+		"userId":           user.Id,
+		"identityProvider": idp.IdentityProviderIdentifier,
+	})
+
+	if !user.Enabled {
+		lib.LogAudit(r.Context(), constants.AuditUserDisabled, map[string]interface{}{
+			"userId": user.Id,
+		})
+		s.renderAuthPwdError(w, r, "Your account is disabled.")
+		return nil
+	}
+
+	return user
+}
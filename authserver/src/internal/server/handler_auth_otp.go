@@ -3,7 +3,6 @@ package server
 import (
 	"fmt"
 	"net/http"
-	"time"
 
 	"github.com/pkg/errors"
 
@@ -37,11 +36,31 @@ func (s *Server) handleAuthOtpGet(otpSecretGenerator otpSecretGenerator) http.Ha
 			return
 		}
 
+		err = s.database.UserLoadPasskeys(nil, user)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		targetAcrLevel, err := s.getTargetAcrLevel(authContext)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		// the passkey ACR level can't be satisfied with an OTP
+		if targetAcrLevel == enums.AcrLevel4 {
+			http.Redirect(w, r, lib.GetBaseUrl()+"/auth/passkey", http.StatusFound)
+			return
+		}
+
+		settings := r.Context().Value(common.ContextKeySettings).(*entities.Settings)
+		smsOTPAvailable := isSMSOTPAvailable(settings, user, targetAcrLevel)
+
 		if !user.OTPEnabled {
 			// must enroll first
 
 			// generate secret
-			settings := r.Context().Value(common.ContextKeySettings).(*entities.Settings)
 			base64Image, secretKey, err := otpSecretGenerator.GenerateOTPSecret(user, settings)
 			if err != nil {
 				s.internalServerError(w, r, err)
@@ -53,6 +72,8 @@ func (s *Server) handleAuthOtpGet(otpSecretGenerator otpSecretGenerator) http.Ha
 				"csrfField":   csrf.TemplateField(r),
 				"base64Image": base64Image,
 				"secretKey":   secretKey,
+				"hasPasskeys": len(user.Passkeys) > 0,
+				"smsOTP":      smsOTPAvailable,
 			}
 
 			// save image and secret in the session state
@@ -80,8 +101,11 @@ func (s *Server) handleAuthOtpGet(otpSecretGenerator otpSecretGenerator) http.Ha
 			}
 
 			bind := map[string]interface{}{
-				"error":     nil,
-				"csrfField": csrf.TemplateField(r),
+				"error":                       nil,
+				"csrfField":                   csrf.TemplateField(r),
+				"hasPasskeys":                 len(user.Passkeys) > 0,
+				"smsOTP":                      smsOTPAvailable,
+				"trustedDeviceLifetimeInDays": settings.TrustedDeviceLifetimeInDays,
 			}
 
 			err = s.renderTemplate(w, r, "/layouts/auth_layout.html", "/auth_otp.html", bind)
@@ -93,7 +117,8 @@ func (s *Server) handleAuthOtpGet(otpSecretGenerator otpSecretGenerator) http.Ha
 	}
 }
 
-func (s *Server) handleAuthOtpPost() http.HandlerFunc {
+func (s *Server) handleAuthOtpPost(otpRecoveryCodeManager otpRecoveryCodeManager, loginLockoutManager loginLockoutManager,
+	emailSender emailSender) http.HandlerFunc {
 
 	return func(w http.ResponseWriter, r *http.Request) {
 
@@ -121,11 +146,39 @@ func (s *Server) handleAuthOtpPost() http.HandlerFunc {
 			s.internalServerError(w, r, err)
 			return
 		}
+		if user == nil {
+			s.internalServerError(w, r, errors.WithStack(errors.New(fmt.Sprintf("user %v not found", authContext.UserId))))
+			return
+		}
+
+		err = s.database.UserLoadPasskeys(nil, user)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		targetAcrLevel, err := s.getTargetAcrLevel(authContext)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		// the passkey ACR level can't be satisfied with an OTP
+		if targetAcrLevel == enums.AcrLevel4 {
+			http.Redirect(w, r, lib.GetBaseUrl()+"/auth/passkey", http.StatusFound)
+			return
+		}
+
+		settings := r.Context().Value(common.ContextKeySettings).(*entities.Settings)
 
 		renderError := func(message string) {
 			bind := map[string]interface{}{
-				"error":     message,
-				"csrfField": csrf.TemplateField(r),
+				"error":                       message,
+				"csrfField":                   csrf.TemplateField(r),
+				"hasPasskeys":                 len(user.Passkeys) > 0,
+				"smsOTP":                      isSMSOTPAvailable(settings, user, targetAcrLevel),
+				"trustedDeviceLifetimeInDays": settings.TrustedDeviceLifetimeInDays,
+				"trustDevice":                 r.FormValue("trustDevice") == "on",
 			}
 
 			template := "/auth_otp.html"
@@ -147,25 +200,60 @@ func (s *Server) handleAuthOtpPost() http.HandlerFunc {
 			return
 		}
 
+		lockoutMessage, err := s.checkLoginLockout(r, loginLockoutManager, user)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+		if len(lockoutMessage) > 0 {
+			renderError(lockoutMessage)
+			return
+		}
+
+		var recoveryCodes []string
 		incorrectOtpError := "Incorrect OTP Code. OTP codes are time-sensitive and change every 30 seconds. Make sure you're using the most recent code generated by your authenticator app."
 
 		if user.OTPEnabled {
 			// already has OTP enrolled
 			otpValid := totp.Validate(otpCode, user.OTPSecret)
 			if !otpValid {
-				lib.LogAudit(constants.AuditAuthFailedOtp, map[string]interface{}{
-					"userId": user.Id,
+				// a recovery code can be used instead, when the authenticator app is not available
+				if !otpRecoveryCodeManager.UseRecoveryCode(user, otpCode) {
+					lib.LogAudit(r.Context(), constants.AuditAuthFailedOtp, map[string]interface{}{
+						"userId": user.Id,
+					})
+					err = s.registerFailedLogin(r, loginLockoutManager, emailSender, user)
+					if err != nil {
+						s.internalServerError(w, r, err)
+						return
+					}
+					renderError(incorrectOtpError)
+					return
+				}
+
+				err = s.database.UpdateUser(nil, user)
+				if err != nil {
+					s.internalServerError(w, r, err)
+					return
+				}
+
+				lib.LogAudit(r.Context(), constants.AuditAuthSuccessOtpRecoveryCode, map[string]interface{}{
+					"userId":                 user.Id,
+					"recoveryCodesRemaining": user.GetOTPRecoveryCodesRemaining(),
 				})
-				renderError(incorrectOtpError)
-				return
 			}
 		} else {
 			// is enrolling to TOTP now
 			otpValid := totp.Validate(otpCode, secretKey)
 			if !otpValid {
-				lib.LogAudit(constants.AuditAuthFailedOtp, map[string]interface{}{
+				lib.LogAudit(r.Context(), constants.AuditAuthFailedOtp, map[string]interface{}{
 					"userId": user.Id,
 				})
+				err = s.registerFailedLogin(r, loginLockoutManager, emailSender, user)
+				if err != nil {
+					s.internalServerError(w, r, err)
+					return
+				}
 				renderError(incorrectOtpError)
 				return
 			}
@@ -173,61 +261,66 @@ func (s *Server) handleAuthOtpPost() http.HandlerFunc {
 			// save TOTP secret
 			user.OTPSecret = secretKey
 			user.OTPEnabled = true
+			recoveryCodes, err = otpRecoveryCodeManager.GenerateRecoveryCodes(user)
+			if err != nil {
+				s.internalServerError(w, r, err)
+				return
+			}
 			err = s.database.UpdateUser(nil, user)
 			if err != nil {
 				s.internalServerError(w, r, err)
 				return
 			}
+			lib.LogAudit(r.Context(), constants.AuditEnrolledOTP, map[string]interface{}{
+				"userId": user.Id,
+			})
+			s.sendSecurityNotification(r, user, constants.AuditEnrolledOTP)
+			lib.LogAudit(r.Context(), constants.AuditGeneratedOTPRecoveryCodes, map[string]interface{}{
+				"userId": user.Id,
+			})
 		}
 
-		lib.LogAudit(constants.AuditAuthSuccessOtp, map[string]interface{}{
+		lib.LogAudit(r.Context(), constants.AuditAuthSuccessOtp, map[string]interface{}{
 			"userId": user.Id,
 		})
 
 		if !user.Enabled {
-			lib.LogAudit(constants.AuditUserDisabled, map[string]interface{}{
+			lib.LogAudit(r.Context(), constants.AuditUserDisabled, map[string]interface{}{
 				"userId": user.Id,
 			})
 			renderError("Your account is disabled.")
 			return
 		}
 
-		client, err := s.database.GetClientByClientIdentifier(nil, authContext.ClientId)
-		if err != nil {
-			s.internalServerError(w, r, err)
-			return
-		}
-		if client == nil {
-			s.internalServerError(w, r, errors.WithStack(errors.New(fmt.Sprintf("client %v not found", authContext.ClientId))))
-			return
+		if r.FormValue("trustDevice") == "on" {
+			err = s.trustDevice(w, r, user)
+			if err != nil {
+				s.internalServerError(w, r, err)
+				return
+			}
 		}
 
-		requestedAcrValues := authContext.ParseRequestedAcrValues()
-		targetAcrLevel := client.DefaultAcrLevel
-
-		if len(requestedAcrValues) > 0 {
-			targetAcrLevel = requestedAcrValues[0]
+		if len(recoveryCodes) == 0 {
+			err = s.completeSecondFactorAuth(w, r, authContext, user, targetAcrLevel, enums.AuthMethodOTP)
+			if err != nil {
+				s.internalServerError(w, r, err)
+			}
+			return
 		}
 
-		// start new session
-		_, err = s.startNewUserSession(w, r, user.Id, client.Id,
-			enums.AuthMethodPassword.String()+" "+enums.AuthMethodOTP.String(), targetAcrLevel.String())
+		// the recovery codes of a new enrollment are only displayed once, before continuing
+		continueUrl, err := s.finishSecondFactorAuth(w, r, authContext, user, targetAcrLevel, enums.AuthMethodOTP)
 		if err != nil {
 			s.internalServerError(w, r, err)
 			return
 		}
-
-		// redirect to consent
-		authContext.AcrLevel = targetAcrLevel.String()
-		authContext.AuthMethods = enums.AuthMethodPassword.String() + " " + enums.AuthMethodOTP.String()
-		authContext.AuthTime = time.Now().UTC()
-		authContext.AuthCompleted = true
-		err = s.saveAuthContext(w, r, authContext)
+		bind := map[string]interface{}{
+			"recoveryCodes": recoveryCodes,
+			"continueUrl":   continueUrl,
+		}
+		err = s.renderTemplate(w, r, "/layouts/auth_layout.html", "/auth_otp_recovery_codes.html", bind)
 		if err != nil {
 			s.internalServerError(w, r, err)
-			return
 		}
-
-		http.Redirect(w, r, lib.GetBaseUrl()+"/auth/consent", http.StatusFound)
 	}
 }
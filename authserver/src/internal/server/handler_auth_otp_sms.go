@@ -0,0 +1,287 @@
+package server
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/gorilla/csrf"
+	"github.com/leodip/goiabada/internal/common"
+	"github.com/leodip/goiabada/internal/constants"
+	core_senders "github.com/leodip/goiabada/internal/core/senders"
+	"github.com/leodip/goiabada/internal/dtos"
+	"github.com/leodip/goiabada/internal/entities"
+	"github.com/leodip/goiabada/internal/enums"
+	"github.com/leodip/goiabada/internal/lib"
+	"github.com/pkg/errors"
+)
+
+const smsOTPCodeLifetime = 5 * time.Minute
+const smsOTPCodeResendWait = 90 * time.Second
+const smsOTPCodeMaxAttempts = 5
+const smsOTPFailedError = "The code is invalid or has expired. Please request a new code if needed."
+
+func (s *Server) handleAuthOtpSMSGet() http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		authContext, err := s.getAuthContext(r)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		user, ok, err := s.getSMSOTPUser(w, r, authContext)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+		if !ok {
+			return
+		}
+
+		s.renderAuthOtpSMS(w, r, user, "", "")
+	}
+}
+
+func (s *Server) handleAuthOtpSMSSendPost(smsSender smsSender) http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		authContext, err := s.getAuthContext(r)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		user, ok, err := s.getSMSOTPUser(w, r, authContext)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+		if !ok {
+			return
+		}
+
+		if user.SMSOTPCodeIssuedAt.Valid {
+			remainingTime := int(user.SMSOTPCodeIssuedAt.Time.Add(smsOTPCodeResendWait).Sub(time.Now().UTC()).Seconds())
+			if remainingTime > 0 {
+				s.renderAuthOtpSMS(w, r, user, "", fmt.Sprintf("Please wait %v seconds before requesting a new code.", remainingTime))
+				return
+			}
+		}
+
+		code := lib.GenerateRandomNumbers(6)
+		codeHash, err := lib.HashString(code)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		user.SMSOTPCodeHash = codeHash
+		user.SMSOTPCodeIssuedAt = sql.NullTime{Time: time.Now().UTC(), Valid: true}
+		user.SMSOTPCodeAttempts = 0
+		err = s.database.UpdateUser(nil, user)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		input := &core_senders.SendSMSInput{
+			To:   user.PhoneNumber,
+			Body: fmt.Sprintf("Your sign-in code is %v", code),
+		}
+		err = smsSender.SendSMS(r.Context(), input)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		lib.LogAudit(r.Context(), constants.AuditSentSMSOtp, map[string]interface{}{
+			"userId": user.Id,
+		})
+
+		s.renderAuthOtpSMS(w, r, user, "A code has been sent to your phone.", "")
+	}
+}
+
+func (s *Server) handleAuthOtpSMSPost(loginLockoutManager loginLockoutManager, emailSender emailSender) http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		authContext, err := s.getAuthContext(r)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		user, ok, err := s.getSMSOTPUser(w, r, authContext)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+		if !ok {
+			return
+		}
+
+		otpCode := strings.TrimSpace(r.FormValue("otp"))
+		if len(otpCode) == 0 {
+			s.renderAuthOtpSMS(w, r, user, "", "OTP code is required.")
+			return
+		}
+
+		lockoutMessage, err := s.checkLoginLockout(r, loginLockoutManager, user)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+		if len(lockoutMessage) > 0 {
+			s.renderAuthOtpSMS(w, r, user, "", lockoutMessage)
+			return
+		}
+
+		if len(user.SMSOTPCodeHash) == 0 || !user.SMSOTPCodeIssuedAt.Valid {
+			lib.LogAudit(r.Context(), constants.AuditAuthFailedSMSOtp, map[string]interface{}{
+				"userId": user.Id,
+			})
+			err = s.registerFailedLogin(r, loginLockoutManager, emailSender, user)
+			if err != nil {
+				s.internalServerError(w, r, err)
+				return
+			}
+			s.renderAuthOtpSMS(w, r, user, "", smsOTPFailedError)
+			return
+		}
+
+		expired := time.Now().UTC().After(user.SMSOTPCodeIssuedAt.Time.Add(smsOTPCodeLifetime))
+		tooManyAttempts := user.SMSOTPCodeAttempts >= smsOTPCodeMaxAttempts
+
+		if expired || tooManyAttempts || !lib.VerifyStringHash(user.SMSOTPCodeHash, otpCode) {
+			if expired || tooManyAttempts {
+				// the issue time is kept, so the resend cooldown still applies
+				user.SMSOTPCodeHash = ""
+			} else {
+				user.SMSOTPCodeAttempts++
+			}
+			err = s.database.UpdateUser(nil, user)
+			if err != nil {
+				s.internalServerError(w, r, err)
+				return
+			}
+
+			lib.LogAudit(r.Context(), constants.AuditAuthFailedSMSOtp, map[string]interface{}{
+				"userId": user.Id,
+			})
+			err = s.registerFailedLogin(r, loginLockoutManager, emailSender, user)
+			if err != nil {
+				s.internalServerError(w, r, err)
+				return
+			}
+			s.renderAuthOtpSMS(w, r, user, "", smsOTPFailedError)
+			return
+		}
+
+		// the code can't be used again
+		user.SMSOTPCodeHash = ""
+		user.SMSOTPCodeIssuedAt = sql.NullTime{Valid: false}
+		user.SMSOTPCodeAttempts = 0
+		err = s.database.UpdateUser(nil, user)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		lib.LogAudit(r.Context(), constants.AuditAuthSuccessSMSOtp, map[string]interface{}{
+			"userId": user.Id,
+		})
+
+		if !user.Enabled {
+			lib.LogAudit(r.Context(), constants.AuditUserDisabled, map[string]interface{}{
+				"userId": user.Id,
+			})
+			s.renderAuthOtpSMS(w, r, user, "", "Your account is disabled.")
+			return
+		}
+
+		targetAcrLevel, err := s.getTargetAcrLevel(authContext)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		err = s.completeSecondFactorAuth(w, r, authContext, user, targetAcrLevel, enums.AuthMethodSMS)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+	}
+}
+
+// getSMSOTPUser returns the user of the auth context. When the second factor can't be sent by SMS
+// the request is redirected to the OTP page, and ok is false.
+func (s *Server) getSMSOTPUser(w http.ResponseWriter, r *http.Request,
+	authContext *dtos.AuthContext) (user *entities.User, ok bool, err error) {
+
+	user, err = s.database.GetUserById(nil, authContext.UserId)
+	if err != nil {
+		return nil, false, err
+	}
+	if user == nil {
+		return nil, false, errors.WithStack(errors.New(fmt.Sprintf("user %v not found", authContext.UserId)))
+	}
+
+	targetAcrLevel, err := s.getTargetAcrLevel(authContext)
+	if err != nil {
+		return nil, false, err
+	}
+
+	settings := r.Context().Value(common.ContextKeySettings).(*entities.Settings)
+	if !isSMSOTPAvailable(settings, user, targetAcrLevel) {
+		http.Redirect(w, r, lib.GetBaseUrl()+"/auth/otp", http.StatusFound)
+		return nil, false, nil
+	}
+	return user, true, nil
+}
+
+func (s *Server) renderAuthOtpSMS(w http.ResponseWriter, r *http.Request, user *entities.User, message string, errorMessage string) {
+
+	// only the last digits of the phone number are displayed
+	digits := strings.Map(func(r rune) rune {
+		if unicode.IsDigit(r) {
+			return r
+		}
+		return -1
+	}, user.PhoneNumber)
+	if len(digits) > 4 {
+		digits = digits[len(digits)-4:]
+	}
+
+	codeSent := len(user.SMSOTPCodeHash) > 0 && user.SMSOTPCodeIssuedAt.Valid &&
+		time.Now().UTC().Before(user.SMSOTPCodeIssuedAt.Time.Add(smsOTPCodeLifetime))
+
+	bind := map[string]interface{}{
+		"phoneNumberEnding": digits,
+		"codeSent":          codeSent,
+		"message":           message,
+		"error":             errorMessage,
+		"csrfField":         csrf.TemplateField(r),
+	}
+
+	err := s.renderTemplate(w, r, "/layouts/auth_layout.html", "/auth_otp_sms.html", bind)
+	if err != nil {
+		s.internalServerError(w, r, err)
+	}
+}
+
+// isSMSOTPAvailable returns true when the user can receive the second factor by SMS, instead of using
+// an authenticator app. For the mandatory OTP level it depends on the SMS settings.
+func isSMSOTPAvailable(settings *entities.Settings, user *entities.User, targetAcrLevel enums.AcrLevel) bool {
+	if len(settings.SMSProvider) == 0 || !user.PhoneNumberVerified || len(user.PhoneNumber) == 0 {
+		return false
+	}
+	return targetAcrLevel == enums.AcrLevel2 ||
+		(targetAcrLevel == enums.AcrLevel3 && settings.SMSOTPAllowedForMandatory2FA)
+}
@@ -0,0 +1,325 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/csrf"
+	"github.com/leodip/goiabada/internal/common"
+	"github.com/leodip/goiabada/internal/constants"
+	core_webauthn "github.com/leodip/goiabada/internal/core/webauthn"
+	"github.com/leodip/goiabada/internal/customerrors"
+	"github.com/leodip/goiabada/internal/dtos"
+	"github.com/leodip/goiabada/internal/entities"
+	"github.com/leodip/goiabada/internal/enums"
+	"github.com/leodip/goiabada/internal/lib"
+	"github.com/pkg/errors"
+)
+
+const passkeyAuthFailedError = "Authentication with the passkey failed. Please try again."
+
+func (s *Server) handleAuthPasskeyGet() http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		authContext, err := s.getAuthContext(r)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		user, err := s.getAuthContextUserWithPasskeys(authContext)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		targetAcrLevel, err := s.getTargetAcrLevel(authContext)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		// the user can use an OTP instead, unless the client requires a passkey
+		otpAllowed := targetAcrLevel != enums.AcrLevel4 && (user.OTPEnabled || targetAcrLevel == enums.AcrLevel3)
+
+		bind := map[string]interface{}{
+			"csrfField":   csrf.TemplateField(r),
+			"hasPasskeys": len(user.Passkeys) > 0,
+			"otpAllowed":  otpAllowed,
+		}
+
+		err = s.renderTemplate(w, r, "/layouts/auth_layout.html", "/auth_passkey.html", bind)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+	}
+}
+
+func (s *Server) handleAuthPasskeyBeginPost(passkeyManager passkeyManager) http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		authContext, err := s.getAuthContext(r)
+		if err != nil {
+			s.jsonError(w, r, err)
+			return
+		}
+
+		user, err := s.getAuthContextUserWithPasskeys(authContext)
+		if err != nil {
+			s.jsonError(w, r, err)
+			return
+		}
+
+		if len(user.Passkeys) == 0 {
+			s.jsonError(w, r, customerrors.NewValidationError("", "You don't have any passkeys registered."))
+			return
+		}
+
+		options, ceremony, err := passkeyManager.BeginLogin(r.Context(), user)
+		if err != nil {
+			s.jsonError(w, r, err)
+			return
+		}
+
+		err = s.savePasskeyCeremony(w, r, ceremony)
+		if err != nil {
+			s.jsonError(w, r, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(options)
+	}
+}
+
+func (s *Server) handleAuthPasskeyFinishPost(passkeyManager passkeyManager) http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		authContext, err := s.getAuthContext(r)
+		if err != nil {
+			s.jsonError(w, r, err)
+			return
+		}
+
+		user, err := s.getAuthContextUserWithPasskeys(authContext)
+		if err != nil {
+			s.jsonError(w, r, err)
+			return
+		}
+
+		ceremony, err := s.popPasskeyCeremony(w, r)
+		if err != nil {
+			s.jsonError(w, r, err)
+			return
+		}
+
+		err = passkeyManager.FinishLogin(r.Context(), user, ceremony, r)
+		if err != nil {
+			if errors.Is(err, core_webauthn.ErrPasskeyVerificationFailed) {
+				lib.LogAudit(r.Context(), constants.AuditAuthFailedPasskey, map[string]interface{}{
+					"userId": user.Id,
+					"error":  err.Error(),
+				})
+				s.jsonError(w, r, customerrors.NewValidationError("", passkeyAuthFailedError))
+				return
+			}
+			s.jsonError(w, r, err)
+			return
+		}
+
+		lib.LogAudit(r.Context(), constants.AuditAuthSuccessPasskey, map[string]interface{}{
+			"userId": user.Id,
+		})
+
+		// the first factor (pwd, fed) was recorded in the auth context before the passkey step
+		firstFactor := enums.AuthMethodPassword.String()
+		if fields := strings.Fields(authContext.AuthMethods); len(fields) > 0 {
+			firstFactor = fields[0]
+		}
+		authMethods := firstFactor + " " + enums.AuthMethodPasskey.String()
+
+		s.completePasskeyAuth(w, r, authContext, user, authMethods)
+	}
+}
+
+func (s *Server) handleAuthPasskeyLoginBeginPost(passkeyManager passkeyManager) http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		_, err := s.getAuthContext(r)
+		if err != nil {
+			s.jsonError(w, r, err)
+			return
+		}
+
+		options, ceremony, err := passkeyManager.BeginDiscoverableLogin(r.Context())
+		if err != nil {
+			s.jsonError(w, r, err)
+			return
+		}
+
+		err = s.savePasskeyCeremony(w, r, ceremony)
+		if err != nil {
+			s.jsonError(w, r, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(options)
+	}
+}
+
+func (s *Server) handleAuthPasskeyLoginFinishPost(passkeyManager passkeyManager) http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		authContext, err := s.getAuthContext(r)
+		if err != nil {
+			s.jsonError(w, r, err)
+			return
+		}
+
+		ceremony, err := s.popPasskeyCeremony(w, r)
+		if err != nil {
+			s.jsonError(w, r, err)
+			return
+		}
+
+		user, err := passkeyManager.FinishDiscoverableLogin(r.Context(), ceremony, r)
+		if err != nil {
+			if errors.Is(err, core_webauthn.ErrPasskeyVerificationFailed) {
+				lib.LogAudit(r.Context(), constants.AuditAuthFailedPasskey, map[string]interface{}{
+					"error": err.Error(),
+				})
+				s.jsonError(w, r, customerrors.NewValidationError("", passkeyAuthFailedError))
+				return
+			}
+			s.jsonError(w, r, err)
+			return
+		}
+
+		lib.LogAudit(r.Context(), constants.AuditAuthSuccessPasskey, map[string]interface{}{
+			"userId": user.Id,
+		})
+
+		// passwordless: the passkey is the only authentication method
+		s.completePasskeyAuth(w, r, authContext, user, enums.AuthMethodPasskey.String())
+	}
+}
+
+// completePasskeyAuth starts a new user session once the user has authenticated with a passkey,
+// and responds with the url of the consent page. A passkey satisfies any ACR level.
+func (s *Server) completePasskeyAuth(w http.ResponseWriter, r *http.Request, authContext *dtos.AuthContext,
+	user *entities.User, authMethods string) {
+
+	if !user.Enabled {
+		lib.LogAudit(r.Context(), constants.AuditUserDisabled, map[string]interface{}{
+			"userId": user.Id,
+		})
+		s.jsonError(w, r, customerrors.NewValidationError("", "Your account is disabled."))
+		return
+	}
+
+	client, err := s.database.GetClientByClientIdentifier(nil, authContext.ClientId)
+	if err != nil {
+		s.jsonError(w, r, err)
+		return
+	}
+	if client == nil {
+		s.jsonError(w, r, errors.WithStack(errors.New(fmt.Sprintf("client %v not found", authContext.ClientId))))
+		return
+	}
+
+	mustChangePassword, err := s.mustChangePassword(w, r, authContext, user, authMethods, enums.AcrLevel4)
+	if err != nil {
+		s.jsonError(w, r, err)
+		return
+	}
+	if mustChangePassword {
+		result := map[string]string{
+			"redirectUrl": lib.GetBaseUrl() + "/auth/change-password",
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(result)
+		return
+	}
+
+	// start new session
+	_, err = s.startNewUserSession(w, r, user.Id, client.Id, authMethods, enums.AcrLevel4.String())
+	if err != nil {
+		s.jsonError(w, r, err)
+		return
+	}
+
+	authContext.UserId = user.Id
+	authContext.AcrLevel = enums.AcrLevel4.String()
+	authContext.AuthMethods = authMethods
+	authContext.AuthTime = time.Now().UTC()
+	authContext.AuthCompleted = true
+	err = s.saveAuthContext(w, r, authContext)
+	if err != nil {
+		s.jsonError(w, r, err)
+		return
+	}
+
+	result := map[string]string{
+		"redirectUrl": lib.GetBaseUrl() + "/auth/consent",
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+func (s *Server) getAuthContextUserWithPasskeys(authContext *dtos.AuthContext) (*entities.User, error) {
+	user, err := s.database.GetUserById(nil, authContext.UserId)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, errors.WithStack(errors.New(fmt.Sprintf("user %v not found", authContext.UserId)))
+	}
+
+	err = s.database.UserLoadPasskeys(nil, user)
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// savePasskeyCeremony keeps the state of a WebAuthn ceremony in the session until it's finished.
+func (s *Server) savePasskeyCeremony(w http.ResponseWriter, r *http.Request, ceremony string) error {
+	sess, err := s.sessionStore.Get(r, common.SessionName)
+	if err != nil {
+		return err
+	}
+
+	sess.Values[common.SessionKeyPasskeyCeremony] = ceremony
+	return sess.Save(r, w)
+}
+
+// popPasskeyCeremony returns the state of the WebAuthn ceremony and removes it from the session,
+// so that the challenge can't be used more than once.
+func (s *Server) popPasskeyCeremony(w http.ResponseWriter, r *http.Request) (string, error) {
+	sess, err := s.sessionStore.Get(r, common.SessionName)
+	if err != nil {
+		return "", err
+	}
+
+	ceremony, ok := sess.Values[common.SessionKeyPasskeyCeremony].(string)
+	if !ok || len(ceremony) == 0 {
+		return "", customerrors.NewValidationError("", "The passkey ceremony was not started or has expired. Please try again.")
+	}
+
+	delete(sess.Values, common.SessionKeyPasskeyCeremony)
+	err = sess.Save(r, w)
+	if err != nil {
+		return "", err
+	}
+	return ceremony, nil
+}
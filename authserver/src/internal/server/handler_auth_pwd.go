@@ -5,13 +5,13 @@ import (
 	"log/slog"
 	"net/http"
 	"strings"
-	"time"
 
 	"github.com/pkg/errors"
 
 	"github.com/gorilla/csrf"
 	"github.com/leodip/goiabada/internal/common"
 	"github.com/leodip/goiabada/internal/constants"
+	core_federation "github.com/leodip/goiabada/internal/core/federation"
 	"github.com/leodip/goiabada/internal/customerrors"
 	"github.com/leodip/goiabada/internal/entities"
 	"github.com/leodip/goiabada/internal/enums"
@@ -22,7 +22,7 @@ func (s *Server) handleAuthPwdGet() http.HandlerFunc {
 
 	return func(w http.ResponseWriter, r *http.Request) {
 
-		_, err := s.getAuthContext(r)
+		authContext, err := s.getAuthContext(r)
 		if err != nil {
 			if errors.Is(err, customerrors.ErrNoAuthContext) {
 				slog.Warn("no auth context, redirecting to " + lib.GetBaseUrl() + "/account/profile")
@@ -53,10 +53,24 @@ func (s *Server) handleAuthPwdGet() http.HandlerFunc {
 
 		settings := r.Context().Value(common.ContextKeySettings).(*entities.Settings)
 
+		identityProviders, err := s.getSignInIdentityProviders()
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		emailLoginEnabled, err := s.isEmailLoginEnabled(r, authContext)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
 		bind := map[string]interface{}{
-			"error":       nil,
-			"smtpEnabled": settings.SMTPEnabled,
-			"csrfField":   csrf.TemplateField(r),
+			"error":             nil,
+			"smtpEnabled":       settings.SMTPEnabled,
+			"emailLoginEnabled": emailLoginEnabled,
+			"identityProviders": identityProviders,
+			"csrfField":         csrf.TemplateField(r),
 		}
 		if len(email) > 0 {
 			bind["email"] = email
@@ -70,7 +84,9 @@ func (s *Server) handleAuthPwdGet() http.HandlerFunc {
 	}
 }
 
-func (s *Server) handleAuthPwdPost(loginManager loginManager) http.HandlerFunc {
+func (s *Server) handleAuthPwdPost(loginManager loginManager, loginLockoutManager loginLockoutManager,
+	riskEngine riskEngine, ldapAuthenticator ldapAuthenticator, federatedUserResolver federatedUserResolver, emailSender emailSender,
+	breachedPasswordChecker breachedPasswordChecker) http.HandlerFunc {
 
 	return func(w http.ResponseWriter, r *http.Request) {
 
@@ -86,11 +102,25 @@ func (s *Server) handleAuthPwdPost(loginManager loginManager) http.HandlerFunc {
 		settings := r.Context().Value(common.ContextKeySettings).(*entities.Settings)
 
 		renderError := func(message string) {
+			identityProviders, err := s.getSignInIdentityProviders()
+			if err != nil {
+				s.internalServerError(w, r, err)
+				return
+			}
+
+			emailLoginEnabled, err := s.isEmailLoginEnabled(r, authContext)
+			if err != nil {
+				s.internalServerError(w, r, err)
+				return
+			}
+
 			bind := map[string]interface{}{
-				"error":       message,
-				"smtpEnabled": settings.SMTPEnabled,
-				"email":       email,
-				"csrfField":   csrf.TemplateField(r),
+				"error":             message,
+				"smtpEnabled":       settings.SMTPEnabled,
+				"emailLoginEnabled": emailLoginEnabled,
+				"identityProviders": identityProviders,
+				"email":             email,
+				"csrfField":         csrf.TemplateField(r),
 			}
 
 			err = s.renderTemplate(w, r, "/layouts/auth_layout.html", "/auth_pwd.html", bind)
@@ -109,143 +139,204 @@ func (s *Server) handleAuthPwdPost(loginManager loginManager) http.HandlerFunc {
 			return
 		}
 
+		authFailedMessage := "Authentication failed."
+
 		user, err := s.database.GetUserByEmail(nil, email)
 		if err != nil {
 			s.internalServerError(w, r, err)
 			return
 		}
 
-		authFailedMessage := "Authentication failed."
-		if user == nil {
-			lib.LogAudit(constants.AuditAuthFailedPwd, map[string]interface{}{
+		// too many failed attempts delay or lock the next ones, before any credential is verified
+		lockoutMessage, err := s.checkLoginLockout(r, loginLockoutManager, user)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+		if len(lockoutMessage) > 0 {
+			renderError(lockoutMessage)
+			return
+		}
+
+		identityProviders, err := s.database.GetEnabledIdentityProviders(nil)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		// the LDAP directory that rejected the password, if any. A single failure is audited per attempt,
+		// once the local password is also rejected
+		failedIdentityProvider := ""
+
+		// the LDAP directories are consulted first, in order
+		for i := range identityProviders {
+			idp := &identityProviders[i]
+			if idp.Type != enums.IdentityProviderTypeLDAP.String() {
+				continue
+			}
+
+			identity, err := ldapAuthenticator.Authenticate(r.Context(), idp, email, password)
+			if err == nil {
+				ldapUser := s.resolveFederatedUser(w, r, federatedUserResolver, idp, identity)
+				if ldapUser == nil {
+					return
+				}
+
+				blocked, err := s.assessLoginRisk(r, riskEngine, emailSender, authContext, ldapUser)
+				if err != nil {
+					s.internalServerError(w, r, err)
+					return
+				}
+				if blocked {
+					renderError(loginBlockedMessage)
+					return
+				}
+
+				err = s.completeFirstFactorAuth(w, r, loginManager, authContext, ldapUser, enums.AuthMethodPassword)
+				if err != nil {
+					s.internalServerError(w, r, err)
+				}
+				return
+			}
+
+			if errors.Is(err, core_federation.ErrLDAPInvalidCredentials) {
+				failedIdentityProvider = idp.IdentityProviderIdentifier
+			} else if !errors.Is(err, core_federation.ErrLDAPUserNotFound) {
+				slog.Error(fmt.Sprintf("unable to authenticate with identity provider %v: %+v", idp.IdentityProviderIdentifier, err))
+			}
+		}
+
+		auditFailedPwd := func() {
+			auditDetails := map[string]interface{}{
 				"email": email,
-			})
+			}
+			if len(failedIdentityProvider) > 0 {
+				auditDetails["identityProvider"] = failedIdentityProvider
+			}
+			lib.LogAudit(r.Context(), constants.AuditAuthFailedPwd, auditDetails)
+		}
+
+		if user == nil {
+			auditFailedPwd()
+			err = s.registerFailedLogin(r, loginLockoutManager, emailSender, nil)
+			if err != nil {
+				s.internalServerError(w, r, err)
+				return
+			}
 			renderError(authFailedMessage)
 			return
 		}
 
-		if !lib.VerifyPasswordHash(user.PasswordHash, password) {
-			lib.LogAudit(constants.AuditAuthFailedPwd, map[string]interface{}{
-				"email": email,
-			})
+		localPasswordAllowed, err := s.isLocalPasswordAllowed(user)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		if !localPasswordAllowed || !lib.VerifyPasswordHash(user.PasswordHash, password) {
+			auditFailedPwd()
+			err = s.registerFailedLogin(r, loginLockoutManager, emailSender, user)
+			if err != nil {
+				s.internalServerError(w, r, err)
+				return
+			}
 			renderError(authFailedMessage)
 			return
 		}
 
 		// from this point the user is considered authenticated with pwd
 
-		lib.LogAudit(constants.AuditAuthSuccessPwd, map[string]interface{}{
+		lib.LogAudit(r.Context(), constants.AuditAuthSuccessPwd, map[string]interface{}{
 			"userId": user.Id,
 		})
 
 		if !user.Enabled {
-			lib.LogAudit(constants.AuditUserDisabled, map[string]interface{}{
+			lib.LogAudit(r.Context(), constants.AuditUserDisabled, map[string]interface{}{
 				"userId": user.Id,
 			})
 			renderError("Your account is disabled.")
 			return
 		}
 
-		sessionIdentifier := ""
-		if r.Context().Value(common.ContextKeySessionIdentifier) != nil {
-			sessionIdentifier = r.Context().Value(common.ContextKeySessionIdentifier).(string)
-		}
-
-		userSession, err := s.database.GetUserSessionBySessionIdentifier(nil, sessionIdentifier)
+		blocked, err := s.assessLoginRisk(r, riskEngine, emailSender, authContext, user)
 		if err != nil {
 			s.internalServerError(w, r, err)
 			return
 		}
-
-		err = s.database.UserSessionLoadUser(nil, userSession)
-		if err != nil {
-			s.internalServerError(w, r, err)
+		if blocked {
+			renderError(loginBlockedMessage)
 			return
 		}
 
-		client, err := s.database.GetClientByClientIdentifier(nil, authContext.ClientId)
-		if err != nil {
-			s.internalServerError(w, r, err)
-			return
-		}
-		if client == nil {
-			s.internalServerError(w, r, errors.WithStack(errors.New(fmt.Sprintf("client %v not found", authContext.ClientId))))
-			return
-		}
-
-		requestedAcrValues := authContext.ParseRequestedAcrValues()
-		targetAcrLevel := client.DefaultAcrLevel
-
-		if len(requestedAcrValues) > 0 {
-			targetAcrLevel = requestedAcrValues[0]
-		}
-
-		hasValidUserSession := loginManager.HasValidUserSession(r.Context(), userSession, authContext.ParseRequestedMaxAge())
-		if hasValidUserSession {
-
-			mustPerformOTPAuth := loginManager.MustPerformOTPAuth(r.Context(), client, userSession, targetAcrLevel)
-			if mustPerformOTPAuth {
-				authContext.UserId = user.Id
-				err = s.saveAuthContext(w, r, authContext)
-				if err != nil {
-					s.internalServerError(w, r, err)
-					return
-				}
-				http.Redirect(w, r, lib.GetBaseUrl()+"/auth/otp", http.StatusFound)
+		// hashes created with an older algorithm or parameters (or imported from another system)
+		// are replaced while the password is at hand
+		if lib.PasswordHashNeedsRehash(user.PasswordHash) {
+			passwordHash, err := lib.HashPassword(password)
+			if err != nil {
+				s.internalServerError(w, r, err)
+				return
+			}
+			user.PasswordHash = passwordHash
+			err = s.database.UpdateUser(nil, user)
+			if err != nil {
+				s.internalServerError(w, r, err)
 				return
 			}
 
+			lib.LogAudit(r.Context(), constants.AuditUpgradedPasswordHash, map[string]interface{}{
+				"userId": user.Id,
+			})
 		}
 
-		// if the client accepts AcrLevel1 that means only the password is sufficient to authenticate
-		// no need to check anything else
-
-		if targetAcrLevel != enums.AcrLevel1 {
-
-			// optional: the system will offer OTP auth if it's enabled for the user
-			optional2fa := targetAcrLevel == enums.AcrLevel2 && user.OTPEnabled
-
-			// mandatory: if target acr is level 3, we'll force an OTP auth
-			mandatory2fa := targetAcrLevel == enums.AcrLevel3
-
-			if optional2fa || mandatory2fa {
-				authContext.UserId = user.Id
-				err = s.saveAuthContext(w, r, authContext)
+		// the password is screened at login, so users with a breached password must change it
+		// before the session starts (see completeFirstFactorAuth)
+		if settings.ForceChangeOfBreachedPasswords && !user.PasswordBreached {
+			passwordBreached, err := breachedPasswordChecker.IsBreached(password)
+			if err != nil {
+				s.internalServerError(w, r, err)
+				return
+			}
+			if passwordBreached {
+				user.PasswordBreached = true
+				err = s.database.UpdateUser(nil, user)
 				if err != nil {
 					s.internalServerError(w, r, err)
 					return
 				}
-				http.Redirect(w, r, lib.GetBaseUrl()+"/auth/otp", http.StatusFound)
-				return
+
+				lib.LogAudit(r.Context(), constants.AuditFlaggedBreachedPassword, map[string]interface{}{
+					"userId": user.Id,
+				})
 			}
 		}
 
-		// user is fully authenticated
-
-		// start new session
-
-		_, err = s.startNewUserSession(w, r, user.Id, client.Id, enums.AuthMethodPassword.String(), targetAcrLevel.String())
+		err = s.completeFirstFactorAuth(w, r, loginManager, authContext, user, enums.AuthMethodPassword)
 		if err != nil {
 			s.internalServerError(w, r, err)
 			return
 		}
+	}
+}
 
-		// redirect to consent
-		authContext.UserId = user.Id
-		err = authContext.SetAcrLevel(targetAcrLevel, userSession)
-		if err != nil {
-			s.internalServerError(w, r, err)
-			return
-		}
-		authContext.AuthMethods = enums.AuthMethodPassword.String()
-		authContext.AuthTime = time.Now().UTC()
-		authContext.AuthCompleted = true
-		err = s.saveAuthContext(w, r, authContext)
-		if err != nil {
-			s.internalServerError(w, r, err)
-			return
-		}
+// isLocalPasswordAllowed returns false when the user is linked to an enabled LDAP identity provider that
+// doesn't allow falling back to the local password. Those users authenticate with the directory only.
+func (s *Server) isLocalPasswordAllowed(user *entities.User) (bool, error) {
+
+	federatedIdentities, err := s.database.GetUserFederatedIdentitiesByUserId(nil, user.Id)
+	if err != nil {
+		return false, err
+	}
+
+	err = s.database.UserFederatedIdentitiesLoadIdentityProviders(nil, federatedIdentities)
+	if err != nil {
+		return false, err
+	}
 
-		http.Redirect(w, r, lib.GetBaseUrl()+"/auth/consent", http.StatusFound)
+	for _, federatedIdentity := range federatedIdentities {
+		idp := federatedIdentity.IdentityProvider
+		if idp.Enabled && idp.Type == enums.IdentityProviderTypeLDAP.String() && !idp.LDAPLocalPasswordFallback {
+			return false, nil
+		}
 	}
+	return true, nil
 }
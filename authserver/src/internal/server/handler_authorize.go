@@ -19,7 +19,7 @@ import (
 )
 
 func (s *Server) handleAuthorizeGet(authorizeValidator authorizeValidator,
-	loginManager loginManager) http.HandlerFunc {
+	loginManager loginManager, stepUpResolver stepUpResolver) http.HandlerFunc {
 
 	return func(w http.ResponseWriter, r *http.Request) {
 
@@ -115,6 +115,15 @@ func (s *Server) handleAuthorizeGet(authorizeValidator authorizeValidator,
 			}
 		}
 
+		// the resources and permissions in the scope can demand a stronger or more recent authentication
+		stepUpRequirements, err := stepUpResolver.GetRequirements(authContext.Scope)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+		authContext.ScopeMinAcrLevel = stepUpRequirements.MinAcrLevel.String()
+		authContext.ScopeMaxAuthAgeInSeconds = stepUpRequirements.MaxAuthAgeInSeconds
+
 		sessionIdentifier := ""
 		if r.Context().Value(common.ContextKeySessionIdentifier) != nil {
 			sessionIdentifier = r.Context().Value(common.ContextKeySessionIdentifier).(string)
@@ -131,6 +140,13 @@ func (s *Server) handleAuthorizeGet(authorizeValidator authorizeValidator,
 			s.internalServerError(w, r, err)
 			return
 		}
+		if userSession != nil {
+			err = s.database.UserLoadPasskeys(nil, &userSession.User)
+			if err != nil {
+				s.internalServerError(w, r, err)
+				return
+			}
+		}
 
 		client, err := s.database.GetClientByClientIdentifier(nil, authContext.ClientId)
 		if err != nil {
@@ -142,16 +158,15 @@ func (s *Server) handleAuthorizeGet(authorizeValidator authorizeValidator,
 			return
 		}
 
-		requestedAcrValues := authContext.ParseRequestedAcrValues()
-		targetAcrLevel := client.DefaultAcrLevel
+		targetAcrLevel := authContext.GetTargetAcrLevel(client.DefaultAcrLevel)
 
-		hasValidUserSession := loginManager.HasValidUserSession(r.Context(), userSession, authContext.ParseRequestedMaxAge())
+		hasValidUserSession := loginManager.HasValidUserSession(r.Context(), userSession, authContext.GetMaxAgeInSeconds())
 		if hasValidUserSession {
 			// valid user session
 
 			if !userSession.User.Enabled {
 
-				lib.LogAudit(constants.AuditUserDisabled, map[string]interface{}{
+				lib.LogAudit(r.Context(), constants.AuditUserDisabled, map[string]interface{}{
 					"userId": userSession.UserId,
 				})
 
@@ -162,19 +177,30 @@ func (s *Server) handleAuthorizeGet(authorizeValidator authorizeValidator,
 				return
 			}
 
-			if len(requestedAcrValues) > 0 {
-				targetAcrLevel = requestedAcrValues[0]
+			if userSession.IsImpersonated() && !client.AcceptImpersonatedTokens {
+				redirToClientWithError(&customerrors.ValidationError{
+					Code:        "access_denied",
+					Description: "The client does not accept impersonated sessions.",
+				})
+				return
+			}
+
+			trustedDevice, err := s.getTrustedDevice(r, userSession.User.Id)
+			if err != nil {
+				s.internalServerError(w, r, err)
+				return
 			}
 
-			mustPerformOTPAuth := loginManager.MustPerformOTPAuth(r.Context(), client, userSession, targetAcrLevel)
+			mustPerformOTPAuth := loginManager.MustPerformOTPAuth(r.Context(), client, userSession, targetAcrLevel, trustedDevice)
 			if mustPerformOTPAuth {
 				authContext.UserId = userSession.User.Id
+				authContext.AuthMethods = userSession.AuthMethods
 				err = s.saveAuthContext(w, r, &authContext)
 				if err != nil {
 					s.internalServerError(w, r, err)
 					return
 				}
-				http.Redirect(w, r, lib.GetBaseUrl()+"/auth/otp", http.StatusFound)
+				http.Redirect(w, r, secondFactorUrl(&userSession.User, targetAcrLevel), http.StatusFound)
 				return
 			}
 
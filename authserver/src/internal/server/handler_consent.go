@@ -106,7 +106,7 @@ func (s *Server) handleConsentGet(codeIssuer codeIssuer, permissionChecker *core
 		}
 
 		if !user.Enabled {
-			lib.LogAudit(constants.AuditUserDisabled, map[string]interface{}{
+			lib.LogAudit(r.Context(), constants.AuditUserDisabled, map[string]interface{}{
 				"userId": user.Id,
 			})
 
@@ -296,7 +296,7 @@ func (s *Server) handleConsentPost(codeIssuer codeIssuer) http.HandlerFunc {
 				}
 				authContext.ConsentedScope = consent.Scope
 
-				lib.LogAudit(constants.AuditSavedConsent, map[string]interface{}{
+				lib.LogAudit(r.Context(), constants.AuditSavedConsent, map[string]interface{}{
 					"userId":   consent.UserId,
 					"clientId": consent.ClientId,
 				})
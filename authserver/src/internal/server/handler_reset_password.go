@@ -10,6 +10,7 @@ import (
 
 	"github.com/gorilla/csrf"
 	"github.com/leodip/goiabada/internal/common"
+	"github.com/leodip/goiabada/internal/constants"
 	"github.com/leodip/goiabada/internal/entities"
 	"github.com/leodip/goiabada/internal/lib"
 )
@@ -65,7 +66,7 @@ func (s *Server) handleResetPasswordGet() http.HandlerFunc {
 	}
 }
 
-func (s *Server) handleResetPasswordPost(passwordValidator passwordValidator) http.HandlerFunc {
+func (s *Server) handleResetPasswordPost(passwordValidator passwordValidator, userPasswordManager userPasswordManager) http.HandlerFunc {
 
 	return func(w http.ResponseWriter, r *http.Request) {
 
@@ -94,12 +95,6 @@ func (s *Server) handleResetPasswordPost(passwordValidator passwordValidator) ht
 			return
 		}
 
-		err := passwordValidator.ValidatePassword(r.Context(), password)
-		if err != nil {
-			renderError(err.Error())
-			return
-		}
-
 		code := r.URL.Query().Get("code")
 		if len(code) == 0 {
 			s.internalServerError(w, r, errors.WithStack(errors.New("expecting code to reset the password, but it's empty")))
@@ -135,12 +130,17 @@ func (s *Server) handleResetPasswordPost(passwordValidator passwordValidator) ht
 			return
 		}
 
-		passwordHash, err := lib.HashPassword(password)
+		err = passwordValidator.ValidatePassword(r.Context(), password, user)
+		if err != nil {
+			renderError(err.Error())
+			return
+		}
+
+		err = userPasswordManager.SetPassword(r.Context(), user, password)
 		if err != nil {
 			s.internalServerError(w, r, err)
 			return
 		}
-		user.PasswordHash = passwordHash
 		user.ForgotPasswordCodeEncrypted = nil
 		user.ForgotPasswordCodeIssuedAt = sql.NullTime{Valid: false}
 		err = s.database.UpdateUser(nil, user)
@@ -149,6 +149,11 @@ func (s *Server) handleResetPasswordPost(passwordValidator passwordValidator) ht
 			return
 		}
 
+		lib.LogAudit(r.Context(), constants.AuditChangedPassword, map[string]interface{}{
+			"userId": user.Id,
+		})
+		s.sendSecurityNotification(r, user, constants.AuditChangedPassword)
+
 		bind := map[string]interface{}{
 			"passwordReset": true,
 		}
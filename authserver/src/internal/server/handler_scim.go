@@ -0,0 +1,244 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/leodip/goiabada/internal/common"
+	core_api "github.com/leodip/goiabada/internal/core/api"
+	core_scim "github.com/leodip/goiabada/internal/core/scim"
+	"github.com/leodip/goiabada/internal/customerrors"
+	"github.com/leodip/goiabada/internal/dtos"
+	"github.com/leodip/goiabada/internal/lib"
+)
+
+func writeScimResponse(w http.ResponseWriter, statusCode int, body any) {
+	w.Header().Set("Content-Type", core_scim.ContentType)
+	w.WriteHeader(statusCode)
+	if body != nil {
+		json.NewEncoder(w).Encode(body)
+	}
+}
+
+// writeScimResource writes a single resource, along with its entity tag.
+func writeScimResource(w http.ResponseWriter, r *http.Request, statusCode int, resource map[string]any) {
+	version := core_scim.SetVersion(resource)
+	w.Header().Set("ETag", version)
+	if statusCode == http.StatusCreated {
+		if meta, ok := resource["meta"].(map[string]any); ok {
+			w.Header().Set("Location", fmt.Sprintf("%v", meta["location"]))
+		}
+	}
+	resource = core_scim.ProjectAttributes(resource,
+		core_scim.SplitAttributeList(r.URL.Query().Get("attributes")),
+		core_scim.SplitAttributeList(r.URL.Query().Get("excludedAttributes")))
+	writeScimResponse(w, statusCode, resource)
+}
+
+func (s *Server) scimError(w http.ResponseWriter, r *http.Request, err error) {
+
+	if scimErr, ok := err.(*core_scim.Error); ok {
+		writeScimResponse(w, scimErr.Status, scimErr)
+		return
+	}
+
+	// the permission checks are shared with the admin API
+	if apiErr, ok := err.(*core_api.Error); ok {
+		writeScimResponse(w, apiErr.Status, core_scim.NewError(apiErr.Status, "", apiErr.Description))
+		return
+	}
+
+	if valError, ok := err.(*customerrors.ValidationError); ok {
+		writeScimResponse(w, http.StatusBadRequest, core_scim.NewInvalidValueError(valError.Description))
+		return
+	}
+
+	requestId := middleware.GetReqID(r.Context())
+	slog.Error(fmt.Sprintf("%+v\nrequest-id: %v", err, requestId))
+
+	writeScimResponse(w, http.StatusInternalServerError, core_scim.NewError(http.StatusInternalServerError, "",
+		fmt.Sprintf("An unexpected server error has occurred. For additional information, refer to the server logs. Request Id: %v", requestId)))
+}
+
+// getScimClient returns the identifier of the client making the request, for the audit logs.
+func getScimClient(r *http.Request) string {
+	if jwtToken, ok := r.Context().Value(common.ContextKeyJwtInfo).(dtos.JwtToken); ok {
+		return jwtToken.GetStringClaim("sub")
+	}
+	return ""
+}
+
+func readScimResource(w http.ResponseWriter, r *http.Request) (map[string]any, error) {
+	var resource map[string]any
+	err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1024*1024)).Decode(&resource)
+	if err != nil || resource == nil {
+		return nil, core_scim.NewError(http.StatusBadRequest, "invalidSyntax", "The request body is not a valid JSON object.")
+	}
+	return resource, nil
+}
+
+func readScimPatchRequest(w http.ResponseWriter, r *http.Request) (*core_scim.PatchRequest, error) {
+	var patchRequest core_scim.PatchRequest
+	err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1024*1024)).Decode(&patchRequest)
+	if err != nil {
+		return nil, core_scim.NewError(http.StatusBadRequest, "invalidSyntax", "The request body is not a valid PATCH request.")
+	}
+	if len(patchRequest.Operations) == 0 {
+		return nil, core_scim.NewInvalidValueError("The PATCH request has no operations.")
+	}
+	return &patchRequest, nil
+}
+
+// checkScimPrecondition enforces the If-Match header of a request that modifies a resource.
+func checkScimPrecondition(r *http.Request, resource map[string]any) error {
+	ifMatch := r.Header.Get("If-Match")
+	if len(ifMatch) > 0 && !core_scim.VersionMatches(ifMatch, core_scim.ComputeVersion(resource)) {
+		return core_scim.NewError(http.StatusPreconditionFailed, "",
+			"The resource has been modified since it was retrieved (the version does not match If-Match).")
+	}
+	return nil
+}
+
+// isScimNotModified handles the If-None-Match header of a request that retrieves a resource.
+func isScimNotModified(w http.ResponseWriter, r *http.Request, resource map[string]any) bool {
+	ifNoneMatch := r.Header.Get("If-None-Match")
+	version := core_scim.ComputeVersion(resource)
+	if len(ifNoneMatch) > 0 && core_scim.VersionMatches(ifNoneMatch, version) {
+		w.Header().Set("ETag", version)
+		w.WriteHeader(http.StatusNotModified)
+		return true
+	}
+	return false
+}
+
+type scimListParameters struct {
+	filter             core_scim.Filter
+	startIndex         int
+	count              int
+	attributes         []string
+	excludedAttributes []string
+}
+
+func parseScimListParameters(r *http.Request) (*scimListParameters, error) {
+	query := r.URL.Query()
+
+	params := &scimListParameters{
+		startIndex:         1,
+		count:              core_scim.DefaultCount,
+		attributes:         core_scim.SplitAttributeList(query.Get("attributes")),
+		excludedAttributes: core_scim.SplitAttributeList(query.Get("excludedAttributes")),
+	}
+
+	if len(query.Get("sortBy")) > 0 {
+		return nil, core_scim.NewError(http.StatusBadRequest, "invalidValue", "Sorting is not supported.")
+	}
+
+	if filter := strings.TrimSpace(query.Get("filter")); len(filter) > 0 {
+		var err error
+		params.filter, err = core_scim.ParseFilter(filter)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// startIndex values less than 1 are interpreted as 1, and negative counts as 0 (RFC 7644, section 3.4.2.4)
+	if value := query.Get("startIndex"); len(value) > 0 {
+		startIndex, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, core_scim.NewInvalidValueError("The startIndex parameter must be an integer.")
+		}
+		params.startIndex = max(startIndex, 1)
+	}
+	if value := query.Get("count"); len(value) > 0 {
+		count, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, core_scim.NewInvalidValueError("The count parameter must be an integer.")
+		}
+		params.count = min(max(count, 0), core_scim.MaxCount)
+	}
+	return params, nil
+}
+
+// scimResourceCollector applies the filter and the pagination to a sequence of resources.
+type scimResourceCollector struct {
+	params    *scimListParameters
+	total     int
+	resources []map[string]any
+
+	// omitVersion is set when the resources are partially loaded, in which case their
+	// version would not match the version of the full resource
+	omitVersion bool
+}
+
+func (c *scimResourceCollector) add(resource map[string]any) {
+	if c.params.filter != nil && !c.params.filter.Matches(resource) {
+		return
+	}
+	c.total++
+	if c.total >= c.params.startIndex && len(c.resources) < c.params.count {
+		if !c.omitVersion {
+			core_scim.SetVersion(resource)
+		}
+		c.resources = append(c.resources, core_scim.ProjectAttributes(resource,
+			c.params.attributes, c.params.excludedAttributes))
+	}
+}
+
+func (c *scimResourceCollector) listResponse() *core_scim.ListResponse {
+	return core_scim.NewListResponse(c.resources, c.total, c.params.startIndex)
+}
+
+func (s *Server) handleScimServiceProviderConfigGet() http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		writeScimResponse(w, http.StatusOK, core_scim.NewServiceProviderConfig(lib.GetBaseUrl()))
+	}
+}
+
+func (s *Server) handleScimResourceTypesGet() http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		resourceTypes := core_scim.NewResourceTypes(lib.GetBaseUrl())
+		writeScimResponse(w, http.StatusOK, core_scim.NewListResponse(resourceTypes, len(resourceTypes), 1))
+	}
+}
+
+func (s *Server) handleScimResourceTypeGet() http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		for _, resourceType := range core_scim.NewResourceTypes(lib.GetBaseUrl()) {
+			if resourceType["id"] == chi.URLParam(r, "resourceTypeId") {
+				writeScimResponse(w, http.StatusOK, resourceType)
+				return
+			}
+		}
+		writeScimResponse(w, http.StatusNotFound, core_scim.NewError(http.StatusNotFound, "", "Resource type not found."))
+	}
+}
+
+func (s *Server) handleScimSchemasGet() http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		schemas := core_scim.NewSchemas(lib.GetBaseUrl())
+		writeScimResponse(w, http.StatusOK, core_scim.NewListResponse(schemas, len(schemas), 1))
+	}
+}
+
+func (s *Server) handleScimSchemaGet() http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		for _, schema := range core_scim.NewSchemas(lib.GetBaseUrl()) {
+			if schema["id"] == chi.URLParam(r, "schemaId") {
+				writeScimResponse(w, http.StatusOK, schema)
+				return
+			}
+		}
+		writeScimResponse(w, http.StatusNotFound, core_scim.NewError(http.StatusNotFound, "", "Schema not found."))
+	}
+}
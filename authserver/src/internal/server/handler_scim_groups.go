@@ -0,0 +1,500 @@
+package server
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/leodip/goiabada/internal/constants"
+	core_scim "github.com/leodip/goiabada/internal/core/scim"
+	"github.com/leodip/goiabada/internal/entities"
+	"github.com/leodip/goiabada/internal/lib"
+)
+
+func (s *Server) getGroupMembers(group *entities.Group) ([]entities.User, error) {
+	members := []entities.User{}
+	for page := 1; ; page++ {
+		users, _, err := s.database.GetGroupMembersPaginated(nil, group.Id, page, scimPageSize)
+		if err != nil {
+			return nil, err
+		}
+		members = append(members, users...)
+		if len(users) < scimPageSize {
+			break
+		}
+	}
+	return members, nil
+}
+
+// getScimGroupResource returns the SCIM representation of the group. Loading the members
+// can be skipped when they are not part of the response.
+func (s *Server) getScimGroupResource(group *entities.Group, loadMembers bool) (map[string]any, error) {
+	var members []entities.User
+	if loadMembers {
+		var err error
+		members, err = s.getGroupMembers(group)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return core_scim.ToMap(core_scim.NewGroup(group, members, lib.GetBaseUrl()))
+}
+
+// getScimGroup returns the group identified by the id in the URL, or nil after writing a 404 response.
+func (s *Server) getScimGroup(w http.ResponseWriter, r *http.Request) (*entities.Group, map[string]any) {
+	notFound := core_scim.NewError(http.StatusNotFound, "", "Group not found.")
+
+	id, err := strconv.ParseInt(chi.URLParam(r, "groupId"), 10, 64)
+	if err != nil {
+		s.scimError(w, r, notFound)
+		return nil, nil
+	}
+	group, err := s.database.GetGroupById(nil, id)
+	if err != nil {
+		s.scimError(w, r, err)
+		return nil, nil
+	}
+	if group == nil {
+		s.scimError(w, r, notFound)
+		return nil, nil
+	}
+	resource, err := s.getScimGroupResource(group, true)
+	if err != nil {
+		s.scimError(w, r, err)
+		return nil, nil
+	}
+	return group, resource
+}
+
+// applyScimGroup validates the SCIM representation of a group and copies its attributes to the group.
+func (s *Server) applyScimGroup(group *entities.Group, scimGroup *core_scim.Group,
+	identifierValidator identifierValidator, inputSanitizer inputSanitizer) error {
+
+	groupIdentifier := strings.TrimSpace(scimGroup.DisplayName)
+	if len(groupIdentifier) == 0 {
+		return core_scim.NewInvalidValueError("The displayName (the group identifier) is required.")
+	}
+
+	err := identifierValidator.ValidateIdentifier(groupIdentifier, true)
+	if err != nil {
+		return err
+	}
+
+	existingGroup, err := s.database.GetGroupByGroupIdentifier(nil, groupIdentifier)
+	if err != nil {
+		return err
+	}
+	if existingGroup != nil && existingGroup.Id != group.Id {
+		return core_scim.NewError(http.StatusConflict, "uniqueness", "The group identifier is already in use.")
+	}
+
+	if len(scimGroup.ExternalId) > 256 {
+		return core_scim.NewInvalidValueError("The externalId cannot exceed a maximum length of 256 characters.")
+	}
+
+	group.GroupIdentifier = inputSanitizer.Sanitize(groupIdentifier)
+	group.ExternalId = scimGroup.ExternalId
+	return nil
+}
+
+// resolveScimGroupMembers returns the users referenced by the members of a group.
+func (s *Server) resolveScimGroupMembers(members []core_scim.MultiValuedAttribute) ([]entities.User, error) {
+	users := []entities.User{}
+	seen := map[int64]bool{}
+	for _, member := range members {
+		if len(member.Type) > 0 && member.Type != "User" {
+			return nil, core_scim.NewInvalidValueError("Only users can be members of a group.")
+		}
+		user, err := s.database.GetUserBySubject(nil, strings.TrimSpace(member.Value))
+		if err != nil {
+			return nil, err
+		}
+		if user == nil {
+			return nil, core_scim.NewInvalidValueError("The member " + member.Value + " does not exist.")
+		}
+		if !seen[user.Id] {
+			seen[user.Id] = true
+			users = append(users, *user)
+		}
+	}
+	return users, nil
+}
+
+func scimGroupMembersChanged(currentMembers []entities.User, members []entities.User) bool {
+	if len(currentMembers) != len(members) {
+		return true
+	}
+	for _, user := range members {
+		if !slices.ContainsFunc(currentMembers, func(u entities.User) bool { return u.Id == user.Id }) {
+			return true
+		}
+	}
+	return false
+}
+
+// syncScimGroupMembers adds and removes users so the members of the group are the given ones.
+func (s *Server) syncScimGroupMembers(r *http.Request, group *entities.Group, currentMembers []entities.User,
+	members []entities.User) error {
+
+	isMember := map[int64]bool{}
+	for _, user := range members {
+		isMember[user.Id] = true
+	}
+
+	wasMember := map[int64]bool{}
+	for _, user := range currentMembers {
+		wasMember[user.Id] = true
+		if isMember[user.Id] {
+			continue
+		}
+		userGroup, err := s.database.GetUserGroupByUserIdAndGroupId(nil, user.Id, group.Id)
+		if err != nil {
+			return err
+		}
+		if userGroup == nil {
+			continue
+		}
+		err = s.database.DeleteUserGroup(nil, userGroup.Id)
+		if err != nil {
+			return err
+		}
+		lib.LogAudit(r.Context(), constants.AuditUserRemovedFromGroup, map[string]interface{}{
+			"userId":     user.Id,
+			"groupId":    group.Id,
+			"scimClient": getScimClient(r),
+		})
+	}
+
+	for _, user := range members {
+		if wasMember[user.Id] {
+			continue
+		}
+		err := s.database.CreateUserGroup(nil, &entities.UserGroup{
+			UserId:  user.Id,
+			GroupId: group.Id,
+		})
+		if err != nil {
+			return err
+		}
+		lib.LogAudit(r.Context(), constants.AuditUserAddedToGroup, map[string]interface{}{
+			"userId":     user.Id,
+			"groupId":    group.Id,
+			"scimClient": getScimClient(r),
+		})
+		s.publishUserEvent(constants.WebhookEventUserAddedToGroup, &user, group)
+	}
+	return nil
+}
+
+func (s *Server) handleScimGroupsGet() http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		params, err := parseScimListParameters(r)
+		if err != nil {
+			s.scimError(w, r, err)
+			return
+		}
+
+		// members are only loaded when they are returned or filtered on
+		loadMembers := core_scim.IsAttributeRequested("members", params.attributes, params.excludedAttributes) ||
+			strings.Contains(strings.ToLower(r.URL.Query().Get("filter")), "members")
+
+		collector := &scimResourceCollector{params: params, omitVersion: !loadMembers}
+
+		addGroup := func(group *entities.Group) error {
+			resource, err := s.getScimGroupResource(group, loadMembers)
+			if err != nil {
+				return err
+			}
+			collector.add(resource)
+			return nil
+		}
+
+		// lookups by displayName or id don't need a scan
+		if params.filter != nil {
+			for _, attribute := range []string{"displayName", "id"} {
+				value, ok := core_scim.GetEqualityValue(params.filter, attribute)
+				if !ok {
+					continue
+				}
+				var group *entities.Group
+				if attribute == "id" {
+					if id, err := strconv.ParseInt(value, 10, 64); err == nil {
+						group, err = s.database.GetGroupById(nil, id)
+						if err != nil {
+							s.scimError(w, r, err)
+							return
+						}
+					}
+				} else {
+					group, err = s.database.GetGroupByGroupIdentifier(nil, strings.TrimSpace(value))
+					if err != nil {
+						s.scimError(w, r, err)
+						return
+					}
+				}
+				if group != nil {
+					err = addGroup(group)
+					if err != nil {
+						s.scimError(w, r, err)
+						return
+					}
+				}
+				writeScimResponse(w, http.StatusOK, collector.listResponse())
+				return
+			}
+		}
+
+		for page := 1; ; page++ {
+			groups, _, err := s.database.GetAllGroupsPaginated(nil, page, scimPageSize)
+			if err != nil {
+				s.scimError(w, r, err)
+				return
+			}
+			for idx := range groups {
+				err = addGroup(&groups[idx])
+				if err != nil {
+					s.scimError(w, r, err)
+					return
+				}
+			}
+			if len(groups) < scimPageSize {
+				break
+			}
+		}
+
+		writeScimResponse(w, http.StatusOK, collector.listResponse())
+	}
+}
+
+func (s *Server) handleScimGroupGet() http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		group, resource := s.getScimGroup(w, r)
+		if group == nil {
+			return
+		}
+
+		if isScimNotModified(w, r, resource) {
+			return
+		}
+		writeScimResource(w, r, http.StatusOK, resource)
+	}
+}
+
+func (s *Server) handleScimGroupsPost(identifierValidator identifierValidator, inputSanitizer inputSanitizer) http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		resource, err := readScimResource(w, r)
+		if err != nil {
+			s.scimError(w, r, err)
+			return
+		}
+
+		scimGroup, err := core_scim.DecodeGroup(resource)
+		if err != nil {
+			s.scimError(w, r, err)
+			return
+		}
+
+		group := &entities.Group{}
+		err = s.applyScimGroup(group, scimGroup, identifierValidator, inputSanitizer)
+		if err != nil {
+			s.scimError(w, r, err)
+			return
+		}
+
+		members, err := s.resolveScimGroupMembers(scimGroup.Members)
+		if err != nil {
+			s.scimError(w, r, err)
+			return
+		}
+
+		err = s.database.CreateGroup(nil, group)
+		if err != nil {
+			s.scimError(w, r, err)
+			return
+		}
+
+		lib.LogAudit(r.Context(), constants.AuditCreatedGroup, map[string]interface{}{
+			"groupId":         group.Id,
+			"groupIdentifier": group.GroupIdentifier,
+			"scimClient":      getScimClient(r),
+		})
+
+		err = s.syncScimGroupMembers(r, group, nil, members)
+		if err != nil {
+			s.scimError(w, r, err)
+			return
+		}
+
+		resource, err = s.getScimGroupResource(group, true)
+		if err != nil {
+			s.scimError(w, r, err)
+			return
+		}
+		writeScimResource(w, r, http.StatusCreated, resource)
+	}
+}
+
+func (s *Server) updateScimGroup(w http.ResponseWriter, r *http.Request, group *entities.Group,
+	scimGroup *core_scim.Group,
+	identifierValidator identifierValidator, inputSanitizer inputSanitizer) {
+
+	err := s.applyScimGroup(group, scimGroup, identifierValidator, inputSanitizer)
+	if err != nil {
+		s.scimError(w, r, err)
+		return
+	}
+
+	members, err := s.resolveScimGroupMembers(scimGroup.Members)
+	if err != nil {
+		s.scimError(w, r, err)
+		return
+	}
+
+	currentMembers, err := s.getGroupMembers(group)
+	if err != nil {
+		s.scimError(w, r, err)
+		return
+	}
+
+	// the members of a group granting authserver permissions can only be changed by a token holding
+	// them, otherwise the provisioning client could make any user an admin
+	if scimGroupMembersChanged(currentMembers, members) {
+		err = s.checkApiGroupPermissionScopes(r, group)
+		if err != nil {
+			s.scimError(w, r, err)
+			return
+		}
+	}
+
+	err = s.database.UpdateGroup(nil, group)
+	if err != nil {
+		s.scimError(w, r, err)
+		return
+	}
+
+	lib.LogAudit(r.Context(), constants.AuditUpdatedGroup, map[string]interface{}{
+		"groupId":         group.Id,
+		"groupIdentifier": group.GroupIdentifier,
+		"scimClient":      getScimClient(r),
+	})
+
+	err = s.syncScimGroupMembers(r, group, currentMembers, members)
+	if err != nil {
+		s.scimError(w, r, err)
+		return
+	}
+
+	resource, err := s.getScimGroupResource(group, true)
+	if err != nil {
+		s.scimError(w, r, err)
+		return
+	}
+	writeScimResource(w, r, http.StatusOK, resource)
+}
+
+func (s *Server) handleScimGroupPut(identifierValidator identifierValidator, inputSanitizer inputSanitizer) http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		group, currentResource := s.getScimGroup(w, r)
+		if group == nil {
+			return
+		}
+
+		err := checkScimPrecondition(r, currentResource)
+		if err != nil {
+			s.scimError(w, r, err)
+			return
+		}
+
+		resource, err := readScimResource(w, r)
+		if err != nil {
+			s.scimError(w, r, err)
+			return
+		}
+
+		scimGroup, err := core_scim.DecodeGroup(resource)
+		if err != nil {
+			s.scimError(w, r, err)
+			return
+		}
+
+		s.updateScimGroup(w, r, group, scimGroup, identifierValidator, inputSanitizer)
+	}
+}
+
+func (s *Server) handleScimGroupPatch(identifierValidator identifierValidator, inputSanitizer inputSanitizer) http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		group, resource := s.getScimGroup(w, r)
+		if group == nil {
+			return
+		}
+
+		err := checkScimPrecondition(r, resource)
+		if err != nil {
+			s.scimError(w, r, err)
+			return
+		}
+
+		patchRequest, err := readScimPatchRequest(w, r)
+		if err != nil {
+			s.scimError(w, r, err)
+			return
+		}
+
+		err = core_scim.ApplyPatch(resource, patchRequest.Operations, "id", "meta")
+		if err != nil {
+			s.scimError(w, r, err)
+			return
+		}
+
+		scimGroup, err := core_scim.DecodeGroup(resource)
+		if err != nil {
+			s.scimError(w, r, err)
+			return
+		}
+
+		s.updateScimGroup(w, r, group, scimGroup, identifierValidator, inputSanitizer)
+	}
+}
+
+func (s *Server) handleScimGroupDelete() http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		group, resource := s.getScimGroup(w, r)
+		if group == nil {
+			return
+		}
+
+		err := checkScimPrecondition(r, resource)
+		if err != nil {
+			s.scimError(w, r, err)
+			return
+		}
+
+		err = s.database.DeleteGroup(nil, group.Id)
+		if err != nil {
+			s.scimError(w, r, err)
+			return
+		}
+
+		lib.LogAudit(r.Context(), constants.AuditDeletedGroup, map[string]interface{}{
+			"groupId":         group.Id,
+			"groupIdentifier": group.GroupIdentifier,
+			"scimClient":      getScimClient(r),
+		})
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,545 @@
+package server
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/leodip/goiabada/internal/constants"
+	"github.com/leodip/goiabada/internal/core"
+	core_scim "github.com/leodip/goiabada/internal/core/scim"
+	core_validators "github.com/leodip/goiabada/internal/core/validators"
+	"github.com/leodip/goiabada/internal/entities"
+	"github.com/leodip/goiabada/internal/lib"
+)
+
+const scimPageSize = 200
+
+func (s *Server) getScimUserResource(user *entities.User) (map[string]any, error) {
+	err := s.database.UserLoadGroups(nil, user)
+	if err != nil {
+		return nil, err
+	}
+	return core_scim.ToMap(core_scim.NewUser(user, lib.GetBaseUrl()))
+}
+
+// getScimUser returns the user identified by the id in the URL (the subject), or nil
+// after writing a 404 response.
+func (s *Server) getScimUser(w http.ResponseWriter, r *http.Request) (*entities.User, map[string]any) {
+	user, err := s.database.GetUserBySubject(nil, chi.URLParam(r, "userId"))
+	if err != nil {
+		s.scimError(w, r, err)
+		return nil, nil
+	}
+	if user == nil {
+		s.scimError(w, r, core_scim.NewError(http.StatusNotFound, "", "User not found."))
+		return nil, nil
+	}
+	resource, err := s.getScimUserResource(user)
+	if err != nil {
+		s.scimError(w, r, err)
+		return nil, nil
+	}
+	return user, resource
+}
+
+// applyScimUser validates the SCIM representation of a user and copies its attributes to the user.
+// As in a PUT request, attributes that are not present are cleared (except active and password).
+// The email and the password of a user holding authserver permissions can't be changed (see
+// checkApiUserPermissionScopes), otherwise the provisioning client could sign in as an admin.
+func (s *Server) applyScimUser(r *http.Request, user *entities.User, scimUser *core_scim.User,
+	profileValidator profileValidator, emailValidator emailValidator, addressValidator addressValidator,
+	passwordValidator passwordValidator, userPasswordManager userPasswordManager, inputSanitizer inputSanitizer) error {
+
+	ctx := r.Context()
+
+	email := strings.ToLower(strings.TrimSpace(scimUser.UserName))
+	if user.Id > 0 && email == user.Email {
+		// the email may have been updated through the emails attribute, which some clients do
+		// instead of changing the userName
+		if primaryEmail := strings.ToLower(core_scim.GetPrimary(scimUser.Emails)); len(primaryEmail) > 0 {
+			email = primaryEmail
+		}
+	}
+	if len(email) == 0 {
+		return core_scim.NewInvalidValueError("The userName (the email address of the user) is required.")
+	}
+	err := emailValidator.ValidateEmailAddress(ctx, email)
+	if err != nil {
+		return err
+	}
+	if len(email) > 60 {
+		return core_scim.NewInvalidValueError("The email address cannot exceed a maximum length of 60 characters.")
+	}
+	existingUser, err := s.database.GetUserByEmail(nil, email)
+	if err != nil {
+		return err
+	}
+	if existingUser != nil && existingUser.Id != user.Id {
+		return core_scim.NewError(http.StatusConflict, "uniqueness", "The email address is already in use.")
+	}
+	if user.Id > 0 && email != user.Email {
+		err = s.checkApiUserPermissionScopes(r, user)
+		if err != nil {
+			return err
+		}
+	}
+
+	name := scimUser.Name
+	if name == nil {
+		name = &core_scim.Name{}
+	}
+	err = profileValidator.ValidateProfile(ctx, &core_validators.ValidateProfileInput{
+		GivenName:  name.GivenName,
+		MiddleName: name.MiddleName,
+		FamilyName: name.FamilyName,
+		Nickname:   scimUser.NickName,
+		Website:    scimUser.ProfileUrl,
+		ZoneInfo:   scimUser.Timezone,
+		Locale:     scimUser.Locale,
+		Subject:    user.Subject.String(),
+	})
+	if err != nil {
+		return err
+	}
+
+	phoneNumber := inputSanitizer.Sanitize(core_scim.GetPrimary(scimUser.PhoneNumbers))
+	if len(phoneNumber) > 30 {
+		return core_scim.NewInvalidValueError("The phone number cannot exceed a maximum length of 30 characters.")
+	}
+
+	addressInput := &core_validators.ValidateAddressInput{}
+	if address := core_scim.GetPrimaryAddress(scimUser.Addresses); address != nil {
+		addressInput.AddressLine1, addressInput.AddressLine2 = core_scim.SplitStreetAddress(address.StreetAddress)
+		addressInput.AddressLocality = strings.TrimSpace(address.Locality)
+		addressInput.AddressRegion = strings.TrimSpace(address.Region)
+		addressInput.AddressPostalCode = strings.TrimSpace(address.PostalCode)
+		if country := strings.TrimSpace(address.Country); len(country) > 0 {
+			addressInput.AddressCountry = core_scim.CountryToAlpha3(country)
+			if len(addressInput.AddressCountry) == 0 {
+				return core_scim.NewInvalidValueError("Invalid country: " + country + ".")
+			}
+		}
+	}
+	err = addressValidator.ValidateAddress(ctx, addressInput)
+	if err != nil {
+		return err
+	}
+
+	if len(scimUser.ExternalId) > 256 {
+		return core_scim.NewInvalidValueError("The externalId cannot exceed a maximum length of 256 characters.")
+	}
+
+	// provisioning clients may send the same password again, which is not a password change
+	if len(scimUser.Password) > 0 && !(user.Id > 0 && lib.VerifyPasswordHash(user.PasswordHash, scimUser.Password)) {
+		if user.Id > 0 {
+			err = s.checkApiUserPermissionScopes(r, user)
+			if err != nil {
+				return err
+			}
+		}
+		passwordUser := *user
+		passwordUser.Email = email
+		err = passwordValidator.ValidatePassword(ctx, scimUser.Password, &passwordUser)
+		if err != nil {
+			return err
+		}
+		err = userPasswordManager.SetPassword(ctx, user, scimUser.Password)
+		if err != nil {
+			return err
+		}
+	}
+
+	if email != user.Email {
+		user.Email = email
+		// the provisioning system is the source of truth for the email address
+		user.EmailVerified = true
+	}
+	user.ExternalId = scimUser.ExternalId
+	user.GivenName = inputSanitizer.Sanitize(name.GivenName)
+	user.MiddleName = inputSanitizer.Sanitize(name.MiddleName)
+	user.FamilyName = inputSanitizer.Sanitize(name.FamilyName)
+	user.Nickname = inputSanitizer.Sanitize(scimUser.NickName)
+	user.Website = inputSanitizer.Sanitize(scimUser.ProfileUrl)
+	user.Locale = scimUser.Locale
+	user.ZoneInfo = scimUser.Timezone
+	user.ZoneInfoCountryName = ""
+	for _, tz := range lib.GetTimeZones() {
+		if tz.Zone == user.ZoneInfo {
+			user.ZoneInfoCountryName = tz.CountryName
+			break
+		}
+	}
+	if phoneNumber != user.PhoneNumber {
+		user.PhoneNumber = phoneNumber
+		user.PhoneNumberVerified = false
+	}
+	user.AddressLine1 = inputSanitizer.Sanitize(addressInput.AddressLine1)
+	user.AddressLine2 = inputSanitizer.Sanitize(addressInput.AddressLine2)
+	user.AddressLocality = inputSanitizer.Sanitize(addressInput.AddressLocality)
+	user.AddressRegion = inputSanitizer.Sanitize(addressInput.AddressRegion)
+	user.AddressPostalCode = inputSanitizer.Sanitize(addressInput.AddressPostalCode)
+	user.AddressCountry = addressInput.AddressCountry
+	if scimUser.Active != nil {
+		user.Enabled = *scimUser.Active
+	}
+	return nil
+}
+
+func (s *Server) handleScimUsersGet() http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		params, err := parseScimListParameters(r)
+		if err != nil {
+			s.scimError(w, r, err)
+			return
+		}
+
+		if params.filter == nil && params.count > 0 && (params.startIndex-1)%params.count == 0 {
+			// no filter: the page can be read directly from the database
+			users, total, err := s.database.SearchUsersPaginated(nil, "", (params.startIndex-1)/params.count+1, params.count)
+			if err != nil {
+				s.scimError(w, r, err)
+				return
+			}
+			err = s.database.UsersLoadGroups(nil, users)
+			if err != nil {
+				s.scimError(w, r, err)
+				return
+			}
+			resources := []map[string]any{}
+			for idx := range users {
+				resource, err := core_scim.ToMap(core_scim.NewUser(&users[idx], lib.GetBaseUrl()))
+				if err != nil {
+					s.scimError(w, r, err)
+					return
+				}
+				core_scim.SetVersion(resource)
+				resources = append(resources, core_scim.ProjectAttributes(resource, params.attributes, params.excludedAttributes))
+			}
+			writeScimResponse(w, http.StatusOK, core_scim.NewListResponse(resources, total, params.startIndex))
+			return
+		}
+
+		collector := &scimResourceCollector{params: params}
+
+		addUser := func(user *entities.User) error {
+			resource, err := s.getScimUserResource(user)
+			if err != nil {
+				return err
+			}
+			collector.add(resource)
+			return nil
+		}
+
+		// lookups by userName or id don't need a scan
+		if params.filter != nil {
+			for _, attribute := range []string{"userName", "emails.value", "emails", "id"} {
+				value, ok := core_scim.GetEqualityValue(params.filter, attribute)
+				if !ok {
+					continue
+				}
+				var user *entities.User
+				if attribute == "id" {
+					user, err = s.database.GetUserBySubject(nil, value)
+				} else {
+					user, err = s.database.GetUserByEmail(nil, strings.ToLower(strings.TrimSpace(value)))
+				}
+				if err != nil {
+					s.scimError(w, r, err)
+					return
+				}
+				if user != nil {
+					err = addUser(user)
+					if err != nil {
+						s.scimError(w, r, err)
+						return
+					}
+				}
+				writeScimResponse(w, http.StatusOK, collector.listResponse())
+				return
+			}
+		}
+
+		for page := 1; ; page++ {
+			users, _, err := s.database.SearchUsersPaginated(nil, "", page, scimPageSize)
+			if err != nil {
+				s.scimError(w, r, err)
+				return
+			}
+			if len(users) == 0 {
+				break
+			}
+			err = s.database.UsersLoadGroups(nil, users)
+			if err != nil {
+				s.scimError(w, r, err)
+				return
+			}
+			for idx := range users {
+				resource, err := core_scim.ToMap(core_scim.NewUser(&users[idx], lib.GetBaseUrl()))
+				if err != nil {
+					s.scimError(w, r, err)
+					return
+				}
+				collector.add(resource)
+			}
+			if len(users) < scimPageSize {
+				break
+			}
+		}
+
+		writeScimResponse(w, http.StatusOK, collector.listResponse())
+	}
+}
+
+func (s *Server) handleScimUserGet() http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		user, resource := s.getScimUser(w, r)
+		if user == nil {
+			return
+		}
+
+		if isScimNotModified(w, r, resource) {
+			return
+		}
+		writeScimResource(w, r, http.StatusOK, resource)
+	}
+}
+
+func (s *Server) handleScimUsersPost(
+	userCreator userCreator,
+	profileValidator profileValidator,
+	emailValidator emailValidator,
+	addressValidator addressValidator,
+	passwordValidator passwordValidator,
+	userPasswordManager userPasswordManager,
+	inputSanitizer inputSanitizer,
+) http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		resource, err := readScimResource(w, r)
+		if err != nil {
+			s.scimError(w, r, err)
+			return
+		}
+
+		scimUser, err := core_scim.DecodeUser(resource)
+		if err != nil {
+			s.scimError(w, r, err)
+			return
+		}
+
+		// the attributes are validated before the user is created
+		newUser := &entities.User{Enabled: true}
+		err = s.applyScimUser(r, newUser, scimUser, profileValidator, emailValidator, addressValidator,
+			passwordValidator, userPasswordManager, inputSanitizer)
+		if err != nil {
+			s.scimError(w, r, err)
+			return
+		}
+
+		user, err := userCreator.CreateUser(r.Context(), &core.CreateUserInput{
+			Email:         newUser.Email,
+			EmailVerified: true,
+			PasswordHash:  newUser.PasswordHash,
+			GivenName:     newUser.GivenName,
+			MiddleName:    newUser.MiddleName,
+			FamilyName:    newUser.FamilyName,
+		})
+		if err != nil {
+			s.scimError(w, r, err)
+			return
+		}
+
+		newUser.Id = user.Id
+		newUser.Subject = user.Subject
+		newUser.CreatedAt = user.CreatedAt
+		err = s.database.UpdateUser(nil, newUser)
+		if err != nil {
+			s.scimError(w, r, err)
+			return
+		}
+
+		lib.LogAudit(r.Context(), constants.AuditCreatedUser, map[string]interface{}{
+			"email":      newUser.Email,
+			"scimClient": getScimClient(r),
+		})
+		s.publishUserEvent(constants.WebhookEventUserCreated, newUser, nil)
+
+		resource, err = s.getScimUserResource(newUser)
+		if err != nil {
+			s.scimError(w, r, err)
+			return
+		}
+		writeScimResource(w, r, http.StatusCreated, resource)
+	}
+}
+
+func (s *Server) updateScimUser(w http.ResponseWriter, r *http.Request, user *entities.User, scimUser *core_scim.User,
+	profileValidator profileValidator, emailValidator emailValidator, addressValidator addressValidator,
+	passwordValidator passwordValidator, userPasswordManager userPasswordManager, inputSanitizer inputSanitizer) {
+
+	oldEmail := user.Email
+	oldPasswordHash := user.PasswordHash
+	wasEnabled := user.Enabled
+	err := s.applyScimUser(r, user, scimUser, profileValidator, emailValidator, addressValidator,
+		passwordValidator, userPasswordManager, inputSanitizer)
+	if err != nil {
+		s.scimError(w, r, err)
+		return
+	}
+
+	err = s.database.UpdateUser(nil, user)
+	if err != nil {
+		s.scimError(w, r, err)
+		return
+	}
+
+	lib.LogAudit(r.Context(), constants.AuditUpdatedUserDetails, map[string]interface{}{
+		"userId":     user.Id,
+		"scimClient": getScimClient(r),
+	})
+	if oldPasswordHash != user.PasswordHash {
+		lib.LogAudit(r.Context(), constants.AuditChangedPassword, map[string]interface{}{
+			"userId":     user.Id,
+			"scimClient": getScimClient(r),
+		})
+		s.sendSecurityNotification(r, user, constants.AuditChangedPassword)
+	}
+	if oldEmail != user.Email {
+		s.notifyEmailChange(r, user, oldEmail)
+	}
+	s.publishUserUpdatedEvent(wasEnabled, user)
+
+	resource, err := s.getScimUserResource(user)
+	if err != nil {
+		s.scimError(w, r, err)
+		return
+	}
+	writeScimResource(w, r, http.StatusOK, resource)
+}
+
+func (s *Server) handleScimUserPut(
+	profileValidator profileValidator,
+	emailValidator emailValidator,
+	addressValidator addressValidator,
+	passwordValidator passwordValidator,
+	userPasswordManager userPasswordManager,
+	inputSanitizer inputSanitizer,
+) http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		user, currentResource := s.getScimUser(w, r)
+		if user == nil {
+			return
+		}
+
+		err := checkScimPrecondition(r, currentResource)
+		if err != nil {
+			s.scimError(w, r, err)
+			return
+		}
+
+		resource, err := readScimResource(w, r)
+		if err != nil {
+			s.scimError(w, r, err)
+			return
+		}
+
+		scimUser, err := core_scim.DecodeUser(resource)
+		if err != nil {
+			s.scimError(w, r, err)
+			return
+		}
+
+		s.updateScimUser(w, r, user, scimUser, profileValidator, emailValidator, addressValidator,
+			passwordValidator, userPasswordManager, inputSanitizer)
+	}
+}
+
+func (s *Server) handleScimUserPatch(
+	profileValidator profileValidator,
+	emailValidator emailValidator,
+	addressValidator addressValidator,
+	passwordValidator passwordValidator,
+	userPasswordManager userPasswordManager,
+	inputSanitizer inputSanitizer,
+) http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		user, resource := s.getScimUser(w, r)
+		if user == nil {
+			return
+		}
+
+		err := checkScimPrecondition(r, resource)
+		if err != nil {
+			s.scimError(w, r, err)
+			return
+		}
+
+		patchRequest, err := readScimPatchRequest(w, r)
+		if err != nil {
+			s.scimError(w, r, err)
+			return
+		}
+
+		err = core_scim.ApplyPatch(resource, patchRequest.Operations, "id", "meta", "groups")
+		if err != nil {
+			s.scimError(w, r, err)
+			return
+		}
+
+		scimUser, err := core_scim.DecodeUser(resource)
+		if err != nil {
+			s.scimError(w, r, err)
+			return
+		}
+
+		s.updateScimUser(w, r, user, scimUser, profileValidator, emailValidator, addressValidator,
+			passwordValidator, userPasswordManager, inputSanitizer)
+	}
+}
+
+func (s *Server) handleScimUserDelete() http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		user, resource := s.getScimUser(w, r)
+		if user == nil {
+			return
+		}
+
+		err := checkScimPrecondition(r, resource)
+		if err != nil {
+			s.scimError(w, r, err)
+			return
+		}
+
+		err = s.checkApiUserPermissionScopes(r, user)
+		if err != nil {
+			s.scimError(w, r, err)
+			return
+		}
+
+		err = s.database.DeleteUser(nil, user.Id)
+		if err != nil {
+			s.scimError(w, r, err)
+			return
+		}
+
+		lib.LogAudit(r.Context(), constants.AuditDeletedUser, map[string]interface{}{
+			"userId":     user.Id,
+			"scimClient": getScimClient(r),
+		})
+		s.publishUserEvent(constants.WebhookEventUserDeleted, user, nil)
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
@@ -49,9 +49,11 @@ func (s *Server) handleTokenPost(tokenIssuer tokenIssuer, tokenValidator tokenVa
 				return
 			}
 
-			lib.LogAudit(constants.AuditTokenIssuedAuthorizationCodeResponse, map[string]interface{}{
+			auditDetails := map[string]interface{}{
 				"codeId": validateTokenRequestResult.CodeEntity.Id,
-			})
+			}
+			addImpersonationAuditDetails(auditDetails, validateTokenRequestResult.CodeEntity)
+			lib.LogAudit(r.Context(), constants.AuditTokenIssuedAuthorizationCodeResponse, auditDetails)
 
 			w.Header().Set("Content-Type", "application/json")
 			w.Header().Set("Cache-Control", "no-store")
@@ -68,7 +70,7 @@ func (s *Server) handleTokenPost(tokenIssuer tokenIssuer, tokenValidator tokenVa
 				return
 			}
 
-			lib.LogAudit(constants.AuditTokenIssuedClientCredentialsResponse, map[string]interface{}{
+			lib.LogAudit(r.Context(), constants.AuditTokenIssuedClientCredentialsResponse, map[string]interface{}{
 				"clientId": validateTokenRequestResult.Client.Id,
 			})
 
@@ -114,10 +116,12 @@ func (s *Server) handleTokenPost(tokenIssuer tokenIssuer, tokenValidator tokenVa
 				}
 			}
 
-			lib.LogAudit(constants.AuditTokenIssuedRefreshTokenResponse, map[string]interface{}{
+			auditDetails := map[string]interface{}{
 				"codeId":          validateTokenRequestResult.CodeEntity.Id,
 				"refreshTokenJti": validateTokenRequestResult.RefreshToken.RefreshTokenJti,
-			})
+			}
+			addImpersonationAuditDetails(auditDetails, validateTokenRequestResult.CodeEntity)
+			lib.LogAudit(r.Context(), constants.AuditTokenIssuedRefreshTokenResponse, auditDetails)
 
 			w.Header().Set("Content-Type", "application/json")
 			w.Header().Set("Cache-Control", "no-store")
@@ -2,6 +2,7 @@ package server
 
 import (
 	"bytes"
+	"database/sql"
 	"encoding/json"
 	"fmt"
 	"html/template"
@@ -58,6 +59,10 @@ func (s *Server) includeLeftPanelImage(templateName string) bool {
 		"/auth_pwd.html",
 		"/auth_otp.html",
 		"/auth_otp_enrollment.html",
+		"/auth_otp_recovery_codes.html",
+		"/auth_otp_sms.html",
+		"/auth_email.html",
+		"/auth_change_password.html",
 		"/forgot_password.html",
 		"/reset_password.html",
 		"/consent.html",
@@ -120,6 +125,14 @@ func (s *Server) renderTemplateToBuffer(r *http.Request, layoutName string, temp
 		}
 	}
 
+	impersonation, err := s.getImpersonationBanner(r)
+	if err != nil {
+		return nil, err
+	}
+	if impersonation != nil {
+		data["impersonation"] = impersonation
+	}
+
 	if s.includeLeftPanelImage(templateName) {
 		leftPanelImage, err := s.getRandomStaticFile("images/left-panel")
 		if err != nil {
@@ -262,7 +275,7 @@ func (s *Server) isAuthorizedToAccessResource(jwtInfo dtos.JwtInfo, scopesAnyOf
 	if jwtInfo.AccessToken != nil && jwtInfo.AccessToken.SignatureIsValid {
 		acrLevel := jwtInfo.AccessToken.GetAcrLevel()
 		if acrLevel != nil &&
-			(*acrLevel == enums.AcrLevel2 || *acrLevel == enums.AcrLevel3) {
+			(*acrLevel == enums.AcrLevel2 || *acrLevel == enums.AcrLevel3 || *acrLevel == enums.AcrLevel4) {
 			for _, scope := range scopesAnyOf {
 				if jwtInfo.AccessToken.HasScope(scope) {
 					return true
@@ -321,6 +334,266 @@ func (s *Server) redirToAuthorize(w http.ResponseWriter, r *http.Request, client
 	http.Redirect(w, r, destUrl, http.StatusFound)
 }
 
+// completeFirstFactorAuth continues the authentication flow once the user has been
+// identified by a first factor (password, federated login). It redirects to the second factor
+// (OTP or passkey) when the target ACR level requires it, otherwise it starts a new user session and
+// redirects to the consent page.
+func (s *Server) completeFirstFactorAuth(w http.ResponseWriter, r *http.Request, loginManager loginManager,
+	authContext *dtos.AuthContext, user *entities.User, authMethod enums.AuthMethod) error {
+
+	sessionIdentifier := ""
+	if r.Context().Value(common.ContextKeySessionIdentifier) != nil {
+		sessionIdentifier = r.Context().Value(common.ContextKeySessionIdentifier).(string)
+	}
+
+	userSession, err := s.database.GetUserSessionBySessionIdentifier(nil, sessionIdentifier)
+	if err != nil {
+		return err
+	}
+
+	err = s.database.UserSessionLoadUser(nil, userSession)
+	if err != nil {
+		return err
+	}
+	if userSession != nil {
+		err = s.database.UserLoadPasskeys(nil, &userSession.User)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = s.database.UserLoadPasskeys(nil, user)
+	if err != nil {
+		return err
+	}
+
+	client, err := s.database.GetClientByClientIdentifier(nil, authContext.ClientId)
+	if err != nil {
+		return err
+	}
+	if client == nil {
+		return errors.WithStack(errors.New(fmt.Sprintf("client %v not found", authContext.ClientId)))
+	}
+
+	targetAcrLevel := authContext.GetTargetAcrLevel(client.DefaultAcrLevel)
+
+	// the first factor is remembered so the second factor step can record it in the amr
+	authContext.AuthMethods = authMethod.String()
+
+	trustedDevice, err := s.getTrustedDevice(r, user.Id)
+	if err != nil {
+		return err
+	}
+
+	hasValidUserSession := loginManager.HasValidUserSession(r.Context(), userSession, authContext.GetMaxAgeInSeconds())
+	if hasValidUserSession {
+
+		mustPerformOTPAuth := loginManager.MustPerformOTPAuth(r.Context(), client, userSession, targetAcrLevel, trustedDevice)
+		if mustPerformOTPAuth {
+			authContext.UserId = user.Id
+			err = s.saveAuthContext(w, r, authContext)
+			if err != nil {
+				return err
+			}
+			http.Redirect(w, r, secondFactorUrl(user, targetAcrLevel), http.StatusFound)
+			return nil
+		}
+
+	}
+
+	// an unusual login must be confirmed with the second factor of the user, whatever the ACR level.
+	// Users without a second factor can't be asked for one.
+	riskStepUp := authContext.RiskStepUp && (user.OTPEnabled || len(user.Passkeys) > 0)
+
+	// if the client accepts AcrLevel1 that means only the first factor is sufficient to authenticate
+	// no need to check anything else
+
+	if targetAcrLevel != enums.AcrLevel1 || riskStepUp {
+
+		// optional: the system will offer a second factor if the user has OTP enabled, passkeys or
+		// a verified phone number to receive the OTP by SMS, unless the user trusted this device
+		settings := r.Context().Value(common.ContextKeySettings).(*entities.Settings)
+		optional2fa := targetAcrLevel == enums.AcrLevel2 &&
+			(user.OTPEnabled || len(user.Passkeys) > 0 || isSMSOTPAvailable(settings, user, targetAcrLevel))
+		if optional2fa && !riskStepUp && loginManager.IsTrustedDevice(trustedDevice, user.Id, targetAcrLevel) {
+			optional2fa = false
+
+			trustedDevice.LastUsedAt = sql.NullTime{Time: time.Now().UTC(), Valid: true}
+			err = s.database.UpdateUserTrustedDevice(nil, trustedDevice)
+			if err != nil {
+				return err
+			}
+		}
+
+		// mandatory: if target acr is level 3 we'll force an OTP auth, and level 4 requires a passkey
+		mandatory2fa := targetAcrLevel == enums.AcrLevel3 || targetAcrLevel == enums.AcrLevel4 || riskStepUp
+
+		if optional2fa || mandatory2fa {
+			authContext.UserId = user.Id
+			err = s.saveAuthContext(w, r, authContext)
+			if err != nil {
+				return err
+			}
+			http.Redirect(w, r, secondFactorUrl(user, targetAcrLevel), http.StatusFound)
+			return nil
+		}
+	}
+
+	// user is fully authenticated
+
+	mustChangePassword, err := s.mustChangePassword(w, r, authContext, user, authMethod.String(), targetAcrLevel)
+	if err != nil {
+		return err
+	}
+	if mustChangePassword {
+		http.Redirect(w, r, lib.GetBaseUrl()+"/auth/change-password", http.StatusFound)
+		return nil
+	}
+
+	// start new session
+
+	_, err = s.startNewUserSession(w, r, user.Id, client.Id, authMethod.String(), targetAcrLevel.String())
+	if err != nil {
+		return err
+	}
+
+	// redirect to consent
+	authContext.UserId = user.Id
+	err = authContext.SetAcrLevel(targetAcrLevel, userSession)
+	if err != nil {
+		return err
+	}
+	authContext.AuthTime = time.Now().UTC()
+	authContext.AuthCompleted = true
+	err = s.saveAuthContext(w, r, authContext)
+	if err != nil {
+		return err
+	}
+
+	http.Redirect(w, r, lib.GetBaseUrl()+"/auth/consent", http.StatusFound)
+	return nil
+}
+
+// completeSecondFactorAuth starts a new user session once the user has authenticated with the
+// second factor, and redirects to the consent page.
+func (s *Server) completeSecondFactorAuth(w http.ResponseWriter, r *http.Request, authContext *dtos.AuthContext,
+	user *entities.User, targetAcrLevel enums.AcrLevel, secondFactor enums.AuthMethod) error {
+
+	redirectUrl, err := s.finishSecondFactorAuth(w, r, authContext, user, targetAcrLevel, secondFactor)
+	if err != nil {
+		return err
+	}
+	http.Redirect(w, r, redirectUrl, http.StatusFound)
+	return nil
+}
+
+// finishSecondFactorAuth is completeSecondFactorAuth without the redirect. It returns the URL where the
+// authentication continues, for the handlers that display a page before.
+func (s *Server) finishSecondFactorAuth(w http.ResponseWriter, r *http.Request, authContext *dtos.AuthContext,
+	user *entities.User, targetAcrLevel enums.AcrLevel, secondFactor enums.AuthMethod) (string, error) {
+
+	client, err := s.database.GetClientByClientIdentifier(nil, authContext.ClientId)
+	if err != nil {
+		return "", err
+	}
+	if client == nil {
+		return "", errors.WithStack(errors.New(fmt.Sprintf("client %v not found", authContext.ClientId)))
+	}
+
+	// the first factor (pwd, fed, email) was recorded in the auth context before the second factor step
+	firstFactor := enums.AuthMethodPassword.String()
+	if fields := strings.Fields(authContext.AuthMethods); len(fields) > 0 {
+		firstFactor = fields[0]
+	}
+	authMethods := firstFactor + " " + secondFactor.String()
+
+	mustChangePassword, err := s.mustChangePassword(w, r, authContext, user, authMethods, targetAcrLevel)
+	if err != nil {
+		return "", err
+	}
+	if mustChangePassword {
+		return lib.GetBaseUrl() + "/auth/change-password", nil
+	}
+
+	// start new session
+	_, err = s.startNewUserSession(w, r, user.Id, client.Id, authMethods, targetAcrLevel.String())
+	if err != nil {
+		return "", err
+	}
+
+	// continue to consent
+	authContext.AcrLevel = targetAcrLevel.String()
+	authContext.AuthMethods = authMethods
+	authContext.AuthTime = time.Now().UTC()
+	authContext.AuthCompleted = true
+	err = s.saveAuthContext(w, r, authContext)
+	if err != nil {
+		return "", err
+	}
+
+	return lib.GetBaseUrl() + "/auth/consent", nil
+}
+
+// mustChangePassword returns true when the user signed in with a password that was flagged as breached
+// or that is older than the maximum password age, and must choose a new one before the session starts.
+// The auth methods and the ACR level are kept in the auth context, to complete the authentication once
+// the password is changed.
+func (s *Server) mustChangePassword(w http.ResponseWriter, r *http.Request, authContext *dtos.AuthContext,
+	user *entities.User, authMethods string, targetAcrLevel enums.AcrLevel) (bool, error) {
+
+	if !slices.Contains(strings.Fields(authMethods), enums.AuthMethodPassword.String()) {
+		return false, nil
+	}
+
+	settings := r.Context().Value(common.ContextKeySettings).(*entities.Settings)
+	passwordBreached := settings.ForceChangeOfBreachedPasswords && user.PasswordBreached
+	if !passwordBreached && !user.IsPasswordExpired(settings.PasswordMaxAgeInDays) {
+		return false, nil
+	}
+
+	authContext.UserId = user.Id
+	authContext.AuthMethods = authMethods
+	authContext.AcrLevel = targetAcrLevel.String()
+	authContext.PasswordChangeRequired = true
+	err := s.saveAuthContext(w, r, authContext)
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func getClientIpAddress(r *http.Request) string {
+	ipWithoutPort, _, _ := net.SplitHostPort(r.RemoteAddr)
+	if len(ipWithoutPort) == 0 {
+		ipWithoutPort = r.RemoteAddr
+	}
+	return ipWithoutPort
+}
+
+// getTargetAcrLevel returns the first ACR level requested by the client, or the default ACR level of the client,
+// raised to the ACR level required by the resources and permissions in the scope.
+func (s *Server) getTargetAcrLevel(authContext *dtos.AuthContext) (enums.AcrLevel, error) {
+	client, err := s.database.GetClientByClientIdentifier(nil, authContext.ClientId)
+	if err != nil {
+		return "", err
+	}
+	if client == nil {
+		return "", errors.WithStack(errors.New(fmt.Sprintf("client %v not found", authContext.ClientId)))
+	}
+
+	return authContext.GetTargetAcrLevel(client.DefaultAcrLevel), nil
+}
+
+// secondFactorUrl returns the page of the second factor. Passkeys are preferred when the user has
+// registered any, and the passkey ACR level can only be satisfied with a passkey. The passkeys of
+// the user must be loaded.
+func secondFactorUrl(user *entities.User, targetAcrLevel enums.AcrLevel) string {
+	if targetAcrLevel == enums.AcrLevel4 || len(user.Passkeys) > 0 {
+		return lib.GetBaseUrl() + "/auth/passkey"
+	}
+	return lib.GetBaseUrl() + "/auth/otp"
+}
+
 func (s *Server) startNewUserSession(w http.ResponseWriter, r *http.Request,
 	userId int64, clientId int64, authMethods string, acrLevel string) (*entities.UserSession, error) {
 
@@ -375,6 +648,21 @@ func (s *Server) startNewUserSession(w http.ResponseWriter, r *http.Request,
 		return nil, err
 	}
 
+	// the user is fully authenticated, so the failed login attempts are cleared
+	user, err := s.database.GetUserById(nil, userId)
+	if err != nil {
+		return nil, err
+	}
+	if user != nil && (user.FailedLoginAttempts > 0 || user.LastFailedLoginAt.Valid || user.LockedUntil.Valid) {
+		user.FailedLoginAttempts = 0
+		user.LastFailedLoginAt = sql.NullTime{Valid: false}
+		user.LockedUntil = sql.NullTime{Valid: false}
+		err = s.database.UpdateUser(nil, user)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	allUserSessions, err := s.database.GetUserSessionsByUserId(nil, userId)
 	if err != nil {
 		return nil, err
@@ -405,11 +693,19 @@ func (s *Server) startNewUserSession(w http.ResponseWriter, r *http.Request,
 		return nil, err
 	}
 
-	lib.LogAudit(constants.AuditStartedNewUserSesson, map[string]interface{}{
+	lib.LogAudit(r.Context(), constants.AuditStartedNewUserSesson, map[string]interface{}{
 		"userId":   userId,
 		"clientId": clientId,
 	})
 
+	newDevice, err := s.registerKnownDevice(userSession)
+	if err != nil {
+		return nil, err
+	}
+	if newDevice && user != nil {
+		s.sendSecurityNotification(r, user, constants.AuditStartedNewUserSesson)
+	}
+
 	return userSession, nil
 }
 
@@ -497,7 +793,7 @@ func (s *Server) bumpUserSession(r *http.Request, sessionIdentifier string, clie
 			return nil, err
 		}
 
-		lib.LogAudit(constants.AuditBumpedUserSession, map[string]interface{}{
+		lib.LogAudit(r.Context(), constants.AuditBumpedUserSession, map[string]interface{}{
 			"userId":   userSession.UserId,
 			"clientId": clientId,
 		})
@@ -0,0 +1,79 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/csrf"
+	"github.com/gorilla/sessions"
+	"github.com/leodip/goiabada/internal/common"
+	"github.com/leodip/goiabada/internal/constants"
+	"github.com/leodip/goiabada/internal/data"
+	"github.com/leodip/goiabada/internal/entities"
+	"github.com/leodip/goiabada/internal/lib"
+	"github.com/pkg/errors"
+)
+
+// endImpersonation deletes the impersonated session and restores the session of the admin. The JWT in
+// the session belongs to the impersonated user, so it's removed as well. The caller saves the session.
+func endImpersonation(ctx context.Context, database data.Database, sess *sessions.Session, userSession *entities.UserSession) error {
+
+	err := database.DeleteUserSession(nil, userSession.Id)
+	if err != nil {
+		return err
+	}
+
+	if impersonatorSessionIdentifier, ok := sess.Values[common.SessionKeyImpersonatorSessionIdentifier].(string); ok &&
+		len(impersonatorSessionIdentifier) > 0 {
+		sess.Values[common.SessionKeySessionIdentifier] = impersonatorSessionIdentifier
+	} else {
+		delete(sess.Values, common.SessionKeySessionIdentifier)
+	}
+	delete(sess.Values, common.SessionKeyImpersonatorSessionIdentifier)
+	delete(sess.Values, common.SessionKeyJwt)
+
+	lib.LogAudit(ctx, constants.AuditEndedImpersonation, map[string]interface{}{
+		"userId":             userSession.UserId,
+		"impersonatorUserId": userSession.ImpersonatorUserId.Int64,
+		"expired":            userSession.IsImpersonationExpired(),
+	})
+	return nil
+}
+
+// addImpersonationAuditDetails adds the admin to the audit details of tokens issued for impersonated sessions.
+func addImpersonationAuditDetails(auditDetails map[string]interface{}, code *entities.Code) {
+	if len(code.ImpersonatorSubject) > 0 {
+		auditDetails["userId"] = code.UserId
+		auditDetails["impersonatorSubject"] = code.ImpersonatorSubject
+	}
+}
+
+// getImpersonationBanner returns the data of the banner displayed in the pages during an impersonation,
+// or nil when the session is not impersonated.
+func (s *Server) getImpersonationBanner(r *http.Request) (map[string]interface{}, error) {
+
+	userSession, ok := r.Context().Value(common.ContextKeyImpersonatedUserSession).(*entities.UserSession)
+	if !ok || userSession == nil {
+		return nil, nil
+	}
+
+	user, err := s.database.GetUserById(nil, userSession.UserId)
+	if err != nil {
+		return nil, err
+	}
+	impersonator, err := s.database.GetUserById(nil, userSession.ImpersonatorUserId.Int64)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil || impersonator == nil {
+		return nil, errors.WithStack(errors.New("unable to find the users of the impersonated session"))
+	}
+
+	return map[string]interface{}{
+		"userEmail":         user.Email,
+		"impersonatorEmail": impersonator.Email,
+		"expiresAt":         userSession.ImpersonationExpiresAt.Time.Format(time.RFC1123),
+		"csrfField":         csrf.TemplateField(r),
+	}, nil
+}
@@ -2,11 +2,20 @@ package server
 
 import (
 	"context"
-
+	"database/sql"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/crewjam/saml"
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/golang-jwt/jwt/v5"
 	"github.com/leodip/goiabada/internal/core"
 	core_authorize "github.com/leodip/goiabada/internal/core/authorize"
+	core_federation "github.com/leodip/goiabada/internal/core/federation"
 	core_senders "github.com/leodip/goiabada/internal/core/senders"
 	core_token "github.com/leodip/goiabada/internal/core/token"
+	core_users "github.com/leodip/goiabada/internal/core/users"
 	core_validators "github.com/leodip/goiabada/internal/core/validators"
 	"github.com/leodip/goiabada/internal/dtos"
 	"github.com/leodip/goiabada/internal/entities"
@@ -17,6 +26,33 @@ type otpSecretGenerator interface {
 	GenerateOTPSecret(user *entities.User, settings *entities.Settings) (string, string, error)
 }
 
+type otpRecoveryCodeManager interface {
+	GenerateRecoveryCodes(user *entities.User) ([]string, error)
+	UseRecoveryCode(user *entities.User, code string) bool
+}
+
+type breachedPasswordChecker interface {
+	IsBreached(password string) (bool, error)
+	ImportCorpus(reader io.ReadSeeker, fileName string) (int64, error)
+	CountHashes() (int, error)
+	ClearCorpus() error
+}
+
+type stepUpResolver interface {
+	GetRequirements(scope string) (*core.StepUpRequirements, error)
+}
+
+type riskEngine interface {
+	AssessLogin(ctx context.Context, input *core.LoginRiskInput) (*core.LoginRiskAssessment, error)
+}
+
+type loginLockoutManager interface {
+	CheckLogin(ctx context.Context, user *entities.User, ipAddress string) (*core.LoginCheckResult, error)
+	RegisterFailedLogin(ctx context.Context, user *entities.User, ipAddress string) (userLocked bool, ipAddressLocked bool, err error)
+	UnlockUser(user *entities.User) error
+	UnlockIpAddress(failedLoginIpId int64) error
+}
+
 type tokenIssuer interface {
 	GenerateTokenResponseForAuthCode(ctx context.Context, input *core_token.GenerateTokenResponseForAuthCodeInput) (*dtos.TokenResponse, error)
 	GenerateTokenResponseForClientCred(ctx context.Context, client *entities.Client, scope string) (*dtos.TokenResponse, error)
@@ -37,7 +73,9 @@ type loginManager interface {
 	HasValidUserSession(ctx context.Context, userSession *entities.UserSession, requestedMaxAgeInSeconds *int) bool
 
 	MustPerformOTPAuth(ctx context.Context, client *entities.Client, userSession *entities.UserSession,
-		targetAcrLevel enums.AcrLevel) bool
+		targetAcrLevel enums.AcrLevel, trustedDevice *entities.UserTrustedDevice) bool
+
+	IsTrustedDevice(trustedDevice *entities.UserTrustedDevice, userId int64, targetAcrLevel enums.AcrLevel) bool
 }
 
 type tokenValidator interface {
@@ -58,6 +96,11 @@ type emailSender interface {
 	SendEmail(ctx context.Context, input *core_senders.SendEmailInput) error
 }
 
+type webhookPublisher interface {
+	PublishUserEvent(tx *sql.Tx, eventType string, user *entities.User, group *entities.Group) error
+	Redeliver(tx *sql.Tx, webhookDelivery *entities.WebhookDelivery) (*entities.WebhookDelivery, error)
+}
+
 type addressValidator interface {
 	ValidateAddress(ctx context.Context, input *core_validators.ValidateAddressInput) error
 }
@@ -71,7 +114,12 @@ type smsSender interface {
 }
 
 type passwordValidator interface {
-	ValidatePassword(ctx context.Context, password string) error
+	ValidatePassword(ctx context.Context, password string, user *entities.User) error
+}
+
+type userPasswordManager interface {
+	SetPassword(ctx context.Context, user *entities.User, password string) error
+	GetPasswordChangeAllowedAt(ctx context.Context, user *entities.User) time.Time
 }
 
 type identifierValidator interface {
@@ -85,3 +133,48 @@ type inputSanitizer interface {
 type userCreator interface {
 	CreateUser(ctx context.Context, input *core.CreateUserInput) (*entities.User, error)
 }
+
+type oidcClient interface {
+	BuildAuthorizationURL(ctx context.Context, input *core_federation.BuildAuthorizationURLInput) (string, error)
+	ExchangeCode(ctx context.Context, input *core_federation.ExchangeCodeInput) (jwt.MapClaims, error)
+}
+
+type federatedUserResolver interface {
+	ResolveUser(ctx context.Context, idp *entities.IdentityProvider, identity *core_federation.FederatedIdentity) (*core_federation.ResolveUserResult, error)
+}
+
+type ldapAuthenticator interface {
+	Authenticate(ctx context.Context, idp *entities.IdentityProvider, email string, password string) (*core_federation.FederatedIdentity, error)
+}
+
+type samlServiceProvider interface {
+	GetServiceProviderMetadata(idp *entities.IdentityProvider) ([]byte, error)
+	BuildAuthnRequestURL(idp *entities.IdentityProvider, relayState string) (string, string, error)
+	ParseResponse(r *http.Request, idp *entities.IdentityProvider, requestId string) (*core_federation.FederatedIdentity, error)
+}
+
+type samlMetadataLoader interface {
+	FetchMetadata(ctx context.Context, metadataURL string) ([]byte, error)
+	ParseMetadata(metadataXML []byte) (*saml.EntityDescriptor, error)
+}
+
+type passkeyManager interface {
+	BeginRegistration(ctx context.Context, user *entities.User) (*protocol.CredentialCreation, string, error)
+	FinishRegistration(ctx context.Context, user *entities.User, ceremony string, name string, r *http.Request) (*entities.UserPasskey, error)
+	BeginLogin(ctx context.Context, user *entities.User) (*protocol.CredentialAssertion, string, error)
+	FinishLogin(ctx context.Context, user *entities.User, ceremony string, r *http.Request) error
+	BeginDiscoverableLogin(ctx context.Context) (*protocol.CredentialAssertion, string, error)
+	FinishDiscoverableLogin(ctx context.Context, ceremony string, r *http.Request) (*entities.User, error)
+}
+
+type keyRotator interface {
+	RotateKeys() error
+}
+
+type userImporter interface {
+	Import(ctx context.Context, records []core_users.UserRecord, dryRun bool) (*core_users.ImportResult, error)
+}
+
+type userExporter interface {
+	Export(groupId int64) ([]core_users.UserRecord, error)
+}
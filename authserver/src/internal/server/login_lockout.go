@@ -0,0 +1,97 @@
+package server
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/leodip/goiabada/internal/common"
+	"github.com/leodip/goiabada/internal/constants"
+	core_senders "github.com/leodip/goiabada/internal/core/senders"
+	"github.com/leodip/goiabada/internal/entities"
+	"github.com/leodip/goiabada/internal/lib"
+)
+
+// checkLoginLockout returns the message to show when the login attempt (password or OTP) is rejected
+// because of the failed attempts of the user or the IP address. It returns an empty string when the
+// attempt is allowed. The user can be nil, when unknown.
+func (s *Server) checkLoginLockout(r *http.Request, loginLockoutManager loginLockoutManager,
+	user *entities.User) (string, error) {
+
+	ipAddress := getClientIpAddress(r)
+	result, err := loginLockoutManager.CheckLogin(r.Context(), user, ipAddress)
+	if err != nil {
+		return "", err
+	}
+	if result.Allowed() {
+		return "", nil
+	}
+
+	auditDetails := map[string]interface{}{
+		"ipAddress": ipAddress,
+	}
+	if user != nil {
+		auditDetails["userId"] = user.Id
+	}
+	lib.LogAudit(r.Context(), constants.AuditAuthBlockedLockout, auditDetails)
+
+	if result.IpAddressLocked || result.UserLocked {
+		return "Too many failed login attempts. Your account is temporarily locked, please try again later.", nil
+	}
+	return fmt.Sprintf("Too many failed login attempts. Please wait %v second(s) before trying again.",
+		int(result.RetryAfter.Seconds())), nil
+}
+
+// registerFailedLogin counts a failed password or OTP attempt. When the user gets locked, an email is
+// sent to let the user know.
+func (s *Server) registerFailedLogin(r *http.Request, loginLockoutManager loginLockoutManager,
+	emailSender emailSender, user *entities.User) error {
+
+	ipAddress := getClientIpAddress(r)
+	userLocked, ipAddressLocked, err := loginLockoutManager.RegisterFailedLogin(r.Context(), user, ipAddress)
+	if err != nil {
+		return err
+	}
+
+	if ipAddressLocked {
+		lib.LogAudit(r.Context(), constants.AuditLockedIpAddress, map[string]interface{}{
+			"ipAddress": ipAddress,
+		})
+	}
+
+	if !userLocked {
+		return nil
+	}
+
+	lib.LogAudit(r.Context(), constants.AuditLockedUser, map[string]interface{}{
+		"userId":    user.Id,
+		"ipAddress": ipAddress,
+	})
+
+	settings := r.Context().Value(common.ContextKeySettings).(*entities.Settings)
+	if !settings.SMTPEnabled || len(user.Email) == 0 {
+		return nil
+	}
+
+	bind := map[string]interface{}{
+		"name":           user.GetFullName(),
+		"ipAddress":      ipAddress,
+		"lockoutMinutes": (settings.LoginLockoutDurationInSeconds + 59) / 60,
+	}
+	buf, err := s.renderTemplateToBuffer(r, "/layouts/email_layout.html", "/emails/email_account_locked.html", bind)
+	if err != nil {
+		return err
+	}
+
+	input := &core_senders.SendEmailInput{
+		To:       user.Email,
+		Subject:  "Your account was temporarily locked",
+		HtmlBody: buf.String(),
+	}
+	err = emailSender.SendEmail(r.Context(), input)
+	if err != nil {
+		// the lockout is in place even if the notification can't be delivered
+		slog.Error(fmt.Sprintf("unable to send the account locked email to user %v: %+v", user.Id, err))
+	}
+	return nil
+}
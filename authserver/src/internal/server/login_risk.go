@@ -0,0 +1,95 @@
+package server
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/leodip/goiabada/internal/common"
+	"github.com/leodip/goiabada/internal/constants"
+	"github.com/leodip/goiabada/internal/core"
+	core_senders "github.com/leodip/goiabada/internal/core/senders"
+	"github.com/leodip/goiabada/internal/dtos"
+	"github.com/leodip/goiabada/internal/entities"
+	"github.com/leodip/goiabada/internal/lib"
+)
+
+const loginBlockedMessage = "This sign-in looks unusual and was blocked. Please contact the administrator."
+
+// assessLoginRisk scores the password login of the user. A login that looks unusual can be blocked,
+// or must be confirmed with the second factor (see authContext.RiskStepUp), and the user can be
+// notified. It returns true when the login is blocked.
+func (s *Server) assessLoginRisk(r *http.Request, riskEngine riskEngine, emailSender emailSender,
+	authContext *dtos.AuthContext, user *entities.User) (bool, error) {
+
+	ipAddress := getClientIpAddress(r)
+	riskAssessment, err := riskEngine.AssessLogin(r.Context(), &core.LoginRiskInput{
+		User:       user,
+		IpAddress:  ipAddress,
+		DeviceName: lib.GetDeviceName(r),
+		DeviceType: lib.GetDeviceType(r),
+		DeviceOS:   lib.GetDeviceOS(r),
+	})
+	if err != nil {
+		return false, err
+	}
+
+	if riskAssessment.Block || riskAssessment.StepUp || riskAssessment.Notify {
+		lib.LogAudit(r.Context(), constants.AuditRiskyLogin, map[string]interface{}{
+			"userId":    user.Id,
+			"ipAddress": ipAddress,
+			"score":     riskAssessment.Score,
+			"signals":   strings.Join(riskAssessment.Signals, " "),
+		})
+	}
+
+	if riskAssessment.Notify {
+		s.sendUnusualLoginEmail(r, emailSender, user, riskAssessment.Block)
+	}
+
+	if riskAssessment.Block {
+		lib.LogAudit(r.Context(), constants.AuditAuthBlockedRisk, map[string]interface{}{
+			"userId":    user.Id,
+			"ipAddress": ipAddress,
+		})
+		return true, nil
+	}
+	authContext.RiskStepUp = riskAssessment.StepUp
+	return false, nil
+}
+
+// sendUnusualLoginEmail lets the user know about a sign-in that looked unusual. Failing to send the
+// email doesn't stop the login.
+func (s *Server) sendUnusualLoginEmail(r *http.Request, emailSender emailSender, user *entities.User, blocked bool) {
+
+	settings := r.Context().Value(common.ContextKeySettings).(*entities.Settings)
+	if !settings.SMTPEnabled || len(user.Email) == 0 {
+		return
+	}
+
+	bind := map[string]interface{}{
+		"name":       user.GetFullName(),
+		"ipAddress":  getClientIpAddress(r),
+		"deviceName": lib.GetDeviceName(r),
+		"deviceOS":   lib.GetDeviceOS(r),
+		"time":       time.Now().UTC().Format(time.RFC1123),
+		"blocked":    blocked,
+	}
+	buf, err := s.renderTemplateToBuffer(r, "/layouts/email_layout.html", "/emails/email_unusual_login.html", bind)
+	if err != nil {
+		slog.Error(fmt.Sprintf("unable to render the unusual sign-in email: %+v", err))
+		return
+	}
+
+	input := &core_senders.SendEmailInput{
+		To:       user.Email,
+		Subject:  "Unusual sign-in to your account",
+		HtmlBody: buf.String(),
+	}
+	err = emailSender.SendEmail(r.Context(), input)
+	if err != nil {
+		slog.Error(fmt.Sprintf("unable to send the unusual sign-in email to user %v: %+v", user.Id, err))
+	}
+}
@@ -0,0 +1,43 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/leodip/goiabada/internal/common"
+	"github.com/leodip/goiabada/internal/constants"
+	core_api "github.com/leodip/goiabada/internal/core/api"
+	"github.com/leodip/goiabada/internal/dtos"
+	"github.com/leodip/goiabada/internal/enums"
+)
+
+// MiddlewareRequiresApiScope only lets through requests with a bearer access token carrying the
+// authserver permission of the admin API passed as parameter. Impersonated tokens are refused,
+// as the admin API is never available while impersonating a user.
+func MiddlewareRequiresApiScope(next http.Handler, permissionIdentifier string) http.HandlerFunc {
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		// refresh and ID tokens are signed with the same key, but only access tokens are accepted here
+		jwtToken, ok := r.Context().Value(common.ContextKeyJwtInfo).(dtos.JwtToken)
+		if !ok || jwtToken.GetStringClaim("typ") != enums.TokenTypeBearer.String() {
+			w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+			writeApiResponse(w, http.StatusUnauthorized, core_api.ErrorResponse{
+				Error:            "invalid_token",
+				ErrorDescription: "Access to this resource is denied. Please provide a valid access token in the Authorization header and try again.",
+			})
+			return
+		}
+
+		scope := constants.AuthServerResourceIdentifier + ":" + permissionIdentifier
+		if _, impersonated := jwtToken.Claims["act"]; impersonated || !jwtToken.HasScope(scope) {
+			w.Header().Set("WWW-Authenticate", `Bearer error="insufficient_scope", scope="`+scope+`"`)
+			writeApiResponse(w, http.StatusForbidden, core_api.ErrorResponse{
+				Error:            "insufficient_scope",
+				ErrorDescription: "The access token is not authorized to access this resource. The " + scope + " scope is required.",
+			})
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -15,8 +15,13 @@ func MiddlewareSkipCsrf() func(next http.Handler) http.Handler {
 			skip := false
 			if strings.HasPrefix(r.URL.Path, "/static") ||
 				strings.HasPrefix(r.URL.Path, "/userinfo") ||
+				strings.HasPrefix(r.URL.Path, "/scim/") ||
+				strings.HasPrefix(r.URL.Path, "/api/") ||
 				strings.HasPrefix(r.URL.Path, "/auth/token") ||
-				strings.HasPrefix(r.URL.Path, "/auth/callback") {
+				strings.HasPrefix(r.URL.Path, "/auth/callback") ||
+				(strings.HasPrefix(r.URL.Path, "/auth/federated/") &&
+					(strings.HasSuffix(r.URL.Path, "/saml/acs") || strings.HasSuffix(r.URL.Path, "/callback"))) {
+				// the SAML response is bound to the session by the relay state
 				skip = true
 			}
 			if skip {
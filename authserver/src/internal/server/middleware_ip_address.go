@@ -0,0 +1,20 @@
+package server
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/leodip/goiabada/internal/common"
+)
+
+// MiddlewareIpAddress adds the IP address of the client to the context, so it's recorded in the
+// audit events.
+func MiddlewareIpAddress() func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			ctx := context.WithValue(r.Context(), common.ContextKeyIpAddress, getClientIpAddress(r))
+			next.ServeHTTP(w, r.WithContext(ctx))
+		}
+		return http.HandlerFunc(fn)
+	}
+}
@@ -0,0 +1,38 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/leodip/goiabada/internal/common"
+	"github.com/leodip/goiabada/internal/constants"
+	core_scim "github.com/leodip/goiabada/internal/core/scim"
+	"github.com/leodip/goiabada/internal/dtos"
+	"github.com/leodip/goiabada/internal/enums"
+)
+
+// MiddlewareRequiresScimScope only lets through requests with a bearer access token carrying
+// the authserver:scim scope (usually obtained with the client credentials flow).
+func MiddlewareRequiresScimScope(next http.Handler) http.HandlerFunc {
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		// refresh and ID tokens are signed with the same key, but only access tokens are accepted here
+		jwtToken, ok := r.Context().Value(common.ContextKeyJwtInfo).(dtos.JwtToken)
+		if !ok || jwtToken.GetStringClaim("typ") != enums.TokenTypeBearer.String() {
+			w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+			writeScimResponse(w, http.StatusUnauthorized, core_scim.NewError(http.StatusUnauthorized, "",
+				"Access to this resource is denied. Please provide a valid access token in the Authorization header and try again."))
+			return
+		}
+
+		if !jwtToken.HasScope(constants.AuthServerResourceIdentifier + ":" + constants.ScimPermissionIdentifier) {
+			w.Header().Set("WWW-Authenticate", `Bearer error="insufficient_scope"`)
+			writeScimResponse(w, http.StatusForbidden, core_scim.NewError(http.StatusForbidden, "",
+				"The access token is not authorized to access this resource. The "+constants.AuthServerResourceIdentifier+
+					":"+constants.ScimPermissionIdentifier+" scope is required."))
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
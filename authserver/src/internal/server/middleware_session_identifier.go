@@ -5,11 +5,14 @@ import (
 	"fmt"
 	"log/slog"
 	"net/http"
+	"strings"
 
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/gorilla/sessions"
 	"github.com/leodip/goiabada/internal/common"
+	"github.com/leodip/goiabada/internal/constants"
 	"github.com/leodip/goiabada/internal/data"
+	"github.com/leodip/goiabada/internal/lib"
 )
 
 func MiddlewareSessionIdentifier(sessionStore sessions.Store, database data.Database) func(next http.Handler) http.Handler {
@@ -45,8 +48,37 @@ func MiddlewareSessionIdentifier(sessionStore sessions.Store, database data.Data
 						http.Error(w, errorMsg, http.StatusInternalServerError)
 						return
 					}
+				} else if userSession.IsImpersonationExpired() {
+					// the impersonation is over, back to the session of the admin
+					err = endImpersonation(ctx, database, sess, userSession)
+					if err != nil {
+						slog.Error(fmt.Sprintf("unable to end the impersonation: %+v", err), "request-id", requestId)
+						http.Error(w, errorMsg, http.StatusInternalServerError)
+						return
+					}
+					err = sess.Save(r, w)
+					if err != nil {
+						slog.Error(fmt.Sprintf("unable to save the session: %+v", err), "request-id", requestId)
+						http.Error(w, errorMsg, http.StatusInternalServerError)
+						return
+					}
+					if sess.Values[common.SessionKeySessionIdentifier] != nil {
+						ctx = context.WithValue(ctx, common.ContextKeySessionIdentifier, sess.Values[common.SessionKeySessionIdentifier].(string))
+					}
 				} else {
 					ctx = context.WithValue(ctx, common.ContextKeySessionIdentifier, sessionIdentifier)
+
+					if userSession.IsImpersonated() {
+						ctx = context.WithValue(ctx, common.ContextKeyImpersonatedUserSession, userSession)
+						if !strings.HasPrefix(r.URL.Path, "/static/") {
+							lib.LogAudit(r.Context(), constants.AuditImpersonatedRequest, map[string]interface{}{
+								"userId":             userSession.UserId,
+								"impersonatorUserId": userSession.ImpersonatorUserId.Int64,
+								"method":             r.Method,
+								"path":               r.URL.Path,
+							})
+						}
+					}
 				}
 			}
 
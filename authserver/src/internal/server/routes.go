@@ -8,9 +8,13 @@ import (
 	"github.com/leodip/goiabada/internal/constants"
 	"github.com/leodip/goiabada/internal/core"
 	core_authorize "github.com/leodip/goiabada/internal/core/authorize"
+	core_federation "github.com/leodip/goiabada/internal/core/federation"
 	core_senders "github.com/leodip/goiabada/internal/core/senders"
 	core_token "github.com/leodip/goiabada/internal/core/token"
+	core_users "github.com/leodip/goiabada/internal/core/users"
 	core_validators "github.com/leodip/goiabada/internal/core/validators"
+	core_webauthn "github.com/leodip/goiabada/internal/core/webauthn"
+	core_webhooks "github.com/leodip/goiabada/internal/core/webhooks"
 	"github.com/leodip/goiabada/internal/lib"
 )
 
@@ -19,30 +23,49 @@ func (s *Server) initRoutes() {
 	authorizeValidator := core_validators.NewAuthorizeValidator(s.database)
 	tokenParser := core_token.NewTokenParser(s.database)
 	permissionChecker := core.NewPermissionChecker(s.database)
-	tokenValidator := core_validators.NewTokenValidator(s.database, tokenParser, permissionChecker)
+	stepUpResolver := core.NewStepUpResolver(s.database)
+	tokenValidator := core_validators.NewTokenValidator(s.database, tokenParser, permissionChecker, stepUpResolver)
 	profileValidator := core_validators.NewProfileValidator(s.database)
 	emailValidator := core_validators.NewEmailValidator(s.database)
 	addressValidator := core_validators.NewAddressValidator(s.database)
 	phoneValidator := core_validators.NewPhoneValidator(s.database)
-	passwordValidator := core_validators.NewPasswordValidator()
+	breachedPasswordChecker := core.NewBreachedPasswordChecker(s.database)
+	passwordValidator := core_validators.NewPasswordValidator(s.database, breachedPasswordChecker)
+	userPasswordManager := core.NewUserPasswordManager(s.database)
 	identifierValidator := core_validators.NewIdentifierValidator(s.database)
 	inputSanitizer := core.NewInputSanitizer()
 
 	codeIssuer := core_authorize.NewCodeIssuer(s.database)
 	loginManager := core_authorize.NewLoginManager(codeIssuer)
 	otpSecretGenerator := core.NewOTPSecretGenerator()
+	otpRecoveryCodeManager := core.NewOTPRecoveryCodeManager()
+	loginLockoutManager := core.NewLoginLockoutManager(s.database)
+	riskEngine := core.NewRiskEngine(s.database)
 	tokenIssuer := core_token.NewTokenIssuer(s.database, tokenParser)
 	emailSender := core_senders.NewEmailSender(s.database)
+	// the security notifications are sent from helpers shared by many handlers
+	s.emailSender = emailSender
 	smsSender := core_senders.NewSMSSender(s.database)
 	userCreator := core.NewUserCreator(s.database)
+	oidcClient := core_federation.NewOIDCClient()
+	webhookPublisher := core_webhooks.NewPublisher(s.database)
+	federatedUserResolver := core_federation.NewFederatedUserResolver(s.database, userCreator, webhookPublisher)
+	samlServiceProvider := core_federation.NewSAMLServiceProvider()
+	ldapAuthenticator := core_federation.NewLDAPAuthenticator()
+	passkeyManager := core_webauthn.NewPasskeyManager(s.database)
+	keyRotator := core.NewKeyRotator(s.database)
+	userImporter := core_users.NewImporter(s.database, userCreator, profileValidator, emailValidator, phoneValidator,
+		addressValidator, identifierValidator, inputSanitizer)
+	userExporter := core_users.NewExporter(s.database)
 
 	s.router.NotFound(s.handleNotFoundGet())
 	s.router.Get("/", s.handleIndexGet())
 	s.router.Get("/unauthorized", s.handleUnauthorizedGet())
+	s.router.Post("/impersonation/stop", s.handleImpersonationStopPost())
 	s.router.Get("/forgot-password", s.handleForgotPasswordGet())
 	s.router.Post("/forgot-password", s.handleForgotPasswordPost(emailSender))
 	s.router.Get("/reset-password", s.handleResetPasswordGet())
-	s.router.Post("/reset-password", s.handleResetPasswordPost(passwordValidator))
+	s.router.Post("/reset-password", s.handleResetPasswordPost(passwordValidator, userPasswordManager))
 	s.router.Get("/.well-known/openid-configuration", s.handleWellKnownOIDCConfigGet())
 	s.router.Get("/certs", s.handleCertsGet())
 	s.router.With(s.jwtAuthorizationHeaderToContext).Get("/userinfo", s.handleUserInfoGetPost())
@@ -50,12 +73,118 @@ func (s *Server) initRoutes() {
 	s.router.Get("/health", s.handleHealthCheckGet())
 	s.router.Get("/test", s.handleRequestTestGet())
 
+	s.router.With(s.jwtAuthorizationHeaderToContext).With(s.requiresScimScope).Route("/scim/v2", func(r chi.Router) {
+		r.Get("/ServiceProviderConfig", s.handleScimServiceProviderConfigGet())
+		r.Get("/ResourceTypes", s.handleScimResourceTypesGet())
+		r.Get("/ResourceTypes/{resourceTypeId}", s.handleScimResourceTypeGet())
+		r.Get("/Schemas", s.handleScimSchemasGet())
+		r.Get("/Schemas/{schemaId}", s.handleScimSchemaGet())
+		r.Get("/Users", s.handleScimUsersGet())
+		r.Post("/Users", s.handleScimUsersPost(userCreator, profileValidator, emailValidator, addressValidator, passwordValidator, userPasswordManager, inputSanitizer))
+		r.Get("/Users/{userId}", s.handleScimUserGet())
+		r.Put("/Users/{userId}", s.handleScimUserPut(profileValidator, emailValidator, addressValidator, passwordValidator, userPasswordManager, inputSanitizer))
+		r.Patch("/Users/{userId}", s.handleScimUserPatch(profileValidator, emailValidator, addressValidator, passwordValidator, userPasswordManager, inputSanitizer))
+		r.Delete("/Users/{userId}", s.handleScimUserDelete())
+		r.Get("/Groups", s.handleScimGroupsGet())
+		r.Post("/Groups", s.handleScimGroupsPost(identifierValidator, inputSanitizer))
+		r.Get("/Groups/{groupId}", s.handleScimGroupGet())
+		r.Put("/Groups/{groupId}", s.handleScimGroupPut(identifierValidator, inputSanitizer))
+		r.Patch("/Groups/{groupId}", s.handleScimGroupPatch(identifierValidator, inputSanitizer))
+		r.Delete("/Groups/{groupId}", s.handleScimGroupDelete())
+	})
+
+	s.router.With(s.jwtAuthorizationHeaderToContext).Route("/api/v1", func(r chi.Router) {
+		r.Get("/openapi.json", s.handleApiOpenAPIGet())
+
+		usersRead := s.requiresApiScope(constants.AdminApiUsersReadPermissionIdentifier)
+		usersWrite := s.requiresApiScope(constants.AdminApiUsersWritePermissionIdentifier)
+		r.With(usersRead).Get("/users", s.handleApiUsersGet())
+		r.With(usersWrite).Post("/users", s.handleApiUsersPost(userCreator, profileValidator, emailValidator, phoneValidator, addressValidator, passwordValidator, userPasswordManager, inputSanitizer))
+		r.With(usersRead).Get("/users/{userId}", s.handleApiUserGet())
+		r.With(usersWrite).Put("/users/{userId}", s.handleApiUserPut(profileValidator, emailValidator, phoneValidator, addressValidator, passwordValidator, userPasswordManager, inputSanitizer))
+		r.With(usersWrite).Delete("/users/{userId}", s.handleApiUserDelete())
+		r.With(usersRead).Get("/users/{userId}/groups", s.handleApiUserGroupsGet())
+		r.With(usersWrite).Put("/users/{userId}/groups", s.handleApiUserGroupsPut())
+		r.With(usersRead).Get("/users/{userId}/permissions", s.handleApiUserPermissionsGet())
+		r.With(usersWrite).Put("/users/{userId}/permissions", s.handleApiUserPermissionsPut())
+		r.With(usersRead).Get("/users/{userId}/attributes", s.handleApiUserAttributesGet())
+		r.With(usersWrite).Post("/users/{userId}/attributes", s.handleApiUserAttributesPost(identifierValidator, inputSanitizer))
+		r.With(usersWrite).Put("/users/{userId}/attributes/{attributeId}", s.handleApiUserAttributePut(identifierValidator, inputSanitizer))
+		r.With(usersWrite).Delete("/users/{userId}/attributes/{attributeId}", s.handleApiUserAttributeDelete())
+		r.With(usersRead).Get("/users/{userId}/sessions", s.handleApiUserSessionsGet())
+		r.With(usersWrite).Delete("/users/{userId}/sessions/{sessionId}", s.handleApiUserSessionDelete())
+		r.With(usersRead).Get("/users/{userId}/consents", s.handleApiUserConsentsGet())
+		r.With(usersWrite).Delete("/users/{userId}/consents/{consentId}", s.handleApiUserConsentDelete())
+
+		groupsRead := s.requiresApiScope(constants.AdminApiGroupsReadPermissionIdentifier)
+		groupsWrite := s.requiresApiScope(constants.AdminApiGroupsWritePermissionIdentifier)
+		r.With(groupsRead).Get("/groups", s.handleApiGroupsGet())
+		r.With(groupsWrite).Post("/groups", s.handleApiGroupsPost(identifierValidator, inputSanitizer))
+		r.With(groupsRead).Get("/groups/{groupId}", s.handleApiGroupGet())
+		r.With(groupsWrite).Put("/groups/{groupId}", s.handleApiGroupPut(identifierValidator, inputSanitizer))
+		r.With(groupsWrite).Delete("/groups/{groupId}", s.handleApiGroupDelete())
+		r.With(groupsRead).Get("/groups/{groupId}/members", s.handleApiGroupMembersGet())
+		r.With(groupsWrite).Post("/groups/{groupId}/members", s.handleApiGroupMembersPost())
+		r.With(groupsWrite).Delete("/groups/{groupId}/members/{userId}", s.handleApiGroupMemberDelete())
+		r.With(groupsRead).Get("/groups/{groupId}/permissions", s.handleApiGroupPermissionsGet())
+		r.With(groupsWrite).Put("/groups/{groupId}/permissions", s.handleApiGroupPermissionsPut())
+		r.With(groupsRead).Get("/groups/{groupId}/attributes", s.handleApiGroupAttributesGet())
+		r.With(groupsWrite).Post("/groups/{groupId}/attributes", s.handleApiGroupAttributesPost(identifierValidator, inputSanitizer))
+		r.With(groupsWrite).Put("/groups/{groupId}/attributes/{attributeId}", s.handleApiGroupAttributePut(identifierValidator, inputSanitizer))
+		r.With(groupsWrite).Delete("/groups/{groupId}/attributes/{attributeId}", s.handleApiGroupAttributeDelete())
+
+		clientsRead := s.requiresApiScope(constants.AdminApiClientsReadPermissionIdentifier)
+		clientsWrite := s.requiresApiScope(constants.AdminApiClientsWritePermissionIdentifier)
+		r.With(clientsRead).Get("/clients", s.handleApiClientsGet())
+		r.With(clientsWrite).Post("/clients", s.handleApiClientsPost(identifierValidator, inputSanitizer))
+		r.With(clientsRead).Get("/clients/{clientId}", s.handleApiClientGet())
+		r.With(clientsWrite).Put("/clients/{clientId}", s.handleApiClientPut(identifierValidator, inputSanitizer))
+		r.With(clientsWrite).Delete("/clients/{clientId}", s.handleApiClientDelete())
+		r.With(clientsWrite).Post("/clients/{clientId}/secret", s.handleApiClientSecretPost())
+		r.With(clientsRead).Get("/clients/{clientId}/permissions", s.handleApiClientPermissionsGet())
+		r.With(clientsWrite).Put("/clients/{clientId}/permissions", s.handleApiClientPermissionsPut())
+
+		resourcesRead := s.requiresApiScope(constants.AdminApiResourcesReadPermissionIdentifier)
+		resourcesWrite := s.requiresApiScope(constants.AdminApiResourcesWritePermissionIdentifier)
+		r.With(resourcesRead).Get("/resources", s.handleApiResourcesGet())
+		r.With(resourcesWrite).Post("/resources", s.handleApiResourcesPost(identifierValidator, inputSanitizer))
+		r.With(resourcesRead).Get("/resources/{resourceId}", s.handleApiResourceGet())
+		r.With(resourcesWrite).Put("/resources/{resourceId}", s.handleApiResourcePut(identifierValidator, inputSanitizer))
+		r.With(resourcesWrite).Delete("/resources/{resourceId}", s.handleApiResourceDelete())
+		r.With(resourcesRead).Get("/resources/{resourceId}/permissions", s.handleApiResourcePermissionsGet())
+		r.With(resourcesWrite).Post("/resources/{resourceId}/permissions", s.handleApiResourcePermissionsPost(identifierValidator, inputSanitizer))
+		r.With(resourcesWrite).Put("/resources/{resourceId}/permissions/{permissionId}", s.handleApiResourcePermissionPut(identifierValidator, inputSanitizer))
+		r.With(resourcesWrite).Delete("/resources/{resourceId}/permissions/{permissionId}", s.handleApiResourcePermissionDelete())
+
+		r.With(s.requiresApiScope(constants.AdminApiSettingsReadPermissionIdentifier)).Get("/settings", s.handleApiSettingsGet())
+		r.With(s.requiresApiScope(constants.AdminApiSettingsWritePermissionIdentifier)).Put("/settings", s.handleApiSettingsPut(inputSanitizer))
+	})
+
 	s.router.With(s.jwtSessionToContext).Route("/auth", func(r chi.Router) {
-		r.Get("/authorize", s.handleAuthorizeGet(authorizeValidator, loginManager))
+		r.Get("/authorize", s.handleAuthorizeGet(authorizeValidator, loginManager, stepUpResolver))
 		r.Get("/pwd", s.handleAuthPwdGet())
-		r.Post("/pwd", s.handleAuthPwdPost(loginManager))
+		r.Post("/pwd", s.handleAuthPwdPost(loginManager, loginLockoutManager, riskEngine, ldapAuthenticator, federatedUserResolver, emailSender, breachedPasswordChecker))
+		r.Get("/federated/{identityProviderIdentifier}", s.handleAuthFederatedGet(oidcClient, samlServiceProvider))
+		r.Get("/federated/{identityProviderIdentifier}/callback", s.handleAuthFederatedCallbackGet(oidcClient, federatedUserResolver, loginManager))
+		r.Post("/federated/{identityProviderIdentifier}/callback", s.handleAuthFederatedCallbackPost(samlServiceProvider, federatedUserResolver, loginManager))
+		r.Get("/federated/{identityProviderIdentifier}/saml/metadata", s.handleAuthFederatedSAMLMetadataGet(samlServiceProvider))
+		r.Post("/federated/{identityProviderIdentifier}/saml/acs", s.handleAuthFederatedSAMLAcsPost())
+		r.Get("/email", s.handleAuthEmailGet())
+		r.Post("/email", s.handleAuthEmailPost(emailSender))
+		r.Post("/email/code", s.handleAuthEmailCodePost(loginManager, loginLockoutManager, emailSender))
+		r.Get("/email/verify", s.handleAuthEmailVerifyGet(loginManager, loginLockoutManager, emailSender))
 		r.Get("/otp", s.handleAuthOtpGet(otpSecretGenerator))
-		r.Post("/otp", s.handleAuthOtpPost())
+		r.Post("/otp", s.handleAuthOtpPost(otpRecoveryCodeManager, loginLockoutManager, emailSender))
+		r.Get("/change-password", s.handleAuthChangePasswordGet())
+		r.Post("/change-password", s.handleAuthChangePasswordPost(passwordValidator, userPasswordManager))
+		r.Get("/otp/sms", s.handleAuthOtpSMSGet())
+		r.Post("/otp/sms", s.handleAuthOtpSMSPost(loginLockoutManager, emailSender))
+		r.Post("/otp/sms/send", s.handleAuthOtpSMSSendPost(smsSender))
+		r.Get("/passkey", s.handleAuthPasskeyGet())
+		r.Post("/passkey/begin", s.handleAuthPasskeyBeginPost(passkeyManager))
+		r.Post("/passkey/finish", s.handleAuthPasskeyFinishPost(passkeyManager))
+		r.Post("/passkey/login/begin", s.handleAuthPasskeyLoginBeginPost(passkeyManager))
+		r.Post("/passkey/login/finish", s.handleAuthPasskeyLoginFinishPost(passkeyManager))
 		r.Get("/consent", s.handleConsentGet(codeIssuer, permissionChecker))
 		r.Post("/consent", s.handleConsentPost(codeIssuer))
 		r.Post("/token", s.handleTokenPost(tokenIssuer, tokenValidator))
@@ -82,13 +211,21 @@ func (s *Server) initRoutes() {
 		r.With(s.jwtSessionToContext).With(s.requiresAccountScope).Get("/phone-verify", s.handleAccountPhoneVerifyGet())
 		r.With(s.jwtSessionToContext).With(s.requiresAccountScope).Post("/phone-verify", s.handleAccountPhoneVerifyPost())
 		r.With(s.jwtSessionToContext).With(s.requiresAccountScope).Get("/change-password", s.handleAccountChangePasswordGet())
-		r.With(s.jwtSessionToContext).With(s.requiresAccountScope).Post("/change-password", s.handleAccountChangePasswordPost(passwordValidator))
+		r.With(s.jwtSessionToContext).With(s.requiresAccountScope).Post("/change-password", s.handleAccountChangePasswordPost(passwordValidator, userPasswordManager))
 		r.With(s.jwtSessionToContext).With(s.requiresAccountScope).Get("/otp", s.handleAccountOtpGet(otpSecretGenerator))
-		r.With(s.jwtSessionToContext).With(s.requiresAccountScope).Post("/otp", s.handleAccountOtpPost())
+		r.With(s.jwtSessionToContext).With(s.requiresAccountScope).Post("/otp", s.handleAccountOtpPost(otpRecoveryCodeManager))
+		r.With(s.jwtSessionToContext).With(s.requiresAccountScope).Post("/otp/recovery-codes", s.handleAccountOtpRecoveryCodesPost(otpRecoveryCodeManager))
+		r.With(s.jwtSessionToContext).With(s.requiresAccountScope).Get("/passkeys", s.handleAccountPasskeysGet())
+		r.With(s.jwtSessionToContext).With(s.requiresAccountScope).Post("/passkeys/register/begin", s.handleAccountPasskeysRegisterBeginPost(passkeyManager))
+		r.With(s.jwtSessionToContext).With(s.requiresAccountScope).Post("/passkeys/register/finish", s.handleAccountPasskeysRegisterFinishPost(passkeyManager))
+		r.With(s.jwtSessionToContext).With(s.requiresAccountScope).Post("/passkeys/delete", s.handleAccountPasskeysDeletePost())
+		r.With(s.jwtSessionToContext).With(s.requiresAccountScope).Get("/notifications", s.handleAccountNotificationsGet())
+		r.With(s.jwtSessionToContext).With(s.requiresAccountScope).Post("/notifications", s.handleAccountNotificationsPost())
 		r.With(s.jwtSessionToContext).With(s.requiresAccountScope).Get("/manage-consents", s.handleAccountManageConsentsGet())
 		r.With(s.jwtSessionToContext).With(s.requiresAccountScope).Post("/manage-consents", s.handleAccountManageConsentsRevokePost())
 		r.With(s.jwtSessionToContext).With(s.requiresAccountScope).Get("/sessions", s.handleAccountSessionsGet())
 		r.With(s.jwtSessionToContext).With(s.requiresAccountScope).Post("/sessions", s.handleAccountSessionsEndSesssionPost())
+		r.With(s.jwtSessionToContext).With(s.requiresAccountScope).Post("/sessions/trusted-devices", s.handleAccountTrustedDevicesRevokePost())
 		r.Get("/register", s.handleAccountRegisterGet())
 		r.Post("/register", s.handleAccountRegisterPost(userCreator, emailValidator, passwordValidator, emailSender))
 		r.Get("/activate", s.handleAccountActivateGet(userCreator))
@@ -161,7 +298,26 @@ func (s *Server) initRoutes() {
 		r.Get("/groups/new", s.handleAdminGroupNewGet())
 		r.Post("/groups/new", s.handleAdminGroupNewPost(identifierValidator, inputSanitizer))
 
+		r.Get("/audit", s.handleAdminAuditGet())
+		r.Get("/audit/export", s.handleAdminAuditExportGet())
+
+		r.Get("/webhooks", s.handleAdminWebhooksGet())
+		r.Get("/webhooks/{webhookId}/settings", s.handleAdminWebhookSettingsGet())
+		r.Post("/webhooks/{webhookId}/settings", s.handleAdminWebhookSettingsPost(inputSanitizer))
+		r.Get("/webhooks/{webhookId}/deliveries", s.handleAdminWebhookDeliveriesGet())
+		r.Post("/webhooks/{webhookId}/deliveries/{deliveryId}/redeliver", s.handleAdminWebhookDeliveryRedeliverPost(webhookPublisher))
+		r.Get("/webhooks/{webhookId}/delete", s.handleAdminWebhookDeleteGet())
+		r.Post("/webhooks/{webhookId}/delete", s.handleAdminWebhookDeletePost())
+		r.Get("/webhooks/new", s.handleAdminWebhookNewGet())
+		r.Post("/webhooks/new", s.handleAdminWebhookNewPost(inputSanitizer))
+
 		r.Get("/users", s.handleAdminUsersGet())
+		r.Get("/users/locked", s.handleAdminUsersLockedGet())
+		r.Post("/users/locked", s.handleAdminUsersLockedPost(loginLockoutManager))
+		r.Get("/users/import", s.handleAdminUsersImportGet())
+		r.Post("/users/import", s.handleAdminUsersImportPost(userImporter, emailSender))
+		r.Get("/users/export", s.handleAdminUsersExportGet())
+		r.Post("/users/export", s.handleAdminUsersExportPost(userExporter))
 		r.Get("/users/{userId}/details", s.handleAdminUserDetailsGet())
 		r.Post("/users/{userId}/details", s.handleAdminUserDetailsPost())
 		r.Get("/users/{userId}/profile", s.handleAdminUserProfileGet())
@@ -173,11 +329,12 @@ func (s *Server) initRoutes() {
 		r.Get("/users/{userId}/address", s.handleAdminUserAddressGet())
 		r.Post("/users/{userId}/address", s.handleAdminUserAddressPost(addressValidator, inputSanitizer))
 		r.Get("/users/{userId}/authentication", s.handleAdminUserAuthenticationGet())
-		r.Post("/users/{userId}/authentication", s.handleAdminUserAuthenticationPost(passwordValidator))
+		r.Post("/users/{userId}/authentication", s.handleAdminUserAuthenticationPost(passwordValidator, userPasswordManager))
 		r.Get("/users/{userId}/consents", s.handleAdminUserConsentsGet())
 		r.Post("/users/{userId}/consents", s.handleAdminUserConsentsPost())
 		r.Get("/users/{userId}/sessions", s.handleAdminUserSessionsGet())
 		r.Post("/users/{userId}/sessions", s.handleAdminUserSessionsPost())
+		r.Post("/users/{userId}/sessions/trusted-devices", s.handleAdminUserTrustedDevicesRevokePost())
 		r.Get("/users/{userId}/attributes", s.handleAdminUserAttributesGet())
 		r.Get("/users/{userId}/attributes/add", s.handleAdminUserAttributesAddGet())
 		r.Post("/users/{userId}/attributes/add", s.handleAdminUserAttributesAddPost(identifierValidator, inputSanitizer))
@@ -190,19 +347,36 @@ func (s *Server) initRoutes() {
 		r.Post("/users/{userId}/groups", s.handleAdminUserGroupsPost())
 		r.Get("/users/{userId}/delete", s.handleAdminUserDeleteGet())
 		r.Post("/users/{userId}/delete", s.handleAdminUserDeletePost())
+		r.Post("/users/{userId}/impersonate", s.handleAdminUserImpersonatePost(permissionChecker))
 		r.Get("/users/new", s.handleAdminUserNewGet())
 		r.Post("/users/new", s.handleAdminUserNewPost(userCreator, profileValidator, emailValidator, passwordValidator, inputSanitizer, emailSender))
 
+		r.Get("/identity-providers", s.handleAdminIdentityProvidersGet())
+		r.Get("/identity-providers/{identityProviderId}/settings", s.handleAdminIdentityProviderSettingsGet())
+		r.Post("/identity-providers/{identityProviderId}/settings", s.handleAdminIdentityProviderSettingsPost(identifierValidator, inputSanitizer, samlServiceProvider, permissionChecker))
+		r.Get("/identity-providers/{identityProviderId}/delete", s.handleAdminIdentityProviderDeleteGet())
+		r.Post("/identity-providers/{identityProviderId}/delete", s.handleAdminIdentityProviderDeletePost())
+		r.Get("/identity-providers/new", s.handleAdminIdentityProviderNewGet())
+		r.Post("/identity-providers/new", s.handleAdminIdentityProviderNewPost(identifierValidator, inputSanitizer, samlServiceProvider))
+
 		r.Get("/settings/general", s.handleAdminSettingsGeneralGet())
 		r.Post("/settings/general", s.handleAdminSettingsGeneralPost(inputSanitizer))
 		r.Get("/settings/ui-theme", s.handleAdminSettingsUIThemeGet())
 		r.Post("/settings/ui-theme", s.handleAdminSettingsUIThemePost())
 		r.Get("/settings/sessions", s.handleAdminSettingsSessionsGet())
 		r.Post("/settings/sessions", s.handleAdminSettingsSessionsPost())
+		r.Get("/settings/login-security", s.handleAdminSettingsLoginSecurityGet())
+		r.Post("/settings/login-security", s.handleAdminSettingsLoginSecurityPost())
+		r.Get("/settings/password-policy", s.handleAdminSettingsPasswordPolicyGet())
+		r.Post("/settings/password-policy", s.handleAdminSettingsPasswordPolicyPost())
+		r.Get("/settings/breached-passwords", s.handleAdminSettingsBreachedPasswordsGet(breachedPasswordChecker))
+		r.Post("/settings/breached-passwords", s.handleAdminSettingsBreachedPasswordsPost())
+		r.Post("/settings/breached-passwords/import", s.handleAdminSettingsBreachedPasswordsImportPost(breachedPasswordChecker))
+		r.Post("/settings/breached-passwords/clear", s.handleAdminSettingsBreachedPasswordsClearPost(breachedPasswordChecker))
 		r.Get("/settings/tokens", s.handleAdminSettingsTokensGet())
 		r.Post("/settings/tokens", s.handleAdminSettingsTokensPost())
 		r.Get("/settings/keys", s.handleAdminSettingsKeysGet())
-		r.Post("/settings/keys/rotate", s.handleAdminSettingsKeysRotatePost())
+		r.Post("/settings/keys/rotate", s.handleAdminSettingsKeysRotatePost(keyRotator))
 		r.Post("/settings/keys/revoke", s.handleAdminSettingsKeysRevokePost())
 		r.Get("/settings/email", s.handleAdminSettingsEmailGet())
 		r.Post("/settings/email", s.handleAdminSettingsEmailPost(emailValidator, inputSanitizer))
@@ -221,6 +395,16 @@ func (s *Server) jwtAuthorizationHeaderToContext(handler http.Handler) http.Hand
 	return MiddlewareJwtAuthorizationHeaderToContext(handler, s.sessionStore, s.tokenParser)
 }
 
+func (s *Server) requiresScimScope(handler http.Handler) http.Handler {
+	return MiddlewareRequiresScimScope(handler)
+}
+
+func (s *Server) requiresApiScope(permissionIdentifier string) func(http.Handler) http.Handler {
+	return func(handler http.Handler) http.Handler {
+		return MiddlewareRequiresApiScope(handler, permissionIdentifier)
+	}
+}
+
 func (s *Server) requiresAdminScope(handler http.Handler) http.Handler {
 	return MiddlewareRequiresScope(handler, s, constants.SystemClientIdentifier,
 		[]string{fmt.Sprintf("%v:%v", constants.AuthServerResourceIdentifier, constants.AdminWebsitePermissionIdentifier)})
@@ -0,0 +1,150 @@
+package server
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/leodip/goiabada/internal/common"
+	"github.com/leodip/goiabada/internal/constants"
+	core_senders "github.com/leodip/goiabada/internal/core/senders"
+	"github.com/leodip/goiabada/internal/entities"
+	"github.com/leodip/goiabada/internal/lib"
+)
+
+type securityNotification struct {
+	subject    string
+	message    string
+	isDisabled func(user *entities.User) bool
+}
+
+// the audited events the users are notified about
+var securityNotifications = map[string]securityNotification{
+	constants.AuditChangedPassword: {
+		subject:    "Your password was changed",
+		message:    "The password of your account was changed.",
+		isDisabled: func(user *entities.User) bool { return user.DisablePasswordChangeNotification },
+	},
+	constants.AuditUpdatedUserEmail: {
+		subject:    "Your email address was changed",
+		message:    "The email address of your account was changed to %v.",
+		isDisabled: func(user *entities.User) bool { return user.DisableEmailChangeNotification },
+	},
+	constants.AuditEnrolledOTP: {
+		subject:    "Two-factor authentication was enabled",
+		message:    "Two-factor authentication (OTP) was enabled on your account.",
+		isDisabled: func(user *entities.User) bool { return user.DisableOTPChangeNotification },
+	},
+	constants.AuditDisabledOTP: {
+		subject:    "Two-factor authentication was disabled",
+		message:    "Two-factor authentication (OTP) was disabled on your account.",
+		isDisabled: func(user *entities.User) bool { return user.DisableOTPChangeNotification },
+	},
+	constants.AuditStartedNewUserSesson: {
+		subject:    "New sign-in to your account",
+		message:    "Your account was signed in from a new device.",
+		isDisabled: func(user *entities.User) bool { return user.DisableNewDeviceNotification },
+	},
+}
+
+// sendSecurityNotification emails the user about an audited event of the account, unless the user turned the
+// notification off. Failures are logged and don't interrupt the request.
+func (s *Server) sendSecurityNotification(r *http.Request, user *entities.User, auditEvent string) {
+	notification, ok := securityNotifications[auditEvent]
+	if !ok || notification.isDisabled(user) {
+		return
+	}
+	s.deliverSecurityNotification(r, user, auditEvent, user.Email)
+}
+
+// notifyEmailChange emails both the old and the new address of the user about an email change. The notice to
+// the old address is sent even when the user turned the notification off, as it's the only warning the owner
+// gets when the account is taken over.
+func (s *Server) notifyEmailChange(r *http.Request, user *entities.User, oldEmail string) {
+	s.deliverSecurityNotification(r, user, constants.AuditUpdatedUserEmail, oldEmail)
+	if !user.DisableEmailChangeNotification {
+		s.deliverSecurityNotification(r, user, constants.AuditUpdatedUserEmail, user.Email)
+	}
+}
+
+func (s *Server) deliverSecurityNotification(r *http.Request, user *entities.User, auditEvent string, to string) {
+
+	settings := r.Context().Value(common.ContextKeySettings).(*entities.Settings)
+	if !settings.SMTPEnabled || len(to) == 0 {
+		return
+	}
+	notification := securityNotifications[auditEvent]
+
+	message := notification.message
+	if auditEvent == constants.AuditUpdatedUserEmail {
+		message = fmt.Sprintf(message, user.Email)
+	}
+
+	bind := map[string]interface{}{
+		"name":       user.GetFullName(),
+		"message":    message,
+		"ipAddress":  getClientIpAddress(r),
+		"deviceName": lib.GetDeviceName(r),
+		"deviceOS":   lib.GetDeviceOS(r),
+		"time":       time.Now().UTC().Format(time.RFC1123),
+	}
+	// the email doesn't depend on who is signed in, and the API and SCIM requests carry an access token in
+	// place of the JWT info of the session
+	emailRequest := r.WithContext(context.WithValue(r.Context(), common.ContextKeyJwtInfo, nil))
+	buf, err := s.renderTemplateToBuffer(emailRequest, "/layouts/email_layout.html", "/emails/email_security_notification.html", bind)
+	if err != nil {
+		slog.Error(fmt.Sprintf("unable to render the %v notification email: %+v", auditEvent, err))
+		return
+	}
+
+	input := &core_senders.SendEmailInput{
+		To:       to,
+		Subject:  notification.subject,
+		HtmlBody: buf.String(),
+	}
+	err = s.emailSender.SendEmail(r.Context(), input)
+	if err != nil {
+		slog.Error(fmt.Sprintf("unable to send the %v notification email to user %v: %+v", auditEvent, user.Id, err))
+	}
+}
+
+// registerKnownDevice remembers the device of the session, and returns true when the user has never signed
+// in from it before. The first device of the user is not considered new.
+func (s *Server) registerKnownDevice(userSession *entities.UserSession) (bool, error) {
+
+	fingerprintHash := sha256.Sum256([]byte(userSession.DeviceName + "|" + userSession.DeviceType + "|" + userSession.DeviceOS))
+	fingerprint := hex.EncodeToString(fingerprintHash[:])
+
+	knownDevices, err := s.database.GetUserKnownDevicesByUserId(nil, userSession.UserId)
+	if err != nil {
+		return false, err
+	}
+
+	for _, knownDevice := range knownDevices {
+		if knownDevice.Fingerprint == fingerprint {
+			knownDevice.LastSeenAt = userSession.Started
+			err = s.database.UpdateUserKnownDevice(nil, &knownDevice)
+			if err != nil {
+				return false, err
+			}
+			return false, nil
+		}
+	}
+
+	err = s.database.CreateUserKnownDevice(nil, &entities.UserKnownDevice{
+		UserId:      userSession.UserId,
+		Fingerprint: fingerprint,
+		DeviceName:  userSession.DeviceName,
+		DeviceType:  userSession.DeviceType,
+		DeviceOS:    userSession.DeviceOS,
+		LastSeenAt:  userSession.Started,
+	})
+	if err != nil {
+		return false, err
+	}
+	return len(knownDevices) > 0, nil
+}
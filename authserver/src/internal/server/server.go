@@ -17,6 +17,7 @@ import (
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	core_token "github.com/leodip/goiabada/internal/core/token"
+	core_webhooks "github.com/leodip/goiabada/internal/core/webhooks"
 	"github.com/leodip/goiabada/internal/data"
 	"github.com/leodip/goiabada/internal/entities"
 	"github.com/leodip/goiabada/internal/lib"
@@ -25,10 +26,12 @@ import (
 )
 
 type Server struct {
-	router       *chi.Mux
-	database     data.Database
-	sessionStore sessions.Store
-	tokenParser  *core_token.TokenParser
+	router           *chi.Mux
+	database         data.Database
+	sessionStore     sessions.Store
+	tokenParser      *core_token.TokenParser
+	emailSender      emailSender
+	webhookPublisher webhookPublisher
 
 	staticFS   fs.FS
 	templateFS fs.FS
@@ -37,10 +40,11 @@ type Server struct {
 func NewServer(router *chi.Mux, database data.Database, sessionStore sessions.Store) *Server {
 
 	s := Server{
-		router:       router,
-		database:     database,
-		sessionStore: sessionStore,
-		tokenParser:  core_token.NewTokenParser(database),
+		router:           router,
+		database:         database,
+		sessionStore:     sessionStore,
+		tokenParser:      core_token.NewTokenParser(database),
+		webhookPublisher: core_webhooks.NewPublisher(database),
 	}
 
 	if envVar := viper.GetString("StaticDir"); len(envVar) == 0 {
@@ -132,6 +136,9 @@ func (s *Server) initMiddleware(settings *entities.Settings) {
 		slog.Info("not adding real ip middleware")
 	}
 
+	// IP address
+	s.router.Use(MiddlewareIpAddress())
+
 	// Recoverer
 	s.router.Use(middleware.Recoverer)
 
@@ -0,0 +1,37 @@
+package server
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/leodip/goiabada/internal/customerrors"
+	"github.com/leodip/goiabada/internal/enums"
+)
+
+// parseStepUpRequirements parses the minimum ACR level and the maximum authentication age of a resource
+// or permission, as posted by the admin. Empty values mean there's no requirement.
+func parseStepUpRequirements(minAcrLevel string, maxAuthAgeInSeconds string) (enums.AcrLevel, int, error) {
+
+	minAcrLevelEnum := enums.AcrLevel("")
+	if len(minAcrLevel) > 0 {
+		acrLevel, err := enums.AcrLevelFromString(minAcrLevel)
+		if err != nil {
+			return "", 0, customerrors.NewValidationError("", "Invalid minimum ACR level.")
+		}
+		minAcrLevelEnum = acrLevel
+	}
+
+	const maxAuthAgeLimitInSeconds = 31536000
+	maxAuthAgeInSecondsInt := 0
+	if len(strings.TrimSpace(maxAuthAgeInSeconds)) > 0 {
+		i, err := strconv.Atoi(strings.TrimSpace(maxAuthAgeInSeconds))
+		if err != nil || i < 0 || i > maxAuthAgeLimitInSeconds {
+			return "", 0, customerrors.NewValidationError("",
+				fmt.Sprintf("The maximum authentication age must be between 0 and %v seconds.", maxAuthAgeLimitInSeconds))
+		}
+		maxAuthAgeInSecondsInt = i
+	}
+
+	return minAcrLevelEnum, maxAuthAgeInSecondsInt, nil
+}
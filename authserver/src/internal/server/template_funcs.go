@@ -105,7 +105,7 @@ var templateFuncMap = template.FuncMap{
 		return false
 	},
 	"isAdminUserPage": func(urlPath string) bool {
-		if urlPath == "/admin/users" {
+		if urlPath == "/admin/users" || urlPath == "/admin/users/locked" {
 			return true
 		}
 
@@ -128,6 +128,35 @@ var templateFuncMap = template.FuncMap{
 		}
 		return false
 	},
+	"isAdminIdentityProviderPage": func(urlPath string) bool {
+		if urlPath == "/admin/identity-providers" {
+			return true
+		}
+
+		if strings.HasPrefix(urlPath, "/admin/identity-providers/") {
+			if strings.HasSuffix(urlPath, "/settings") ||
+				strings.HasSuffix(urlPath, "/new") ||
+				strings.HasSuffix(urlPath, "/delete") {
+				return true
+			}
+		}
+		return false
+	},
+	"isAdminWebhookPage": func(urlPath string) bool {
+		if urlPath == "/admin/webhooks" {
+			return true
+		}
+
+		if strings.HasPrefix(urlPath, "/admin/webhooks/") {
+			if strings.HasSuffix(urlPath, "/settings") ||
+				strings.HasSuffix(urlPath, "/deliveries") ||
+				strings.HasSuffix(urlPath, "/new") ||
+				strings.HasSuffix(urlPath, "/delete") {
+				return true
+			}
+		}
+		return false
+	},
 	"isAdminSettingsEmailPage": func(urlPath string) bool {
 		if urlPath == "/admin/settings" {
 			return true
@@ -0,0 +1,137 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/securecookie"
+	"github.com/leodip/goiabada/internal/common"
+	"github.com/leodip/goiabada/internal/constants"
+	"github.com/leodip/goiabada/internal/entities"
+	"github.com/leodip/goiabada/internal/lib"
+	"github.com/pkg/errors"
+)
+
+type trustedDeviceCookie struct {
+	UserId     int64
+	Identifier string
+}
+
+func trustedDeviceCookieCodec(settings *entities.Settings) *securecookie.SecureCookie {
+	codec := securecookie.New(settings.SessionAuthenticationKey, settings.SessionEncryptionKey)
+	codec.MaxAge(settings.TrustedDeviceLifetimeInDays * 86400)
+	return codec
+}
+
+// trustDevice remembers the browser, so the optional second factor is not requested again until
+// the trusted device expires or is revoked.
+func (s *Server) trustDevice(w http.ResponseWriter, r *http.Request, user *entities.User) error {
+
+	settings := r.Context().Value(common.ContextKeySettings).(*entities.Settings)
+	if settings.TrustedDeviceLifetimeInDays <= 0 {
+		return nil
+	}
+
+	identifier := lib.GenerateSecureRandomString(32)
+	identifierHash, err := lib.HashString(identifier)
+	if err != nil {
+		return err
+	}
+
+	trustedDevice := &entities.UserTrustedDevice{
+		UserId:         user.Id,
+		IdentifierHash: identifierHash,
+		IpAddress:      getClientIpAddress(r),
+		DeviceName:     lib.GetDeviceName(r),
+		DeviceType:     lib.GetDeviceType(r),
+		DeviceOS:       lib.GetDeviceOS(r),
+		ExpiresAt:      time.Now().UTC().AddDate(0, 0, settings.TrustedDeviceLifetimeInDays),
+	}
+	err = s.database.CreateUserTrustedDevice(nil, trustedDevice)
+	if err != nil {
+		return err
+	}
+
+	encoded, err := trustedDeviceCookieCodec(settings).Encode(common.TrustedDeviceCookieName,
+		trustedDeviceCookie{UserId: user.Id, Identifier: identifier})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     common.TrustedDeviceCookieName,
+		Value:    encoded,
+		Path:     "/",
+		Expires:  trustedDevice.ExpiresAt,
+		MaxAge:   settings.TrustedDeviceLifetimeInDays * 86400,
+		HttpOnly: true,
+		Secure:   lib.IsHttpsEnabled(),
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	lib.LogAudit(r.Context(), constants.AuditTrustedDevice, map[string]interface{}{
+		"userId":          user.Id,
+		"trustedDeviceId": trustedDevice.Id,
+	})
+	return nil
+}
+
+// getTrustedDevice returns the trusted device of the user from the cookie, or nil when the browser
+// is not trusted by the user (or the trust was revoked).
+func (s *Server) getTrustedDevice(r *http.Request, userId int64) (*entities.UserTrustedDevice, error) {
+
+	cookie, err := r.Cookie(common.TrustedDeviceCookieName)
+	if err != nil {
+		return nil, nil
+	}
+
+	settings := r.Context().Value(common.ContextKeySettings).(*entities.Settings)
+	if settings.TrustedDeviceLifetimeInDays <= 0 {
+		return nil, nil
+	}
+
+	var value trustedDeviceCookie
+	err = trustedDeviceCookieCodec(settings).Decode(common.TrustedDeviceCookieName, cookie.Value, &value)
+	if err != nil || value.UserId != userId {
+		// a tampered or expired cookie, or the browser was trusted by another user
+		return nil, nil
+	}
+
+	identifierHash, err := lib.HashString(value.Identifier)
+	if err != nil {
+		return nil, err
+	}
+
+	trustedDevice, err := s.database.GetUserTrustedDeviceByIdentifierHash(nil, identifierHash)
+	if err != nil {
+		return nil, err
+	}
+	if trustedDevice == nil || trustedDevice.UserId != userId || trustedDevice.IsExpired() {
+		return nil, nil
+	}
+	return trustedDevice, nil
+}
+
+func (s *Server) revokeTrustedDevice(r *http.Request, user *entities.User, trustedDeviceId int64) error {
+
+	trustedDevice, err := s.database.GetUserTrustedDeviceById(nil, trustedDeviceId)
+	if err != nil {
+		return err
+	}
+	if trustedDevice == nil || trustedDevice.UserId != user.Id {
+		return errors.WithStack(errors.New(fmt.Sprintf("trusted device %v not found for user %v", trustedDeviceId, user.Id)))
+	}
+
+	err = s.database.DeleteUserTrustedDevice(nil, trustedDevice.Id)
+	if err != nil {
+		return err
+	}
+
+	lib.LogAudit(r.Context(), constants.AuditRevokedTrustedDevice, map[string]interface{}{
+		"userId":          user.Id,
+		"trustedDeviceId": trustedDevice.Id,
+		"loggedInUser":    s.getLoggedInSubject(r),
+	})
+	return nil
+}
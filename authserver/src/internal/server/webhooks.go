@@ -0,0 +1,29 @@
+package server
+
+import (
+	"fmt"
+	"log/slog"
+
+	"github.com/leodip/goiabada/internal/constants"
+	"github.com/leodip/goiabada/internal/entities"
+)
+
+// publishUserEvent adds the event to the outbox of the webhooks subscribed to it. Failures are logged
+// and don't interrupt the request, as the change to the user was already made. The group is only
+// used in user.added_to_group.
+func (s *Server) publishUserEvent(eventType string, user *entities.User, group *entities.Group) {
+	err := s.webhookPublisher.PublishUserEvent(nil, eventType, user, group)
+	if err != nil {
+		slog.Error(fmt.Sprintf("unable to publish the webhook event %v of user %v: %+v", eventType, user.Subject, err))
+	}
+}
+
+// publishUserUpdatedEvent publishes user.disabled when the update disabled the user, and user.updated
+// otherwise.
+func (s *Server) publishUserUpdatedEvent(wasEnabled bool, user *entities.User) {
+	if wasEnabled && !user.Enabled {
+		s.publishUserEvent(constants.WebhookEventUserDisabled, user, nil)
+		return
+	}
+	s.publishUserEvent(constants.WebhookEventUserUpdated, user, nil)
+}
@@ -0,0 +1,102 @@
+// passkeys.js
+
+function passkeysSupported() {
+  return window.PublicKeyCredential !== undefined && navigator.credentials !== undefined;
+}
+
+function base64UrlToBuffer(value) {
+  const base64 = value.replace(/-/g, "+").replace(/_/g, "/");
+  const padded = base64 + "=".repeat((4 - (base64.length % 4)) % 4);
+  const binary = atob(padded);
+  const bytes = new Uint8Array(binary.length);
+  for (let i = 0; i < binary.length; i++) {
+    bytes[i] = binary.charCodeAt(i);
+  }
+  return bytes.buffer;
+}
+
+function bufferToBase64Url(buffer) {
+  const bytes = new Uint8Array(buffer);
+  let binary = "";
+  for (let i = 0; i < bytes.length; i++) {
+    binary += String.fromCharCode(bytes[i]);
+  }
+  return btoa(binary).replace(/\+/g, "-").replace(/\//g, "_").replace(/=+$/, "");
+}
+
+async function postPasskeyJson(url, body) {
+  const csrfInput = document.querySelector("input[name='gorilla.csrf.Token']");
+  const response = await fetch(url, {
+    method: "POST",
+    headers: {
+      "Content-Type": "application/json",
+      "X-CSRF-Token": csrfInput ? csrfInput.value : "",
+    },
+    body: JSON.stringify(body || {}),
+  });
+
+  const result = await response.json();
+  if (!response.ok) {
+    throw new Error(result.error_description || "An unexpected error has occurred.");
+  }
+  return result;
+}
+
+// createPasskey registers a new passkey with the options returned by the server,
+// and returns the response of the authenticator in the format expected by the server.
+async function createPasskey(options) {
+  const publicKey = options.publicKey;
+  publicKey.challenge = base64UrlToBuffer(publicKey.challenge);
+  publicKey.user.id = base64UrlToBuffer(publicKey.user.id);
+  if (publicKey.excludeCredentials) {
+    publicKey.excludeCredentials = publicKey.excludeCredentials.map((c) => ({ ...c, id: base64UrlToBuffer(c.id) }));
+  }
+
+  const credential = await navigator.credentials.create({ publicKey: publicKey });
+
+  return {
+    id: credential.id,
+    rawId: bufferToBase64Url(credential.rawId),
+    type: credential.type,
+    authenticatorAttachment: credential.authenticatorAttachment,
+    response: {
+      clientDataJSON: bufferToBase64Url(credential.response.clientDataJSON),
+      attestationObject: bufferToBase64Url(credential.response.attestationObject),
+      transports: credential.response.getTransports ? credential.response.getTransports() : [],
+    },
+  };
+}
+
+// getPasskey authenticates with a passkey using the options returned by the server,
+// and returns the response of the authenticator in the format expected by the server.
+async function getPasskey(options) {
+  const publicKey = options.publicKey;
+  publicKey.challenge = base64UrlToBuffer(publicKey.challenge);
+  if (publicKey.allowCredentials) {
+    publicKey.allowCredentials = publicKey.allowCredentials.map((c) => ({ ...c, id: base64UrlToBuffer(c.id) }));
+  }
+
+  const credential = await navigator.credentials.get({ publicKey: publicKey });
+
+  return {
+    id: credential.id,
+    rawId: bufferToBase64Url(credential.rawId),
+    type: credential.type,
+    authenticatorAttachment: credential.authenticatorAttachment,
+    response: {
+      clientDataJSON: bufferToBase64Url(credential.response.clientDataJSON),
+      authenticatorData: bufferToBase64Url(credential.response.authenticatorData),
+      signature: bufferToBase64Url(credential.response.signature),
+      userHandle: credential.response.userHandle ? bufferToBase64Url(credential.response.userHandle) : null,
+    },
+  };
+}
+
+// authenticateWithPasskey runs an authentication ceremony against the begin/finish endpoints,
+// and navigates to the url returned by the server.
+async function authenticateWithPasskey(beginUrl, finishUrl) {
+  const options = await postPasskeyJson(beginUrl);
+  const assertion = await getPasskey(options);
+  const result = await postPasskeyJson(finishUrl, assertion);
+  window.location.href = result.redirectUrl;
+}